@@ -0,0 +1,282 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errTaskNotFound = errors.New("task not found")
+
+// 接口查询元数据库超时时间
+const queryTimeout = 10 * time.Second
+
+// Server server 模式任务管理接口，与 pprof 以及 /metrics 共用 pprof-port 端口
+//
+//	GET    /api/v1/tasks                 任务列表
+//	POST   /api/v1/tasks                 提交任务定义
+//	GET    /api/v1/tasks/{id}            任务状态以及进度
+//	DELETE /api/v1/tasks/{id}            删除非运行状态任务
+//	POST   /api/v1/tasks/{id}/start      运行任务
+//	POST   /api/v1/tasks/{id}/stop       停止任务
+//	POST   /api/v1/tasks/{id}/pause      暂停任务，进程不退出
+//	POST   /api/v1/tasks/{id}/resume     恢复已暂停任务或者断点续传继续运行任务
+//	GET    /api/v1/tasks/{id}/tune       运行中任务当前调优参数
+//	POST   /api/v1/tasks/{id}/tune       调整运行中任务线程数、批次大小以及限速，未指定参数保持不变
+type Server struct {
+	*Manager
+
+	mu     sync.Mutex
+	metaDB *meta.Meta
+}
+
+func NewServer(ctx context.Context, cfg *config.Config) *Server {
+	return &Server{Manager: NewManager(ctx, cfg)}
+}
+
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/tasks", s.tasks)
+	mux.HandleFunc("/api/v1/tasks/", s.task)
+}
+
+func (s *Server) tasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tasks": s.List()})
+	case http.MethodPost:
+		var def TaskDefinition
+		if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		t, err := s.Create(def)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, t)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *Server) task(w http.ResponseWriter, r *http.Request) {
+	paths := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/tasks/"), "/"), "/")
+	taskID := paths[0]
+
+	if len(paths) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.status(w, r, taskID)
+		case http.MethodDelete:
+			if err := s.Delete(taskID); err != nil {
+				writeError(w, httpStatus(err), err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		}
+		return
+	}
+
+	if len(paths) == 2 && paths[1] == "tune" {
+		s.tune(w, r, taskID)
+		return
+	}
+	if len(paths) != 2 || r.Method != http.MethodPost {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	var (
+		t   Task
+		err error
+	)
+	switch paths[1] {
+	case "start":
+		t, err = s.Start(taskID)
+	case "stop":
+		t, err = s.Stop(taskID)
+	case "pause":
+		t, err = s.Pause(taskID)
+	case "resume":
+		t, err = s.Resume(taskID)
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) tune(w http.ResponseWriter, r *http.Request, taskID string) {
+	var (
+		params common.TuningParams
+		err    error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		params, err = s.TuningParams(taskID)
+	case http.MethodPost:
+		params, err = s.Tune(taskID, r.Body)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if err != nil {
+		writeError(w, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, params)
+}
+
+// TaskProgress 任务表级别进度，来源元数据库 wait_sync_meta 对应任务 ID 记录，仅 full/csv/all 模式存在
+type TaskProgress struct {
+	TableTotals      int   `json:"table_totals"`
+	TableSuccess     int   `json:"table_success"`
+	TableFailed      int   `json:"table_failed"`
+	TableRunning     int   `json:"table_running"`
+	TableWaiting     int   `json:"table_waiting"`
+	ChunkTotalNums   int64 `json:"chunk_total_nums"`
+	ChunkSuccessNums int64 `json:"chunk_success_nums"`
+	ChunkFailedNums  int64 `json:"chunk_failed_nums"`
+	Progress         int   `json:"progress"`
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request, taskID string) {
+	ctx, cancel := context.WithTimeout(r.Context(), queryTimeout)
+	defer cancel()
+	status, err := s.Status(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, errTaskNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// TaskStatus 任务状态以及进度
+type TaskStatus struct {
+	Task     Task          `json:"task"`
+	Progress *TaskProgress `json:"progress,omitempty"`
+}
+
+// Status 获取任务状态，full/csv/all 模式附带元数据库表级别进度
+func (s *Server) Status(ctx context.Context, taskID string) (TaskStatus, error) {
+	t, cfg, err := s.Get(taskID)
+	if err != nil {
+		return TaskStatus{}, err
+	}
+	status := TaskStatus{Task: t}
+	switch t.TaskMode {
+	case common.TaskModeFull, common.TaskModeCSV, common.TaskModeAll:
+		progress, err := s.progress(meta.WithTaskID(ctx, t.TaskID), cfg)
+		if err != nil {
+			return status, err
+		}
+		status.Progress = &progress
+	}
+	return status, nil
+}
+
+func (s *Server) progress(ctx context.Context, cfg *config.Config) (TaskProgress, error) {
+	var progress TaskProgress
+	metaDB, err := s.getMetaDB(ctx)
+	if err != nil {
+		return progress, err
+	}
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+		TaskMode:    cfg.TaskMode,
+	})
+	if err != nil {
+		return progress, err
+	}
+	for _, m := range waitSyncMetas {
+		progress.TableTotals++
+		switch m.TaskStatus {
+		case common.TaskStatusSuccess:
+			progress.TableSuccess++
+		case common.TaskStatusFailed:
+			progress.TableFailed++
+		case common.TaskStatusRunning:
+			progress.TableRunning++
+		default:
+			progress.TableWaiting++
+		}
+		if m.ChunkTotalNums > 0 {
+			progress.ChunkTotalNums += m.ChunkTotalNums
+			progress.ChunkSuccessNums += m.ChunkSuccessNums
+			progress.ChunkFailedNums += m.ChunkFailedNums
+		}
+	}
+	if progress.ChunkTotalNums > 0 {
+		progress.Progress = int(progress.ChunkSuccessNums * 100 / progress.ChunkTotalNums)
+	}
+	return progress, nil
+}
+
+// 元数据库连接延迟到首次查询进度创建，连接失败下次查询重试
+func (s *Server) getMetaDB(ctx context.Context) (*meta.Meta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metaDB != nil {
+		return s.metaDB, nil
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, s.base.MetaConfig, s.base.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	s.metaDB = metaDB
+	return metaDB, nil
+}
+
+func httpStatus(err error) int {
+	if errors.Is(err, errTaskNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusConflict
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		zap.L().Warn("api write response failed", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,276 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"time"
+)
+
+// 进度推送默认间隔，单位秒
+const defaultWatchInterval = 5
+
+// MigrationServiceServer gRPC 服务定义，对应 proto/transferdb.proto
+type MigrationServiceServer interface {
+	Submit(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Start(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Stop(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Resume(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Pause(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Tune(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Status(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	List(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Watch(*structpb.Struct, grpc.ServerStream) error
+	Run(*structpb.Struct, grpc.ServerStream) error
+}
+
+// RegisterGRPC 注册 gRPC 服务，与 REST 接口共用同一任务管理
+func (s *Server) RegisterGRPC(gs *grpc.Server) {
+	gs.RegisterService(&migrationServiceDesc, &grpcService{s: s})
+}
+
+type grpcService struct {
+	s *Server
+}
+
+func (g *grpcService) Submit(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	var def TaskDefinition
+	if err := fromStruct(req, &def); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	t, err := g.s.Create(normalizeDefinition(def))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return toStruct(t)
+}
+
+func (g *grpcService) Start(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.taskAction(req, g.s.Start)
+}
+
+func (g *grpcService) Stop(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.taskAction(req, g.s.Stop)
+}
+
+func (g *grpcService) Resume(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.taskAction(req, g.s.Resume)
+}
+
+func (g *grpcService) Pause(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.taskAction(req, g.s.Pause)
+}
+
+// Tune 请求 {"task_id", "params"}，params 为空返回当前调优参数
+func (g *grpcService) Tune(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	taskID := req.GetFields()["task_id"].GetStringValue()
+	params := req.GetFields()["params"].GetStructValue()
+	if params == nil {
+		p, err := g.s.TuningParams(taskID)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		return toStruct(p)
+	}
+	b, err := params.MarshalJSON()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	p, err := g.s.Tune(taskID, bytes.NewReader(b))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toStruct(p)
+}
+
+func (g *grpcService) taskAction(req *structpb.Struct, action func(taskID string) (Task, error)) (*structpb.Struct, error) {
+	t, err := action(req.GetFields()["task_id"].GetStringValue())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toStruct(t)
+}
+
+func (g *grpcService) Status(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	st, err := g.s.Status(ctx, req.GetFields()["task_id"].GetStringValue())
+	if err != nil {
+		return nil, grpcStatusError(err)
+	}
+	return toStruct(st)
+}
+
+func (g *grpcService) List(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return toStruct(map[string]interface{}{"tasks": g.s.List()})
+}
+
+func (g *grpcService) Watch(req *structpb.Struct, stream grpc.ServerStream) error {
+	return g.watch(stream, req.GetFields()["task_id"].GetStringValue(), watchInterval(req))
+}
+
+func (g *grpcService) Run(req *structpb.Struct, stream grpc.ServerStream) error {
+	var def TaskDefinition
+	if err := fromStruct(req, &def); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	t, err := g.s.Create(normalizeDefinition(def))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err = g.s.Start(t.TaskID); err != nil {
+		return grpcError(err)
+	}
+	return g.watch(stream, t.TaskID, watchInterval(req))
+}
+
+// watch 定时推送任务状态，任务非运行/暂停状态时推送最终状态后结束
+func (g *grpcService) watch(stream grpc.ServerStream, taskID string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(stream.Context(), queryTimeout)
+		st, err := g.s.Status(ctx, taskID)
+		cancel()
+		if err != nil {
+			return grpcStatusError(err)
+		}
+		msg, err := toStruct(st)
+		if err != nil {
+			return err
+		}
+		if err = stream.SendMsg(msg); err != nil {
+			return err
+		}
+		if st.Task.TaskStatus != common.APITaskStatusRunning && st.Task.TaskStatus != common.APITaskStatusPaused {
+			return nil
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// incr 对应命令行 all 模式（全量 + 增量）
+func normalizeDefinition(def TaskDefinition) TaskDefinition {
+	if common.StringUPPER(def.TaskMode) == "INCR" {
+		def.TaskMode = common.TaskModeAll
+	}
+	return def
+}
+
+func watchInterval(req *structpb.Struct) time.Duration {
+	interval := int(req.GetFields()["interval"].GetNumberValue())
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+func grpcError(err error) error {
+	if errors.Is(err, errTaskNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.FailedPrecondition, err.Error())
+}
+
+// 任务进度查询元数据库失败
+func grpcStatusError(err error) error {
+	if errors.Is(err, errTaskNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	m := make(map[string]interface{})
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	st, err := structpb.NewStruct(m)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return st, nil
+}
+
+func fromStruct(st *structpb.Struct, v interface{}) error {
+	b, err := st.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func unaryHandler(call func(MigrationServiceServer, context.Context, *structpb.Struct) (*structpb.Struct, error), method string) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(structpb.Struct)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(MigrationServiceServer), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/transferdb.v1.MigrationService/" + method}
+		return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(MigrationServiceServer), ctx, req.(*structpb.Struct))
+		})
+	}
+}
+
+func streamHandler(call func(MigrationServiceServer, *structpb.Struct, grpc.ServerStream) error) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		in := new(structpb.Struct)
+		if err := stream.RecvMsg(in); err != nil {
+			return err
+		}
+		return call(srv.(MigrationServiceServer), in, stream)
+	}
+}
+
+var migrationServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transferdb.v1.MigrationService",
+	HandlerType: (*MigrationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Submit", Handler: unaryHandler(MigrationServiceServer.Submit, "Submit")},
+		{MethodName: "Start", Handler: unaryHandler(MigrationServiceServer.Start, "Start")},
+		{MethodName: "Stop", Handler: unaryHandler(MigrationServiceServer.Stop, "Stop")},
+		{MethodName: "Resume", Handler: unaryHandler(MigrationServiceServer.Resume, "Resume")},
+		{MethodName: "Pause", Handler: unaryHandler(MigrationServiceServer.Pause, "Pause")},
+		{MethodName: "Tune", Handler: unaryHandler(MigrationServiceServer.Tune, "Tune")},
+		{MethodName: "Status", Handler: unaryHandler(MigrationServiceServer.Status, "Status")},
+		{MethodName: "List", Handler: unaryHandler(MigrationServiceServer.List, "List")},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Watch", Handler: streamHandler(MigrationServiceServer.Watch), ServerStreams: true},
+		{StreamName: "Run", Handler: streamHandler(MigrationServiceServer.Run), ServerStreams: true},
+	},
+	Metadata: "api/proto/transferdb.proto",
+}
@@ -0,0 +1,371 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/google/uuid"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/server"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task 通过接口提交的迁移任务定义以及运行状态，任务定义仅保存于内存，进程重启后需重新提交
+// 任务配置快照、进度以及断点信息按任务 ID 保存于元数据库，以相同 task_id 重新提交并 resume 即可断点续传
+type Task struct {
+	TaskID     string `json:"task_id"`
+	TaskName   string `json:"task_name"`
+	TaskMode   string `json:"task_mode"`
+	DBTypeS    string `json:"db_type_s"`
+	DBTypeT    string `json:"db_type_t"`
+	SchemaS    string `json:"schema_name_s"`
+	TaskStatus string `json:"task_status"`
+	Error      string `json:"error,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+
+	cfg    *config.Config
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// TaskDefinition 任务提交请求
+// Config 为 toml 格式配置片段，覆盖服务启动配置文件中对应配置项，未配置项沿用服务启动配置
+// TaskID 为空自动生成 uuid，非空沿用指定任务 ID，覆盖配置 [app] task-id
+type TaskDefinition struct {
+	TaskID   string `json:"task_id"`
+	TaskName string `json:"task_name"`
+	TaskMode string `json:"task_mode"`
+	DBTypeS  string `json:"db_type_s"`
+	DBTypeT  string `json:"db_type_t"`
+	Config   string `json:"config"`
+}
+
+// Manager 接口任务管理
+type Manager struct {
+	ctx  context.Context
+	base *config.Config
+
+	mu    sync.Mutex
+	tasks map[string]*Task
+}
+
+func NewManager(ctx context.Context, base *config.Config) *Manager {
+	return &Manager{
+		ctx:   ctx,
+		base:  base,
+		tasks: make(map[string]*Task),
+	}
+}
+
+// Create 依据服务启动配置以及任务定义生成任务配置，仅登记任务不运行
+func (m *Manager) Create(def TaskDefinition) (Task, error) {
+	if def.TaskID == "" {
+		def.TaskID = uuid.NewString()
+	}
+	cfg, err := m.newTaskConfig(def)
+	if err != nil {
+		return Task{}, err
+	}
+	t := &Task{
+		TaskID:     cfg.AppConfig.TaskID,
+		TaskName:   def.TaskName,
+		TaskMode:   cfg.TaskMode,
+		DBTypeS:    cfg.DBTypeS,
+		DBTypeT:    cfg.DBTypeT,
+		SchemaS:    cfg.SchemaConfig.SourceSchema,
+		TaskStatus: common.APITaskStatusCreated,
+		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+		cfg:        cfg,
+	}
+	m.mu.Lock()
+	if _, ok := m.tasks[t.TaskID]; ok {
+		m.mu.Unlock()
+		return Task{}, fmt.Errorf("task [%s] is already exist", t.TaskID)
+	}
+	m.tasks[t.TaskID] = t
+	m.mu.Unlock()
+
+	zap.L().Info("api task created", zap.String("task", t.TaskID), zap.String("mode", t.TaskMode), zap.String("schema", t.SchemaS))
+	return *t, nil
+}
+
+func (m *Manager) newTaskConfig(def TaskDefinition) (*config.Config, error) {
+	// 服务启动配置深拷贝，避免任务间相互覆盖
+	cfgBytes, err := json.Marshal(m.base)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config.Config{}
+	if err = json.Unmarshal(cfgBytes, cfg); err != nil {
+		return nil, err
+	}
+	if def.Config != "" {
+		md, err := toml.Decode(def.Config, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("task config decode failed: %v", err)
+		}
+		if err = config.CheckUndecodedKeys("task config", def.Config, md); err != nil {
+			return nil, err
+		}
+	}
+	cfg.TaskMode = def.TaskMode
+	cfg.AppConfig.TaskID = def.TaskID
+	if def.DBTypeS != "" {
+		cfg.DBTypeS = def.DBTypeS
+	}
+	if def.DBTypeT != "" {
+		cfg.DBTypeT = def.DBTypeT
+	}
+	if err = cfg.AdjustConfig(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.TaskMode {
+	case common.TaskModePrepare, common.TaskModeAssess, common.TaskModeReverse, common.TaskModeCheck,
+		common.TaskModeCompare, common.TaskModeCSV, common.TaskModeFull, common.TaskModeAll:
+	default:
+		return nil, fmt.Errorf("task mode [%s] isn't support, support mode: [prepare assess reverse full csv all check compare]", def.TaskMode)
+	}
+	if cfg.SchemaConfig.SourceSchema == "" {
+		return nil, fmt.Errorf("task config schema-config source-schema can not null")
+	}
+	return cfg, nil
+}
+
+// Start 运行任务，元数据按任务 ID 隔离，不同任务可同时运行，同一任务同时仅允许运行一次
+func (m *Manager) Start(taskID string) (Task, error) {
+	return m.run(taskID, false)
+}
+
+// Resume 恢复已暂停任务，或者断点续传继续运行已停止或失败的任务，full/csv/compare 模式开启 enable-checkpoint，all 模式增量依据元数据 SCN 继续同步
+func (m *Manager) Resume(taskID string) (Task, error) {
+	m.mu.Lock()
+	t, ok := m.tasks[taskID]
+	if ok && t.TaskStatus == common.APITaskStatusPaused {
+		defer m.mu.Unlock()
+		signal.Resume(taskID)
+		t.TaskStatus = common.APITaskStatusRunning
+		zap.L().Warn("api task resumed", zap.String("task", t.TaskID))
+		return *t, nil
+	}
+	m.mu.Unlock()
+	return m.run(taskID, true)
+}
+
+// Pause 暂停运行中任务，不再拉取新的表/chunk，进行中 chunk 正常完成并写入断点，进程不退出，Resume 后继续拉取
+func (m *Manager) Pause(taskID string) (Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return Task{}, errTaskNotFound
+	}
+	if t.TaskStatus != common.APITaskStatusRunning {
+		return *t, fmt.Errorf("task [%s] isn't running, current status [%s]", taskID, t.TaskStatus)
+	}
+	signal.Pause(taskID)
+	t.TaskStatus = common.APITaskStatusPaused
+	zap.L().Warn("api task paused", zap.String("task", t.TaskID))
+	return *t, nil
+}
+
+// TuningParams 运行中任务当前调优参数
+func (m *Manager) TuningParams(taskID string) (common.TuningParams, error) {
+	tuning, err := m.getTuning(taskID)
+	if err != nil {
+		return common.TuningParams{}, err
+	}
+	return tuning.Params(), nil
+}
+
+// Tune 调整运行中任务调优参数，请求体未指定的参数保持当前值，限速即时生效，线程数以及批次大小拉取下一张表/chunk 生效
+func (m *Manager) Tune(taskID string, body io.Reader) (common.TuningParams, error) {
+	tuning, err := m.getTuning(taskID)
+	if err != nil {
+		return common.TuningParams{}, err
+	}
+	old := tuning.Params()
+	params := old
+	if err = json.NewDecoder(body).Decode(&params); err != nil {
+		return old, fmt.Errorf("task [%s] tuning params decode failed: %v", taskID, err)
+	}
+	if err = tuning.Update(params); err != nil {
+		return old, err
+	}
+	zap.L().Warn("api task tuning params updated", zap.String("task", taskID), zap.Any("old", old), zap.Any("new", params))
+	return params, nil
+}
+
+func (m *Manager) getTuning(taskID string) (*common.Tuning, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return nil, errTaskNotFound
+	}
+	if t.TaskStatus != common.APITaskStatusRunning && t.TaskStatus != common.APITaskStatusPaused {
+		return nil, fmt.Errorf("task [%s] isn't running, current status [%s]", taskID, t.TaskStatus)
+	}
+	tuning, ok := common.GetTuning(taskID)
+	if !ok {
+		return nil, fmt.Errorf("task [%s] mode [%s] not support tuning, only oracle to mysql/tidb full and all mode support", taskID, t.cfg.TaskMode)
+	}
+	return tuning, nil
+}
+
+func (m *Manager) run(taskID string, resume bool) (Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return Task{}, errTaskNotFound
+	}
+	switch t.TaskStatus {
+	case common.APITaskStatusRunning, common.APITaskStatusPaused:
+		return *t, fmt.Errorf("task [%s] is %s", taskID, strings.ToLower(t.TaskStatus))
+	case common.APITaskStatusCreated:
+		if resume {
+			return *t, fmt.Errorf("task [%s] never started, please start the task", taskID)
+		}
+	}
+	if resume {
+		t.cfg.FullConfig.EnableCheckpoint = true
+		t.cfg.CSVConfig.EnableCheckpoint = true
+		t.cfg.DiffConfig.EnableCheckpoint = true
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	t.TaskStatus = common.APITaskStatusRunning
+	t.Error = ""
+	t.StartedAt = time.Now().Format("2006-01-02 15:04:05")
+	t.FinishedAt = ""
+
+	go func(t *Task, cfg *config.Config, done chan struct{}) {
+		defer close(done)
+		zap.L().Info("api task start", zap.String("task", t.TaskID), zap.String("mode", cfg.TaskMode), zap.Bool("resume", resume))
+		err := server.Run(ctx, cfg)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		t.FinishedAt = time.Now().Format("2006-01-02 15:04:05")
+		switch {
+		case t.TaskStatus == common.APITaskStatusStopped:
+			// 接口停止任务，ctx 取消导致的报错忽略
+		case err != nil:
+			t.TaskStatus = common.APITaskStatusFailed
+			t.Error = err.Error()
+			zap.L().Error("api task failed", zap.String("task", t.TaskID), zap.Error(err))
+		default:
+			t.TaskStatus = common.APITaskStatusSuccess
+			zap.L().Info("api task finished", zap.String("task", t.TaskID))
+		}
+	}(t, t.cfg, t.done)
+
+	return *t, nil
+}
+
+// Stop 停止运行中任务，取消任务上下文中断正在执行的上下游查询，已完成 chunk 断点保存于元数据库
+func (m *Manager) Stop(taskID string) (Task, error) {
+	m.mu.Lock()
+	t, ok := m.tasks[taskID]
+	if !ok {
+		m.mu.Unlock()
+		return Task{}, errTaskNotFound
+	}
+	if t.TaskStatus != common.APITaskStatusRunning && t.TaskStatus != common.APITaskStatusPaused {
+		m.mu.Unlock()
+		return *t, fmt.Errorf("task [%s] isn't running, current status [%s]", taskID, t.TaskStatus)
+	}
+	t.TaskStatus = common.APITaskStatusStopped
+	cancel, done := t.cancel, t.done
+	m.mu.Unlock()
+
+	cancel()
+	<-done
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	zap.L().Warn("api task stopped", zap.String("task", t.TaskID))
+	return *t, nil
+}
+
+// Delete 删除非运行状态任务定义
+func (m *Manager) Delete(taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return errTaskNotFound
+	}
+	if t.TaskStatus == common.APITaskStatusRunning || t.TaskStatus == common.APITaskStatusPaused {
+		return fmt.Errorf("task [%s] is %s, please stop the task first", taskID, strings.ToLower(t.TaskStatus))
+	}
+	delete(m.tasks, taskID)
+	return nil
+}
+
+func (m *Manager) Get(taskID string) (Task, *config.Config, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return Task{}, nil, errTaskNotFound
+	}
+	return *t, t.cfg, nil
+}
+
+func (m *Manager) List() []Task {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tasks := make([]Task, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		tasks = append(tasks, *t)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt < tasks[j].CreatedAt
+	})
+	return tasks
+}
+
+// Wait 等待运行中任务退出，用于服务优雅退出
+func (m *Manager) Wait() {
+	m.mu.Lock()
+	var dones []chan struct{}
+	for _, t := range m.tasks {
+		if t.TaskStatus == common.APITaskStatusRunning || t.TaskStatus == common.APITaskStatusPaused {
+			dones = append(dones, t.done)
+		}
+	}
+	m.mu.Unlock()
+	for _, done := range dones {
+		<-done
+	}
+}
@@ -17,17 +17,26 @@ package main
 
 import (
 	"context"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/wentaojin/transferdb/api"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/dashboard"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/signal"
+	"google.golang.org/grpc"
 	"log"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/logger"
 
 	"github.com/wentaojin/transferdb/server"
+	"github.com/wentaojin/transferdb/tracing"
 	"go.uber.org/zap"
 )
 
@@ -41,21 +50,102 @@ func main() {
 	logger.NewZapLogger(cfg)
 	config.RecordAppVersion("transferdb", cfg)
 
-	go func() {
-		if err := http.ListenAndServe(cfg.AppConfig.PprofPort, nil); err != nil {
-			zap.L().Fatal("listen and serve pprof failed", zap.Error(errors.Cause(err)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 链路追踪，退出前刷新未导出 span
+	traceShutdown, err := tracing.Init(ctx, cfg)
+	if err != nil {
+		log.Fatalf("init trace failed. error is [%s]", err)
+	}
+	defer func() {
+		if err := traceShutdown(context.Background()); err != nil {
+			zap.L().Warn("trace shutdown failed", zap.Error(err))
 		}
-		os.Exit(0)
 	}()
 
+	// pprof、运行时诊断以及 prometheus /metrics 共用 pprof-port 端口
+	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/debug/runtime", metrics.RuntimeStatsHandler)
+	if cfg.AppConfig.Dashboard {
+		dashboard.Register(http.DefaultServeMux, cfg)
+	}
+	// server 模式提供任务管理接口，任务由接口提交运行
+	var apiServer *api.Server
+	if cfg.TaskMode == common.TaskModeServer {
+		apiServer = api.NewServer(ctx, cfg)
+		apiServer.Register(http.DefaultServeMux)
+
+		if cfg.AppConfig.GRPCAddr != "" {
+			lis, err := net.Listen("tcp", cfg.AppConfig.GRPCAddr)
+			if err != nil {
+				zap.L().Fatal("listen grpc addr failed", zap.String("addr", cfg.AppConfig.GRPCAddr), zap.Error(err))
+			}
+			grpcServer := grpc.NewServer()
+			apiServer.RegisterGRPC(grpcServer)
+			go func() {
+				if err := grpcServer.Serve(lis); err != nil {
+					zap.L().Fatal("grpc serve failed", zap.Error(err))
+				}
+			}()
+		}
+	}
+	// pprof-port 为空不开启调试端口
+	if cfg.AppConfig.PprofPort != "" {
+		go func() {
+			if err := http.ListenAndServe(cfg.AppConfig.PprofPort, nil); err != nil {
+				zap.L().Fatal("listen and serve pprof failed", zap.Error(errors.Cause(err)))
+			}
+			os.Exit(0)
+		}()
+	}
+
 	// 信号量监听处理
+	// 优雅退出：不再拉取新表/chunk，等待进行中 chunk 完成并写入断点
+	// 超过 graceful-timeout 取消 ctx，等待上下游查询中断（godror break、MySQL KILL QUERY）以及任务返回后退出
+	runDone := make(chan struct{})
 	signal.SetupSignalHandler(func() {
+		signal.Shutdown()
+
+		gracefulTimeout := cfg.AppConfig.GracefulTimeout
+		if gracefulTimeout <= 0 {
+			gracefulTimeout = common.DefaultGracefulTimeout
+		}
+		time.Sleep(time.Duration(gracefulTimeout) * time.Second)
+
+		zap.L().Warn("graceful shutdown timeout, cancel in-flight task", zap.Int("graceful-timeout", gracefulTimeout))
+		cancel()
+
+		select {
+		case <-runDone:
+		case <-time.After(common.DefaultCancelTimeout * time.Second):
+			zap.L().Warn("cancel in-flight task timeout, force exit", zap.Int("cancel-timeout", common.DefaultCancelTimeout))
+		}
 		os.Exit(1)
+	}, func() {
+		// 热更新：重新读取配置文件调优参数，server 模式任务配置由接口提交，通过接口 tune 调整
+		if apiServer != nil {
+			zap.L().Warn("server mode ignore reload signal, please use api /api/v1/tasks/{id}/tune")
+			return
+		}
+		if err := server.ReloadTuning(cfg); err != nil {
+			zap.L().Warn("reload tuning params failed, keep current params", zap.Error(err))
+		}
 	})
 
+	// server 模式常驻运行，收到退出信号后等待运行中任务退出
+	if apiServer != nil {
+		zap.L().Info("transferdb api server started", zap.String("addr", cfg.AppConfig.PprofPort), zap.String("grpc-addr", cfg.AppConfig.GRPCAddr))
+		<-signal.Done()
+		apiServer.Wait()
+		close(runDone)
+		return
+	}
+
 	// 程序运行
-	ctx := context.Background()
-	if err := server.Run(ctx, cfg); err != nil {
+	err = server.Run(ctx, cfg)
+	close(runDone)
+	if err != nil {
 		zap.L().Fatal("server run failed", zap.Error(errors.Cause(err)))
 	}
 }
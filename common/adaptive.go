@@ -0,0 +1,109 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptiveBatch 自适应批次大小，根据单行平均字节数以及批次写入耗时动态调整每批次行数
+// 1、批次写入耗时超过目标耗时，按比例收缩，单次最多收缩一半
+// 2、批次写入耗时低于目标耗时一半，每次放大 25%
+// 3、每批次行数 * 单行平均字节数不超过目标批次字节数，且介于最小/最大行数之间
+// nil AdaptiveBatch 代表关闭自适应，沿用固定 insert-batch-size
+type AdaptiveBatch struct {
+	mu            sync.Mutex
+	rows          int
+	minRows       int
+	maxRows       int
+	targetBytes   int
+	targetLatency time.Duration
+	avgRowBytes   float64
+}
+
+// NewAdaptiveBatch 生成自适应批次，initRows 初始行数，targetBytes 小于等于 0 代表不限制批次字节数
+func NewAdaptiveBatch(initRows, minRows, maxRows, targetBytes int, targetLatency time.Duration) *AdaptiveBatch {
+	if minRows <= 0 {
+		minRows = 1
+	}
+	if maxRows < minRows {
+		maxRows = minRows
+	}
+	a := &AdaptiveBatch{
+		minRows:       minRows,
+		maxRows:       maxRows,
+		targetBytes:   targetBytes,
+		targetLatency: targetLatency,
+	}
+	a.rows = a.clamp(initRows)
+	return a
+}
+
+// Rows 当前每批次行数，nil 返回 0
+func (a *AdaptiveBatch) Rows() int {
+	if a == nil {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rows
+}
+
+// Observe 记录一次批次写入行数、字节数以及耗时，返回调整后的每批次行数
+func (a *AdaptiveBatch) Observe(rows, bytes int, latency time.Duration) int {
+	if a == nil || rows <= 0 {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// 单行平均字节数指数加权平均
+	rowBytes := float64(bytes) / float64(rows)
+	if a.avgRowBytes == 0 {
+		a.avgRowBytes = rowBytes
+	} else {
+		a.avgRowBytes = a.avgRowBytes*0.7 + rowBytes*0.3
+	}
+
+	next := a.rows
+	switch {
+	case a.targetLatency > 0 && latency > a.targetLatency:
+		next = int(float64(a.rows) * float64(a.targetLatency) / float64(latency))
+		if next < a.rows/2 {
+			next = a.rows / 2
+		}
+	case a.targetLatency <= 0 || latency < a.targetLatency/2:
+		next = a.rows + a.rows/4 + 1
+	}
+	if a.targetBytes > 0 && a.avgRowBytes > 0 {
+		if limit := int(float64(a.targetBytes) / a.avgRowBytes); next > limit {
+			next = limit
+		}
+	}
+	a.rows = a.clamp(next)
+	return a.rows
+}
+
+func (a *AdaptiveBatch) clamp(rows int) int {
+	if rows < a.minRows {
+		return a.minRows
+	}
+	if rows > a.maxRows {
+		return a.maxRows
+	}
+	return rows
+}
@@ -0,0 +1,36 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"strings"
+)
+
+// DDLAuditor 目标端 DDL 以及破坏性语句审计，execErr 为语句执行结果
+type DDLAuditor interface {
+	Audit(sql string, execErr error)
+}
+
+// IsDDLAuditStatement 判断是否 CREATE/DROP/TRUNCATE/ALTER/RENAME 语句，返回语句操作类型
+func IsDDLAuditStatement(sql string) (string, bool) {
+	stmt := strings.TrimLeft(sql, " \t\r\n")
+	for _, op := range DDLAuditOperations {
+		if len(stmt) > len(op) && strings.EqualFold(stmt[:len(op)], op) && (stmt[len(op)] == ' ' || stmt[len(op)] == '\t' || stmt[len(op)] == '\n') {
+			return op, true
+		}
+	}
+	return "", false
+}
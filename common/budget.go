@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBudget 全量同步内存预算，统计已转换待写入下游批次字节数，进程内同一数据库引擎所有表/chunk 共享
+// 待写入字节数达到预算时数据抽取暂停，下游写入完成释放后恢复，避免上游读取快、下游写入慢导致内存溢出
+// nil MemoryBudget 代表不限制
+type MemoryBudget struct {
+	limit  int64
+	used   int64
+	mu     sync.Mutex
+	notify chan struct{}
+}
+
+// NewMemoryBudget 根据配置生成内存预算，mb 单位：MB，小于等于 0 返回 nil
+func NewMemoryBudget(mb int) *MemoryBudget {
+	if mb <= 0 {
+		return nil
+	}
+	return &MemoryBudget{
+		limit:  int64(mb) * 1024 * 1024,
+		notify: make(chan struct{}),
+	}
+}
+
+// Wait 待写入字节数达到预算时阻塞，直至写入释放或者 ctx 取消，用于数据抽取批次发送前
+func (b *MemoryBudget) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		b.mu.Lock()
+		if b.used < b.limit {
+			b.mu.Unlock()
+			return nil
+		}
+		notify := b.notify
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// Add 批次进入写入队列，累加待写入字节数，不阻塞，避免写入队列与抽取互相等待
+func (b *MemoryBudget) Add(bytes int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.used += int64(bytes)
+	b.mu.Unlock()
+}
+
+// Release 批次写入完成（成功或者失败），释放待写入字节数并唤醒等待中的数据抽取
+func (b *MemoryBudget) Release(bytes int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.used -= int64(bytes)
+	if b.used < 0 {
+		b.used = 0
+	}
+	close(b.notify)
+	b.notify = make(chan struct{})
+	b.mu.Unlock()
+}
+
+// Used 当前待写入字节数
+func (b *MemoryBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"math"
+	"strconv"
+)
+
+// 数据校验时间戳小数秒最大比较位数，MySQL 时间类型最大精度 6
+const MaxCompareTimestampPrecision = 6
+
+// 在线数据校验等待增量同步追平对比 SCN 默认超时时间，单位: 秒
+const DefaultOnlineCompareTimeout = 600
+
+// 数据校验浮点类型字段，[diff] float-epsilon 大于 0 时按容差对应小数位数四舍五入后比较
+var compareFloatDatatypes = []string{"FLOAT", "BINARY_FLOAT", "BINARY_DOUBLE", "DOUBLE PRECISION", "REAL"}
+
+// FloatEpsilonScale 浮点容差折算小数位数，例如 0.000001 -> 6，0.005 -> 3
+func FloatEpsilonScale(epsilon float64) int {
+	if epsilon <= 0 {
+		return -1
+	}
+	scale := int(math.Ceil(-math.Log10(epsilon) - 1e-9))
+	if scale < 0 {
+		return 0
+	}
+	return scale
+}
+
+// GenCompareFloatColumn 浮点字段按容差归一化，源端转换 NUMBER 避免 BINARY_FLOAT/BINARY_DOUBLE 科学计数法输出，未配置容差或者非浮点类型返回 false
+// colNameT 为下游字段名，字段名映射规则不存在时与源端字段名相同
+func GenCompareFloatColumn(colName, colNameT, dataType string, epsilon float64) (string, string, bool) {
+	scale := FloatEpsilonScale(epsilon)
+	if scale < 0 || !IsContainString(compareFloatDatatypes, StringUPPER(dataType)) {
+		return "", "", false
+	}
+	s := strconv.Itoa(scale)
+	sourceExpr := StringsBuilder("ROUND(TO_NUMBER(", colName, "),", s, ")")
+	return StringsBuilder("DECODE(SUBSTR(", sourceExpr, ",1,1),'.','0' || ", sourceExpr, ",", sourceExpr, ") AS ", colName),
+		StringsBuilder("CAST(0 + CAST(ROUND(", colNameT, ",", s, ") AS CHAR) AS CHAR) AS ", colNameT), true
+}
+
+// GenCompareTimestampColumn 时间戳字段按 [diff] timestamp-precision 截断小数秒位数后比较，0 代表精确到秒
+func GenCompareTimestampColumn(colName, colNameT string, precision int) (string, string) {
+	if precision <= 0 {
+		return StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName),
+			StringsBuilder("FROM_UNIXTIME(UNIX_TIMESTAMP(", colNameT, "),'%Y-%m-%d %H:%i:%s') AS ", colNameT)
+	}
+	length := strconv.Itoa(len("yyyy-MM-dd HH:mm:ss.") + precision)
+	return StringsBuilder("SUBSTR(TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss.FF9'),1,", length, ") AS ", colName),
+		StringsBuilder("SUBSTR(FROM_UNIXTIME(UNIX_TIMESTAMP(", colNameT, "),'%Y-%m-%d %H:%i:%s.%f'),1,", length, ") AS ", colNameT)
+}
@@ -15,10 +15,18 @@ limitations under the License.
 */
 package common
 
+import (
+	"fmt"
+	"strings"
+)
+
 // 数据全量/实时同步 Oracle 版本要求
 // 要求 oracle 11g 及以上
 const RequireOracleDBVersion = "11"
 
+// 增量同步 logminer 需 LOGMINING 权限 Oracle 版本要求
+const RequireOracleLogminingDBVersion = "12"
+
 // Oracle Redo 同步操作类型
 const (
 	MigrateOperationUpdate   = "UPDATE"
@@ -30,9 +38,253 @@ const (
 	MigrateOperationDDL           = "DDL"
 	MigrateOperationTruncateTable = "TRUNCATE TABLE"
 	MigrateOperationDropTable     = "DROP TABLE"
+
+	MigrateOperationCreateTable   = "CREATE TABLE"
+	MigrateOperationAddColumn     = "ADD COLUMN"
+	MigrateOperationModifyColumn  = "MODIFY COLUMN"
+	MigrateOperationDropColumn    = "DROP COLUMN"
+	MigrateOperationRenameColumn  = "RENAME COLUMN"
+	MigrateOperationCreateIndex   = "CREATE INDEX"
+	MigrateOperationDropIndex     = "DROP INDEX"
+	MigrateOperationCommentTable  = "COMMENT TABLE"
+	MigrateOperationCommentColumn = "COMMENT COLUMN"
+)
+
+// 增量同步冲突处理策略
+// ERROR 报错退出，SKIP 跳过并记录审计，OVERWRITE 覆盖写入（INSERT 转 REPLACE，UPDATE 转 DELETE+REPLACE upsert）
+const (
+	ConflictPolicyError     = "ERROR"
+	ConflictPolicySkip      = "SKIP"
+	ConflictPolicyOverwrite = "OVERWRITE"
+
+	ConflictTypeDuplicateKey = "DUPLICATE KEY"
+	ConflictTypeZeroRows     = "ZERO ROWS"
+)
+
+// 增量同步 DDL 处理方式
+// APPLY 转换并应用下游，LOG 只记录日志不应用，SKIP 忽略全部 DDL
+const (
+	DDLModeApply = "APPLY"
+	DDLModeLog   = "LOG"
+	DDLModeSkip  = "SKIP"
+)
+
+// 增量同步写入目标
+// MYSQL 直接应用下游数据库，KAFKA 变更事件发布至 Kafka 表级别 topic，消息 key 为主键/唯一键字段值
+const (
+	SinkTypeMySQL = "MYSQL"
+	SinkTypeKafka = "KAFKA"
+
+	KafkaFormatJSON = "JSON"
+	KafkaFormatAvro = "AVRO"
+
+	KafkaDefaultTopicPrefix  = "transferdb"
+	KafkaDefaultBatchSize    = 100
+	KafkaDefaultBatchTimeout = 10
+)
+
+// 增量同步捕获方式
+// LOGMINER 基于 logminer 挖掘 redo/归档日志，FLASHBACK 按表水位（ORA_ROWSCN 或者 watermark-column 时间字段）周期性闪回查询变更行 replace 写入下游
+// FLASHBACK 不捕获 DELETE 以及 DDL，适用于无法开启 logminer 的 Oracle 版本或者权限受限场景
+const (
+	CaptureModeLogminer  = "LOGMINER"
+	CaptureModeFlashback = "FLASHBACK"
+
+	// watermark-column 时间字段水位格式，DATE 字段 CAST AS TIMESTAMP 统一格式
+	FlashbackWatermarkFormat = "YYYY-MM-DD HH24:MI:SS.FF6"
+)
+
+var IncrCaptureModes = []string{CaptureModeLogminer, CaptureModeFlashback}
+
+// 增量同步表级别 ignore-operations 可忽略变更类型
+var IncrIgnoreOperations = []string{MigrateOperationInsert, MigrateOperationUpdate, MigrateOperationDelete, MigrateOperationTruncate}
+
+// CSV 导出文件格式
+const (
+	ExportFileFormatCSV     = "CSV"
+	ExportFileFormatParquet = "PARQUET"
+
+	// Parquet row group 大小，单位 MB
+	ParquetDefaultRowGroupSize = 128
+	ParquetDefaultCompression  = "SNAPPY"
+
+	// Hive 风格分区 NULL 值目录
+	ParquetDefaultPartitionNull = "__HIVE_DEFAULT_PARTITION__"
+)
+
+// CSV 导出文件存储
+const (
+	ExportStorageLocal = "LOCAL"
+	ExportStorageS3    = "S3"
+	ExportStorageOSS   = "OSS"
+
+	// multipart 分片大小，单位 MB，S3 最小 5MB
+	S3DefaultPartSize    = 16
+	S3MinPartSize        = 5
+	S3DefaultConcurrency = 4
+	S3DefaultMaxRetries  = 10
+)
+
+// 数据全量同步空字符串处理方式
+// oracle: 默认，兼容 Oracle 空字符串与 NULL 同一类，NULL 以及空字符串统一按 NULL 写入
+// null: NULL 按 NULL 写入，空字符串原样按空字符串写入，不做转换
+// empty: 字符类型字段 NULL 按空字符串写入，非字符类型字段 NULL 按 NULL 写入
+const (
+	EmptyStringModeOracle = "ORACLE"
+	EmptyStringModeNull   = "NULL"
+	EmptyStringModeEmpty  = "EMPTY"
 )
 
-// 用于控制当程序消费追平到当前 CURRENT 重做日志，
-// 当值 == 0 启用 filterOracleIncrRecord 大于或者等于逻辑
-// 当值 == 1 启用 filterOracleIncrRecord 大于逻辑，避免已被消费得日志一直被重复消费
-var MigrateCurrentResetFlag = 0
+// empty-string-mode = empty 按空字符串写入 NULL 值的 Oracle 字符类型（DatabaseTypeName）
+var OracleCharacterDatabaseTypes = []string{"CHAR", "NCHAR", "VARCHAR2", "NVARCHAR2", "LONG", "CLOB", "NCLOB"}
+
+// 数据全量同步 Oracle 二进制数据类型（DatabaseTypeName），以十六进制字面量写入下游
+var OracleBinaryDatabaseTypes = []string{"RAW", "LONG RAW", "BLOB"}
+
+// 数据全量同步 Oracle LOB 数据类型（DatabaseTypeName），受 lob-max-size 限制
+var OracleLOBDatabaseTypes = []string{"CLOB", "NCLOB", "BLOB"}
+
+// 数据抽取 Oracle LONG 数据类型（DATA_TYPE），存在该类型字段的表逐行抽取
+var OracleLongDatabaseTypes = []string{"LONG", "LONG RAW"}
+
+// 数据全量同步 LOB 字段值超过 lob-max-size 处理方式
+const (
+	LOBOversizeModeError = "ERROR"
+	LOBOversizeModeSkip  = "SKIP"
+)
+
+// 数据全量同步下游写入模式
+const (
+	WriteModeReplace = "REPLACE"
+	WriteModeInsert  = "INSERT"
+	WriteModeIgnore  = "IGNORE"
+	WriteModeUpsert  = "UPSERT"
+)
+
+var MigrateWriteModes = []string{WriteModeReplace, WriteModeInsert, WriteModeIgnore, WriteModeUpsert}
+
+// 数据全量同步表迁移顺序，默认按表名字母序
+const (
+	TableOrderName          = "NAME"
+	TableOrderLargestFirst  = "LARGEST-FIRST"
+	TableOrderSmallestFirst = "SMALLEST-FIRST"
+	TableOrderDependency    = "DEPENDENCY"
+)
+
+var MigrateTableOrders = []string{TableOrderName, TableOrderLargestFirst, TableOrderSmallestFirst, TableOrderDependency}
+
+// 全量装载前后删除重建下游二级索引默认并发数
+const DefaultRebuildIndexThreads = 4
+
+// 全量迁移预估计划默认整体写入速率，单位: 行/秒
+const MigratePlanDefaultRowsPerSecond = 50000
+
+// 全量迁移预估计划目标端存储放大系数（相对源端表段大小）
+// MySQL InnoDB 页填充率以及二级索引约 1.2 倍；TiDB 默认 3 副本，RocksDB 压缩后单副本约 0.5 倍
+var MigratePlanTargetStorageRatio = map[string]float64{
+	DatabaseTypeMySQL: 1.2,
+	DatabaseTypeTiDB:  1.5,
+}
+
+// 增量同步日志挖掘默认轮询间隔，单位: 毫秒
+const DefaultLogminerInterval = 300
+
+// 全量 skip-error 隔离文件默认目录
+const DefaultQuarantineDir = "./error"
+
+// 收到退出信号后等待进行中 chunk 完成的默认超时时间，单位: 秒
+const DefaultGracefulTimeout = 60
+
+// 优雅退出超时取消 ctx 后等待上下游查询中断、任务返回的超时时间，单位: 秒
+const DefaultCancelTimeout = 30
+
+// 数据全量/CSV 字段转换规则，源端 SELECT 阶段以 Oracle 表达式转换字段值
+const (
+	ColumnTransformHash       = "HASH"
+	ColumnTransformMask       = "MASK"
+	ColumnTransformTruncate   = "TRUNCATE"
+	ColumnTransformNull       = "NULL"
+	ColumnTransformExpression = "EXPRESSION"
+
+	// 自定义表达式字段名占位符
+	ColumnTransformPlaceholder = "{column}"
+	// 字段转换 hash 默认算法
+	DefaultColumnTransformAlgorithm = "SHA256"
+)
+
+// Oracle STANDARD_HASH 支持算法
+var ColumnTransformAlgorithms = []string{"MD5", "SHA1", "SHA256", "SHA384", "SHA512"}
+
+// GenOracleColumnTransform 生成字段转换查询表达式，返回值带字段别名，保证查询结果字段名不变
+// hash 基于 STANDARD_HASH（要求 oracle 12c 及以上，且不支持 LONG/LOB 字段），mask 保留前 length 个字符其余以 * 替换，truncate 截取前 length 个字符
+func GenOracleColumnTransform(columnName, rule, algorithm string, length int, expression string) (string, error) {
+	column := StringsBuilder(`"`, columnName, `"`)
+
+	var expr string
+	switch StringUPPER(rule) {
+	case ColumnTransformHash:
+		if strings.EqualFold(algorithm, "") {
+			algorithm = DefaultColumnTransformAlgorithm
+		}
+		if !IsContainString(ColumnTransformAlgorithms, StringUPPER(algorithm)) {
+			return "", fmt.Errorf("column [%s] transform rule [%s] algorithm [%s] isn't support, only support [%v]", columnName, rule, algorithm, ColumnTransformAlgorithms)
+		}
+		expr = StringsBuilder(`CASE WHEN `, column, ` IS NULL THEN NULL ELSE LOWER(RAWTOHEX(STANDARD_HASH(`, column, `,'`, StringUPPER(algorithm), `'))) END`)
+	case ColumnTransformMask:
+		if length <= 0 {
+			expr = StringsBuilder(`RPAD('*',LENGTH(`, column, `),'*')`)
+		} else {
+			expr = fmt.Sprintf(`CASE WHEN LENGTH(%s) <= %d THEN TO_CHAR(%s) ELSE RPAD(SUBSTR(%s,1,%d),LENGTH(%s),'*') END`, column, length, column, column, length, column)
+		}
+	case ColumnTransformTruncate:
+		if length <= 0 {
+			return "", fmt.Errorf("column [%s] transform rule [%s] length [%d] must be greater than 0", columnName, rule, length)
+		}
+		expr = fmt.Sprintf(`SUBSTR(%s,1,%d)`, column, length)
+	case ColumnTransformNull:
+		expr = `NULL`
+	case ColumnTransformExpression:
+		if !strings.Contains(expression, ColumnTransformPlaceholder) {
+			return "", fmt.Errorf("column [%s] transform rule [%s] expression [%s] must contain placeholder [%s]", columnName, rule, expression, ColumnTransformPlaceholder)
+		}
+		expr = strings.ReplaceAll(expression, ColumnTransformPlaceholder, column)
+	default:
+		return "", fmt.Errorf("column [%s] transform rule [%s] isn't support, only support [hash/mask/truncate/null/expression]", columnName, rule)
+	}
+	return StringsBuilder(expr, ` AS `, column), nil
+}
+
+// 数据全量/CSV DATE 字段默认 TO_CHAR 格式
+const DefaultOracleDateFormat = "yyyy-mm-dd hh24:mi:ss"
+
+// GetOracleDateFormat 返回 DATE 字段 TO_CHAR 格式，未配置 date-format 使用默认格式
+func GetOracleDateFormat(dateFormat string) string {
+	if strings.EqualFold(dateFormat, "") {
+		return DefaultOracleDateFormat
+	}
+	return dateFormat
+}
+
+// GenOracleDatatypeStrategyColumn 返回 interval-mode numeric、raw-mode hex 字段查询表达式，其他字段或者默认方式返回 false
+func GenOracleDatatypeStrategyColumn(columnName, dataType, intervalMode, rawMode string) (string, bool) {
+	col := StringsBuilder(`"`, columnName, `"`)
+	dataType = StringUPPER(dataType)
+	switch {
+	case strings.EqualFold(intervalMode, ReverseIntervalModeNumeric) && strings.Contains(dataType, "INTERVAL YEAR"):
+		return StringsBuilder(`(EXTRACT(YEAR FROM `, col, `) * 12 + EXTRACT(MONTH FROM `, col, `))`), true
+	case strings.EqualFold(intervalMode, ReverseIntervalModeNumeric) && strings.Contains(dataType, "INTERVAL DAY"):
+		return StringsBuilder(`(EXTRACT(DAY FROM `, col, `) * 86400 + EXTRACT(HOUR FROM `, col, `) * 3600 + EXTRACT(MINUTE FROM `, col, `) * 60 + EXTRACT(SECOND FROM `, col, `))`), true
+	case strings.EqualFold(rawMode, ReverseRawModeHex) && dataType == BuildInOracleDatatypeRaw:
+		return StringsBuilder(`RAWTOHEX(`, col, `)`), true
+	default:
+		return "", false
+	}
+}
+
+// GenOracleTimestampColumn 返回时间字段查询表达式，配置 target-time-zone 时带时区时间（WITH TIME ZONE/WITH LOCAL TIME ZONE）转换为目标时区
+func GenOracleTimestampColumn(columnName, dataType, targetTimeZone string) string {
+	if !strings.EqualFold(targetTimeZone, "") && strings.Contains(StringUPPER(dataType), "TIME ZONE") {
+		return StringsBuilder(`("`, columnName, `" AT TIME ZONE '`, targetTimeZone, `')`)
+	}
+	return StringsBuilder(`"`, columnName, `"`)
+}
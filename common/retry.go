@@ -0,0 +1,202 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 重试默认值
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBackoff    = 1000  // 单位: 毫秒
+	DefaultRetryMaxBackoff = 30000 // 单位: 毫秒
+	// 连接中断等待连接恢复最大时长，单位: 秒
+	DefaultReconnectTimeout = 300
+)
+
+// 可重试错误关键字
+// 上游 Oracle 连接中断、快照过旧、死锁、实例关闭（故障切换），下游 MySQL/TiDB 死锁、锁等待超时、写冲突、连接中断、实例关闭
+var RetryableErrorKeywords = []string{
+	"ORA-00060", "ORA-01555", "ORA-03113", "ORA-03114", "ORA-03135", "ORA-12170", "ORA-12537", "ORA-25408",
+	"ORA-01033", "ORA-01089", "ORA-12514", "ORA-12541",
+	"Error 1053", "Error 1205", "Error 1213", "Error 2006", "Error 2013", "Error 8022", "Error 9007",
+	"driver: bad connection", "invalid connection", "connection reset by peer", "connection refused", "broken pipe", "i/o timeout",
+}
+
+// RetryPolicy 重试策略，退避时间按 2 的指数增长，不超过 MaxBackoff
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewRetryPolicy 根据配置生成重试策略，参数小于等于 0 采用默认值，maxAttempts 为 1 代表不重试
+func NewRetryPolicy(maxAttempts, backoff, maxBackoff int) RetryPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRetryAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+	return RetryPolicy{
+		MaxAttempts: maxAttempts,
+		Backoff:     time.Duration(backoff) * time.Millisecond,
+		MaxBackoff:  time.Duration(maxBackoff) * time.Millisecond,
+	}
+}
+
+// IsRetryableError 判断是否瞬时错误
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	// 已标记不可重试的错误，即使错误信息包含瞬时错误关键字也不重试
+	var nr *nonRetryableError
+	if errors.As(err, &nr) {
+		return false
+	}
+	errMsg := err.Error()
+	for _, k := range RetryableErrorKeywords {
+		if strings.Contains(errMsg, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonRetryableError 已产生副作用无法安全重试的错误，Retry 不重试直接返回
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+// NonRetryable 标记错误不可重试，比如 chunk 部分数据已写入下游，外层使用 %w 包装保留标记，IsRetryableError 识别
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// Retry 执行 fn，瞬时错误按指数退避重试，非瞬时错误或重试次数耗尽返回最后一次错误
+// onRetry 每次重试前回调，用于日志及指标记录
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error, onRetry func(attempt int, err error)) error {
+	var err error
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !IsRetryableError(err) {
+			return err
+		}
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = backoff * 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// SplitHostPort 解析 host:port 地址，用于 failover-hosts 配置
+func SplitHostPort(addr string) (string, int, error) {
+	host, port, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return "", 0, err
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum <= 0 {
+		return "", 0, fmt.Errorf("port [%s] isn't valid", port)
+	}
+	return host, portNum, nil
+}
+
+// StartDBHealthCheck 连接池健康检查，按 interval 周期 Ping，失效空闲连接由连接池丢弃并在下次获取时重建
+// onFailure 每次检查失败回调，用于日志及指标记录
+func StartDBHealthCheck(ctx context.Context, db *sql.DB, interval time.Duration, onFailure func(err error)) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pingCtx, cancel := context.WithTimeout(ctx, interval)
+				err := db.PingContext(pingCtx)
+				cancel()
+				if err != nil && onFailure != nil {
+					onFailure(err)
+				}
+			}
+		}
+	}()
+}
+
+// WaitDBHealthy 连接中断后按指数退避 Ping，直至连接恢复、ctx 取消或者超过 timeout，用于 chunk 重新分发前等待数据库故障切换完成
+func WaitDBHealthy(ctx context.Context, policy RetryPolicy, timeout time.Duration, db *sql.DB) error {
+	if timeout <= 0 {
+		timeout = time.Duration(DefaultReconnectTimeout) * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	backoff := policy.Backoff
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("wait database connection healthy timeout [%v]: %v", timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = backoff * 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
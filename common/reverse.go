@@ -24,6 +24,15 @@ const (
 	MySQLCheckConsVersion = "8.0.15"
 	// MySQL 表达式索引版本 > 8.0.0
 	MySQLExpressionIndexVersion = "8.0.0"
+	// MySQL functional key parts 表达式索引版本 >= 8.0.13
+	MySQLFunctionalIndexVersion = "8.0.13"
+	// TiDB 表达式索引版本 >= 5.2.0
+	TiDBExpressionIndexVersion = "5.2.0"
+	// TiDB 版本前缀，VERSION() 格式 5.7.25-TiDB-v6.5.0
+	TiDBVersionPrefix = "TiDB-v"
+	// MySQL/TiDB 表注释、字段注释最大字符长度，超出截断
+	MySQLTableCommentMaxLength  = 2048
+	MySQLColumnCommentMaxLength = 1024
 	// MySQL 版本分隔符号
 	MySQLVersionDelimiter = "-"
 
@@ -31,6 +40,10 @@ const (
 	// 需要 oracle 12.2g 及以上
 	OracleTableColumnCollationDBVersion = "12.2"
 
+	// Oracle identity 字段
+	// 需要 oracle 12c 及以上
+	OracleIdentityColumnDBVersion = "12"
+
 	// Oracle 用户、表、字段默认使用 DB 排序规则
 	OracleUserTableColumnDefaultCollation = "USING_NLS_COMP"
 
@@ -43,11 +56,69 @@ const (
 	JSONPartition    = "PARTITION"
 )
 
+const (
+	// reverse index-compatible-mode MySQL/TiDB 不支持索引（函数索引、位图索引、反向键索引、DOMAIN 索引）处理方式
+	// compatible 输出 Oracle 原索引语句到不兼容性文件，skip 忽略并告警，convert 尽可能转换，无法转换输出到不兼容性文件
+	ReverseIndexModeCompatible = "compatible"
+	ReverseIndexModeSkip       = "skip"
+	ReverseIndexModeConvert    = "convert"
+
+	// reverse foreign-key-mode 外键处理方式
+	// inline 全部表创建完成之后按依赖顺序创建外键，defer 外键输出到单独文件 foreign_key_${source_schema}.sql，待数据迁移完成之后手工执行
+	ReverseForeignKeyModeInline = "inline"
+	ReverseForeignKeyModeDefer  = "defer"
+
+	// reverse virtual-column-mode 虚拟列处理方式
+	// generated 转换 MySQL/TiDB 生成列，表达式无法转换输出到不兼容性文件，skip 不创建虚拟列并输出到不兼容性文件
+	// 两种方式全量/csv 数据抽取均排除虚拟列
+	ReverseVirtualColumnModeGenerated = "generated"
+	ReverseVirtualColumnModeSkip      = "skip"
+
+	// reverse interval-mode INTERVAL 字段处理方式
+	// string TO_CHAR 字符串 VARCHAR(30)，numeric YEAR TO MONTH 转换总月数 BIGINT，DAY TO SECOND 转换总秒数 DECIMAL
+	ReverseIntervalModeString  = "string"
+	ReverseIntervalModeNumeric = "numeric"
+
+	// reverse raw-mode RAW 字段处理方式
+	// binary 二进制 VARBINARY，hex RAWTOHEX 十六进制字符串 VARCHAR(2*N)，LONG RAW SQL 无法 RAWTOHEX 沿用二进制
+	ReverseRawModeBinary = "binary"
+	ReverseRawModeHex    = "hex"
+
+	// reverse rowid-mode ROWID/UROWID 字段处理方式
+	// string 字符串 VARCHAR，skip 不创建以及不迁移（源端物理地址下游无意义）并输出到不兼容性文件
+	// BFILE 仅存储数据库外部文件定位符，始终不创建以及不迁移并输出到不兼容性文件
+	ReverseRowidModeString = "string"
+	ReverseRowidModeSkip   = "skip"
+
+	// reverse materialized-view-mode 物化视图处理方式，migrate-config 可按物化视图单独指定
+	// table 按普通表转换并迁移物化视图数据，view 物化视图定义查询转换 MySQL/TiDB 视图，skip 输出到不兼容性文件不做转换
+	// view/skip 两种方式全量/csv/数据校验均排除物化视图
+	ReverseMaterializedViewModeTable = "table"
+	ReverseMaterializedViewModeView  = "view"
+	ReverseMaterializedViewModeSkip  = "skip"
+
+	// reverse number-unconstrained-type 未指定精度 number 默认映射类型
+	// number-sample-percent 未指定精度 number 字段数据采样百分比默认值
+	ReverseNumberUnconstrainedType      = "DECIMAL(65,30)"
+	ReverseNumberSamplePercent          = 10
+	ReverseNumberSampleIntegerType      = "BIGINT"
+	ReverseNumberSampleDecimalType      = "DECIMAL(65,0)"
+	ReverseNumberUnconstrainedPrecision = "38"
+	ReverseNumberUnconstrainedScale     = "127"
+)
+
 const (
 	// TiDB 数据库
 	TiDBClusteredIndexIntOnlyValue = "INT_ONLY"
 	TiDBClusteredIndexONValue      = "ON"
 	TiDBClusteredIndexOFFValue     = "OFF"
+
+	// reverse tidb-clustered-index 主键聚簇索引可选项，为空沿用 tidb_enable_clustered_index
+	TiDBPrimaryKeyClustered    = "CLUSTERED"
+	TiDBPrimaryKeyNonClustered = "NONCLUSTERED"
+
+	// AUTO_RANDOM 默认 shard bits
+	DefaultTiDBAutoRandomBits = 5
 )
 
 // alter-primary-key = fase 主键整型数据类型列表
@@ -64,6 +135,8 @@ var (
 	ORACLECharsetZHT16BIG5    = "ZHT16BIG5"
 	ORACLECharsetZHS16GBK     = "ZHS16GBK"
 	ORACLECharsetZHS32GB18030 = "ZHS32GB18030"
+	ORACLECharsetWE8ISO8859P1 = "WE8ISO8859P1"
+	ORACLECharsetWE8MSWIN1252 = "WE8MSWIN1252"
 )
 
 // 数据迁移、数据校验、表结构默认值、注释
@@ -75,6 +148,17 @@ const (
 	CharsetGB18030 = "GB18030"
 	CharsetBIG5    = "BIG5"
 	CharsetGBK     = "GBK"
+	// 仅用于源端 Oracle 单字节字符集数据解码
+	CharsetISO88591 = "ISO88591"
+	CharsetCP1252   = "CP1252"
+)
+
+// 字符集转换非法字符处理方式
+//   - REPLACE 非法字符替换（解码 U+FFFD，编码替换字符）
+//   - STRICT 存在非法字符报错
+const (
+	CharsetErrorModeReplace = "REPLACE"
+	CharsetErrorModeStrict  = "STRICT"
 )
 
 var MigrateDataSupportCharset = []string{CharsetUTF8MB4, CharsetGBK, CharsetBIG5, CharsetGB18030}
@@ -84,6 +168,8 @@ var MigrateOracleCharsetStringConvertMapping = map[string]string{
 	ORACLECharsetZHT16BIG5:    CharsetBIG5,
 	ORACLECharsetZHS16GBK:     CharsetGBK,
 	ORACLECharsetZHS32GB18030: CharsetGB18030,
+	ORACLECharsetWE8ISO8859P1: CharsetISO88591,
+	ORACLECharsetWE8MSWIN1252: CharsetCP1252,
 }
 
 var MigrateMYSQLCompatibleCharsetStringConvertMapping = map[string]string{
@@ -102,6 +188,8 @@ var MigrateTableStructureDatabaseCharsetMap = map[string]map[string]string{
 		ORACLECharsetZHT16BIG5:    MYSQLCharsetBIG5,
 		ORACLECharsetZHS16GBK:     MYSQLCharsetGBK,
 		ORACLECharsetZHS32GB18030: MYSQLCharsetGB18030,
+		ORACLECharsetWE8ISO8859P1: MYSQLCharsetUTF8MB4,
+		ORACLECharsetWE8MSWIN1252: MYSQLCharsetUTF8MB4,
 	},
 	// TiDB 表结构以及字段属性统一使用 UTF8MB4 字符集，适用于 check、compare、reverse 模式下 o2t、t2o
 	TaskTypeOracle2TiDB: {
@@ -109,6 +197,8 @@ var MigrateTableStructureDatabaseCharsetMap = map[string]map[string]string{
 		ORACLECharsetZHT16BIG5:    MYSQLCharsetUTF8MB4,
 		ORACLECharsetZHS16GBK:     MYSQLCharsetUTF8MB4,
 		ORACLECharsetZHS32GB18030: MYSQLCharsetUTF8MB4,
+		ORACLECharsetWE8ISO8859P1: MYSQLCharsetUTF8MB4,
+		ORACLECharsetWE8MSWIN1252: MYSQLCharsetUTF8MB4,
 	},
 	TaskTypeMySQL2Oracle: {
 		MYSQLCharsetUTF8MB4: ORACLECharsetAL32UTF8,
@@ -346,6 +436,13 @@ const (
 	MigrateTableStructFieldNameUpperCase  = "2"
 )
 
+// MySQL/TiDB 标识符（库名、表名、字段名、索引以及约束名）最大长度
+// lower_case_table_names 非 0 时 MySQL 库表名按小写存储以及比较
+const (
+	MySQLIdentifierMaxLength              = 64
+	MySQLLowerCaseTableNamesCaseSensitive = "0"
+)
+
 // Table Attr Null 以及空字符串特殊处理
 const (
 	OracleNULLSTRINGTableAttrWithoutNULL = "NULLSTRING"
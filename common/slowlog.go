@@ -0,0 +1,56 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"go.uber.org/zap"
+	"time"
+)
+
+// SlowLog 抽取/写入慢 SQL 记录，耗时超过阈值的语句连同影响行数写入独立慢日志文件
+// nil SlowLog 代表不记录
+type SlowLog struct {
+	logger    *zap.Logger
+	source    string
+	threshold time.Duration
+}
+
+// NewSlowLog 根据配置生成慢 SQL 记录器，source 为 source/target 标识，thresholdMS 单位：毫秒，慢日志未开启或者阈值小于等于 0 返回 nil
+func NewSlowLog(logger *zap.Logger, source string, thresholdMS int) *SlowLog {
+	if logger == nil || thresholdMS <= 0 {
+		return nil
+	}
+	return &SlowLog{
+		logger:    logger,
+		source:    source,
+		threshold: time.Duration(thresholdMS) * time.Millisecond,
+	}
+}
+
+// Observe 语句耗时超过阈值写入慢日志，批量写入语句超过 SlowLogSQLMaxLength 截断
+func (s *SlowLog) Observe(sql string, cost time.Duration, rows int64) {
+	if s == nil || cost < s.threshold {
+		return
+	}
+	if len(sql) > SlowLogSQLMaxLength {
+		sql = StringsBuilder(sql[:SlowLogSQLMaxLength], "...")
+	}
+	s.logger.Warn("slow query",
+		zap.String("source", s.source),
+		zap.String("cost", cost.String()),
+		zap.Int64("rows", rows),
+		zap.String("sql", sql))
+}
@@ -17,11 +17,14 @@ package common
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/encoding/traditionalchinese"
 	"golang.org/x/text/transform"
+	"hash/crc32"
 	"io"
 	"os"
 	"reflect"
@@ -380,58 +383,61 @@ func DiffStructArray(structA, structB interface{}) ([]interface{}, []interface{}
 }
 
 func CharsetConvert(data []byte, fromCharset, toCharset string) ([]byte, error) {
-	switch {
-	case strings.EqualFold(fromCharset, CharsetUTF8MB4) && strings.EqualFold(toCharset, CharsetGBK):
-		reader := transform.NewReader(bytes.NewReader(data), encoding.ReplaceUnsupported(simplifiedchinese.GBK.NewEncoder()))
-		gbkBytes, err := io.ReadAll(reader)
-		if err != nil {
-			return nil, err
-		}
-		return gbkBytes, nil
-
-	case strings.EqualFold(fromCharset, CharsetUTF8MB4) && strings.EqualFold(toCharset, CharsetGB18030):
-		reader := transform.NewReader(bytes.NewReader(data), encoding.ReplaceUnsupported(simplifiedchinese.GB18030.NewEncoder()))
-		gbk18030Bytes, err := io.ReadAll(reader)
-		if err != nil {
-			return nil, err
-		}
-		return gbk18030Bytes, nil
+	return CharsetConvertByMode(data, fromCharset, toCharset, CharsetErrorModeReplace)
+}
 
-	case strings.EqualFold(fromCharset, CharsetUTF8MB4) && strings.EqualFold(toCharset, CharsetBIG5):
-		reader := transform.NewReader(bytes.NewReader(data), encoding.ReplaceUnsupported(traditionalchinese.Big5.NewEncoder()))
-		bigBytes, err := io.ReadAll(reader)
-		if err != nil {
-			return nil, err
-		}
-		return bigBytes, nil
+// 字符集对应编码，UTF8MB4 无需编码转换
+var charsetEncodings = map[string]encoding.Encoding{
+	CharsetGBK:      simplifiedchinese.GBK,
+	CharsetGB18030:  simplifiedchinese.GB18030,
+	CharsetBIG5:     traditionalchinese.Big5,
+	CharsetISO88591: charmap.ISO8859_1,
+	CharsetCP1252:   charmap.Windows1252,
+}
 
+// CharsetConvertByMode 字符集转换，只支持 UTF8MB4 与其他字符集之间互转
+// errorMode STRICT 非法或者无法表示字符报错，其余非法字符替换
+func CharsetConvertByMode(data []byte, fromCharset, toCharset, errorMode string) ([]byte, error) {
+	strict := strings.EqualFold(errorMode, CharsetErrorModeStrict)
+	switch {
 	case strings.EqualFold(fromCharset, CharsetUTF8MB4) && strings.EqualFold(toCharset, CharsetUTF8MB4):
+		if strict && !utf8.Valid(data) {
+			return nil, fmt.Errorf("charset [%v] data [%v] contains invalid byte sequence", fromCharset, hex.EncodeToString(data))
+		}
 		return data, nil
 
-	case strings.EqualFold(fromCharset, CharsetGBK) && strings.EqualFold(toCharset, CharsetUTF8MB4):
-		decoder := simplifiedchinese.GBK.NewDecoder()
-		utf8Data, err := decoder.Bytes(data)
-		if err != nil {
-			return nil, err
+	case strings.EqualFold(fromCharset, CharsetUTF8MB4):
+		enc, ok := charsetEncodings[StringUPPER(toCharset)]
+		if !ok {
+			return nil, fmt.Errorf("from charset [%v], to charset [%v] convert isn't support", fromCharset, toCharset)
 		}
-
-		return utf8Data, nil
-
-	case strings.EqualFold(fromCharset, CharsetGB18030) && strings.EqualFold(toCharset, CharsetUTF8MB4):
-		decoder := simplifiedchinese.GB18030.NewDecoder()
-		utf8Data, err := decoder.Bytes(data)
+		if strict {
+			targetBytes, err := enc.NewEncoder().Bytes(data)
+			if err != nil {
+				return nil, fmt.Errorf("charset [%v] data [%s] convert to charset [%v] failed: %v", fromCharset, data, toCharset, err)
+			}
+			return targetBytes, nil
+		}
+		reader := transform.NewReader(bytes.NewReader(data), encoding.ReplaceUnsupported(enc.NewEncoder()))
+		targetBytes, err := io.ReadAll(reader)
 		if err != nil {
 			return nil, err
 		}
-		return utf8Data, nil
+		return targetBytes, nil
 
-	case strings.EqualFold(fromCharset, CharsetBIG5) && strings.EqualFold(toCharset, CharsetUTF8MB4):
-		decoder := traditionalchinese.Big5.NewDecoder()
-		utf8Data, err := decoder.Bytes(data)
+	case strings.EqualFold(toCharset, CharsetUTF8MB4):
+		enc, ok := charsetEncodings[StringUPPER(fromCharset)]
+		if !ok {
+			return nil, fmt.Errorf("from charset [%v], to charset [%v] convert isn't support", fromCharset, toCharset)
+		}
+		utf8Data, err := enc.NewDecoder().Bytes(data)
 		if err != nil {
 			return nil, err
 		}
-
+		// 解码器非法字节统一替换为 U+FFFD
+		if strict && bytes.ContainsRune(utf8Data, utf8.RuneError) {
+			return nil, fmt.Errorf("charset [%v] data [%v] contains invalid byte sequence", fromCharset, hex.EncodeToString(data))
+		}
 		return utf8Data, nil
 
 	default:
@@ -456,6 +462,15 @@ func SpecialLettersUsingMySQL(bs []byte) string {
 		chars []rune
 	)
 	for _, r := range bytes.Runes(bs) {
+		// mysql/tidb NUL 以及 Ctrl+Z 控制字符转义 \0 、\Z
+		if r == 0 {
+			chars = append(chars, '\\', '0')
+			continue
+		}
+		if r == '\x1a' {
+			chars = append(chars, '\\', 'Z')
+			continue
+		}
 		if unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) {
 			// mysql/tidb % 字符, /% 代表 /%，% 代表 % ,无需转义
 			// mysql/tidb _ 字符, /_ 代表 /_，_ 代表 _ ,无需转义
@@ -474,6 +489,219 @@ func SpecialLettersUsingMySQL(bs []byte) string {
 	return b.String()
 }
 
+// PostgreSQL 标识符双引号引用，保留大小写，内部双引号转义为两个双引号
+func QuotePostgreSQLIdentifier(name string) string {
+	return StringsBuilder(`"`, strings.ReplaceAll(name, `"`, `""`), `"`)
+}
+
+// PostgreSQL 未引用标识符统一小写，lower-case-field-name 控制表结构标识符大小写（同 reverse），配合双引号引用
+func PostgreSQLIdentifierCase(lowerCaseFieldName, name string) string {
+	switch {
+	case strings.EqualFold(lowerCaseFieldName, MigrateTableStructFieldNameLowerCase):
+		return strings.ToLower(name)
+	case strings.EqualFold(lowerCaseFieldName, MigrateTableStructFieldNameUpperCase):
+		return strings.ToUpper(name)
+	default:
+		return name
+	}
+}
+
+// MySQL/TiDB 标识符反引号引用，内部反引号转义为两个反引号，避免关键字（ORDER、GROUP 等）以及大小写混合标识符语法错误
+func QuoteMySQLIdentifier(name string) string {
+	return StringsBuilder("`", strings.ReplaceAll(name, "`", "``"), "`")
+}
+
+// MySQL/TiDB 库名.表名反引号引用
+func QuoteMySQLTableName(schemaName, tableName string) string {
+	return StringsBuilder(QuoteMySQLIdentifier(schemaName), ".", QuoteMySQLIdentifier(tableName))
+}
+
+// MySQL/TiDB 标识符超过 64 字符限制重命名建议，截断保留前缀并追加原名 CRC32 后缀保证唯一
+func MySQLIdentifierRenameSuggest(name string) string {
+	if utf8.RuneCountInString(name) <= MySQLIdentifierMaxLength {
+		return name
+	}
+	suffix := fmt.Sprintf("_%08x", crc32.ChecksumIEEE([]byte(name)))
+	prefix := []rune(name)[:MySQLIdentifierMaxLength-len(suffix)]
+	return StringsBuilder(strings.TrimRight(string(prefix), "_"), suffix)
+}
+
+// ClickHouse 标识符反引号引用，内部反引号以及反斜杠转义
+func QuoteClickHouseIdentifier(name string) string {
+	return StringsBuilder("`", strings.ReplaceAll(strings.ReplaceAll(name, `\`, `\\`), "`", "\\`"), "`")
+}
+
+// ClickHouse 标识符大小写敏感，lower-case-field-name 控制表结构标识符大小写（同 reverse）
+func ClickHouseIdentifierCase(lowerCaseFieldName, name string) string {
+	switch {
+	case strings.EqualFold(lowerCaseFieldName, MigrateTableStructFieldNameLowerCase):
+		return strings.ToLower(name)
+	case strings.EqualFold(lowerCaseFieldName, MigrateTableStructFieldNameUpperCase):
+		return strings.ToUpper(name)
+	default:
+		return name
+	}
+}
+
+// PostgreSQL COPY text 格式字段值转义，反斜杠、制表符、换行符以及回车符转义
+// PostgreSQL 字符串不支持 NUL 字符，NUL 字符按空处理
+func SpecialLettersUsingPostgreSQLCopy(bs []byte) string {
+	var b strings.Builder
+	for _, r := range bytes.Runes(bs) {
+		switch r {
+		case 0:
+			continue
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SQL 字面量值转换 LOAD DATA 字段值（FIELDS TERMINATED BY '\t' ESCAPED BY '\\'）
+// - NULL -> \N
+// - X'hex' -> 二进制数据转义 \\、\t、\n、\0
+// - 'xxx' -> 去除引号，SpecialLettersUsingMySQL 反斜杠转义内容 LOAD DATA 同样适用
+// - 数值 -> 原值
+func SQLValueToLoadDataField(val string) (string, error) {
+	switch {
+	case val == "NULL":
+		return `\N`, nil
+	case len(val) >= 3 && strings.HasPrefix(val, "X'") && strings.HasSuffix(val, "'"):
+		bs, err := hex.DecodeString(val[2 : len(val)-1])
+		if err != nil {
+			return val, fmt.Errorf("load data field hex value decode failed: %v", err)
+		}
+		var b strings.Builder
+		for _, c := range bs {
+			switch c {
+			case '\\':
+				b.WriteString(`\\`)
+			case '\t':
+				b.WriteString(`\t`)
+			case '\n':
+				b.WriteString(`\n`)
+			case 0:
+				b.WriteString(`\0`)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return b.String(), nil
+	case len(val) >= 2 && strings.HasPrefix(val, "'") && strings.HasSuffix(val, "'"):
+		return val[1 : len(val)-1], nil
+	default:
+		return val, nil
+	}
+}
+
+// SQL 字面量值转换消息字段值，用于变更事件发布
+// - NULL -> nil
+// - X'hex' -> []byte
+// - 'xxx' -> 去除引号以及反斜杠转义（SpecialLettersUsingMySQL），连续两个单引号还原为单引号
+// - 数值以及其他表达式 -> 原值
+func SQLValueToMessageValue(val string) (interface{}, error) {
+	switch {
+	case strings.EqualFold(val, "NULL"):
+		return nil, nil
+	case len(val) >= 3 && (strings.HasPrefix(val, "X'") || strings.HasPrefix(val, "x'")) && strings.HasSuffix(val, "'"):
+		bs, err := hex.DecodeString(val[2 : len(val)-1])
+		if err != nil {
+			return val, fmt.Errorf("message field hex value decode failed: %v", err)
+		}
+		return bs, nil
+	case len(val) >= 2 && strings.HasPrefix(val, "'") && strings.HasSuffix(val, "'"):
+		var (
+			b     strings.Builder
+			chars = []rune(val[1 : len(val)-1])
+		)
+		for i := 0; i < len(chars); i++ {
+			switch {
+			case chars[i] == '\\' && i+1 < len(chars):
+				i++
+				switch chars[i] {
+				case '0':
+					b.WriteRune(0)
+				case 'Z':
+					b.WriteRune('\x1a')
+				default:
+					b.WriteRune(chars[i])
+				}
+			case chars[i] == '\'' && i+1 < len(chars) && chars[i+1] == '\'':
+				i++
+				b.WriteRune('\'')
+			default:
+				b.WriteRune(chars[i])
+			}
+		}
+		return b.String(), nil
+	default:
+		return val, nil
+	}
+}
+
+// Oracle 分区 HIGH_VALUE 转换 MySQL RANGE/LIST COLUMNS 分区值
+// - MAXVALUE、NULL、数值、'xxx' -> 原值
+// - TO_DATE(' 2020-01-01 00:00:00', ...)、TIMESTAMP' 2020-01-01 00:00:00' -> '2020-01-01 00:00:00'
+// - 多值以逗号分隔，其他表达式不支持
+func OraclePartitionHighValueToMySQL(highValue string) (string, error) {
+	var (
+		items   []string
+		item    strings.Builder
+		depth   int
+		inQuote bool
+	)
+	for _, c := range highValue {
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+		case c == '(' && !inQuote:
+			depth++
+		case c == ')' && !inQuote:
+			depth--
+		case c == ',' && !inQuote && depth == 0:
+			items = append(items, item.String())
+			item.Reset()
+			continue
+		}
+		item.WriteRune(c)
+	}
+	items = append(items, item.String())
+
+	var values []string
+	for _, it := range items {
+		val := strings.TrimSpace(it)
+		upperVal := strings.ToUpper(val)
+		switch {
+		case upperVal == "MAXVALUE" || upperVal == "NULL" || IsNum(val):
+			values = append(values, val)
+		case strings.HasPrefix(upperVal, "TO_DATE(") || strings.HasPrefix(upperVal, "TIMESTAMP"):
+			start := strings.Index(val, "'")
+			if start < 0 {
+				return "", fmt.Errorf("oracle partition high value [%s] isn't support", val)
+			}
+			end := strings.Index(val[start+1:], "'")
+			if end < 0 {
+				return "", fmt.Errorf("oracle partition high value [%s] isn't support", val)
+			}
+			values = append(values, StringsBuilder("'", strings.TrimSpace(val[start+1:start+1+end]), "'"))
+		case len(val) >= 2 && strings.HasPrefix(val, "'") && strings.HasSuffix(val, "'"):
+			values = append(values, val)
+		default:
+			return "", fmt.Errorf("oracle partition high value [%s] isn't support", val)
+		}
+	}
+	return strings.Join(values, ","), nil
+}
+
 func SpecialLettersUsingOracle(bs []byte) string {
 
 	var (
@@ -508,3 +736,12 @@ func PathExist(path string) error {
 	}
 	return err
 }
+
+// TruncateComment 按字符长度截断注释，返回是否截断
+func TruncateComment(comment []byte, maxLength int) ([]byte, bool) {
+	runes := bytes.Runes(comment)
+	if len(runes) <= maxLength {
+		return comment, false
+	}
+	return []byte(string(runes[:maxLength])), true
+}
@@ -23,11 +23,65 @@ const (
 	MySQLMaxConn         = 1024
 	MySQLConnMaxLifeTime = 300 * time.Second
 	MySQLConnMaxIdleTime = 200 * time.Second
+	// 驱动 TLS 配置注册名称
+	MySQLTLSConfigName = "transferdb"
+)
+
+// PostgreSQL 连接配置
+const (
+	PostgreSQLMaxIdleConn     = 512
+	PostgreSQLMaxConn         = 1024
+	PostgreSQLConnMaxLifeTime = 300 * time.Second
+	PostgreSQLConnMaxIdleTime = 200 * time.Second
+)
+
+// SQLServer 连接配置
+const (
+	SQLServerMaxIdleConn     = 512
+	SQLServerMaxConn         = 1024
+	SQLServerConnMaxLifeTime = 300 * time.Second
+	SQLServerConnMaxIdleTime = 200 * time.Second
+	// CDC 增量轮询默认间隔，单位：秒
+	SQLServerCDCDefaultInterval = 5
+)
+
+// ClickHouse 连接配置
+const (
+	ClickHouseMaxIdleConn     = 5
+	ClickHouseMaxConn         = 64
+	ClickHouseConnMaxLifeTime = 3600 * time.Second
+	ClickHouseDialTimeout     = 30 * time.Second
+	// 建表默认表引擎
+	ClickHouseDefaultEngine = "MergeTree()"
+)
+
+// Oracle 连接会话配置
+const (
+	// 会话 NLS_NUMERIC_CHARACTERS 默认值，小数点 '.'，千分位 ','
+	OracleNLSNumericCharacters = ".,"
+	// TCPS 加密传输协议
+	OracleProtocolTCPS = "TCPS"
+	OracleProtocolTCP  = "TCP"
+	// ADG 备库数据库角色以及打开模式
+	OracleDatabaseRolePhysicalStandby = "PHYSICAL STANDBY"
+	OracleOpenModeReadOnly            = "READ ONLY"
+	// 连接池最大空闲连接数默认值，max-idle-conns 为 0 生效
+	OracleMaxIdleConn = 512
 )
 
 // 任务并发通道 Channle Size
 const ChannelBufferSize = 1024
 
+// schema 级别元数据流式查询批次行数
+const OracleQueryStreamBatchSize = 512
+
+// 自适应批次默认值，目标耗时单位：毫秒，最大行数默认 insert-batch-size 倍数
+const (
+	AdaptiveBatchDefaultLatency       = 500
+	AdaptiveBatchDefaultMinRows       = 16
+	AdaptiveBatchDefaultMaxRowsFactor = 8
+)
+
 // 任务模式
 const (
 	TaskModePrepare = "PREPARE"
@@ -38,6 +92,12 @@ const (
 	TaskModeCSV     = "CSV"
 	TaskModeFull    = "FULL"
 	TaskModeAll     = "ALL"
+	TaskModeStatus  = "STATUS"
+	TaskModeServer  = "SERVER"
+	TaskModePause   = "PAUSE"
+	TaskModeResume  = "RESUME"
+	// 运行环境预检查，不登记任务元数据
+	TaskModeCheckEnv = "CHECK-ENV"
 )
 
 // 任务状态
@@ -46,6 +106,30 @@ const (
 	TaskStatusRunning = "RUNNING"
 	TaskStatusSuccess = "SUCCESS"
 	TaskStatusFailed  = "FAILED"
+	TaskStatusStopped = "STOPPED"
+	TaskStatusPaused  = "PAUSED"
+)
+
+// server 模式接口任务状态
+const (
+	APITaskStatusCreated = "CREATED"
+	APITaskStatusRunning = "RUNNING"
+	APITaskStatusStopped = "STOPPED"
+	APITaskStatusPaused  = "PAUSED"
+	APITaskStatusSuccess = "SUCCESS"
+	APITaskStatusFailed  = "FAILED"
+)
+
+// 任务 ID，元数据库 task_id 字段长度 varchar(64)
+const (
+	TaskIDMaxLength = 64
+)
+
+// 任务暂停状态同步间隔，运行中任务定时读取元数据表 task_meta 任务状态
+const (
+	TaskPausePollInterval = 5 * time.Second
+	// 运行时间窗口检查间隔
+	TimeWindowCheckInterval = 30 * time.Second
 )
 
 // 任务初始值
@@ -58,9 +142,12 @@ const (
 
 // 任务 DB 类型
 const (
-	DatabaseTypeOracle = "ORACLE"
-	DatabaseTypeTiDB   = "TIDB"
-	DatabaseTypeMySQL  = "MYSQL"
+	DatabaseTypeOracle     = "ORACLE"
+	DatabaseTypeTiDB       = "TIDB"
+	DatabaseTypeMySQL      = "MYSQL"
+	DatabaseTypePostgreSQL = "POSTGRESQL"
+	DatabaseTypeSQLServer  = "SQLSERVER"
+	DatabaseTypeClickHouse = "CLICKHOUSE"
 )
 
 // 任务类型
@@ -69,4 +156,68 @@ const (
 	TaskTypeOracle2TiDB  = "ORACLE2TIDB"
 	TaskTypeMySQL2Oracle = "MYSQL2ORACLE"
 	TaskTypeTiDB2Oracle  = "TIDB2ORACLE"
+
+	TaskTypeOracle2PostgreSQL = "ORACLE2POSTGRESQL"
+	TaskTypeSQLServer2MySQL   = "SQLSERVER2MYSQL"
+	TaskTypeOracle2ClickHouse = "ORACLE2CLICKHOUSE"
+)
+
+// 源端增量变更操作类型
+const (
+	ChangeOperationInsert = "INSERT"
+	ChangeOperationUpdate = "UPDATE"
+	ChangeOperationDelete = "DELETE"
+	// 全量数据行
+	ChangeOperationRead = "READ"
+)
+
+// 链路追踪导出方式
+const (
+	TraceExporterOTLP   = "OTLP"
+	TraceExporterJaeger = "JAEGER"
+
+	TraceDefaultServiceName = "transferdb"
+	TraceDefaultSampleRatio = 1.0
+)
+
+// 任务结束汇总报告 warnings/skipped 明细最多记录条数
+const ReportDetailLimit = 1000
+
+// 日志输出格式以及日志级别
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+var LogLevels = []string{"DEBUG", "INFO", "WARN", "ERROR", "DPANIC", "PANIC", "FATAL"}
+
+// 慢 SQL 日志
+const (
+	SlowLogSource       = "source"
+	SlowLogTarget       = "target"
+	SlowLogSQLMaxLength = 2048
+)
+
+// 目标端 DDL 审计
+const (
+	DDLAuditStatusSuccess = "SUCCESS"
+	DDLAuditStatusFailed  = "FAILED"
+	DDLAuditTimeFormat    = "2006-01-02 15:04:05.000"
+)
+
+var DDLAuditOperations = []string{"CREATE", "DROP", "TRUNCATE", "ALTER", "RENAME"}
+
+// check-env 运行环境预检查
+const (
+	CheckEnvResultPass = "PASS"
+	CheckEnvResultWarn = "WARN"
+	CheckEnvResultFail = "FAIL"
+
+	RequireMySQLDBVersion = "5.7"
+	RequireTiDBDBVersion  = "4.0"
+
+	// 输出目录最小可用空间，单位 MB
+	CheckEnvMinDiskFreeMB = 1024
+	// 目标端 max_allowed_packet 最小建议值，单位字节
+	CheckEnvMinMaxAllowedPacket = 16 * 1024 * 1024
 )
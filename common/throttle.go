@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"sync/atomic"
+)
+
+// Throttle 行数以及字节数限速，令牌桶容量等于每秒速率，进程内同一数据库引擎所有表/chunk 共享
+// nil Throttle 或者速率小于等于 0 代表不限速
+type Throttle struct {
+	rows  atomic.Pointer[rate.Limiter]
+	bytes atomic.Pointer[rate.Limiter]
+}
+
+// NewThrottle 根据配置生成限速器，rowsPerSecond 单位：行/秒，mbPerSecond 单位：MB/秒，都不限速返回 nil
+func NewThrottle(rowsPerSecond, mbPerSecond int) *Throttle {
+	if rowsPerSecond <= 0 && mbPerSecond <= 0 {
+		return nil
+	}
+	t := &Throttle{}
+	t.SetRate(rowsPerSecond, mbPerSecond)
+	return t
+}
+
+// SetRate 运行中调整限速，即时生效，速率小于等于 0 关闭对应限速
+func (t *Throttle) SetRate(rowsPerSecond, mbPerSecond int) {
+	if t == nil {
+		return
+	}
+	t.rows.Store(newLimiter(rowsPerSecond))
+	t.bytes.Store(newLimiter(mbPerSecond * 1024 * 1024))
+}
+
+func newLimiter(limit int) *rate.Limiter {
+	if limit <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(limit), limit)
+}
+
+// Wait 等待 rows 行以及 bytes 字节令牌，超过令牌桶容量按容量分批等待
+func (t *Throttle) Wait(ctx context.Context, rows, bytes int) error {
+	if t == nil {
+		return nil
+	}
+	if err := waitN(ctx, t.rows.Load(), rows); err != nil {
+		return err
+	}
+	return waitN(ctx, t.bytes.Load(), bytes)
+}
+
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	if limiter == nil {
+		return nil
+	}
+	for n > 0 {
+		batch := n
+		if batch > limiter.Burst() {
+			batch = limiter.Burst()
+		}
+		if err := limiter.WaitN(ctx, batch); err != nil {
+			return err
+		}
+		n -= batch
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// TuningParams 运行中任务可热更新调优参数，SIGHUP 重新读取配置文件或者 server 模式接口 tune 调整
+// - table-threads/sql-threads/apply-threads 对应 [full] 同名配置项，incr-apply-threads 对应 [all] apply-threads
+// - insert-batch-size 以及限速对应 [app] 同名配置项
+type TuningParams struct {
+	TableThreads         int `json:"table-threads"`
+	SQLThreads           int `json:"sql-threads"`
+	ApplyThreads         int `json:"apply-threads"`
+	IncrApplyThreads     int `json:"incr-apply-threads"`
+	InsertBatchSize      int `json:"insert-batch-size"`
+	ExtractRowsPerSecond int `json:"extract-rows-per-second"`
+	ExtractMBPerSecond   int `json:"extract-mb-per-second"`
+	ApplyRowsPerSecond   int `json:"apply-rows-per-second"`
+	ApplyMBPerSecond     int `json:"apply-mb-per-second"`
+}
+
+// validate 只校验调整的参数，当前模式未使用的参数（例如 full 模式 [all] apply-threads）允许保持未配置
+func (p TuningParams) validate(old TuningParams) error {
+	for _, v := range []struct {
+		name     string
+		old, new int
+	}{
+		{"table-threads", old.TableThreads, p.TableThreads},
+		{"sql-threads", old.SQLThreads, p.SQLThreads},
+		{"apply-threads", old.ApplyThreads, p.ApplyThreads},
+		{"incr-apply-threads", old.IncrApplyThreads, p.IncrApplyThreads},
+		{"insert-batch-size", old.InsertBatchSize, p.InsertBatchSize},
+	} {
+		if v.new != v.old && v.new <= 0 {
+			return fmt.Errorf("tuning param [%s] value [%d] must be greater than 0", v.name, v.new)
+		}
+	}
+	if p.ExtractRowsPerSecond < 0 || p.ExtractMBPerSecond < 0 || p.ApplyRowsPerSecond < 0 || p.ApplyMBPerSecond < 0 {
+		return fmt.Errorf("tuning param rate limits can not be less than 0, params: %+v", p)
+	}
+	return nil
+}
+
+// Tuning 任务运行时调优参数，限速即时生效，线程数以及批次大小在拉取下一张表/chunk 时生效，进行中表/chunk 不受影响
+type Tuning struct {
+	mu      sync.Mutex
+	params  TuningParams
+	extract *Throttle
+	apply   *Throttle
+
+	// 运行中表数以及 table-threads 调整唤醒
+	tableRunning int
+	tableCh      chan struct{}
+}
+
+// NewTuning 生成任务调优参数，抽取以及写入限速器不限速同样生成，便于运行中开启限速
+func NewTuning(params TuningParams) *Tuning {
+	t := &Tuning{
+		params:  params,
+		extract: &Throttle{},
+		apply:   &Throttle{},
+		tableCh: make(chan struct{}),
+	}
+	t.extract.SetRate(params.ExtractRowsPerSecond, params.ExtractMBPerSecond)
+	t.apply.SetRate(params.ApplyRowsPerSecond, params.ApplyMBPerSecond)
+	return t
+}
+
+// ExtractThrottle 源端抽取限速器
+func (t *Tuning) ExtractThrottle() *Throttle {
+	return t.extract
+}
+
+// ApplyThrottle 目标端写入限速器
+func (t *Tuning) ApplyThrottle() *Throttle {
+	return t.apply
+}
+
+// Params 当前调优参数
+func (t *Tuning) Params() TuningParams {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.params
+}
+
+// Update 更新调优参数，参数非法整体不生效
+func (t *Tuning) Update(params TuningParams) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := params.validate(t.params); err != nil {
+		return err
+	}
+	t.extract.SetRate(params.ExtractRowsPerSecond, params.ExtractMBPerSecond)
+	t.apply.SetRate(params.ApplyRowsPerSecond, params.ApplyMBPerSecond)
+	if params.TableThreads > t.params.TableThreads {
+		t.notifyTable()
+	}
+	t.params = params
+	return nil
+}
+
+func (t *Tuning) SQLThreads() int {
+	return t.Params().SQLThreads
+}
+
+func (t *Tuning) ApplyThreads() int {
+	return t.Params().ApplyThreads
+}
+
+func (t *Tuning) IncrApplyThreads() int {
+	return t.Params().IncrApplyThreads
+}
+
+func (t *Tuning) InsertBatchSize() int {
+	return t.Params().InsertBatchSize
+}
+
+// AcquireTable 运行中表数达到 table-threads 阻塞等待，table-threads 调小后运行中表完成前不再拉取新表
+func (t *Tuning) AcquireTable(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		if t.tableRunning < t.params.TableThreads {
+			t.tableRunning++
+			t.mu.Unlock()
+			return nil
+		}
+		ch := t.tableCh
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+}
+
+// ReleaseTable 表完成释放
+func (t *Tuning) ReleaseTable() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tableRunning--
+	t.notifyTable()
+}
+
+// notifyTable 唤醒全部等待者重新判断，调用方需持有 mu
+func (t *Tuning) notifyTable() {
+	close(t.tableCh)
+	t.tableCh = make(chan struct{})
+}
+
+// 进程内运行中任务调优参数，按任务 ID 登记，任务结束注销
+var (
+	tuningMu sync.Mutex
+	tunings  = make(map[string]*Tuning)
+)
+
+func RegisterTuning(taskID string, t *Tuning) {
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	tunings[taskID] = t
+}
+
+func UnregisterTuning(taskID string) {
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	delete(tunings, taskID)
+}
+
+// GetTuning 获取运行中任务调优参数，仅 oracle 到 mysql/tidb full 以及 all 模式登记
+func GetTuning(taskID string) (*Tuning, bool) {
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	t, ok := tunings[taskID]
+	return t, ok
+}
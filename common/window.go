@@ -0,0 +1,196 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeWindow 任务运行时间窗口，支持以下格式（时间均为进程本地时区）
+// - 每日窗口：22:00-06:00，结束时间小于等于开始时间视为跨天，结束时间可配置 24:00
+// - 指定星期窗口：Mon-Fri 22:00-06:00 或者 Sat,Sun 00:00-24:00，跨天窗口星期以开始时间为准
+// - 固定时间段：2023-01-01 00:00~2023-01-02 06:00
+type TimeWindow struct {
+	raw      string
+	weekdays [7]bool
+	start    int
+	end      int
+	absolute bool
+	absStart time.Time
+	absEnd   time.Time
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+// ParseTimeWindows 解析时间窗口配置
+func ParseTimeWindows(windows []string) ([]TimeWindow, error) {
+	var tws []TimeWindow
+	for _, w := range windows {
+		tw, err := parseTimeWindow(strings.TrimSpace(w))
+		if err != nil {
+			return nil, fmt.Errorf("time window [%s] parse failed: %v", w, err)
+		}
+		tws = append(tws, tw)
+	}
+	return tws, nil
+}
+
+func parseTimeWindow(w string) (TimeWindow, error) {
+	tw := TimeWindow{raw: w}
+	if strings.Contains(w, "~") {
+		parts := strings.SplitN(w, "~", 2)
+		start, err := time.ParseInLocation("2006-01-02 15:04", strings.TrimSpace(parts[0]), time.Local)
+		if err != nil {
+			return tw, err
+		}
+		end, err := time.ParseInLocation("2006-01-02 15:04", strings.TrimSpace(parts[1]), time.Local)
+		if err != nil {
+			return tw, err
+		}
+		if !end.After(start) {
+			return tw, fmt.Errorf("end time must be after start time")
+		}
+		tw.absolute, tw.absStart, tw.absEnd = true, start, end
+		return tw, nil
+	}
+
+	fields := strings.Fields(w)
+	switch len(fields) {
+	case 1:
+		for i := range tw.weekdays {
+			tw.weekdays[i] = true
+		}
+	case 2:
+		if err := tw.parseWeekdays(fields[0]); err != nil {
+			return tw, err
+		}
+		fields = fields[1:]
+	default:
+		return tw, fmt.Errorf("format should be [HH:MM-HH:MM], [Mon-Fri HH:MM-HH:MM] or [YYYY-MM-DD HH:MM~YYYY-MM-DD HH:MM]")
+	}
+
+	clock := strings.SplitN(fields[0], "-", 2)
+	if len(clock) != 2 {
+		return tw, fmt.Errorf("clock range should be [HH:MM-HH:MM]")
+	}
+	var err error
+	if tw.start, err = parseClock(clock[0]); err != nil {
+		return tw, err
+	}
+	if tw.end, err = parseClock(clock[1]); err != nil {
+		return tw, err
+	}
+	if tw.start == 24*60 {
+		return tw, fmt.Errorf("start time can not be 24:00")
+	}
+	return tw, nil
+}
+
+func (tw *TimeWindow) parseWeekdays(s string) error {
+	for _, item := range strings.Split(s, ",") {
+		days := strings.SplitN(item, "-", 2)
+		from, ok := weekdayNames[StringUPPER(days[0])]
+		if !ok {
+			return fmt.Errorf("weekday [%s] isn't support, support: [Mon Tue Wed Thu Fri Sat Sun]", days[0])
+		}
+		to := from
+		if len(days) == 2 {
+			if to, ok = weekdayNames[StringUPPER(days[1])]; !ok {
+				return fmt.Errorf("weekday [%s] isn't support, support: [Mon Tue Wed Thu Fri Sat Sun]", days[1])
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			tw.weekdays[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM 为当日分钟数
+func parseClock(s string) (int, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("clock [%s] should be [HH:MM]", s)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("clock [%s] should be [HH:MM]", s)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("clock [%s] should be [HH:MM]", s)
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("clock [%s] out of range [00:00-24:00]", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// Contains 判断时间是否处于窗口内
+func (tw TimeWindow) Contains(t time.Time) bool {
+	if tw.absolute {
+		return !t.Before(tw.absStart) && t.Before(tw.absEnd)
+	}
+	t = t.In(time.Local)
+	minute := t.Hour()*60 + t.Minute()
+	if tw.start < tw.end {
+		return tw.weekdays[t.Weekday()] && minute >= tw.start && minute < tw.end
+	}
+	// 跨天窗口，凌晨部分归属前一天
+	if minute >= tw.start {
+		return tw.weekdays[t.Weekday()]
+	}
+	if minute < tw.end {
+		return tw.weekdays[(t.Weekday()+6)%7]
+	}
+	return false
+}
+
+func (tw TimeWindow) String() string {
+	return tw.raw
+}
+
+// InTimeWindow 判断当前时间是否允许运行：未配置运行窗口视为全天允许，且不处于任一禁止运行窗口
+func InTimeWindow(runWindows, blackoutWindows []TimeWindow, t time.Time) bool {
+	for _, w := range blackoutWindows {
+		if w.Contains(t) {
+			return false
+		}
+	}
+	if len(runWindows) == 0 {
+		return true
+	}
+	for _, w := range runWindows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -22,51 +22,116 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/wentaojin/transferdb/common"
 	"os"
+	"strings"
 )
 
 // 程序配置文件
 type Config struct {
-	*flag.FlagSet `json:"-"`
-	AppConfig     AppConfig     `toml:"app" json:"app"`
-	ReverseConfig ReverseConfig `toml:"reverse" json:"reverse"`
-	CheckConfig   CheckConfig   `toml:"check" json:"check"`
-	FullConfig    FullConfig    `toml:"full" json:"full"`
-	CSVConfig     CSVConfig     `toml:"csv" json:"csv"`
-	AllConfig     AllConfig     `toml:"all" json:"all"`
-	SchemaConfig  SchemaConfig  `toml:"schema-config" json:"schema-config"`
-	OracleConfig  OracleConfig  `toml:"oracle" json:"oracle"`
-	MySQLConfig   MySQLConfig   `toml:"mysql" json:"mysql"`
-	MetaConfig    MetaConfig    `toml:"meta" json:"meta"`
-	LogConfig     LogConfig     `toml:"log" json:"log"`
-	DiffConfig    DiffConfig    `toml:"compare" json:"compare"`
-	ConfigFile    string        `json:"config-file"`
-	PrintVersion  bool
-	TaskMode      string `json:"task-mode"`
-	DBTypeS       string `json:"db-type-s"`
-	DBTypeT       string `json:"db-type-t"`
+	*flag.FlagSet    `json:"-"`
+	AppConfig        AppConfig        `toml:"app" json:"app"`
+	ReverseConfig    ReverseConfig    `toml:"reverse" json:"reverse"`
+	CheckConfig      CheckConfig      `toml:"check" json:"check"`
+	FullConfig       FullConfig       `toml:"full" json:"full"`
+	CSVConfig        CSVConfig        `toml:"csv" json:"csv"`
+	AllConfig        AllConfig        `toml:"all" json:"all"`
+	SchemaConfig     SchemaConfig     `toml:"schema-config" json:"schema-config"`
+	OracleConfig     OracleConfig     `toml:"oracle" json:"oracle"`
+	MySQLConfig      MySQLConfig      `toml:"mysql" json:"mysql"`
+	PostgreSQLConfig PostgreSQLConfig `toml:"postgresql" json:"postgresql"`
+	SQLServerConfig  SQLServerConfig  `toml:"sqlserver" json:"sqlserver"`
+	ClickHouseConfig ClickHouseConfig `toml:"clickhouse" json:"clickhouse"`
+	KafkaConfig      KafkaConfig      `toml:"kafka" json:"kafka"`
+	S3Config         S3Config         `toml:"s3" json:"s3"`
+	MetaConfig       MetaConfig       `toml:"meta" json:"meta"`
+	LogConfig        LogConfig        `toml:"log" json:"log"`
+	TraceConfig      TraceConfig      `toml:"trace" json:"trace"`
+	DiffConfig       DiffConfig       `toml:"compare" json:"compare"`
+	SecretConfig     SecretConfig     `toml:"secret" json:"secret"`
+	ConfigFile       string           `json:"config-file"`
+	PrintVersion     bool
+	DryRun           bool
+	EncryptText      string `json:"-"`
+	TaskMode         string `json:"task-mode"`
+	DBTypeS          string `json:"db-type-s"`
+	DBTypeT          string `json:"db-type-t"`
 }
 
 type AppConfig struct {
-	InsertBatchSize  int    `toml:"insert-batch-size" json:"insert-batch-size"`
-	SlowlogThreshold int    `toml:"slowlog-threshold" json:"slowlog-threshold"`
-	PprofPort        string `toml:"pprof-port" json:"pprof-port"`
+	TaskID               string   `toml:"task-id" json:"task-id"`
+	InsertBatchSize      int      `toml:"insert-batch-size" json:"insert-batch-size"`
+	InsertBatchBytes     int      `toml:"insert-batch-bytes" json:"insert-batch-bytes"`
+	AdaptiveBatch        bool     `toml:"adaptive-batch" json:"adaptive-batch"`
+	AdaptiveBatchLatency int      `toml:"adaptive-batch-latency" json:"adaptive-batch-latency"`
+	AdaptiveBatchMinRows int      `toml:"adaptive-batch-min-rows" json:"adaptive-batch-min-rows"`
+	AdaptiveBatchMaxRows int      `toml:"adaptive-batch-max-rows" json:"adaptive-batch-max-rows"`
+	EmptyStringMode      string   `toml:"empty-string-mode" json:"empty-string-mode"`
+	LOBMaxSize           int      `toml:"lob-max-size" json:"lob-max-size"`
+	LOBOversizeMode      string   `toml:"lob-oversize-mode" json:"lob-oversize-mode"`
+	CharsetErrorMode     string   `toml:"charset-error-mode" json:"charset-error-mode"`
+	SlowlogThreshold     int      `toml:"slowlog-threshold" json:"slowlog-threshold"`
+	PprofPort            string   `toml:"pprof-port" json:"pprof-port"`
+	Dashboard            bool     `toml:"dashboard" json:"dashboard"`
+	GRPCAddr             string   `toml:"grpc-addr" json:"grpc-addr"`
+	GracefulTimeout      int      `toml:"graceful-timeout" json:"graceful-timeout"`
+	DDLAuditFile         string   `toml:"ddl-audit-file" json:"ddl-audit-file"`
+	RetryAttempts        int      `toml:"retry-attempts" json:"retry-attempts"`
+	RetryBackoff         int      `toml:"retry-backoff" json:"retry-backoff"`
+	RetryMaxBackoff      int      `toml:"retry-max-backoff" json:"retry-max-backoff"`
+	ChunkRedispatch      int      `toml:"chunk-redispatch" json:"chunk-redispatch"`
+	ReconnectTimeout     int      `toml:"reconnect-timeout" json:"reconnect-timeout"`
+	TargetDBType         string   `toml:"target-db-type" json:"target-db-type"`
+	ExtractRowsPerSecond int      `toml:"extract-rows-per-second" json:"extract-rows-per-second"`
+	ExtractMBPerSecond   int      `toml:"extract-mb-per-second" json:"extract-mb-per-second"`
+	ApplyRowsPerSecond   int      `toml:"apply-rows-per-second" json:"apply-rows-per-second"`
+	ApplyMBPerSecond     int      `toml:"apply-mb-per-second" json:"apply-mb-per-second"`
+	DateFormat           string   `toml:"date-format" json:"date-format"`
+	TargetTimeZone       string   `toml:"target-time-zone" json:"target-time-zone"`
+	RunWindows           []string `toml:"run-windows" json:"run-windows"`
+	BlackoutWindows      []string `toml:"blackout-windows" json:"blackout-windows"`
+	ReportDir            string   `toml:"report-dir" json:"report-dir"`
 }
 
 type DiffConfig struct {
 	ChunkSize         int    `toml:"chunk-size" json:"chunk-size"`
 	DiffThreads       int    `toml:"diff-threads" json:"diff-threads"`
 	OnlyCheckRows     bool   `toml:"only-check-rows" json:"only-check-rows"`
+	QuickCheckRows    bool   `toml:"quick-check-rows" json:"quick-check-rows"`
+	SpotCheckRows     int    `toml:"spot-check-rows" json:"spot-check-rows"`
 	EnableCheckpoint  bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
 	IgnoreStructCheck bool   `toml:"ignore-struct-check" json:"ignore-struct-check"`
 	FixSqlDir         string `toml:"fix-sql-dir" json:"fix-sql-dir"`
+	// 浮点类型容差以及时间戳小数秒比较位数
+	FloatEpsilon       float64 `toml:"float-epsilon" json:"float-epsilon"`
+	TimestampPrecision int     `toml:"timestamp-precision" json:"timestamp-precision"`
+	// 增量同步运行中基于 SCN 一致性数据校验
+	OnlineCompare        bool   `toml:"online-compare" json:"online-compare"`
+	OnlineCompareSCN     uint64 `toml:"online-compare-scn" json:"online-compare-scn"`
+	OnlineCompareTimeout int    `toml:"online-compare-timeout" json:"online-compare-timeout"`
+	OnlineCompareRetries int    `toml:"online-compare-retries" json:"online-compare-retries"`
 }
 
 type ReverseConfig struct {
-	LowerCaseFieldName string `toml:"lower-case-field-name" json:"lower-case-field-name"`
-	ReverseThreads     int    `toml:"reverse-threads" json:"reverse-threads"`
-	DirectWrite        bool   `toml:"direct-write" json:"direct-write"`
-	DDLReverseDir      string `toml:"ddl-reverse-dir" json:"ddl-reverse-dir"`
-	DDLCompatibleDir   string `toml:"ddl-compatible-dir" json:"ddl-compatible-dir"`
+	LowerCaseFieldName      string `toml:"lower-case-field-name" json:"lower-case-field-name"`
+	ReverseThreads          int    `toml:"reverse-threads" json:"reverse-threads"`
+	DirectWrite             bool   `toml:"direct-write" json:"direct-write"`
+	DDLReverseDir           string `toml:"ddl-reverse-dir" json:"ddl-reverse-dir"`
+	DDLCompatibleDir        string `toml:"ddl-compatible-dir" json:"ddl-compatible-dir"`
+	PartitionTable          bool   `toml:"partition-table" json:"partition-table"`
+	SequenceAutoIncrement   bool   `toml:"sequence-auto-increment" json:"sequence-auto-increment"`
+	TiDBClusteredIndex      string `toml:"tidb-clustered-index" json:"tidb-clustered-index"`
+	TiDBAutoRandom          bool   `toml:"tidb-auto-random" json:"tidb-auto-random"`
+	TiDBAutoRandomBits      int    `toml:"tidb-auto-random-bits" json:"tidb-auto-random-bits"`
+	IndexCompatibleMode     string `toml:"index-compatible-mode" json:"index-compatible-mode"`
+	ForeignKeyMode          string `toml:"foreign-key-mode" json:"foreign-key-mode"`
+	VirtualColumnMode       string `toml:"virtual-column-mode" json:"virtual-column-mode"`
+	MaterializedViewMode    string `toml:"materialized-view-mode" json:"materialized-view-mode"`
+	IntervalMode            string `toml:"interval-mode" json:"interval-mode"`
+	RawMode                 string `toml:"raw-mode" json:"raw-mode"`
+	RowidMode               string `toml:"rowid-mode" json:"rowid-mode"`
+	ViewConvert             bool   `toml:"view-convert" json:"view-convert"`
+	NumberUnconstrainedType string `toml:"number-unconstrained-type" json:"number-unconstrained-type"`
+	NumberSampleCheck       bool   `toml:"number-sample-check" json:"number-sample-check"`
+	NumberSamplePercent     int    `toml:"number-sample-percent" json:"number-sample-percent"`
 }
 
 type CheckConfig struct {
@@ -81,6 +146,7 @@ type CSVConfig struct {
 	Charset          string `toml:"charset" json:"charset"`
 	Delimiter        string `toml:"delimiter" json:"delimiter"`
 	EscapeBackslash  bool   `toml:"escape-backslash" json:"escape-backslash"`
+	NullValue        string `toml:"null-value" json:"null-value"`
 	Rows             int    `toml:"rows" json:"rows"`
 	OutputDir        string `toml:"output-dir" json:"output-dir"`
 	TaskThreads      int    `toml:"task-threads" json:"task-threads"`
@@ -89,25 +155,56 @@ type CSVConfig struct {
 	EnableCheckpoint bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
 	ConsistentRead   bool   `toml:"consistent-read" json:"consistent-read"`
 	SQLHint          string `toml:"sql-hint" json:"sql-hint"`
+	FileFormat       string `toml:"file-format" json:"file-format"`
+	RowGroupSize     int    `toml:"row-group-size" json:"row-group-size"`
+	Compression      string `toml:"compression" json:"compression"`
+	Storage          string `toml:"storage" json:"storage"`
 }
 
 type FullConfig struct {
-	ChunkSize        int    `toml:"chunk-size" json:"chunk-size"`
-	TaskThreads      int    `toml:"task-threads" json:"task-threads"`
-	TableThreads     int    `toml:"table-threads" json:"table-threads"`
-	SQLThreads       int    `toml:"sql-threads" json:"sql-threads"`
-	ApplyThreads     int    `toml:"apply-threads" json:"apply-threads"`
-	EnableCheckpoint bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
-	ConsistentRead   bool   `toml:"consistent-read" json:"consistent-read"`
-	SQLHint          string `toml:"sql-hint" json:"sql-hint"`
+	ChunkSize           int      `toml:"chunk-size" json:"chunk-size"`
+	TaskThreads         int      `toml:"task-threads" json:"task-threads"`
+	TableThreads        int      `toml:"table-threads" json:"table-threads"`
+	SQLThreads          int      `toml:"sql-threads" json:"sql-threads"`
+	ApplyThreads        int      `toml:"apply-threads" json:"apply-threads"`
+	EnableCheckpoint    bool     `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	RetryFailed         bool     `toml:"retry-failed" json:"retry-failed"`
+	ConsistentRead      bool     `toml:"consistent-read" json:"consistent-read"`
+	SQLHint             string   `toml:"sql-hint" json:"sql-hint"`
+	WriteMode           string   `toml:"write-mode" json:"write-mode"`
+	SkipError           bool     `toml:"skip-error" json:"skip-error"`
+	ErrorDir            string   `toml:"error-dir" json:"error-dir"`
+	TableOrder          string   `toml:"table-order" json:"table-order"`
+	PinnedTables        []string `toml:"pinned-tables" json:"pinned-tables"`
+	PlanRowsPerSecond   int      `toml:"plan-rows-per-second" json:"plan-rows-per-second"`
+	RebuildIndex        bool     `toml:"rebuild-index" json:"rebuild-index"`
+	RebuildIndexThreads int      `toml:"rebuild-index-threads" json:"rebuild-index-threads"`
+	MemoryBudget        int      `toml:"memory-budget" json:"memory-budget"`
 }
 
 type AllConfig struct {
-	LogminerQueryTimeout int `toml:"logminer-query-timeout" json:"logminer-query-timeout"`
-	FilterThreads        int `toml:"filter-threads" json:"filter-threads"`
-	ApplyThreads         int `toml:"apply-threads" json:"apply-threads"`
-	WorkerQueue          int `toml:"worker-queue" json:"worker-queue"`
-	WorkerThreads        int `toml:"worker-threads" json:"worker-threads"`
+	LogminerQueryTimeout int    `toml:"logminer-query-timeout" json:"logminer-query-timeout"`
+	LogminerInterval     int    `toml:"logminer-interval" json:"logminer-interval"`
+	FilterThreads        int    `toml:"filter-threads" json:"filter-threads"`
+	ApplyThreads         int    `toml:"apply-threads" json:"apply-threads"`
+	WorkerQueue          int    `toml:"worker-queue" json:"worker-queue"`
+	WorkerThreads        int    `toml:"worker-threads" json:"worker-threads"`
+	PrerequisiteAutoFix  bool   `toml:"prerequisite-auto-fix" json:"prerequisite-auto-fix"`
+	DDLMode              string `toml:"ddl-mode" json:"ddl-mode"`
+	ConflictPolicy       string `toml:"conflict-policy" json:"conflict-policy"`
+	SinkType             string `toml:"sink-type" json:"sink-type"`
+	CaptureMode          string `toml:"capture-mode" json:"capture-mode"`
+	TxnSpillRows         int    `toml:"txn-spill-rows" json:"txn-spill-rows"`
+	TxnSpillDir          string `toml:"txn-spill-dir" json:"txn-spill-dir"`
+	// 单任务多 schema 增量同步，与 [schema-config] 共用 logminer 会话
+	SchemaRoute []SchemaRoute `toml:"schema-route" json:"schema-route"`
+}
+
+type SchemaRoute struct {
+	SourceSchema       string   `toml:"source-schema" json:"source-schema"`
+	SourceIncludeTable []string `toml:"source-include-table" json:"source-include-table"`
+	SourceExcludeTable []string `toml:"source-exclude-table" json:"source-exclude-table"`
+	TargetSchema       string   `toml:"target-schema" json:"target-schema"`
 }
 
 type SchemaConfig struct {
@@ -126,34 +223,159 @@ type CompareConfig struct {
 }
 
 type MigrateConfig struct {
-	SourceTable string `toml:"source-table" json:"source-table"`
-	EnableSplit bool   `toml:"enable-split" json:"enable-split"`
-	Range       string `toml:"range" json:"range"`
-	SQLHint     string `toml:"sql-hint" json:"sql-hint"`
+	SourceTable          string            `toml:"source-table" json:"source-table"`
+	EnableSplit          bool              `toml:"enable-split" json:"enable-split"`
+	Range                string            `toml:"range" json:"range"`
+	SQLHint              string            `toml:"sql-hint" json:"sql-hint"`
+	SQLThreads           int               `toml:"sql-threads" json:"sql-threads"`
+	LoadData             bool              `toml:"load-data" json:"load-data"`
+	FetchArraySize       int               `toml:"fetch-array-size" json:"fetch-array-size"`
+	PrefetchCount        int               `toml:"prefetch-count" json:"prefetch-count"`
+	Priority             int               `toml:"priority" json:"priority"`
+	ColumnTransform      []ColumnTransform `toml:"column-transform" json:"column-transform"`
+	PartitionBy          []string          `toml:"partition-by" json:"partition-by"`
+	OrderBy              []string          `toml:"order-by" json:"order-by"`
+	IncludeColumns       []string          `toml:"include-columns" json:"include-columns"`
+	ExcludeColumns       []string          `toml:"exclude-columns" json:"exclude-columns"`
+	MaterializedViewMode string            `toml:"materialized-view-mode" json:"materialized-view-mode"`
+	WatermarkColumn      string            `toml:"watermark-column" json:"watermark-column"`
+	IgnoreOperations     []string          `toml:"ignore-operations" json:"ignore-operations"`
+	IgnoreUpdateColumns  []string          `toml:"ignore-update-columns" json:"ignore-update-columns"`
+}
+
+type ColumnTransform struct {
+	ColumnName string `toml:"column-name" json:"column-name"`
+	Rule       string `toml:"rule" json:"rule"`
+	Algorithm  string `toml:"algorithm" json:"algorithm"`
+	Length     int    `toml:"length" json:"length"`
+	Expression string `toml:"expression" json:"expression"`
 }
 
 type OracleConfig struct {
-	Username      string   `toml:"username" json:"username"`
-	Password      string   `toml:"password" json:"password"`
-	Host          string   `toml:"host" json:"host"`
-	Port          int      `toml:"port" json:"port"`
-	ServiceName   string   `toml:"service-name" json:"service-name"`
-	PDBName       string   `toml:"pdb-name" json:"pdb-name"`
-	Charset       string   `toml:"charset" json:"charset"`
-	LibDir        string   `toml:"lib-dir" json:"lib-dir"`
-	ConnectParams string   `toml:"connect-params" json:"connect-params"`
-	SessionParams []string `toml:"session-params" json:"session-params"`
+	Username             string   `toml:"username" json:"username"`
+	Password             string   `toml:"password" json:"password"`
+	Host                 string   `toml:"host" json:"host"`
+	Port                 int      `toml:"port" json:"port"`
+	ServiceName          string   `toml:"service-name" json:"service-name"`
+	InstanceName         string   `toml:"instance-name" json:"instance-name"`
+	FailoverHosts        []string `toml:"failover-hosts" json:"failover-hosts"`
+	HealthCheckInterval  int      `toml:"health-check-interval" json:"health-check-interval"`
+	Standby              bool     `toml:"standby" json:"standby"`
+	PDBName              string   `toml:"pdb-name" json:"pdb-name"`
+	Charset              string   `toml:"charset" json:"charset"`
+	LibDir               string   `toml:"lib-dir" json:"lib-dir"`
+	ConnectParams        string   `toml:"connect-params" json:"connect-params"`
+	ConnectString        string   `toml:"connect-string" json:"connect-string"`
+	Protocol             string   `toml:"protocol" json:"protocol"`
+	WalletLocation       string   `toml:"wallet-location" json:"wallet-location"`
+	ConfigDir            string   `toml:"config-dir" json:"config-dir"`
+	SSLServerDNMatch     bool     `toml:"ssl-server-dn-match" json:"ssl-server-dn-match"`
+	SSLServerCertDN      string   `toml:"ssl-server-cert-dn" json:"ssl-server-cert-dn"`
+	ExternalAuth         bool     `toml:"external-auth" json:"external-auth"`
+	SessionParams        []string `toml:"session-params" json:"session-params"`
+	NLSDateFormat        string   `toml:"nls-date-format" json:"nls-date-format"`
+	NLSTimestampFormat   string   `toml:"nls-timestamp-format" json:"nls-timestamp-format"`
+	NLSTimestampTZFormat string   `toml:"nls-timestamp-tz-format" json:"nls-timestamp-tz-format"`
+	TimeZone             string   `toml:"time-zone" json:"time-zone"`
+	NLSNumericCharacters string   `toml:"nls-numeric-characters" json:"nls-numeric-characters"`
+	ParallelDegree       int      `toml:"parallel-degree" json:"parallel-degree"`
+	MaxIdleConns         int      `toml:"max-idle-conns" json:"max-idle-conns"`
+	MaxOpenConns         int      `toml:"max-open-conns" json:"max-open-conns"`
+	ConnMaxLifetime      int      `toml:"conn-max-lifetime" json:"conn-max-lifetime"`
+	FetchArraySize       int      `toml:"fetch-array-size" json:"fetch-array-size"`
+	PrefetchCount        int      `toml:"prefetch-count" json:"prefetch-count"`
 }
 
 type MySQLConfig struct {
-	Username      string `toml:"username" json:"username"`
-	Password      string `toml:"password" json:"password"`
-	Host          string `toml:"host" json:"host"`
-	Port          int    `toml:"port" json:"port"`
-	Charset       string `toml:"charset" json:"charset"`
-	ConnectParams string `toml:"connect-params" json:"connect-params"`
-	TableOption   string `toml:"table-option" json:"table-option"`
-	Overwrite     bool   `toml:"overwrite" json:"overwrite"`
+	Username            string   `toml:"username" json:"username"`
+	Password            string   `toml:"password" json:"password"`
+	Host                string   `toml:"host" json:"host"`
+	Port                int      `toml:"port" json:"port"`
+	FailoverHosts       []string `toml:"failover-hosts" json:"failover-hosts"`
+	Charset             string   `toml:"charset" json:"charset"`
+	ConnectParams       string   `toml:"connect-params" json:"connect-params"`
+	SessionParams       []string `toml:"session-params" json:"session-params"`
+	ConnectTimeout      int      `toml:"connect-timeout" json:"connect-timeout"`
+	HealthCheckInterval int      `toml:"health-check-interval" json:"health-check-interval"`
+	ReadTimeout         int      `toml:"read-timeout" json:"read-timeout"`
+	WriteTimeout        int      `toml:"write-timeout" json:"write-timeout"`
+	TLSCA               string   `toml:"tls-ca" json:"tls-ca"`
+	TLSCert             string   `toml:"tls-cert" json:"tls-cert"`
+	TLSKey              string   `toml:"tls-key" json:"tls-key"`
+	TLSSkipVerify       bool     `toml:"tls-skip-verify" json:"tls-skip-verify"`
+	TLSServerName       string   `toml:"tls-server-name" json:"tls-server-name"`
+	MaxAllowedPacket    int      `toml:"max-allowed-packet" json:"max-allowed-packet"`
+	LoadSessionParams   []string `toml:"load-session-params" json:"load-session-params"`
+	MaxIdleConns        int      `toml:"max-idle-conns" json:"max-idle-conns"`
+	MaxOpenConns        int      `toml:"max-open-conns" json:"max-open-conns"`
+	ConnMaxLifetime     int      `toml:"conn-max-lifetime" json:"conn-max-lifetime"`
+	TableOption         string   `toml:"table-option" json:"table-option"`
+	Overwrite           bool     `toml:"overwrite" json:"overwrite"`
+}
+
+type PostgreSQLConfig struct {
+	Username        string `toml:"username" json:"username"`
+	Password        string `toml:"password" json:"password"`
+	Host            string `toml:"host" json:"host"`
+	Port            int    `toml:"port" json:"port"`
+	DBName          string `toml:"dbname" json:"dbname"`
+	ConnectParams   string `toml:"connect-params" json:"connect-params"`
+	ConnectTimeout  int    `toml:"connect-timeout" json:"connect-timeout"`
+	MaxIdleConns    int    `toml:"max-idle-conns" json:"max-idle-conns"`
+	MaxOpenConns    int    `toml:"max-open-conns" json:"max-open-conns"`
+	ConnMaxLifetime int    `toml:"conn-max-lifetime" json:"conn-max-lifetime"`
+}
+
+type SQLServerConfig struct {
+	Username        string `toml:"username" json:"username"`
+	Password        string `toml:"password" json:"password"`
+	Host            string `toml:"host" json:"host"`
+	Port            int    `toml:"port" json:"port"`
+	DBName          string `toml:"dbname" json:"dbname"`
+	ConnectParams   string `toml:"connect-params" json:"connect-params"`
+	ConnectTimeout  int    `toml:"connect-timeout" json:"connect-timeout"`
+	MaxIdleConns    int    `toml:"max-idle-conns" json:"max-idle-conns"`
+	MaxOpenConns    int    `toml:"max-open-conns" json:"max-open-conns"`
+	ConnMaxLifetime int    `toml:"conn-max-lifetime" json:"conn-max-lifetime"`
+	CDCInterval     int    `toml:"cdc-interval" json:"cdc-interval"`
+}
+
+type ClickHouseConfig struct {
+	Username        string   `toml:"username" json:"username"`
+	Password        string   `toml:"password" json:"password"`
+	Addrs           []string `toml:"addrs" json:"addrs"`
+	Compression     string   `toml:"compression" json:"compression"`
+	DialTimeout     int      `toml:"dial-timeout" json:"dial-timeout"`
+	MaxIdleConns    int      `toml:"max-idle-conns" json:"max-idle-conns"`
+	MaxOpenConns    int      `toml:"max-open-conns" json:"max-open-conns"`
+	ConnMaxLifetime int      `toml:"conn-max-lifetime" json:"conn-max-lifetime"`
+	Engine          string   `toml:"engine" json:"engine"`
+	Cluster         string   `toml:"cluster" json:"cluster"`
+}
+
+type KafkaConfig struct {
+	Brokers         []string `toml:"brokers" json:"brokers"`
+	TopicPrefix     string   `toml:"topic-prefix" json:"topic-prefix"`
+	Format          string   `toml:"format" json:"format"`
+	FullLoad        bool     `toml:"full-load" json:"full-load"`
+	RequiredAcks    string   `toml:"required-acks" json:"required-acks"`
+	Compression     string   `toml:"compression" json:"compression"`
+	BatchSize       int      `toml:"batch-size" json:"batch-size"`
+	BatchTimeout    int      `toml:"batch-timeout" json:"batch-timeout"`
+	AutoCreateTopic bool     `toml:"auto-create-topic" json:"auto-create-topic"`
+}
+
+type S3Config struct {
+	Endpoint       string `toml:"endpoint" json:"endpoint"`
+	Region         string `toml:"region" json:"region"`
+	Bucket         string `toml:"bucket" json:"bucket"`
+	AccessKey      string `toml:"access-key" json:"access-key"`
+	SecretKey      string `toml:"secret-key" json:"secret-key"`
+	DisableSSL     bool   `toml:"disable-ssl" json:"disable-ssl"`
+	ForcePathStyle bool   `toml:"force-path-style" json:"force-path-style"`
+	PartSize       int    `toml:"part-size" json:"part-size"`
+	Concurrency    int    `toml:"concurrency" json:"concurrency"`
+	MaxRetries     int    `toml:"max-retries" json:"max-retries"`
 }
 
 type MetaConfig struct {
@@ -170,6 +392,23 @@ type LogConfig struct {
 	MaxSize    int    `toml:"max-size" json:"max-size"`
 	MaxDays    int    `toml:"max-days" json:"max-days"`
 	MaxBackups int    `toml:"max-backups" json:"max-backups"`
+	Compress   bool   `toml:"compress" json:"compress"`
+	LogFormat  string `toml:"log-format" json:"log-format"`
+	// 模块日志级别覆盖，格式 module=level，module 为源码目录，例如 module/migrate/sql/oracle/o2m=debug
+	ModuleLevels []string `toml:"module-levels" json:"module-levels"`
+	// 慢 SQL 日志文件，源端抽取以及目标端写入耗时阈值，单位毫秒，小于等于 0 不记录
+	SlowQueryFile       string `toml:"slow-query-file" json:"slow-query-file"`
+	SlowSourceThreshold int    `toml:"slow-source-threshold" json:"slow-source-threshold"`
+	SlowTargetThreshold int    `toml:"slow-target-threshold" json:"slow-target-threshold"`
+}
+
+type TraceConfig struct {
+	Enable      bool    `toml:"enable" json:"enable"`
+	Exporter    string  `toml:"exporter" json:"exporter"`
+	Endpoint    string  `toml:"endpoint" json:"endpoint"`
+	Insecure    bool    `toml:"insecure" json:"insecure"`
+	SampleRatio float64 `toml:"sample-ratio" json:"sample-ratio"`
+	ServiceName string  `toml:"service-name" json:"service-name"`
 }
 
 func NewConfig() *Config {
@@ -183,9 +422,11 @@ func NewConfig() *Config {
 	}
 	fs.BoolVar(&cfg.PrintVersion, "V", false, "print version information and exit")
 	fs.StringVar(&cfg.ConfigFile, "config", "./config.toml", "path to the configuration file")
-	fs.StringVar(&cfg.TaskMode, "mode", "", "specify the program running mode: [prepare assess reverse full csv all check compare]")
-	fs.StringVar(&cfg.DBTypeS, "source", "oracle", "specify the source db type")
-	fs.StringVar(&cfg.DBTypeT, "target", "mysql", "specify the target db type")
+	fs.StringVar(&cfg.TaskMode, "mode", "", "specify the program running mode: [prepare assess reverse full csv all check compare status server pause resume check-env]")
+	fs.StringVar(&cfg.DBTypeS, "source", "oracle", "specify the source db type: [oracle mysql tidb sqlserver]")
+	fs.StringVar(&cfg.DBTypeT, "target", "mysql", "specify the target db type: [mysql tidb postgresql clickhouse]")
+	fs.StringVar(&cfg.EncryptText, "encrypt", "", "encrypt the plaintext password with [secret] key-file, print ENC(...) and exit")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "print what would be done (table list, ddl, sample dml, estimated rows and chunk plan) and exit, without touching the target")
 	return cfg
 }
 
@@ -212,6 +453,19 @@ func (c *Config) Parse(args []string) error {
 		return fmt.Errorf("no config file")
 	}
 
+	if c.EncryptText != "" {
+		key, err := c.SecretConfig.readKey()
+		if err != nil {
+			return err
+		}
+		ciphertext, err := EncryptSecret(key, c.EncryptText)
+		if err != nil {
+			return err
+		}
+		fmt.Println(ciphertext)
+		os.Exit(0)
+	}
+
 	err = c.AdjustConfig()
 	if err != nil {
 		return err
@@ -222,13 +476,22 @@ func (c *Config) Parse(args []string) error {
 
 // 加载配置文件并解析
 func (c *Config) configFromFile(file string) error {
-	if _, err := toml.DecodeFile(file, c); err != nil {
-		return fmt.Errorf("failed decode toml config file %s: %v", file, err)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed read toml config file %s: %v", file, err)
 	}
-	return nil
+	md, err := toml.Decode(string(content), c)
+	if err != nil {
+		return decodeError(common.StringsBuilder("file ", file), err)
+	}
+	return CheckUndecodedKeys(common.StringsBuilder("file ", file), string(content), md)
 }
 
 func (c *Config) AdjustConfig() error {
+	// target-db-type 配置优先于 -target 参数
+	if c.AppConfig.TargetDBType != "" {
+		c.DBTypeT = c.AppConfig.TargetDBType
+	}
 	c.DBTypeS = common.StringUPPER(c.DBTypeS)
 	c.DBTypeT = common.StringUPPER(c.DBTypeT)
 	c.TaskMode = common.StringUPPER(c.TaskMode)
@@ -236,12 +499,151 @@ func (c *Config) AdjustConfig() error {
 
 	c.SchemaConfig.SourceSchema = common.StringUPPER(c.SchemaConfig.SourceSchema)
 	c.SchemaConfig.TargetSchema = common.StringUPPER(c.SchemaConfig.TargetSchema)
+	for i := range c.AllConfig.SchemaRoute {
+		c.AllConfig.SchemaRoute[i].SourceSchema = common.StringUPPER(c.AllConfig.SchemaRoute[i].SourceSchema)
+		c.AllConfig.SchemaRoute[i].TargetSchema = common.StringUPPER(c.AllConfig.SchemaRoute[i].TargetSchema)
+	}
 
+	c.AppConfig.TaskID = strings.TrimSpace(c.AppConfig.TaskID)
+	if len(c.AppConfig.TaskID) > common.TaskIDMaxLength {
+		return fmt.Errorf("app config task-id [%s] length can not exceed %d", c.AppConfig.TaskID, common.TaskIDMaxLength)
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	for _, t := range c.SchemaConfig.MigrateConfig {
+		if len(t.IncludeColumns) > 0 && len(t.ExcludeColumns) > 0 {
+			return fmt.Errorf("schema-config migrate-config table [%s] include-columns and exclude-columns can not be configured at the same time", t.SourceTable)
+		}
+		if !isMaterializedViewMode(t.MaterializedViewMode) {
+			return fmt.Errorf("schema-config migrate-config table [%s] materialized-view-mode [%s] isn't support, only support [table view skip]", t.SourceTable, t.MaterializedViewMode)
+		}
+		if err := checkOperationFilter(t); err != nil {
+			return err
+		}
+	}
+	if c.LogConfig.LogFormat == "" {
+		c.LogConfig.LogFormat = common.LogFormatConsole
+	}
+	if !strings.EqualFold(c.LogConfig.LogFormat, common.LogFormatConsole) && !strings.EqualFold(c.LogConfig.LogFormat, common.LogFormatJSON) {
+		return fmt.Errorf("log config log-format [%s] isn't support, only support [console json]", c.LogConfig.LogFormat)
+	}
+	for _, ml := range c.LogConfig.ModuleLevels {
+		kv := strings.SplitN(ml, "=", 2)
+		if len(kv) != 2 || strings.Trim(strings.TrimSpace(kv[0]), "/") == "" || !common.IsContainString(common.LogLevels, common.StringUPPER(strings.TrimSpace(kv[1]))) {
+			return fmt.Errorf("log config module-levels [%s] format error, must be module=level, level only support %v", ml, common.LogLevels)
+		}
+	}
+	for _, h := range c.OracleConfig.FailoverHosts {
+		if _, _, err := common.SplitHostPort(h); err != nil {
+			return fmt.Errorf("oracle config failover-hosts [%s] format error: %v", h, err)
+		}
+	}
+	for _, h := range c.MySQLConfig.FailoverHosts {
+		if _, _, err := common.SplitHostPort(h); err != nil {
+			return fmt.Errorf("mysql config failover-hosts [%s] format error: %v", h, err)
+		}
+	}
+	if strings.EqualFold(c.AppConfig.PprofPort, "") && (c.AppConfig.Dashboard || strings.EqualFold(c.TaskMode, common.TaskModeServer)) {
+		return fmt.Errorf("app config pprof-port can not be empty when dashboard is enabled or task mode is [%s]", common.TaskModeServer)
+	}
+	if c.OracleConfig.Standby && strings.EqualFold(c.TaskMode, common.TaskModeAll) {
+		return fmt.Errorf("oracle config standby is enabled, task mode [%s] logminer incr sync isn't support on standby database", c.TaskMode)
+	}
+	if !isMaterializedViewMode(c.ReverseConfig.MaterializedViewMode) {
+		return fmt.Errorf("reverse config materialized-view-mode [%s] isn't support, only support [table view skip]", c.ReverseConfig.MaterializedViewMode)
+	}
+	if err := c.ReverseConfig.validDatatypeMode(); err != nil {
+		return err
+	}
+	if _, err := common.ParseTimeWindows(c.AppConfig.RunWindows); err != nil {
+		return fmt.Errorf("app config run-windows: %v", err)
+	}
+	if _, err := common.ParseTimeWindows(c.AppConfig.BlackoutWindows); err != nil {
+		return fmt.Errorf("app config blackout-windows: %v", err)
+	}
+
+	if c.TraceConfig.Enable {
+		c.TraceConfig.Exporter = common.StringUPPER(c.TraceConfig.Exporter)
+		if c.TraceConfig.Exporter == "" {
+			c.TraceConfig.Exporter = common.TraceExporterOTLP
+		}
+		if c.TraceConfig.Exporter != common.TraceExporterOTLP && c.TraceConfig.Exporter != common.TraceExporterJaeger {
+			return fmt.Errorf("trace config exporter [%s] isn't support, only support [otlp jaeger]", c.TraceConfig.Exporter)
+		}
+		// 未配置采样比例默认全部采样
+		if c.TraceConfig.SampleRatio == 0 {
+			c.TraceConfig.SampleRatio = common.TraceDefaultSampleRatio
+		}
+		if c.TraceConfig.SampleRatio < 0 || c.TraceConfig.SampleRatio > 1 {
+			return fmt.Errorf("trace config sample-ratio [%v] should be in [0, 1]", c.TraceConfig.SampleRatio)
+		}
+	}
+
+	if err := c.resolveSecrets(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// SchemaRouteConfig 按 [[all.schema-route]] 生成单 schema 任务配置，其余配置沿用当前任务，[schema-config] 表级别配置不继承
+func (c *Config) SchemaRouteConfig(route SchemaRoute) *Config {
+	rc := *c
+	rc.SchemaConfig = SchemaConfig{
+		SourceSchema:       route.SourceSchema,
+		SourceIncludeTable: route.SourceIncludeTable,
+		SourceExcludeTable: route.SourceExcludeTable,
+		TargetSchema:       route.TargetSchema,
+	}
+	rc.AllConfig.SchemaRoute = nil
+	return &rc
+}
+
+// TuningParams 运行中可热更新调优参数
+func (c *Config) TuningParams() common.TuningParams {
+	return common.TuningParams{
+		TableThreads:         c.FullConfig.TableThreads,
+		SQLThreads:           c.FullConfig.SQLThreads,
+		ApplyThreads:         c.FullConfig.ApplyThreads,
+		IncrApplyThreads:     c.AllConfig.ApplyThreads,
+		InsertBatchSize:      c.AppConfig.InsertBatchSize,
+		ExtractRowsPerSecond: c.AppConfig.ExtractRowsPerSecond,
+		ExtractMBPerSecond:   c.AppConfig.ExtractMBPerSecond,
+		ApplyRowsPerSecond:   c.AppConfig.ApplyRowsPerSecond,
+		ApplyMBPerSecond:     c.AppConfig.ApplyMBPerSecond,
+	}
+}
+
+// ReadTuningParams 重新读取配置文件，用于 SIGHUP 热更新，调优参数之外的配置项修改不生效
+func ReadTuningParams(file string) (string, common.TuningParams, error) {
+	c := &Config{}
+	if err := c.configFromFile(file); err != nil {
+		return "", common.TuningParams{}, err
+	}
+	if err := c.AdjustConfig(); err != nil {
+		return "", common.TuningParams{}, err
+	}
+	return c.AppConfig.TaskID, c.TuningParams(), nil
+}
+
+// String 输出配置，密码以及 Vault token 脱敏
 func (c *Config) String() string {
-	cfg, err := json.Marshal(c)
+	masked := *c
+	for _, password := range []*string{
+		&masked.OracleConfig.Password,
+		&masked.MySQLConfig.Password,
+		&masked.PostgreSQLConfig.Password,
+		&masked.SQLServerConfig.Password,
+		&masked.ClickHouseConfig.Password,
+		&masked.MetaConfig.Password,
+		&masked.S3Config.SecretKey,
+		&masked.SecretConfig.VaultToken,
+	} {
+		if *password != "" {
+			*password = "******"
+		}
+	}
+	cfg, err := json.Marshal(masked)
 	if err != nil {
 		return "<nil>"
 	}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// SkipColumnDatatypes 不创建以及不迁移字段类型，BFILE 始终跳过，rowid-mode = skip 跳过 ROWID/UROWID
+func (rc *ReverseConfig) SkipColumnDatatypes() []string {
+	datatypes := []string{common.BuildInOracleDatatypeBfile}
+	if strings.EqualFold(rc.RowidMode, common.ReverseRowidModeSkip) {
+		datatypes = append(datatypes, common.BuildInOracleDatatypeRowid, common.BuildInOracleDatatypeUrowid)
+	}
+	return datatypes
+}
+
+func (rc *ReverseConfig) validDatatypeMode() error {
+	modes := []struct {
+		name    string
+		value   string
+		support []string
+	}{
+		{"interval-mode", rc.IntervalMode, []string{common.ReverseIntervalModeString, common.ReverseIntervalModeNumeric}},
+		{"raw-mode", rc.RawMode, []string{common.ReverseRawModeBinary, common.ReverseRawModeHex}},
+		{"rowid-mode", rc.RowidMode, []string{common.ReverseRowidModeString, common.ReverseRowidModeSkip}},
+	}
+	for _, m := range modes {
+		if m.value != "" && !common.IsContainString(m.support, strings.ToLower(m.value)) {
+			return fmt.Errorf("reverse config %s [%s] isn't support, only support %v", m.name, m.value, m.support)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// GetMaterializedViewMode 获取物化视图转换模式，migrate-config 表级配置优先于 reverse-config，均未配置默认 skip
+func (c *Config) GetMaterializedViewMode(sourceTable string) string {
+	for _, t := range c.SchemaConfig.MigrateConfig {
+		if strings.EqualFold(t.SourceTable, sourceTable) && t.MaterializedViewMode != "" {
+			return strings.ToLower(t.MaterializedViewMode)
+		}
+	}
+	if c.ReverseConfig.MaterializedViewMode != "" {
+		return strings.ToLower(c.ReverseConfig.MaterializedViewMode)
+	}
+	return common.ReverseMaterializedViewModeSkip
+}
+
+// FilterMaterializedViewTable 数据迁移、数据校验表列表仅保留 table 模式物化视图
+// view/skip 模式物化视图目标端不存在对应表，返回排除后的表列表以及被排除的物化视图
+func (c *Config) FilterMaterializedViewTable(tables, materializedViews []string) ([]string, []string) {
+	var excludes []string
+	for _, mv := range common.FilterIntersectionStringItems(tables, materializedViews) {
+		if c.GetMaterializedViewMode(mv) != common.ReverseMaterializedViewModeTable {
+			excludes = append(excludes, mv)
+		}
+	}
+	if len(excludes) == 0 {
+		return tables, excludes
+	}
+	return common.FilterDifferenceStringItems(tables, excludes), excludes
+}
+
+func isMaterializedViewMode(mode string) bool {
+	switch strings.ToLower(mode) {
+	case "", common.ReverseMaterializedViewModeTable, common.ReverseMaterializedViewModeView, common.ReverseMaterializedViewModeSkip:
+		return true
+	default:
+		return false
+	}
+}
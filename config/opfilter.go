@@ -0,0 +1,90 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// OperationFilter 增量同步表级别变更过滤，忽略的变更不写入下游以及不发布 kafka
+type OperationFilter struct {
+	operations    map[string]struct{}
+	updateColumns map[string]struct{}
+}
+
+// GetOperationFilter 获取增量同步表变更过滤，未配置 ignore-operations / ignore-update-columns 返回 nil，nil 代表全部应用
+func (c *SchemaConfig) GetOperationFilter(sourceTable string) *OperationFilter {
+	for _, t := range c.MigrateConfig {
+		if !strings.EqualFold(t.SourceTable, sourceTable) {
+			continue
+		}
+		if len(t.IgnoreOperations) == 0 && len(t.IgnoreUpdateColumns) == 0 {
+			return nil
+		}
+		f := &OperationFilter{
+			operations:    make(map[string]struct{}),
+			updateColumns: make(map[string]struct{}),
+		}
+		for _, op := range t.IgnoreOperations {
+			op = common.StringUPPER(strings.TrimSpace(op))
+			// 增量 TRUNCATE 转换后变更类型为 TRUNCATE TABLE
+			if op == common.MigrateOperationTruncate {
+				op = common.MigrateOperationTruncateTable
+			}
+			f.operations[op] = struct{}{}
+		}
+		for _, col := range t.IgnoreUpdateColumns {
+			f.updateColumns[common.StringUPPER(strings.TrimSpace(col))] = struct{}{}
+		}
+		return f
+	}
+	return nil
+}
+
+// IsIgnored 变更是否忽略，UPDATE 修改字段全部属于 ignore-update-columns 时忽略，字段名忽略大小写以及引号
+func (f *OperationFilter) IsIgnored(operationType string, updateColumns []string) bool {
+	if f == nil {
+		return false
+	}
+	if _, ok := f.operations[common.StringUPPER(operationType)]; ok {
+		return true
+	}
+	if !strings.EqualFold(operationType, common.MigrateOperationUpdate) || len(f.updateColumns) == 0 || len(updateColumns) == 0 {
+		return false
+	}
+	for _, c := range updateColumns {
+		if _, ok := f.updateColumns[strings.ToUpper(strings.Trim(strings.TrimSpace(c), "`\""))]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// HasUpdateColumns 是否配置 ignore-update-columns，未配置无需解析 UPDATE 修改字段
+func (f *OperationFilter) HasUpdateColumns() bool {
+	return f != nil && len(f.updateColumns) > 0
+}
+
+func checkOperationFilter(t MigrateConfig) error {
+	for _, op := range t.IgnoreOperations {
+		if !common.IsContainString(common.IncrIgnoreOperations, common.StringUPPER(strings.TrimSpace(op))) {
+			return fmt.Errorf("schema-config migrate-config table [%s] ignore-operations [%s] isn't support, only support %v", t.SourceTable, op, common.IncrIgnoreOperations)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ColumnProjection 表字段投影，include-columns 只迁移指定字段，exclude-columns 排除指定字段
+// 表结构转换、全量抽取写入、csv 导出、增量应用、表结构校验以及数据校验统一按投影后字段处理
+type ColumnProjection struct {
+	include map[string]struct{}
+	exclude map[string]struct{}
+}
+
+// GetColumnProjection 获取迁移表字段投影，未配置 include-columns / exclude-columns 返回 nil，nil 代表全部字段
+func (c *SchemaConfig) GetColumnProjection(sourceTable string) *ColumnProjection {
+	for _, t := range c.MigrateConfig {
+		if !strings.EqualFold(t.SourceTable, sourceTable) {
+			continue
+		}
+		if len(t.IncludeColumns) == 0 && len(t.ExcludeColumns) == 0 {
+			return nil
+		}
+		p := &ColumnProjection{
+			include: make(map[string]struct{}),
+			exclude: make(map[string]struct{}),
+		}
+		for _, col := range t.IncludeColumns {
+			p.include[strings.ToUpper(strings.TrimSpace(col))] = struct{}{}
+		}
+		for _, col := range t.ExcludeColumns {
+			p.exclude[strings.ToUpper(strings.TrimSpace(col))] = struct{}{}
+		}
+		return p
+	}
+	return nil
+}
+
+// IsProjected 字段是否迁移，字段名忽略大小写以及引号
+func (p *ColumnProjection) IsProjected(columnName string) bool {
+	if p == nil {
+		return true
+	}
+	col := strings.ToUpper(strings.Trim(strings.TrimSpace(columnName), "`\""))
+	if _, ok := p.exclude[col]; ok {
+		return false
+	}
+	if len(p.include) > 0 {
+		_, ok := p.include[col]
+		return ok
+	}
+	return true
+}
+
+// Exclude 追加排除字段，例如虚拟列不参与数据抽取，返回新字段投影
+func (p *ColumnProjection) Exclude(columnNames ...string) *ColumnProjection {
+	if len(columnNames) == 0 {
+		return p
+	}
+	np := &ColumnProjection{
+		include: make(map[string]struct{}),
+		exclude: make(map[string]struct{}),
+	}
+	if p != nil {
+		for c := range p.include {
+			np.include[c] = struct{}{}
+		}
+		for c := range p.exclude {
+			np.exclude[c] = struct{}{}
+		}
+	}
+	for _, c := range columnNames {
+		np.exclude[strings.ToUpper(strings.TrimSpace(c))] = struct{}{}
+	}
+	return np
+}
+
+// ProjectColumnINFO 过滤字段元数据，字段名取 COLUMN_NAME
+func (p *ColumnProjection) ProjectColumnINFO(columnsINFO []map[string]string) []map[string]string {
+	if p == nil {
+		return columnsINFO
+	}
+	var projected []map[string]string
+	for _, c := range columnsINFO {
+		if p.IsProjected(c["COLUMN_NAME"]) {
+			projected = append(projected, c)
+		}
+	}
+	return projected
+}
+
+// ProjectColumnNames 过滤字段名列表
+func (p *ColumnProjection) ProjectColumnNames(columnNames []string) []string {
+	if p == nil {
+		return columnNames
+	}
+	var projected []string
+	for _, c := range columnNames {
+		if p.IsProjected(c) {
+			projected = append(projected, c)
+		}
+	}
+	return projected
+}
+
+// IsColumnListProjected 约束以及索引字段列表（逗号分隔）是否全部迁移，存在排除字段的约束以及索引不迁移
+func (p *ColumnProjection) IsColumnListProjected(columnList string) bool {
+	if p == nil {
+		return true
+	}
+	for _, c := range strings.Split(columnList, ",") {
+		if !p.IsProjected(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsExpressionProjected 检查约束条件以及函数索引表达式是否引用排除字段，columnNames 为表全部字段
+func (p *ColumnProjection) IsExpressionProjected(expression string, columnNames []string) bool {
+	if p == nil {
+		return true
+	}
+	for _, c := range columnNames {
+		if p.IsProjected(c) {
+			continue
+		}
+		if regexp.MustCompile(fmt.Sprintf(`(?i)(^|[^\w$#])"?%s"?([^\w$#]|$)`, regexp.QuoteMeta(c))).MatchString(expression) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsIndexProjected 索引字段列表是否全部迁移，普通字段按字段名判断，函数索引表达式按引用字段判断，columnNames 为表全部字段
+func (p *ColumnProjection) IsIndexProjected(columnList string, columnNames []string) bool {
+	if p == nil {
+		return true
+	}
+	for _, c := range strings.Split(columnList, ",") {
+		col := strings.ToUpper(strings.Trim(strings.TrimSpace(c), "`\""))
+		isColumn := false
+		for _, name := range columnNames {
+			if strings.EqualFold(name, col) {
+				isColumn = true
+				break
+			}
+		}
+		if isColumn {
+			if !p.IsProjected(col) {
+				return false
+			}
+			continue
+		}
+		if !p.IsExpressionProjected(c, columnNames) {
+			return false
+		}
+	}
+	return true
+}
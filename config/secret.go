@@ -0,0 +1,258 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 密码配置项支持以下格式，未匹配任何格式视为明文
+//   - ENC(base64)：AES-GCM 加密密文，密钥来源 [secret] key-file，可通过 -encrypt 参数生成
+//   - env://VAR：读取环境变量 VAR
+//   - vault://path#field：读取 Vault KV 引擎 path 下 field 字段，兼容 KV v1/v2
+//   - 其他 scheme://ref：通过 RegisterSecretProvider 注册自定义获取方式
+const (
+	secretEncryptPrefix = "ENC("
+	secretEncryptSuffix = ")"
+	secretSchemeSep     = "://"
+
+	// Vault 请求默认超时时间，单位秒
+	defaultVaultTimeout = 10
+)
+
+type SecretConfig struct {
+	KeyFile        string `toml:"key-file" json:"key-file"`
+	VaultAddr      string `toml:"vault-addr" json:"vault-addr"`
+	VaultToken     string `toml:"vault-token" json:"vault-token"`
+	VaultNamespace string `toml:"vault-namespace" json:"vault-namespace"`
+	VaultTimeout   int    `toml:"vault-timeout" json:"vault-timeout"`
+}
+
+// SecretProvider 依据 scheme:// 之后的引用获取密码明文
+type SecretProvider func(sc SecretConfig, ref string) (string, error)
+
+var (
+	secretProviderMu sync.RWMutex
+	secretProviders  = make(map[string]SecretProvider)
+)
+
+func init() {
+	RegisterSecretProvider("env", envSecretProvider)
+	RegisterSecretProvider("vault", vaultSecretProvider)
+}
+
+// RegisterSecretProvider 注册密码获取方式，同名 scheme 覆盖内置实现
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProviderMu.Lock()
+	defer secretProviderMu.Unlock()
+	secretProviders[strings.ToLower(scheme)] = provider
+}
+
+// ResolveSecret 解析密码配置项
+func ResolveSecret(sc SecretConfig, value string) (string, error) {
+	if strings.HasPrefix(value, secretEncryptPrefix) && strings.HasSuffix(value, secretEncryptSuffix) {
+		key, err := sc.readKey()
+		if err != nil {
+			return "", err
+		}
+		return DecryptSecret(key, strings.TrimSuffix(strings.TrimPrefix(value, secretEncryptPrefix), secretEncryptSuffix))
+	}
+
+	idx := strings.Index(value, secretSchemeSep)
+	if idx <= 0 {
+		return value, nil
+	}
+	secretProviderMu.RLock()
+	provider, ok := secretProviders[strings.ToLower(value[:idx])]
+	secretProviderMu.RUnlock()
+	if !ok {
+		// 未注册 scheme 视为明文，避免包含 :// 的明文密码无法使用
+		return value, nil
+	}
+	return provider(sc, value[idx+len(secretSchemeSep):])
+}
+
+// EncryptSecret AES-GCM 加密，输出 ENC(base64) 格式，nonce 前置于密文
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretEncryptPrefix + base64.StdEncoding.EncodeToString(ciphertext) + secretEncryptSuffix, nil
+}
+
+func DecryptSecret(key []byte, encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("secret ciphertext base64 decode failed: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", fmt.Errorf("secret ciphertext length [%d] too short", len(ciphertext))
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("secret decrypt failed, please check key-file: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// readKey 读取 AES 密钥文件，内容为 16/24/32 字节原始密钥或其 hex/base64 编码，对应 AES-128/192/256
+func (sc SecretConfig) readKey() ([]byte, error) {
+	if sc.KeyFile == "" {
+		return nil, fmt.Errorf("secret config key-file can not null when password is encrypted")
+	}
+	content, err := os.ReadFile(sc.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("secret key-file [%s] read failed: %v", sc.KeyFile, err)
+	}
+	text := strings.TrimSpace(string(content))
+	if key, err := hex.DecodeString(text); err == nil && validKeyLength(key) {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && validKeyLength(key) {
+		return key, nil
+	}
+	if validKeyLength([]byte(text)) {
+		return []byte(text), nil
+	}
+	return nil, fmt.Errorf("secret key-file [%s] key length must be 16/24/32 bytes (raw, hex or base64 encoded)", sc.KeyFile)
+}
+
+func validKeyLength(key []byte) bool {
+	return len(key) == 16 || len(key) == 24 || len(key) == 32
+}
+
+func envSecretProvider(sc SecretConfig, ref string) (string, error) {
+	val, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("secret environment variable [%s] isn't exist", ref)
+	}
+	return val, nil
+}
+
+// vaultSecretProvider 读取 Vault KV 引擎，ref 格式 path#field，例如 secret/data/transferdb#oracle
+// vault-addr/vault-token 未配置时读取环境变量 VAULT_ADDR/VAULT_TOKEN，vault-token 支持 env:// 等格式
+func vaultSecretProvider(sc SecretConfig, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault secret reference [%s] format error, should be vault://path#field", ref)
+	}
+	addr := sc.VaultAddr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	token := sc.VaultToken
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	} else if strings.HasPrefix(token, "env"+secretSchemeSep) || strings.HasPrefix(token, secretEncryptPrefix) {
+		var err error
+		if token, err = ResolveSecret(sc, token); err != nil {
+			return "", err
+		}
+	}
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("vault secret [%s] vault-addr or vault-token can not null", ref)
+	}
+	timeout := sc.VaultTimeout
+	if timeout <= 0 {
+		timeout = defaultVaultTimeout
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if sc.VaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", sc.VaultNamespace)
+	}
+	resp, err := (&http.Client{Timeout: time.Duration(timeout) * time.Second}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault secret [%s] request failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault secret [%s] request failed, status [%s]", path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault secret [%s] response decode failed: %v", path, err)
+	}
+	// KV v2 字段位于 data.data，KV v1 字段位于 data
+	data := body.Data
+	if inner, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, exist := body.Data["metadata"]; exist {
+			data = inner
+		}
+	}
+	val, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret [%s] field [%s] isn't exist or isn't string", path, field)
+	}
+	return val, nil
+}
+
+// resolveSecrets 解析各数据库密码配置项
+func (c *Config) resolveSecrets() error {
+	for name, password := range map[string]*string{
+		"oracle":     &c.OracleConfig.Password,
+		"mysql":      &c.MySQLConfig.Password,
+		"postgresql": &c.PostgreSQLConfig.Password,
+		"sqlserver":  &c.SQLServerConfig.Password,
+		"clickhouse": &c.ClickHouseConfig.Password,
+		"meta":       &c.MetaConfig.Password,
+		"s3":         &c.S3Config.SecretKey,
+	} {
+		val, err := ResolveSecret(c.SecretConfig, *password)
+		if err != nil {
+			return fmt.Errorf("config [%s] password resolve failed: %v", name, err)
+		}
+		*password = val
+	}
+	return nil
+}
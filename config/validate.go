@@ -0,0 +1,276 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/filter"
+	"reflect"
+	"strings"
+)
+
+// decodeError toml 解析错误输出出错行以及列位置
+func decodeError(name string, err error) error {
+	var perr toml.ParseError
+	if errors.As(err, &perr) {
+		return fmt.Errorf("failed decode toml config %s:\n%s", name, perr.ErrorWithPosition())
+	}
+	return fmt.Errorf("failed decode toml config %s: %v", name, err)
+}
+
+// CheckUndecodedKeys 未知配置项报错，输出配置项所在行号以及相近配置项提示，未知 section 只输出 section
+func CheckUndecodedKeys(name, content string, md toml.MetaData) error {
+	undecoded := md.Undecoded()
+	if len(undecoded) == 0 {
+		return nil
+	}
+	unknowns := make(map[string]struct{}, len(undecoded))
+	for _, k := range undecoded {
+		unknowns[k.String()] = struct{}{}
+	}
+	lines := tomlKeyLines(content)
+	validKeys := tomlValidKeys(reflect.TypeOf(Config{}), "")
+
+	var errMsg []string
+	for _, k := range undecoded {
+		if len(k) > 1 {
+			if _, ok := unknowns[k[:len(k)-1].String()]; ok {
+				continue
+			}
+		}
+		msg := fmt.Sprintf("  - unknown config key [%s]", k.String())
+		if line, ok := lines[k.String()]; ok {
+			msg = fmt.Sprintf("  - line %d: unknown config key [%s]", line, k.String())
+		}
+		if suggest := suggestKey(k.String(), validKeys); suggest != "" {
+			msg = common.StringsBuilder(msg, ", did you mean [", suggest, "]?")
+		}
+		errMsg = append(errMsg, msg)
+	}
+	return fmt.Errorf("config %s validate failed:\n%s", name, strings.Join(errMsg, "\n"))
+}
+
+// validate 必填项、互斥项以及表过滤规则校验，全部校验失败项合并输出
+// 未指定 mode 不校验，由运行阶段报错
+func (c *Config) validate() error {
+	if strings.EqualFold(c.TaskMode, "") {
+		return nil
+	}
+	var errMsg []string
+	required := func(section, key, value string) {
+		if strings.EqualFold(strings.TrimSpace(value), "") {
+			errMsg = append(errMsg, fmt.Sprintf("  - [%s] %s is required in mode [%s]", section, key, strings.ToLower(c.TaskMode)))
+		}
+	}
+
+	dbMode := common.IsContainString([]string{common.TaskModeAssess, common.TaskModeReverse, common.TaskModeCheck, common.TaskModeCompare,
+		common.TaskModeCSV, common.TaskModeFull, common.TaskModeAll, common.TaskModeCheckEnv}, c.TaskMode)
+	if dbMode && !strings.EqualFold(c.TaskMode, common.TaskModeCheckEnv) {
+		required("schema-config", "source-schema", c.SchemaConfig.SourceSchema)
+	}
+	// 源端以及目标端连接配置，assess 以及 csv 模式不连接目标端
+	if dbMode {
+		dbTypes := []string{c.DBTypeS}
+		if !strings.EqualFold(c.TaskMode, common.TaskModeAssess) && !strings.EqualFold(c.TaskMode, common.TaskModeCSV) {
+			dbTypes = append(dbTypes, c.DBTypeT)
+		}
+		for _, dbType := range dbTypes {
+			switch dbType {
+			case common.DatabaseTypeOracle:
+				if !c.OracleConfig.ExternalAuth {
+					required("oracle", "username", c.OracleConfig.Username)
+				}
+				if strings.EqualFold(c.OracleConfig.ConnectString, "") {
+					required("oracle", "host", c.OracleConfig.Host)
+				}
+			case common.DatabaseTypeMySQL, common.DatabaseTypeTiDB:
+				required("mysql", "username", c.MySQLConfig.Username)
+				required("mysql", "host", c.MySQLConfig.Host)
+			}
+		}
+	}
+	// 元数据库，dry-run 以及 check-env 不连接元数据库
+	if !c.DryRun && !strings.EqualFold(c.TaskMode, common.TaskModeCheckEnv) {
+		required("meta", "username", c.MetaConfig.Username)
+		required("meta", "host", c.MetaConfig.Host)
+		required("meta", "meta-schema", c.MetaConfig.MetaSchema)
+	}
+
+	if len(c.SchemaConfig.SourceIncludeTable) > 0 && len(c.SchemaConfig.SourceExcludeTable) > 0 {
+		errMsg = append(errMsg, "  - [schema-config] source-include-table and source-exclude-table can not be configured at the same time")
+	}
+	if _, err := filter.Parse(c.SchemaConfig.SourceIncludeTable); err != nil {
+		errMsg = append(errMsg, fmt.Sprintf("  - [schema-config] source-include-table %v parse failed: %v", c.SchemaConfig.SourceIncludeTable, err))
+	}
+	if _, err := filter.Parse(c.SchemaConfig.SourceExcludeTable); err != nil {
+		errMsg = append(errMsg, fmt.Sprintf("  - [schema-config] source-exclude-table %v parse failed: %v", c.SchemaConfig.SourceExcludeTable, err))
+	}
+
+	if c.DiffConfig.SpotCheckRows < 0 {
+		errMsg = append(errMsg, fmt.Sprintf("  - [diff] spot-check-rows [%d] can not be less than 0", c.DiffConfig.SpotCheckRows))
+	}
+	// 数据校验容差
+	if c.DiffConfig.FloatEpsilon < 0 || c.DiffConfig.FloatEpsilon >= 1 {
+		errMsg = append(errMsg, fmt.Sprintf("  - [diff] float-epsilon [%v] must be in range [0, 1)", c.DiffConfig.FloatEpsilon))
+	}
+	if c.DiffConfig.TimestampPrecision < 0 || c.DiffConfig.TimestampPrecision > common.MaxCompareTimestampPrecision {
+		errMsg = append(errMsg, fmt.Sprintf("  - [diff] timestamp-precision [%d] must be in range [0, %d]", c.DiffConfig.TimestampPrecision, common.MaxCompareTimestampPrecision))
+	}
+	if c.DiffConfig.OnlineCompareTimeout < 0 {
+		errMsg = append(errMsg, fmt.Sprintf("  - [diff] online-compare-timeout [%d] can not be less than 0", c.DiffConfig.OnlineCompareTimeout))
+	}
+	if c.DiffConfig.OnlineCompareRetries < 0 {
+		errMsg = append(errMsg, fmt.Sprintf("  - [diff] online-compare-retries [%d] can not be less than 0", c.DiffConfig.OnlineCompareRetries))
+	}
+
+	// 多 schema 增量路由，源端 schema 不能重复
+	if len(c.AllConfig.SchemaRoute) > 0 && !strings.EqualFold(c.TaskMode, common.TaskModeAll) {
+		errMsg = append(errMsg, fmt.Sprintf("  - [all] schema-route only support mode [all], current mode [%s]", strings.ToLower(c.TaskMode)))
+	}
+	routeSchemas := []string{common.StringUPPER(c.SchemaConfig.SourceSchema)}
+	for _, route := range c.AllConfig.SchemaRoute {
+		required("all.schema-route", "source-schema", route.SourceSchema)
+		if common.IsContainString(routeSchemas, common.StringUPPER(route.SourceSchema)) {
+			errMsg = append(errMsg, fmt.Sprintf("  - [all.schema-route] source-schema [%s] is duplicate", route.SourceSchema))
+		}
+		routeSchemas = append(routeSchemas, common.StringUPPER(route.SourceSchema))
+		if len(route.SourceIncludeTable) > 0 && len(route.SourceExcludeTable) > 0 {
+			errMsg = append(errMsg, fmt.Sprintf("  - [all.schema-route] source-schema [%s] source-include-table and source-exclude-table can not be configured at the same time", route.SourceSchema))
+		}
+		if _, err := filter.Parse(route.SourceIncludeTable); err != nil {
+			errMsg = append(errMsg, fmt.Sprintf("  - [all.schema-route] source-schema [%s] source-include-table %v parse failed: %v", route.SourceSchema, route.SourceIncludeTable, err))
+		}
+		if _, err := filter.Parse(route.SourceExcludeTable); err != nil {
+			errMsg = append(errMsg, fmt.Sprintf("  - [all.schema-route] source-schema [%s] source-exclude-table %v parse failed: %v", route.SourceSchema, route.SourceExcludeTable, err))
+		}
+	}
+
+	if len(errMsg) > 0 {
+		return fmt.Errorf("config validate failed:\n%s", strings.Join(errMsg, "\n"))
+	}
+	return nil
+}
+
+// tomlKeyLines 扫描配置内容，返回配置项完整路径对应行号，同一配置项多次出现取首次
+func tomlKeyLines(content string) map[string]int {
+	var (
+		section string
+		lines   = make(map[string]int)
+	)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			section = strings.TrimSpace(strings.Trim(strings.SplitN(line, "#", 2)[0], "[] \t"))
+			if _, ok := lines[section]; !ok {
+				lines[section] = i + 1
+			}
+		default:
+			idx := strings.Index(line, "=")
+			if idx <= 0 {
+				continue
+			}
+			key := strings.Trim(strings.TrimSpace(line[:idx]), `"'`)
+			if section != "" {
+				key = common.StringsBuilder(section, ".", key)
+			}
+			if _, ok := lines[key]; !ok {
+				lines[key] = i + 1
+			}
+		}
+	}
+	return lines
+}
+
+// tomlValidKeys 根据结构体 toml tag 生成全部合法配置项完整路径
+func tomlValidKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = common.StringsBuilder(prefix, ".", tag)
+		}
+		keys = append(keys, key)
+
+		ft := f.Type
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			keys = append(keys, tomlValidKeys(ft, key)...)
+		}
+	}
+	return keys
+}
+
+// suggestKey 同一 section 下编辑距离最小且不超过 2 的合法配置项，section 拼写错误按 section 提示
+func suggestKey(key string, validKeys []string) string {
+	var (
+		suggest string
+		minDist = 3
+	)
+	parent := key[:strings.LastIndex(key, ".")+1]
+	for _, v := range validKeys {
+		if v[:strings.LastIndex(v, ".")+1] != parent {
+			continue
+		}
+		if d := editDistance(key, v); d < minDist {
+			minDist = d
+			suggest = v
+		}
+	}
+	return suggest
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(nums ...int) int {
+	m := nums[0]
+	for _, n := range nums[1:] {
+		if n < m {
+			m = n
+		}
+	}
+	return m
+}
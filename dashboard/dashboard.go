@@ -0,0 +1,353 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dashboard
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	iofs "io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//go:embed template
+var fs embed.FS
+
+const (
+	// 最近错误记录默认条数以及上限
+	recentErrorLimit    = 50
+	recentErrorMaxLimit = 500
+	// 接口查询元数据库超时时间
+	queryTimeout = 10 * time.Second
+)
+
+// Dashboard 任务 Web 面板，页面轮询 JSON 接口展示表级别进度、吞吐、最近错误以及增量延迟
+// 任务进度以及错误来源元数据库，吞吐以及增量延迟来源当前进程 prometheus 指标
+type Dashboard struct {
+	cfg *config.Config
+
+	mu     sync.Mutex
+	metaDB *meta.Meta
+}
+
+// Register 注册面板路由，与 pprof 以及 /metrics 共用 pprof-port 端口
+func Register(mux *http.ServeMux, cfg *config.Config) {
+	d := &Dashboard{cfg: cfg}
+	mux.Handle("/dashboard/", http.StripPrefix("/dashboard/", http.FileServer(http.FS(mustSub()))))
+	mux.HandleFunc("/dashboard/api/tasks", d.tasks)
+	mux.HandleFunc("/dashboard/api/errors", d.errors)
+	mux.HandleFunc("/dashboard/api/incr", d.incr)
+	mux.HandleFunc("/dashboard/api/throughput", d.throughput)
+}
+
+// 元数据库连接延迟到首次访问创建，避免面板影响任务启动，连接失败下次访问重试
+func (d *Dashboard) getMetaDB(ctx context.Context) (*meta.Meta, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.metaDB != nil {
+		return d.metaDB, nil
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, d.cfg.MetaConfig, d.cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	d.metaDB = metaDB
+	return metaDB, nil
+}
+
+type taskStatus struct {
+	TaskMode         string `json:"task_mode"`
+	TableNameS       string `json:"table_name_s"`
+	TaskStatus       string `json:"task_status"`
+	ChunkTotalNums   int64  `json:"chunk_total_nums"`
+	ChunkSuccessNums int64  `json:"chunk_success_nums"`
+	ChunkFailedNums  int64  `json:"chunk_failed_nums"`
+	TableNumRows     uint64 `json:"table_num_rows"`
+	RowsMoved        uint64 `json:"rows_moved"`
+	Progress         int    `json:"progress"`
+	Remaining        string `json:"remaining"`
+	UpdatedAt        string `json:"updated_at"`
+}
+
+func (d *Dashboard) tasks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), queryTimeout)
+	defer cancel()
+	metaDB, err := d.getMetaDB(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     d.cfg.DBTypeS,
+		DBTypeT:     d.cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(d.cfg.SchemaConfig.SourceSchema),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sort.Slice(waitSyncMetas, func(i, j int) bool {
+		if waitSyncMetas[i].TaskMode != waitSyncMetas[j].TaskMode {
+			return waitSyncMetas[i].TaskMode < waitSyncMetas[j].TaskMode
+		}
+		return waitSyncMetas[i].TableNameS < waitSyncMetas[j].TableNameS
+	})
+
+	tasks := make([]taskStatus, 0, len(waitSyncMetas))
+	summary := make(map[string]int)
+	for _, m := range waitSyncMetas {
+		summary[m.TaskStatus]++
+		t := taskStatus{
+			TaskMode:         m.TaskMode,
+			TableNameS:       m.TableNameS,
+			TaskStatus:       m.TaskStatus,
+			ChunkTotalNums:   m.ChunkTotalNums,
+			ChunkSuccessNums: m.ChunkSuccessNums,
+			ChunkFailedNums:  m.ChunkFailedNums,
+			TableNumRows:     m.TableNumRows,
+		}
+		if m.BaseModel != nil {
+			t.UpdatedAt = m.UpdatedAt.Format("2006-01-02 15:04:05")
+		}
+		// 全量任务 chunk 未切分 ChunkTotalNums 为 -1，以已完成 chunk 平均耗时估算剩余时间
+		if m.ChunkTotalNums > 0 {
+			t.Progress = int(m.ChunkSuccessNums * 100 / m.ChunkTotalNums)
+			t.RowsMoved = uint64(float64(m.TableNumRows) * float64(m.ChunkSuccessNums) / float64(m.ChunkTotalNums))
+			if m.TaskStatus == common.TaskStatusRunning && m.ChunkSuccessNums > 0 && m.BaseModel != nil {
+				elapsed := m.UpdatedAt.Sub(m.CreatedAt)
+				remainChunks := m.ChunkTotalNums - m.ChunkSuccessNums - m.ChunkFailedNums
+				if elapsed > 0 && remainChunks > 0 {
+					t.Remaining = (time.Duration(int64(elapsed) / m.ChunkSuccessNums * remainChunks)).Truncate(time.Second).String()
+				}
+			}
+		}
+		if m.TaskStatus == common.TaskStatusSuccess {
+			t.Progress = 100
+		}
+		tasks = append(tasks, t)
+	}
+	writeJSON(w, map[string]interface{}{
+		"schema":  d.cfg.SchemaConfig.SourceSchema,
+		"tasks":   tasks,
+		"summary": summary,
+	})
+}
+
+type errorRecord struct {
+	Source     string `json:"source"`
+	TaskMode   string `json:"task_mode"`
+	TableNameS string `json:"table_name_s"`
+	Detail     string `json:"detail"`
+	Error      string `json:"error"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func (d *Dashboard) errors(w http.ResponseWriter, r *http.Request) {
+	limit := recentErrorLimit
+	if val, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && val > 0 {
+		limit = val
+	}
+	if limit > recentErrorMaxLimit {
+		limit = recentErrorMaxLimit
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), queryTimeout)
+	defer cancel()
+	metaDB, err := d.getMetaDB(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	chunkErrs, err := meta.NewChunkErrorDetailModel(metaDB).DetailRecentChunkErrorDetail(ctx, &meta.ChunkErrorDetail{
+		DBTypeS:     d.cfg.DBTypeS,
+		DBTypeT:     d.cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(d.cfg.SchemaConfig.SourceSchema),
+	}, limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	logErrs, err := meta.NewErrorLogDetailModel(metaDB).DetailRecentErrorLog(ctx, &meta.ErrorLogDetail{
+		DBTypeS:     d.cfg.DBTypeS,
+		DBTypeT:     d.cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(d.cfg.SchemaConfig.SourceSchema),
+	}, limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var (
+		records   []errorRecord
+		createdAt []time.Time
+	)
+	for _, e := range chunkErrs {
+		rec := errorRecord{Source: "chunk_error_detail", TaskMode: e.TaskMode, TableNameS: e.TableNameS, Detail: e.ChunkDetailS, Error: e.ErrorDetail}
+		var t time.Time
+		if e.BaseModel != nil {
+			t = e.CreatedAt
+			rec.CreatedAt = t.Format("2006-01-02 15:04:05")
+		}
+		records = append(records, rec)
+		createdAt = append(createdAt, t)
+	}
+	for _, e := range logErrs {
+		rec := errorRecord{Source: "error_log_detail", TaskMode: e.TaskMode, TableNameS: e.TableNameS, Detail: e.InfoDetail, Error: e.ErrorDetail}
+		var t time.Time
+		if e.BaseModel != nil {
+			t = e.CreatedAt
+			rec.CreatedAt = t.Format("2006-01-02 15:04:05")
+		}
+		records = append(records, rec)
+		createdAt = append(createdAt, t)
+	}
+
+	// 两类错误记录按时间倒序合并，取最近 limit 条
+	idx := make([]int, len(records))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return createdAt[idx[i]].After(createdAt[idx[j]]) })
+	sorted := make([]errorRecord, 0, len(records))
+	for _, i := range idx {
+		if len(sorted) == limit {
+			break
+		}
+		sorted = append(sorted, records[i])
+	}
+	writeJSON(w, map[string]interface{}{"errors": sorted})
+}
+
+type incrTable struct {
+	TableNameS string `json:"table_name_s"`
+	TableNameT string `json:"table_name_t"`
+	GlobalScnS uint64 `json:"global_scn_s"`
+	TableScnS  uint64 `json:"table_scn_s"`
+}
+
+func (d *Dashboard) incr(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), queryTimeout)
+	defer cancel()
+	metaDB, err := d.getMetaDB(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(metaDB).DetailIncrSyncMetaBySchema(ctx, &meta.IncrSyncMeta{
+		DBTypeS:     d.cfg.DBTypeS,
+		DBTypeT:     d.cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(d.cfg.SchemaConfig.SourceSchema),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sort.Slice(incrSyncMetas, func(i, j int) bool {
+		return incrSyncMetas[i].TableNameS < incrSyncMetas[j].TableNameS
+	})
+	tables := make([]incrTable, 0, len(incrSyncMetas))
+	for _, m := range incrSyncMetas {
+		tables = append(tables, incrTable{TableNameS: m.TableNameS, TableNameT: m.TableNameT, GlobalScnS: m.GlobalScnS, TableScnS: m.TableScnS})
+	}
+
+	// 增量延迟来源当前进程 all 模式指标，非 all 模式进程指标不存在
+	values, err := d.gatherMetrics()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"tables":           tables,
+		"applied_scn":      values["transferdb_incr_applied_scn"],
+		"source_scn":       values["transferdb_incr_source_scn"],
+		"unreplicated_scn": values["transferdb_incr_unreplicated_scn"],
+		"lag_seconds":      values["transferdb_incr_lag_seconds"],
+	})
+}
+
+func (d *Dashboard) throughput(w http.ResponseWriter, r *http.Request) {
+	values, err := d.gatherMetrics()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"timestamp":       time.Now().UnixMilli(),
+		"rows_read":       values["transferdb_full_rows_read_total"],
+		"batches_written": values["transferdb_full_batches_written_total"],
+		"bytes_written":   values["transferdb_full_bytes_written_total"],
+	})
+}
+
+// gatherMetrics 汇总当前进程 transferdb 指标，按 schema 标签过滤当前任务 schema，返回指标名 -> 指标值之和
+func (d *Dashboard) gatherMetrics() (map[string]float64, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			matched := false
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "schema" && common.StringUPPER(l.GetValue()) == common.StringUPPER(d.cfg.SchemaConfig.SourceSchema) {
+					matched = true
+				}
+			}
+			if !matched {
+				continue
+			}
+			switch {
+			case m.GetCounter() != nil:
+				values[f.GetName()] += m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				values[f.GetName()] += m.GetGauge().GetValue()
+			}
+		}
+	}
+	return values, nil
+}
+
+// 页面静态资源位于 template 目录
+func mustSub() iofs.FS {
+	sub, err := iofs.Sub(fs, "template")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		zap.L().Warn("dashboard write response failed", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,182 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"strings"
+	"time"
+)
+
+type ClickHouse struct {
+	Ctx          context.Context
+	ClickHouseDB driver.Conn
+	// ON CLUSTER 集群名，为空单机执行 DDL
+	Cluster string
+	// 目标端数据写入限速，nil 不限速
+	Throttle *common.Throttle
+}
+
+// NewClickHouseDBEngine 基于 native 协议连接 clickhouse，addrs 多地址连接失败按顺序切换
+func NewClickHouseDBEngine(ctx context.Context, chCfg config.ClickHouseConfig) (*ClickHouse, error) {
+	if len(chCfg.Addrs) == 0 {
+		return nil, fmt.Errorf("clickhouse config addrs can't be null")
+	}
+
+	compression := &clickhouse.Compression{Method: clickhouse.CompressionLZ4}
+	switch strings.ToLower(chCfg.Compression) {
+	case "", "lz4":
+	case "zstd":
+		compression.Method = clickhouse.CompressionZSTD
+	case "none":
+		compression.Method = clickhouse.CompressionNone
+	default:
+		return nil, fmt.Errorf("clickhouse config compression [%s] isn't support, only support lz4/zstd/none", chCfg.Compression)
+	}
+
+	dialTimeout := common.ClickHouseDialTimeout
+	if chCfg.DialTimeout > 0 {
+		dialTimeout = time.Duration(chCfg.DialTimeout) * time.Second
+	}
+	maxIdleConns := common.ClickHouseMaxIdleConn
+	if chCfg.MaxIdleConns > 0 {
+		maxIdleConns = chCfg.MaxIdleConns
+	}
+	maxOpenConns := common.ClickHouseMaxConn
+	if chCfg.MaxOpenConns > 0 {
+		maxOpenConns = chCfg.MaxOpenConns
+	}
+	connMaxLifetime := common.ClickHouseConnMaxLifeTime
+	if chCfg.ConnMaxLifetime > 0 {
+		connMaxLifetime = time.Duration(chCfg.ConnMaxLifetime) * time.Second
+	}
+
+	conn, err := clickhouse.Open(&clickhouse.Options{
+		Addr: chCfg.Addrs,
+		Auth: clickhouse.Auth{
+			Username: chCfg.Username,
+			Password: chCfg.Password,
+		},
+		Compression:     compression,
+		DialTimeout:     dialTimeout,
+		MaxIdleConns:    maxIdleConns,
+		MaxOpenConns:    maxOpenConns,
+		ConnMaxLifetime: connMaxLifetime,
+		ClientInfo: clickhouse.ClientInfo{
+			Products: []struct {
+				Name    string
+				Version string
+			}{{Name: "transferdb", Version: "v1"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on open clickhouse database connection: %v", err)
+	}
+	if err = conn.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("error on ping clickhouse database connection: %v", err)
+	}
+
+	return &ClickHouse{
+		Ctx:          ctx,
+		ClickHouseDB: conn,
+		Cluster:      chCfg.Cluster,
+	}, nil
+}
+
+// OnCluster 分布式 DDL 子句
+func (c *ClickHouse) OnCluster() string {
+	if c.Cluster == "" {
+		return ""
+	}
+	return common.StringsBuilder(" ON CLUSTER ", common.QuoteClickHouseIdentifier(c.Cluster))
+}
+
+func (c *ClickHouse) WriteClickHouseTable(sql string) error {
+	err := c.ClickHouseDB.Exec(c.Ctx, sql)
+	if err != nil {
+		return fmt.Errorf("clickhouse sql [%v] exec failed: %v", sql, err)
+	}
+	return nil
+}
+
+func (c *ClickHouse) TruncateClickHouseTable(schemaName, tableName string) error {
+	return c.WriteClickHouseTable(fmt.Sprintf("TRUNCATE TABLE IF EXISTS %s.%s%s",
+		common.QuoteClickHouseIdentifier(schemaName), common.QuoteClickHouseIdentifier(tableName), c.OnCluster()))
+}
+
+// GetClickHouseTableColumnType 获取表字段类型，用于写入前按字段类型转换字段值
+func (c *ClickHouse) GetClickHouseTableColumnType(schemaName, tableName string) (map[string]string, error) {
+	querySQL := `SELECT name, type FROM system.columns WHERE database = ? AND table = ?`
+	rows, err := c.ClickHouseDB.Query(c.Ctx, querySQL, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse sql [%v] query failed: %v", querySQL, err)
+	}
+	defer rows.Close()
+
+	columnTypes := make(map[string]string)
+	for rows.Next() {
+		var name, columnType string
+		if err = rows.Scan(&name, &columnType); err != nil {
+			return nil, fmt.Errorf("clickhouse sql [%v] query rows.Scan failed: %v", querySQL, err)
+		}
+		columnTypes[name] = columnType
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("clickhouse sql [%v] query rows.Next failed: %v", querySQL, err)
+	}
+	if len(columnTypes) == 0 {
+		return nil, fmt.Errorf("clickhouse table [%s.%s] isn't exist, please reverse table first", schemaName, tableName)
+	}
+	return columnTypes, nil
+}
+
+// InsertClickHouseTable native 协议批量写入，rows 字段值按 columnTypes 字段类型转换
+func (c *ClickHouse) InsertClickHouseTable(schemaName, tableName string, columnNames, columnTypes []string, rows [][]interface{}) (int64, error) {
+	var quoteColumns []string
+	for _, col := range columnNames {
+		quoteColumns = append(quoteColumns, common.QuoteClickHouseIdentifier(col))
+	}
+	insertSQL := fmt.Sprintf("INSERT INTO %s.%s (%s)",
+		common.QuoteClickHouseIdentifier(schemaName), common.QuoteClickHouseIdentifier(tableName), strings.Join(quoteColumns, ","))
+
+	batch, err := c.ClickHouseDB.PrepareBatch(c.Ctx, insertSQL)
+	if err != nil {
+		return 0, fmt.Errorf("clickhouse sql [%v] prepare batch failed: %v", insertSQL, err)
+	}
+	for _, row := range rows {
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			values[i], err = ConvertClickHouseValue(columnTypes[i], v)
+			if err != nil {
+				_ = batch.Abort()
+				return 0, fmt.Errorf("clickhouse table [%s.%s] column [%s] %v", schemaName, tableName, columnNames[i], err)
+			}
+		}
+		if err = batch.Append(values...); err != nil {
+			_ = batch.Abort()
+			return 0, fmt.Errorf("clickhouse sql [%v] batch append failed: %v", insertSQL, err)
+		}
+	}
+	if err = batch.Send(); err != nil {
+		return 0, fmt.Errorf("clickhouse sql [%v] batch send failed: %v", insertSQL, err)
+	}
+	return int64(len(rows)), nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clickhouse
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 源端时间 TO_CHAR 统一格式，按 UTC 解析，写入 DateTime64(p, 'UTC') 保持源端时间字面值
+var datetimeParseLayouts = []string{"2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// ConvertClickHouseValue 源端解码值（oracle.ColumnDecoder 结果）按 clickhouse 字段类型转换，native 协议要求字段值类型严格匹配
+func ConvertClickHouseValue(columnType string, value interface{}) (interface{}, error) {
+	chType := strings.TrimSpace(columnType)
+	nullable := false
+	for {
+		switch {
+		case strings.HasPrefix(chType, "Nullable(") && strings.HasSuffix(chType, ")"):
+			nullable = true
+			chType = chType[len("Nullable(") : len(chType)-1]
+			continue
+		case strings.HasPrefix(chType, "LowCardinality(") && strings.HasSuffix(chType, ")"):
+			chType = chType[len("LowCardinality(") : len(chType)-1]
+			continue
+		}
+		break
+	}
+	if value == nil {
+		if nullable {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("type [%s] isn't nullable, value can't be NULL", columnType)
+	}
+
+	baseType := chType
+	if idx := strings.Index(chType, "("); idx > 0 {
+		baseType = chType[:idx]
+	}
+
+	switch baseType {
+	case "String", "FixedString", "UUID", "Enum8", "Enum16":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		case time.Time:
+			return v.Format("2006-01-02 15:04:05.999999999"), nil
+		case decimal.Decimal:
+			return v.String(), nil
+		default:
+			return fmt.Sprintf("%v", v), nil
+		}
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64":
+		i, err := toInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		switch baseType {
+		case "Int8":
+			if i < math.MinInt8 || i > math.MaxInt8 {
+				return nil, fmt.Errorf("value [%d] overflow type [%s]", i, columnType)
+			}
+			return int8(i), nil
+		case "Int16":
+			if i < math.MinInt16 || i > math.MaxInt16 {
+				return nil, fmt.Errorf("value [%d] overflow type [%s]", i, columnType)
+			}
+			return int16(i), nil
+		case "Int32":
+			if i < math.MinInt32 || i > math.MaxInt32 {
+				return nil, fmt.Errorf("value [%d] overflow type [%s]", i, columnType)
+			}
+			return int32(i), nil
+		case "Int64":
+			return i, nil
+		default:
+			if i < 0 {
+				return nil, fmt.Errorf("value [%d] overflow type [%s]", i, columnType)
+			}
+			switch baseType {
+			case "UInt8":
+				return uint8(i), nil
+			case "UInt16":
+				return uint16(i), nil
+			case "UInt32":
+				return uint32(i), nil
+			default:
+				return uint64(i), nil
+			}
+		}
+	case "Float32", "Float64":
+		var f float64
+		switch v := value.(type) {
+		case float32:
+			f = float64(v)
+		case float64:
+			f = v
+		case int64:
+			f = float64(v)
+		case decimal.Decimal:
+			f, _ = v.Float64()
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("value [%s] parse type [%s] failed: %v", v, columnType, err)
+			}
+		default:
+			return nil, fmt.Errorf("value type [%T] can't convert type [%s]", value, columnType)
+		}
+		if baseType == "Float32" {
+			return float32(f), nil
+		}
+		return f, nil
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		switch v := value.(type) {
+		case decimal.Decimal:
+			return v, nil
+		case int64:
+			return decimal.NewFromInt(v), nil
+		case float32:
+			return decimal.NewFromFloat32(v), nil
+		case float64:
+			return decimal.NewFromFloat(v), nil
+		case string:
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, fmt.Errorf("value [%s] parse type [%s] failed: %v", v, columnType, err)
+			}
+			return d, nil
+		default:
+			return nil, fmt.Errorf("value type [%T] can't convert type [%s]", value, columnType)
+		}
+	case "Date", "Date32", "DateTime", "DateTime64":
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			for _, layout := range datetimeParseLayouts {
+				if t, err := time.ParseInLocation(layout, v, time.UTC); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("value [%s] parse type [%s] failed, layout isn't match", v, columnType)
+		default:
+			return nil, fmt.Errorf("value type [%T] can't convert type [%s]", value, columnType)
+		}
+	case "Bool":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		default:
+			return nil, fmt.Errorf("value type [%T] can't convert type [%s]", value, columnType)
+		}
+	default:
+		return value, nil
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case decimal.Decimal:
+		if !v.IsInteger() || !v.BigInt().IsInt64() {
+			return 0, fmt.Errorf("value [%s] can't convert integer", v.String())
+		}
+		return v.IntPart(), nil
+	case float32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value [%s] can't convert integer: %v", v, err)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("value type [%T] can't convert integer", value)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+// SchemaReader 源端表结构读取
+type SchemaReader interface {
+	// GetSchemaTables 获取 schema 下所有数据表
+	GetSchemaTables(schemaName string) ([]string, error)
+	// GetSchemaTableColumns 获取表字段信息，按字段顺序返回，COLUMN_NAME 字段名、DATA_TYPE 字段类型
+	GetSchemaTableColumns(schemaName, tableName string) ([]map[string]string, error)
+	// GetSchemaTablePrimaryKey 获取表主键字段，无主键返回空
+	GetSchemaTablePrimaryKey(schemaName, tableName string) ([]string, error)
+	// GetSchemaTableRows 获取表数据行数（统计信息）
+	GetSchemaTableRows(schemaName, tableName string) (int64, error)
+}
+
+// Chunker 源端表数据切分
+type Chunker interface {
+	// GetTableChunks 按 chunkSize 行数切分表数据，返回源端 WHERE 条件，无法切分返回 1 = 1
+	GetTableChunks(schemaName, tableName string, chunkSize int) ([]string, error)
+}
+
+// RowReader 源端表数据读取
+type RowReader interface {
+	// GenTableColumnDetail 生成查询字段，时间等类型格式化为下游可识别格式，columns 为 GetSchemaTableColumns 返回字段信息
+	GenTableColumnDetail(columns []map[string]string) string
+	// GenTableQuerySQL 生成表 chunk 数据查询语句
+	GenTableQuerySQL(schemaName, tableName, columnDetail, chunk string) string
+	// GetTableRowsData 读取表数据，按 insertBatchSize 批量写入通道，字段值为下游 MySQL/TiDB SQL 字面量
+	GetTableRowsData(querySQL string, insertBatchSize int, targetDBCharset, charsetErrorMode string, dataChan chan []map[string]string) error
+}
+
+// RowChange 源端增量变更记录
+type RowChange struct {
+	// Position 变更位点，例如 SQLServer LSN 十六进制字符串
+	Position string
+	// Operation 变更类型 INSERT/UPDATE/DELETE
+	Operation string
+	// Values 字段值为下游 MySQL/TiDB SQL 字面量，UPDATE 为变更后字段值
+	Values map[string]string
+}
+
+// CDCReader 源端增量变更读取
+type CDCReader interface {
+	// GetCurrentPosition 获取源端当前最大变更位点
+	GetCurrentPosition() (string, error)
+	// GetTableChanges 获取 (fromPosition, toPosition] 区间表变更记录，按变更顺序返回
+	GetTableChanges(schemaName, tableName, fromPosition, toPosition, targetDBCharset, charsetErrorMode string) ([]RowChange, error)
+}
+
+// Source 迁移源端，同一套全量/增量同步、数据校验以及元数据流程基于该接口适配不同源端数据库
+type Source interface {
+	SchemaReader
+	Chunker
+	RowReader
+	CDCReader
+	// DBType 源端数据库类型
+	DBType() string
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/linkedin/goavro/v2"
+	"github.com/wentaojin/transferdb/common"
+)
+
+// ChangeEvent 变更事件，before/after 字段值 nil 代表 NULL，二进制字段 JSON 格式 base64 编码
+// INSERT/READ 只存在 after，DELETE 只存在 before，UPDATE 同时存在，DDL/TRUNCATE 只存在 ddl
+type ChangeEvent struct {
+	Schema    string                 `json:"schema"`
+	Table     string                 `json:"table"`
+	Operation string                 `json:"op"`
+	SCN       uint64                 `json:"scn"`
+	Timestamp int64                  `json:"ts_ms"`
+	KeyNames  []string               `json:"pk_names"`
+	Before    map[string]interface{} `json:"before"`
+	After     map[string]interface{} `json:"after"`
+	DDL       string                 `json:"ddl,omitempty"`
+}
+
+// AvroSchema 变更事件 Avro schema，消息采用 Avro Single Object Encoding（魔数 + schema 指纹 + 二进制数据）
+const AvroSchema = `{
+  "type": "record",
+  "name": "ChangeEvent",
+  "namespace": "transferdb",
+  "fields": [
+    {"name": "schema", "type": "string"},
+    {"name": "table", "type": "string"},
+    {"name": "op", "type": "string"},
+    {"name": "scn", "type": "long"},
+    {"name": "ts_ms", "type": "long"},
+    {"name": "pk_names", "type": {"type": "array", "items": "string"}},
+    {"name": "before", "type": ["null", {"type": "map", "values": ["null", "string", "bytes"]}], "default": null},
+    {"name": "after", "type": ["null", {"type": "map", "values": ["null", "string", "bytes"]}], "default": null},
+    {"name": "ddl", "type": ["null", "string"], "default": null}
+  ]
+}`
+
+var avroCodec *goavro.Codec
+
+func init() {
+	codec, err := goavro.NewCodec(AvroSchema)
+	if err != nil {
+		panic(fmt.Errorf("kafka change event avro schema parse failed: %v", err))
+	}
+	avroCodec = codec
+}
+
+// Key 消息 key，主键/唯一键字段值 JSON 对象，DELETE 取 before，其他取 after
+// 表不存在主键/唯一键或者 DDL 返回 nil，消息分区随机分配
+func (e ChangeEvent) Key() ([]byte, error) {
+	if len(e.KeyNames) == 0 {
+		return nil, nil
+	}
+	data := e.After
+	if e.Operation == common.ChangeOperationDelete {
+		data = e.Before
+	}
+	if data == nil {
+		return nil, nil
+	}
+	keys := make(map[string]interface{}, len(e.KeyNames))
+	for _, k := range e.KeyNames {
+		keys[k] = data[k]
+	}
+	key, err := json.Marshal(keys)
+	if err != nil {
+		return nil, fmt.Errorf("kafka change event schema table [%s.%s] key marshal failed: %v", e.Schema, e.Table, err)
+	}
+	return key, nil
+}
+
+// Encode 按 format 序列化，json / avro
+func (e ChangeEvent) Encode(format string) ([]byte, error) {
+	if format != common.KafkaFormatAvro {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return nil, fmt.Errorf("kafka change event schema table [%s.%s] json marshal failed: %v", e.Schema, e.Table, err)
+		}
+		return value, nil
+	}
+
+	keyNames := make([]interface{}, 0, len(e.KeyNames))
+	for _, k := range e.KeyNames {
+		keyNames = append(keyNames, k)
+	}
+	native := map[string]interface{}{
+		"schema":   e.Schema,
+		"table":    e.Table,
+		"op":       e.Operation,
+		"scn":      int64(e.SCN),
+		"ts_ms":    e.Timestamp,
+		"pk_names": keyNames,
+		"before":   avroRowUnion(e.Before),
+		"after":    avroRowUnion(e.After),
+		"ddl":      nil,
+	}
+	if e.DDL != "" {
+		native["ddl"] = goavro.Union("string", e.DDL)
+	}
+	value, err := avroCodec.SingleFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("kafka change event schema table [%s.%s] avro encode failed: %v", e.Schema, e.Table, err)
+	}
+	return value, nil
+}
+
+func avroRowUnion(data map[string]interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+	row := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		switch val := v.(type) {
+		case nil:
+			row[k] = nil
+		case []byte:
+			row[k] = goavro.Union("bytes", val)
+		default:
+			row[k] = goavro.Union("string", fmt.Sprintf("%v", val))
+		}
+	}
+	return goavro.Union("map", row)
+}
+
+// NewRowData SQL 字面量字段值转换事件字段值，字段名去除反引号
+func NewRowData(data map[string]interface{}) (map[string]interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	row := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		val, err := common.SQLValueToMessageValue(fmt.Sprintf("%v", v))
+		if err != nil {
+			return nil, err
+		}
+		row[common.ReplaceSpecifiedString(k, "`", "")] = val
+	}
+	return row, nil
+}
+
+// NewKeyNames 主键/唯一键字段名去除反引号
+func NewKeyNames(keyColumns []string) []string {
+	keyNames := make([]string, 0, len(keyColumns))
+	for _, k := range keyColumns {
+		keyNames = append(keyNames, common.ReplaceSpecifiedString(k, "`", ""))
+	}
+	return keyNames
+}
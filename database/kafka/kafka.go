@@ -0,0 +1,143 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"strings"
+	"time"
+)
+
+type Kafka struct {
+	Ctx         context.Context
+	Writer      *kafka.Writer
+	TopicPrefix string
+	Format      string
+	// 消息发布限速，nil 不限速
+	Throttle *common.Throttle
+}
+
+func NewKafkaProducer(ctx context.Context, kafkaCfg config.KafkaConfig) (*Kafka, error) {
+	if len(kafkaCfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka config brokers can't be null")
+	}
+
+	format := common.StringUPPER(kafkaCfg.Format)
+	if format == "" {
+		format = common.KafkaFormatJSON
+	}
+	if format != common.KafkaFormatJSON && format != common.KafkaFormatAvro {
+		return nil, fmt.Errorf("kafka config format [%s] isn't support, only support json/avro", kafkaCfg.Format)
+	}
+
+	// 默认全部副本确认
+	acks := kafka.RequireAll
+	if kafkaCfg.RequiredAcks != "" {
+		if err := acks.UnmarshalText([]byte(strings.ToLower(kafkaCfg.RequiredAcks))); err != nil {
+			return nil, fmt.Errorf("kafka config required-acks failed: %v", err)
+		}
+	}
+	var compression kafka.Compression
+	if kafkaCfg.Compression != "" {
+		if err := compression.UnmarshalText([]byte(strings.ToLower(kafkaCfg.Compression))); err != nil {
+			return nil, fmt.Errorf("kafka config compression failed: %v", err)
+		}
+	}
+
+	batchSize := common.KafkaDefaultBatchSize
+	if kafkaCfg.BatchSize > 0 {
+		batchSize = kafkaCfg.BatchSize
+	}
+	batchTimeout := common.KafkaDefaultBatchTimeout
+	if kafkaCfg.BatchTimeout > 0 {
+		batchTimeout = kafkaCfg.BatchTimeout
+	}
+	topicPrefix := common.KafkaDefaultTopicPrefix
+	if kafkaCfg.TopicPrefix != "" {
+		topicPrefix = kafkaCfg.TopicPrefix
+	}
+
+	conn, err := kafka.DialContext(ctx, "tcp", kafkaCfg.Brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("error on dial kafka broker [%s] connection: %v", kafkaCfg.Brokers[0], err)
+	}
+	if err = conn.Close(); err != nil {
+		return nil, fmt.Errorf("error on close kafka broker [%s] connection: %v", kafkaCfg.Brokers[0], err)
+	}
+
+	return &Kafka{
+		Ctx: ctx,
+		Writer: &kafka.Writer{
+			Addr: kafka.TCP(kafkaCfg.Brokers...),
+			// 相同 key 路由至相同分区，保证单行变更有序
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           acks,
+			Compression:            compression,
+			BatchSize:              batchSize,
+			BatchTimeout:           time.Duration(batchTimeout) * time.Millisecond,
+			AllowAutoTopicCreation: kafkaCfg.AutoCreateTopic,
+		},
+		TopicPrefix: topicPrefix,
+		Format:      format,
+	}, nil
+}
+
+// Topic 表级别 topic，格式：${topic-prefix}.${schema}.${table}
+func (k *Kafka) Topic(schemaName, tableName string) string {
+	return common.StringsBuilder(k.TopicPrefix, ".", schemaName, ".", tableName)
+}
+
+// WriteEvents 变更事件同步发布，全部消息确认后返回
+func (k *Kafka) WriteEvents(events []ChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var (
+		msgs       []kafka.Message
+		eventBytes int
+	)
+	for _, e := range events {
+		key, err := e.Key()
+		if err != nil {
+			return err
+		}
+		value, err := e.Encode(k.Format)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Topic: k.Topic(e.Schema, e.Table),
+			Key:   key,
+			Value: value,
+		})
+		eventBytes += len(key) + len(value)
+	}
+	if err := k.Throttle.Wait(k.Ctx, len(events), eventBytes); err != nil {
+		return err
+	}
+	if err := k.Writer.WriteMessages(k.Ctx, msgs...); err != nil {
+		return fmt.Errorf("kafka write schema table [%s.%s] messages failed: %v", events[0].Schema, events[0].Table, err)
+	}
+	return nil
+}
+
+func (k *Kafka) Close() error {
+	return k.Writer.Close()
+}
@@ -24,6 +24,7 @@ import (
 
 type ChunkErrorDetail struct {
 	ID           uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID       string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map;comment:'任务 ID'" json:"task_id"`
 	DBTypeS      string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT      string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
@@ -79,3 +80,19 @@ func (rw *ChunkErrorDetail) CreateChunkErrorDetail(ctx context.Context, createS
 	}
 	return nil
 }
+
+// DetailRecentChunkErrorDetail 按编号倒序获取 schema 最近 limit 条 chunk 错误记录
+func (rw *ChunkErrorDetail) DetailRecentChunkErrorDetail(ctx context.Context, detailS *ChunkErrorDetail, limit int) ([]ChunkErrorDetail, error) {
+	var chunkErrDetails []ChunkErrorDetail
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return chunkErrDetails, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Order("id DESC").Limit(limit).Find(&chunkErrDetails).Error; err != nil {
+		return chunkErrDetails, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return chunkErrDetails, nil
+}
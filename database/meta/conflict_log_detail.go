@@ -0,0 +1,70 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// 增量同步冲突审计表，记录 UPDATE/DELETE 影响行数为 0 以及 INSERT 主键/唯一键冲突事件
+type ConflictLogDetail struct {
+	ID             uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID         string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map;comment:'任务 ID'" json:"task_id"`
+	DBTypeS        string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT        string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS     string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT    string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT     string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	SourceScn      uint64 `gorm:"comment:'源端事件 SCN'" json:"source_scn"`
+	Operation      string `gorm:"type:varchar(30);not null;comment:'事件操作类型'" json:"operation"`
+	ConflictType   string `gorm:"type:varchar(30);not null;comment:'冲突类型'" json:"conflict_type"`
+	ConflictPolicy string `gorm:"type:varchar(30);not null;comment:'冲突处理策略'" json:"conflict_policy"`
+	SourceRedo     string `gorm:"type:longtext;not null;comment:'源端 Redo SQL'" json:"source_redo"`
+	TargetRedo     string `gorm:"type:longtext;not null;comment:'目标端执行 SQL'" json:"target_redo"`
+	ErrorDetail    string `gorm:"type:longtext;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewConflictLogDetailModel(m *Meta) *ConflictLogDetail {
+	return &ConflictLogDetail{
+		BaseModel: &BaseModel{
+			Meta: m,
+		},
+	}
+}
+
+func (rw *ConflictLogDetail) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [ConflictLogDetail] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *ConflictLogDetail) CreateConflictLog(ctx context.Context, createS *ConflictLogDetail) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
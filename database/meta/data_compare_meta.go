@@ -25,6 +25,7 @@ import (
 // 数据校验元数据表
 type DataCompareMeta struct {
 	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID        string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_obj,unique;comment:'任务 ID'" json:"task_id"`
 	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_obj,unique;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_obj,unique;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_obj,unique;comment:'源端 schema'" json:"schema_name_s"`
@@ -99,7 +100,8 @@ func (rw *DataCompareMeta) TruncateDataCompareMeta(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	err = rw.DB(ctx).Raw(fmt.Sprintf("TRUNCATE TABLE %s", table)).Error
+	// 元数据按任务 ID 隔离，仅清理当前任务记录
+	err = rw.DB(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&DataCompareMeta{}).Error
 	if err != nil {
 		return fmt.Errorf("truncate table [%s] record failed: %v", table, err)
 	}
@@ -84,3 +84,22 @@ func (rw *ColumnDatatypeRule) DetailColumnRule(ctx context.Context, detailS *Col
 
 	return columnRuleMap, nil
 }
+
+func (rw *ColumnDatatypeRule) DetailColumnRuleByTable(ctx context.Context, detailS *ColumnDatatypeRule) ([]ColumnDatatypeRule, error) {
+	var columnRuleMap []ColumnDatatypeRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ? AND UPPER(table_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		common.StringUPPER(detailS.TableNameS)).Find(&columnRuleMap).Error; err != nil {
+		return columnRuleMap, fmt.Errorf("detail table [%s] record by table failed: %v", table, err)
+	}
+
+	return columnRuleMap, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 目标端 DDL 审计表，记录工具在目标端执行的 CREATE/DROP/TRUNCATE/ALTER/RENAME 语句
+type DDLAuditLog struct {
+	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID      string `gorm:"type:varchar(64);not null;default:'';index:idx_task_db;comment:'任务 ID'" json:"task_id"`
+	DBTypeT     string `gorm:"type:varchar(30);index:idx_task_db;comment:'目标数据库类型'" json:"db_type_t"`
+	TaskMode    string `gorm:"type:varchar(30);comment:'任务模式'" json:"task_mode"`
+	Operation   string `gorm:"type:varchar(30);not null;comment:'语句操作类型'" json:"operation"`
+	Statement   string `gorm:"type:longtext;not null;comment:'目标端执行语句'" json:"statement"`
+	ExecStatus  string `gorm:"type:varchar(30);not null;comment:'执行结果'" json:"exec_status"`
+	ErrorDetail string `gorm:"type:longtext;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewDDLAuditLogModel(m *Meta) *DDLAuditLog {
+	return &DDLAuditLog{
+		BaseModel: &BaseModel{
+			Meta: m,
+		},
+	}
+}
+
+func (rw *DDLAuditLog) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [DDLAuditLog] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *DDLAuditLog) CreateDDLAuditLog(ctx context.Context, createS *DDLAuditLog) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+// DDLAudit 目标端 DDL 审计，语句执行结果写入审计表以及追加写入审计文件，file 为空只写审计表
+// 审计写入失败仅告警，不影响任务执行
+type DDLAudit struct {
+	Ctx      context.Context
+	MetaDB   *Meta
+	TaskID   string
+	DBTypeT  string
+	TaskMode string
+	File     string
+	mu       sync.Mutex
+}
+
+func NewDDLAudit(ctx context.Context, metaDB *Meta, taskID, dbTypeT, taskMode, file string) *DDLAudit {
+	return &DDLAudit{
+		Ctx:      ctx,
+		MetaDB:   metaDB,
+		TaskID:   taskID,
+		DBTypeT:  dbTypeT,
+		TaskMode: taskMode,
+		File:     file,
+	}
+}
+
+func (a *DDLAudit) Audit(sql string, execErr error) {
+	operation, ok := common.IsDDLAuditStatement(sql)
+	if !ok {
+		return
+	}
+	status := common.DDLAuditStatusSuccess
+	var errDetail string
+	if execErr != nil {
+		status = common.DDLAuditStatusFailed
+		errDetail = execErr.Error()
+	}
+	if err := NewDDLAuditLogModel(a.MetaDB).CreateDDLAuditLog(a.Ctx, &DDLAuditLog{
+		TaskID:      a.TaskID,
+		DBTypeT:     a.DBTypeT,
+		TaskMode:    a.TaskMode,
+		Operation:   operation,
+		Statement:   sql,
+		ExecStatus:  status,
+		ErrorDetail: errDetail,
+	}); err != nil {
+		zap.L().Warn("target ddl audit record meta failed", zap.String("sql", sql), zap.Error(err))
+	}
+	if err := a.writeFile(operation, status, sql, errDetail); err != nil {
+		zap.L().Warn("target ddl audit record file failed", zap.String("file", a.File), zap.String("sql", sql), zap.Error(err))
+	}
+}
+
+// writeFile 追加写入审计文件，每条语句一行头信息：时间 任务 ID 目标数据库类型 任务模式 操作类型 执行结果
+func (a *DDLAudit) writeFile(operation, status, sql, errDetail string) error {
+	if a.File == "" {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.OpenFile(a.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := common.StringsBuilder("-- [", time.Now().Format(common.DDLAuditTimeFormat), "] [", a.TaskID, "] [", a.DBTypeT, "] [", a.TaskMode, "] [", operation, "] [", status, "]")
+	if errDetail != "" {
+		header = common.StringsBuilder(header, " ", strings.ReplaceAll(errDetail, "\n", " "))
+	}
+	_, err = file.WriteString(common.StringsBuilder(header, "\n", strings.TrimRight(strings.TrimSpace(sql), ";"), ";\n"))
+	return err
+}
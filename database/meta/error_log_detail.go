@@ -24,6 +24,7 @@ import (
 
 type ErrorLogDetail struct {
 	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID      string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map;comment:'任务 ID'" json:"task_id"`
 	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
@@ -101,3 +102,19 @@ func (rw *ErrorLogDetail) CountsErrorLogBySchema(ctx context.Context, detailS *E
 	}
 	return totals, nil
 }
+
+// DetailRecentErrorLog 按编号倒序获取 schema 最近 limit 条错误记录
+func (rw *ErrorLogDetail) DetailRecentErrorLog(ctx context.Context, detailS *ErrorLogDetail, limit int) ([]ErrorLogDetail, error) {
+	var tableErrDetails []ErrorLogDetail
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return tableErrDetails, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND UPPER(schema_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Order("id DESC").Limit(limit).Find(&tableErrDetails).Error; err != nil {
+		return tableErrDetails, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return tableErrDetails, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// IndexRebuildMeta 全量装载前删除的下游二级索引以及外键，装载完成重建后清理，用于中断后断点续传重建
+type IndexRebuildMeta struct {
+	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID      string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map;comment:'任务 ID'" json:"task_id"`
+	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT  string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	TaskMode    string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_map;comment:'任务模式'" json:"task_mode"`
+	IndexName   string `gorm:"type:varchar(100);not null;comment:'索引/外键名'" json:"index_name"`
+	IndexType   string `gorm:"type:varchar(30);not null;comment:'INDEX/FOREIGN KEY'" json:"index_type"`
+	Definition  string `gorm:"type:longtext;not null;comment:'ALTER TABLE ADD 子句'" json:"definition"`
+	*BaseModel
+}
+
+func NewIndexRebuildMetaModel(m *Meta) *IndexRebuildMeta {
+	return &IndexRebuildMeta{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *IndexRebuildMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [IndexRebuildMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *IndexRebuildMeta) BatchCreateIndexRebuildMeta(ctx context.Context, createS []IndexRebuildMeta, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).CreateInBatches(createS, batchSize).Error; err != nil {
+		return fmt.Errorf("batch create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *IndexRebuildMeta) DetailIndexRebuildMeta(ctx context.Context, detailS *IndexRebuildMeta) ([]IndexRebuildMeta, error) {
+	var rebuilds []IndexRebuildMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return rebuilds, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		common.StringUPPER(detailS.TableNameS),
+		detailS.TaskMode).Order("id").Find(&rebuilds).Error; err != nil {
+		return rebuilds, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return rebuilds, nil
+}
+
+// DetailIndexRebuildMetaTables 获取 schema 存在待重建索引的源端表列表
+func (rw *IndexRebuildMeta) DetailIndexRebuildMetaTables(ctx context.Context, detailS *IndexRebuildMeta) ([]string, error) {
+	var tables []string
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return tables, err
+	}
+	if err = rw.DB(ctx).Model(&IndexRebuildMeta{}).Distinct("table_name_s").Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.TaskMode).Pluck("table_name_s", &tables).Error; err != nil {
+		return tables, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return tables, nil
+}
+
+func (rw *IndexRebuildMeta) DeleteIndexRebuildMeta(ctx context.Context, deleteS *IndexRebuildMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		common.StringUPPER(deleteS.TableNameS),
+		deleteS.TaskMode).Delete(&IndexRebuildMeta{}).Error
+	if err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
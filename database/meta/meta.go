@@ -25,6 +25,7 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
+	"sync"
 )
 
 type Meta struct {
@@ -73,6 +74,9 @@ func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MetaConfig, slowThresh
 	if err != nil {
 		return nil, fmt.Errorf("error on open meta database connection: %v", err)
 	}
+	if err = registerTaskScope(gormDB); err != nil {
+		return nil, fmt.Errorf("error on register meta database task scope callback: %v", err)
+	}
 
 	return &Meta{GormDB: gormDB}, nil
 }
@@ -98,7 +102,12 @@ func (m *Meta) DB(ctx context.Context) *gorm.DB {
 }
 
 func (m *Meta) MigrateTables() (err error) {
-	return m.migrateStream(
+	return m.migrateStream(metaModels()...)
+}
+
+// metaModels 元数据库表模型列表
+func metaModels() []interface{} {
+	return []interface{}{
 		new(ColumnDatatypeRule),
 		new(TableDatatypeRule),
 		new(SchemaDatatypeRule),
@@ -112,8 +121,31 @@ func (m *Meta) MigrateTables() (err error) {
 		new(BuildinObjectCompatible),
 		new(BuildinDatatypeRule),
 		new(TableNameRule),
+		new(ColumnNameRule),
 		new(ChunkErrorDetail),
+		new(ConflictLogDetail),
+		new(DDLAuditLog),
+		new(MigratePlan),
+		new(IndexRebuildMeta),
+		new(CDCSyncMeta),
+		new(TaskMeta),
+	}
+}
+
+// DryRunTables 返回 prepare 阶段待创建的元数据库表名列表，无需连接元数据库
+func DryRunTables() ([]string, error) {
+	var (
+		tables []string
+		cache  sync.Map
 	)
+	for _, model := range metaModels() {
+		s, err := schema.Parse(model, &cache, schema.NamingStrategy{SingularTable: true})
+		if err != nil {
+			return tables, fmt.Errorf("error on parse meta model: %v", err)
+		}
+		tables = append(tables, s.Table)
+	}
+	return tables, nil
 }
 
 func (m *Meta) InitDefaultValue(ctx context.Context) error {
@@ -154,6 +186,9 @@ func (m *Meta) InitDefaultValue(ctx context.Context) error {
 
 func (m *Meta) migrateStream(models ...interface{}) (err error) {
 	for _, model := range models {
+		if err = m.upgradeTaskIndex(model); err != nil {
+			return fmt.Errorf("error on migrate stream: %v", err)
+		}
 		err = m.GormDB.AutoMigrate(model)
 		if err != nil {
 			return fmt.Errorf("error on migrate stream: %v", err)
@@ -162,6 +197,30 @@ func (m *Meta) migrateStream(models ...interface{}) (err error) {
 	return nil
 }
 
+// upgradeTaskIndex 历史版本元数据表不存在 task_id 字段，AutoMigrate 不会重建已存在索引，
+// 需先删除旧索引，由 AutoMigrate 新增 task_id 字段并重建包含 task_id 的索引，历史元数据 task_id 为空
+func (m *Meta) upgradeTaskIndex(model interface{}) error {
+	migrator := m.GormDB.Migrator()
+	if !migrator.HasTable(model) || migrator.HasColumn(model, "TaskID") {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: m.GormDB}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	if stmt.Schema.LookUpField("TaskID") == nil {
+		return nil
+	}
+	for name := range stmt.Schema.ParseIndexes() {
+		if migrator.HasIndex(model, name) {
+			if err := migrator.DropIndex(model, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func ArrayStructGroupsOf[T any](fsm []T, num int64) [][]T {
 	max := int64(len(fsm))
 	//判断数组大小是否小于等于指定分割大小的值，是则把原数组放入二维数组返回
@@ -0,0 +1,101 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// MigratePlan 全量迁移前预估计划，按任务模式每次启动重新生成
+type MigratePlan struct {
+	ID                uint    `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID            string  `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map;comment:'任务 ID'" json:"task_id"`
+	DBTypeS           string  `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT           string  `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS       string  `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS        string  `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT       string  `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT        string  `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	TaskMode          string  `gorm:"type:varchar(30);not null;index:idx_dbtype_st_map;comment:'任务模式'" json:"task_mode"`
+	EstimatedRows     int64   `gorm:"comment:'统计信息预估行数'" json:"estimated_rows"`
+	SourceBytes       int64   `gorm:"comment:'源端表段大小（字节）'" json:"source_bytes"`
+	ChunkSize         int     `gorm:"comment:'chunk 切分行数'" json:"chunk_size"`
+	EstimatedChunks   int     `gorm:"comment:'预估 chunk 数'" json:"estimated_chunks"`
+	TargetBytes       int64   `gorm:"comment:'目标端预估存储大小（字节）'" json:"target_bytes"`
+	EstimatedDuration float64 `gorm:"comment:'预估迁移耗时（秒）'" json:"estimated_duration"`
+	*BaseModel
+}
+
+func NewMigratePlanModel(m *Meta) *MigratePlan {
+	return &MigratePlan{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *MigratePlan) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [MigratePlan] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *MigratePlan) DeleteMigratePlanBySchemaTaskMode(ctx context.Context, deleteS *MigratePlan) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		deleteS.TaskMode).Delete(&MigratePlan{}).Error
+	if err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *MigratePlan) BatchCreateMigratePlan(ctx context.Context, createS []MigratePlan, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).CreateInBatches(createS, batchSize).Error; err != nil {
+		return fmt.Errorf("batch create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *MigratePlan) DetailMigratePlan(ctx context.Context, detailS *MigratePlan) ([]MigratePlan, error) {
+	var plans []MigratePlan
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return plans, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.TaskMode).Order("id").Find(&plans).Error; err != nil {
+		return plans, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return plans, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// 上下游数据表字段名字映射规则
+type ColumnNameRule struct {
+	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端库 schema'" json:"schema_name_s"`
+	TableNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
+	ColumnNameS string `gorm:"type:varchar(200);not null;index:idx_dbtype_st_map,unique;comment:'源端表字段列名'" json:"column_name_s"`
+	ColumnNameT string `gorm:"type:varchar(200);not null;comment:'目标表字段列名'" json:"column_name_t"`
+	*BaseModel
+}
+
+func NewColumnNameRuleModel(m *Meta) *ColumnNameRule {
+	return &ColumnNameRule{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *ColumnNameRule) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [ColumnNameRule] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *ColumnNameRule) DetailColumnNameRule(ctx context.Context, detailS *ColumnNameRule) ([]ColumnNameRule, error) {
+	var columnRuleMap []ColumnNameRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ? AND UPPER(table_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		common.StringUPPER(detailS.TableNameS)).Find(&columnRuleMap).Error; err != nil {
+		return columnRuleMap, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return columnRuleMap, nil
+}
+
+// DetailColumnNameRuleMap 获取 schema 表字段名映射规则，表名 -> 源端字段名 -> 目标字段名，表名以及源端字段名大写
+func (rw *ColumnNameRule) DetailColumnNameRuleMap(ctx context.Context, detailS *ColumnNameRule) (map[string]map[string]string, error) {
+	var columnRules []ColumnNameRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Find(&columnRules).Error; err != nil {
+		return nil, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+
+	columnRuleMap := make(map[string]map[string]string)
+	for _, cr := range columnRules {
+		// 字段名未变更无需映射
+		if strings.EqualFold(cr.ColumnNameS, cr.ColumnNameT) {
+			continue
+		}
+		tableName := common.StringUPPER(cr.TableNameS)
+		if _, ok := columnRuleMap[tableName]; !ok {
+			columnRuleMap[tableName] = make(map[string]string)
+		}
+		columnRuleMap[tableName][common.StringUPPER(cr.ColumnNameS)] = cr.ColumnNameT
+	}
+	return columnRuleMap, nil
+}
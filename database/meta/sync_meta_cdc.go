@@ -0,0 +1,111 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 增量 CDC 同步元数据表，用于非 SCN 位点源端（例如 SQLServer LSN）记录表级别增量同步位点
+type CDCSyncMeta struct {
+	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID        string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map,unique;comment:'任务 ID'" json:"task_id"`
+	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT   string `gorm:"type:varchar(100);not null;comment:'目标 schema'" json:"schema_name_t"`
+	TableNameT    string `gorm:"type:varchar(100);not null;comment:'目标表名'" json:"table_name_t"`
+	StartPosition string `gorm:"type:varchar(100);comment:'全量开始前源端变更位点'" json:"start_position"`
+	Position      string `gorm:"type:varchar(100);comment:'源端表已同步变更位点'" json:"position"`
+	*BaseModel
+}
+
+func NewCDCSyncMetaModel(m *Meta) *CDCSyncMeta {
+	return &CDCSyncMeta{BaseModel: &BaseModel{
+		Meta: m}}
+}
+
+func (rw *CDCSyncMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [CDCSyncMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *CDCSyncMeta) DetailCDCSyncMetaBySchema(ctx context.Context, detailS *CDCSyncMeta) ([]CDCSyncMeta, error) {
+	var cdcMetas []CDCSyncMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return cdcMetas, err
+	}
+	if err = rw.DB(ctx).
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			detailS.SchemaNameS,
+		).
+		Find(&cdcMetas).Error; err != nil {
+		return cdcMetas, fmt.Errorf("detail table [%s] record by column [schema_name_s] failed: %v", table, err)
+	}
+	return cdcMetas, nil
+}
+
+func (rw *CDCSyncMeta) BatchCreateCDCSyncMeta(ctx context.Context, createS []CDCSyncMeta, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).CreateInBatches(createS, batchSize).Error; err != nil {
+		return fmt.Errorf("batch create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *CDCSyncMeta) UpdateCDCSyncMetaPosition(ctx context.Context, detailS *CDCSyncMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Model(&CDCSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		detailS.SchemaNameS,
+		detailS.TableNameS).
+		Updates(CDCSyncMeta{Position: detailS.Position}).Error; err != nil {
+		return fmt.Errorf("update table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *CDCSyncMeta) DeleteCDCSyncMetaBySchema(ctx context.Context, deleteS *CDCSyncMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		deleteS.SchemaNameS).Delete(&CDCSyncMeta{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 // 全量同步元数据表
 type FullSyncMeta struct {
 	ID             uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID         string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'任务 ID'" json:"task_id"`
 	DBTypeS        string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT        string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'源端 schema'" json:"schema_name_s"`
@@ -25,6 +25,7 @@ import (
 // 增量同步元数据表
 type IncrSyncMeta struct {
 	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID      string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map,unique;comment:'任务 ID'" json:"task_id"`
 	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
@@ -33,6 +34,7 @@ type IncrSyncMeta struct {
 	TableNameT  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'目标表名'" json:"table_name_t"`
 	GlobalScnS  uint64 `gorm:"comment:'源端全局 SCN'" json:"global_scn_s"`
 	TableScnS   uint64 `gorm:"comment:'源端表同步 SCN'" json:"table_scn_s"`
+	WatermarkS  string `gorm:"type:varchar(64);comment:'flashback 捕获 watermark-column 已同步水位'" json:"watermark_s"`
 	IsPartition string `gorm:"type:varchar(10);comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
 	*BaseModel
 }
@@ -148,7 +150,7 @@ func (rw *IncrSyncMeta) UpdateIncrSyncMeta(ctx context.Context, detailS *IncrSyn
 		common.StringUPPER(detailS.DBTypeT),
 		common.StringUPPER(detailS.SchemaNameS),
 		common.StringUPPER(detailS.TableNameS)).
-		Updates(IncrSyncMeta{GlobalScnS: detailS.GlobalScnS, TableScnS: detailS.TableScnS}).Error; err != nil {
+		Updates(IncrSyncMeta{GlobalScnS: detailS.GlobalScnS, TableScnS: detailS.TableScnS, WatermarkS: detailS.WatermarkS}).Error; err != nil {
 		return fmt.Errorf("update table [%s] record failed: %v", table, err)
 	}
 	return nil
@@ -25,6 +25,7 @@ import (
 // 同步元数据表
 type WaitSyncMeta struct {
 	ID               uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID           string `gorm:"type:varchar(64);not null;default:'';index:idx_dbtype_st_map,unique;comment:'任务 ID'" json:"task_id"`
 	DBTypeS          string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT          string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS      string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
@@ -220,3 +221,26 @@ func (rw *WaitSyncMeta) DetailWaitSyncMetaBySchema(ctx context.Context, detailS
 	}
 	return tableMetas, nil
 }
+
+// GetWaitSyncMetaMinGlobalScnSBySchema 获取当前任务未完成（WAITING/RUNNING/FAILED）且一致性读表的最小全局 SCN，用于断点续传沿用同一快照
+// 已完成表不参与，避免新一轮任务沿用历史任务 SCN（超出 undo 保留期 ORA-01555 以及增量起始 SCN 错误）
+func (rw *WaitSyncMeta) GetWaitSyncMetaMinGlobalScnSBySchema(ctx context.Context, detailS *WaitSyncMeta) (uint64, error) {
+	var globalSCN uint64
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return globalSCN, err
+	}
+	if err = rw.DB(ctx).Model(&WaitSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND global_scn_s > 0 AND task_mode = ? AND consistent_read = ? AND task_status IN (?)",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.TaskMode,
+		"YES",
+		[]string{common.TaskStatusWaiting, common.TaskStatusRunning, common.TaskStatusFailed},
+	).
+		Distinct().
+		Order("global_scn_s ASC").Limit(1).Pluck("global_scn_s", &globalSCN).Error; err != nil {
+		return globalSCN, fmt.Errorf("get table [%s] column [global_scn_s] min value failed: %v", table, err)
+	}
+	return globalSCN, nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+// 任务元数据表，记录任务配置快照以及运行状态
+// 表状态以及断点元数据表（wait_sync_meta、full_sync_meta 等）存在 task_id 字段，按任务 ID 隔离，相互独立的任务可同时运行
+type TaskMeta struct {
+	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskID      string `gorm:"type:varchar(64);not null;default:'';index:idx_task_id,unique;comment:'任务 ID'" json:"task_id"`
+	TaskMode    string `gorm:"type:varchar(30);not null;comment:'任务模式'" json:"task_mode"`
+	DBTypeS     string `gorm:"type:varchar(30);comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT     string `gorm:"type:varchar(30);comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS string `gorm:"type:varchar(100);comment:'源端 schema'" json:"schema_name_s"`
+	TaskStatus  string `gorm:"type:varchar(30);not null;comment:'任务状态'" json:"task_status"`
+	TaskConfig  string `gorm:"type:longtext;comment:'任务配置快照（密码脱敏）'" json:"task_config"`
+	ErrorDetail string `gorm:"type:longtext;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewTaskMetaModel(m *Meta) *TaskMeta {
+	return &TaskMeta{BaseModel: &BaseModel{
+		Meta: m}}
+}
+
+func (rw *TaskMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [TaskMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+// CreateOrUpdateTaskMeta 任务运行登记，相同任务 ID 重新运行覆盖配置快照以及状态
+func (rw *TaskMeta) CreateOrUpdateTaskMeta(ctx context.Context, createS *TaskMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"task_mode", "db_type_s", "db_type_t", "schema_name_s", "task_status", "task_config", "error_detail"}),
+	}).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *TaskMeta) UpdateTaskMetaStatus(ctx context.Context, taskID, taskStatus, errorDetail string) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Model(&TaskMeta{}).Where("task_id = ?", taskID).Updates(map[string]interface{}{
+		"TaskStatus":  taskStatus,
+		"ErrorDetail": errorDetail,
+	}).Error; err != nil {
+		return fmt.Errorf("update table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *TaskMeta) DetailTaskMeta(ctx context.Context, taskID string) ([]TaskMeta, error) {
+	var taskMetas []TaskMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return taskMetas, err
+	}
+	if err = rw.DB(ctx).Where("task_id = ?", taskID).Find(&taskMetas).Error; err != nil {
+		return taskMetas, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return taskMetas, nil
+}
+
+type ctxTaskIDKeyStruct struct{}
+
+var ctxTaskIDKey = ctxTaskIDKeyStruct{}
+
+// WithTaskID 上下文设置任务 ID，元数据库操作按任务 ID 隔离
+// 存在 TaskID 字段的表模型写入自动填充 task_id，查询/更新/删除自动追加 task_id 过滤条件
+// 未设置任务 ID 的上下文（例如 dashboard、接口进度汇总）不做隔离，可读取全部任务元数据
+func WithTaskID(ctx context.Context, taskID string) context.Context {
+	return context.WithValue(ctx, ctxTaskIDKey, taskID)
+}
+
+func TaskIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	taskID, ok := ctx.Value(ctxTaskIDKey).(string)
+	return taskID, ok
+}
+
+// registerTaskScope 注册 gorm 回调，依据上下文任务 ID 隔离元数据
+func registerTaskScope(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("transferdb:task_id_create", taskScopeCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register("transferdb:task_id_query", taskScopeWhere); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("transferdb:task_id_update", taskScopeWhere); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("transferdb:task_id_delete", taskScopeWhere); err != nil {
+		return err
+	}
+	return nil
+}
+
+func taskScopeCreate(db *gorm.DB) {
+	taskID, ok := TaskIDFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("TaskID")
+	if field == nil {
+		return
+	}
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := field.Set(db.Statement.Context, reflect.Indirect(rv.Index(i)), taskID); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(db.Statement.Context, rv, taskID); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
+
+func taskScopeWhere(db *gorm.DB) {
+	taskID, ok := TaskIDFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("TaskID")
+	if field == nil {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: taskID},
+	}})
+}
@@ -54,6 +54,37 @@ func (rw *Transaction) CreateErrorDetailAndUpdateWaitSyncMetaTaskStatus(ctx cont
 	return nil
 }
 
+// 断点续传失败重试：失败表状态重置为 RUNNING，并清理当前任务 chunk 错误记录
+func (rw *Transaction) UpdateFailedWaitSyncMetaAndDeleteChunkErrorDetail(ctx context.Context, waitSyncMeta *WaitSyncMeta) error {
+	txn := rw.DB(ctx).Begin()
+	err := txn.Model(&WaitSyncMeta{}).
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ? AND task_status = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS),
+			waitSyncMeta.TaskMode,
+			common.TaskStatusFailed).
+		Updates(map[string]interface{}{
+			"TaskStatus":      common.TaskStatusRunning,
+			"ChunkFailedNums": 0,
+		}).Error
+	if err != nil {
+		txn.Rollback()
+		return fmt.Errorf("update table [wait_sync_meta] reocrd by transaction failed: %v", err)
+	}
+	err = txn.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(waitSyncMeta.DBTypeS),
+		common.StringUPPER(waitSyncMeta.DBTypeT),
+		common.StringUPPER(waitSyncMeta.SchemaNameS),
+		waitSyncMeta.TaskMode).Delete(&ChunkErrorDetail{}).Error
+	if err != nil {
+		txn.Rollback()
+		return fmt.Errorf("delete table [chunk_error_detail] reocrd by transaction failed: %v", err)
+	}
+	txn.Commit()
+	return nil
+}
+
 func (rw *Transaction) DeleteTableDataCompareMetaAndUpdateWaitSyncMeta(ctx context.Context, deleteS *DataCompareMeta, updateS *WaitSyncMeta) error {
 	txn := rw.DB(ctx).Begin()
 	if err := txn.Model(DataCompareMeta{}).
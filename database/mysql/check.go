@@ -17,6 +17,7 @@ package mysql
 
 import (
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"strings"
 )
 
@@ -108,6 +109,27 @@ func (m *MySQL) GetMySQLDBServerCollation() (string, error) {
 	return res[0]["VALUE"], nil
 }
 
+// GetMySQLDBSQLMode 获取服务端全局 sql_mode
+func (m *MySQL) GetMySQLDBSQLMode() (string, error) {
+	_, res, err := Query(m.Ctx, m.MySQLDB, `SELECT @@GLOBAL.sql_mode AS SQL_MODE`)
+	if err != nil {
+		return "", err
+	}
+	return res[0]["SQL_MODE"], nil
+}
+
+// GetMySQLDBLowerCaseTableNames 非 0 时库表名按小写存储以及比较
+func (m *MySQL) GetMySQLDBLowerCaseTableNames() (string, error) {
+	_, res, err := Query(m.Ctx, m.MySQLDB, `SHOW VARIABLES LIKE 'lower_case_table_names'`)
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return common.MySQLLowerCaseTableNamesCaseSensitive, nil
+	}
+	return res[0]["VALUE"], nil
+}
+
 func (m *MySQL) IsExistMySQLTableCharacterSetAndCollation(schemaName, tableName string) (bool, error) {
 	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT
 	COUNT(1) COUNT
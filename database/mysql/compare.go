@@ -69,7 +69,7 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 
 	stringSet := set.NewStringSet()
 
-	rows, err = m.MySQLDB.Query(querySQL)
+	rows, err = m.MySQLDB.QueryContext(m.Ctx, querySQL)
 	if err != nil {
 		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
 	}
@@ -113,84 +113,11 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 		}
 
 		for i, raw := range rawResult {
-			// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
-			if raw == nil {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else if string(raw) == "" {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else {
-				switch columnTypes[i] {
-				case "int8":
-					r, err := common.StrconvIntBitSize(string(raw), 8)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "int16":
-					r, err := common.StrconvIntBitSize(string(raw), 16)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "int32", "sql.NullInt32":
-					r, err := common.StrconvIntBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "int64", "sql.NullInt64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint8":
-					r, err := common.StrconvUintBitSize(string(raw), 8)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint16":
-					r, err := common.StrconvUintBitSize(string(raw), 16)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint32":
-					r, err := common.StrconvUintBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float64", "sql.NullFloat64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				default:
-					// 特殊字符
-					rowsTMP = append(rowsTMP, fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)))
-				}
+			val, err := formatCompareRawValue(columnTypes[i], raw)
+			if err != nil {
+				return cols, stringSet, crc32Value, err
 			}
+			rowsTMP = append(rowsTMP, val)
 		}
 
 		rowS := exstrings.Join(rowsTMP, ",")
@@ -209,3 +136,131 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 
 	return cols, stringSet, crc32SUM, err
 }
+
+// GetMySQLDataRowValues 数据校验查询，按行返回各字段格式化值，格式与 GetMySQLDataRowStrings 一致，用于逐字段对比
+func (m *MySQL) GetMySQLDataRowValues(querySQL string) ([]string, [][]string, error) {
+	var results [][]string
+
+	rows, err := m.MySQLDB.QueryContext(m.Ctx, querySQL)
+	if err != nil {
+		return nil, results, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+	}
+	defer rows.Close()
+
+	var columnTypes []string
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, results, err
+	}
+	for _, ct := range colTypes {
+		columnTypes = append(columnTypes, ct.ScanType().String())
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return cols, results, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+	}
+
+	rawResult := make([][]byte, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range rawResult {
+		scans[i] = &rawResult[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(scans...); err != nil {
+			return cols, results, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+		}
+		values := make([]string, len(cols))
+		for i, raw := range rawResult {
+			if values[i], err = formatCompareRawValue(columnTypes[i], raw); err != nil {
+				return cols, results, err
+			}
+		}
+		results = append(results, values)
+	}
+
+	if err = rows.Err(); err != nil {
+		return cols, results, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+	}
+	return cols, results, nil
+}
+
+// formatCompareRawValue 数据校验字段值格式化
+// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
+func formatCompareRawValue(columnType string, raw []byte) (string, error) {
+	if raw == nil || string(raw) == "" {
+		return `NULL`, nil
+	}
+	switch columnType {
+	case "int8":
+		r, err := common.StrconvIntBitSize(string(raw), 8)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "int16":
+		r, err := common.StrconvIntBitSize(string(raw), 16)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "int32", "sql.NullInt32":
+		r, err := common.StrconvIntBitSize(string(raw), 32)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "int64", "sql.NullInt64":
+		r, err := common.StrconvIntBitSize(string(raw), 64)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "uint8":
+		r, err := common.StrconvUintBitSize(string(raw), 8)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "uint16":
+		r, err := common.StrconvUintBitSize(string(raw), 16)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "uint32":
+		r, err := common.StrconvUintBitSize(string(raw), 32)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "uint64":
+		r, err := common.StrconvUintBitSize(string(raw), 64)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "float32":
+		r, err := common.StrconvFloatBitSize(string(raw), 32)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "float64", "sql.NullFloat64":
+		r, err := common.StrconvFloatBitSize(string(raw), 64)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	case "rune":
+		r, err := common.StrconvRune(string(raw))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", r), nil
+	default:
+		// 特殊字符
+		return fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)), nil
+	}
+}
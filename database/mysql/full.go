@@ -16,11 +16,41 @@ limitations under the License.
 package mysql
 
 import (
+	"errors"
 	"fmt"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/common"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// LOAD DATA LOCAL INFILE Reader 注册名序号，保证并发写入注册名唯一
+var loadDataReaderID uint64
+
+// IsDuplicateEntryError 判断是否主键/唯一键冲突错误 Error 1062
+func IsDuplicateEntryError(err error) bool {
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	return false
+}
+
+// AuditDDL 目标端 DDL 以及破坏性语句写入审计，非 DDL 语句或者未开启审计忽略
+func (m *MySQL) AuditDDL(sql string, execErr error) {
+	if m.Audit == nil {
+		return
+	}
+	m.Audit.Audit(sql, execErr)
+}
+
 func (m *MySQL) TruncateMySQLTable(targetSchema string, targetTable string) error {
-	_, err := m.MySQLDB.ExecContext(m.Ctx, fmt.Sprintf("TRUNCATE TABLE %s.%s", targetSchema, targetTable))
+	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s.%s", targetSchema, targetTable)
+	_, err := m.MySQLDB.ExecContext(m.Ctx, truncateSQL)
+	m.AuditDDL(truncateSQL, err)
 	if err != nil {
 		return err
 	}
@@ -28,9 +58,109 @@ func (m *MySQL) TruncateMySQLTable(targetSchema string, targetTable string) erro
 }
 
 func (m *MySQL) WriteMySQLTable(sql string) error {
-	_, err := m.MySQLDB.ExecContext(m.Ctx, sql)
+	startTime := time.Now()
+	res, err := m.MySQLDB.ExecContext(m.Ctx, sql)
+	m.AuditDDL(sql, err)
 	if err != nil {
 		return err
 	}
+	affectRows, _ := res.RowsAffected()
+	m.SlowLog.Observe(sql, time.Since(startTime), affectRows)
+	return nil
+}
+
+// LoadMySQLTable 以 LOAD DATA LOCAL INFILE 方式写入数据，data 为 TAB 分隔、换行结尾的数据内容
+// writeMode 主键/唯一键冲突处理：REPLACE/UPSERT 覆盖，IGNORE 跳过，INSERT 报错
+func (m *MySQL) LoadMySQLTable(targetSchema, targetTable string, columns []string, targetDBCharset string, writeMode string, data string) error {
+	readerName := common.StringsBuilder("transferdb_", strconv.FormatUint(atomic.AddUint64(&loadDataReaderID, 1), 10))
+	driver.RegisterReaderHandler(readerName, func() io.Reader {
+		return strings.NewReader(data)
+	})
+	defer driver.DeregisterReaderHandler(readerName)
+
+	var mode string
+	switch strings.ToUpper(writeMode) {
+	case common.WriteModeReplace, common.WriteModeUpsert:
+		mode = "REPLACE "
+	case common.WriteModeIgnore:
+		mode = "IGNORE "
+	}
+	loadSQL := common.StringsBuilder(`LOAD DATA LOCAL INFILE 'Reader::`, readerName, `' `, mode, `INTO TABLE `, targetSchema, ".", targetTable,
+		` CHARACTER SET `, strings.ToLower(targetDBCharset),
+		` FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (`, strings.Join(columns, ","), `)`)
+
+	startTime := time.Now()
+	res, err := m.MySQLDB.ExecContext(m.Ctx, loadSQL)
+	if err != nil {
+		return fmt.Errorf("load data sql [%v] failed: %v", loadSQL, err)
+	}
+	affectRows, _ := res.RowsAffected()
+	m.SlowLog.Observe(loadSQL, time.Since(startTime), affectRows)
+	return nil
+}
+
+// GetMySQLTableRowsData 读取 mysql 表数据，按 insertBatchSize 批量写入通道，用于 mysql/tidb -> oracle 数据迁移
+// NULL 值返回 nil，二进制类型返回 []byte，其余类型返回字符串，由下游 oracle 绑定变量隐式/显式转换
+func (m *MySQL) GetMySQLTableRowsData(querySQL string, insertBatchSize int, dataChan chan [][]interface{}) error {
+	rows, err := m.MySQLDB.QueryContext(m.Ctx, querySQL)
+	if err != nil {
+		return fmt.Errorf("mysql sql [%v] query failed: %v", querySQL, err)
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("mysql sql [%v] query rows.ColumnTypes failed: %v", querySQL, err)
+	}
+	var binaryColumns []bool
+	for _, ct := range colTypes {
+		switch common.StringUPPER(ct.DatabaseTypeName()) {
+		case common.BuildInMySQLDatatypeBit, common.BuildInMySQLDatatypeBinary, common.BuildInMySQLDatatypeVarbinary,
+			common.BuildInMySQLDatatypeTinyBlob, common.BuildInMySQLDatatypeBlob, common.BuildInMySQLDatatypeMediumBlob, common.BuildInMySQLDatatypeLongBlob:
+			binaryColumns = append(binaryColumns, true)
+		default:
+			binaryColumns = append(binaryColumns, false)
+		}
+	}
+
+	rawResult := make([][]byte, len(colTypes))
+	scans := make([]interface{}, len(colTypes))
+	for i := range rawResult {
+		scans[i] = &rawResult[i]
+	}
+
+	if insertBatchSize <= 0 {
+		insertBatchSize = common.ChannelBufferSize
+	}
+	batchRows := make([][]interface{}, 0, insertBatchSize)
+	for rows.Next() {
+		if err = rows.Scan(scans...); err != nil {
+			return fmt.Errorf("mysql sql [%v] query rows.Scan failed: %v", querySQL, err)
+		}
+		row := make([]interface{}, len(rawResult))
+		for i, raw := range rawResult {
+			switch {
+			case raw == nil:
+				row[i] = nil
+			case binaryColumns[i]:
+				val := make([]byte, len(raw))
+				copy(val, raw)
+				row[i] = val
+			default:
+				row[i] = string(raw)
+			}
+		}
+		batchRows = append(batchRows, row)
+		if len(batchRows) == insertBatchSize {
+			dataChan <- batchRows
+			batchRows = make([][]interface{}, 0, insertBatchSize)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("mysql sql [%v] query rows.Next failed: %v", querySQL, err)
+	}
+	if len(batchRows) > 0 {
+		dataChan <- batchRows
+	}
 	return nil
 }
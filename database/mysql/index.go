@@ -0,0 +1,213 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mysql
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// 全量装载前删除、装载后重建的下游二级索引以及外键
+const (
+	RebuildIndexTypeIndex      = "INDEX"
+	RebuildIndexTypeForeignKey = "FOREIGN KEY"
+)
+
+// RebuildIndex 待重建索引/外键，Definition 为 ALTER TABLE ADD 子句
+type RebuildIndex struct {
+	IndexName  string
+	IndexType  string
+	Definition string
+}
+
+// GetMySQLTableRebuildIndex 获取下游表可删除重建的非唯一二级索引以及外键
+// 主键、唯一键保留（write-mode replace/ignore/upsert 依赖唯一约束去重），函数索引（字段名为空）保留
+func (m *MySQL) GetMySQLTableRebuildIndex(schemaName, tableName string) ([]RebuildIndex, error) {
+	var rebuilds []RebuildIndex
+
+	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT INDEX_NAME,
+	INDEX_TYPE,
+	IFNULL(COLUMN_NAME,'') COLUMN_NAME,
+	IFNULL(SUB_PART,0) SUB_PART
+FROM INFORMATION_SCHEMA.STATISTICS
+WHERE UPPER(TABLE_SCHEMA) = UPPER('%s')
+  AND UPPER(TABLE_NAME) = UPPER('%s')
+  AND NON_UNIQUE = 1
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`, schemaName, tableName))
+	if err != nil {
+		return rebuilds, err
+	}
+	var (
+		indexNames   []string
+		indexTypes   = make(map[string]string)
+		indexColumns = make(map[string][]string)
+		skipIndexes  = make(map[string]bool)
+	)
+	for _, r := range res {
+		name := r["INDEX_NAME"]
+		if _, ok := indexTypes[name]; !ok {
+			indexNames = append(indexNames, name)
+			indexTypes[name] = common.StringUPPER(r["INDEX_TYPE"])
+		}
+		if r["COLUMN_NAME"] == "" {
+			skipIndexes[name] = true
+			continue
+		}
+		col := common.StringsBuilder("`", r["COLUMN_NAME"], "`")
+		if r["SUB_PART"] != "0" {
+			col = common.StringsBuilder(col, "(", r["SUB_PART"], ")")
+		}
+		indexColumns[name] = append(indexColumns[name], col)
+	}
+	for _, name := range indexNames {
+		if skipIndexes[name] {
+			continue
+		}
+		keyword := "INDEX"
+		switch indexTypes[name] {
+		case "FULLTEXT":
+			keyword = "FULLTEXT INDEX"
+		case "SPATIAL":
+			keyword = "SPATIAL INDEX"
+		}
+		rebuilds = append(rebuilds, RebuildIndex{
+			IndexName:  name,
+			IndexType:  RebuildIndexTypeIndex,
+			Definition: fmt.Sprintf("ADD %s `%s` (%s)", keyword, name, strings.Join(indexColumns[name], ",")),
+		})
+	}
+
+	_, res, err = Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT rc.CONSTRAINT_NAME,
+	ku.COLUMN_NAME,
+	ku.REFERENCED_TABLE_SCHEMA,
+	ku.REFERENCED_TABLE_NAME,
+	ku.REFERENCED_COLUMN_NAME,
+	rc.UPDATE_RULE,
+	rc.DELETE_RULE
+FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc,
+	INFORMATION_SCHEMA.KEY_COLUMN_USAGE ku
+WHERE rc.CONSTRAINT_SCHEMA = ku.CONSTRAINT_SCHEMA
+  AND rc.CONSTRAINT_NAME = ku.CONSTRAINT_NAME
+  AND rc.TABLE_NAME = ku.TABLE_NAME
+  AND UPPER(rc.CONSTRAINT_SCHEMA) = UPPER('%s')
+  AND UPPER(rc.TABLE_NAME) = UPPER('%s')
+ORDER BY rc.CONSTRAINT_NAME, ku.ORDINAL_POSITION`, schemaName, tableName))
+	if err != nil {
+		return rebuilds, err
+	}
+	var (
+		fkNames   []string
+		fkColumns = make(map[string][]string)
+		fkRefCols = make(map[string][]string)
+		fkRows    = make(map[string]map[string]string)
+	)
+	for _, r := range res {
+		name := r["CONSTRAINT_NAME"]
+		if _, ok := fkRows[name]; !ok {
+			fkNames = append(fkNames, name)
+			fkRows[name] = r
+		}
+		fkColumns[name] = append(fkColumns[name], common.StringsBuilder("`", r["COLUMN_NAME"], "`"))
+		fkRefCols[name] = append(fkRefCols[name], common.StringsBuilder("`", r["REFERENCED_COLUMN_NAME"], "`"))
+	}
+	for _, name := range fkNames {
+		r := fkRows[name]
+		rebuilds = append(rebuilds, RebuildIndex{
+			IndexName: name,
+			IndexType: RebuildIndexTypeForeignKey,
+			Definition: fmt.Sprintf("ADD CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s`.`%s` (%s) ON UPDATE %s ON DELETE %s",
+				name, strings.Join(fkColumns[name], ","), r["REFERENCED_TABLE_SCHEMA"], r["REFERENCED_TABLE_NAME"],
+				strings.Join(fkRefCols[name], ","), r["UPDATE_RULE"], r["DELETE_RULE"]),
+		})
+	}
+	return rebuilds, nil
+}
+
+// DropMySQLTableRebuildIndex 删除下游表二级索引以及外键，外键优先删除（外键依赖索引），已不存在的跳过
+func (m *MySQL) DropMySQLTableRebuildIndex(schemaName, tableName string, rebuilds []RebuildIndex) error {
+	for _, indexType := range []string{RebuildIndexTypeForeignKey, RebuildIndexTypeIndex} {
+		for _, r := range rebuilds {
+			if r.IndexType != indexType {
+				continue
+			}
+			if !m.IsExistMysqlRebuildIndex(schemaName, tableName, r) {
+				continue
+			}
+			var dropSQL string
+			if r.IndexType == RebuildIndexTypeForeignKey {
+				dropSQL = fmt.Sprintf("ALTER TABLE `%s`.`%s` DROP FOREIGN KEY `%s`", schemaName, tableName, r.IndexName)
+			} else {
+				dropSQL = fmt.Sprintf("ALTER TABLE `%s`.`%s` DROP INDEX `%s`", schemaName, tableName, r.IndexName)
+			}
+			_, err := m.MySQLDB.ExecContext(m.Ctx, dropSQL)
+			m.AuditDDL(dropSQL, err)
+			if err != nil {
+				return fmt.Errorf("drop target table index sql [%s] failed: %v", dropSQL, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CreateMySQLTableRebuildIndex 重建下游表二级索引以及外键，索引优先重建，已存在的跳过
+// combine 为 true 时同一张表所有索引合并为一条 ALTER TABLE 语句（MySQL 单次扫描构建多个索引），否则逐条执行
+func (m *MySQL) CreateMySQLTableRebuildIndex(schemaName, tableName string, rebuilds []RebuildIndex, combine bool) error {
+	for _, indexType := range []string{RebuildIndexTypeIndex, RebuildIndexTypeForeignKey} {
+		var definitions []string
+		for _, r := range rebuilds {
+			if r.IndexType != indexType || m.IsExistMysqlRebuildIndex(schemaName, tableName, r) {
+				continue
+			}
+			definitions = append(definitions, r.Definition)
+		}
+		if len(definitions) == 0 {
+			continue
+		}
+		var createSQLs []string
+		if combine {
+			createSQLs = append(createSQLs, fmt.Sprintf("ALTER TABLE `%s`.`%s` %s", schemaName, tableName, strings.Join(definitions, ", ")))
+		} else {
+			for _, d := range definitions {
+				createSQLs = append(createSQLs, fmt.Sprintf("ALTER TABLE `%s`.`%s` %s", schemaName, tableName, d))
+			}
+		}
+		for _, createSQL := range createSQLs {
+			_, err := m.MySQLDB.ExecContext(m.Ctx, createSQL)
+			m.AuditDDL(createSQL, err)
+			if err != nil {
+				return fmt.Errorf("rebuild target table index sql [%s] failed: %v", createSQL, err)
+			}
+		}
+	}
+	return nil
+}
+
+// IsExistMysqlRebuildIndex 判断下游表索引或者外键是否存在
+func (m *MySQL) IsExistMysqlRebuildIndex(schemaName, tableName string, r RebuildIndex) bool {
+	if r.IndexType == RebuildIndexTypeIndex {
+		return m.IsExistMysqlIndex(schemaName, tableName, r.IndexName)
+	}
+	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT COUNT(1) AS CT
+FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS
+WHERE UPPER(CONSTRAINT_SCHEMA) = UPPER('%s')
+  AND UPPER(TABLE_NAME) = UPPER('%s')
+  AND UPPER(CONSTRAINT_NAME) = UPPER('%s')`, schemaName, tableName, r.IndexName))
+	if err != nil || len(res) == 0 {
+		return false
+	}
+	return res[0]["CT"] != "0"
+}
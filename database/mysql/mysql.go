@@ -17,44 +17,239 @@ package mysql
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/metrics"
+	"go.uber.org/zap"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type MySQL struct {
 	Ctx     context.Context
 	MySQLDB *sql.DB
+	// 目标端数据写入限速，nil 不限速
+	Throttle *common.Throttle
+	// 目标端写入慢 SQL 记录，nil 不记录
+	SlowLog *common.SlowLog
+	// 目标端 DDL 以及破坏性语句审计，nil 不审计
+	Audit common.DDLAuditor
+	// 目标端 max_allowed_packet，单位字节，连接建立时获取，0 表示获取失败
+	MaxAllowedPacket int
 }
 
 func NewMySQLDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig) (*MySQL, error) {
-	if !strings.EqualFold(mysqlCfg.Charset, "") {
-		mysqlCfg.ConnectParams = fmt.Sprintf("charset=%s&%s", strings.ToLower(mysqlCfg.Charset), mysqlCfg.ConnectParams)
-	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/?%s",
-		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.ConnectParams)
-
-	mysqlDB, err := sql.Open("mysql", dsn)
+	connector, err := newFailoverConnector(mysqlCfg)
 	if err != nil {
 		return nil, fmt.Errorf("error on open mysql database connection: %v", err)
 	}
+	mysqlDB := sql.OpenDB(connector)
 
-	mysqlDB.SetMaxIdleConns(common.MySQLMaxIdleConn)
-	mysqlDB.SetMaxOpenConns(common.MySQLMaxConn)
-	mysqlDB.SetConnMaxLifetime(common.MySQLConnMaxLifeTime)
+	maxIdleConns := common.MySQLMaxIdleConn
+	if mysqlCfg.MaxIdleConns > 0 {
+		maxIdleConns = mysqlCfg.MaxIdleConns
+	}
+	maxOpenConns := common.MySQLMaxConn
+	if mysqlCfg.MaxOpenConns > 0 {
+		maxOpenConns = mysqlCfg.MaxOpenConns
+	}
+	connMaxLifetime := common.MySQLConnMaxLifeTime
+	if mysqlCfg.ConnMaxLifetime > 0 {
+		connMaxLifetime = time.Duration(mysqlCfg.ConnMaxLifetime) * time.Second
+	}
+	mysqlDB.SetMaxIdleConns(maxIdleConns)
+	mysqlDB.SetMaxOpenConns(maxOpenConns)
+	mysqlDB.SetConnMaxLifetime(connMaxLifetime)
 	mysqlDB.SetConnMaxIdleTime(common.MySQLConnMaxIdleTime)
 
 	if err = mysqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("error on ping mysql database connection: %v", err)
 	}
+	common.StartDBHealthCheck(ctx, mysqlDB, time.Duration(mysqlCfg.HealthCheckInterval)*time.Second, func(err error) {
+		metrics.DBHealthCheckFailedCounter.WithLabelValues("mysql").Inc()
+		zap.L().Warn("mysql connection pool health check failed", zap.Error(err))
+	})
 
-	return &MySQL{
+	engine := &MySQL{
 		Ctx:     ctx,
 		MySQLDB: mysqlDB,
-	}, nil
+	}
+	_, res, err := Query(ctx, mysqlDB, "SELECT @@max_allowed_packet AS MAX_ALLOWED_PACKET")
+	if err == nil && len(res) == 1 {
+		engine.MaxAllowedPacket, _ = strconv.Atoi(res[0]["MAX_ALLOWED_PACKET"])
+	}
+	return engine, nil
+}
+
+// NewMySQLLoadEngine 全量数据装载引擎，连接会话额外设置 load-session-params，例如 foreign_key_checks=0、unique_checks=0
+func NewMySQLLoadEngine(ctx context.Context, mysqlCfg config.MySQLConfig) (*MySQL, error) {
+	sessionParams := make([]string, 0, len(mysqlCfg.SessionParams)+len(mysqlCfg.LoadSessionParams))
+	sessionParams = append(sessionParams, mysqlCfg.SessionParams...)
+	sessionParams = append(sessionParams, mysqlCfg.LoadSessionParams...)
+	mysqlCfg.SessionParams = sessionParams
+	return NewMySQLDBEngine(ctx, mysqlCfg)
+}
+
+// InsertBatchBytes 单条 batch 写入 SQL 最大字节数，insert-batch-bytes 未配置或超过下游 max_allowed_packet 时按 max_allowed_packet 预留 10% 限制
+func (m *MySQL) InsertBatchBytes(batchBytes int) int {
+	if m.MaxAllowedPacket <= 0 {
+		return batchBytes
+	}
+	limit := m.MaxAllowedPacket / 10 * 9
+	if batchBytes <= 0 || batchBytes > limit {
+		return limit
+	}
+	return batchBytes
+}
+
+// genMySQLDSN 生成连接串，字符集、超时以及 session 变量以连接参数形式追加，session 变量由驱动在连接建立时 SET
+func genMySQLDSN(mysqlCfg config.MySQLConfig) (string, error) {
+	var params []string
+	if !strings.EqualFold(mysqlCfg.Charset, "") {
+		params = append(params, fmt.Sprintf("charset=%s", strings.ToLower(mysqlCfg.Charset)))
+	}
+	if mysqlCfg.ConnectTimeout > 0 {
+		params = append(params, fmt.Sprintf("timeout=%ds", mysqlCfg.ConnectTimeout))
+	}
+	if mysqlCfg.ReadTimeout > 0 {
+		params = append(params, fmt.Sprintf("readTimeout=%ds", mysqlCfg.ReadTimeout))
+	}
+	if mysqlCfg.WriteTimeout > 0 {
+		params = append(params, fmt.Sprintf("writeTimeout=%ds", mysqlCfg.WriteTimeout))
+	}
+	for _, p := range mysqlCfg.SessionParams {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) != 2 || strings.EqualFold(strings.TrimSpace(kv[0]), "") {
+			return "", fmt.Errorf("mysql config session-params [%s] format error, must be name=value", p)
+		}
+		params = append(params, fmt.Sprintf("%s=%s", strings.TrimSpace(kv[0]), url.QueryEscape(strings.TrimSpace(kv[1]))))
+	}
+	// 客户端 max_allowed_packet，0 表示连接建立时读取服务端配置
+	if mysqlCfg.MaxAllowedPacket >= 0 {
+		params = append(params, fmt.Sprintf("maxAllowedPacket=%d", mysqlCfg.MaxAllowedPacket))
+	}
+	tlsName, err := registerMySQLTLS(mysqlCfg)
+	if err != nil {
+		return "", err
+	}
+	if tlsName != "" {
+		params = append(params, fmt.Sprintf("tls=%s", tlsName))
+	}
+	// UPDATE 影响行数按匹配行数返回，用于增量同步冲突判断
+	params = append(params, "clientFoundRows=true")
+	if !strings.EqualFold(mysqlCfg.ConnectParams, "") {
+		params = append(params, mysqlCfg.ConnectParams)
+	}
+
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/?%s",
+		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port, strings.Join(params, "&")), nil
+}
+
+// failoverConnector 连接池新建连接依次尝试主地址以及 failover-hosts，优先沿用上次连接成功地址
+type failoverConnector struct {
+	addrs      []string
+	connectors []driver.Connector
+	current    uint32
+}
+
+func newFailoverConnector(mysqlCfg config.MySQLConfig) (*failoverConnector, error) {
+	c := &failoverConnector{}
+	hosts := append([]string{net.JoinHostPort(mysqlCfg.Host, strconv.Itoa(mysqlCfg.Port))}, mysqlCfg.FailoverHosts...)
+	for _, h := range hosts {
+		// 地址格式 AdjustConfig 已校验
+		host, port, err := common.SplitHostPort(h)
+		if err != nil {
+			return nil, err
+		}
+		hostCfg := mysqlCfg
+		hostCfg.Host, hostCfg.Port = host, port
+		// TLS 配置全局注册一份，证书校验沿用主地址，failover-hosts 开启 TLS 需配置 tls-server-name 对应证书覆盖全部地址
+		if strings.EqualFold(hostCfg.TLSServerName, "") {
+			hostCfg.TLSServerName = mysqlCfg.Host
+		}
+		dsn, err := genMySQLDSN(hostCfg)
+		if err != nil {
+			return nil, err
+		}
+		connector, err := mysql.MySQLDriver{}.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		c.addrs = append(c.addrs, h)
+		c.connectors = append(c.connectors, connector)
+	}
+	return c, nil
+}
+
+func (c *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var err error
+	start := atomic.LoadUint32(&c.current)
+	for i := 0; i < len(c.connectors); i++ {
+		idx := (int(start) + i) % len(c.connectors)
+		var conn driver.Conn
+		conn, err = c.connectors[idx].Connect(ctx)
+		if err == nil {
+			if idx != int(start) {
+				atomic.StoreUint32(&c.current, uint32(idx))
+				zap.L().Warn("mysql connection failover",
+					zap.String("from", c.addrs[start]),
+					zap.String("to", c.addrs[idx]))
+			}
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func (c *failoverConnector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
+// registerMySQLTLS 注册驱动 TLS 配置，未配置证书以及 tls-skip-verify 时不开启 TLS
+func registerMySQLTLS(mysqlCfg config.MySQLConfig) (string, error) {
+	if strings.EqualFold(mysqlCfg.TLSCA, "") && strings.EqualFold(mysqlCfg.TLSCert, "") && !mysqlCfg.TLSSkipVerify {
+		return "", nil
+	}
+	tlsCfg := &tls.Config{
+		ServerName:         mysqlCfg.TLSServerName,
+		InsecureSkipVerify: mysqlCfg.TLSSkipVerify,
+	}
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = mysqlCfg.Host
+	}
+	if !strings.EqualFold(mysqlCfg.TLSCA, "") {
+		caPEM, err := os.ReadFile(mysqlCfg.TLSCA)
+		if err != nil {
+			return "", fmt.Errorf("mysql config tls-ca [%s] read failed: %v", mysqlCfg.TLSCA, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return "", fmt.Errorf("mysql config tls-ca [%s] isn't valid pem certificate", mysqlCfg.TLSCA)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if !strings.EqualFold(mysqlCfg.TLSCert, "") || !strings.EqualFold(mysqlCfg.TLSKey, "") {
+		cert, err := tls.LoadX509KeyPair(mysqlCfg.TLSCert, mysqlCfg.TLSKey)
+		if err != nil {
+			return "", fmt.Errorf("mysql config tls-cert [%s] tls-key [%s] load failed: %v", mysqlCfg.TLSCert, mysqlCfg.TLSKey, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if err := mysql.RegisterTLSConfig(common.MySQLTLSConfigName, tlsCfg); err != nil {
+		return "", err
+	}
+	return common.MySQLTLSConfigName, nil
 }
 
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
@@ -94,6 +94,23 @@ AND upper(index_name) = upper('%s')`, schemaName, tableName, indexName)
 	return true
 }
 
+// 获取索引所属表，用于增量 DROP INDEX 路由
+func (m *MySQL) GetMySQLIndexTableName(schemaName, indexName string) ([]string, error) {
+	var tables []string
+	querySQL := fmt.Sprintf(`SELECT DISTINCT table_name AS TABLE_NAME
+FROM information_schema.statistics 
+WHERE upper(table_schema) = upper('%s')
+AND upper(index_name) = upper('%s')`, schemaName, indexName)
+	_, res, err := Query(m.Ctx, m.MySQLDB, querySQL)
+	if err != nil {
+		return tables, err
+	}
+	for _, r := range res {
+		tables = append(tables, r["TABLE_NAME"])
+	}
+	return tables, nil
+}
+
 func (m *MySQL) getMySQLSchema() ([]string, error) {
 	var (
 		schemas []string
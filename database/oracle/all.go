@@ -142,12 +142,11 @@ func (o *Oracle) GetOracleALLRedoLogFile() ([]string, error) {
 }
 
 func (o *Oracle) AddOracleLogminerlogFile(logFile string) error {
-	ctx, _ := context.WithCancel(context.Background())
 	sql := common.StringsBuilder(`BEGIN
   dbms_logmnr.add_logfile(logfilename => '`, logFile, `',
                           options     => dbms_logmnr.NEW);
 END;`)
-	_, err := o.OracleDB.ExecContext(ctx, sql)
+	_, err := o.OracleDB.ExecContext(o.Ctx, sql)
 	if err != nil {
 		return fmt.Errorf("oracle logminer sql [%v] add log file [%s] failed: %v", sql, logFile, err)
 	}
@@ -155,7 +154,6 @@ END;`)
 }
 
 func (o *Oracle) StartOracleLogminerStoredProcedure(scn string) error {
-	ctx, _ := context.WithCancel(context.Background())
 	sql := common.StringsBuilder(`BEGIN
   dbms_logmnr.start_logmnr(startSCN => `, scn, `,
                            options  => SYS.DBMS_LOGMNR.SKIP_CORRUPTION +       -- 日志遇到坏块，不报错退出，直接跳过
@@ -165,16 +163,16 @@ func (o *Oracle) StartOracleLogminerStoredProcedure(scn string) error {
                                        SYS.DBMS_LOGMNR.DICT_FROM_ONLINE_CATALOG +
                                        SYS.DBMS_LOGMNR.STRING_LITERALS_IN_STMT);
 END;`)
-	_, err := o.OracleDB.ExecContext(ctx, sql)
+	_, err := o.OracleDB.ExecContext(o.Ctx, sql)
 	if err != nil {
 		return fmt.Errorf("oracle logminer stored procedure sql [%v] startscn [%v] failed: %v", sql, scn, err)
 	}
 	return nil
 }
 
+// 任务取消后仍需关闭 logminer 会话，故不使用任务 Ctx
 func (o *Oracle) EndOracleLogminerStoredProcedure() error {
-	ctx, _ := context.WithCancel(context.Background())
-	_, err := o.OracleDB.ExecContext(ctx, common.StringsBuilder(`BEGIN
+	_, err := o.OracleDB.ExecContext(context.Background(), common.StringsBuilder(`BEGIN
   dbms_logmnr.end_logmnr();
 END;`))
 	if err != nil {
@@ -182,3 +180,81 @@ END;`))
 	}
 	return nil
 }
+
+// 按 SCN_TO_TIMESTAMP 映射获取 SCN 距当前时间秒数，SCN 超出映射保留范围报错 ORA-08181
+func (o *Oracle) GetOracleSCNLagSeconds(scn uint64) (int64, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT ROUND((CAST(SYSTIMESTAMP AS DATE) - CAST(SCN_TO_TIMESTAMP(%d) AS DATE)) * 86400) AS LAG_SECONDS FROM DUAL`, scn))
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, fmt.Errorf("get oracle scn [%d] lag seconds failed: query result is empty", scn)
+	}
+	lag, err := common.StrconvIntBitSize(res[0]["LAG_SECONDS"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("get oracle scn [%d] lag seconds [%s] utils.StrconvIntBitSize failed: %v", scn, res[0]["LAG_SECONDS"], err)
+	}
+	return lag, nil
+}
+
+// GetOracleTableFlashbackWatermark 闪回查询 SCN 时间点表 watermark 字段最大值，表为空或者字段值全部为 NULL 返回空字符串
+func (o *Oracle) GetOracleTableFlashbackWatermark(schemaName, tableName, columnName string, scn uint64) (string, error) {
+	querySQL := fmt.Sprintf(`SELECT TO_CHAR(CAST(MAX(%s) AS TIMESTAMP), '%s') AS WATERMARK FROM %s.%s AS OF SCN %d`,
+		columnName, common.FlashbackWatermarkFormat, common.StringUPPER(schemaName), common.StringUPPER(tableName), scn)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 || res[0]["WATERMARK"] == "NULLABLE" {
+		return "", nil
+	}
+	return res[0]["WATERMARK"], nil
+}
+
+func (o *Oracle) GetOracleDBLogMode() (string, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT LOG_MODE FROM V$DATABASE`)
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", fmt.Errorf("get oracle database log mode failed: query result is empty")
+	}
+	return res[0]["LOG_MODE"], nil
+}
+
+func (o *Oracle) GetOracleDBSupplementalLogging() (map[string]string, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT SUPPLEMENTAL_LOG_DATA_MIN,SUPPLEMENTAL_LOG_DATA_PK,SUPPLEMENTAL_LOG_DATA_UI,SUPPLEMENTAL_LOG_DATA_ALL FROM V$DATABASE`)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("get oracle database supplemental logging failed: query result is empty")
+	}
+	return res[0], nil
+}
+
+// GetOracleSchemaTableSupplementalLogging 获取 schema 表级别附加日志，返回表名 -> 附加日志类型（ALL COLUMN LOGGING/PRIMARY KEY LOGGING/UNIQUE KEY LOGGING 等）
+func (o *Oracle) GetOracleSchemaTableSupplementalLogging(schemaName string) (map[string][]string, error) {
+	tableLogs := make(map[string][]string)
+	err := o.queryStreamRows(common.StringsBuilder(`SELECT TABLE_NAME,LOG_GROUP_TYPE FROM DBA_LOG_GROUPS WHERE UPPER(OWNER) = '`, common.StringUPPER(schemaName), `'`), func(r map[string]string) error {
+		tableLogs[common.StringUPPER(r["TABLE_NAME"])] = append(tableLogs[common.StringUPPER(r["TABLE_NAME"])], common.StringUPPER(r["LOG_GROUP_TYPE"]))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tableLogs, nil
+}
+
+// GetOracleSessionPrivileges 获取当前会话系统权限以及角色
+func (o *Oracle) GetOracleSessionPrivileges() ([]string, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT PRIVILEGE AS NAME FROM SESSION_PRIVS UNION ALL SELECT ROLE AS NAME FROM SESSION_ROLES`)
+	if err != nil {
+		return nil, err
+	}
+	var privs []string
+	for _, r := range res {
+		privs = append(privs, common.StringUPPER(r["NAME"]))
+	}
+	return privs, nil
+}
@@ -351,7 +351,18 @@ func (o *Oracle) GetOracleSchemaSynonymObject(schemaName []string) ([]map[string
 }
 
 func (o *Oracle) GetOracleSchemaMaterializedViewObject(schemaName []string) ([]map[string]string, error) {
-	querySQL := fmt.Sprintf(`SELECT OWNER,MVIEW_NAME,REWRITE_CAPABILITY,REFRESH_MODE,REFRESH_METHOD,FAST_REFRESHABLE FROM DBA_MVIEWS WHERE OWNER IN (%s)`, strings.Join(schemaName, ","))
+	querySQL := fmt.Sprintf(`SELECT M.OWNER,M.MVIEW_NAME,M.REWRITE_CAPABILITY,M.REFRESH_MODE,M.REFRESH_METHOD,M.FAST_REFRESHABLE,
+	NVL((SELECT ROUND(SUM(S.BYTES)/1024/1024,2) FROM DBA_SEGMENTS S WHERE S.OWNER = M.OWNER AND S.SEGMENT_NAME = M.MVIEW_NAME),0) AS SIZE_MB
+FROM DBA_MVIEWS M WHERE M.OWNER IN (%s)`, strings.Join(schemaName, ","))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (o *Oracle) GetOracleSchemaDBLinkObject(schemaName []string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT OWNER,DB_LINK,NVL(USERNAME,' ') USERNAME,NVL(HOST,' ') HOST,TO_CHAR(CREATED,'YYYY-MM-DD HH24:MI:SS') CREATED FROM DBA_DB_LINKS WHERE OWNER IN (%s)`, strings.Join(schemaName, ","))
 	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
 	if err != nil {
 		return res, err
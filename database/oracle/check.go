@@ -72,16 +72,16 @@ func (o *Oracle) GetOracleSchemaTableCollation(schemaName, schemaCollation strin
 
 	tablesMap := make(map[string]string)
 
-	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT TABLE_NAME,DEFAULT_COLLATION FROM DBA_TABLES WHERE UPPER(owner) = UPPER('%s') AND (IOT_TYPE IS NUll OR IOT_TYPE='IOT')`, schemaName))
-	if err != nil {
-		return tablesMap, err
-	}
-	for _, r := range res {
+	err = o.queryStreamRows(fmt.Sprintf(`SELECT TABLE_NAME,DEFAULT_COLLATION FROM DBA_TABLES WHERE UPPER(owner) = UPPER('%s') AND (IOT_TYPE IS NUll OR IOT_TYPE='IOT')`, schemaName), func(r map[string]string) error {
 		if strings.ToUpper(r["DEFAULT_COLLATION"]) == common.OracleUserTableColumnDefaultCollation {
 			tablesMap[strings.ToUpper(r["TABLE_NAME"])] = strings.ToUpper(schemaCollation)
 		} else {
 			tablesMap[strings.ToUpper(r["TABLE_NAME"])] = strings.ToUpper(r["DEFAULT_COLLATION"])
 		}
+		return nil
+	})
+	if err != nil {
+		return tablesMap, err
 	}
 
 	return tablesMap, nil
@@ -90,7 +90,7 @@ func (o *Oracle) GetOracleSchemaTableCollation(schemaName, schemaCollation strin
 func (o *Oracle) GetOracleSchemaTableType(schemaName string) (map[string]string, error) {
 	tableMap := make(map[string]string)
 
-	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT 
+	err := o.queryStreamRows(fmt.Sprintf(`SELECT 
 f.TABLE_NAME,
 	(
 	CASE WHEN f.CLUSTER_NAME IS NOT NULL THEN 'CLUSTERED' ELSE
@@ -114,21 +114,20 @@ FROM
 	DBA_TABLES tmp, DBA_TABLES w
 WHERE tmp.owner=w.owner AND tmp.table_name = w.table_name AND tmp.owner  = '%s' AND (w.IOT_TYPE IS NUll OR w.IOT_TYPE='IOT')) f left join (
 select owner,iot_name,iot_type from DBA_TABLES WHERE owner  = '%s')t 
-ON f.owner = t.owner AND f.table_name = t.iot_name`, strings.ToUpper(schemaName), strings.ToUpper(schemaName)))
+ON f.owner = t.owner AND f.table_name = t.iot_name`, strings.ToUpper(schemaName), strings.ToUpper(schemaName)), func(r map[string]string) error {
+		if len(r) > 2 || len(r) == 0 || len(r) == 1 {
+			return fmt.Errorf("oracle schema [%s] table type values should be 2, result: %v", schemaName, r)
+		}
+		tableMap[r["TABLE_NAME"]] = r["TABLE_TYPE"]
+		return nil
+	})
 	if err != nil {
 		return tableMap, err
 	}
-	if len(res) == 0 {
+	if len(tableMap) == 0 {
 		return tableMap, fmt.Errorf("oracle schema [%s] table type can't be null", schemaName)
 	}
 
-	for _, r := range res {
-		if len(r) > 2 || len(r) == 0 || len(r) == 1 {
-			return tableMap, fmt.Errorf("oracle schema [%s] table type values should be 2, result: %v", schemaName, r)
-		}
-		tableMap[r["TABLE_NAME"]] = r["TABLE_TYPE"]
-	}
-
 	return tableMap, nil
 }
 
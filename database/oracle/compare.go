@@ -16,12 +16,10 @@ limitations under the License.
 package oracle
 
 import (
-	"context"
 	"database/sql"
 	"fmt"
 	"github.com/scylladb/go-set"
 	"github.com/scylladb/go-set/strset"
-	"github.com/shopspring/decimal"
 	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
 	"hash/crc32"
@@ -103,8 +101,6 @@ func (o *Oracle) GetOracleTableRowsByStatistics(schemaName, tableName string) (i
 }
 
 func (o *Oracle) StartOracleCreateChunkByNUMBER(taskName, schemaName, tableName, numberColName string, chunkSize string) error {
-	ctx, _ := context.WithCancel(o.Ctx)
-
 	chunkSQL := common.StringsBuilder(`BEGIN
   DBMS_PARALLEL_EXECUTE.CREATE_CHUNKS_BY_NUMBER_COL (task_name   => '`, taskName, `',
                                                table_owner => '`, schemaName, `',
@@ -112,7 +108,7 @@ func (o *Oracle) StartOracleCreateChunkByNUMBER(taskName, schemaName, tableName,
                                                table_column => '`, numberColName, `',
                                                chunk_size  => `, chunkSize, `);
 END;`)
-	_, err := o.OracleDB.ExecContext(ctx, chunkSQL)
+	_, err := o.OracleDB.ExecContext(o.Ctx, chunkSQL)
 	if err != nil {
 		return fmt.Errorf("oracle DBMS_PARALLEL_EXECUTE create_chunks_by_rowid task failed: %v, sql: %v", err, chunkSQL)
 	}
@@ -156,23 +152,22 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 
 	stringSet := set.NewStringSet()
 
-	rows, err = o.OracleDB.Query(querySQL)
+	rows, err = o.OracleDB.QueryContext(o.Ctx, querySQL, o.fetchOptions()...)
 	if err != nil {
 		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
 	}
 
 	defer rows.Close()
 
-	// 用于判断字段值是数字还是字符
-	var columnTypes []string
+	// 字段值按字段类型解码，用于判断字段值是数字还是字符
+	var decoders []ColumnDecoder
 	colTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return cols, stringSet, crc32Value, err
 	}
 
 	for _, ct := range colTypes {
-		// 数据库字段类型 DatabaseTypeName() 映射 go 类型 ScanType()
-		columnTypes = append(columnTypes, ct.ScanType().String())
+		decoders = append(decoders, GetColumnDecoder(ct.DatabaseTypeName()))
 	}
 
 	//不确定字段通用查询，自动获取字段名称
@@ -194,66 +189,11 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 		}
 
 		for i, raw := range rawResult {
-			// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
-			if raw == nil {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else if string(raw) == "" {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else {
-				switch columnTypes[i] {
-				case "int64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "godror.Number":
-					r, err := decimal.NewFromString(string(raw))
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					if r.IsInteger() {
-						si, err := common.StrconvIntBitSize(string(raw), 64)
-						if err != nil {
-							return cols, stringSet, crc32Value, err
-						}
-						rowsTMP = append(rowsTMP, fmt.Sprintf("%v", si))
-					} else {
-						rf, err := common.StrconvFloatBitSize(string(raw), 64)
-						if err != nil {
-							return cols, stringSet, crc32Value, err
-						}
-						rowsTMP = append(rowsTMP, fmt.Sprintf("%v", rf))
-					}
-				default:
-					// 特殊字符
-					rowsTMP = append(rowsTMP, fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)))
-				}
+			val, err := formatCompareRawValue(decoders[i], cols[i], raw)
+			if err != nil {
+				return cols, stringSet, crc32Value, err
 			}
+			rowsTMP = append(rowsTMP, val)
 		}
 
 		rowS := exstrings.Join(rowsTMP, ",")
@@ -272,3 +212,69 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 
 	return cols, stringSet, crc32SUM, err
 }
+
+// GetOracleDataRowValues 数据校验查询，按行返回各字段格式化值，格式与 GetOracleDataRowStrings 一致，用于逐字段对比
+func (o *Oracle) GetOracleDataRowValues(querySQL string) ([]string, [][]string, error) {
+	var results [][]string
+
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, o.fetchOptions()...)
+	if err != nil {
+		return nil, results, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+	}
+	defer rows.Close()
+
+	var decoders []ColumnDecoder
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, results, err
+	}
+	for _, ct := range colTypes {
+		decoders = append(decoders, GetColumnDecoder(ct.DatabaseTypeName()))
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return cols, results, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+	}
+
+	rawResult := make([][]byte, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range rawResult {
+		scans[i] = &rawResult[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(scans...); err != nil {
+			return cols, results, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+		}
+		values := make([]string, len(cols))
+		for i, raw := range rawResult {
+			if values[i], err = formatCompareRawValue(decoders[i], cols[i], raw); err != nil {
+				return cols, results, err
+			}
+		}
+		results = append(results, values)
+	}
+
+	if err = rows.Err(); err != nil {
+		return cols, results, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+	}
+	return cols, results, nil
+}
+
+// formatCompareRawValue 数据校验字段值格式化
+// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别，数据校验不做字符集转换
+func formatCompareRawValue(decoder ColumnDecoder, columnName string, raw []byte) (string, error) {
+	if raw == nil || string(raw) == "" {
+		return `NULL`, nil
+	}
+	val, err := decoder(raw, ColumnConvertParam{
+		ColumnName:      columnName,
+		SourceDBCharset: common.CharsetUTF8MB4,
+		TargetDBCharset: common.CharsetUTF8MB4,
+	})
+	if err != nil {
+		return "", err
+	}
+	return FormatCompareColumnValue(val), nil
+}
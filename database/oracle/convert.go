@@ -0,0 +1,63 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"sync"
+)
+
+// ColumnConvertParam 字段值转换参数
+type ColumnConvertParam struct {
+	ColumnName       string
+	SourceDBCharset  string
+	TargetDBCharset  string
+	CharsetErrorMode string
+}
+
+// ColumnConverter 字段值转换为下游 MySQL/TiDB SQL 字面量，raw 为非 NULL、非空字符串原始值
+type ColumnConverter func(raw []byte, param ColumnConvertParam) (string, error)
+
+var (
+	columnConverterMu sync.RWMutex
+	// 以 godror DatabaseTypeName() 字段类型注册的自定义转换器，优先于字段类型解码器
+	columnConverters = map[string]ColumnConverter{}
+)
+
+// RegisterColumnConverter 注册或者覆盖字段类型转换器，databaseType 为 godror DatabaseTypeName()，例如 NUMBER、VARCHAR2、DATE、RAW
+func RegisterColumnConverter(databaseType string, converter ColumnConverter) {
+	columnConverterMu.Lock()
+	defer columnConverterMu.Unlock()
+	columnConverters[common.StringUPPER(databaseType)] = converter
+}
+
+// GetColumnConverter 获取字段类型转换器，未注册自定义转换器按字段类型解码器解码后格式化为 SQL 字面量
+// 字段值是否加引号由字段类型决定而非字段值内容，避免 '007'、'1e5'、手机号等字符值按数字写入
+func GetColumnConverter(databaseType string) ColumnConverter {
+	columnConverterMu.RLock()
+	defer columnConverterMu.RUnlock()
+	if converter, ok := columnConverters[common.StringUPPER(databaseType)]; ok {
+		return converter
+	}
+	decoder := GetColumnDecoder(databaseType)
+	return func(raw []byte, param ColumnConvertParam) (string, error) {
+		value, err := decoder(raw, param)
+		if err != nil {
+			return "", err
+		}
+		return FormatMySQLColumnValue(value, param)
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ColumnDecoder 字段原始值解码为 Go 类型值，raw 为非 NULL、非空字符串原始值
+// 解码结果类型：int64、float32、float64、decimal.Decimal（NUMBER 非整数或超出 int64 范围）、bool、time.Time、[]byte、string（已转换为 UTF8MB4）
+// 下游写入、数据校验以及 CSV 导出基于解码结果按各自格式输出，无需再次按字符串解析
+type ColumnDecoder func(raw []byte, param ColumnConvertParam) (interface{}, error)
+
+// timestampDecodeLayouts 时间类型原始值解析格式，database/sql 扫描 time.Time 至 []byte 按 RFC3339Nano 输出
+var timestampDecodeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+var (
+	columnDecoderMu sync.RWMutex
+	// 以 godror DatabaseTypeName() 字段类型注册解码器
+	columnDecoders = map[string]ColumnDecoder{
+		"NUMBER":                         numberColumnDecoder,
+		"BINARY_INTEGER":                 integerColumnDecoder,
+		"FLOAT":                          floatColumnDecoder(32),
+		"DOUBLE":                         floatColumnDecoder(64),
+		"BOOLEAN":                        booleanColumnDecoder,
+		"DATE":                           timestampColumnDecoder,
+		"TIMESTAMP":                      timestampColumnDecoder,
+		"TIMESTAMP WITH TIME ZONE":       timestampColumnDecoder,
+		"TIMESTAMP WITH LOCAL TIME ZONE": timestampColumnDecoder,
+		"RAW":                            binaryColumnDecoder,
+		"LONG RAW":                       binaryColumnDecoder,
+		"BLOB":                           binaryColumnDecoder,
+	}
+)
+
+// RegisterColumnDecoder 注册或者覆盖字段类型解码器，databaseType 为 godror DatabaseTypeName()
+func RegisterColumnDecoder(databaseType string, decoder ColumnDecoder) {
+	columnDecoderMu.Lock()
+	defer columnDecoderMu.Unlock()
+	columnDecoders[common.StringUPPER(databaseType)] = decoder
+}
+
+// GetColumnDecoder 获取字段类型解码器，未注册类型（VARCHAR2/CHAR/CLOB 以及 TO_CHAR 格式化时间等）按字符串解码
+func GetColumnDecoder(databaseType string) ColumnDecoder {
+	columnDecoderMu.RLock()
+	defer columnDecoderMu.RUnlock()
+	if decoder, ok := columnDecoders[common.StringUPPER(databaseType)]; ok {
+		return decoder
+	}
+	return stringColumnDecoder
+}
+
+// NUMBER 整数且未超出 int64 范围解码为 int64，否则按十进制精确解码，不经 float 转换避免精度丢失
+func numberColumnDecoder(raw []byte, param ColumnConvertParam) (interface{}, error) {
+	r, err := decimal.NewFromString(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("column [%s] number value [%s] decode failed, %v", param.ColumnName, string(raw), err)
+	}
+	if r.IsInteger() {
+		if i, err := strconv.ParseInt(r.String(), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return r, nil
+}
+
+func integerColumnDecoder(raw []byte, param ColumnConvertParam) (interface{}, error) {
+	r, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("column [%s] integer value [%s] decode failed, %v", param.ColumnName, string(raw), err)
+	}
+	return r, nil
+}
+
+// BINARY_FLOAT/BINARY_DOUBLE 按对应精度解码，Nan/Inf 下游不支持，解码报错
+func floatColumnDecoder(bitSize int) ColumnDecoder {
+	return func(raw []byte, param ColumnConvertParam) (interface{}, error) {
+		r, err := strconv.ParseFloat(string(raw), bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] float value [%s] decode failed, %v", param.ColumnName, string(raw), err)
+		}
+		if math.IsInf(r, 0) || math.IsNaN(r) {
+			return nil, fmt.Errorf("column [%s] float value [%s] isn't support", param.ColumnName, string(raw))
+		}
+		if bitSize == 32 {
+			return float32(r), nil
+		}
+		return r, nil
+	}
+}
+
+func booleanColumnDecoder(raw []byte, param ColumnConvertParam) (interface{}, error) {
+	r, err := strconv.ParseBool(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("column [%s] boolean value [%s] decode failed, %v", param.ColumnName, string(raw), err)
+	}
+	return r, nil
+}
+
+// DATE/TIMESTAMP 未经 TO_CHAR 格式化直接查询时解码为 time.Time
+func timestampColumnDecoder(raw []byte, param ColumnConvertParam) (interface{}, error) {
+	for _, layout := range timestampDecodeLayouts {
+		if t, err := time.Parse(layout, string(raw)); err == nil {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("column [%s] timestamp value [%s] decode failed, layout isn't match", param.ColumnName, string(raw))
+}
+
+// RAW/LONG RAW/BLOB 二进制数据，不做字符集转换
+func binaryColumnDecoder(raw []byte, param ColumnConvertParam) (interface{}, error) {
+	return raw, nil
+}
+
+// 字符数据源端字符集转换为 UTF8MB4
+func stringColumnDecoder(raw []byte, param ColumnConvertParam) (interface{}, error) {
+	convertUtf8Raw, err := common.CharsetConvertByMode(raw, param.SourceDBCharset, common.CharsetUTF8MB4, param.CharsetErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("column [%s] charset convert failed, %v", param.ColumnName, err)
+	}
+	return string(convertUtf8Raw), nil
+}
+
+// FormatMySQLColumnValue 解码值格式化为下游 MySQL/TiDB SQL 字面量
+func FormatMySQLColumnValue(value interface{}, param ColumnConvertParam) (string, error) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case decimal.Decimal:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return common.StringsBuilder("'", v.Format("2006-01-02 15:04:05.999999999"), "'"), nil
+	case []byte:
+		// 二进制数据十六进制字面量写入，不做字符集转换以及特殊字符转义
+		return common.StringsBuilder("X'", hex.EncodeToString(v), "'"), nil
+	case string:
+		convertTargetRaw, err := common.CharsetConvertByMode([]byte(common.SpecialLettersUsingMySQL([]byte(v))), common.CharsetUTF8MB4, param.TargetDBCharset, param.CharsetErrorMode)
+		if err != nil {
+			return "", fmt.Errorf("column [%s] charset convert failed, %v", param.ColumnName, err)
+		}
+		return common.StringsBuilder("'", string(convertTargetRaw), "'"), nil
+	default:
+		return "", fmt.Errorf("column [%s] value type [%T] format isn't support", param.ColumnName, value)
+	}
+}
+
+// DecodedRowsBytes 解码批次估算字节数，字符以及二进制按实际长度、其余类型按 8 字节计，用于内存预算统计，写入端按相同方式释放
+func DecodedRowsBytes(rows [][]interface{}) int {
+	var bytes int
+	for _, row := range rows {
+		for _, v := range row {
+			switch val := v.(type) {
+			case string:
+				bytes += len(val)
+			case []byte:
+				bytes += len(val)
+			default:
+				bytes += 8
+			}
+		}
+	}
+	return bytes
+}
+
+// FormatCompareColumnValue 解码值格式化为数据校验行字符串，需与 MySQL 端查询结果格式保持一致
+// 二进制以及字符数据按原始内容特殊字符转义加引号输出
+func FormatCompareColumnValue(value interface{}) string {
+	switch v := value.(type) {
+	case int64, float32, float64:
+		return fmt.Sprintf("%v", v)
+	case decimal.Decimal:
+		// 十进制精确输出并去除小数末尾 0，不经 float 转换避免高精度 NUMBER 精度丢失以及科学计数法输出
+		return v.String()
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return fmt.Sprintf("'%v'", v.Format("2006-01-02 15:04:05.999999999"))
+	case []byte:
+		return fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(v))
+	default:
+		return fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL([]byte(fmt.Sprintf("%v", v))))
+	}
+}
+
+// formatCSVColumnValue 解码值格式化为 CSV 字段值
+func formatCSVColumnValue(value interface{}, columnName, targetDBCharset string, cfg *config.Config) (string, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999"), nil
+	case []byte:
+		// RAW/LONG RAW/BLOB 二进制数据，十六进制字符串输出，不做字符集转换以及特殊字符转义
+		return hex.EncodeToString(v), nil
+	case string:
+		var (
+			convertTargetRaw []byte
+			err              error
+		)
+		// 处理字符集、特殊字符转义、字符串引用定界符
+		if cfg.CSVConfig.EscapeBackslash {
+			convertTargetRaw, err = common.CharsetConvertByMode([]byte(common.SpecialLettersUsingMySQL([]byte(v))), common.CharsetUTF8MB4, targetDBCharset, cfg.AppConfig.CharsetErrorMode)
+		} else {
+			convertTargetRaw, err = common.CharsetConvertByMode([]byte(v), common.CharsetUTF8MB4, targetDBCharset, cfg.AppConfig.CharsetErrorMode)
+		}
+		if err != nil {
+			return "", fmt.Errorf("column [%s] charset convert failed, %v", columnName, err)
+		}
+		if cfg.CSVConfig.Delimiter == "" {
+			return string(convertTargetRaw), nil
+		}
+		return common.StringsBuilder(cfg.CSVConfig.Delimiter, string(convertTargetRaw), cfg.CSVConfig.Delimiter), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
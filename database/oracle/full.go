@@ -17,10 +17,15 @@ package oracle
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"github.com/shopspring/decimal"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func (o *Oracle) GetOracleCurrentSnapshotSCN() (uint64, error) {
@@ -49,11 +54,10 @@ func (o *Oracle) StartOracleChunkCreateTask(taskName string) error {
 		}
 	}
 
-	ctx, _ := context.WithCancel(o.Ctx)
 	createSQL := common.StringsBuilder(`BEGIN
   DBMS_PARALLEL_EXECUTE.CREATE_TASK (task_name => '`, taskName, `');
 END;`)
-	_, err = o.OracleDB.ExecContext(ctx, createSQL)
+	_, err = o.OracleDB.ExecContext(o.Ctx, createSQL)
 	if err != nil {
 		return fmt.Errorf("oracle DBMS_PARALLEL_EXECUTE create task failed: %v, sql: %v", err, createSQL)
 	}
@@ -61,8 +65,6 @@ END;`)
 }
 
 func (o *Oracle) StartOracleCreateChunkByRowID(taskName, schemaName, tableName string, chunkSize string) error {
-	ctx, _ := context.WithCancel(o.Ctx)
-
 	chunkSQL := common.StringsBuilder(`BEGIN
   DBMS_PARALLEL_EXECUTE.CREATE_CHUNKS_BY_ROWID (task_name   => '`, taskName, `',
                                                table_owner => '`, schemaName, `',
@@ -70,7 +72,7 @@ func (o *Oracle) StartOracleCreateChunkByRowID(taskName, schemaName, tableName s
                                                by_row      => TRUE,
                                                chunk_size  => `, chunkSize, `);
 END;`)
-	_, err := o.OracleDB.ExecContext(ctx, chunkSQL)
+	_, err := o.OracleDB.ExecContext(o.Ctx, chunkSQL)
 	if err != nil {
 		return fmt.Errorf("oracle DBMS_PARALLEL_EXECUTE create_chunks_by_rowid task failed: %v, sql: %v", err, chunkSQL)
 	}
@@ -87,14 +89,55 @@ func (o *Oracle) GetOracleTableChunksByRowID(taskName string) ([]map[string]stri
 	return res, nil
 }
 
-func (o *Oracle) CloseOracleChunkTask(taskName string) error {
-	ctx, _ := context.WithCancel(context.Background())
+// TruncateOracleTable 清理 oracle 表数据，用于 mysql/tidb -> oracle 数据迁移
+func (o *Oracle) TruncateOracleTable(targetSchema, targetTable string) error {
+	_, err := o.OracleDB.ExecContext(o.Ctx, fmt.Sprintf("TRUNCATE TABLE %s.%s", targetSchema, targetTable))
+	if err != nil {
+		return fmt.Errorf("oracle truncate table [%s.%s] failed: %v", targetSchema, targetTable, err)
+	}
+	return nil
+}
+
+// InsertOracleTable 单事务预编译绑定变量批量写入 oracle 表，返回写入行数
+func (o *Oracle) InsertOracleTable(insertSQL string, rows [][]interface{}) (int64, error) {
+	txn, err := o.OracleDB.BeginTx(o.Ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("oracle begin transaction failed: %v", err)
+	}
+	stmt, err := txn.PrepareContext(o.Ctx, insertSQL)
+	if err != nil {
+		_ = txn.Rollback()
+		return 0, fmt.Errorf("oracle sql [%s] prepare failed: %v", insertSQL, err)
+	}
+	defer stmt.Close()
+
+	var affectRows int64
+	for _, row := range rows {
+		res, err := stmt.ExecContext(o.Ctx, row...)
+		if err != nil {
+			_ = txn.Rollback()
+			return 0, fmt.Errorf("oracle sql [%s] exec failed: %v, row data: %v", insertSQL, err, row)
+		}
+		rowCounts, err := res.RowsAffected()
+		if err != nil {
+			_ = txn.Rollback()
+			return 0, fmt.Errorf("oracle sql [%s] rows affected failed: %v", insertSQL, err)
+		}
+		affectRows += rowCounts
+	}
+	if err = txn.Commit(); err != nil {
+		return 0, fmt.Errorf("oracle commit transaction failed: %v", err)
+	}
+	return affectRows, nil
+}
 
+// 任务取消后仍需清理 chunk 任务，故不使用任务 Ctx
+func (o *Oracle) CloseOracleChunkTask(taskName string) error {
 	clearSQL := common.StringsBuilder(`BEGIN
   DBMS_PARALLEL_EXECUTE.DROP_TASK ('`, taskName, `');
 END;`)
 
-	_, err := o.OracleDB.ExecContext(ctx, clearSQL)
+	_, err := o.OracleDB.ExecContext(context.Background(), clearSQL)
 	if err != nil {
 		return fmt.Errorf("oracle DBMS_PARALLEL_EXECUTE drop task failed: %v, sql: %v", err, clearSQL)
 	}
@@ -121,15 +164,28 @@ func (o *Oracle) GetOracleTableRowsColumnCSV(querySQL string) ([]string, error)
 
 func (o *Oracle) GetOracleTableRowsDataCSV(querySQL, sourceDBCharset, targetDBCharset string, cfg *config.Config, dataChan chan []map[string]string) error {
 	var (
-		err         error
-		columnNames []string
-		columnTypes []string
+		err           error
+		columnNames   []string
+		databaseTypes []string
+		decoders      []ColumnDecoder
 	)
 	// 临时数据存放
 	var rowsTMP []map[string]string
 	rowsMap := make(map[string]string)
 
-	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
+	// NULL 值输出表示
+	nullValue := `NULL`
+	if cfg.CSVConfig.NullValue != "" {
+		nullValue = cfg.CSVConfig.NullValue
+	}
+
+	// 慢 SQL 耗时不含限速以及下游通道等待耗时
+	var (
+		rowCounts int64
+		waitCost  time.Duration
+	)
+	startTime := time.Now()
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, o.fetchOptions()...)
 	if err != nil {
 		return err
 	}
@@ -142,8 +198,8 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL, sourceDBCharset, targetDBCh
 
 	for _, ct := range colTypes {
 		columnNames = append(columnNames, ct.Name())
-		// 数据库字段类型 DatabaseTypeName() 映射 go 类型 ScanType()
-		columnTypes = append(columnTypes, ct.ScanType().String())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+		decoders = append(decoders, GetColumnDecoder(ct.DatabaseTypeName()))
 	}
 
 	// 数据 SCAN
@@ -154,6 +210,9 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL, sourceDBCharset, targetDBCh
 		dest[i] = &rawResult[i]
 	}
 
+	// 限速统计批次字节数
+	var batchBytes int
+
 	// 表行数读取
 	for rows.Next() {
 		err = rows.Scan(dest...)
@@ -162,79 +221,34 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL, sourceDBCharset, targetDBCh
 		}
 
 		for i, raw := range rawResult {
+			batchBytes += len(raw)
 			// 注意 Oracle/Mysql NULL VS 空字符串区别
 			// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理 （is null 可以查询 NULL 以及空字符串值，空字符串查询无法查询到空字符串值）
 			// Mysql 空字符串与 NULL 非一类，NULL 是 NULL，空字符串是空字符串（is null 只查询 NULL 值，空字符串查询只查询到空字符串值）
 			// 按照 Oracle 特性来，转换同步统一转换成 NULL 即可，但需要注意业务逻辑中空字符串得写入，需要变更
 			// Oracle/Mysql 对于 'NULL' 统一字符 NULL 处理，查询出来转成 NULL,所以需要判断处理
-			if raw == nil {
-				rowsMap[columnNames[i]] = fmt.Sprintf("%v", `NULL`)
-			} else if string(raw) == "" {
-				rowsMap[columnNames[i]] = fmt.Sprintf("%v", `NULL`)
+			// empty-string-mode 配置 NULL 以及空字符串处理方式，empty 字符类型字段 NULL 按空字符串写入
+			if isNull, isEmpty := emptyStringValue(raw, databaseTypes[i], cfg.AppConfig.EmptyStringMode); isNull {
+				rowsMap[columnNames[i]] = nullValue
+			} else if isEmpty {
+				rowsMap[columnNames[i]] = common.StringsBuilder(cfg.CSVConfig.Delimiter, cfg.CSVConfig.Delimiter)
+			} else if skip, err := lobOversize(columnNames[i], databaseTypes[i], raw, cfg.AppConfig.LOBMaxSize, cfg.AppConfig.LOBOversizeMode); err != nil {
+				return err
+			} else if skip {
+				rowsMap[columnNames[i]] = nullValue
 			} else {
-				switch columnTypes[i] {
-				case "int64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[columnNames[i]] = fmt.Sprintf("%v", r)
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[columnNames[i]] = fmt.Sprintf("%v", r)
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[columnNames[i]] = fmt.Sprintf("%v", r)
-				case "float64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[columnNames[i]] = fmt.Sprintf("%v", r)
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[columnNames[i]] = fmt.Sprintf("%v", r)
-				case "godror.Number":
-					r, err := decimal.NewFromString(string(raw))
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[columnNames[i]] = fmt.Sprintf("%v", r)
-				default:
-					var convertTargetRaw []byte
-
-					convertUtf8Raw, err := common.CharsetConvert(raw, sourceDBCharset, common.CharsetUTF8MB4)
-					if err != nil {
-						return fmt.Errorf("column [%s] charset convert failed, %v", columnNames[i], err)
-					}
-
-					// 处理字符集、特殊字符转义、字符串引用定界符
-					if cfg.CSVConfig.EscapeBackslash {
-						convertTargetRaw, err = common.CharsetConvert([]byte(common.SpecialLettersUsingMySQL(convertUtf8Raw)), common.CharsetUTF8MB4, targetDBCharset)
-						if err != nil {
-							return fmt.Errorf("column [%s] charset convert failed, %v", columnNames[i], err)
-						}
-					} else {
-						convertTargetRaw, err = common.CharsetConvert(convertUtf8Raw, common.CharsetUTF8MB4, targetDBCharset)
-						if err != nil {
-							return fmt.Errorf("column [%s] charset convert failed, %v", columnNames[i], err)
-						}
-					}
-
-					if cfg.CSVConfig.Delimiter == "" {
-						rowsMap[columnNames[i]] = fmt.Sprintf("%v", string(convertTargetRaw))
-					} else {
-						rowsMap[columnNames[i]] = fmt.Sprintf("%v", common.StringsBuilder(cfg.CSVConfig.Delimiter, string(convertTargetRaw), cfg.CSVConfig.Delimiter))
-					}
+				val, err := decoders[i](raw, ColumnConvertParam{
+					ColumnName:       columnNames[i],
+					SourceDBCharset:  sourceDBCharset,
+					TargetDBCharset:  targetDBCharset,
+					CharsetErrorMode: cfg.AppConfig.CharsetErrorMode,
+				})
+				if err != nil {
+					return err
+				}
+				rowsMap[columnNames[i]], err = formatCSVColumnValue(val, columnNames[i], targetDBCharset, cfg)
+				if err != nil {
+					return err
 				}
 			}
 		}
@@ -247,8 +261,18 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL, sourceDBCharset, targetDBCh
 
 		// batch 批次
 		if len(rowsTMP) == cfg.AppConfig.InsertBatchSize {
-
+			rowCounts += int64(len(rowsTMP))
+			waitTime := time.Now()
+			if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+				return err
+			}
+			// 待写入批次超过内存预算，暂停抽取等待下游写入
+			if err = o.Budget.Wait(o.Ctx); err != nil {
+				return err
+			}
+			batchBytes = 0
 			dataChan <- rowsTMP
+			waitCost += time.Since(waitTime)
 
 			// 数组清空
 			rowsTMP = make([]map[string]string, 0)
@@ -261,13 +285,124 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL, sourceDBCharset, targetDBCh
 
 	// 非 batch 批次
 	if len(rowsTMP) > 0 {
-
+		rowCounts += int64(len(rowsTMP))
+		waitTime := time.Now()
+		if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+			return err
+		}
+		if err = o.Budget.Wait(o.Ctx); err != nil {
+			return err
+		}
+		batchBytes = 0
 		dataChan <- rowsTMP
+		waitCost += time.Since(waitTime)
 	}
+	o.SlowLog.Observe(querySQL, time.Since(startTime)-waitCost, rowCounts)
 
 	return nil
 }
 
+// 获取 schema 下表段大小（字节，含分区/子分区以及 LOB 段）-> 用于全量表迁移顺序 largest-first/smallest-first
+func (o *Oracle) GetOracleSchemaTableSegmentBytes(schemaName string) (map[string]int64, error) {
+	querySQL := fmt.Sprintf(`SELECT TABLE_NAME, SUM(BYTES) AS BYTES
+  FROM (SELECT S.SEGMENT_NAME AS TABLE_NAME, S.BYTES
+          FROM DBA_SEGMENTS S
+         WHERE S.OWNER = '%[1]s'
+           AND S.SEGMENT_TYPE IN ('TABLE', 'TABLE PARTITION', 'TABLE SUBPARTITION')
+        UNION ALL
+        SELECT L.TABLE_NAME, S.BYTES
+          FROM DBA_LOBS L, DBA_SEGMENTS S
+         WHERE L.OWNER = '%[1]s'
+           AND S.OWNER = L.OWNER
+           AND S.SEGMENT_NAME = L.SEGMENT_NAME)
+ GROUP BY TABLE_NAME`, common.StringUPPER(schemaName))
+	tableBytes := make(map[string]int64)
+	err := o.queryStreamRows(querySQL, func(r map[string]string) error {
+		bytes, err := strconv.ParseInt(r["BYTES"], 10, 64)
+		if err != nil {
+			return fmt.Errorf("get oracle schema [%s] table [%s] segment bytes [%s] strconv.ParseInt failed: %v", schemaName, r["TABLE_NAME"], r["BYTES"], err)
+		}
+		tableBytes[common.StringUPPER(r["TABLE_NAME"])] = bytes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tableBytes, nil
+}
+
+// 获取 schema 下表统计信息行数 -> 用于全量迁移预估计划
+func (o *Oracle) GetOracleSchemaTableRowsByStatistics(schemaName string) (map[string]int64, error) {
+	querySQL := fmt.Sprintf(`SELECT TABLE_NAME, NVL(NUM_ROWS,0) AS NUM_ROWS FROM DBA_TABLES WHERE OWNER = '%s'`, common.StringUPPER(schemaName))
+	tableRows := make(map[string]int64)
+	err := o.queryStreamRows(querySQL, func(r map[string]string) error {
+		rows, err := strconv.ParseInt(r["NUM_ROWS"], 10, 64)
+		if err != nil {
+			return fmt.Errorf("get oracle schema [%s] table [%s] rows [%s] by statistics strconv.ParseInt failed: %v", schemaName, r["TABLE_NAME"], r["NUM_ROWS"], err)
+		}
+		tableRows[common.StringUPPER(r["TABLE_NAME"])] = rows
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tableRows, nil
+}
+
+// 获取 schema 下表外键依赖（子表 -> 父表列表），仅同 schema 内外键 -> 用于全量表迁移顺序 dependency
+func (o *Oracle) GetOracleSchemaTableDependency(schemaName string) (map[string][]string, error) {
+	querySQL := fmt.Sprintf(`SELECT DISTINCT C.TABLE_NAME, P.TABLE_NAME AS R_TABLE_NAME
+  FROM DBA_CONSTRAINTS C, DBA_CONSTRAINTS P
+ WHERE C.CONSTRAINT_TYPE = 'R'
+   AND C.OWNER = '%[1]s'
+   AND P.OWNER = C.R_OWNER
+   AND P.CONSTRAINT_NAME = C.R_CONSTRAINT_NAME
+   AND P.OWNER = '%[1]s'`, common.StringUPPER(schemaName))
+	dependency := make(map[string][]string)
+	err := o.queryStreamRows(querySQL, func(r map[string]string) error {
+		child, parent := common.StringUPPER(r["TABLE_NAME"]), common.StringUPPER(r["R_TABLE_NAME"])
+		if child != parent {
+			dependency[child] = append(dependency[child], parent)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dependency, nil
+}
+
+// 源端 NULL 以及空字符串按 empty-string-mode 处理，返回字段值按 NULL 写入或者按空字符串写入，均为 false 代表非空值
+func emptyStringValue(raw []byte, databaseType, emptyStringMode string) (isNull bool, isEmpty bool) {
+	if len(raw) > 0 {
+		return false, false
+	}
+	switch {
+	case strings.EqualFold(emptyStringMode, common.EmptyStringModeEmpty) && common.IsContainString(common.OracleCharacterDatabaseTypes, common.StringUPPER(databaseType)):
+		return false, true
+	case strings.EqualFold(emptyStringMode, common.EmptyStringModeNull) && raw != nil:
+		return false, true
+	default:
+		return true, false
+	}
+}
+
+// LOB 字段值超过 lob-max-size 处理，返回是否跳过字段值（按 NULL 写入）
+func lobOversize(columnName, databaseType string, raw []byte, lobMaxSize int, lobOversizeMode string) (bool, error) {
+	if lobMaxSize <= 0 || len(raw) <= lobMaxSize || !common.IsContainString(common.OracleLOBDatabaseTypes, common.StringUPPER(databaseType)) {
+		return false, nil
+	}
+	if strings.EqualFold(lobOversizeMode, common.LOBOversizeModeSkip) {
+		zap.L().Warn("oracle lob column value over lob-max-size, skip and write NULL",
+			zap.String("column", columnName),
+			zap.String("datatype", databaseType),
+			zap.Int("size", len(raw)),
+			zap.Int("lob-max-size", lobMaxSize))
+		return true, nil
+	}
+	return false, fmt.Errorf("column [%s] datatype [%s] value size [%d] over lob-max-size [%d]", columnName, databaseType, len(raw), lobMaxSize)
+}
+
 // 获取表字段名以及行数据 -> 用于 FULL/ALL
 func (o *Oracle) GetOracleTableRowsColumn(querySQL string) ([]string, error) {
 	var (
@@ -293,7 +428,7 @@ func (o *Oracle) GetOracleTableRowsColumn(querySQL string) ([]string, error) {
 	return columns, nil
 }
 
-func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, sourceDBCharset, targetDBCharset string, dataChan chan []map[string]string) error {
+func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, sourceDBCharset, targetDBCharset, emptyStringMode string, lobMaxSize int, lobOversizeMode, charsetErrorMode string, dataChan chan []map[string]string) error {
 	var (
 		err  error
 		cols []string
@@ -303,7 +438,13 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, so
 	var rowsTMP []map[string]string
 	rowsMap := make(map[string]string)
 
-	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
+	// 慢 SQL 耗时不含限速、内存预算以及下游通道等待耗时
+	var (
+		rowCounts int64
+		waitCost  time.Duration
+	)
+	startTime := time.Now()
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, o.fetchOptions()...)
 	if err != nil {
 		return err
 	}
@@ -319,11 +460,11 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, so
 		cols = append(cols, common.StringsBuilder("`", col, "`"))
 	}
 
-	// 用于判断字段值是数字还是字符
+	// 字段值按字段类型转换，是否加引号由字段类型决定
 	var (
 		columnNames   []string
-		columnTypes   []string
 		databaseTypes []string
+		converters    []ColumnConverter
 	)
 	colTypes, err := rows.ColumnTypes()
 	if err != nil {
@@ -332,9 +473,8 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, so
 
 	for _, ct := range colTypes {
 		columnNames = append(columnNames, ct.Name())
-		// 数据库字段类型 DatabaseTypeName() 映射 go 类型 ScanType()
-		columnTypes = append(columnTypes, ct.ScanType().String())
 		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+		converters = append(converters, GetColumnConverter(ct.DatabaseTypeName()))
 	}
 
 	// 数据 Scan
@@ -345,6 +485,9 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, so
 		dest[i] = &rawResult[i]
 	}
 
+	// 限速统计批次字节数
+	var batchBytes int
+
 	// 表行数读取
 	for rows.Next() {
 		err = rows.Scan(dest...)
@@ -353,83 +496,310 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, so
 		}
 
 		for i, raw := range rawResult {
+			batchBytes += len(raw)
 			// 注意 Oracle/Mysql NULL VS 空字符串区别
 			// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理 （is null 可以查询 NULL 以及空字符串值，空字符串查询无法查询到空字符串值）
 			// Mysql 空字符串与 NULL 非一类，NULL 是 NULL，空字符串是空字符串（is null 只查询 NULL 值，空字符串查询只查询到空字符串值）
 			// 按照 Oracle 特性来，转换同步统一转换成 NULL 即可，但需要注意业务逻辑中空字符串得写入，需要变更
 			// Oracle/Mysql 对于 'NULL' 统一字符 NULL 处理，查询出来转成 NULL,所以需要判断处理
-			if raw == nil {
+			// empty-string-mode 配置 NULL 以及空字符串处理方式，empty 字符类型字段 NULL 按空字符串写入
+			if isNull, isEmpty := emptyStringValue(raw, databaseTypes[i], emptyStringMode); isNull {
 				rowsMap[cols[i]] = fmt.Sprintf("%v", `NULL`)
-			} else if string(raw) == "" {
+			} else if isEmpty {
+				rowsMap[cols[i]] = fmt.Sprintf("%v", `''`)
+			} else if skip, err := lobOversize(columnNames[i], databaseTypes[i], raw, lobMaxSize, lobOversizeMode); err != nil {
+				return err
+			} else if skip {
 				rowsMap[cols[i]] = fmt.Sprintf("%v", `NULL`)
+			} else {
+				val, err := converters[i](raw, ColumnConvertParam{
+					ColumnName:       columnNames[i],
+					SourceDBCharset:  sourceDBCharset,
+					TargetDBCharset:  targetDBCharset,
+					CharsetErrorMode: charsetErrorMode,
+				})
+				if err != nil {
+					return err
+				}
+				rowsMap[cols[i]] = val
+			}
+		}
+
+		// 临时数组
+		rowsTMP = append(rowsTMP, rowsMap)
+
+		// MAP 清空
+		rowsMap = make(map[string]string)
+
+		// batch 批次
+		if len(rowsTMP) == insertBatchSize {
+			rowCounts += int64(len(rowsTMP))
+			waitTime := time.Now()
+			if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+				return err
+			}
+			// 待写入批次超过内存预算，暂停抽取等待下游写入
+			if err = o.Budget.Wait(o.Ctx); err != nil {
+				return err
+			}
+			batchBytes = 0
+			dataChan <- rowsTMP
+			waitCost += time.Since(waitTime)
+
+			// 数组清空
+			rowsTMP = make([]map[string]string, 0)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// 非 batch 批次
+	if len(rowsTMP) > 0 {
+		rowCounts += int64(len(rowsTMP))
+		waitTime := time.Now()
+		if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+			return err
+		}
+		if err = o.Budget.Wait(o.Ctx); err != nil {
+			return err
+		}
+		batchBytes = 0
+		dataChan <- rowsTMP
+		waitCost += time.Since(waitTime)
+	}
+	o.SlowLog.Observe(querySQL, time.Since(startTime)-waitCost, rowCounts)
+
+	return nil
+}
+
+// copyRowsBytes COPY 批次待写入字节数，与 o2p 写入端按相同方式计算，用于内存预算统计
+func copyRowsBytes(rows []string) int {
+	var bytes int
+	for _, r := range rows {
+		bytes += len(r)
+	}
+	return bytes
+}
+
+// 获取表行数据并转换 PostgreSQL COPY text 格式行（字段 \t 分隔、NULL 为 \N）-> 用于 oracle -> postgresql FULL
+// postgresql 连接字符集固定 UTF8，字段值统一转换 UTF8
+func (o *Oracle) GetOracleTableRowsDataCOPY(querySQL string, insertBatchSize int, sourceDBCharset, emptyStringMode string, lobMaxSize int, lobOversizeMode, charsetErrorMode string, dataChan chan []string) error {
+	var (
+		err           error
+		columnNames   []string
+		columnTypes   []string
+		databaseTypes []string
+	)
+
+	// 临时数据存放
+	var (
+		rowsTMP []string
+		rowTMP  []string
+	)
+
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, o.fetchOptions()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	for _, ct := range colTypes {
+		columnNames = append(columnNames, ct.Name())
+		// 数据库字段类型 DatabaseTypeName() 映射 go 类型 ScanType()
+		columnTypes = append(columnTypes, ct.ScanType().String())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+	}
+
+	// 数据 Scan
+	columns := len(columnNames)
+	rawResult := make([][]byte, columns)
+	dest := make([]interface{}, columns)
+	for i := range rawResult {
+		dest[i] = &rawResult[i]
+	}
+
+	// 限速统计批次字节数
+	var batchBytes int
+
+	// 表行数读取
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return err
+		}
+
+		for i, raw := range rawResult {
+			batchBytes += len(raw)
+			// NULL 以及空字符串处理同 GetOracleTableRowsData
+			if isNull, isEmpty := emptyStringValue(raw, databaseTypes[i], emptyStringMode); isNull {
+				rowTMP = append(rowTMP, `\N`)
+			} else if isEmpty {
+				rowTMP = append(rowTMP, "")
+			} else if skip, err := lobOversize(columnNames[i], databaseTypes[i], raw, lobMaxSize, lobOversizeMode); err != nil {
+				return err
+			} else if skip {
+				rowTMP = append(rowTMP, `\N`)
 			} else {
 				switch columnTypes[i] {
-				case "int64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
-				case "float64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
-					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
-				case "godror.Number":
+				case "int64", "uint64", "float32", "float64", "rune", "godror.Number":
 					r, err := decimal.NewFromString(string(raw))
 					if err != nil {
 						return fmt.Errorf("column [%s] NewFromString strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowTMP = append(rowTMP, r.String())
 				default:
-					// 特殊字符
-					convertUtf8Raw, err := common.CharsetConvert(raw, sourceDBCharset, common.CharsetUTF8MB4)
-					if err != nil {
-						return fmt.Errorf("column [%s] charset convert failed, %v", columnNames[i], err)
+					// RAW/LONG RAW/BLOB 二进制数据，bytea 十六进制格式 \x 写入，COPY text 格式反斜杠需转义
+					if common.IsContainString(common.OracleBinaryDatabaseTypes, common.StringUPPER(databaseTypes[i])) {
+						rowTMP = append(rowTMP, common.StringsBuilder(`\\x`, hex.EncodeToString(raw)))
+						continue
 					}
 
-					convertTargetRaw, err := common.CharsetConvert([]byte(common.SpecialLettersUsingMySQL(convertUtf8Raw)), common.CharsetUTF8MB4, targetDBCharset)
+					convertUtf8Raw, err := common.CharsetConvertByMode(raw, sourceDBCharset, common.CharsetUTF8MB4, charsetErrorMode)
 					if err != nil {
 						return fmt.Errorf("column [%s] charset convert failed, %v", columnNames[i], err)
 					}
-
-					rowsMap[cols[i]] = fmt.Sprintf("'%v'", string(convertTargetRaw))
+					rowTMP = append(rowTMP, common.SpecialLettersUsingPostgreSQLCopy(convertUtf8Raw))
 				}
 			}
 		}
 
-		// 临时数组
-		rowsTMP = append(rowsTMP, rowsMap)
-
-		// MAP 清空
-		rowsMap = make(map[string]string)
+		rowsTMP = append(rowsTMP, strings.Join(rowTMP, "\t"))
+		rowTMP = rowTMP[0:0]
 
 		// batch 批次
 		if len(rowsTMP) == insertBatchSize {
 
+			if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+				return err
+			}
+			// 待写入批次超过内存预算，暂停抽取等待下游写入
+			if err = o.Budget.Wait(o.Ctx); err != nil {
+				return err
+			}
+			o.Budget.Add(copyRowsBytes(rowsTMP))
+			batchBytes = 0
 			dataChan <- rowsTMP
 
 			// 数组清空
-			rowsTMP = make([]map[string]string, 0)
+			rowsTMP = make([]string, 0)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// 非 batch 批次
+	if len(rowsTMP) > 0 {
+		if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+			return err
+		}
+		if err = o.Budget.Wait(o.Ctx); err != nil {
+			return err
+		}
+		o.Budget.Add(copyRowsBytes(rowsTMP))
+		batchBytes = 0
+		dataChan <- rowsTMP
+	}
+
+	return nil
+}
+
+// 获取表行数据并按字段类型解码为 Go 类型值（ColumnDecoder），NULL 为 nil -> 用于 oracle -> clickhouse FULL 等强类型写入
+// 字段值按查询字段顺序输出，字符数据统一转换 UTF8
+func (o *Oracle) GetOracleTableRowsDataDecoded(querySQL string, insertBatchSize int, sourceDBCharset, emptyStringMode string, lobMaxSize int, lobOversizeMode, charsetErrorMode string, dataChan chan [][]interface{}) error {
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, o.fetchOptions()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	var (
+		columnNames   []string
+		databaseTypes []string
+		decoders      []ColumnDecoder
+	)
+	for _, ct := range colTypes {
+		columnNames = append(columnNames, ct.Name())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+		decoders = append(decoders, GetColumnDecoder(ct.DatabaseTypeName()))
+	}
+
+	// 数据 Scan
+	columns := len(columnNames)
+	rawResult := make([][]byte, columns)
+	dest := make([]interface{}, columns)
+	for i := range rawResult {
+		dest[i] = &rawResult[i]
+	}
+
+	var (
+		rowsTMP    [][]interface{}
+		batchBytes int
+	)
+
+	// 表行数读取
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return err
+		}
+
+		rowTMP := make([]interface{}, columns)
+		for i, raw := range rawResult {
+			batchBytes += len(raw)
+			// NULL 以及空字符串处理同 GetOracleTableRowsData
+			if isNull, isEmpty := emptyStringValue(raw, databaseTypes[i], emptyStringMode); isNull {
+				rowTMP[i] = nil
+			} else if isEmpty {
+				rowTMP[i] = ""
+			} else if skip, err := lobOversize(columnNames[i], databaseTypes[i], raw, lobMaxSize, lobOversizeMode); err != nil {
+				return err
+			} else if skip {
+				rowTMP[i] = nil
+			} else {
+				// Scan 复用 rawResult 缓冲区，二进制值需拷贝
+				val, err := decoders[i](append([]byte(nil), raw...), ColumnConvertParam{
+					ColumnName:       columnNames[i],
+					SourceDBCharset:  sourceDBCharset,
+					TargetDBCharset:  common.CharsetUTF8MB4,
+					CharsetErrorMode: charsetErrorMode,
+				})
+				if err != nil {
+					return err
+				}
+				rowTMP[i] = val
+			}
+		}
+		rowsTMP = append(rowsTMP, rowTMP)
+
+		// batch 批次
+		if len(rowsTMP) == insertBatchSize {
+			if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+				return err
+			}
+			// 待写入批次超过内存预算，暂停抽取等待下游写入
+			if err = o.Budget.Wait(o.Ctx); err != nil {
+				return err
+			}
+			o.Budget.Add(DecodedRowsBytes(rowsTMP))
+			batchBytes = 0
+			dataChan <- rowsTMP
+
+			// 数组清空
+			rowsTMP = make([][]interface{}, 0)
 		}
 	}
 
@@ -439,6 +809,13 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, so
 
 	// 非 batch 批次
 	if len(rowsTMP) > 0 {
+		if err = o.Throttle.Wait(o.Ctx, len(rowsTMP), batchBytes); err != nil {
+			return err
+		}
+		if err = o.Budget.Wait(o.Ctx); err != nil {
+			return err
+		}
+		o.Budget.Add(DecodedRowsBytes(rowsTMP))
 		dataChan <- rowsTMP
 	}
 
@@ -23,14 +23,31 @@ import (
 	"github.com/godror/godror/dsn"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Oracle struct {
 	Ctx      context.Context
 	OracleDB *sql.DB
+	// 源端数据抽取限速，nil 不限速
+	Throttle *common.Throttle
+	// 全量同步读写背压内存预算，nil 不限制
+	Budget *common.MemoryBudget
+	// 源端 chunk 抽取慢 SQL 记录，nil 不记录
+	SlowLog *common.SlowLog
+	// 数据抽取 godror fetch array size 以及 prefetch rows，小于等于 0 沿用驱动默认值
+	FetchArraySize int
+	PrefetchCount  int
+	// LONG/LONG RAW 字段表逐行抽取，godror 数组抓取 LONG 字段按最大长度分配缓冲区
+	LongFetch bool
+	// ADG 备库只读抽取，chunk 切分不使用 DBMS_PARALLEL_EXECUTE
+	Standby bool
 }
 
 // 创建 oracle 数据库引擎
@@ -70,9 +87,34 @@ func NewOracleDBEngine(ctx context.Context, oraCfg config.OracleConfig, currentS
 		oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET CURRENT_SCHEMA = %s`, currentSchema))
 	}
 
+	// NLS 时间格式以及会话时区，影响未显式 TO_CHAR 格式化的时间字段以及 TIMESTAMP WITH LOCAL TIME ZONE 输出
+	if !strings.EqualFold(oraCfg.NLSDateFormat, "") {
+		oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET NLS_DATE_FORMAT = '%s'`, oraCfg.NLSDateFormat))
+	}
+	if !strings.EqualFold(oraCfg.NLSTimestampFormat, "") {
+		oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET NLS_TIMESTAMP_FORMAT = '%s'`, oraCfg.NLSTimestampFormat))
+	}
+	if !strings.EqualFold(oraCfg.NLSTimestampTZFormat, "") {
+		oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET NLS_TIMESTAMP_TZ_FORMAT = '%s'`, oraCfg.NLSTimestampTZFormat))
+	}
+	if !strings.EqualFold(oraCfg.TimeZone, "") {
+		oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET TIME_ZONE = '%s'`, oraCfg.TimeZone))
+	}
+	// 数字小数点以及千分位字符，数据抽取按 '.' 小数点解析数字，未配置固定 '.,'，避免数据库默认 NLS_TERRITORY 影响 TO_CHAR 数字输出
+	nlsNumericCharacters := common.OracleNLSNumericCharacters
+	if !strings.EqualFold(oraCfg.NLSNumericCharacters, "") {
+		nlsNumericCharacters = oraCfg.NLSNumericCharacters
+	}
+	oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET NLS_NUMERIC_CHARACTERS = '%s'`, nlsNumericCharacters))
+	// 会话级别强制并行查询，大于 1 生效
+	if oraCfg.ParallelDegree > 1 {
+		oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION FORCE PARALLEL QUERY PARALLEL %d`, oraCfg.ParallelDegree))
+	}
+
 	// 关闭外部认证
 	oraDSN.ExternalAuth = false
 	oraDSN.OnInitStmts = oraCfg.SessionParams
+	setOracleConnSecurity(&oraDSN, oraCfg)
 
 	// libDir won't have any effect on Linux for linking reasons to do with Oracle's libnnz library that are proving to be intractable.
 	// You must set LD_LIBRARY_PATH or run ldconfig before your process starts.
@@ -93,18 +135,71 @@ func NewOracleDBEngine(ctx context.Context, oraCfg config.OracleConfig, currentS
 	// godror.SetLogger(zapr.NewLogger(zap.L()))
 
 	sqlDB := sql.OpenDB(godror.NewConnector(oraDSN))
-	sqlDB.SetMaxIdleConns(0)
-	sqlDB.SetMaxOpenConns(0)
-	sqlDB.SetConnMaxLifetime(0)
+	setOracleConnPool(sqlDB, oraCfg)
 
 	err = sqlDB.Ping()
 	if err != nil {
 		return nil, fmt.Errorf("error on ping oracle database connection:%v", err)
 	}
-	return &Oracle{
-		Ctx:      ctx,
-		OracleDB: sqlDB,
-	}, nil
+	common.StartDBHealthCheck(ctx, sqlDB, time.Duration(oraCfg.HealthCheckInterval)*time.Second, func(err error) {
+		metrics.DBHealthCheckFailedCounter.WithLabelValues("oracle").Inc()
+		zap.L().Warn("oracle connection pool health check failed", zap.Error(err))
+	})
+	engine := &Oracle{
+		Ctx:            ctx,
+		OracleDB:       sqlDB,
+		FetchArraySize: oraCfg.FetchArraySize,
+		PrefetchCount:  oraCfg.PrefetchCount,
+		Standby:        oraCfg.Standby,
+	}
+	if engine.Standby {
+		if err = engine.CheckOracleStandby(); err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// WithFetchSize 返回共享连接池的数据库引擎副本，表级别 fetch array size/prefetch rows 覆盖全局配置，小于等于 0 沿用全局配置
+func (o *Oracle) WithFetchSize(fetchArraySize, prefetchCount int) *Oracle {
+	engine := *o
+	if fetchArraySize > 0 {
+		engine.FetchArraySize = fetchArraySize
+	}
+	if prefetchCount > 0 {
+		engine.PrefetchCount = prefetchCount
+	}
+	return &engine
+}
+
+// WithTableLongFetch 表存在 LONG/LONG RAW 字段返回逐行抽取的数据库引擎副本以及对应字段，否则返回原引擎
+func (o *Oracle) WithTableLongFetch(schemaName, tableName string) (*Oracle, []string, error) {
+	longColumns, err := o.GetOracleSchemaTableDatatypeColumn(schemaName, tableName, common.OracleLongDatabaseTypes)
+	if err != nil {
+		return o, longColumns, err
+	}
+	if len(longColumns) == 0 {
+		return o, longColumns, nil
+	}
+	engine := *o
+	engine.LongFetch = true
+	return &engine, longColumns, nil
+}
+
+// fetchOptions 数据抽取查询 godror 语句选项，大数组减少广域网环境网络往返次数
+func (o *Oracle) fetchOptions() []interface{} {
+	var opts []interface{}
+	// LONG/LONG RAW 字段不支持数组抓取，单行 fetch 且不预取
+	if o.LongFetch {
+		return append(opts, godror.FetchArraySize(1), godror.PrefetchCount(1))
+	}
+	if o.FetchArraySize > 0 {
+		opts = append(opts, godror.FetchArraySize(o.FetchArraySize))
+	}
+	if o.PrefetchCount > 0 {
+		opts = append(opts, godror.PrefetchCount(o.PrefetchCount))
+	}
+	return opts
 }
 
 // Only Used for ALL Mode
@@ -138,6 +233,7 @@ func NewOracleLogminerEngine(ctx context.Context, oraCfg config.OracleConfig) (*
 	// 关闭外部认证
 	oraDSN.ExternalAuth = false
 	oraDSN.OnInitStmts = oraCfg.SessionParams
+	setOracleConnSecurity(&oraDSN, oraCfg)
 
 	// libDir won't have any effect on Linux for linking reasons to do with Oracle's libnnz library that are proving to be intractable.
 	// You must set LD_LIBRARY_PATH or run ldconfig before your process starts.
@@ -158,9 +254,7 @@ func NewOracleLogminerEngine(ctx context.Context, oraCfg config.OracleConfig) (*
 	// godror.SetLogger(zapr.NewLogger(zap.L()))
 
 	sqlDB := sql.OpenDB(godror.NewConnector(oraDSN))
-	sqlDB.SetMaxIdleConns(0)
-	sqlDB.SetMaxOpenConns(0)
-	sqlDB.SetConnMaxLifetime(0)
+	setOracleConnPool(sqlDB, oraCfg)
 
 	err = sqlDB.Ping()
 	if err != nil {
@@ -172,6 +266,86 @@ func NewOracleLogminerEngine(ctx context.Context, oraCfg config.OracleConfig) (*
 	}, nil
 }
 
+// 连接安全配置：TCPS 加密传输、wallet 证书以及外部认证
+//   - connect-string 非空直接使用（tnsnames 别名或完整连接描述符），忽略 host/port/service-name/protocol
+//   - protocol = tcps 或配置 wallet-location 时生成 TCPS 连接描述符，wallet 目录需包含 cwallet.sso
+//   - instance-name 非空时生成连接描述符 CONNECT_DATA 指定 INSTANCE_NAME，连接 RAC 指定实例
+//   - failover-hosts 非空时生成 FAILOVER=ON 地址列表，主地址不可用时连接池新建连接按顺序切换备用地址
+//   - config-dir 对应 TNS_ADMIN，读取其中 sqlnet.ora/tnsnames.ora
+//   - external-auth 基于 wallet 安全外部密码存储（SEPS）认证，忽略 username/password
+func setOracleConnSecurity(oraDSN *dsn.ConnectionParams, oraCfg config.OracleConfig) {
+	if !strings.EqualFold(oraCfg.ConfigDir, "") {
+		oraDSN.ConfigDir = oraCfg.ConfigDir
+	}
+
+	switch {
+	case !strings.EqualFold(oraCfg.ConnectString, ""):
+		oraDSN.ConnectString = oraCfg.ConnectString
+	case strings.EqualFold(oraCfg.Protocol, common.OracleProtocolTCPS) || !strings.EqualFold(oraCfg.WalletLocation, ""):
+		oraDSN.ConnectString = genOracleTCPSConnectDescriptor(oraCfg)
+	case !strings.EqualFold(oraCfg.InstanceName, "") || len(oraCfg.FailoverHosts) > 0:
+		oraDSN.ConnectString = fmt.Sprintf("(DESCRIPTION=%s(CONNECT_DATA=%s))",
+			genOracleAddress(oraCfg, common.OracleProtocolTCP), genOracleConnectData(oraCfg))
+	}
+
+	if oraCfg.ExternalAuth {
+		oraDSN.Username, oraDSN.Password = "", godror.NewPassword("")
+		oraDSN.ExternalAuth = true
+	}
+}
+
+func genOracleTCPSConnectDescriptor(oraCfg config.OracleConfig) string {
+	var security []string
+	if oraCfg.SSLServerDNMatch {
+		security = append(security, "(SSL_SERVER_DN_MATCH=YES)")
+	} else {
+		security = append(security, "(SSL_SERVER_DN_MATCH=NO)")
+	}
+	if !strings.EqualFold(oraCfg.SSLServerCertDN, "") {
+		security = append(security, fmt.Sprintf(`(SSL_SERVER_CERT_DN="%s")`, oraCfg.SSLServerCertDN))
+	}
+	if !strings.EqualFold(oraCfg.WalletLocation, "") {
+		security = append(security, fmt.Sprintf(`(MY_WALLET_DIRECTORY="%s")`, oraCfg.WalletLocation))
+	}
+	return fmt.Sprintf("(DESCRIPTION=%s(CONNECT_DATA=%s)(SECURITY=%s))",
+		genOracleAddress(oraCfg, common.OracleProtocolTCPS), genOracleConnectData(oraCfg), strings.Join(security, ""))
+}
+
+// 连接描述符地址，配置 failover-hosts 生成 FAILOVER=ON 地址列表，按顺序尝试连接
+func genOracleAddress(oraCfg config.OracleConfig, protocol string) string {
+	address := fmt.Sprintf("(ADDRESS=(PROTOCOL=%s)(HOST=%s)(PORT=%d))", protocol, oraCfg.Host, oraCfg.Port)
+	if len(oraCfg.FailoverHosts) == 0 {
+		return address
+	}
+	addressList := []string{address}
+	for _, h := range oraCfg.FailoverHosts {
+		// 地址格式 AdjustConfig 已校验
+		host, port, _ := common.SplitHostPort(h)
+		addressList = append(addressList, fmt.Sprintf("(ADDRESS=(PROTOCOL=%s)(HOST=%s)(PORT=%d))", protocol, host, port))
+	}
+	return fmt.Sprintf("(FAILOVER=ON)(ADDRESS_LIST=%s)", strings.Join(addressList, ""))
+}
+
+// 连接描述符 CONNECT_DATA，RAC 实例亲和指定 INSTANCE_NAME
+func genOracleConnectData(oraCfg config.OracleConfig) string {
+	if strings.EqualFold(oraCfg.InstanceName, "") {
+		return fmt.Sprintf("(SERVICE_NAME=%s)", oraCfg.ServiceName)
+	}
+	return fmt.Sprintf("(SERVICE_NAME=%s)(INSTANCE_NAME=%s)", oraCfg.ServiceName, oraCfg.InstanceName)
+}
+
+// 连接池配置，max-open-conns/conn-max-lifetime 参数值 0 表示不限制
+// max-idle-conns 参数值 0 取默认值，SetMaxIdleConns(0) 不保留空闲连接，每次查询均需重新建立会话
+func setOracleConnPool(sqlDB *sql.DB, oraCfg config.OracleConfig) {
+	maxIdleConns := common.OracleMaxIdleConn
+	if oraCfg.MaxIdleConns > 0 {
+		maxIdleConns = oraCfg.MaxIdleConns
+	}
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+	sqlDB.SetMaxOpenConns(oraCfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(oraCfg.ConnMaxLifetime) * time.Second)
+}
+
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
 	var (
 		cols []string
@@ -226,6 +400,71 @@ func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[st
 	return cols, res, nil
 }
 
+// 流式查询，按 batchSize 批次输出至 dataChan，避免大结果集一次性加载内存
+// 字段值处理规则与 Query 保持一致，由调用方负责关闭 dataChan
+func QueryStream(ctx context.Context, db *sql.DB, querySQL string, batchSize int, dataChan chan []map[string]string) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("general sql [%v] query stream batch size [%d] must be greater than 0", querySQL, batchSize)
+	}
+
+	rows, err := db.QueryContext(ctx, querySQL)
+	if err != nil {
+		return fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+	}
+
+	values := make([][]byte, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range values {
+		scans[i] = &values[i]
+	}
+
+	batchRows := make([]map[string]string, 0, batchSize)
+	for rows.Next() {
+		err = rows.Scan(scans...)
+		if err != nil {
+			return fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+		}
+
+		row := make(map[string]string, len(cols))
+		for k, v := range values {
+			if v == nil {
+				row[cols[k]] = "NULLABLE"
+			} else {
+				row[cols[k]] = string(v)
+			}
+		}
+		batchRows = append(batchRows, row)
+
+		if len(batchRows) == batchSize {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case dataChan <- batchRows:
+			}
+			batchRows = make([]map[string]string, 0, batchSize)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+	}
+
+	if len(batchRows) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case dataChan <- batchRows:
+		}
+	}
+	return nil
+}
+
 func (o *Oracle) GetOracleSchemas() ([]string, error) {
 	var (
 		schemas []string
@@ -258,3 +497,34 @@ func (o *Oracle) GetOracleSchemaTable(schemaName string) ([]string, error) {
 
 	return tables, nil
 }
+
+// queryStreamRows 流式查询逐行回调，schema 级别元数据查询按行聚合结果，避免 Query 一次性加载全部结果集
+// 回调出错取消查询，返回回调错误
+func (o *Oracle) queryStreamRows(querySQL string, fn func(row map[string]string) error) error {
+	ctx, cancel := context.WithCancel(o.Ctx)
+	defer cancel()
+
+	dataChan := make(chan []map[string]string, common.ChannelBufferSize)
+	g := &errgroup.Group{}
+	g.Go(func() error {
+		defer close(dataChan)
+		return QueryStream(ctx, o.OracleDB, querySQL, common.OracleQueryStreamBatchSize, dataChan)
+	})
+
+	var fnErr error
+	for rows := range dataChan {
+		if fnErr != nil {
+			continue
+		}
+		for _, r := range rows {
+			if fnErr = fn(r); fnErr != nil {
+				cancel()
+				break
+			}
+		}
+	}
+	if err := g.Wait(); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
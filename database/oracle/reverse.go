@@ -22,64 +22,60 @@ import (
 )
 
 func (o *Oracle) GetOracleSchemaPartitionTable(schemaName string) ([]string, error) {
-	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT table_name AS TABLE_NAME
+	var tables []string
+	err := o.queryStreamRows(fmt.Sprintf(`SELECT table_name AS TABLE_NAME
 	FROM DBA_TABLES
  WHERE partitioned = 'YES'
-   AND UPPER(owner) = UPPER('%s')`, schemaName))
+   AND UPPER(owner) = UPPER('%s')`, schemaName), func(r map[string]string) error {
+		tables = append(tables, r["TABLE_NAME"])
+		return nil
+	})
 	if err != nil {
 		return []string{}, err
 	}
-
-	var tables []string
-	for _, r := range res {
-		tables = append(tables, r["TABLE_NAME"])
-	}
 	return tables, nil
 }
 
 func (o *Oracle) GetOracleSchemaTemporaryTable(schemaName string) ([]string, error) {
-	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`select table_name AS TABLE_NAME
+	var tables []string
+	err := o.queryStreamRows(fmt.Sprintf(`select table_name AS TABLE_NAME
   from dba_tables
  where TEMPORARY = 'Y'
-   and upper(owner) = upper('%s')`, schemaName))
+   and upper(owner) = upper('%s')`, schemaName), func(r map[string]string) error {
+		tables = append(tables, r["TABLE_NAME"])
+		return nil
+	})
 	if err != nil {
 		return []string{}, err
 	}
-
-	var tables []string
-	for _, r := range res {
-		tables = append(tables, r["TABLE_NAME"])
-	}
 	return tables, nil
 }
 
 func (o *Oracle) GetOracleSchemaClusteredTable(schemaName string) ([]string, error) {
 	// 过滤蔟表
-	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`select table_name AS TABLE_NAME
+	var tables []string
+	err := o.queryStreamRows(fmt.Sprintf(`select table_name AS TABLE_NAME
   from dba_tables
  where CLUSTER_NAME IS NOT NULL
-   and upper(owner) = upper('%s')`, schemaName))
+   and upper(owner) = upper('%s')`, schemaName), func(r map[string]string) error {
+		tables = append(tables, r["TABLE_NAME"])
+		return nil
+	})
 	if err != nil {
 		return []string{}, err
 	}
-
-	var tables []string
-	for _, r := range res {
-		tables = append(tables, r["TABLE_NAME"])
-	}
 	return tables, nil
 }
 
 func (o *Oracle) GetOracleSchemaMaterializedView(schemaName string) ([]string, error) {
 	// 过滤物化视图
-	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT OWNER,MVIEW_NAME FROM DBA_MVIEWS WHERE UPPER(OWNER) = UPPER('%s')`, schemaName))
-	if err != nil {
-		return []string{}, err
-	}
-
 	var tables []string
-	for _, r := range res {
+	err := o.queryStreamRows(fmt.Sprintf(`SELECT OWNER,MVIEW_NAME FROM DBA_MVIEWS WHERE UPPER(OWNER) = UPPER('%s')`, schemaName), func(r map[string]string) error {
 		tables = append(tables, r["MVIEW_NAME"])
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
 	}
 	return tables, nil
 }
@@ -192,6 +188,136 @@ func (o *Oracle) GetOracleSchemaMaterializedView(schemaName string) ([]string, e
 //	return res, nil
 //}
 
+func (o *Oracle) GetOracleSchemaTablePartition(schemaName string, tableName string) ([]map[string]string, error) {
+	// HIGH_VALUE 为 LONG 类型，按分区位置顺序返回
+	querySQL := fmt.Sprintf(`select pt.partitioning_type,
+       pt.subpartitioning_type,
+       (select LISTAGG(pk.column_name, ',') WITHIN GROUP(ORDER BY pk.column_position)
+          from dba_part_key_columns pk
+         where pk.owner = pt.owner
+           and pk.name = pt.table_name
+           and pk.object_type = 'TABLE') AS partition_express,
+       tp.partition_name,
+       tp.partition_position,
+       tp.high_value
+  from dba_part_tables pt, dba_tab_partitions tp
+ where pt.owner = tp.table_owner
+   and pt.table_name = tp.table_name
+   and upper(pt.table_name) = upper('%s')
+   and upper(pt.owner) = upper('%s')
+ order by tp.partition_position`,
+		strings.ToUpper(tableName),
+		strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (o *Oracle) GetOracleSchemaSequence(schemaName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`select sequence_name,
+       min_value,
+       increment_by,
+       last_number
+  from dba_sequences
+ where upper(sequence_owner) = upper('%s')
+ order by sequence_name`, strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// 虚拟列，排除函数索引等系统生成隐藏列，虚拟列表达式见字段 DATA_DEFAULT
+func (o *Oracle) GetOracleSchemaTableVirtualColumn(schemaName string, tableName string) ([]string, error) {
+	var columns []string
+	querySQL := fmt.Sprintf(`SELECT COLUMN_NAME
+  FROM DBA_TAB_COLS
+ WHERE UPPER(OWNER) = UPPER('%s')
+   AND UPPER(TABLE_NAME) = UPPER('%s')
+   AND VIRTUAL_COLUMN = 'YES'
+   AND HIDDEN_COLUMN = 'NO'
+ ORDER BY COLUMN_ID`, schemaName, tableName)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return columns, err
+	}
+	for _, r := range res {
+		columns = append(columns, r["COLUMN_NAME"])
+	}
+	return columns, nil
+}
+
+// GetOracleSchemaTableDatatypeColumn 获取表指定字段类型的字段，例如 BFILE、ROWID
+func (o *Oracle) GetOracleSchemaTableDatatypeColumn(schemaName string, tableName string, dataTypes []string) ([]string, error) {
+	var columns []string
+	if len(dataTypes) == 0 {
+		return columns, nil
+	}
+	var types []string
+	for _, t := range dataTypes {
+		types = append(types, fmt.Sprintf("'%s'", strings.ToUpper(t)))
+	}
+	querySQL := fmt.Sprintf(`SELECT COLUMN_NAME
+  FROM DBA_TAB_COLUMNS
+ WHERE UPPER(OWNER) = UPPER('%s')
+   AND UPPER(TABLE_NAME) = UPPER('%s')
+   AND DATA_TYPE IN (%s)
+ ORDER BY COLUMN_ID`, schemaName, tableName, strings.Join(types, ","))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return columns, err
+	}
+	for _, r := range res {
+		columns = append(columns, r["COLUMN_NAME"])
+	}
+	return columns, nil
+}
+
+// identity 字段以及对应系统序列，only oracle 12c 及以上
+func (o *Oracle) GetOracleSchemaTableIdentityColumn(schemaName string, tableName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT I.COLUMN_NAME,
+       I.GENERATION_TYPE,
+       I.SEQUENCE_NAME,
+       S.INCREMENT_BY,
+       S.LAST_NUMBER
+  FROM DBA_TAB_IDENTITY_COLS I, DBA_SEQUENCES S
+ WHERE I.OWNER = S.SEQUENCE_OWNER
+   AND I.SEQUENCE_NAME = S.SEQUENCE_NAME
+   AND UPPER(I.OWNER) = UPPER('%s')
+   AND UPPER(I.TABLE_NAME) = UPPER('%s')`, schemaName, tableName)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (o *Oracle) GetOracleSchemaTableTriggerSequence(schemaName string, tableName string) ([]map[string]string, error) {
+	// 表 INSERT 触发器引用的同 schema 序列
+	querySQL := fmt.Sprintf(`select t.trigger_name,
+       d.referenced_name AS sequence_name
+  from dba_triggers t, dba_dependencies d
+ where t.owner = d.owner
+   and t.trigger_name = d.name
+   and d.type = 'TRIGGER'
+   and d.referenced_type = 'SEQUENCE'
+   and d.referenced_owner = t.table_owner
+   and t.status = 'ENABLED'
+   and t.triggering_event like '%%INSERT%%'
+   and upper(t.table_name) = upper('%s')
+   and upper(t.table_owner) = upper('%s')`,
+		strings.ToUpper(tableName),
+		strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
 func (o *Oracle) GetOracleSchemaTablePrimaryKey(schemaName string, tableName string) ([]map[string]string, error) {
 	// for the primary key of an Engine table, you can use the following command to set whether the primary key takes effect.
 	// disable the primary key: alter table tableName disable primary key;
@@ -263,7 +389,7 @@ temp2 as
    where t1.constraint_name = a1.constraint_name
      AND upper(t1.owner) = upper('%s')
      AND t1.STATUS = 'ENABLED'
-     AND t1.Constraint_Type = 'P'
+     AND t1.Constraint_Type IN ('P', 'U')
    group by t1.owner,t1.TABLE_NAME, t1.r_owner, t1.constraint_name)
 select x.constraint_name,
        x.COLUMN_LIST,
@@ -284,6 +410,25 @@ select x.constraint_name,
 	return res, nil
 }
 
+// GetOracleSchemaForeignKeyDependency 获取 schema 内表外键依赖关系，TABLE_NAME 依赖 RTABLE_NAME
+func (o *Oracle) GetOracleSchemaForeignKeyDependency(schemaName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`select distinct t1.TABLE_NAME, t2.TABLE_NAME AS RTABLE_NAME
+  from dba_constraints t1, dba_constraints t2
+ where t1.r_owner = t2.owner
+   and t1.r_constraint_name = t2.constraint_name
+   and upper(t1.owner) = upper('%s')
+   and upper(t1.r_owner) = upper('%s')
+   and t1.STATUS = 'ENABLED'
+   and t1.Constraint_Type = 'R'`,
+		strings.ToUpper(schemaName),
+		strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
 func (o *Oracle) GetOracleSchemaTableCheckKey(schemaName string, tableName string) ([]map[string]string, error) {
 	querySQL := fmt.Sprintf(`select cu.constraint_name,au.SEARCH_CONDITION
           from dba_cons_columns cu, dba_constraints au
@@ -680,6 +825,96 @@ func (o *Oracle) GetOracleSchemaTableColumnComment(schemaName string, tableName
 	return queryRes, nil
 }
 
+// GetOracleSchemaView 获取 schema 视图定义，TEXT 为 LONG 类型
+func (o *Oracle) GetOracleSchemaView(schemaName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT VIEW_NAME, TEXT FROM DBA_VIEWS WHERE UPPER(OWNER) = UPPER('%s') ORDER BY VIEW_NAME`, strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// GetOracleSchemaLongIdentifier 获取 schema 内长度超过 maxLength 的表名、字段名、索引名以及约束名
+func (o *Oracle) GetOracleSchemaLongIdentifier(schemaName string, maxLength int) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT 'TABLE' AS OBJECT_TYPE, TABLE_NAME, TABLE_NAME AS OBJECT_NAME FROM DBA_TABLES WHERE UPPER(OWNER) = UPPER('%[1]s') AND LENGTH(TABLE_NAME) > %[2]d
+UNION ALL
+SELECT 'COLUMN' AS OBJECT_TYPE, TABLE_NAME, COLUMN_NAME AS OBJECT_NAME FROM DBA_TAB_COLUMNS WHERE UPPER(OWNER) = UPPER('%[1]s') AND LENGTH(COLUMN_NAME) > %[2]d
+UNION ALL
+SELECT 'INDEX' AS OBJECT_TYPE, TABLE_NAME, INDEX_NAME AS OBJECT_NAME FROM DBA_INDEXES WHERE UPPER(TABLE_OWNER) = UPPER('%[1]s') AND LENGTH(INDEX_NAME) > %[2]d
+UNION ALL
+SELECT 'CONSTRAINT' AS OBJECT_TYPE, TABLE_NAME, CONSTRAINT_NAME AS OBJECT_NAME FROM DBA_CONSTRAINTS WHERE UPPER(OWNER) = UPPER('%[1]s') AND CONSTRAINT_TYPE IN ('P','U','R','C') AND LENGTH(CONSTRAINT_NAME) > %[2]d`, strings.ToUpper(schemaName), maxLength)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// GetOracleSchemaMaterializedViewQuery 获取 schema 物化视图定义查询，QUERY 为 LONG 类型
+func (o *Oracle) GetOracleSchemaMaterializedViewQuery(schemaName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT MVIEW_NAME, QUERY FROM DBA_MVIEWS WHERE UPPER(OWNER) = UPPER('%s') ORDER BY MVIEW_NAME`, strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// GetOracleSchemaViewDependency 获取 schema 内视图依赖视图关系，TABLE_NAME 依赖 RTABLE_NAME
+func (o *Oracle) GetOracleSchemaViewDependency(schemaName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT DISTINCT NAME AS TABLE_NAME, REFERENCED_NAME AS RTABLE_NAME
+  FROM DBA_DEPENDENCIES
+ WHERE UPPER(OWNER) = UPPER('%s')
+   AND UPPER(REFERENCED_OWNER) = UPPER('%s')
+   AND TYPE = 'VIEW'
+   AND REFERENCED_TYPE = 'VIEW'`, strings.ToUpper(schemaName), strings.ToUpper(schemaName))
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// GetOracleTableNumberColumnSample 采样统计未指定精度 number 字段数据分布，samplePercent >= 100 全表扫描
+// 返回字段 -> SAMPLE_ROWS 非空采样行数、DECIMAL_ROWS 含小数行数、MAX_VALUE 最大值、MIN_VALUE 最小值
+func (o *Oracle) GetOracleTableNumberColumnSample(schemaName, tableName string, columnNames []string, samplePercent int) (map[string]map[string]string, error) {
+	sampleRes := make(map[string]map[string]string)
+	if len(columnNames) == 0 {
+		return sampleRes, nil
+	}
+
+	var (
+		colQuery    []string
+		sampleQuery string
+	)
+	for i, c := range columnNames {
+		colQuery = append(colQuery, fmt.Sprintf(`COUNT("%s") AS R%d, COUNT(CASE WHEN "%s" <> TRUNC("%s") THEN 1 END) AS D%d, NVL(TO_CHAR(MAX("%s")),'0') AS X%d, NVL(TO_CHAR(MIN("%s")),'0') AS N%d`,
+			c, i, c, c, i, c, i, c, i))
+	}
+	if samplePercent > 0 && samplePercent < 100 {
+		sampleQuery = fmt.Sprintf(" SAMPLE(%d)", samplePercent)
+	}
+
+	querySQL := fmt.Sprintf(`SELECT %s FROM "%s"."%s"%s`, strings.Join(colQuery, ", "), schemaName, tableName, sampleQuery)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return sampleRes, err
+	}
+	if len(res) != 1 {
+		return sampleRes, fmt.Errorf("oracle schema [%s] table [%s] number column sample query result isn't one row, results: [%v]", schemaName, tableName, res)
+	}
+	for i, c := range columnNames {
+		sampleRes[c] = map[string]string{
+			"SAMPLE_ROWS":  res[0][fmt.Sprintf("R%d", i)],
+			"DECIMAL_ROWS": res[0][fmt.Sprintf("D%d", i)],
+			"MAX_VALUE":    res[0][fmt.Sprintf("X%d", i)],
+			"MIN_VALUE":    res[0][fmt.Sprintf("N%d", i)],
+		}
+	}
+	return sampleRes, nil
+}
+
 func (o *Oracle) GetOracleExtendedMode() (bool, error) {
 	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT VALUE FROM V$PARAMETER WHERE UPPER(NAME) = UPPER('MAX_STRING_SIZE')`)
 	if err != nil {
@@ -0,0 +1,52 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"github.com/wentaojin/transferdb/database"
+	"strings"
+)
+
+// Oracle 实现源端表结构读取接口，全量/增量数据读取沿用 oracle 专用 SCN 一致性读以及 logminer 流程
+var _ database.SchemaReader = (*Oracle)(nil)
+
+func (o *Oracle) GetSchemaTables(schemaName string) ([]string, error) {
+	return o.GetOracleSchemaTable(schemaName)
+}
+
+func (o *Oracle) GetSchemaTableColumns(schemaName, tableName string) ([]map[string]string, error) {
+	return o.GetOracleSchemaTableColumn(schemaName, tableName, false)
+}
+
+func (o *Oracle) GetSchemaTablePrimaryKey(schemaName, tableName string) ([]string, error) {
+	var columns []string
+	pk, err := o.GetOracleSchemaTablePrimaryKey(schemaName, tableName)
+	if err != nil {
+		return columns, err
+	}
+	if len(pk) == 0 {
+		return columns, nil
+	}
+	return strings.Split(pk[0]["COLUMN_LIST"], ","), nil
+}
+
+func (o *Oracle) GetSchemaTableRows(schemaName, tableName string) (int64, error) {
+	numRows, err := o.GetOracleTableRowsByStatistics(schemaName, tableName)
+	if err != nil {
+		return 0, err
+	}
+	return int64(numRows), nil
+}
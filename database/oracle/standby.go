@@ -0,0 +1,171 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+// oracle 扩展 ROWID base64 编码字符表
+const oracleRowIDAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// 数据块内最大行号，ROWID 区间结束行号
+const oracleRowIDMaxRowNum = 32767
+
+// CheckOracleStandby 校验 standby 连接为 ADG 只读备库（PHYSICAL STANDBY 且 READ ONLY [WITH APPLY]），并输出当前应用延迟
+func (o *Oracle) CheckOracleStandby() error {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT DATABASE_ROLE, OPEN_MODE FROM V$DATABASE`)
+	if err != nil {
+		return err
+	}
+	if len(res) != 1 {
+		return fmt.Errorf("get oracle database role failed, results: [%v]", res)
+	}
+	if !strings.EqualFold(res[0]["DATABASE_ROLE"], common.OracleDatabaseRolePhysicalStandby) || !strings.HasPrefix(common.StringUPPER(res[0]["OPEN_MODE"]), common.OracleOpenModeReadOnly) {
+		return fmt.Errorf("oracle config standby is enabled, but database role [%s] open mode [%s] isn't active data guard read only standby, please check again",
+			res[0]["DATABASE_ROLE"], res[0]["OPEN_MODE"])
+	}
+
+	applyLag, err := o.GetOracleStandbyApplyLag()
+	if err != nil {
+		return err
+	}
+	zap.L().Warn("oracle active data guard standby extraction",
+		zap.String("database role", res[0]["DATABASE_ROLE"]),
+		zap.String("open mode", res[0]["OPEN_MODE"]),
+		zap.String("apply lag", applyLag))
+	return nil
+}
+
+// GetOracleStandbyApplyLag 备库应用延迟，快照 SCN 为备库已应用 SCN，延迟期间主库变更不可见
+func (o *Oracle) GetOracleStandbyApplyLag() (string, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT VALUE FROM V$DATAGUARD_STATS WHERE NAME = 'apply lag'`)
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", nil
+	}
+	return res[0]["VALUE"], nil
+}
+
+// GetOracleStandbyTableChunksByRowID 备库只读不支持 DBMS_PARALLEL_EXECUTE，基于表段区间按统计信息每块行数切分 ROWID chunk，返回结果同 GetOracleTableChunksByRowID
+func (o *Oracle) GetOracleStandbyTableChunksByRowID(schemaName, tableName string, chunkSize int) ([]map[string]string, error) {
+	var chunks []map[string]string
+
+	_, stats, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT NVL(NUM_ROWS,0) AS NUM_ROWS, NVL(BLOCKS,0) AS BLOCKS
+  FROM DBA_TABLES
+ WHERE UPPER(OWNER) = UPPER('%s')
+   AND UPPER(TABLE_NAME) = UPPER('%s')`, schemaName, tableName))
+	if err != nil {
+		return chunks, err
+	}
+	if len(stats) != 1 {
+		return chunks, fmt.Errorf("get oracle schema table [%s.%s] statistics blocks failed, results: [%v]", schemaName, tableName, stats)
+	}
+	numRows, err := strconv.ParseUint(stats[0]["NUM_ROWS"], 10, 64)
+	if err != nil {
+		return chunks, fmt.Errorf("get oracle schema table [%s.%s] rows [%s] strconv.ParseUint failed: %v", schemaName, tableName, stats[0]["NUM_ROWS"], err)
+	}
+	numBlocks, err := strconv.ParseUint(stats[0]["BLOCKS"], 10, 64)
+	if err != nil {
+		return chunks, fmt.Errorf("get oracle schema table [%s.%s] blocks [%s] strconv.ParseUint failed: %v", schemaName, tableName, stats[0]["BLOCKS"], err)
+	}
+	// 单 chunk 数据块数，统计信息缺失按区间切分
+	var chunkBlocks uint64
+	if numRows > 0 && numBlocks > 0 && chunkSize > 0 {
+		chunkBlocks = uint64(chunkSize) * numBlocks / numRows
+		if chunkBlocks == 0 {
+			chunkBlocks = 1
+		}
+	}
+
+	_, extents, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT O.DATA_OBJECT_ID, E.RELATIVE_FNO, E.BLOCK_ID, E.BLOCKS
+  FROM DBA_EXTENTS E, DBA_OBJECTS O
+ WHERE E.OWNER = O.OWNER
+   AND E.SEGMENT_NAME = O.OBJECT_NAME
+   AND NVL(E.PARTITION_NAME, '-') = NVL(O.SUBOBJECT_NAME, '-')
+   AND E.SEGMENT_TYPE LIKE 'TABLE%%'
+   AND O.OBJECT_TYPE LIKE 'TABLE%%'
+   AND UPPER(E.OWNER) = UPPER('%s')
+   AND UPPER(E.SEGMENT_NAME) = UPPER('%s')
+ ORDER BY O.DATA_OBJECT_ID, E.RELATIVE_FNO, E.BLOCK_ID`, schemaName, tableName))
+	if err != nil {
+		return chunks, err
+	}
+
+	for _, e := range extents {
+		var vals []uint64
+		for _, k := range []string{"DATA_OBJECT_ID", "RELATIVE_FNO", "BLOCK_ID", "BLOCKS"} {
+			v, err := strconv.ParseUint(e[k], 10, 64)
+			if err != nil {
+				return chunks, fmt.Errorf("get oracle schema table [%s.%s] extent [%s] value [%s] strconv.ParseUint failed: %v", schemaName, tableName, k, e[k], err)
+			}
+			vals = append(vals, v)
+		}
+		objectID, fileNo, blockID, blocks := vals[0], vals[1], vals[2], vals[3]
+		step := blocks
+		if chunkBlocks > 0 && chunkBlocks < blocks {
+			step = chunkBlocks
+		}
+		for start := blockID; start < blockID+blocks; start += step {
+			end := start + step - 1
+			if end > blockID+blocks-1 {
+				end = blockID + blocks - 1
+			}
+			chunks = append(chunks, map[string]string{
+				"CMD": common.StringsBuilder(`ROWID BETWEEN '`, genOracleRowID(objectID, fileNo, start, 0),
+					`' AND '`, genOracleRowID(objectID, fileNo, end, oracleRowIDMaxRowNum), `'`),
+			})
+		}
+	}
+	return chunks, nil
+}
+
+// GetOracleStandbyTableChunksByNUMBER 备库只读不支持 DBMS_PARALLEL_EXECUTE，基于 NTILE 按数字字段等分切分 chunk，返回结果同 GetOracleTableChunksByNUMBER
+func (o *Oracle) GetOracleStandbyTableChunksByNUMBER(schemaName, tableName, numberColName string, tableRows, chunkSize int) ([]map[string]string, error) {
+	buckets := 1
+	if chunkSize > 0 && tableRows > chunkSize {
+		buckets = (tableRows + chunkSize - 1) / chunkSize
+	}
+	querySQL := fmt.Sprintf(`SELECT '%s BETWEEN ' || MIN(%s) || ' AND ' || MAX(%s) CMD
+  FROM (SELECT %s, NTILE(%d) OVER (ORDER BY %s) BUCKET FROM %s.%s WHERE %s IS NOT NULL)
+ GROUP BY BUCKET
+ ORDER BY BUCKET`, numberColName, numberColName, numberColName, numberColName, buckets, numberColName, schemaName, tableName, numberColName)
+
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// genOracleRowID 生成扩展 ROWID：数据对象号 6 位、相对文件号 3 位、数据块号 6 位、行号 3 位 base64 编码
+func genOracleRowID(objectID, fileNo, blockNo, rowNo uint64) string {
+	encode := func(val uint64, width int) string {
+		buf := make([]byte, width)
+		for i := width - 1; i >= 0; i-- {
+			buf[i] = oracleRowIDAlphabet[val&63]
+			val >>= 6
+		}
+		return string(buf)
+	}
+	return common.StringsBuilder(encode(objectID, 6), encode(fileNo, 3), encode(blockNo, 6), encode(rowNo, 3))
+}
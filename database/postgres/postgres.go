@@ -19,29 +19,72 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"net/url"
 	"strings"
+	"time"
 )
 
-type Postgres struct {
-	Ctx  context.Context
-	PGDB *sql.DB
+type PostgreSQL struct {
+	Ctx          context.Context
+	PostgreSQLDB *sql.DB
+	// 目标端数据写入限速，nil 不限速
+	Throttle *common.Throttle
 }
 
-func NewPostgresEngine(ctx context.Context, dbUser, dbPassword, ipAddr, dbPort, dbName string) (*Postgres, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		ipAddr, dbPort, dbUser, dbPassword, dbName)
-	db, err := sql.Open("ora", dsn) // this does not really open a new connection
+func NewPostgreSQLDBEngine(ctx context.Context, pgCfg config.PostgreSQLConfig) (*PostgreSQL, error) {
+	pgDB, err := sql.Open("pgx", genPostgreSQLDSN(pgCfg))
 	if err != nil {
-		return nil, fmt.Errorf("error on initializing database connection: %s", err.Error())
+		return nil, fmt.Errorf("error on open postgresql database connection: %v", err)
 	}
-	db.SetMaxIdleConns(100)
 
-	err = db.Ping() // This DOES open a connection if necessary. This makes sure the database is accessible
-	if err != nil {
-		return nil, fmt.Errorf("error on opening database connection: %s", err.Error())
+	maxIdleConns := common.PostgreSQLMaxIdleConn
+	if pgCfg.MaxIdleConns > 0 {
+		maxIdleConns = pgCfg.MaxIdleConns
+	}
+	maxOpenConns := common.PostgreSQLMaxConn
+	if pgCfg.MaxOpenConns > 0 {
+		maxOpenConns = pgCfg.MaxOpenConns
+	}
+	connMaxLifetime := common.PostgreSQLConnMaxLifeTime
+	if pgCfg.ConnMaxLifetime > 0 {
+		connMaxLifetime = time.Duration(pgCfg.ConnMaxLifetime) * time.Second
+	}
+	pgDB.SetMaxIdleConns(maxIdleConns)
+	pgDB.SetMaxOpenConns(maxOpenConns)
+	pgDB.SetConnMaxLifetime(connMaxLifetime)
+	pgDB.SetConnMaxIdleTime(common.PostgreSQLConnMaxIdleTime)
+
+	if err = pgDB.Ping(); err != nil {
+		return nil, fmt.Errorf("error on ping postgresql database connection: %v", err)
 	}
 
-	return &Postgres{Ctx: ctx, PGDB: db}, nil
+	return &PostgreSQL{
+		Ctx:          ctx,
+		PostgreSQLDB: pgDB,
+	}, nil
+}
+
+// genPostgreSQLDSN 生成 URL 格式连接串，connect-params 以 URL 参数形式追加，如 sslmode=disable&application_name=transferdb
+func genPostgreSQLDSN(pgCfg config.PostgreSQLConfig) string {
+	var params []string
+	if pgCfg.ConnectTimeout > 0 {
+		params = append(params, fmt.Sprintf("connect_timeout=%d", pgCfg.ConnectTimeout))
+	}
+	if !strings.EqualFold(pgCfg.ConnectParams, "") {
+		params = append(params, pgCfg.ConnectParams)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(pgCfg.Username, pgCfg.Password),
+		Host:     fmt.Sprintf("%s:%d", pgCfg.Host, pgCfg.Port),
+		Path:     "/" + pgCfg.DBName,
+		RawQuery: strings.Join(params, "&"),
+	}
+	return dsn.String()
 }
 
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
@@ -61,7 +104,7 @@ func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[st
 		return cols, res, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
 	}
 
-	values := make([][]byte, len(cols))
+	values := make([]sql.NullString, len(cols))
 	scans := make([]interface{}, len(cols))
 	for i := range values {
 		scans[i] = &values[i]
@@ -75,18 +118,11 @@ func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[st
 
 		row := make(map[string]string)
 		for k, v := range values {
-			// Oracle/Mysql 对于 'NULL' 统一字符 NULL 处理，查询出来转成 NULL,所以需要判断处理
-			// 查询字段值 NULL
-			// 如果字段值 = NULLABLE 则表示值是 NULL
-			// 如果字段值 = "" 则表示值是空字符串
-			// 如果字段值 = 'NULL' 则表示值是 NULL 字符串
-			// 如果字段值 = 'null' 则表示值是 null 字符串
-			if v == nil {
+			// 与 mysql Query 保持一致，NULL 值以 NULLABLE 表示
+			if !v.Valid {
 				row[cols[k]] = "NULLABLE"
 			} else {
-				// 处理空字符串以及其他值情况
-				// 数据统一 string 格式显示
-				row[cols[k]] = string(v)
+				row[cols[k]] = v.String
 			}
 		}
 		res = append(res, row)
@@ -98,16 +134,74 @@ func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[st
 	return cols, res, nil
 }
 
-func (p *Postgres) QuerySQL(querySQL string) (cols []string, res []map[string]string) {
-	cols, res, _ = Query(p.Ctx, p.PGDB, querySQL)
+func (p *PostgreSQL) WritePostgreSQLTable(sql string) error {
+	_, err := p.PostgreSQLDB.ExecContext(p.Ctx, sql)
+	if err != nil {
+		return fmt.Errorf("postgresql sql [%v] exec failed: %v", sql, err)
+	}
+	return nil
+}
+
+func (p *PostgreSQL) TruncatePostgreSQLTable(schemaName, tableName string) error {
+	return p.WritePostgreSQLTable(fmt.Sprintf("TRUNCATE TABLE %s.%s",
+		common.QuotePostgreSQLIdentifier(schemaName), common.QuotePostgreSQLIdentifier(tableName)))
+}
+
+// CopyPostgreSQLTable COPY FROM STDIN 批量导入，rows 为 COPY text 格式数据行（字段 \t 分隔、NULL 为 \N，不含换行符）
+func (p *PostgreSQL) CopyPostgreSQLTable(schemaName, tableName string, columnNames []string, rows []string) (int64, error) {
+	var quoteColumns []string
+	for _, c := range columnNames {
+		quoteColumns = append(quoteColumns, common.QuotePostgreSQLIdentifier(c))
+	}
+	copySQL := fmt.Sprintf("COPY %s.%s (%s) FROM STDIN",
+		common.QuotePostgreSQLIdentifier(schemaName), common.QuotePostgreSQLIdentifier(tableName), strings.Join(quoteColumns, ","))
+
+	conn, err := p.PostgreSQLDB.Conn(p.Ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgresql get connection failed: %v", err)
+	}
+	defer conn.Close()
+
+	var affectRows int64
+	err = conn.Raw(func(driverConn any) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn().PgConn()
+
+		pr, pw := io.Pipe()
+		go func() {
+			var werr error
+			for _, r := range rows {
+				if _, werr = io.WriteString(pw, r+"\n"); werr != nil {
+					break
+				}
+			}
+			_ = pw.CloseWithError(werr)
+		}()
+
+		tag, err := pgConn.CopyFrom(p.Ctx, pr, copySQL)
+		// 写入端提前结束，避免 goroutine 阻塞
+		_ = pr.Close()
+		if err != nil {
+			return err
+		}
+		affectRows = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return affectRows, fmt.Errorf("postgresql sql [%v] copy failed: %v", copySQL, err)
+	}
+	return affectRows, nil
+}
+
+func (p *PostgreSQL) QuerySQL(querySQL string) (cols []string, res []map[string]string) {
+	cols, res, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
 
-func (p *Postgres) GetSchemaMeta() (schemaMeta []string) {
+func (p *PostgreSQL) GetSchemaMeta() (schemaMeta []string) {
 	querySQL := fmt.Sprintf(`SELECT DISTINCT nspname 
 FROM pg_namespace 
 WHERE nspname not in ('pg_toast','pg_temp_1','pg_toast_temp_1','pg_catalog','information_schema')`)
-	cols, res, _ := Query(p.Ctx, p.PGDB, querySQL)
+	cols, res, _ := Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	for _, col := range cols {
 		for _, r := range res {
 			schemaMeta = append(schemaMeta, r[col])
@@ -116,7 +210,7 @@ WHERE nspname not in ('pg_toast','pg_temp_1','pg_toast_temp_1','pg_catalog','inf
 	return
 }
 
-func (p *Postgres) GetTableMeta(schemaName string) (tableMeta []map[string]string) {
+func (p *PostgreSQL) GetTableMeta(schemaName string) (tableMeta []map[string]string) {
 	querySQL := fmt.Sprintf(` SELECT c.relname AS TABLE_NAME, 
                    CASE n.nspname ~ '^pg_' OR n.nspname = 'information_schema' 
                    WHEN true THEN CASE 
@@ -157,11 +251,11 @@ func (p *Postgres) GetTableMeta(schemaName string) (tableMeta []map[string]strin
                    LEFT JOIN pg_catalog.pg_class dc ON (d.classoid=dc.oid AND dc.relname='pg_class') 
                    LEFT JOIN pg_catalog.pg_namespace dn ON (dn.oid=dc.relnamespace AND dn.nspname='pg_catalog') 
                    WHERE c.relnamespace = n.oid and c.relkind in('r','v') and n.nspname='%s'`, schemaName)
-	_, tableMeta, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, tableMeta, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
 
-func (p *Postgres) GetViewMeta(schemaName, viewName string) (viewMeta []map[string]string) {
+func (p *PostgreSQL) GetViewMeta(schemaName, viewName string) (viewMeta []map[string]string) {
 	querySQL := fmt.Sprintf(`SELECT
 	schemaname,
 	viewname,
@@ -172,11 +266,11 @@ FROM
 WHERE
 	viewname = '%s' 
 	AND schemaname = '%s'`, viewName, schemaName)
-	_, viewMeta, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, viewMeta, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
 
-func (p *Postgres) GetTableColumnMeta(schemaName string, tableName string) (colMeta []map[string]string) {
+func (p *PostgreSQL) GetTableColumnMeta(schemaName string, tableName string) (colMeta []map[string]string) {
 	querySQL := fmt.Sprintf(`SELECT
 	pcol.*,
 	des.description 
@@ -204,11 +298,11 @@ ORDER BY
 	) pcol
 	LEFT JOIN pg_description des ON pcol.oid = des.objoid 
 	AND pcol.ordinal_position = des.objsubid`, strings.ToLower(schemaName), strings.ToLower(tableName))
-	_, colMeta, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, colMeta, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return colMeta
 }
 
-func (p *Postgres) GetTablePrimaryKey(schemaName string, tableName string) (pkList []map[string]string) {
+func (p *PostgreSQL) GetTablePrimaryKey(schemaName string, tableName string) (pkList []map[string]string) {
 	querySQL := fmt.Sprintf(`SELECT 
 	-- n.nspname AS TABLE_SCHEM,
 	-- ct.relname AS TABLE_NAME,
@@ -239,11 +333,11 @@ GROUP BY
 --	TABLE_NAME,
 --	key_seq,
 	pk_name`, strings.ToLower(schemaName), strings.ToLower(tableName))
-	_, pkList, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, pkList, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
 
-func (p *Postgres) GetTableUniqueKey(schemaName string, tableName string) (ukList []map[string]string) {
+func (p *PostgreSQL) GetTableUniqueKey(schemaName string, tableName string) (ukList []map[string]string) {
 	// tc.constraint_type support PRIMARY KEY、UNIQUE、FOREIGN KEY、CHECK
 	querySQL := fmt.Sprintf(`SELECT
 	tc.CONSTRAINT_NAME,
@@ -258,11 +352,11 @@ WHERE
 	AND tc.table_schema = '%s' 
 GROUP BY
 	tc.CONSTRAINT_NAME`, strings.ToLower(tableName), strings.ToLower(schemaName))
-	_, ukList, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, ukList, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
 
-func (p *Postgres) GetTableForeignKey(schemaName string, tableName string) (fkList []map[string]string) {
+func (p *PostgreSQL) GetTableForeignKey(schemaName string, tableName string) (fkList []map[string]string) {
 	querySQL := fmt.Sprintf(`SELECT
 	tc.CONSTRAINT_NAME,
 	tc.TABLE_NAME,
@@ -277,11 +371,11 @@ WHERE
 	constraint_type = 'FOREIGN KEY' 
 	AND tc.TABLE_NAME = '%s' 
 	AND tc.table_schema = '%s'`, strings.ToLower(tableName), strings.ToLower(schemaName))
-	_, fkList, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, fkList, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
 
-func (p *Postgres) GetTableIndexMeta(schemaName string, tableName string) (idxMeta []map[string]string) {
+func (p *PostgreSQL) GetTableIndexMeta(schemaName string, tableName string) (idxMeta []map[string]string) {
 	querySQL := fmt.Sprintf(`SELECT T
 	.relname AS TABLE_NAME,
 	i.relname AS index_name,
@@ -323,6 +417,6 @@ GROUP BY
 	TABLE_NAME,
 	index_name,
 	is_unique`, strings.ToLower(tableName), strings.ToLower(schemaName), strings.ToLower(tableName), strings.ToLower(schemaName))
-	_, idxMeta, _ = Query(p.Ctx, p.PGDB, querySQL)
+	_, idxMeta, _ = Query(p.Ctx, p.PostgreSQLDB, querySQL)
 	return
 }
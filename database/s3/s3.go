@@ -0,0 +1,156 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s3
+
+import (
+	"context"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// S3 兼容对象存储（AWS S3/MinIO/阿里云 OSS），导出文件流式 multipart 上传，无需本地落盘
+type S3 struct {
+	Ctx         context.Context
+	Client      *minio.Client
+	Bucket      string
+	PartSize    uint64
+	Concurrency uint
+}
+
+func NewS3Storage(ctx context.Context, storage string, s3Cfg config.S3Config) (*S3, error) {
+	if s3Cfg.Endpoint == "" || s3Cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 config endpoint and bucket can't be null")
+	}
+
+	// OSS 仅支持 virtual hosted 访问方式
+	lookup := minio.BucketLookupAuto
+	switch {
+	case strings.EqualFold(storage, common.ExportStorageOSS):
+		lookup = minio.BucketLookupDNS
+	case s3Cfg.ForcePathStyle:
+		lookup = minio.BucketLookupPath
+	}
+
+	partSize := common.S3DefaultPartSize
+	if s3Cfg.PartSize > 0 {
+		partSize = s3Cfg.PartSize
+	}
+	if partSize < common.S3MinPartSize {
+		return nil, fmt.Errorf("s3 config part-size [%d] can't be less than %dMB", partSize, common.S3MinPartSize)
+	}
+	concurrency := common.S3DefaultConcurrency
+	if s3Cfg.Concurrency > 0 {
+		concurrency = s3Cfg.Concurrency
+	}
+	// 单请求（包括单个分片）失败重试次数，全局生效
+	minio.MaxRetry = common.S3DefaultMaxRetries
+	if s3Cfg.MaxRetries > 0 {
+		minio.MaxRetry = s3Cfg.MaxRetries
+	}
+
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(s3Cfg.Endpoint, "https://"), "http://")
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(s3Cfg.AccessKey, s3Cfg.SecretKey, ""),
+		Secure:       !s3Cfg.DisableSSL,
+		Region:       s3Cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on create s3 endpoint [%s] client: %v", s3Cfg.Endpoint, err)
+	}
+
+	exist, err := client.BucketExists(ctx, s3Cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error on check s3 bucket [%s] exist: %v", s3Cfg.Bucket, err)
+	}
+	if !exist {
+		return nil, fmt.Errorf("s3 bucket [%s] isn't exist, please create it first", s3Cfg.Bucket)
+	}
+
+	return &S3{
+		Ctx:         ctx,
+		Client:      client,
+		Bucket:      s3Cfg.Bucket,
+		PartSize:    uint64(partSize) * 1024 * 1024,
+		Concurrency: uint(concurrency),
+	}, nil
+}
+
+// ObjectKey 本地路径格式文件名转换对象 key，output-dir 作为 key 前缀
+func (s *S3) ObjectKey(file string) string {
+	return strings.TrimLeft(filepath.ToSlash(filepath.Clean(file)), "/")
+}
+
+// URL 对象完整路径，用于日志以及元数据展示
+func (s *S3) URL(file string) string {
+	return common.StringsBuilder("s3://", s.Bucket, "/", s.ObjectKey(file))
+}
+
+// NewWriter 对象流式写入，Close 完成 multipart 上传，Abort 取消上传（不生成对象）
+func (s *S3) NewWriter(file string) *ObjectWriter {
+	pr, pw := io.Pipe()
+	w := &ObjectWriter{
+		name: s.URL(file),
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+	go func() {
+		_, err := s.Client.PutObject(s.Ctx, s.Bucket, s.ObjectKey(file), pr, -1, minio.PutObjectOptions{
+			PartSize:    s.PartSize,
+			NumThreads:  s.Concurrency,
+			ContentType: "application/octet-stream",
+		})
+		// 上传失败关闭读端，写端返回错误
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w
+}
+
+type ObjectWriter struct {
+	name string
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *ObjectWriter) Name() string {
+	return w.name
+}
+
+func (w *ObjectWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *ObjectWriter) Close() error {
+	if err := w.pw.Close(); err != nil {
+		return err
+	}
+	if err := <-w.done; err != nil {
+		return fmt.Errorf("s3 object [%s] upload failed: %v", w.name, err)
+	}
+	return nil
+}
+
+func (w *ObjectWriter) Abort(err error) {
+	w.pw.CloseWithError(fmt.Errorf("s3 object [%s] upload aborted: %v", w.name, err))
+	<-w.done
+}
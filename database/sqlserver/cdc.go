@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sqlserver
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database"
+	"strings"
+)
+
+// CDC 变更操作类型 __$operation，all update old 行过滤选项 UPDATE 同时返回变更前镜像（3）以及变更后镜像（4）
+// 变更前镜像按 DELETE 处理，配合变更后镜像覆盖写入，支持 UPDATE 修改主键字段值
+var sqlserverCDCOperations = map[string]string{
+	"1": common.ChangeOperationDelete,
+	"2": common.ChangeOperationInsert,
+	"3": common.ChangeOperationDelete,
+	"4": common.ChangeOperationUpdate,
+}
+
+// GetSQLServerCDCMaxLSN 获取数据库 CDC 当前最大 LSN，十六进制字符串表示，例如 0x0000002A000001F80003
+func (s *SQLServer) GetSQLServerCDCMaxLSN() (string, error) {
+	_, res, err := Query(s.Ctx, s.SQLServerDB, `SELECT CONVERT(VARCHAR(22), sys.fn_cdc_get_max_lsn(), 1) AS MAX_LSN`)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(res[0]["MAX_LSN"], "NULLABLE") {
+		return "", fmt.Errorf("sqlserver database cdc max lsn is null, please check database cdc whether enable (sys.sp_cdc_enable_db) and sql server agent whether running")
+	}
+	return res[0]["MAX_LSN"], nil
+}
+
+// GetSQLServerTableCaptureInstance 获取表最新 CDC 捕获实例以及捕获字段
+func (s *SQLServer) GetSQLServerTableCaptureInstance(schemaName, tableName string) (string, []string, error) {
+	var columns []string
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT TOP 1 capture_instance AS CAPTURE_INSTANCE
+  FROM cdc.change_tables
+ WHERE source_object_id = OBJECT_ID(%s)
+ ORDER BY create_date DESC`, sqlserverObjectName(schemaName, tableName)))
+	if err != nil {
+		return "", columns, err
+	}
+	if len(res) == 0 {
+		return "", columns, fmt.Errorf("sqlserver table [%s.%s] cdc isn't enable, please enable table cdc (sys.sp_cdc_enable_table)", schemaName, tableName)
+	}
+	captureInstance := res[0]["CAPTURE_INSTANCE"]
+
+	_, res, err = Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT cc.column_name AS COLUMN_NAME
+  FROM cdc.captured_columns cc
+  JOIN cdc.change_tables ct ON cc.object_id = ct.object_id
+ WHERE ct.capture_instance = N'%s'
+ ORDER BY cc.column_ordinal`, strings.ReplaceAll(captureInstance, "'", "''")))
+	if err != nil {
+		return captureInstance, columns, err
+	}
+	for _, r := range res {
+		columns = append(columns, r["COLUMN_NAME"])
+	}
+	return captureInstance, columns, nil
+}
+
+// GetSQLServerTableChanges 获取 (fromLSN, toLSN] 区间表 CDC 变更记录，按 LSN、事务内序号以及操作类型排序
+// fromLSN 为空代表从捕获实例最小 LSN 开始读取，fromLSN 早于捕获实例最小 LSN 代表变更数据已被清理，需重新全量同步
+func (s *SQLServer) GetSQLServerTableChanges(schemaName, tableName, fromLSN, toLSN, targetDBCharset, charsetErrorMode string) ([]database.RowChange, error) {
+	var changes []database.RowChange
+	if fromLSN != "" && strings.ToUpper(fromLSN) >= strings.ToUpper(toLSN) {
+		return changes, nil
+	}
+
+	captureInstance, capturedColumns, err := s.GetSQLServerTableCaptureInstance(schemaName, tableName)
+	if err != nil {
+		return changes, err
+	}
+	instanceLiteral := strings.ReplaceAll(captureInstance, "'", "''")
+
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT CONVERT(VARCHAR(22), sys.fn_cdc_get_min_lsn(N'%s'), 1) AS MIN_LSN`, instanceLiteral))
+	if err != nil {
+		return changes, err
+	}
+	minLSN := res[0]["MIN_LSN"]
+	var fromExpr string
+	if fromLSN == "" {
+		fromExpr = fmt.Sprintf(`sys.fn_cdc_get_min_lsn(N'%s')`, instanceLiteral)
+	} else {
+		if strings.ToUpper(fromLSN) < strings.ToUpper(minLSN) {
+			return changes, fmt.Errorf("sqlserver table [%s.%s] cdc capture instance [%s] min lsn [%s] is greater than checkpoint lsn [%s], change data has been cleanup, please rerunning full", schemaName, tableName, captureInstance, minLSN, fromLSN)
+		}
+		fromExpr = fmt.Sprintf(`sys.fn_cdc_increment_lsn(CONVERT(BINARY(10), '%s', 1))`, fromLSN)
+	}
+
+	// 捕获字段与当前表字段交集，按字段类型格式化查询
+	tableColumns, err := s.GetSQLServerSchemaTableColumn(schemaName, tableName)
+	if err != nil {
+		return changes, err
+	}
+	var columns []map[string]string
+	for _, c := range tableColumns {
+		if common.IsContainString(capturedColumns, c["COLUMN_NAME"]) {
+			columns = append(columns, c)
+		}
+	}
+
+	querySQL := fmt.Sprintf(`SELECT CONVERT(VARCHAR(22), __$start_lsn, 1) AS [__$start_lsn], CAST(__$operation AS VARCHAR(2)) AS [__$operation], %s
+  FROM cdc.fn_cdc_get_all_changes_%s(%s, CONVERT(BINARY(10), '%s', 1), N'all update old')
+ ORDER BY __$start_lsn, __$seqval, __$operation`, GenSQLServerTableColumnDetail(columns), captureInstance, fromExpr, toLSN)
+
+	rows, err := s.SQLServerDB.QueryContext(s.Ctx, querySQL)
+	if err != nil {
+		return changes, fmt.Errorf("sqlserver sql [%v] query failed: %v", querySQL, err)
+	}
+	defer rows.Close()
+
+	columnNames, converters, err := getRowsColumnConverters(rows)
+	if err != nil {
+		return changes, fmt.Errorf("sqlserver sql [%v] query rows.ColumnTypes failed: %v", querySQL, err)
+	}
+	rawResult := make([][]byte, len(columnNames))
+	dest := make([]interface{}, len(columnNames))
+	for i := range rawResult {
+		dest[i] = &rawResult[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return changes, fmt.Errorf("sqlserver sql [%v] query rows.Scan failed: %v", querySQL, err)
+		}
+		operation, ok := sqlserverCDCOperations[string(rawResult[1])]
+		if !ok {
+			continue
+		}
+		values, err := convertRowValues(columnNames[2:], converters[2:], rawResult[2:], targetDBCharset, charsetErrorMode)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, database.RowChange{
+			Position:  string(rawResult[0]),
+			Operation: operation,
+			Values:    values,
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return changes, fmt.Errorf("sqlserver sql [%v] query rows.Next failed: %v", querySQL, err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sqlserver
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// 主键可切分字段类型，数值类型字面量不加引号，字符类型 N” 引用
+var (
+	sqlserverChunkNumberTypes = []string{"tinyint", "smallint", "int", "bigint", "decimal", "numeric"}
+	sqlserverChunkStringTypes = []string{"char", "varchar", "nchar", "nvarchar"}
+)
+
+// GetSQLServerTableChunks 基于单字段主键 NTILE 分桶切分表数据，返回 WHERE 条件
+// 以相邻分桶下边界作为切分点，首尾 chunk 不设下界/上界，切分后新写入数据同样落在某个 chunk 内
+// 无主键、联合主键、主键字段类型不支持或者表数据量不超过 chunkSize 时不切分，返回 1 = 1
+func (s *SQLServer) GetSQLServerTableChunks(schemaName, tableName string, chunkSize int) ([]string, error) {
+	fullChunk := []string{"1 = 1"}
+
+	if chunkSize <= 0 {
+		return fullChunk, nil
+	}
+	pkColumns, err := s.GetSQLServerSchemaTablePrimaryKey(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkColumns) != 1 {
+		return fullChunk, nil
+	}
+
+	columns, err := s.GetSQLServerSchemaTableColumn(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var dataType string
+	for _, c := range columns {
+		if strings.EqualFold(c["COLUMN_NAME"], pkColumns[0]) {
+			dataType = strings.ToLower(c["DATA_TYPE"])
+		}
+	}
+	isNumber := common.IsContainString(sqlserverChunkNumberTypes, dataType)
+	if !isNumber && !common.IsContainString(sqlserverChunkStringTypes, dataType) {
+		return fullChunk, nil
+	}
+
+	numRows, err := s.GetSQLServerTableRowsByStatistics(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	buckets := (numRows + int64(chunkSize) - 1) / int64(chunkSize)
+	if buckets <= 1 {
+		return fullChunk, nil
+	}
+
+	pkColumn := QuoteSQLServerIdentifier(pkColumns[0])
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT MIN(%s) AS LOWER_BOUND
+  FROM (SELECT %s, NTILE(%d) OVER (ORDER BY %s) AS BUCKET FROM %s.%s) b
+ GROUP BY BUCKET
+ ORDER BY BUCKET`, pkColumn, pkColumn, buckets, pkColumn, QuoteSQLServerIdentifier(schemaName), QuoteSQLServerIdentifier(tableName)))
+	if err != nil {
+		return nil, err
+	}
+	if len(res) <= 1 {
+		return fullChunk, nil
+	}
+
+	var bounds []string
+	for _, r := range res {
+		if isNumber {
+			bounds = append(bounds, r["LOWER_BOUND"])
+		} else {
+			bounds = append(bounds, common.StringsBuilder(`N'`, strings.ReplaceAll(r["LOWER_BOUND"], "'", "''"), `'`))
+		}
+	}
+
+	var chunks []string
+	for i := 1; i < len(bounds); i++ {
+		if i == 1 {
+			chunks = append(chunks, fmt.Sprintf("%s < %s", pkColumn, bounds[i]))
+			continue
+		}
+		chunks = append(chunks, fmt.Sprintf("%s >= %s AND %s < %s", pkColumn, bounds[i-1], pkColumn, bounds[i]))
+	}
+	chunks = append(chunks, fmt.Sprintf("%s >= %s", pkColumn, bounds[len(bounds)-1]))
+	return chunks, nil
+}
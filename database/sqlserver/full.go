@@ -0,0 +1,229 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sqlserver
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"strconv"
+	"strings"
+)
+
+// GenSQLServerTableColumnDetail 生成查询字段，时间、GUID、XML 等类型 CONVERT 格式化为 MySQL/TiDB 可识别字符串
+// datetimeoffset 丢弃时区偏移，按存储的本地时间写入
+func GenSQLServerTableColumnDetail(columns []map[string]string) string {
+	var columnDetails []string
+	for _, c := range columns {
+		columnName := QuoteSQLServerIdentifier(c["COLUMN_NAME"])
+		switch strings.ToLower(c["DATA_TYPE"]) {
+		case "date":
+			columnDetails = append(columnDetails, common.StringsBuilder("CONVERT(VARCHAR(10), ", columnName, ", 23) AS ", columnName))
+		case "datetime", "datetime2", "smalldatetime":
+			columnDetails = append(columnDetails, common.StringsBuilder("CONVERT(VARCHAR(27), ", columnName, ", 121) AS ", columnName))
+		case "datetimeoffset":
+			columnDetails = append(columnDetails, common.StringsBuilder("CONVERT(VARCHAR(27), CAST(", columnName, " AS DATETIME2), 121) AS ", columnName))
+		case "time":
+			columnDetails = append(columnDetails, common.StringsBuilder("CAST(", columnName, " AS VARCHAR(16)) AS ", columnName))
+		case "uniqueidentifier":
+			columnDetails = append(columnDetails, common.StringsBuilder("CONVERT(VARCHAR(36), ", columnName, ") AS ", columnName))
+		case "xml":
+			columnDetails = append(columnDetails, common.StringsBuilder("CAST(", columnName, " AS NVARCHAR(MAX)) AS ", columnName))
+		case "sql_variant", "hierarchyid":
+			columnDetails = append(columnDetails, common.StringsBuilder("CAST(", columnName, " AS NVARCHAR(4000)) AS ", columnName))
+		case "geography", "geometry":
+			columnDetails = append(columnDetails, common.StringsBuilder(columnName, ".STAsText() AS ", columnName))
+		default:
+			columnDetails = append(columnDetails, columnName)
+		}
+	}
+	return strings.Join(columnDetails, ",")
+}
+
+// columnValueConverter 字段值转换为下游 MySQL/TiDB SQL 字面量，raw 为非 NULL 原始值
+type columnValueConverter func(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error)
+
+// 以 go-mssqldb DatabaseTypeName() 字段类型注册转换器，未注册类型按字符串转换
+var columnValueConverters = map[string]columnValueConverter{
+	"TINYINT":    integerColumnConverter,
+	"SMALLINT":   integerColumnConverter,
+	"INT":        integerColumnConverter,
+	"BIGINT":     integerColumnConverter,
+	"DECIMAL":    decimalColumnConverter,
+	"MONEY":      decimalColumnConverter,
+	"SMALLMONEY": decimalColumnConverter,
+	"REAL":       floatColumnConverter(32),
+	"FLOAT":      floatColumnConverter(64),
+	"BIT":        bitColumnConverter,
+	"BINARY":     binaryColumnConverter,
+	"VARBINARY":  binaryColumnConverter,
+	"IMAGE":      binaryColumnConverter,
+}
+
+func integerColumnConverter(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error) {
+	r, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("column [%s] integer value [%s] convert failed, %v", columnName, string(raw), err)
+	}
+	return strconv.FormatInt(r, 10), nil
+}
+
+// DECIMAL/NUMERIC/MONEY 按十进制精确解析输出，不经 float 转换避免精度丢失
+func decimalColumnConverter(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error) {
+	r, err := decimal.NewFromString(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("column [%s] decimal value [%s] convert failed, %v", columnName, string(raw), err)
+	}
+	return r.String(), nil
+}
+
+func floatColumnConverter(bitSize int) columnValueConverter {
+	return func(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error) {
+		r, err := strconv.ParseFloat(string(raw), bitSize)
+		if err != nil {
+			return "", fmt.Errorf("column [%s] float value [%s] convert failed, %v", columnName, string(raw), err)
+		}
+		return strconv.FormatFloat(r, 'g', -1, bitSize), nil
+	}
+}
+
+func bitColumnConverter(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error) {
+	r, err := strconv.ParseBool(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("column [%s] bit value [%s] convert failed, %v", columnName, string(raw), err)
+	}
+	if r {
+		return "1", nil
+	}
+	return "0", nil
+}
+
+// BINARY/VARBINARY/IMAGE/ROWVERSION 二进制数据，十六进制字面量写入，不做字符集转换以及特殊字符转义
+func binaryColumnConverter(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error) {
+	return common.StringsBuilder("X'", hex.EncodeToString(raw), "'"), nil
+}
+
+// 字符数据驱动已按排序规则代码页解码为 UTF8，转换为目标端字符集以及特殊字符转义
+func stringColumnConverter(columnName string, raw []byte, targetDBCharset, charsetErrorMode string) (string, error) {
+	convertTargetRaw, err := common.CharsetConvertByMode([]byte(common.SpecialLettersUsingMySQL(raw)), common.CharsetUTF8MB4, targetDBCharset, charsetErrorMode)
+	if err != nil {
+		return "", fmt.Errorf("column [%s] charset convert failed, %v", columnName, err)
+	}
+	return common.StringsBuilder("'", string(convertTargetRaw), "'"), nil
+}
+
+func getColumnValueConverter(databaseType string) columnValueConverter {
+	if converter, ok := columnValueConverters[common.StringUPPER(databaseType)]; ok {
+		return converter
+	}
+	return stringColumnConverter
+}
+
+// GetSQLServerTableRowsData 读取表数据，按 insertBatchSize 批量写入通道，字段值为下游 MySQL/TiDB SQL 字面量
+// SQLServer 与 MySQL 一致区分 NULL 与空字符串，空字符串按空字符串写入
+func (s *SQLServer) GetSQLServerTableRowsData(querySQL string, insertBatchSize int, targetDBCharset, charsetErrorMode string, dataChan chan []map[string]string) error {
+	rows, err := s.SQLServerDB.QueryContext(s.Ctx, querySQL)
+	if err != nil {
+		return fmt.Errorf("sqlserver sql [%v] query failed: %v", querySQL, err)
+	}
+	defer rows.Close()
+
+	columnNames, converters, err := getRowsColumnConverters(rows)
+	if err != nil {
+		return fmt.Errorf("sqlserver sql [%v] query rows.ColumnTypes failed: %v", querySQL, err)
+	}
+
+	rawResult := make([][]byte, len(columnNames))
+	dest := make([]interface{}, len(columnNames))
+	for i := range rawResult {
+		dest[i] = &rawResult[i]
+	}
+
+	if insertBatchSize <= 0 {
+		insertBatchSize = common.ChannelBufferSize
+	}
+
+	// 限速统计批次字节数
+	var batchBytes int
+	rowsTMP := make([]map[string]string, 0, insertBatchSize)
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return fmt.Errorf("sqlserver sql [%v] query rows.Scan failed: %v", querySQL, err)
+		}
+		for _, raw := range rawResult {
+			batchBytes += len(raw)
+		}
+		rowsMap, err := convertRowValues(columnNames, converters, rawResult, targetDBCharset, charsetErrorMode)
+		if err != nil {
+			return err
+		}
+		rowsTMP = append(rowsTMP, rowsMap)
+
+		if len(rowsTMP) == insertBatchSize {
+			if err = s.Throttle.Wait(s.Ctx, len(rowsTMP), batchBytes); err != nil {
+				return err
+			}
+			batchBytes = 0
+			dataChan <- rowsTMP
+			rowsTMP = make([]map[string]string, 0, insertBatchSize)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("sqlserver sql [%v] query rows.Next failed: %v", querySQL, err)
+	}
+
+	if len(rowsTMP) > 0 {
+		if err = s.Throttle.Wait(s.Ctx, len(rowsTMP), batchBytes); err != nil {
+			return err
+		}
+		dataChan <- rowsTMP
+	}
+	return nil
+}
+
+func getRowsColumnConverters(rows *sql.Rows) ([]string, []columnValueConverter, error) {
+	var (
+		columnNames []string
+		converters  []columnValueConverter
+	)
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return columnNames, converters, err
+	}
+	for _, ct := range colTypes {
+		columnNames = append(columnNames, ct.Name())
+		converters = append(converters, getColumnValueConverter(ct.DatabaseTypeName()))
+	}
+	return columnNames, converters, nil
+}
+
+func convertRowValues(columnNames []string, converters []columnValueConverter, rawResult [][]byte, targetDBCharset, charsetErrorMode string) (map[string]string, error) {
+	rowsMap := make(map[string]string, len(columnNames))
+	for i, raw := range rawResult {
+		if raw == nil {
+			rowsMap[columnNames[i]] = `NULL`
+			continue
+		}
+		val, err := converters[i](columnNames[i], raw, targetDBCharset, charsetErrorMode)
+		if err != nil {
+			return nil, err
+		}
+		rowsMap[columnNames[i]] = val
+	}
+	return rowsMap, nil
+}
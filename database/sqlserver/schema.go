@@ -0,0 +1,115 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sqlserver
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strconv"
+	"strings"
+)
+
+// sqlserverObjectName 生成 OBJECT_ID 函数对象名参数
+func sqlserverObjectName(schemaName, tableName string) string {
+	return common.StringsBuilder(`N'`, strings.ReplaceAll(common.StringsBuilder(QuoteSQLServerIdentifier(schemaName), ".", QuoteSQLServerIdentifier(tableName)), "'", "''"), `'`)
+}
+
+func (s *SQLServer) GetSQLServerSchemaTable(schemaName string) ([]string, error) {
+	var tables []string
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT t.name AS TABLE_NAME
+  FROM sys.tables t
+  JOIN sys.schemas s ON t.schema_id = s.schema_id
+ WHERE UPPER(s.name) = UPPER(N'%s')
+   AND t.is_ms_shipped = 0
+ ORDER BY t.name`, strings.ReplaceAll(schemaName, "'", "''")))
+	if err != nil {
+		return tables, err
+	}
+	for _, r := range res {
+		tables = append(tables, r["TABLE_NAME"])
+	}
+	return tables, nil
+}
+
+// GetSQLServerSchemaTableColumn 获取表字段信息，计算列不参与迁移，DATA_TYPE 以系统类型表示（用户自定义别名类型取基础类型）
+func (s *SQLServer) GetSQLServerSchemaTableColumn(schemaName, tableName string) ([]map[string]string, error) {
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT c.name AS COLUMN_NAME,
+       LOWER(TYPE_NAME(c.system_type_id)) AS DATA_TYPE,
+       c.max_length AS DATA_LENGTH,
+       c.precision AS DATA_PRECISION,
+       c.scale AS DATA_SCALE,
+       CASE WHEN c.is_nullable = 1 THEN 'Y' ELSE 'N' END AS NULLABLE,
+       CASE WHEN c.is_identity = 1 THEN 'Y' ELSE 'N' END AS IS_IDENTITY
+  FROM sys.columns c
+ WHERE c.object_id = OBJECT_ID(%s)
+   AND c.is_computed = 0
+ ORDER BY c.column_id`, sqlserverObjectName(schemaName, tableName)))
+	if err != nil {
+		return res, err
+	}
+	if len(res) == 0 {
+		return res, fmt.Errorf("sqlserver table [%s.%s] column isn't exist, please check table whether exist", schemaName, tableName)
+	}
+	return res, nil
+}
+
+func (s *SQLServer) GetSQLServerSchemaTablePrimaryKey(schemaName, tableName string) ([]string, error) {
+	var columns []string
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT c.name AS COLUMN_NAME
+  FROM sys.indexes i
+  JOIN sys.index_columns ic ON i.object_id = ic.object_id AND i.index_id = ic.index_id
+  JOIN sys.columns c ON ic.object_id = c.object_id AND ic.column_id = c.column_id
+ WHERE i.object_id = OBJECT_ID(%s)
+   AND i.is_primary_key = 1
+ ORDER BY ic.key_ordinal`, sqlserverObjectName(schemaName, tableName)))
+	if err != nil {
+		return columns, err
+	}
+	for _, r := range res {
+		columns = append(columns, r["COLUMN_NAME"])
+	}
+	return columns, nil
+}
+
+// GetSQLServerTableRowsByStatistics 按 sys.partitions 行数统计获取表数据行数
+func (s *SQLServer) GetSQLServerTableRowsByStatistics(schemaName, tableName string) (int64, error) {
+	_, res, err := Query(s.Ctx, s.SQLServerDB, fmt.Sprintf(`SELECT CAST(ISNULL(SUM(p.rows), 0) AS BIGINT) AS NUM_ROWS
+  FROM sys.partitions p
+ WHERE p.object_id = OBJECT_ID(%s)
+   AND p.index_id IN (0, 1)`, sqlserverObjectName(schemaName, tableName)))
+	if err != nil {
+		return 0, err
+	}
+	numRows, err := strconv.ParseInt(res[0]["NUM_ROWS"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("get sqlserver table [%s.%s] rows [%s] by statistics strconv failed: %v", schemaName, tableName, res[0]["NUM_ROWS"], err)
+	}
+	return numRows, nil
+}
+
+// GetSQLServerTableActualRows 获取表实际数据行数，用于数据校验
+func (s *SQLServer) GetSQLServerTableActualRows(schemaName, tableName string) (int64, error) {
+	_, res, err := Query(s.Ctx, s.SQLServerDB, common.StringsBuilder(`SELECT COUNT_BIG(1) AS NUM_ROWS FROM `,
+		QuoteSQLServerIdentifier(schemaName), ".", QuoteSQLServerIdentifier(tableName)))
+	if err != nil {
+		return 0, err
+	}
+	numRows, err := strconv.ParseInt(res[0]["NUM_ROWS"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("get sqlserver table [%s.%s] actual rows [%s] strconv failed: %v", schemaName, tableName, res[0]["NUM_ROWS"], err)
+	}
+	return numRows, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sqlserver
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database"
+)
+
+// SQLServer 实现迁移源端接口
+var _ database.Source = (*SQLServer)(nil)
+
+func (s *SQLServer) DBType() string {
+	return common.DatabaseTypeSQLServer
+}
+
+func (s *SQLServer) GetSchemaTables(schemaName string) ([]string, error) {
+	return s.GetSQLServerSchemaTable(schemaName)
+}
+
+func (s *SQLServer) GetSchemaTableColumns(schemaName, tableName string) ([]map[string]string, error) {
+	return s.GetSQLServerSchemaTableColumn(schemaName, tableName)
+}
+
+func (s *SQLServer) GetSchemaTablePrimaryKey(schemaName, tableName string) ([]string, error) {
+	return s.GetSQLServerSchemaTablePrimaryKey(schemaName, tableName)
+}
+
+func (s *SQLServer) GetSchemaTableRows(schemaName, tableName string) (int64, error) {
+	return s.GetSQLServerTableRowsByStatistics(schemaName, tableName)
+}
+
+func (s *SQLServer) GetTableChunks(schemaName, tableName string, chunkSize int) ([]string, error) {
+	return s.GetSQLServerTableChunks(schemaName, tableName, chunkSize)
+}
+
+func (s *SQLServer) GenTableColumnDetail(columns []map[string]string) string {
+	return GenSQLServerTableColumnDetail(columns)
+}
+
+func (s *SQLServer) GenTableQuerySQL(schemaName, tableName, columnDetail, chunk string) string {
+	return common.StringsBuilder(`SELECT `, columnDetail, ` FROM `, QuoteSQLServerIdentifier(schemaName), `.`, QuoteSQLServerIdentifier(tableName), ` WHERE `, chunk)
+}
+
+func (s *SQLServer) GetTableRowsData(querySQL string, insertBatchSize int, targetDBCharset, charsetErrorMode string, dataChan chan []map[string]string) error {
+	return s.GetSQLServerTableRowsData(querySQL, insertBatchSize, targetDBCharset, charsetErrorMode, dataChan)
+}
+
+func (s *SQLServer) GetCurrentPosition() (string, error) {
+	return s.GetSQLServerCDCMaxLSN()
+}
+
+func (s *SQLServer) GetTableChanges(schemaName, tableName, fromPosition, toPosition, targetDBCharset, charsetErrorMode string) ([]database.RowChange, error) {
+	return s.GetSQLServerTableChanges(schemaName, tableName, fromPosition, toPosition, targetDBCharset, charsetErrorMode)
+}
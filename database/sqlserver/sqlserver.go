@@ -0,0 +1,146 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	_ "github.com/microsoft/go-mssqldb"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type SQLServer struct {
+	Ctx         context.Context
+	SQLServerDB *sql.DB
+	// 源端数据读取限速，nil 不限速
+	Throttle *common.Throttle
+}
+
+func NewSQLServerDBEngine(ctx context.Context, sqlserverCfg config.SQLServerConfig) (*SQLServer, error) {
+	sqlserverDB, err := sql.Open("sqlserver", genSQLServerDSN(sqlserverCfg))
+	if err != nil {
+		return nil, fmt.Errorf("error on open sqlserver database connection: %v", err)
+	}
+
+	maxIdleConns := common.SQLServerMaxIdleConn
+	if sqlserverCfg.MaxIdleConns > 0 {
+		maxIdleConns = sqlserverCfg.MaxIdleConns
+	}
+	maxOpenConns := common.SQLServerMaxConn
+	if sqlserverCfg.MaxOpenConns > 0 {
+		maxOpenConns = sqlserverCfg.MaxOpenConns
+	}
+	connMaxLifetime := common.SQLServerConnMaxLifeTime
+	if sqlserverCfg.ConnMaxLifetime > 0 {
+		connMaxLifetime = time.Duration(sqlserverCfg.ConnMaxLifetime) * time.Second
+	}
+	sqlserverDB.SetMaxIdleConns(maxIdleConns)
+	sqlserverDB.SetMaxOpenConns(maxOpenConns)
+	sqlserverDB.SetConnMaxLifetime(connMaxLifetime)
+	sqlserverDB.SetConnMaxIdleTime(common.SQLServerConnMaxIdleTime)
+
+	if err = sqlserverDB.Ping(); err != nil {
+		return nil, fmt.Errorf("error on ping sqlserver database connection: %v", err)
+	}
+
+	return &SQLServer{
+		Ctx:         ctx,
+		SQLServerDB: sqlserverDB,
+	}, nil
+}
+
+// genSQLServerDSN 生成 URL 格式连接串，connect-params 以 URL 参数形式追加，如 encrypt=disable&app+name=transferdb
+func genSQLServerDSN(sqlserverCfg config.SQLServerConfig) string {
+	params := []string{common.StringsBuilder("database=", url.QueryEscape(sqlserverCfg.DBName))}
+	if sqlserverCfg.ConnectTimeout > 0 {
+		params = append(params, fmt.Sprintf("connection+timeout=%d", sqlserverCfg.ConnectTimeout))
+	}
+	if !strings.EqualFold(sqlserverCfg.ConnectParams, "") {
+		params = append(params, sqlserverCfg.ConnectParams)
+	}
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(sqlserverCfg.Username, sqlserverCfg.Password),
+		Host:     fmt.Sprintf("%s:%d", sqlserverCfg.Host, sqlserverCfg.Port),
+		RawQuery: strings.Join(params, "&"),
+	}
+	return dsn.String()
+}
+
+func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
+	var (
+		cols []string
+		res  []map[string]string
+	)
+	rows, err := db.QueryContext(ctx, querySQL)
+	if err != nil {
+		return cols, res, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+	}
+	defer rows.Close()
+
+	//不确定字段通用查询，自动获取字段名称
+	cols, err = rows.Columns()
+	if err != nil {
+		return cols, res, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+	}
+
+	values := make([]sql.NullString, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range values {
+		scans[i] = &values[i]
+	}
+
+	for rows.Next() {
+		err = rows.Scan(scans...)
+		if err != nil {
+			return cols, res, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+		}
+
+		row := make(map[string]string)
+		for k, v := range values {
+			// 与 mysql Query 保持一致，NULL 值以 NULLABLE 表示
+			if !v.Valid {
+				row[cols[k]] = "NULLABLE"
+			} else {
+				row[cols[k]] = v.String
+			}
+		}
+		res = append(res, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return cols, res, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+	}
+	return cols, res, nil
+}
+
+// QuoteSQLServerIdentifier SQLServer 标识符方括号引用，内部右方括号转义为两个右方括号
+func QuoteSQLServerIdentifier(name string) string {
+	return common.StringsBuilder("[", strings.ReplaceAll(name, "]", "]]"), "]")
+}
+
+func (s *SQLServer) GetSQLServerDBVersion() (string, error) {
+	_, res, err := Query(s.Ctx, s.SQLServerDB, `SELECT CAST(SERVERPROPERTY('ProductVersion') AS VARCHAR(128)) AS VERSION`)
+	if err != nil {
+		return "", err
+	}
+	return res[0]["VERSION"], nil
+}
@@ -49,6 +49,14 @@ func (p *tableRulesParser) parsePattern(line string) (matcher, error) {
 		isLiteralString        = true
 		i                      = 0
 	)
+	// 以 ~ 开头表示正则表达式，例如 ~^TMP_，大小写不敏感
+	if strings.HasPrefix(line, "~") {
+		if len(line) == 1 {
+			return nil, fmt.Errorf("syntax error: empty regular expression")
+		}
+		return newRegexpMatcher("(?i)" + line[1:])
+	}
+
 	literalStringBuilder.Grow(len(line))
 	wildcardPatternBuilder.Grow(len(line) + 6)
 	wildcardPatternBuilder.WriteString("(?i)(^|([\\s\\t\\n]+))")
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -29,37 +30,63 @@ const (
 	logTmFmt = "2006-01-02 15:04:05.000"
 )
 
+// slowLogger 慢 SQL 日志记录器，slow-query-file 为空不开启
+var slowLogger *zap.Logger
+
 // 初始化日志记录器
 func NewZapLogger(cfg *config.Config) {
-	Encoder := GetEncoder()
+	Encoder := GetEncoder(cfg.LogConfig.LogFormat)
 	WriteSyncer := GetWriteSyncer(cfg)
 	LevelEnabler := GetLevelEnabler(cfg.LogConfig.LogLevel)
+	// 模块级别覆盖需最低级别放行，由 moduleLevelCore 按模块过滤
+	minLevel := LevelEnabler
+	for _, ml := range cfg.LogConfig.ModuleLevels {
+		if kv := strings.SplitN(ml, "=", 2); len(kv) == 2 && GetLevelEnabler(strings.TrimSpace(kv[1])) < minLevel {
+			minLevel = GetLevelEnabler(strings.TrimSpace(kv[1]))
+		}
+	}
 	// ConsoleEncoder := GetConsoleEncoder()
 	newCore := zapcore.NewTee(
-		zapcore.NewCore(Encoder, WriteSyncer, LevelEnabler), // 写入文件
+		newModuleLevelCore(zapcore.NewCore(Encoder, WriteSyncer, minLevel), LevelEnabler, cfg.LogConfig.ModuleLevels), // 写入文件
 		//zapcore.NewCore(ConsoleEncoder, zapcore.Lock(os.Stdout), zapcore.DebugLevel), // 写入控制台
 	)
 	logger := zap.New(newCore, zap.AddCaller())
 	zap.ReplaceGlobals(logger)
+
+	if cfg.LogConfig.SlowQueryFile != "" {
+		slowLogger = zap.New(zapcore.NewCore(Encoder, GetSlowWriteSyncer(cfg), zapcore.DebugLevel))
+	}
 }
 
-// GetEncoder 自定义的Encoder
-func GetEncoder() zapcore.Encoder {
-	return zapcore.NewConsoleEncoder(
-		zapcore.EncoderConfig{
-			TimeKey:        "ts",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller_line",
-			FunctionKey:    zapcore.OmitKey,
-			MessageKey:     "msg",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    cEncodeLevel,
-			EncodeTime:     cEncodeTime,
-			EncodeDuration: zapcore.SecondsDurationEncoder,
-			EncodeCaller:   cEncodeCaller,
-		})
+// SlowLogger 慢 SQL 日志记录器，未开启返回 nil
+func SlowLogger() *zap.Logger {
+	return slowLogger
+}
+
+// GetEncoder 自定义的Encoder，log-format json 输出 JSON 格式，便于日志平台采集，默认 console
+func GetEncoder(logFormat string) zapcore.Encoder {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller_line",
+		FunctionKey:    zapcore.OmitKey,
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    cEncodeLevel,
+		EncodeTime:     cEncodeTime,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   cEncodeCaller,
+	}
+	if strings.EqualFold(logFormat, common.LogFormatJSON) {
+		// JSON 字段值不加 [] 包裹
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(logTmFmt)
+		encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
 // GetConsoleEncoder 输出日志到控制台
@@ -74,10 +101,22 @@ func GetWriteSyncer(cfg *config.Config) zapcore.WriteSyncer {
 		MaxSize:    cfg.LogConfig.MaxSize,
 		MaxAge:     cfg.LogConfig.MaxDays,
 		MaxBackups: cfg.LogConfig.MaxBackups,
+		Compress:   cfg.LogConfig.Compress,
 	}
 	return zapcore.AddSync(lumberJackLogger)
 }
 
+// GetSlowWriteSyncer 慢 SQL 日志 WriteSyncer，轮转配置同 log-file
+func GetSlowWriteSyncer(cfg *config.Config) zapcore.WriteSyncer {
+	return zapcore.AddSync(&lumberjack.Logger{
+		Filename:   cfg.LogConfig.SlowQueryFile,
+		MaxSize:    cfg.LogConfig.MaxSize,
+		MaxAge:     cfg.LogConfig.MaxDays,
+		MaxBackups: cfg.LogConfig.MaxBackups,
+		Compress:   cfg.LogConfig.Compress,
+	})
+}
+
 // GetLevelEnabler 自定义的LevelEnabler
 func GetLevelEnabler(logLevel string) zapcore.Level {
 	switch strings.ToUpper(logLevel) {
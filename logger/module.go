@@ -0,0 +1,101 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"strings"
+)
+
+// moduleLevel 模块日志级别，module 为源码目录，例如 module/migrate/sql/oracle/o2m、database/meta
+type moduleLevel struct {
+	module string
+	level  zapcore.Level
+}
+
+// moduleLevelCore 按日志调用方源码目录覆盖日志级别，多个模块匹配时最长目录优先，未匹配模块沿用全局 log-level
+// zap 在 Check 之后才填充调用方信息，Check 按全部级别最小值放行，Write 阶段按调用方所在模块过滤
+type moduleLevelCore struct {
+	zapcore.Core
+	level   zapcore.Level
+	modules []moduleLevel
+}
+
+// newModuleLevelCore 解析 module-levels 配置，格式 module=level，配置格式已由 AdjustConfig 校验，未配置返回原 core
+func newModuleLevelCore(core zapcore.Core, level zapcore.Level, moduleLevels []string) zapcore.Core {
+	if len(moduleLevels) == 0 {
+		return core
+	}
+	c := &moduleLevelCore{Core: core, level: level}
+	for _, ml := range moduleLevels {
+		kv := strings.SplitN(strings.TrimSpace(ml), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		c.modules = append(c.modules, moduleLevel{
+			module: strings.Trim(strings.TrimSpace(kv[0]), "/"),
+			level:  GetLevelEnabler(strings.TrimSpace(kv[1])),
+		})
+	}
+	sort.SliceStable(c.modules, func(i, j int) bool {
+		return len(c.modules[i].module) > len(c.modules[j].module)
+	})
+	return c
+}
+
+// Enabled 全局级别以及模块级别任一开启即放行
+func (c *moduleLevelCore) Enabled(level zapcore.Level) bool {
+	if level >= c.level {
+		return true
+	}
+	for _, m := range c.modules {
+		if level >= m.level {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *moduleLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleLevelCore{Core: c.Core.With(fields), level: c.level, modules: c.modules}
+}
+
+func (c *moduleLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *moduleLevelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level < c.callerLevel(ent.Caller) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// callerLevel 调用方所在模块日志级别，源码路径包含 /${module}/ 即匹配
+func (c *moduleLevelCore) callerLevel(caller zapcore.EntryCaller) zapcore.Level {
+	if caller.Defined {
+		for _, m := range c.modules {
+			if strings.Contains(caller.File, "/"+m.module+"/") {
+				return m.level
+			}
+		}
+	}
+	return c.level
+}
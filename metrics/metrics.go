@@ -0,0 +1,211 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// transferdb 运行指标，通过 pprof-port /metrics 暴露
+var (
+	// 全量读取上游行数
+	FullRowsReadCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "rows_read_total",
+			Help:      "Counter of rows read from the source table.",
+		}, []string{"schema", "table"})
+
+	// 全量写入下游批次数以及字节数
+	FullBatchWrittenCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "batches_written_total",
+			Help:      "Counter of batches written to the target table.",
+		}, []string{"schema", "table"})
+
+	FullBytesWrittenCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "bytes_written_total",
+			Help:      "Counter of bytes written to the target table.",
+		}, []string{"schema", "table"})
+
+	// 全量 chunk 完成数，status: success/failed
+	FullChunkCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "chunks_total",
+			Help:      "Counter of finished chunks by status.",
+		}, []string{"schema", "table", "status"})
+
+	// 全量 skip-error 写入失败隔离行数
+	FullRowsQuarantinedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "rows_quarantined_total",
+			Help:      "Counter of rows failed to apply and written to the quarantine file.",
+		}, []string{"schema", "table"})
+
+	// 全量写入下游单批次耗时
+	FullApplyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "apply_duration_seconds",
+			Help:      "Bucketed histogram of batch apply duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 20),
+		}, []string{"schema", "table"})
+
+	// 全量自适应批次当前每批次行数以及最近一次批次字节数
+	FullAdaptiveBatchRowsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "adaptive_batch_rows",
+			Help:      "Gauge of rows per batch chosen by adaptive batch sizing.",
+		}, []string{"schema", "table"})
+
+	FullAdaptiveBatchBytesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "adaptive_batch_bytes",
+			Help:      "Gauge of last batch statement bytes under adaptive batch sizing.",
+		}, []string{"schema", "table"})
+
+	// 瞬时错误重试次数，operation: extract/apply/incr_apply
+	RetryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "retry",
+			Name:      "attempts_total",
+			Help:      "Counter of retries on transient errors by operation.",
+		}, []string{"schema", "table", "operation"})
+
+	// 连接池健康检查失败次数，db: oracle/mysql
+	DBHealthCheckFailedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "db",
+			Name:      "health_check_failed_total",
+			Help:      "Counter of connection pool health check failures by database.",
+		}, []string{"db"})
+
+	// 连接中断失败 chunk 重新分发次数
+	FullChunkRedispatchCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "full",
+			Name:      "chunk_redispatch_total",
+			Help:      "Counter of full chunks re-dispatched after connection failures.",
+		}, []string{"schema", "table"})
+
+	// 增量同步已应用 SCN 以及上游当前 SCN，两者差值即同步延迟
+	IncrAppliedSCNGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "applied_scn",
+			Help:      "Gauge of the source scn applied by incremental sync.",
+		}, []string{"schema"})
+
+	IncrCurrentSCNGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "current_scn",
+			Help:      "Gauge of the source current redo log max scn.",
+		}, []string{"schema"})
+
+	// 增量同步上游当前 SCN、未同步 SCN 区间长度以及延迟秒数，延迟秒数 -1 代表无法计算
+	IncrSourceSCNGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "source_scn",
+			Help:      "Gauge of the source database current scn.",
+		}, []string{"schema"})
+
+	IncrUnreplicatedSCNGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "unreplicated_scn",
+			Help:      "Gauge of the scn range between source current scn and applied scn.",
+		}, []string{"schema"})
+
+	IncrLagSecondsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "lag_seconds",
+			Help:      "Gauge of seconds behind source by applied scn.",
+		}, []string{"schema"})
+
+	// 增量捕获单事务行数分布，以及超过 txn-spill-rows 落盘的事务数、落盘字节数
+	IncrTxnRowsHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "txn_rows",
+			Help:      "Bucketed histogram of captured rows per source transaction.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 12),
+		}, []string{"schema"})
+
+	IncrTxnSpilledCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "txn_spilled_total",
+			Help:      "Counter of source transactions spilled to disk.",
+		}, []string{"schema"})
+
+	IncrTxnSpilledBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "txn_spilled_bytes_total",
+			Help:      "Counter of redo and undo sql bytes spilled to disk.",
+		}, []string{"schema"})
+)
+
+func init() {
+	prometheus.MustRegister(FullRowsReadCounter)
+	prometheus.MustRegister(FullBatchWrittenCounter)
+	prometheus.MustRegister(FullBytesWrittenCounter)
+	prometheus.MustRegister(FullChunkCounter)
+	prometheus.MustRegister(FullRowsQuarantinedCounter)
+	prometheus.MustRegister(FullApplyDuration)
+	prometheus.MustRegister(FullAdaptiveBatchRowsGauge)
+	prometheus.MustRegister(FullAdaptiveBatchBytesGauge)
+	prometheus.MustRegister(RetryCounter)
+	prometheus.MustRegister(DBHealthCheckFailedCounter)
+	prometheus.MustRegister(FullChunkRedispatchCounter)
+	prometheus.MustRegister(IncrAppliedSCNGauge)
+	prometheus.MustRegister(IncrCurrentSCNGauge)
+	prometheus.MustRegister(IncrSourceSCNGauge)
+	prometheus.MustRegister(IncrUnreplicatedSCNGauge)
+	prometheus.MustRegister(IncrLagSecondsGauge)
+	prometheus.MustRegister(IncrTxnRowsHistogram)
+	prometheus.MustRegister(IncrTxnSpilledCounter)
+	prometheus.MustRegister(IncrTxnSpilledBytesCounter)
+}
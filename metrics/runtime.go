@@ -0,0 +1,94 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// 进程启动时间，用于运行时诊断输出运行时长
+var startTime = time.Now()
+
+// 运行时诊断最近 GC 暂停记录数，runtime.MemStats.PauseNs 环形缓冲最多 256 条
+const runtimeRecentGCPauses = 16
+
+// RuntimeStats 进程运行时诊断信息，用于排查长时间运行迁移任务 goroutine 以及内存泄漏
+type RuntimeStats struct {
+	Uptime         string   `json:"uptime"`
+	GoVersion      string   `json:"go-version"`
+	NumCPU         int      `json:"num-cpu"`
+	GOMAXPROCS     int      `json:"gomaxprocs"`
+	Goroutines     int      `json:"goroutines"`
+	HeapAlloc      uint64   `json:"heap-alloc"`
+	HeapInuse      uint64   `json:"heap-inuse"`
+	HeapIdle       uint64   `json:"heap-idle"`
+	HeapReleased   uint64   `json:"heap-released"`
+	HeapObjects    uint64   `json:"heap-objects"`
+	Sys            uint64   `json:"sys"`
+	TotalAlloc     uint64   `json:"total-alloc"`
+	NumGC          uint32   `json:"num-gc"`
+	LastGC         string   `json:"last-gc"`
+	GCCPUFraction  float64  `json:"gc-cpu-fraction"`
+	PauseTotal     string   `json:"pause-total"`
+	RecentGCPauses []string `json:"recent-gc-pauses"`
+}
+
+// ReadRuntimeStats 读取当前进程运行时信息，最近 GC 暂停按时间倒序
+func ReadRuntimeStats() RuntimeStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	stats := RuntimeStats{
+		Uptime:        time.Since(startTime).Round(time.Second).String(),
+		GoVersion:     runtime.Version(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     ms.HeapAlloc,
+		HeapInuse:     ms.HeapInuse,
+		HeapIdle:      ms.HeapIdle,
+		HeapReleased:  ms.HeapReleased,
+		HeapObjects:   ms.HeapObjects,
+		Sys:           ms.Sys,
+		TotalAlloc:    ms.TotalAlloc,
+		NumGC:         ms.NumGC,
+		GCCPUFraction: ms.GCCPUFraction,
+		PauseTotal:    time.Duration(ms.PauseTotalNs).String(),
+	}
+	if ms.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(ms.LastGC)).Format(time.RFC3339)
+	}
+	for i := uint32(0); i < ms.NumGC && i < runtimeRecentGCPauses; i++ {
+		stats.RecentGCPauses = append(stats.RecentGCPauses, time.Duration(ms.PauseNs[(ms.NumGC-1-i)%256]).String())
+	}
+	return stats
+}
+
+// RuntimeStatsHandler 运行时诊断接口，与 pprof 以及 /metrics 共用 pprof-port 端口，?gc=true 先执行一次 GC 用于区分内存泄漏与未回收垃圾
+func RuntimeStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("gc") == "true" {
+		runtime.GC()
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ReadRuntimeStats()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -141,6 +141,12 @@ func (r *Assess) Assess() error {
 		return err
 	}
 
+	if r.cfg.DryRun {
+		fmt.Printf("dry-run assess schema %v would gather oracle database report, output [%s]\n",
+			usernameArray, filepath.Join(pwdDir, fileName))
+		return nil
+	}
+
 	file, err := os.OpenFile(filepath.Join(pwdDir, fileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
@@ -1288,7 +1288,7 @@ func AssessOracleSchemaCodeOverview(schemaName []string, oracle *oracle.Oracle)
 }
 
 func AssessOracleSchemaSynonymOverview(schemaName []string, oracle *oracle.Oracle) ([]public.SchemaSynonymObject, public.ReportSummary, error) {
-	overview, err := oracle.GetOracleSchemaCodeObject(schemaName)
+	overview, err := oracle.GetOracleSchemaSynonymObject(schemaName)
 	if err != nil {
 		return nil, public.ReportSummary{}, err
 	}
@@ -1348,6 +1348,7 @@ func AssessOracleSchemaMaterializedViewOverview(schemaName []string, oracle *ora
 			RefreshMode:       ow["REFRESH_MODE"],
 			RefreshMethod:     ow["REFRESH_METHOD"],
 			FastRefreshable:   ow["FAST_REFRESHABLE"],
+			SizeMB:            ow["SIZE_MB"],
 		})
 
 	}
@@ -1363,6 +1364,45 @@ func AssessOracleSchemaMaterializedViewOverview(schemaName []string, oracle *ora
 	}, nil
 }
 
+func AssessOracleSchemaDBLinkOverview(schemaName []string, oracle *oracle.Oracle) ([]public.SchemaDBLinkObject, public.ReportSummary, error) {
+	overview, err := oracle.GetOracleSchemaDBLinkObject(schemaName)
+	if err != nil {
+		return nil, public.ReportSummary{}, err
+	}
+
+	if len(overview) == 0 {
+		return nil, public.ReportSummary{}, nil
+	}
+
+	var listData []public.SchemaDBLinkObject
+	assessComp := 0
+	assessInComp := 0
+	assessConvert := 0
+	assessInConvert := 0
+
+	for _, ow := range overview {
+		listData = append(listData, public.SchemaDBLinkObject{
+			Schema:   ow["OWNER"],
+			DBLink:   ow["DB_LINK"],
+			Username: ow["USERNAME"],
+			Host:     ow["HOST"],
+			Created:  ow["CREATED"],
+		})
+		// DB Link 无法迁移，需手工改造
+		assessInConvert += 1
+	}
+
+	return listData, public.ReportSummary{
+		AssessType:    common.AssessTypeObjectTypeRelated,
+		AssessName:    common.AssessNameSchemaDBLinkObjectRelated,
+		AssessTotal:   len(listData),
+		Compatible:    assessComp,
+		Incompatible:  assessInComp,
+		Convertible:   assessConvert,
+		InConvertible: assessInConvert,
+	}, nil
+}
+
 func AssessOracleSchemaTableAvgRowLengthTOP(schemaName []string, oracle *oracle.Oracle) ([]public.SchemaTableAvgRowLengthTOP, public.ReportSummary, error) {
 
 	synonymInfo, err := oracle.GetOracleSchemaTableAvgRowLengthTOP(schemaName)
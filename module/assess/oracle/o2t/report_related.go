@@ -31,6 +31,7 @@ func GetAssessDatabaseRelatedResult(schemaName []string, oracle *oracle.Oracle)
 		ListSchemaCodeObject             []public.SchemaCodeObject
 		ListSchemaSynonymObject          []public.SchemaSynonymObject
 		ListSchemaMaterializedViewObject []public.SchemaMaterializedViewObject
+		ListSchemaDBLinkObject           []public.SchemaDBLinkObject
 		ListSchemaTableAvgRowLengthTOP   []public.SchemaTableAvgRowLengthTOP
 		ListSchemaTableNumberTypeEqual0  []public.SchemaTableNumberTypeEqual0
 	)
@@ -101,6 +102,16 @@ func GetAssessDatabaseRelatedResult(schemaName []string, oracle *oracle.Oracle)
 	convertibleS += mViewSummary.Convertible
 	inconvertibleS += mViewSummary.InConvertible
 
+	ListSchemaDBLinkObject, dbLinkSummary, err := AssessOracleSchemaDBLinkOverview(schemaName, oracle)
+	if err != nil {
+		return nil, nil, err
+	}
+	assessTotal += dbLinkSummary.AssessTotal
+	compatibleS += dbLinkSummary.Compatible
+	incompatibleS += dbLinkSummary.Incompatible
+	convertibleS += dbLinkSummary.Convertible
+	inconvertibleS += dbLinkSummary.InConvertible
+
 	ListSchemaTableAvgRowLengthTOP, tableTSummary, err := AssessOracleSchemaTableAvgRowLengthTOP(schemaName, oracle)
 	if err != nil {
 		return nil, nil, err
@@ -128,6 +139,7 @@ func GetAssessDatabaseRelatedResult(schemaName []string, oracle *oracle.Oracle)
 			ListSchemaCodeObject:             ListSchemaCodeObject,
 			ListSchemaSynonymObject:          ListSchemaSynonymObject,
 			ListSchemaMaterializedViewObject: ListSchemaMaterializedViewObject,
+			ListSchemaDBLinkObject:           ListSchemaDBLinkObject,
 			ListSchemaTableAvgRowLengthTOP:   ListSchemaTableAvgRowLengthTOP,
 			ListSchemaTableNumberTypeEqual0:  ListSchemaTableNumberTypeEqual0,
 		}, &public.ReportSummary{
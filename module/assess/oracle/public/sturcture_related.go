@@ -24,6 +24,7 @@ type ReportRelated struct {
 	ListSchemaCodeObject             []SchemaCodeObject             `json:"list_schema_code_object"`
 	ListSchemaSynonymObject          []SchemaSynonymObject          `json:"list_schema_synonym_object"`
 	ListSchemaMaterializedViewObject []SchemaMaterializedViewObject `json:"list_schema_materialized_view_object"`
+	ListSchemaDBLinkObject           []SchemaDBLinkObject           `json:"list_schema_db_link_object"`
 	ListSchemaTableAvgRowLengthTOP   []SchemaTableAvgRowLengthTOP   `json:"list_schema_table_avg_row_length_top"`
 	ListSchemaTableNumberTypeEqual0  []SchemaTableNumberTypeEqual0  `json:"list_schema_table_number_type_equal_0"`
 }
@@ -104,6 +105,7 @@ type SchemaMaterializedViewObject struct {
 	RefreshMode       string `json:"refresh_mode"`
 	RefreshMethod     string `json:"refresh_method"`
 	FastRefreshable   string `json:"fast_refreshable"`
+	SizeMB            string `json:"size_mb"`
 }
 
 func (ro *SchemaMaterializedViewObject) String() string {
@@ -111,6 +113,19 @@ func (ro *SchemaMaterializedViewObject) String() string {
 	return string(jsonStr)
 }
 
+type SchemaDBLinkObject struct {
+	Schema   string `json:"schema"`
+	DBLink   string `json:"db_link"`
+	Username string `json:"username"`
+	Host     string `json:"host"`
+	Created  string `json:"created"`
+}
+
+func (ro *SchemaDBLinkObject) String() string {
+	jsonStr, _ := json.Marshal(ro)
+	return string(jsonStr)
+}
+
 type SchemaTableAvgRowLengthTOP struct {
 	Schema       string `json:"schema"`
 	TableName    string `json:"table_name"`
@@ -17,6 +17,7 @@ package check
 
 import (
 	"bufio"
+	"encoding/json"
 	"os"
 	"sync"
 )
@@ -25,6 +26,7 @@ type File struct {
 	CFile   *os.File
 	CWriter *bufio.Writer
 	Mutex   *sync.Mutex
+	Reports []TableReport
 }
 
 func NewWriter(checkFile string) (*File, error) {
@@ -44,6 +46,28 @@ func (f *File) CWriteFile(s string) (nn int, err error) {
 	return f.CWriter.WriteString(s)
 }
 
+// CAppendReport 记录单表结构差异 JSON 报告
+func (f *File) CAppendReport(report TableReport) {
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	f.Reports = append(f.Reports, report)
+}
+
+// WriteJSONReport 输出全部表结构差异 JSON 报告
+func (f *File) WriteJSONReport(jsonFile string) error {
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	reports := f.Reports
+	if reports == nil {
+		reports = []TableReport{}
+	}
+	jsonBytes, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(jsonFile, jsonBytes, 0666)
+}
+
 func (f *File) initOutFile(checkFile string) error {
 	outCheckFile, err := os.OpenFile(checkFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND|os.O_TRUNC, 0666)
 	if err != nil {
@@ -111,6 +111,11 @@ func (r *Check) Check() error {
 		return fmt.Errorf("mysql tables %v isn't exist in the oracle schema [%v], please create", noExistTables, r.cfg.SchemaConfig.TargetSchema)
 	}
 
+	if r.cfg.DryRun {
+		public.DryRunCFGTable(r.cfg, tablesByCfg, sourceTableNameRuleMap)
+		return nil
+	}
+
 	// 清理非当前任务 SUCCESS 表元数据记录 wait_sync_meta (用于统计 SUCCESS 准备)
 	// 例如：当前任务表 A/B，之前任务表 A/C (SUCCESS)，清理元数据 C，对于表 A 任务 Skip 忽略处理，除非手工清理表 A
 	tablesByMeta, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMetaSuccessTables(r.ctx, &meta.WaitSyncMeta{
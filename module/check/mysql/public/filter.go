@@ -17,6 +17,7 @@ package public
 
 import (
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"go.uber.org/zap"
@@ -54,3 +55,16 @@ func FilterCFGTable(cfg *config.Config, mysql *mysql.MySQL) ([]string, []string,
 		zap.String("cost", endTime.Sub(startTime).String()))
 	return normalTables, viewTables, err
 }
+
+// DryRunCFGTable 打印 dry-run 模式下待检查表列表以及对应下游表名，不写元数据以及检查文件
+func DryRunCFGTable(cfg *config.Config, exporters []string, tableNameRuleMap map[string]string) {
+	fmt.Printf("dry-run source schema [%s] target schema [%s] task mode [%s] resolved table counts [%d]\n",
+		cfg.SchemaConfig.SourceSchema, cfg.SchemaConfig.TargetSchema, cfg.TaskMode, len(exporters))
+	for _, t := range exporters {
+		targetTable := t
+		if v, ok := tableNameRuleMap[common.StringUPPER(t)]; ok {
+			targetTable = v
+		}
+		fmt.Printf("%s.%s -> %s.%s\n", cfg.SchemaConfig.SourceSchema, t, cfg.SchemaConfig.TargetSchema, targetTable)
+	}
+}
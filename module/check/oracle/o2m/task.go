@@ -18,6 +18,7 @@ package o2m
 import (
 	"encoding/json"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
@@ -35,6 +36,8 @@ type Task struct {
 	SourceDBCollation     bool   `json:"source_db_collation"`
 	SourceTableCollation  string `json:"source_table_collation"`
 	SourceSchemaCollation string `json:"source_schema_collation"`
+	// 字段投影，nil 代表全部字段
+	ColumnProjection *config.ColumnProjection `json:"-"`
 
 	Oracle *oracle.Oracle `json:"-"`
 	MySQL  *mysql.MySQL   `json:"-"`
@@ -75,6 +78,7 @@ func (t *Task) GenOracleTable() (*public.Table, error) {
 	if err != nil {
 		return info, err
 	}
+	info.ProjectColumns(t.ColumnProjection)
 	return info, nil
 }
 
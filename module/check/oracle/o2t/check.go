@@ -111,6 +111,11 @@ func (r *Check) Check() error {
 		return fmt.Errorf("oracle tables %v isn't exist in the mysqldb schema [%v], please create", noExistTables, r.cfg.SchemaConfig.TargetSchema)
 	}
 
+	if r.cfg.DryRun {
+		public.DryRunCFGTable(r.cfg, tablesByCfg, sourceTableNameRuleMap)
+		return nil
+	}
+
 	// 清理非当前任务 SUCCESS 表元数据记录 wait_sync_meta (用于统计 SUCCESS 准备)
 	// 例如：当前任务表 A/B，之前任务表 A/C (SUCCESS)，清理元数据 C，对于表 A 任务 Skip 忽略处理，除非手工清理表 A
 	tablesByMeta, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMetaSuccessTables(r.ctx, &meta.WaitSyncMeta{
@@ -274,6 +279,14 @@ func (r *Check) Check() error {
 	tasks := GenCheckTaskTable(r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, oracleDBCharacterSet,
 		nlsSort, nlsComp, oracleTableCollation, oracleSchemaCollation, oracleDBCollation,
 		r.oracle, r.mysql, sourceTableNameRuleMap, waitSyncMetas)
+	// BFILE 以及 rowid-mode = skip ROWID/UROWID 下游不创建，不参与表结构校验
+	for _, t := range tasks {
+		skipColumns, err := r.oracle.GetOracleSchemaTableDatatypeColumn(r.cfg.SchemaConfig.SourceSchema, t.SourceTableName, r.cfg.ReverseConfig.SkipColumnDatatypes())
+		if err != nil {
+			return err
+		}
+		t.ColumnProjection = r.cfg.SchemaConfig.GetColumnProjection(t.SourceTableName).Exclude(skipColumns...)
+	}
 
 	err = common.PathExist(r.cfg.CheckConfig.CheckSQLDir)
 	if err != nil {
@@ -367,6 +380,11 @@ func (r *Check) Check() error {
 		return err
 	}
 
+	jsonFile := filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.json", r.cfg.SchemaConfig.SourceSchema))
+	if err = f.WriteJSONReport(jsonFile); err != nil {
+		return err
+	}
+
 	// 任务详情
 	succTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
 		DBTypeS:     r.cfg.DBTypeS,
@@ -389,7 +407,8 @@ func (r *Check) Check() error {
 		return err
 	}
 
-	zap.L().Info("check", zap.String("output", filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.sql", r.cfg.SchemaConfig.SourceSchema))))
+	zap.L().Info("check", zap.String("output", filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.sql", r.cfg.SchemaConfig.SourceSchema))),
+		zap.String("json report", jsonFile))
 	if len(failedTotals) == 0 {
 		zap.L().Info("check table oracle to mysql finished",
 			zap.Int("table totals", len(waitSyncMetas)),
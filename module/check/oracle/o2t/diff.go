@@ -555,74 +555,73 @@ func (c *Diff) Writer(f *check.File) error {
 		zap.String("oracle table", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
 		zap.String("tidb table", fmt.Sprintf("%s.%s", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)))
 
-	var builder strings.Builder
+	var (
+		builder strings.Builder
+		items   []check.ReportItem
+	)
 
-	if !strings.EqualFold(c.CheckPartitionTableType(), "") {
-		builder.WriteString(c.CheckPartitionTableType())
-	}
-	if !strings.EqualFold(c.CheckTableComment(), "") {
-		builder.WriteString(c.CheckTableComment())
-	}
-	if !strings.EqualFold(c.CheckTableCharacterSetAndCollation(), "") {
-		builder.WriteString(c.CheckTableCharacterSetAndCollation())
+	// 文本报告以及 JSON 报告输出
+	addItem := func(checkType, content string) {
+		if !strings.EqualFold(content, "") {
+			builder.WriteString(content)
+			items = append(items, check.NewReportItem(checkType, content))
+		}
 	}
 
+	addItem(check.ReportPartitionTableType, c.CheckPartitionTableType())
+	addItem(check.ReportTableComment, c.CheckTableComment())
+	addItem(check.ReportTableCharsetCollation, c.CheckTableCharacterSetAndCollation())
+
 	counts, err := c.CheckColumnCounts()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(counts, "") {
-		builder.WriteString(counts)
-	}
+	addItem(check.ReportColumnCounts, counts)
 	key, err := c.CheckPrimaryAndUniqueKey()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(key, "") {
-		builder.WriteString(key)
-	}
+	addItem(check.ReportPrimaryUniqueKey, key)
 	foreignKey, err := c.CheckForeignKey()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(foreignKey, "") {
-		builder.WriteString(foreignKey)
-	}
+	addItem(check.ReportForeignKey, foreignKey)
 	checkKey, err := c.CheckCheckKey()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(checkKey, "") {
-		builder.WriteString(checkKey)
-	}
+	addItem(check.ReportCheckKey, checkKey)
 	index, err := c.CheckIndex()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(index, "") {
-		builder.WriteString(index)
-	}
+	addItem(check.ReportIndex, index)
 
 	partitionTable, err := c.CheckPartitionTable()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(partitionTable, "") {
-		builder.WriteString(partitionTable)
-	}
+	addItem(check.ReportPartitionTable, partitionTable)
 
 	column, err := c.CheckColumn()
 	if err != nil {
 		return err
 	}
-	if !strings.EqualFold(column, "") {
-		builder.WriteString(column)
-	}
+	addItem(check.ReportColumn, column)
+
 	// diff 记录不为空
 	if builder.String() != "" {
 		if _, err := f.CWriteFile(builder.String()); err != nil {
 			return err
 		}
+		f.CAppendReport(check.TableReport{
+			SourceSchema: c.OracleTableINFO.SchemaName,
+			SourceTable:  c.OracleTableINFO.TableName,
+			TargetSchema: c.MySQLTableINFO.SchemaName,
+			TargetTable:  c.MySQLTableINFO.TableName,
+			Items:        items,
+		})
 	}
 
 	endTime := time.Now()
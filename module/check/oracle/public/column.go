@@ -18,6 +18,7 @@ package public
 import (
 	"encoding/json"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
 )
 
 type Table struct {
@@ -118,3 +119,51 @@ func (c *Column) String() string {
 	jsonStr, _ := json.Marshal(c)
 	return string(jsonStr)
 }
+
+// ProjectColumns 字段投影，排除字段以及引用排除字段的索引、约束不参与表结构校验，与 reverse 表结构转换保持一致
+func (t *Table) ProjectColumns(projection *config.ColumnProjection) {
+	if projection == nil {
+		return
+	}
+	var columnNames []string
+	for c := range t.Columns {
+		columnNames = append(columnNames, c)
+	}
+	for _, c := range columnNames {
+		if !projection.IsProjected(c) {
+			delete(t.Columns, c)
+		}
+	}
+
+	var indexes []Index
+	for _, idx := range t.Indexes {
+		if projection.IsIndexProjected(idx.IndexColumn, columnNames) {
+			indexes = append(indexes, idx)
+		}
+	}
+	t.Indexes = indexes
+
+	var puConstraints []ConstraintPUKey
+	for _, pu := range t.PUConstraints {
+		if projection.IsColumnListProjected(pu.ConstraintColumn) {
+			puConstraints = append(puConstraints, pu)
+		}
+	}
+	t.PUConstraints = puConstraints
+
+	var fkConstraints []ConstraintForeign
+	for _, fk := range t.ForeignConstraints {
+		if projection.IsColumnListProjected(fk.ColumnName) {
+			fkConstraints = append(fkConstraints, fk)
+		}
+	}
+	t.ForeignConstraints = fkConstraints
+
+	var ckConstraints []ConstraintCheck
+	for _, ck := range t.CheckConstraints {
+		if projection.IsExpressionProjected(ck.ConstraintExpression, columnNames) {
+			ckConstraints = append(ckConstraints, ck)
+		}
+	}
+	t.CheckConstraints = ckConstraints
+}
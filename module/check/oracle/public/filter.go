@@ -54,7 +54,7 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		// 过滤规则加载
 		f, err := filter.Parse(cfg.SchemaConfig.SourceIncludeTable)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("source config params include-table [%v] parse failed: %v", cfg.SchemaConfig.SourceIncludeTable, err)
 		}
 
 		for _, t := range allTables {
@@ -66,7 +66,7 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		// 过滤规则加载
 		f, err := filter.Parse(cfg.SchemaConfig.SourceExcludeTable)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("source config params exclude-table [%v] parse failed: %v", cfg.SchemaConfig.SourceExcludeTable, err)
 		}
 
 		for _, t := range allTables {
@@ -83,6 +83,19 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		return exporterTableSlice, fmt.Errorf("source config params include-table/exclude-table cannot exist at the same time")
 	}
 
+	// view/skip 模式物化视图目标端不存在对应表，排除
+	materializedViews, err := oracle.GetOracleSchemaMaterializedView(common.StringUPPER(cfg.SchemaConfig.SourceSchema))
+	if err != nil {
+		return exporterTableSlice, err
+	}
+	exporterTableSlice, excludeMViews := cfg.FilterMaterializedViewTable(exporterTableSlice, materializedViews)
+	if len(excludeMViews) > 0 {
+		zap.L().Warn("exclude oracle materialized views",
+			zap.String("schema", cfg.SchemaConfig.SourceSchema),
+			zap.Strings("materialized view list", excludeMViews),
+			zap.String("suggest", "materialized view mode isn't table, skip"))
+	}
+
 	if len(exporterTableSlice) == 0 {
 		return exporterTableSlice, fmt.Errorf("exporter tables aren't exist, please check config params include-table/exclude-table")
 	}
@@ -98,3 +111,16 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 
 	return exporterTableSlice, nil
 }
+
+// DryRunCFGTable 打印 dry-run 模式下待检查表列表以及对应下游表名，不写元数据以及检查文件
+func DryRunCFGTable(cfg *config.Config, exporters []string, tableNameRuleMap map[string]string) {
+	fmt.Printf("dry-run source schema [%s] target schema [%s] task mode [%s] resolved table counts [%d]\n",
+		cfg.SchemaConfig.SourceSchema, cfg.SchemaConfig.TargetSchema, cfg.TaskMode, len(exporters))
+	for _, t := range exporters {
+		targetTable := t
+		if v, ok := tableNameRuleMap[common.StringUPPER(t)]; ok {
+			targetTable = v
+		}
+		fmt.Printf("%s.%s -> %s.%s\n", cfg.SchemaConfig.SourceSchema, t, cfg.SchemaConfig.TargetSchema, targetTable)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"strings"
+)
+
+// 表结构检查项
+const (
+	ReportPartitionTableType    = "partition_table_type"
+	ReportTableComment          = "table_comment"
+	ReportTableCharsetCollation = "table_charset_collation"
+	ReportColumnCounts          = "column_counts"
+	ReportPrimaryUniqueKey      = "primary_unique_key"
+	ReportForeignKey            = "foreign_key"
+	ReportCheckKey              = "check_key"
+	ReportIndex                 = "index"
+	ReportPartitionTable        = "partition_table"
+	ReportColumn                = "column"
+)
+
+// TableReport 单表结构差异 JSON 报告
+type TableReport struct {
+	SourceSchema string       `json:"source_schema"`
+	SourceTable  string       `json:"source_table"`
+	TargetSchema string       `json:"target_schema"`
+	TargetTable  string       `json:"target_table"`
+	Items        []ReportItem `json:"items"`
+}
+
+// ReportItem 结构差异检查项，Detail 为差异说明（文本表格），FixSQL 为修复建议语句
+type ReportItem struct {
+	Check  string   `json:"check"`
+	Detail string   `json:"detail"`
+	FixSQL []string `json:"fix_sql"`
+}
+
+// NewReportItem 基于检查项文本输出生成 JSON 检查项，/* */ 注释为差异说明，-- 以及 # 开头为提示信息，其余为修复语句
+func NewReportItem(check, content string) ReportItem {
+	var (
+		details []string
+		fixSQL  []string
+		comment bool
+	)
+	for _, line := range strings.Split(content, "\n") {
+		trimLine := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimLine, "/*"):
+			comment = true
+		case strings.HasPrefix(trimLine, "*/"):
+			comment = false
+		case comment:
+			details = append(details, line)
+		case strings.EqualFold(trimLine, ""):
+		case strings.HasPrefix(trimLine, "--"), strings.HasPrefix(trimLine, "#"):
+			details = append(details, trimLine)
+		default:
+			fixSQL = append(fixSQL, trimLine)
+		}
+	}
+	return ReportItem{
+		Check:  check,
+		Detail: strings.Join(details, "\n"),
+		FixSQL: fixSQL,
+	}
+}
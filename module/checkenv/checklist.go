@@ -0,0 +1,139 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checkenv
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Item 预检查项，Result 取值 PASS/WARN/FAIL，WARN 不影响任务运行但需关注
+type Item struct {
+	Category string
+	Name     string
+	Expect   string
+	Actual   string
+	Result   string
+	Suggest  string
+}
+
+// Checklist 运行环境预检查清单
+type Checklist struct {
+	Items []Item
+}
+
+func (c *Checklist) Pass(category, name, expect, actual string) {
+	c.Items = append(c.Items, Item{Category: category, Name: name, Expect: expect, Actual: actual, Result: common.CheckEnvResultPass})
+}
+
+func (c *Checklist) Warn(category, name, expect, actual, suggest string) {
+	c.Items = append(c.Items, Item{Category: category, Name: name, Expect: expect, Actual: actual, Result: common.CheckEnvResultWarn, Suggest: suggest})
+}
+
+func (c *Checklist) Fail(category, name, expect, actual, suggest string) {
+	c.Items = append(c.Items, Item{Category: category, Name: name, Expect: expect, Actual: actual, Result: common.CheckEnvResultFail, Suggest: suggest})
+}
+
+// Check pass 为 false 按 failResult 记录，failResult 为 WARN 或者 FAIL
+func (c *Checklist) Check(pass bool, failResult, category, name, expect, actual, suggest string) {
+	switch {
+	case pass:
+		c.Pass(category, name, expect, actual)
+	case strings.EqualFold(failResult, common.CheckEnvResultWarn):
+		c.Warn(category, name, expect, actual, suggest)
+	default:
+		c.Fail(category, name, expect, actual, suggest)
+	}
+}
+
+// Report 输出预检查清单至标准输出以及日志，存在 FAIL 项返回错误
+func (c *Checklist) Report() error {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleLight)
+	tw.AppendHeader(table.Row{"#", "CATEGORY", "CHECK ITEM", "EXPECT", "ACTUAL", "RESULT", "SUGGEST"})
+
+	var warns, fails int
+	for i, item := range c.Items {
+		tw.AppendRow(table.Row{i + 1, item.Category, item.Name, item.Expect, item.Actual, item.Result, item.Suggest})
+		switch item.Result {
+		case common.CheckEnvResultWarn:
+			warns++
+		case common.CheckEnvResultFail:
+			fails++
+		}
+		zap.L().Info("check env item",
+			zap.String("category", item.Category),
+			zap.String("item", item.Name),
+			zap.String("expect", item.Expect),
+			zap.String("actual", item.Actual),
+			zap.String("result", item.Result),
+			zap.String("suggest", item.Suggest))
+	}
+	tw.AppendFooter(table.Row{"", "", "", "", "TOTAL", fmt.Sprintf("%d ITEMS", len(c.Items)), fmt.Sprintf("%d WARN / %d FAIL", warns, fails)})
+	fmt.Println(tw.Render())
+
+	if fails > 0 {
+		return fmt.Errorf("check env failed, [%d] items failed, [%d] items warning, please fix the failed items before running task", fails, warns)
+	}
+	zap.L().Info("check env passed", zap.Int("items", len(c.Items)), zap.Int("warns", warns))
+	return nil
+}
+
+// CheckDisk 检查输出目录所在文件系统可用空间，目录不存在时检查最近已存在的上级目录
+// requireBytes 小于等于 0 按 CheckEnvMinDiskFreeMB 检查
+func (c *Checklist) CheckDisk(name, dir string, requireBytes int64) {
+	if dir == "" {
+		return
+	}
+	if requireBytes <= 0 {
+		requireBytes = common.CheckEnvMinDiskFreeMB * 1024 * 1024
+	}
+	expect := fmt.Sprintf("free >= %s", formatBytes(requireBytes))
+
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		c.Warn("DISK", name, expect, err.Error(), fmt.Sprintf("check dir [%s] manually", dir))
+		return
+	}
+	for {
+		if _, err = os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+	free, err := diskFreeBytes(path)
+	if err != nil {
+		c.Warn("DISK", name, expect, err.Error(), fmt.Sprintf("check dir [%s] free space manually", dir))
+		return
+	}
+	c.Check(int64(free) >= requireBytes, common.CheckEnvResultFail, "DISK", name, expect,
+		fmt.Sprintf("%s free on [%s]", formatBytes(int64(free)), path),
+		fmt.Sprintf("clean up or change dir [%s]", dir))
+}
+
+func formatBytes(bytes int64) string {
+	switch {
+	case bytes >= 1024*1024*1024:
+		return fmt.Sprintf("%.2fGB", float64(bytes)/1024/1024/1024)
+	default:
+		return fmt.Sprintf("%.2fMB", float64(bytes)/1024/1024)
+	}
+}
@@ -0,0 +1,27 @@
+// +build windows
+
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checkenv
+
+import (
+	"fmt"
+)
+
+// diskFreeBytes windows 暂不支持获取可用空间，检查项按 WARN 输出
+func diskFreeBytes(dir string) (uint64, error) {
+	return 0, fmt.Errorf("disk free space check isn't support on windows")
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/checkenv"
+	"github.com/wentaojin/transferdb/module/checkenv/oracle/public"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type CheckEnv struct {
+	Ctx context.Context
+	Cfg *config.Config
+}
+
+func NewCheckEnv(ctx context.Context, cfg *config.Config) *CheckEnv {
+	return &CheckEnv{
+		Ctx: ctx,
+		Cfg: cfg,
+	}
+}
+
+// CheckEnv 运行环境预检查，数据库连接失败记录 FAIL 并跳过对应数据库检查项，不写入元数据库
+func (r *CheckEnv) CheckEnv() error {
+	zap.L().Info("check env oracle to mysql start",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+
+	cl := &checkenv.Checklist{}
+
+	oracleDB, err := oracle.NewOracleDBEngine(r.Ctx, r.Cfg.OracleConfig, r.Cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		cl.Fail("ORACLE", "connection", "connected", err.Error(), "check [oracle] config and network")
+	} else {
+		cl.Pass("ORACLE", "connection", "connected", fmt.Sprintf("%s:%d", r.Cfg.OracleConfig.Host, r.Cfg.OracleConfig.Port))
+		defer oracleDB.OracleDB.Close()
+		public.CheckOracleSource(cl, r.Cfg, oracleDB)
+	}
+
+	mysqlDB, err := mysql.NewMySQLDBEngine(r.Ctx, r.Cfg.MySQLConfig)
+	if err != nil {
+		cl.Fail("MYSQL", "connection", "connected", err.Error(), "check [mysql] config and network")
+	} else {
+		cl.Pass("MYSQL", "connection", "connected", fmt.Sprintf("%s:%d", r.Cfg.MySQLConfig.Host, r.Cfg.MySQLConfig.Port))
+		defer mysqlDB.MySQLDB.Close()
+		r.checkTarget(cl, mysqlDB)
+	}
+
+	public.CheckOutputDisk(cl, r.Cfg, oracleDB)
+
+	return cl.Report()
+}
+
+// checkTarget 目标端版本、sql_mode、max_allowed_packet 以及字符集预检查
+func (r *CheckEnv) checkTarget(cl *checkenv.Checklist, mysqlDB *mysql.MySQL) {
+	dbVersion, err := mysqlDB.GetMySQLDBVersion()
+	if err != nil {
+		cl.Fail("MYSQL", "version", fmt.Sprintf(">= %s", common.RequireMySQLDBVersion), err.Error(), "check mysql connection user privileges")
+	} else {
+		// MySQL 版本格式 8.0.32 或者 5.7.40-log
+		cl.Check(common.VersionOrdinal(strings.Split(dbVersion, common.MySQLVersionDelimiter)[0]) >= common.VersionOrdinal(common.RequireMySQLDBVersion), common.CheckEnvResultFail,
+			"MYSQL", "version", fmt.Sprintf(">= %s", common.RequireMySQLDBVersion), dbVersion, "mysql db version isn't support")
+	}
+
+	sqlMode, err := mysqlDB.GetMySQLDBSQLMode()
+	if err != nil {
+		cl.Warn("MYSQL", "sql_mode", "without NO_BACKSLASH_ESCAPES", err.Error(), "check sql_mode manually")
+	} else {
+		// 数据写入语句字符串值按反斜杠转义
+		cl.Check(!strings.Contains(strings.ToUpper(sqlMode), "NO_BACKSLASH_ESCAPES"), common.CheckEnvResultFail,
+			"MYSQL", "sql_mode", "without NO_BACKSLASH_ESCAPES", sqlMode, "remove NO_BACKSLASH_ESCAPES from global sql_mode")
+		cl.Check(strings.Contains(strings.ToUpper(sqlMode), "STRICT_TRANS_TABLES"), common.CheckEnvResultWarn,
+			"MYSQL", "sql_mode strict", "STRICT_TRANS_TABLES", sqlMode, "non-strict sql_mode may silently truncate data")
+	}
+
+	// max_allowed_packet 连接建立时获取，0 表示获取失败
+	if mysqlDB.MaxAllowedPacket == 0 {
+		cl.Warn("MYSQL", "max_allowed_packet", fmt.Sprintf(">= %d", common.CheckEnvMinMaxAllowedPacket), "unknown", "check max_allowed_packet manually")
+	} else {
+		cl.Check(mysqlDB.MaxAllowedPacket >= common.CheckEnvMinMaxAllowedPacket, common.CheckEnvResultWarn,
+			"MYSQL", "max_allowed_packet", fmt.Sprintf(">= %d", common.CheckEnvMinMaxAllowedPacket), fmt.Sprintf("%d", mysqlDB.MaxAllowedPacket),
+			"large lob row or insert-batch-bytes may exceed max_allowed_packet, increase max_allowed_packet")
+		if r.Cfg.AppConfig.InsertBatchBytes > 0 {
+			cl.Check(r.Cfg.AppConfig.InsertBatchBytes <= mysqlDB.MaxAllowedPacket, common.CheckEnvResultWarn,
+				"MYSQL", "insert-batch-bytes", fmt.Sprintf("<= %d", mysqlDB.MaxAllowedPacket), fmt.Sprintf("%d", r.Cfg.AppConfig.InsertBatchBytes),
+				"insert-batch-bytes will be limited by max_allowed_packet")
+		}
+	}
+
+	_, ok := common.MigrateMYSQLCompatibleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.MySQLConfig.Charset)]
+	cl.Check(ok, common.CheckEnvResultFail,
+		"MYSQL", "config charset", "utf8mb4/utf8/gbk/gb18030/big5", r.Cfg.MySQLConfig.Charset, "setting [mysql] charset supported character set")
+	serverCharset, err := mysqlDB.GetMySQLDBServerCharacterSet()
+	if err != nil {
+		cl.Warn("MYSQL", "character_set_server", "supported", err.Error(), "check character_set_server manually")
+	} else {
+		_, ok = common.MigrateMYSQLCompatibleCharsetStringConvertMapping[common.StringUPPER(serverCharset)]
+		cl.Check(ok, common.CheckEnvResultWarn,
+			"MYSQL", "character_set_server", "utf8mb4/utf8/gbk/gb18030/big5", serverCharset, "tables created without charset will use character_set_server")
+	}
+}
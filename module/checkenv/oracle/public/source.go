@@ -0,0 +1,114 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/checkenv"
+	"path/filepath"
+	"strings"
+)
+
+// CheckOracleSource 源端 Oracle 版本、版本类型、权限、归档以及字符集预检查
+// logminer 权限以及归档模式仅 all 模式需要，缺失按 WARN 输出
+func CheckOracleSource(cl *checkenv.Checklist, cfg *config.Config, oracleDB *oracle.Oracle) {
+	oraDBVersion, versionErr := oracleDB.GetOracleDBVersion()
+	if versionErr != nil {
+		cl.Fail("ORACLE", "version", fmt.Sprintf(">= %s", common.RequireOracleDBVersion), versionErr.Error(), "check oracle connection user privileges")
+	} else {
+		cl.Check(common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.RequireOracleDBVersion), common.CheckEnvResultFail,
+			"ORACLE", "version", fmt.Sprintf(">= %s", common.RequireOracleDBVersion), oraDBVersion, "oracle db version isn't support")
+	}
+
+	edition, err := oracleDB.GetOracleSoftVersion()
+	if err != nil {
+		cl.Warn("ORACLE", "edition", "Enterprise Edition", err.Error(), "grant select on v$version")
+	} else {
+		cl.Check(strings.Contains(edition, "Enterprise"), common.CheckEnvResultWarn,
+			"ORACLE", "edition", "Enterprise Edition", edition, "non-enterprise edition may not support parallel chunk and logminer features")
+	}
+
+	privs, err := oracleDB.GetOracleSessionPrivileges()
+	if err != nil {
+		cl.Fail("ORACLE", "privileges", "SELECT ANY DICTIONARY", err.Error(), "check oracle connection user privileges")
+	} else {
+		isDBA := common.IsContainString(privs, "DBA")
+		cl.Check(isDBA || common.IsContainString(privs, "SELECT ANY DICTIONARY"), common.CheckEnvResultFail,
+			"ORACLE", "privilege SELECT ANY DICTIONARY", "granted", fmt.Sprintf("dba: %v", isDBA),
+			fmt.Sprintf("GRANT SELECT ANY DICTIONARY TO %s", strings.ToUpper(cfg.OracleConfig.Username)))
+
+		logminerPrivs := []string{"SELECT ANY TRANSACTION", "EXECUTE_CATALOG_ROLE"}
+		// oracle 12c 及以上 logminer 需 LOGMINING 权限
+		if versionErr == nil && common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.RequireOracleLogminingDBVersion) {
+			logminerPrivs = append(logminerPrivs, "LOGMINING")
+		}
+		for _, p := range logminerPrivs {
+			cl.Check(isDBA || common.IsContainString(privs, p), common.CheckEnvResultWarn,
+				"ORACLE", fmt.Sprintf("privilege %s", p), "granted (all mode)", fmt.Sprintf("dba: %v", isDBA),
+				fmt.Sprintf("GRANT %s TO %s", p, strings.ToUpper(cfg.OracleConfig.Username)))
+		}
+	}
+
+	logMode, err := oracleDB.GetOracleDBLogMode()
+	if err != nil {
+		cl.Warn("ORACLE", "log mode", "ARCHIVELOG (all mode)", err.Error(), "grant select on v$database")
+	} else {
+		cl.Check(strings.EqualFold(logMode, "ARCHIVELOG"), common.CheckEnvResultWarn,
+			"ORACLE", "log mode", "ARCHIVELOG (all mode)", logMode, "SHUTDOWN IMMEDIATE; STARTUP MOUNT; ALTER DATABASE ARCHIVELOG; ALTER DATABASE OPEN;")
+	}
+
+	charset, err := oracleDB.GetOracleDBCharacterSet()
+	if err != nil {
+		cl.Fail("ORACLE", "character set", "supported", err.Error(), "check oracle connection user privileges")
+		return
+	}
+	// 字符集格式 AMERICAN_AMERICA.AL32UTF8
+	dbCharset := charset
+	if s := strings.Split(charset, "."); len(s) == 2 {
+		dbCharset = s[1]
+	}
+	_, ok := common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(dbCharset)]
+	cl.Check(ok, common.CheckEnvResultFail,
+		"ORACLE", "character set", "AL32UTF8/ZHS16GBK/ZHS32GB18030/ZHT16BIG5/WE8ISO8859P1/WE8MSWIN1252", dbCharset, "oracle db character set isn't support")
+	cl.Check(strings.EqualFold(cfg.OracleConfig.Charset, dbCharset), common.CheckEnvResultWarn,
+		"ORACLE", "config charset", dbCharset, cfg.OracleConfig.Charset, "setting [oracle] charset same as oracle db character set")
+}
+
+// CheckOutputDisk 日志、报告、csv 输出、reverse 输出以及隔离目录可用空间预检查
+// csv 输出目录按源端 schema 段大小检查
+func CheckOutputDisk(cl *checkenv.Checklist, cfg *config.Config, oracleDB *oracle.Oracle) {
+	if cfg.LogConfig.LogFile != "" {
+		cl.CheckDisk("log-file dir", filepath.Dir(cfg.LogConfig.LogFile), 0)
+	}
+	cl.CheckDisk("report-dir", cfg.AppConfig.ReportDir, 0)
+	cl.CheckDisk("ddl-reverse-dir", cfg.ReverseConfig.DDLReverseDir, 0)
+	cl.CheckDisk("error-dir", cfg.FullConfig.ErrorDir, 0)
+	if cfg.CSVConfig.OutputDir == "" {
+		return
+	}
+	var requireBytes int64
+	if oracleDB != nil {
+		if tableBytes, err := oracleDB.GetOracleSchemaTableSegmentBytes(cfg.SchemaConfig.SourceSchema); err == nil {
+			for _, b := range tableBytes {
+				requireBytes += b
+			}
+		}
+	}
+	cl.CheckDisk("csv output-dir", cfg.CSVConfig.OutputDir, requireBytes)
+}
@@ -38,6 +38,7 @@ type Compare struct {
 	oracle *oracle.Oracle
 	mysql  *mysql.MySQL
 	metaDB *meta.Meta
+	online *public.OnlineCompare
 }
 
 func NewCompare(ctx context.Context, cfg *config.Config) (*Compare, error) {
@@ -102,6 +103,28 @@ func (r *Compare) NewCompare() error {
 		return nil
 	}
 
+	if r.cfg.DryRun {
+		return public.DryRunCFGTable(r.cfg, r.oracle, exporters)
+	}
+
+	// 行数快速校验
+	if r.cfg.DiffConfig.QuickCheckRows {
+		return r.QuickCheckRows(exporters)
+	}
+
+	// 抽样行级校验
+	if r.cfg.DiffConfig.SpotCheckRows > 0 {
+		return r.SpotCheckRows(exporters, common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion))
+	}
+
+	// 增量同步运行中在线校验，等待增量追平校验 SCN
+	if r.cfg.DiffConfig.OnlineCompare {
+		r.online, err = public.NewOnlineCompare(r.ctx, r.cfg, r.oracle, r.metaDB)
+		if err != nil {
+			return err
+		}
+	}
+
 	// 关于全量断点恢复
 	if !r.cfg.DiffConfig.EnableCheckpoint {
 		err = meta.NewDataCompareMetaModel(r.metaDB).TruncateDataCompareMeta(r.ctx)
@@ -374,8 +397,18 @@ func (r *Compare) NewCompare() error {
 		}
 	}
 
-	partTableTasks := NewPartCompareTableTask(r.ctx, r.cfg, partSyncTables, r.mysql, r.oracle, tableNameRuleMap)
-	waitTableTasks := NewWaitCompareTableTask(r.ctx, r.cfg, waitSyncTables, oracleCollation, r.mysql, r.oracle, tableNameRuleMap)
+	// 获取字段名自定义规则
+	columnNameRuleMap, err := meta.NewColumnNameRuleModel(r.metaDB).DetailColumnNameRuleMap(r.ctx, &meta.ColumnNameRule{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	partTableTasks := NewPartCompareTableTask(r.ctx, r.cfg, partSyncTables, r.mysql, r.oracle, tableNameRuleMap, columnNameRuleMap)
+	waitTableTasks := NewWaitCompareTableTask(r.ctx, r.cfg, waitSyncTables, oracleCollation, r.mysql, r.oracle, tableNameRuleMap, columnNameRuleMap)
 
 	// 数据对比
 	err = common.PathExist(r.cfg.DiffConfig.FixSqlDir)
@@ -504,11 +537,19 @@ func (r *Compare) comparePartTableTasks(f *compare.File, partTableTasks []*Task)
 
 		// 设置工作池
 		// 设置 goroutine 数
+		// LONG/LONG RAW 字段表逐行抽取
+		tableOracle, _, err := r.oracle.WithTableLongFetch(r.cfg.SchemaConfig.SourceSchema, task.sourceTableName)
+		if err != nil {
+			return err
+		}
+
 		g1 := &errgroup.Group{}
 		g1.SetLimit(r.cfg.DiffConfig.DiffThreads)
 
 		for _, compareMeta := range waitCompareMetas {
-			newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows)
+			newReport := NewReport(compareMeta, r.mysql, tableOracle, r.cfg.DiffConfig.OnlyCheckRows)
+			newReport.Online = r.online
+			newReport.ColumnNameRule = task.columnNameRule
 			g1.Go(func() error {
 				// 数据对比报告
 				report, err := public.IReport(newReport)
@@ -0,0 +1,158 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quickRows 表行数快速校验结果
+type quickRows struct {
+	SourceTable string
+	TargetTable string
+	SourceRows  int64
+	TargetRows  int64
+	Range       string
+}
+
+// QuickCheckRows 行数快速校验，表级别 SELECT COUNT(1) 并发对比上下游行数，不切分 chunk、不记录元数据以及不支持断点续检
+// 过滤条件优先 compare-config range，其次 migrate-config range（enable-split = true），保持与数据迁移范围一致
+func (r *Compare) QuickCheckRows(exporters []string) error {
+	startTime := time.Now()
+
+	// 获取表名自定义规则
+	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+		SchemaNameT: r.cfg.SchemaConfig.TargetSchema,
+	})
+	if err != nil {
+		return err
+	}
+	tableNameRuleMap := make(map[string]string)
+	for _, tr := range tableNameRules {
+		tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+	}
+
+	var (
+		mu      sync.Mutex
+		results []quickRows
+	)
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.DiffConfig.DiffThreads)
+
+	for _, t := range exporters {
+		sourceTable := common.StringUPPER(t)
+		g.Go(func() error {
+			targetTable := sourceTable
+			if val, ok := tableNameRuleMap[sourceTable]; ok {
+				targetTable = val
+			}
+			whereRange := r.quickCheckRange(sourceTable)
+
+			oracleRows, err := r.oracle.GetOracleTableActualRows(common.StringsBuilder(
+				"SELECT COUNT(1) FROM ", common.StringUPPER(r.cfg.SchemaConfig.SourceSchema), ".", sourceTable, " WHERE ", whereRange))
+			if err != nil {
+				return fmt.Errorf("oracle table [%s] quick check rows failed: %v", sourceTable, err)
+			}
+			mysqlRows, err := r.mysql.GetMySQLTableActualRows(common.StringsBuilder(
+				"SELECT COUNT(1) FROM ", r.cfg.SchemaConfig.TargetSchema, ".", targetTable, " WHERE ", whereRange))
+			if err != nil {
+				return fmt.Errorf("mysql table [%s] quick check rows failed: %v", targetTable, err)
+			}
+
+			mu.Lock()
+			results = append(results, quickRows{
+				SourceTable: sourceTable,
+				TargetTable: targetTable,
+				SourceRows:  oracleRows,
+				TargetRows:  mysqlRows,
+				Range:       whereRange,
+			})
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].SourceTable < results[j].SourceTable
+	})
+
+	var diffTables []string
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.AppendHeader(table.Row{"SOURCE TABLE", "SOURCE COUNTS", "TARGET TABLE", "TARGET COUNTS", "DIFF", "RANGE"})
+	for _, res := range results {
+		if res.SourceRows == res.TargetRows {
+			continue
+		}
+		diffTables = append(diffTables, res.SourceTable)
+		sw.AppendRow(table.Row{
+			common.StringsBuilder(r.cfg.SchemaConfig.SourceSchema, ".", res.SourceTable),
+			res.SourceRows,
+			common.StringsBuilder(r.cfg.SchemaConfig.TargetSchema, ".", res.TargetTable),
+			res.TargetRows,
+			res.SourceRows - res.TargetRows,
+			res.Range,
+		})
+	}
+
+	if len(diffTables) > 0 {
+		fmt.Printf("oracle schema [%s] and mysql schema [%s] table rows aren't equal:\n%s\n", r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, sw.Render())
+		zap.L().Warn("quick check table rows oracle to mysql finished",
+			zap.Int("table totals", len(exporters)),
+			zap.Int("table equal", len(exporters)-len(diffTables)),
+			zap.Int("table diff", len(diffTables)),
+			zap.Strings("diff tables", diffTables),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+		return nil
+	}
+
+	fmt.Printf("oracle schema [%s] and mysql schema [%s] table rows are all equal, table totals [%d]\n", r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, len(exporters))
+	zap.L().Info("quick check table rows oracle to mysql finished",
+		zap.Int("table totals", len(exporters)),
+		zap.Int("table equal", len(exporters)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (r *Compare) quickCheckRange(sourceTable string) string {
+	for _, tableCfg := range r.cfg.SchemaConfig.CompareConfig {
+		if strings.EqualFold(sourceTable, tableCfg.SourceTable) && !strings.EqualFold(tableCfg.Range, "") {
+			return tableCfg.Range
+		}
+	}
+	for _, tableCfg := range r.cfg.SchemaConfig.MigrateConfig {
+		if strings.EqualFold(sourceTable, tableCfg.SourceTable) && tableCfg.EnableSplit && !strings.EqualFold(tableCfg.Range, "") {
+			return common.StringsBuilder("(", tableCfg.Range, ")")
+		}
+	}
+	return "1 = 1"
+}
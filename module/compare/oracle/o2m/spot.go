@@ -0,0 +1,311 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spotDiff 抽样行级校验差异字段，目标端缺失行 Column 为空
+type spotDiff struct {
+	SourceTable string
+	TargetTable string
+	Key         string
+	Column      string
+	SourceValue string
+	TargetValue string
+}
+
+// SpotCheckRows 抽样行级校验，每张表随机抽样 spot-check-rows 行主键/唯一键，上下游按数据校验字段归一化规则查询整行并逐字段对比
+// 不切分 chunk、不记录元数据以及不生成修复 SQL，用于快速确认数据一致性；表不存在主键/唯一键跳过
+func (r *Compare) SpotCheckRows(exporters []string, oracleCollation bool) error {
+	startTime := time.Now()
+
+	// 获取表名自定义规则
+	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+		SchemaNameT: r.cfg.SchemaConfig.TargetSchema,
+	})
+	if err != nil {
+		return err
+	}
+	tableNameRuleMap := make(map[string]string)
+	for _, tr := range tableNameRules {
+		tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+	}
+	// 获取字段名自定义规则
+	columnNameRuleMap, err := meta.NewColumnNameRuleModel(r.metaDB).DetailColumnNameRuleMap(r.ctx, &meta.ColumnNameRule{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu            sync.Mutex
+		diffs         []spotDiff
+		diffTables    []string
+		skipTables    []string
+		sampleTotals  int
+		tableTotals   = len(exporters)
+		compareTables = NewWaitCompareTableTask(r.ctx, r.cfg, exporters, oracleCollation, r.mysql, r.oracle, tableNameRuleMap, columnNameRuleMap)
+	)
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.DiffConfig.DiffThreads)
+
+	for _, t := range compareTables {
+		task := t
+		g.Go(func() error {
+			tableDiffs, samples, err := r.spotCheckTable(task)
+			if err != nil {
+				return fmt.Errorf("oracle table [%s] spot check rows failed: %v", task.sourceTableName, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if samples < 0 {
+				skipTables = append(skipTables, task.sourceTableName)
+				return nil
+			}
+			sampleTotals += samples
+			if len(tableDiffs) > 0 {
+				diffTables = append(diffTables, task.sourceTableName)
+				diffs = append(diffs, tableDiffs...)
+			}
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	if len(skipTables) > 0 {
+		sort.Strings(skipTables)
+		zap.L().Warn("spot check table rows skip tables without primary key or unique key",
+			zap.Strings("skip tables", skipTables))
+	}
+
+	if len(diffs) > 0 {
+		sort.SliceStable(diffs, func(i, j int) bool {
+			if diffs[i].SourceTable != diffs[j].SourceTable {
+				return diffs[i].SourceTable < diffs[j].SourceTable
+			}
+			return diffs[i].Key < diffs[j].Key
+		})
+		sw := table.NewWriter()
+		sw.SetStyle(table.StyleLight)
+		sw.AppendHeader(table.Row{"SOURCE TABLE", "TARGET TABLE", "KEY", "COLUMN", "SOURCE VALUE", "TARGET VALUE"})
+		for _, d := range diffs {
+			sw.AppendRow(table.Row{
+				common.StringsBuilder(r.cfg.SchemaConfig.SourceSchema, ".", d.SourceTable),
+				common.StringsBuilder(r.cfg.SchemaConfig.TargetSchema, ".", d.TargetTable),
+				d.Key,
+				d.Column,
+				d.SourceValue,
+				d.TargetValue,
+			})
+		}
+		fmt.Printf("oracle schema [%s] and mysql schema [%s] spot check rows aren't equal:\n%s\n", r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, sw.Render())
+		zap.L().Warn("spot check table rows oracle to mysql finished",
+			zap.Int("table totals", tableTotals),
+			zap.Int("table skip", len(skipTables)),
+			zap.Int("table diff", len(diffTables)),
+			zap.Int("sample rows", sampleTotals),
+			zap.Int("diff columns", len(diffs)),
+			zap.Strings("diff tables", diffTables),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+		return nil
+	}
+
+	fmt.Printf("oracle schema [%s] and mysql schema [%s] spot check rows are all equal, table totals [%d] skip [%d] sample rows [%d]\n",
+		r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, tableTotals, len(skipTables), sampleTotals)
+	zap.L().Info("spot check table rows oracle to mysql finished",
+		zap.Int("table totals", tableTotals),
+		zap.Int("table skip", len(skipTables)),
+		zap.Int("sample rows", sampleTotals),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// spotCheckTable 单表抽样行级校验，返回差异字段以及抽样行数，表不存在主键/唯一键返回 -1
+func (r *Compare) spotCheckTable(t *Task) ([]spotDiff, int, error) {
+	keyColumns, err := r.spotCheckKeyColumns(t.sourceTableName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(keyColumns) == 0 {
+		return nil, -1, nil
+	}
+
+	sourceColumnInfo, targetColumnInfo, err := t.AdjustDBSelectColumn()
+	if err != nil {
+		return nil, 0, err
+	}
+	whereRange := r.quickCheckRange(t.sourceTableName)
+
+	// 按统计信息行数折算块抽样比例，抽样 10 倍行数后随机取 spot-check-rows 行，统计信息缺失或者表较小时全表随机
+	sampleRows := r.cfg.DiffConfig.SpotCheckRows
+	numRows, err := r.oracle.GetOracleTableRowsByStatistics(r.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+	if err != nil {
+		return nil, 0, err
+	}
+	var sampleClause string
+	if numRows > 0 {
+		if percent := float64(sampleRows) * 10 / float64(numRows) * 100; percent < 100 {
+			sampleClause = common.StringsBuilder(" SAMPLE BLOCK (", strconv.FormatFloat(percent, 'f', 6, 64), ")")
+		}
+	}
+	oracleQuery := common.StringsBuilder("SELECT * FROM (SELECT ", sourceColumnInfo, " FROM ",
+		common.StringUPPER(r.cfg.SchemaConfig.SourceSchema), ".", t.sourceTableName, sampleClause,
+		" WHERE ", whereRange, " ORDER BY DBMS_RANDOM.VALUE) WHERE ROWNUM <= ", strconv.Itoa(sampleRows))
+
+	sourceColumns, sourceRows, err := r.oracle.GetOracleDataRowValues(oracleQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(sourceRows) == 0 {
+		return nil, 0, nil
+	}
+
+	var keyIndex []int
+	for _, k := range keyColumns {
+		for i, c := range sourceColumns {
+			if strings.EqualFold(c, k) {
+				keyIndex = append(keyIndex, i)
+			}
+		}
+	}
+	if len(keyIndex) != len(keyColumns) {
+		// 主键/唯一键字段不参与数据校验（字段投影排除），无法按键对比
+		return nil, -1, nil
+	}
+
+	// 上下游按键值定位行，键值为归一化后的 MySQL 字面量，NULL 键值行不参与抽样
+	var (
+		keyValues  []string
+		sourceKeys []string
+	)
+	sourceRowMap := make(map[string][]string)
+	for _, row := range sourceRows {
+		var (
+			values  []string
+			hasNULL bool
+		)
+		for _, i := range keyIndex {
+			if row[i] == `NULL` {
+				hasNULL = true
+			}
+			values = append(values, row[i])
+		}
+		if hasNULL {
+			continue
+		}
+		key := strings.Join(values, ",")
+		if _, ok := sourceRowMap[key]; ok {
+			continue
+		}
+		sourceRowMap[key] = row
+		sourceKeys = append(sourceKeys, key)
+		keyValues = append(keyValues, common.StringsBuilder("(", key, ")"))
+	}
+	if len(sourceKeys) == 0 {
+		return nil, 0, nil
+	}
+
+	var quoteKeys []string
+	for _, k := range keyColumns {
+		quoteKeys = append(quoteKeys, common.StringsBuilder("`", t.targetColumnName(k), "`"))
+	}
+	mysqlQuery := common.StringsBuilder("SELECT ", targetColumnInfo, " FROM ", r.cfg.SchemaConfig.TargetSchema, ".", t.targetTableName,
+		" WHERE (", strings.Join(quoteKeys, ","), ") IN (", strings.Join(keyValues, ","), ")")
+	_, targetRows, err := r.mysql.GetMySQLDataRowValues(mysqlQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	targetRowMap := make(map[string][]string)
+	for _, row := range targetRows {
+		var values []string
+		for _, i := range keyIndex {
+			values = append(values, row[i])
+		}
+		targetRowMap[strings.Join(values, ",")] = row
+	}
+
+	var diffs []spotDiff
+	for _, key := range sourceKeys {
+		sourceRow := sourceRowMap[key]
+		targetRow, ok := targetRowMap[key]
+		if !ok {
+			diffs = append(diffs, spotDiff{
+				SourceTable: t.sourceTableName,
+				TargetTable: t.targetTableName,
+				Key:         key,
+				SourceValue: strings.Join(sourceRow, ","),
+				TargetValue: "ROW NOT EXIST",
+			})
+			continue
+		}
+		for i, col := range sourceColumns {
+			if sourceRow[i] != targetRow[i] {
+				diffs = append(diffs, spotDiff{
+					SourceTable: t.sourceTableName,
+					TargetTable: t.targetTableName,
+					Key:         key,
+					Column:      col,
+					SourceValue: sourceRow[i],
+					TargetValue: targetRow[i],
+				})
+			}
+		}
+	}
+	return diffs, len(sourceKeys), nil
+}
+
+// spotCheckKeyColumns 抽样校验定位键，优先主键其次唯一键
+func (r *Compare) spotCheckKeyColumns(sourceTable string) ([]string, error) {
+	keys, err := r.oracle.GetOracleSchemaTablePrimaryKey(r.cfg.SchemaConfig.SourceSchema, sourceTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		keys, err = r.oracle.GetOracleSchemaTableUniqueKey(r.cfg.SchemaConfig.SourceSchema, sourceTable)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	var keyColumns []string
+	for _, c := range strings.Split(keys[0]["COLUMN_LIST"], ",") {
+		keyColumns = append(keyColumns, common.StringUPPER(c))
+	}
+	return keyColumns, nil
+}
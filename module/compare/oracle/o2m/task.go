@@ -37,11 +37,13 @@ type Task struct {
 	sourceTableName string
 	targetTableName string
 	oracleCollation bool
-	mysql           *mysql.MySQL
-	oracle          *oracle.Oracle
+	// 字段名映射规则，源端字段名 -> 目标字段名
+	columnNameRule map[string]string
+	mysql          *mysql.MySQL
+	oracle         *oracle.Oracle
 }
 
-func NewPartCompareTableTask(ctx context.Context, cfg *config.Config, compareTables []string, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string) []*Task {
+func NewPartCompareTableTask(ctx context.Context, cfg *config.Config, compareTables []string, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string, columnNameRule map[string]map[string]string) []*Task {
 	var tasks []*Task
 	for _, table := range compareTables {
 		// 库名、表名规则
@@ -56,6 +58,7 @@ func NewPartCompareTableTask(ctx context.Context, cfg *config.Config, compareTab
 			cfg:             cfg,
 			sourceTableName: table,
 			targetTableName: targetTableName,
+			columnNameRule:  columnNameRule[common.StringUPPER(table)],
 			mysql:           mysql,
 			oracle:          oracle,
 		})
@@ -64,7 +67,7 @@ func NewPartCompareTableTask(ctx context.Context, cfg *config.Config, compareTab
 }
 
 func NewWaitCompareTableTask(ctx context.Context, cfg *config.Config, compareTables []string, oracleCollation bool, mysql *mysql.MySQL, oracle *oracle.Oracle,
-	tableNameRule map[string]string) []*Task {
+	tableNameRule map[string]string, columnNameRule map[string]map[string]string) []*Task {
 	var tasks []*Task
 	for _, table := range compareTables {
 		// 库名、表名规则
@@ -80,6 +83,7 @@ func NewWaitCompareTableTask(ctx context.Context, cfg *config.Config, compareTab
 			sourceTableName: table,
 			targetTableName: targetTableName,
 			oracleCollation: oracleCollation,
+			columnNameRule:  columnNameRule[common.StringUPPER(table)],
 			mysql:           mysql,
 			oracle:          oracle,
 		})
@@ -116,7 +120,7 @@ func PreTableStructCheck(ctx context.Context, cfg *config.Config, metaDB *meta.M
 		})
 
 		if errTotals != 0 || err != nil {
-			return fmt.Errorf("compare schema [%s] mode [%s] table structure task failed: %v, please check log, error: %v", strings.ToUpper(cfg.SchemaConfig.SourceSchema), cfg.TaskMode, err)
+			return fmt.Errorf("compare schema [%s] mode [%s] table structure task failed: %v, please check log, error log counts: %d", strings.ToUpper(cfg.SchemaConfig.SourceSchema), cfg.TaskMode, err, errTotals)
 		}
 		endTime := time.Now()
 		zap.L().Info("pre check schema oracle to mysql finished",
@@ -139,43 +143,74 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 	if err != nil {
 		return sourceColumnInfo, targetColumnInfo, err
 	}
+	// 字段投影，排除字段不参与数据校验
+	projection, err := t.columnProjection()
+	if err != nil {
+		return sourceColumnInfo, targetColumnInfo, err
+	}
+	columnInfo = projection.ProjectColumnINFO(columnInfo)
 
 	for _, colsInfo := range columnInfo {
 		colName := colsInfo["COLUMN_NAME"]
+		// 字段名映射，下游字段按目标字段名查询
+		colNameT := t.targetColumnName(colName)
+		// interval-mode numeric 下游数值按 NUMBER 方式对比，raw-mode hex 下游十六进制字符串
+		if expr, ok := common.GenOracleDatatypeStrategyColumn(colName, colsInfo["DATA_TYPE"], t.cfg.ReverseConfig.IntervalMode, t.cfg.ReverseConfig.RawMode); ok {
+			if strings.Contains(common.StringUPPER(colsInfo["DATA_TYPE"]), "INTERVAL") {
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", expr, ",1,1),'.','0' || ", expr, ",", expr, ") AS ", colName))
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colNameT, " AS CHAR) AS CHAR) AS ", colNameT))
+			} else {
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(", expr, ",'') AS ", colName))
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colNameT, ",'') AS ", colNameT))
+			}
+			continue
+		}
+		// float-epsilon 浮点容差归一化
+		if sourceExpr, targetExpr, ok := common.GenCompareFloatColumn(colName, colNameT, colsInfo["DATA_TYPE"], t.cfg.DiffConfig.FloatEpsilon); ok {
+			sourceColumnInfos = append(sourceColumnInfos, sourceExpr)
+			targetColumnInfos = append(targetColumnInfos, targetExpr)
+			continue
+		}
 		switch strings.ToUpper(colsInfo["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", colName, ",1,1),'.','0' || ", colName, ",", colName, ") AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colName, " AS CHAR) AS CHAR) AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colNameT, " AS CHAR) AS CHAR) AS ", colNameT))
 		case "DECIMAL", "DEC", "DOUBLE PRECISION", "FLOAT", "INTEGER", "INT", "REAL", "NUMERIC", "BINARY_FLOAT", "BINARY_DOUBLE", "SMALLINT":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", colName, ",1,1),'.','0' || ", colName, ",", colName, ") AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colName, " AS CHAR) AS CHAR) AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colNameT, " AS CHAR) AS CHAR) AS ", colNameT))
 		// 字符
-		case "BFILE", "CHARACTER", "LONG", "NCHAR VARYING", "ROWID", "UROWID", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "NCLOB", "CLOB":
+		// LONG 字段不支持函数调用，NULL 与空字符串统一 NULL 处理
+		case "LONG":
+			sourceColumnInfos = append(sourceColumnInfos, colName)
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colNameT, ",'') AS ", colNameT))
+		case "BFILE", "CHARACTER", "NCHAR VARYING", "ROWID", "UROWID", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "NCLOB", "CLOB":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(", colName, ",'') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colName, ",'') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colNameT, ",'') AS ", colNameT))
 		case "XMLTYPE":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(XMLSERIALIZE(CONTENT ", colName, " AS CLOB),'') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colName, ",'') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colNameT, ",'') AS ", colNameT))
 		// 二进制
 		case "BLOB", "LONG RAW", "RAW":
 			sourceColumnInfos = append(sourceColumnInfos, colName)
-			targetColumnInfos = append(targetColumnInfos, colName)
+			targetColumnInfos = append(targetColumnInfos, colNameT)
 		// 时间
 		case "DATE":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("DATE_FORMAT(", colName, ",'%Y-%m-%d %H:%i:%s') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("DATE_FORMAT(", colNameT, ",'%Y-%m-%d %H:%i:%s') AS ", colNameT))
 		// 默认其他类型
 		default:
 			if strings.Contains(colsInfo["DATA_TYPE"], "INTERVAL") {
 				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ") AS ", colName))
-				targetColumnInfos = append(targetColumnInfos, colName)
+				targetColumnInfos = append(targetColumnInfos, colNameT)
 			} else if strings.Contains(colsInfo["DATA_TYPE"], "TIMESTAMP") {
-				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
-				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("FROM_UNIXTIME(UNIX_TIMESTAMP(", colName, "),'%Y-%m-%d %H:%i:%s') AS ", colName))
+				// timestamp-precision 截断小数秒位数
+				sourceExpr, targetExpr := common.GenCompareTimestampColumn(colName, colNameT, t.cfg.DiffConfig.TimestampPrecision)
+				sourceColumnInfos = append(sourceColumnInfos, sourceExpr)
+				targetColumnInfos = append(targetColumnInfos, targetExpr)
 			} else {
 				sourceColumnInfos = append(sourceColumnInfos, colName)
-				targetColumnInfos = append(targetColumnInfos, colName)
+				targetColumnInfos = append(targetColumnInfos, colNameT)
 			}
 		}
 	}
@@ -186,11 +221,37 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 	return sourceColumnInfo, targetColumnInfo, nil
 }
 
+// 下游字段名，字段名映射规则不存在返回源端字段名
+func (t *Task) targetColumnName(columnName string) string {
+	if val, ok := t.columnNameRule[common.StringUPPER(columnName)]; ok {
+		return val
+	}
+	return columnName
+}
+
 // 筛选 NUMBER 字段以及判断表是否存在主键/唯一键/唯一索引
 // 第一优先级配置文件指定字段【忽略是否存在索引】
 // 第二优先级任意取某个主键/唯一索引 NUMBER 字段
 // 第三优先级取某个唯一性 DISTINCT 高的索引 NUMBER 字段
 // 如果表没有索引 NUMBER 字段或者没有 NUMBER 字段则报错
+// 字段投影，virtual-column-mode skip 下游不存在虚拟列，虚拟列不参与数据校验
+func (t *Task) columnProjection() (*config.ColumnProjection, error) {
+	// BFILE 以及 rowid-mode = skip ROWID/UROWID 下游不存在，不参与数据校验
+	skipColumns, err := t.oracle.GetOracleSchemaTableDatatypeColumn(t.cfg.SchemaConfig.SourceSchema, t.sourceTableName, t.cfg.ReverseConfig.SkipColumnDatatypes())
+	if err != nil {
+		return nil, err
+	}
+	projection := t.cfg.SchemaConfig.GetColumnProjection(t.sourceTableName).Exclude(skipColumns...)
+	if !strings.EqualFold(t.cfg.ReverseConfig.VirtualColumnMode, common.ReverseVirtualColumnModeSkip) {
+		return projection, nil
+	}
+	virtualColumns, err := t.oracle.GetOracleSchemaTableVirtualColumn(t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+	if err != nil {
+		return nil, err
+	}
+	return projection.Exclude(virtualColumns...), nil
+}
+
 func (t *Task) FilterDBWhereColumn() (string, error) {
 	// 以参数配置文件 indexFiledName 忽略是否存在索引，需要人工确认
 	// 字段筛选优先级：配置文件优先级 > PK > UK > Index > Distinct Value
@@ -200,6 +261,12 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	// 排除字段不作为对比切分字段
+	projection, err := t.columnProjection()
+	if err != nil {
+		return "", err
+	}
+	columnInfo = projection.ProjectColumnINFO(columnInfo)
 
 	// number 数据类型字段
 	var integerColumns []string
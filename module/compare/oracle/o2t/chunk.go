@@ -254,18 +254,27 @@ func (c *Chunk) Split() error {
 
 	taskName := common.StringsBuilder(common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), `_`, c.SourceTable, `_`, `TASK`, strconv.Itoa(c.ChunkID))
 
-	if err = c.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
-		return err
-	}
+	var chunkRes []map[string]string
+	// ADG 备库只读不支持 DBMS_PARALLEL_EXECUTE，基于 NTILE 等分切分 chunk
+	if c.Oracle.Standby {
+		chunkRes, err = c.Oracle.GetOracleStandbyTableChunksByNUMBER(common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(c.SourceTable), c.WhereColumn, tableRowsByStatistics, c.Cfg.DiffConfig.ChunkSize)
+		if err != nil {
+			return err
+		}
+	} else {
+		if err = c.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
+			return err
+		}
 
-	err = c.Oracle.StartOracleCreateChunkByNUMBER(taskName, common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(c.SourceTable), c.WhereColumn, strconv.Itoa(c.Cfg.DiffConfig.ChunkSize))
-	if err != nil {
-		return err
-	}
+		err = c.Oracle.StartOracleCreateChunkByNUMBER(taskName, common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(c.SourceTable), c.WhereColumn, strconv.Itoa(c.Cfg.DiffConfig.ChunkSize))
+		if err != nil {
+			return err
+		}
 
-	chunkRes, err := c.Oracle.GetOracleTableChunksByNUMBER(taskName, c.WhereColumn)
-	if err != nil {
-		return err
+		chunkRes, err = c.Oracle.GetOracleTableChunksByNUMBER(taskName, c.WhereColumn)
+		if err != nil {
+			return err
+		}
 	}
 
 	// 判断数据是否存在，更新 data_diff_meta 记录
@@ -386,8 +395,10 @@ func (c *Chunk) Split() error {
 		return fmt.Errorf("create table [%s.%s] data_diff_meta [batch size] failed: %v", common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), c.SourceTable, err)
 	}
 
-	if err = c.Oracle.CloseOracleChunkTask(taskName); err != nil {
-		return err
+	if !c.Oracle.Standby {
+		if err = c.Oracle.CloseOracleChunkTask(taskName); err != nil {
+			return err
+		}
 	}
 
 	endTime := time.Now()
@@ -17,6 +17,7 @@ package o2t
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/scylladb/go-set/strset"
@@ -25,8 +26,11 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -38,10 +42,14 @@ type DBSummary struct {
 }
 
 type Report struct {
-	DataCompareMeta meta.DataCompareMeta `json:"data_compare_meta"`
-	Mysql           *mysql.MySQL         `json:"-"`
-	Oracle          *oracle.Oracle       `json:"-"`
-	OnlyCheckRows   bool                 `json:"only_check_rows"`
+	DataCompareMeta meta.DataCompareMeta  `json:"data_compare_meta"`
+	Mysql           *mysql.MySQL          `json:"-"`
+	Oracle          *oracle.Oracle        `json:"-"`
+	OnlyCheckRows   bool                  `json:"only_check_rows"`
+	SCN             uint64                `json:"scn"`
+	Online          *public.OnlineCompare `json:"-"`
+	// 字段名映射规则，源端字段名 -> 目标字段名
+	ColumnNameRule map[string]string `json:"column_name_rule"`
 }
 
 func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle *oracle.Oracle, onlyCheckRows bool) *Report {
@@ -56,21 +64,43 @@ func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle
 func (r *Report) GenDBQuery() (oracleQuery string, mysqlQuery string) {
 	if r.DataCompareMeta.WhereColumn == "" {
 		oracleQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, r.flashbackClause(), " WHERE ", r.DataCompareMeta.WhereRange)
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.targetTable(), " WHERE ", r.DataCompareMeta.WhereRange)
 	} else {
 		oracleQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange,
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, r.flashbackClause(), " WHERE ", r.DataCompareMeta.WhereRange,
 			" ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.targetTable(), " WHERE ", r.DataCompareMeta.WhereRange, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 	}
 	return
 }
 
+// targetTable 下游查询表，存在字段名映射规则时子查询按源端字段名暴露映射字段，chunk 范围条件以及排序字段沿用源端字段名
+func (r *Report) targetTable() string {
+	tableName := common.StringsBuilder(r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT)
+	if len(r.ColumnNameRule) == 0 {
+		return tableName
+	}
+	var columns []string
+	for columnS, columnT := range r.ColumnNameRule {
+		columns = append(columns, common.StringsBuilder("`", columnT, "` AS `", columnS, "`"))
+	}
+	sort.Strings(columns)
+	return common.StringsBuilder("(SELECT *,", strings.Join(columns, ","), " FROM ", tableName, ") T")
+}
+
+// flashbackClause 在线数据校验上游按 SCN 闪回查询
+func (r *Report) flashbackClause() string {
+	if r.SCN == 0 {
+		return ""
+	}
+	return common.StringsBuilder(" AS OF SCN ", strconv.FormatUint(r.SCN, 10))
+}
+
 func (r *Report) CheckOracleRows(oracleQuery string) (int64, error) {
 	rows, err := r.Oracle.GetOracleTableActualRows(oracleQuery)
 	if err != nil {
@@ -237,11 +267,41 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 	//上游不存在，下游不存在 Skip
 	//上游存在，下游不存在 INSERT 下游
 	//上游不存在，下游存在 DELETE 下游
+	//上游存在，下游存在且主键/唯一键相同 UPDATE 下游
 
 	var fixSQL strings.Builder
 
-	// 判断下游数据是否多
 	targetMore := strset.Difference(mysqlReport.StringSet, oraReport.StringSet).List()
+	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
+
+	// 判断上下游键值相同数据是否不一致
+	updateRows, targetMore, sourceMore, err := r.PairUpdateRows(mysqlReport.Columns, targetMore, sourceMore)
+	if err != nil {
+		return "", err
+	}
+	if len(updateRows) > 0 {
+		fixSQL.WriteString("/*\n")
+		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are different \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
+
+		sw := table.NewWriter()
+		sw.SetStyle(table.StyleLight)
+		sw.AppendHeader(table.Row{"DATABASE", "DATA COUNTS SQL", "CRC32"})
+		sw.AppendRows([]table.Row{
+			{"ORACLE",
+				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				oraReport.Crc32Val},
+			{"MySQL", common.StringsBuilder(
+				"SELECT COUNT(1)", " FROM ", r.targetTable(), " WHERE ", r.DataCompareMeta.WhereRange),
+				mysqlReport.Crc32Val},
+		})
+		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
+		fixSQL.WriteString("*/\n")
+		for _, u := range updateRows {
+			fixSQL.WriteString(fmt.Sprintf("%v;\n", u))
+		}
+	}
+
+	// 判断下游数据是否多
 	if len(targetMore) > 0 {
 		fixSQL.WriteString("/*\n")
 		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are more \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
@@ -254,22 +314,22 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.targetTable(), " WHERE ", r.DataCompareMeta.WhereRange),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
 		fixSQL.WriteString("*/\n")
-		deletePrefix := common.StringsBuilder("DELETE FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ")
+		deletePrefix := common.StringsBuilder("DELETE FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ")
 		for _, t := range targetMore {
 			var whereCond []string
 
 			// 计算字段列个数
 			colValues := strings.Split(t, ",")
 			if len(mysqlReport.Columns) != len(colValues) {
-				return "", fmt.Errorf("tidb schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, len(mysqlReport.Columns), len(colValues))
+				return "", fmt.Errorf("tidb schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, len(mysqlReport.Columns), len(colValues))
 			}
 			for i := 0; i < len(mysqlReport.Columns); i++ {
-				whereCond = append(whereCond, common.StringsBuilder(mysqlReport.Columns[i], "=", colValues[i]))
+				whereCond = append(whereCond, genFixWhereCond(mysqlReport.Columns[i], colValues[i]))
 			}
 
 			fixSQL.WriteString(fmt.Sprintf("%v;\n", common.StringsBuilder(deletePrefix, exstrings.Join(whereCond, " AND "))))
@@ -277,10 +337,9 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 	}
 
 	// 判断上游数据是否多
-	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
 	if len(sourceMore) > 0 {
 		fixSQL.WriteString("/*\n")
-		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, r.DataCompareMeta.WhereRange))
+		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
 
 		sw := table.NewWriter()
 		sw.SetStyle(table.StyleLight)
@@ -290,12 +349,12 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.targetTable(), " WHERE ", r.DataCompareMeta.WhereRange),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
 		fixSQL.WriteString("*/\n")
-		insertPrefix := common.StringsBuilder("INSERT INTO ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " (", strings.Join(oraReport.Columns, ","), ") VALUES (")
+		insertPrefix := common.StringsBuilder("INSERT INTO ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " (", strings.Join(mysqlReport.Columns, ","), ") VALUES (")
 		for _, s := range sourceMore {
 			fixSQL.WriteString(fmt.Sprintf("%v;\n", common.StringsBuilder(insertPrefix, s, ")")))
 		}
@@ -303,7 +362,177 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 	return fixSQL.String(), nil
 }
 
+// PairUpdateRows 下游表存在主键或者唯一键时，按键值匹配上下游差异数据行生成 UPDATE 修复语句，返回未匹配的下游多余行以及上游多余行
+// 键值存在 NULL 的数据行不参与匹配，沿用 DELETE/INSERT 修复
+func (r *Report) PairUpdateRows(columns, targetMore, sourceMore []string) ([]string, []string, []string, error) {
+	if len(targetMore) == 0 || len(sourceMore) == 0 {
+		return nil, targetMore, sourceMore, nil
+	}
+
+	keyIndex, err := r.getTargetKeyIndex(columns)
+	if err != nil {
+		return nil, targetMore, sourceMore, err
+	}
+	if len(keyIndex) == 0 {
+		return nil, targetMore, sourceMore, nil
+	}
+	keyIndexSet := make(map[int]struct{})
+	for _, idx := range keyIndex {
+		keyIndexSet[idx] = struct{}{}
+	}
+
+	genKey := func(colValues []string) (string, bool) {
+		var keys []string
+		for _, idx := range keyIndex {
+			if strings.EqualFold(colValues[idx], "NULL") {
+				return "", false
+			}
+			keys = append(keys, colValues[idx])
+		}
+		return exstrings.Join(keys, ","), true
+	}
+
+	targetKeyMap := make(map[string][]string)
+	for _, t := range targetMore {
+		colValues := strings.Split(t, ",")
+		if len(columns) != len(colValues) {
+			return nil, targetMore, sourceMore, fmt.Errorf("tidb schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, len(columns), len(colValues))
+		}
+		if key, ok := genKey(colValues); ok {
+			targetKeyMap[key] = colValues
+		}
+	}
+
+	var (
+		updateRows   []string
+		restSource   []string
+		restTarget   []string
+		matchTargets = make(map[string]struct{})
+	)
+	for _, s := range sourceMore {
+		colValues := strings.Split(s, ",")
+		if len(columns) != len(colValues) {
+			return nil, targetMore, sourceMore, fmt.Errorf("oracle schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, len(columns), len(colValues))
+		}
+		key, ok := genKey(colValues)
+		if !ok {
+			restSource = append(restSource, s)
+			continue
+		}
+		targetValues, exist := targetKeyMap[key]
+		if _, match := matchTargets[key]; !exist || match {
+			restSource = append(restSource, s)
+			continue
+		}
+		matchTargets[key] = struct{}{}
+
+		var setCond, whereCond []string
+		for i := 0; i < len(columns); i++ {
+			if _, ok := keyIndexSet[i]; ok {
+				whereCond = append(whereCond, genFixWhereCond(columns[i], colValues[i]))
+				continue
+			}
+			if colValues[i] != targetValues[i] {
+				setCond = append(setCond, common.StringsBuilder(columns[i], "=", colValues[i]))
+			}
+		}
+		updateRows = append(updateRows, common.StringsBuilder("UPDATE ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT,
+			" SET ", exstrings.Join(setCond, ","), " WHERE ", exstrings.Join(whereCond, " AND ")))
+	}
+
+	for _, t := range targetMore {
+		if key, ok := genKey(strings.Split(t, ",")); ok {
+			if _, match := matchTargets[key]; match {
+				continue
+			}
+		}
+		restTarget = append(restTarget, t)
+	}
+	return updateRows, restTarget, restSource, nil
+}
+
+// 获取下游表主键字段位置，不存在主键取第一个唯一键
+func (r *Report) getTargetKeyIndex(columns []string) ([]int, error) {
+	keys, err := r.Mysql.GetMySQLTablePrimaryKey(r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		keys, err = r.Mysql.GetMySQLTableUniqueKey(r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var keyIndex []int
+	for _, keyCol := range strings.Split(keys[0]["COLUMN_LIST"], ",") {
+		idx := -1
+		for i, col := range columns {
+			if strings.EqualFold(strings.Trim(col, "`"), keyCol) {
+				idx = i
+				break
+			}
+		}
+		// 键字段不在对比字段内，无法匹配
+		if idx == -1 {
+			return nil, nil
+		}
+		keyIndex = append(keyIndex, idx)
+	}
+	return keyIndex, nil
+}
+
+// 修复语句 WHERE 条件，NULL 值使用 IS NULL
+func genFixWhereCond(column, value string) string {
+	if strings.EqualFold(value, "NULL") {
+		return common.StringsBuilder(column, " IS NULL")
+	}
+	return common.StringsBuilder(column, "=", value)
+}
+
 func (r *Report) Report() (string, error) {
+	if r.Online == nil {
+		return r.report()
+	}
+
+	// 在线数据校验，chunk 不一致可能是下游增量对比期间继续应用，等待已应用 SCN 推进后按新 SCN 重新对比
+	if r.SCN == 0 {
+		r.SCN = r.Online.SCN
+	}
+	for attempt := 0; ; attempt++ {
+		report, err := r.report()
+		if err != nil || report == "" || attempt >= r.Online.Retries {
+			return report, err
+		}
+		scn, err := r.Online.NextSCN(r.SCN)
+		if err != nil {
+			// 等待超时不再重试，记录当前差异
+			if errors.Is(err, public.ErrOnlineCompareTimeout) {
+				zap.L().Warn("online compare chunk isn't equal, wait next scn timeout, record diff",
+					zap.String("schema", r.DataCompareMeta.SchemaNameS),
+					zap.String("table", r.DataCompareMeta.TableNameS),
+					zap.String("range", r.DataCompareMeta.WhereRange),
+					zap.Uint64("scn", r.SCN),
+					zap.Error(err))
+				return report, nil
+			}
+			return "", err
+		}
+		zap.L().Warn("online compare chunk isn't equal, recompare by new scn",
+			zap.String("schema", r.DataCompareMeta.SchemaNameS),
+			zap.String("table", r.DataCompareMeta.TableNameS),
+			zap.String("range", r.DataCompareMeta.WhereRange),
+			zap.Uint64("scn", r.SCN),
+			zap.Uint64("new scn", scn),
+			zap.Int("attempt", attempt+1))
+		r.SCN = scn
+	}
+}
+
+func (r *Report) report() (string, error) {
 	if r.OnlyCheckRows {
 		return r.ReportCheckRows()
 	}
@@ -54,7 +54,7 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		// 过滤规则加载
 		f, err := filter.Parse(cfg.SchemaConfig.SourceIncludeTable)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("source config params include-table [%v] parse failed: %v", cfg.SchemaConfig.SourceIncludeTable, err)
 		}
 
 		for _, t := range allTables {
@@ -66,7 +66,7 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		// 过滤规则加载
 		f, err := filter.Parse(cfg.SchemaConfig.SourceExcludeTable)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("source config params exclude-table [%v] parse failed: %v", cfg.SchemaConfig.SourceExcludeTable, err)
 		}
 
 		for _, t := range allTables {
@@ -83,6 +83,19 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		return exporterTableSlice, fmt.Errorf("source config params include-table/exclude-table cannot exist at the same time")
 	}
 
+	// view/skip 模式物化视图目标端不存在对应表，排除
+	materializedViews, err := oracle.GetOracleSchemaMaterializedView(common.StringUPPER(cfg.SchemaConfig.SourceSchema))
+	if err != nil {
+		return exporterTableSlice, err
+	}
+	exporterTableSlice, excludeMViews := cfg.FilterMaterializedViewTable(exporterTableSlice, materializedViews)
+	if len(excludeMViews) > 0 {
+		zap.L().Warn("exclude oracle materialized views",
+			zap.String("schema", cfg.SchemaConfig.SourceSchema),
+			zap.Strings("materialized view list", excludeMViews),
+			zap.String("suggest", "materialized view mode isn't table, skip"))
+	}
+
 	if len(exporterTableSlice) == 0 {
 		return exporterTableSlice, fmt.Errorf("exporter tables aren't exist, please check config params include-table/exclude-table")
 	}
@@ -98,3 +111,31 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 
 	return exporterTableSlice, nil
 }
+
+// DryRunCFGTable 打印 dry-run 模式下待校验表列表、预估行数以及 chunk 切分计划，不写元数据以及修复文件
+func DryRunCFGTable(cfg *config.Config, oracle *oracle.Oracle, exporters []string) error {
+	chunkSize := cfg.DiffConfig.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 1
+	}
+	fmt.Printf("dry-run source schema [%s] target schema [%s] task mode [%s] resolved table counts [%d] diff-threads [%d] chunk-size [%d]\n",
+		cfg.SchemaConfig.SourceSchema, cfg.SchemaConfig.TargetSchema, cfg.TaskMode, len(exporters), cfg.DiffConfig.DiffThreads, chunkSize)
+
+	var totalRows, totalChunks int
+	for _, t := range exporters {
+		rows, err := oracle.GetOracleTableRowsByStatistics(cfg.SchemaConfig.SourceSchema, t)
+		if err != nil {
+			return err
+		}
+		chunks := 1
+		if rows > chunkSize {
+			chunks = (rows + chunkSize - 1) / chunkSize
+		}
+		totalRows += rows
+		totalChunks += chunks
+		fmt.Printf("table [%s] estimated rows [%d] estimated chunks [%d] only-check-rows [%v]\n",
+			t, rows, chunks, cfg.DiffConfig.OnlyCheckRows)
+	}
+	fmt.Printf("dry-run totals: tables [%d] estimated rows [%d] estimated chunks [%d]\n", len(exporters), totalRows, totalChunks)
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ErrOnlineCompareTimeout 等待增量同步已应用 SCN 超时
+var ErrOnlineCompareTimeout = errors.New("online compare wait increment sync applied scn timeout")
+
+// OnlineCompare 增量同步运行中数据校验
+// 上游 AS OF SCN 查询，校验 SCN 不大于 incr_sync_meta schema 内所有表已应用 SCN 后查询下游对比
+// 表已应用 SCN 取 max(table_scn_s, global_scn_s)，空闲表 table_scn_s 只在表存在变更时推进，global_scn_s 随日志文件应用推进
+// 下游在对比期间仍持续应用增量，chunk 不一致时等待已应用 SCN 推进后重新对比，重试耗尽或者等待超时仍不一致才记录差异
+type OnlineCompare struct {
+	Ctx     context.Context
+	Cfg     *config.Config
+	Oracle  *oracle.Oracle
+	MetaDB  *meta.Meta
+	SCN     uint64
+	Retries int
+	Timeout time.Duration
+}
+
+func NewOnlineCompare(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, metaDB *meta.Meta) (*OnlineCompare, error) {
+	timeout := cfg.DiffConfig.OnlineCompareTimeout
+	if timeout <= 0 {
+		timeout = common.DefaultOnlineCompareTimeout
+	}
+	o := &OnlineCompare{
+		Ctx:     ctx,
+		Cfg:     cfg,
+		Oracle:  oracle,
+		MetaDB:  metaDB,
+		SCN:     cfg.DiffConfig.OnlineCompareSCN,
+		Retries: cfg.DiffConfig.OnlineCompareRetries,
+		Timeout: time.Duration(timeout) * time.Second,
+	}
+	appliedSCN, err := o.AppliedSCN()
+	if err != nil {
+		return nil, err
+	}
+	// 未指定 SCN 使用增量同步当前已应用 SCN，下游已追平无需等待
+	if o.SCN == 0 {
+		o.SCN = appliedSCN
+		zap.L().Info("online compare use increment sync applied scn",
+			zap.String("schema", cfg.SchemaConfig.SourceSchema),
+			zap.Uint64("compare scn", o.SCN))
+		return o, nil
+	}
+	if _, err = o.waitApplied(func(applied uint64) bool { return applied >= o.SCN }); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// AppliedSCN 增量同步 schema 已应用 SCN，所有表 max(table_scn_s, global_scn_s) 最小值
+func (o *OnlineCompare) AppliedSCN() (uint64, error) {
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(o.MetaDB).DetailIncrSyncMetaBySchema(o.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     o.Cfg.DBTypeS,
+		DBTypeT:     o.Cfg.DBTypeT,
+		SchemaNameS: o.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(incrSyncMetas) == 0 {
+		return 0, fmt.Errorf("diff config online-compare need increment sync running, but meta table [incr_sync_meta] schema [%s] record is null", o.Cfg.SchemaConfig.SourceSchema)
+	}
+	var appliedSCN uint64
+	for i, m := range incrSyncMetas {
+		tableSCN := m.TableScnS
+		if m.GlobalScnS > tableSCN {
+			tableSCN = m.GlobalScnS
+		}
+		if i == 0 || tableSCN < appliedSCN {
+			appliedSCN = tableSCN
+		}
+	}
+	return appliedSCN, nil
+}
+
+// NextSCN 等待增量同步已应用 SCN 推进超过指定 SCN，返回新的已应用 SCN，用于不一致 chunk 重新对比
+func (o *OnlineCompare) NextSCN(scn uint64) (uint64, error) {
+	return o.waitApplied(func(applied uint64) bool { return applied > scn })
+}
+
+// waitApplied 轮询增量同步已应用 SCN 直至满足条件，超时返回 ErrOnlineCompareTimeout
+func (o *OnlineCompare) waitApplied(reached func(applied uint64) bool) (uint64, error) {
+	startTime := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		appliedSCN, err := o.AppliedSCN()
+		if err != nil {
+			return appliedSCN, err
+		}
+		if reached(appliedSCN) {
+			zap.L().Info("online compare increment sync applied scn reached",
+				zap.String("schema", o.Cfg.SchemaConfig.SourceSchema),
+				zap.Uint64("compare scn", o.SCN),
+				zap.Uint64("applied scn", appliedSCN),
+				zap.String("cost", time.Since(startTime).String()))
+			return appliedSCN, nil
+		}
+		if time.Since(startTime) > o.Timeout {
+			return appliedSCN, fmt.Errorf("%w: schema [%s] applied scn [%d] compare scn [%d] timeout [%s], please check increment sync task", ErrOnlineCompareTimeout, o.Cfg.SchemaConfig.SourceSchema, appliedSCN, o.SCN, o.Timeout.String())
+		}
+		select {
+		case <-o.Ctx.Done():
+			return appliedSCN, o.Ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
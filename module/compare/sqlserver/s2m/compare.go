@@ -0,0 +1,148 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/sqlserver"
+	migrates2m "github.com/wentaojin/transferdb/module/migrate/sql/sqlserver/s2m"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Compare struct {
+	ctx       context.Context
+	cfg       *config.Config
+	sqlserver *sqlserver.SQLServer
+	mysql     *mysql.MySQL
+}
+
+// tableRows 表行数校验结果
+type tableRows struct {
+	Table      string
+	SourceRows int64
+	TargetRows int64
+}
+
+func NewCompare(ctx context.Context, cfg *config.Config) (*Compare, error) {
+	sqlserverDB, err := sqlserver.NewSQLServerDBEngine(ctx, cfg.SQLServerConfig)
+	if err != nil {
+		return nil, err
+	}
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Compare{
+		ctx:       ctx,
+		cfg:       cfg,
+		sqlserver: sqlserverDB,
+		mysql:     mysqlDB,
+	}, nil
+}
+
+// NewCompare SQL Server 源端仅支持表级别行数校验，上下游 COUNT 并发对比
+func (r *Compare) NewCompare() error {
+	startTime := time.Now()
+
+	exporters, err := migrates2m.FilterCFGTable(r.cfg, r.sqlserver)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu      sync.Mutex
+		results []tableRows
+	)
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.DiffConfig.DiffThreads)
+
+	for _, t := range exporters {
+		sourceTable := t
+		g.Go(func() error {
+			sourceRows, err := r.sqlserver.GetSQLServerTableActualRows(r.cfg.SchemaConfig.SourceSchema, sourceTable)
+			if err != nil {
+				return fmt.Errorf("sqlserver table [%s] check rows failed: %v", sourceTable, err)
+			}
+			targetRows, err := r.mysql.GetMySQLTableActualRows(common.StringsBuilder(
+				"SELECT COUNT(1) FROM `", r.cfg.SchemaConfig.TargetSchema, "`.`", sourceTable, "`"))
+			if err != nil {
+				return fmt.Errorf("mysql table [%s] check rows failed: %v", sourceTable, err)
+			}
+
+			mu.Lock()
+			results = append(results, tableRows{
+				Table:      sourceTable,
+				SourceRows: sourceRows,
+				TargetRows: targetRows,
+			})
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Table < results[j].Table
+	})
+
+	var diffTables []string
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.AppendHeader(table.Row{"SOURCE TABLE", "SOURCE COUNTS", "TARGET TABLE", "TARGET COUNTS", "DIFF"})
+	for _, res := range results {
+		if res.SourceRows == res.TargetRows {
+			continue
+		}
+		diffTables = append(diffTables, res.Table)
+		sw.AppendRow(table.Row{
+			common.StringsBuilder(r.cfg.SchemaConfig.SourceSchema, ".", res.Table),
+			res.SourceRows,
+			common.StringsBuilder(r.cfg.SchemaConfig.TargetSchema, ".", res.Table),
+			res.TargetRows,
+			res.SourceRows - res.TargetRows,
+		})
+	}
+
+	if len(diffTables) > 0 {
+		fmt.Printf("sqlserver schema [%s] and mysql schema [%s] table rows aren't equal:\n%s\n", r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, sw.Render())
+		zap.L().Warn("check table rows sqlserver to mysql finished",
+			zap.Int("table totals", len(exporters)),
+			zap.Int("table equal", len(exporters)-len(diffTables)),
+			zap.Int("table diff", len(diffTables)),
+			zap.Strings("diff tables", diffTables),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+		return nil
+	}
+
+	fmt.Printf("sqlserver schema [%s] and mysql schema [%s] table rows are all equal, table totals [%d]\n", r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, len(exporters))
+	zap.L().Info("check table rows sqlserver to mysql finished",
+		zap.Int("table totals", len(exporters)),
+		zap.Int("table equal", len(exporters)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
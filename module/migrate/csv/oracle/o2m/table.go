@@ -24,9 +24,9 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/database/s3"
+	"github.com/wentaojin/transferdb/module/migrate/csv/oracle/public"
 	"go.uber.org/zap"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -42,24 +42,32 @@ type Rows struct {
 	ColumnNameS  []string
 	ReadChannel  chan []map[string]string
 	WriteChannel chan string
+	// Parquet 导出，为空则导出 csv
+	Parquet       *public.ParquetTable
+	RecordChannel chan public.ParquetRecord
+	// S3/OSS 对象存储，为空写本地文件
+	Storage *s3.S3
 }
 
 func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
-	oracle *oracle.Oracle, cfg *config.Config, columnNameS []string, sourceDBCharset string) *Rows {
+	oracle *oracle.Oracle, cfg *config.Config, columnNameS []string, sourceDBCharset string, parquetTable *public.ParquetTable) *Rows {
 
 	writeChannel := make(chan string, common.ChannelBufferSize)
 	readChannel := make(chan []map[string]string, common.ChannelBufferSize)
+	recordChannel := make(chan public.ParquetRecord, common.ChannelBufferSize)
 
 	return &Rows{
-		Ctx:          ctx,
-		SyncMeta:     syncMeta,
-		Oracle:       oracle,
-		Cfg:          cfg,
-		DBCharsetS:   sourceDBCharset,
-		DBCharsetT:   common.StringUPPER(cfg.CSVConfig.Charset),
-		ColumnNameS:  columnNameS,
-		ReadChannel:  readChannel,
-		WriteChannel: writeChannel,
+		Ctx:           ctx,
+		SyncMeta:      syncMeta,
+		Oracle:        oracle,
+		Cfg:           cfg,
+		DBCharsetS:    sourceDBCharset,
+		DBCharsetT:    common.StringUPPER(cfg.CSVConfig.Charset),
+		ColumnNameS:   columnNameS,
+		ReadChannel:   readChannel,
+		WriteChannel:  writeChannel,
+		Parquet:       parquetTable,
+		RecordChannel: recordChannel,
 	}
 }
 
@@ -77,7 +85,14 @@ func (t *Rows) ReadData() error {
 		querySQL = common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, t.SyncMeta.SchemaNameS, `.`, t.SyncMeta.TableNameS, ` WHERE `, t.SyncMeta.ChunkDetailS)
 	}
 
-	err := t.Oracle.GetOracleTableRowsDataCSV(querySQL, t.DBCharsetS, t.DBCharsetT, t.Cfg, t.ReadChannel)
+	var err error
+	if t.Parquet != nil {
+		// parquet 按字段类型写入，复用 SQL 字面量转换，字段名反引号处理
+		err = t.Oracle.GetOracleTableRowsData(querySQL, t.Cfg.AppConfig.InsertBatchSize, t.DBCharsetS, t.DBCharsetT, t.Cfg.AppConfig.EmptyStringMode,
+			t.Cfg.AppConfig.LOBMaxSize, t.Cfg.AppConfig.LOBOversizeMode, t.Cfg.AppConfig.CharsetErrorMode, t.ReadChannel)
+	} else {
+		err = t.Oracle.GetOracleTableRowsDataCSV(querySQL, t.DBCharsetS, t.DBCharsetT, t.Cfg, t.ReadChannel)
+	}
 	if err != nil {
 		// 通道关闭
 		close(t.ReadChannel)
@@ -100,8 +115,19 @@ func (t *Rows) ReadData() error {
 }
 
 func (t *Rows) ProcessData() error {
+	if t.Parquet != nil {
+		return t.processParquetData()
+	}
 
+	// 任务结束或者转换出错，关闭通道，避免写入端等待未关闭通道阻塞
+	defer close(t.WriteChannel)
+
+	var err error
 	for dataC := range t.ReadChannel {
+		// 转换出错继续消费读取通道数据，避免读取端阻塞
+		if err != nil {
+			continue
+		}
 		for _, dMap := range dataC {
 			// 按字段名顺序遍历获取对应值
 			var (
@@ -113,53 +139,62 @@ func (t *Rows) ProcessData() error {
 				}
 			}
 			if len(rowsTMP) != len(t.ColumnNameS) {
-				return fmt.Errorf("source schema table column counts vs data counts isn't match")
+				err = fmt.Errorf("source schema table column counts vs data counts isn't match")
+				break
 			} else {
-				// csv 文件行数据输入
-				t.WriteChannel <- common.StringsBuilder(exstrings.Join(rowsTMP, t.Cfg.CSVConfig.Separator), t.Cfg.CSVConfig.Terminator)
+				// csv 文件行数据输入，待写入字节数计入内存预算
+				row := common.StringsBuilder(exstrings.Join(rowsTMP, t.Cfg.CSVConfig.Separator), t.Cfg.CSVConfig.Terminator)
+				t.Oracle.Budget.Add(len(row))
+				t.WriteChannel <- row
 			}
 		}
 	}
-
-	// 通道关闭
-	close(t.WriteChannel)
-
-	return nil
+	return err
 }
 
 func (t *Rows) ApplyData() error {
-	startTime := time.Now()
-	// 文件目录判断
-	if err := common.PathExist(
-		filepath.Join(
-			t.Cfg.CSVConfig.OutputDir,
-			strings.ToUpper(t.SyncMeta.SchemaNameS),
-			strings.ToUpper(t.SyncMeta.TableNameS))); err != nil {
-		return err
+	if t.Parquet != nil {
+		return t.applyParquetData()
 	}
 
-	fileW, err := os.OpenFile(t.SyncMeta.CSVFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND|os.O_TRUNC, 0666)
+	startTime := time.Now()
+	fileW, err := public.NewFileWriter(t.SyncMeta.CSVFile, t.Storage)
 	if err != nil {
+		t.drainWriteChannel()
 		return err
 	}
-	defer fileW.Close()
 
 	// 使用 bufio 来缓存写入文件，以提高效率
 	writer := bufio.NewWriterSize(fileW, 4096)
-	defer writer.Flush()
 
 	if t.Cfg.CSVConfig.Header {
 		if _, err = writer.WriteString(common.StringsBuilder(exstrings.Join(t.ColumnNameS, t.Cfg.CSVConfig.Separator), t.Cfg.CSVConfig.Terminator)); err != nil {
+			fileW.Abort(err)
+			t.drainWriteChannel()
 			return fmt.Errorf("failed to write headers: %v", err)
 		}
 	}
 
 	for dataC := range t.WriteChannel {
-		if _, err = writer.WriteString(dataC); err != nil {
+		_, err = writer.WriteString(dataC)
+		// 行数据写入缓冲完成（成功或者失败）释放内存预算
+		t.Oracle.Budget.Release(len(dataC))
+		if err != nil {
+			fileW.Abort(err)
+			// 写入出错继续消费通道数据，避免转换端阻塞
+			t.drainWriteChannel()
 			return fmt.Errorf("failed to write data row to csv %w", err)
 		}
 	}
 
+	if err = writer.Flush(); err != nil {
+		fileW.Abort(err)
+		return fmt.Errorf("failed to flush csv file [%s]: %w", fileW.Name(), err)
+	}
+	if err = fileW.Close(); err != nil {
+		return err
+	}
+
 	endTime := time.Now()
 	zap.L().Info("target schema table chunk data applier finished",
 		zap.String("schema", t.SyncMeta.SchemaNameT),
@@ -168,3 +203,78 @@ func (t *Rows) ApplyData() error {
 		zap.String("cost", endTime.Sub(startTime).String()))
 	return nil
 }
+
+func (t *Rows) processParquetData() error {
+	// 任务结束或者转换出错，关闭通道，避免写入端等待未关闭通道阻塞
+	defer close(t.RecordChannel)
+
+	var err error
+	for dataC := range t.ReadChannel {
+		// 转换出错继续消费读取通道数据，避免读取端阻塞
+		if err != nil {
+			continue
+		}
+		for _, dMap := range dataC {
+			var rowsTMP []string
+			for _, column := range t.ColumnNameS {
+				if val, ok := dMap[common.StringsBuilder("`", column, "`")]; ok {
+					rowsTMP = append(rowsTMP, val)
+				}
+			}
+			var rec public.ParquetRecord
+			rec, err = t.Parquet.GenRecord(rowsTMP)
+			if err != nil {
+				break
+			}
+			t.Oracle.Budget.Add(rec.Bytes)
+			t.RecordChannel <- rec
+		}
+	}
+	return err
+}
+
+func (t *Rows) applyParquetData() error {
+	startTime := time.Now()
+	pw, err := public.NewParquetWriter(t.SyncMeta.CSVFile, t.Parquet, t.Cfg.CSVConfig, t.Storage)
+	if err != nil {
+		t.drainRecordChannel()
+		return err
+	}
+
+	for rec := range t.RecordChannel {
+		err = pw.Write(rec)
+		t.Oracle.Budget.Release(rec.Bytes)
+		if err != nil {
+			pw.Abort(err)
+			// 写入出错继续消费通道数据，避免转换端阻塞
+			t.drainRecordChannel()
+			return err
+		}
+	}
+
+	if err = pw.Close(); err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	zap.L().Info("target schema table chunk parquet applier finished",
+		zap.String("schema", t.SyncMeta.SchemaNameT),
+		zap.String("table", t.SyncMeta.TableNameT),
+		zap.String("chunk", t.SyncMeta.ChunkDetailS),
+		zap.String("cost", endTime.Sub(startTime).String()))
+	return nil
+}
+
+// drainRecordChannel 写入出错后消费剩余 parquet 行数据并释放内存预算，避免转换端发送阻塞
+func (t *Rows) drainRecordChannel() {
+	for rec := range t.RecordChannel {
+		t.Oracle.Budget.Release(rec.Bytes)
+	}
+}
+
+// drainWriteChannel 写入出错后消费剩余 csv 行数据并释放内存预算，避免转换端发送阻塞
+func (t *Rows) drainWriteChannel() {
+	for row := range t.WriteChannel {
+		t.Oracle.Budget.Release(len(row))
+	}
+}
@@ -24,7 +24,10 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/database/s3"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/migrate/csv/oracle/public"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"path/filepath"
@@ -40,6 +43,8 @@ type CSV struct {
 	Oracle *oracle.Oracle
 	Mysql  *mysql.MySQL
 	MetaDB *meta.Meta
+	// S3/OSS 对象存储，storage = local 为空
+	Storage *s3.S3
 }
 
 func NewCSV(ctx context.Context, cfg *config.Config) (*CSV, error) {
@@ -47,6 +52,9 @@ func NewCSV(ctx context.Context, cfg *config.Config) (*CSV, error) {
 	if err != nil {
 		return nil, err
 	}
+	oracleDB.Throttle = common.NewThrottle(cfg.AppConfig.ExtractRowsPerSecond, cfg.AppConfig.ExtractMBPerSecond)
+	oracleDB.SlowLog = common.NewSlowLog(logger.SlowLogger(), common.SlowLogSource, cfg.LogConfig.SlowSourceThreshold)
+	oracleDB.Budget = common.NewMemoryBudget(cfg.FullConfig.MemoryBudget)
 	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 	if err != nil {
 		return nil, err
@@ -55,12 +63,25 @@ func NewCSV(ctx context.Context, cfg *config.Config) (*CSV, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	var storage *s3.S3
+	switch common.StringUPPER(cfg.CSVConfig.Storage) {
+	case "", common.ExportStorageLocal:
+	case common.ExportStorageS3, common.ExportStorageOSS:
+		storage, err = s3.NewS3Storage(ctx, cfg.CSVConfig.Storage, cfg.S3Config)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("csv config storage [%v] isn't support, support: local/s3/oss", cfg.CSVConfig.Storage)
+	}
 	return &CSV{
-		Ctx:    ctx,
-		Cfg:    cfg,
-		Oracle: oracleDB,
-		Mysql:  mysqlDB,
-		MetaDB: metaDB,
+		Ctx:     ctx,
+		Cfg:     cfg,
+		Oracle:  oracleDB,
+		Mysql:   mysqlDB,
+		MetaDB:  metaDB,
+		Storage: storage,
 	}, nil
 }
 
@@ -101,6 +122,12 @@ func (r *CSV) CSV() error {
 		return err
 	}
 
+	// dry-run 只输出待同步表列表，不执行迁移
+	if r.Cfg.DryRun {
+		public.DryRunCFGTable(r.Cfg, exporters)
+		return nil
+	}
+
 	// 关于全量断点恢复
 	//  - 若想断点恢复，设置 enable-checkpoint true,首次一旦运行则 batch 数不能调整，
 	//  - 若不想断点恢复或者重新调整 batch 数，设置 enable-checkpoint false,清理元数据表 [wait_sync_meta],重新运行全量任务
@@ -330,6 +357,11 @@ func (r *CSV) CSV() error {
 		}
 	}
 
+	// 收到退出信号，断点已写入元数据库，返回错误非正常退出
+	if signal.IsShutdown() {
+		return fmt.Errorf("oracle schema [%s] csv task interrupted by exit signal, checkpoint saved, please rerun with [enable-checkpoint = true] to resume", r.Cfg.SchemaConfig.SourceSchema)
+	}
+
 	// 任务详情
 	succTotals, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
 		DBTypeS:     r.Cfg.DBTypeS,
@@ -389,6 +421,15 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 				return err
 			}
 
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，不再拉取新表任务，表保持 running 状态用于断点续传
+			if signal.IsShutdown() {
+				return nil
+			}
+
 			waitFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
 				DBTypeS:     r.Cfg.DBTypeS,
 				DBTypeT:     r.Cfg.DBTypeT,
@@ -420,14 +461,57 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 			if err != nil {
 				return nil
 			}
+			// 字段投影，排除字段不导出
+			projection, err := r.GetTableColumnProjection(t)
+			if err != nil {
+				return err
+			}
+			columnNameS = projection.ProjectColumnNames(columnNameS)
+
+			// parquet 导出按字段类型生成表结构
+			var parquetTable *public.ParquetTable
+			if strings.EqualFold(r.Cfg.CSVConfig.FileFormat, common.ExportFileFormatParquet) {
+				columnsINFO, err := r.Oracle.GetOracleSchemaTableColumn(r.Cfg.SchemaConfig.SourceSchema, t, false)
+				if err != nil {
+					return err
+				}
+				columnsINFO = projection.ProjectColumnINFO(columnsINFO)
+				parquetTable, err = public.NewParquetTable(columnNameS, columnsINFO, r.getCustomMigrateConfig()[common.StringUPPER(t)])
+				if err != nil {
+					return err
+				}
+			}
+
+			// LONG/LONG RAW 字段表逐行抽取
+			tableOracle, longColumns, err := r.getTableOracle(t).WithTableLongFetch(r.Cfg.SchemaConfig.SourceSchema, t)
+			if err != nil {
+				return err
+			}
+			if len(longColumns) > 0 {
+				zap.L().Warn("csv table exist long column, single-row fetch",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", t),
+					zap.Strings("long columns", longColumns))
+			}
 
 			g1 := &errgroup.Group{}
-			g1.SetLimit(r.Cfg.CSVConfig.SQLThreads)
+			g1.SetLimit(r.getTableSQLThreads(t))
 
 			for _, fullSyncMeta := range waitFullMetas {
 				m := fullSyncMeta
 				g1.Go(func() error {
-					err = public.IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Cfg, columnNameS, common.MigrateOracleCharsetStringConvertMapping[sourceDBCharset]))
+					// 任务暂停，等待恢复后继续拉取
+					if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+						return err
+					}
+					// 收到退出信号，不再拉取新 chunk，chunk 保持原状态用于断点续传
+					if signal.IsShutdown() {
+						return nil
+					}
+
+					rows := NewRows(r.Ctx, m, tableOracle, r.Cfg, columnNameS, common.MigrateOracleCharsetStringConvertMapping[sourceDBCharset], parquetTable)
+					rows.Storage = r.Storage
+					err = public.IMigrate(rows)
 					if err != nil {
 						var (
 							errorSQL string
@@ -491,6 +575,14 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 				return err
 			}
 
+			// 收到退出信号，跳过表完成状态更新，等待下次断点续传
+			if signal.IsShutdown() {
+				zap.L().Warn("csv single table task interrupted by exit signal, checkpoint saved",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", common.StringUPPER(t)))
+				return nil
+			}
+
 			// 清理元数据记录
 			// 更新 wait_sync_meta 记录
 			failedChunkTotalErrs, err := meta.NewFullSyncMetaModel(r.MetaDB).CountsErrorFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
@@ -608,6 +700,25 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 	if err != nil {
 		return err
 	}
+	// 一致性读断点续传，沿用已初始化表 SCN，保证任务内所有表同一快照
+	if r.Cfg.CSVConfig.ConsistentRead && r.Cfg.CSVConfig.EnableCheckpoint {
+		checkpointSCN, err := meta.NewWaitSyncMetaModel(r.MetaDB).GetWaitSyncMetaMinGlobalScnSBySchema(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TaskMode:    r.Cfg.TaskMode,
+		})
+		if err != nil {
+			return err
+		}
+		if checkpointSCN > 0 {
+			zap.L().Warn("consistent read reuse checkpoint scn",
+				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+				zap.Uint64("current scn", globalSCN),
+				zap.Uint64("checkpoint scn", checkpointSCN))
+			globalSCN = checkpointSCN
+		}
+	}
 	// 获取自定义库表迁移配置
 	tableMigrateRule := r.getCustomMigrateConfig()
 
@@ -630,6 +741,14 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 	for _, tbl := range csvWaitTables {
 		t := tbl
 		g.Go(func() error {
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，不再切分新表 chunk，表保持 waiting 状态
+			if signal.IsShutdown() {
+				return nil
+			}
 			startTime := time.Now()
 
 			// 库名、表名规则
@@ -649,8 +768,17 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 			)
 			if val, ok := tableMigrateRule[common.StringUPPER(t)]; ok {
 				sqlHint = val.SQLHint
-				wherePrefix = val.Range
 				enableSplit = val.EnableSplit
+				// 自定义过滤条件括号包裹，避免 OR 条件破坏 chunk 范围
+				if !strings.EqualFold(val.Range, "") {
+					wherePrefix = common.StringsBuilder("(", val.Range, ")")
+					if !enableSplit {
+						zap.L().Warn("table migrate config range isn't enable, because of enable-split is false",
+							zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+							zap.String("table", t),
+							zap.String("range", val.Range))
+					}
+				}
 			} else {
 				sqlHint = r.Cfg.FullConfig.SQLHint
 			}
@@ -700,7 +828,7 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 					CSVFile: filepath.Join(r.Cfg.CSVConfig.OutputDir,
 						common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t),
 						common.StringsBuilder(common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-							`.`, common.StringUPPER(targetTableName), `.0.csv`)),
+							`.`, common.StringUPPER(targetTableName), `.0`, r.fileSuffix())),
 				}, &meta.WaitSyncMeta{
 					DBTypeS:          r.Cfg.DBTypeS,
 					DBTypeT:          r.Cfg.DBTypeT,
@@ -723,17 +851,26 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 
 			taskName := uuid.New().String()
 
-			if err = r.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
-				return err
-			}
+			var chunkRes []map[string]string
+			// ADG 备库只读不支持 DBMS_PARALLEL_EXECUTE，基于表段区间切分 ROWID chunk
+			if r.Oracle.Standby {
+				chunkRes, err = r.Oracle.GetOracleStandbyTableChunksByRowID(common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), r.Cfg.CSVConfig.Rows)
+				if err != nil {
+					return err
+				}
+			} else {
+				if err = r.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
+					return err
+				}
 
-			if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
-				return err
-			}
+				if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
+					return err
+				}
 
-			chunkRes, err := r.Oracle.GetOracleTableChunksByRowID(taskName)
-			if err != nil {
-				return err
+				chunkRes, err = r.Oracle.GetOracleTableChunksByRowID(taskName)
+				if err != nil {
+					return err
+				}
 			}
 
 			// 判断数据是否存在
@@ -762,7 +899,7 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 					CSVFile: filepath.Join(r.Cfg.CSVConfig.OutputDir,
 						common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t),
 						common.StringsBuilder(common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-							`.`, common.StringUPPER(targetTableName), `.0.csv`)),
+							`.`, common.StringUPPER(targetTableName), `.0`, r.fileSuffix())),
 				}, &meta.WaitSyncMeta{
 					DBTypeS:          r.Cfg.DBTypeS,
 					DBTypeT:          r.Cfg.DBTypeT,
@@ -790,7 +927,7 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 				csvFile = filepath.Join(r.Cfg.CSVConfig.OutputDir,
 					common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t),
 					common.StringsBuilder(common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), `.`,
-						common.StringUPPER(targetTableName), `.`, strconv.Itoa(i), `.csv`))
+						common.StringUPPER(targetTableName), `.`, strconv.Itoa(i), r.fileSuffix()))
 
 				switch {
 				case enableSplit && !strings.EqualFold(wherePrefix, ""):
@@ -837,8 +974,10 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 				return err
 			}
 
-			if err = r.Oracle.CloseOracleChunkTask(taskName); err != nil {
-				return err
+			if !r.Oracle.Standby {
+				if err = r.Oracle.CloseOracleChunkTask(taskName); err != nil {
+					return err
+				}
 			}
 
 			endTime := time.Now()
@@ -860,6 +999,22 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 	return nil
 }
 
+// 表级别 sql-threads 优先级高于全局 sql-threads
+func (r *CSV) getTableSQLThreads(tableName string) int {
+	if val, ok := r.getCustomMigrateConfig()[common.StringUPPER(tableName)]; ok && val.SQLThreads > 0 {
+		return val.SQLThreads
+	}
+	return r.Cfg.CSVConfig.SQLThreads
+}
+
+// 表级别 fetch-array-size/prefetch-count 优先级高于全局 [oracle] 配置
+func (r *CSV) getTableOracle(tableName string) *oracle.Oracle {
+	if val, ok := r.getCustomMigrateConfig()[common.StringUPPER(tableName)]; ok {
+		return r.Oracle.WithFetchSize(val.FetchArraySize, val.PrefetchCount)
+	}
+	return r.Oracle
+}
+
 func (r *CSV) getCustomMigrateConfig() map[string]config.MigrateConfig {
 	tableMigrateMap := make(map[string]config.MigrateConfig)
 	for _, t := range r.Cfg.SchemaConfig.MigrateConfig {
@@ -889,6 +1044,40 @@ func (r *CSV) getTableNameRule() (map[string]string, error) {
 	return tableNameRuleMap, nil
 }
 
+// 导出文件后缀
+func (r *CSV) fileSuffix() string {
+	if strings.EqualFold(r.Cfg.CSVConfig.FileFormat, common.ExportFileFormatParquet) {
+		return ".parquet"
+	}
+	return ".csv"
+}
+
+// GetTableColumnProjection 字段投影，虚拟列下游为生成列或者不创建，数据抽取统一排除
+func (r *CSV) GetTableColumnProjection(sourceTable string) (*config.ColumnProjection, error) {
+	virtualColumns, err := r.Oracle.GetOracleSchemaTableVirtualColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(virtualColumns) > 0 {
+		zap.L().Warn("oracle table virtual column skip data extraction",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", sourceTable),
+			zap.Strings("virtual columns", virtualColumns))
+	}
+	// BFILE 以及 rowid-mode = skip ROWID/UROWID 下游不创建，数据抽取排除
+	skipColumns, err := r.Oracle.GetOracleSchemaTableDatatypeColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable, r.Cfg.ReverseConfig.SkipColumnDatatypes())
+	if err != nil {
+		return nil, err
+	}
+	if len(skipColumns) > 0 {
+		zap.L().Warn("oracle table unsupported datatype column skip data extraction",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", sourceTable),
+			zap.Strings("skip columns", skipColumns))
+	}
+	return r.Cfg.SchemaConfig.GetColumnProjection(sourceTable).Exclude(virtualColumns...).Exclude(skipColumns...), nil
+}
+
 func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, error) {
 	// Date/Timestamp 字段类型格式化
 	// Interval Year/Day 数据字符 TO_CHAR 格式化
@@ -896,10 +1085,37 @@ func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool)
 	if err != nil {
 		return "", err
 	}
+	projection, err := r.GetTableColumnProjection(sourceTable)
+	if err != nil {
+		return "", err
+	}
+	columnsINFO = projection.ProjectColumnINFO(columnsINFO)
+
+	// 字段转换规则
+	columnTransforms := make(map[string]config.ColumnTransform)
+	if val, ok := r.getCustomMigrateConfig()[common.StringUPPER(sourceTable)]; ok {
+		for _, ct := range val.ColumnTransform {
+			columnTransforms[common.StringUPPER(ct.ColumnName)] = ct
+		}
+	}
 
 	var columnNames []string
 
 	for _, rowCol := range columnsINFO {
+		// 字段转换优先，转换表达式替换默认格式化
+		if ct, ok := columnTransforms[common.StringUPPER(rowCol["COLUMN_NAME"])]; ok {
+			expr, err := common.GenOracleColumnTransform(rowCol["COLUMN_NAME"], ct.Rule, ct.Algorithm, ct.Length, ct.Expression)
+			if err != nil {
+				return "", err
+			}
+			columnNames = append(columnNames, expr)
+			continue
+		}
+		// interval-mode/raw-mode 字段转换表达式
+		if expr, ok := common.GenOracleDatatypeStrategyColumn(rowCol["COLUMN_NAME"], rowCol["DATA_TYPE"], r.Cfg.ReverseConfig.IntervalMode, r.Cfg.ReverseConfig.RawMode); ok {
+			columnNames = append(columnNames, common.StringsBuilder(expr, ` AS "`, rowCol["COLUMN_NAME"], `"`))
+			continue
+		}
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
@@ -917,7 +1133,12 @@ func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool)
 			columnNames = append(columnNames, common.StringsBuilder(`"`, rowCol["COLUMN_NAME"], `"`))
 		// 时间
 		case "DATE":
-			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'yyyy-mm-dd hh24:mi:ss') AS "`, rowCol["COLUMN_NAME"], `"`))
+			// parquet 固定格式解析 TIMESTAMP
+			dateFormat := common.GetOracleDateFormat(r.Cfg.AppConfig.DateFormat)
+			if strings.EqualFold(r.Cfg.CSVConfig.FileFormat, common.ExportFileFormatParquet) {
+				dateFormat = "yyyy-mm-dd hh24:mi:ss"
+			}
+			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'`, dateFormat, `') AS "`, rowCol["COLUMN_NAME"], `"`))
 		// 默认其他类型
 		default:
 			if strings.Contains(rowCol["DATA_TYPE"], "INTERVAL") {
@@ -927,13 +1148,15 @@ func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool)
 				if err != nil {
 					return "", fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", rowCol["DATA_SCALE"], err)
 				}
+				// 带时区时间按 target-time-zone 转换
+				timestampCol := common.GenOracleTimestampColumn(rowCol["COLUMN_NAME"], rowCol["DATA_TYPE"], r.Cfg.AppConfig.TargetTimeZone)
 				if dataScale == 0 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'yyyy-mm-dd hh24:mi:ss') AS "`, rowCol["COLUMN_NAME"], `"`))
-				} else if dataScale < 0 && dataScale <= 6 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"],
-						`",'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, rowCol["COLUMN_NAME"], `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, timestampCol, `,'yyyy-mm-dd hh24:mi:ss') AS "`, rowCol["COLUMN_NAME"], `"`))
+				} else if dataScale > 0 && dataScale <= 6 {
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, timestampCol,
+						`,'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, rowCol["COLUMN_NAME"], `"`))
 				} else {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, rowCol["COLUMN_NAME"], `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, timestampCol, `,'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, rowCol["COLUMN_NAME"], `"`))
 				}
 
 			} else {
@@ -973,6 +1196,29 @@ func (r *CSV) AdjustCSVConfig(sourceDBCharset string) error {
 	if r.Cfg.CSVConfig.Separator == "" {
 		r.Cfg.CSVConfig.Separator = ","
 	}
+
+	if r.Cfg.CSVConfig.FileFormat == "" {
+		r.Cfg.CSVConfig.FileFormat = common.ExportFileFormatCSV
+	}
+	switch common.StringUPPER(r.Cfg.CSVConfig.FileFormat) {
+	case common.ExportFileFormatCSV:
+	case common.ExportFileFormatParquet:
+		// parquet 字符串类型固定 UTF8
+		if !strings.EqualFold(r.Cfg.CSVConfig.Charset, common.MYSQLCharsetUTF8MB4) {
+			return fmt.Errorf("csv config file-format [parquet] only support charset [utf8mb4], current charset [%v]", r.Cfg.CSVConfig.Charset)
+		}
+		if r.Cfg.CSVConfig.RowGroupSize <= 0 {
+			r.Cfg.CSVConfig.RowGroupSize = common.ParquetDefaultRowGroupSize
+		}
+		if r.Cfg.CSVConfig.Compression == "" {
+			r.Cfg.CSVConfig.Compression = common.ParquetDefaultCompression
+		}
+		if !common.IsContainString([]string{"UNCOMPRESSED", "SNAPPY", "GZIP", "LZ4", "ZSTD"}, common.StringUPPER(r.Cfg.CSVConfig.Compression)) {
+			return fmt.Errorf("csv config compression [%v] isn't support, support: uncompressed/snappy/gzip/lz4/zstd", r.Cfg.CSVConfig.Compression)
+		}
+	default:
+		return fmt.Errorf("csv config file-format [%v] isn't support, support: csv/parquet", r.Cfg.CSVConfig.FileFormat)
+	}
 	if r.Cfg.CSVConfig.Terminator == "" {
 		r.Cfg.CSVConfig.Terminator = "\r\n"
 	}
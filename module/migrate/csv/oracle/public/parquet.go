@@ -0,0 +1,472 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/s3"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parquet 字段物理/逻辑类型
+const (
+	parquetKindString       = "STRING"
+	parquetKindBinary       = "BINARY"
+	parquetKindInt64        = "INT64"
+	parquetKindDecimalInt64 = "DECIMAL_INT64"
+	parquetKindDecimalBytes = "DECIMAL_BYTES"
+	parquetKindFloat        = "FLOAT"
+	parquetKindDouble       = "DOUBLE"
+	parquetKindTimestamp    = "TIMESTAMP"
+
+	// DECIMAL 精度 <= 18 使用 INT64 存储，否则使用 BYTE_ARRAY 存储
+	parquetDecimalInt64MaxPrecision = 18
+	parquetDecimalMaxPrecision      = 38
+
+	// DATE/TIMESTAMP 统一 TO_CHAR 格式 yyyy-mm-dd hh24:mi:ss[.ff]
+	parquetTimestampLayout = "2006-01-02 15:04:05.999999999"
+)
+
+// 分区粒度，仅适用于 DATE/TIMESTAMP 字段
+const (
+	parquetPartitionYear  = "YEAR"
+	parquetPartitionMonth = "MONTH"
+	parquetPartitionDay   = "DAY"
+)
+
+type ParquetColumn struct {
+	ColumnName string
+	Kind       string
+	Precision  int
+	Scale      int
+}
+
+type ParquetPartition struct {
+	// 分区目录名
+	Name string
+	// 分区字段下标
+	Index int
+	// 分区粒度，为空则按字段值分区
+	Granularity string
+}
+
+type ParquetTable struct {
+	Columns    []ParquetColumn
+	Partitions []ParquetPartition
+	// 写入 parquet 文件的字段下标，按字段值分区的字段只体现在分区目录，不写入文件
+	DataIndex []int
+	Metadata  []string
+}
+
+type ParquetRecord struct {
+	Partition string
+	Values    []interface{}
+	// 行数据字节数，用于内存预算统计
+	Bytes int
+}
+
+// NewParquetTable 根据 Oracle 字段类型生成 Parquet 表结构
+// columnNameS 为 csv 导出查询字段顺序，columnsINFO 来自 GetOracleSchemaTableColumn
+func NewParquetTable(columnNameS []string, columnsINFO []map[string]string, migrateCfg config.MigrateConfig) (*ParquetTable, error) {
+	columnInfoMap := make(map[string]map[string]string)
+	for _, c := range columnsINFO {
+		columnInfoMap[common.StringUPPER(c["COLUMN_NAME"])] = c
+	}
+
+	// 字段转换规则输出字符串
+	columnTransforms := make(map[string]struct{})
+	for _, ct := range migrateCfg.ColumnTransform {
+		columnTransforms[common.StringUPPER(ct.ColumnName)] = struct{}{}
+	}
+
+	pt := &ParquetTable{}
+	for _, col := range columnNameS {
+		pc := ParquetColumn{ColumnName: col, Kind: parquetKindString}
+		if _, ok := columnTransforms[common.StringUPPER(col)]; !ok {
+			if info, ok := columnInfoMap[common.StringUPPER(col)]; ok {
+				c, err := genParquetColumn(col, info)
+				if err != nil {
+					return nil, err
+				}
+				pc = c
+			}
+		}
+		pt.Columns = append(pt.Columns, pc)
+	}
+
+	// 分区规则 COLUMN 或 COLUMN:YEAR|MONTH|DAY
+	excludeIndex := make(map[int]struct{})
+	for _, p := range migrateCfg.PartitionBy {
+		items := strings.Split(strings.TrimSpace(p), ":")
+		if len(items) > 2 || strings.TrimSpace(items[0]) == "" {
+			return nil, fmt.Errorf("table [%s] partition-by [%s] isn't support, format: COLUMN or COLUMN:YEAR|MONTH|DAY", migrateCfg.SourceTable, p)
+		}
+		colName := common.StringUPPER(strings.TrimSpace(items[0]))
+		index := -1
+		for i, c := range pt.Columns {
+			if common.StringUPPER(c.ColumnName) == colName {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return nil, fmt.Errorf("table [%s] partition-by column [%s] isn't exist", migrateCfg.SourceTable, items[0])
+		}
+
+		partition := ParquetPartition{Name: pt.Columns[index].ColumnName, Index: index}
+		if len(items) == 2 {
+			granularity := common.StringUPPER(strings.TrimSpace(items[1]))
+			if !common.IsContainString([]string{parquetPartitionYear, parquetPartitionMonth, parquetPartitionDay}, granularity) {
+				return nil, fmt.Errorf("table [%s] partition-by [%s] granularity isn't support, support: YEAR|MONTH|DAY", migrateCfg.SourceTable, p)
+			}
+			if pt.Columns[index].Kind != parquetKindTimestamp {
+				return nil, fmt.Errorf("table [%s] partition-by [%s] granularity only support date or timestamp column", migrateCfg.SourceTable, p)
+			}
+			partition.Name = common.StringsBuilder(pt.Columns[index].ColumnName, "_", granularity)
+			partition.Granularity = granularity
+		} else {
+			excludeIndex[index] = struct{}{}
+		}
+		pt.Partitions = append(pt.Partitions, partition)
+	}
+
+	for i, c := range pt.Columns {
+		if _, ok := excludeIndex[i]; ok {
+			continue
+		}
+		pt.DataIndex = append(pt.DataIndex, i)
+		pt.Metadata = append(pt.Metadata, c.metadata())
+	}
+	if len(pt.Metadata) == 0 {
+		return nil, fmt.Errorf("table [%s] partition-by can't contain all columns", migrateCfg.SourceTable)
+	}
+	return pt, nil
+}
+
+// genParquetColumn Oracle 字段类型映射 Parquet 类型
+// - NUMBER(p,0) p <= 18 -> INT64，其他 NUMBER(p,s) -> DECIMAL，未定义精度 NUMBER 以字符串输出，避免精度丢失
+// - DATE/TIMESTAMP -> TIMESTAMP_MICROS，带时区时间已按 target-time-zone 转换
+// - BLOB/RAW/LONG RAW -> BYTE_ARRAY
+// - 其他 -> BYTE_ARRAY UTF8
+func genParquetColumn(columnName string, info map[string]string) (ParquetColumn, error) {
+	pc := ParquetColumn{ColumnName: columnName, Kind: parquetKindString}
+	dataType := common.StringUPPER(info["DATA_TYPE"])
+	switch {
+	case dataType == "NUMBER":
+		precision, err := strconv.Atoi(info["DATA_PRECISION"])
+		if err != nil {
+			return pc, fmt.Errorf("column [%s] data_precision [%s] strconv.Atoi failed: %v", columnName, info["DATA_PRECISION"], err)
+		}
+		scale, err := strconv.Atoi(info["DATA_SCALE"])
+		if err != nil {
+			return pc, fmt.Errorf("column [%s] data_scale [%s] strconv.Atoi failed: %v", columnName, info["DATA_SCALE"], err)
+		}
+		switch {
+		// number / number(*) 查询为 number(38,127)
+		case scale == 127:
+			return pc, nil
+		case scale <= 0:
+			// 负数 scale 整数位数扩展
+			precision = precision - scale
+			if precision > parquetDecimalMaxPrecision {
+				precision = parquetDecimalMaxPrecision
+			}
+			if precision <= parquetDecimalInt64MaxPrecision {
+				pc.Kind = parquetKindInt64
+				return pc, nil
+			}
+			pc.Kind = parquetKindDecimalBytes
+			pc.Precision = precision
+			return pc, nil
+		default:
+			// number(2,5) 类型 scale 大于 precision
+			if scale > precision {
+				precision = scale
+			}
+			if precision <= parquetDecimalInt64MaxPrecision {
+				pc.Kind = parquetKindDecimalInt64
+			} else {
+				pc.Kind = parquetKindDecimalBytes
+			}
+			pc.Precision = precision
+			pc.Scale = scale
+			return pc, nil
+		}
+	case dataType == "BINARY_FLOAT":
+		pc.Kind = parquetKindFloat
+	case dataType == "BINARY_DOUBLE" || dataType == "FLOAT":
+		pc.Kind = parquetKindDouble
+	case dataType == "DATE" || (strings.Contains(dataType, "TIMESTAMP") && !strings.Contains(dataType, "INTERVAL")):
+		pc.Kind = parquetKindTimestamp
+	case dataType == "BLOB" || dataType == "RAW" || dataType == "LONG RAW":
+		pc.Kind = parquetKindBinary
+	}
+	return pc, nil
+}
+
+func (c ParquetColumn) metadata() string {
+	name := common.StringsBuilder("name=", c.ColumnName)
+	switch c.Kind {
+	case parquetKindInt64:
+		return common.StringsBuilder(name, ", type=INT64, repetitiontype=OPTIONAL")
+	case parquetKindDecimalInt64:
+		return fmt.Sprintf("%s, type=INT64, convertedtype=DECIMAL, precision=%d, scale=%d, repetitiontype=OPTIONAL", name, c.Precision, c.Scale)
+	case parquetKindDecimalBytes:
+		return fmt.Sprintf("%s, type=BYTE_ARRAY, convertedtype=DECIMAL, precision=%d, scale=%d, repetitiontype=OPTIONAL", name, c.Precision, c.Scale)
+	case parquetKindFloat:
+		return common.StringsBuilder(name, ", type=FLOAT, repetitiontype=OPTIONAL")
+	case parquetKindDouble:
+		return common.StringsBuilder(name, ", type=DOUBLE, repetitiontype=OPTIONAL")
+	case parquetKindTimestamp:
+		return common.StringsBuilder(name, ", type=INT64, convertedtype=TIMESTAMP_MICROS, repetitiontype=OPTIONAL")
+	case parquetKindBinary:
+		return common.StringsBuilder(name, ", type=BYTE_ARRAY, repetitiontype=OPTIONAL")
+	default:
+		return common.StringsBuilder(name, ", type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL")
+	}
+}
+
+// value 字段值转换 Parquet 值，rawValue 为 GetOracleTableRowsData 输出 SQL 字面量
+func (c ParquetColumn) value(rawValue interface{}) (interface{}, error) {
+	if rawValue == nil {
+		return nil, nil
+	}
+	var str string
+	switch v := rawValue.(type) {
+	case []byte:
+		str = string(v)
+	default:
+		str = fmt.Sprintf("%v", v)
+	}
+
+	switch c.Kind {
+	case parquetKindInt64:
+		val, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] value [%s] parse int64 failed: %v", c.ColumnName, str, err)
+		}
+		return val, nil
+	case parquetKindDecimalInt64, parquetKindDecimalBytes:
+		d, err := decimal.NewFromString(str)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] value [%s] parse decimal failed: %v", c.ColumnName, str, err)
+		}
+		// DECIMAL 存储 unscaled 整数值
+		unscaled := d.Round(int32(c.Scale)).Shift(int32(c.Scale)).BigInt()
+		if c.Kind == parquetKindDecimalInt64 {
+			if !unscaled.IsInt64() {
+				return nil, fmt.Errorf("column [%s] value [%s] overflow decimal(%d,%d)", c.ColumnName, str, c.Precision, c.Scale)
+			}
+			return unscaled.Int64(), nil
+		}
+		return types.StrIntToBinary(unscaled.String(), "BigEndian", 0, true), nil
+	case parquetKindFloat:
+		val, err := strconv.ParseFloat(str, 32)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] value [%s] parse float failed: %v", c.ColumnName, str, err)
+		}
+		return float32(val), nil
+	case parquetKindDouble:
+		val, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] value [%s] parse double failed: %v", c.ColumnName, str, err)
+		}
+		return val, nil
+	case parquetKindTimestamp:
+		t, err := time.ParseInLocation(parquetTimestampLayout, str, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] value [%s] parse timestamp failed: %v", c.ColumnName, str, err)
+		}
+		return types.TimeToTIMESTAMP_MICROS(t, true), nil
+	default:
+		return str, nil
+	}
+}
+
+// GenRecord 按字段顺序生成 Parquet 行数据以及分区目录
+func (p *ParquetTable) GenRecord(rowValues []string) (ParquetRecord, error) {
+	var rec ParquetRecord
+	if len(rowValues) != len(p.Columns) {
+		return rec, fmt.Errorf("source schema table column counts vs data counts isn't match")
+	}
+
+	rawValues := make([]interface{}, len(rowValues))
+	for i, v := range rowValues {
+		rec.Bytes += len(v)
+		val, err := common.SQLValueToMessageValue(v)
+		if err != nil {
+			return rec, err
+		}
+		rawValues[i] = val
+	}
+
+	var dirs []string
+	for _, part := range p.Partitions {
+		dirs = append(dirs, common.StringsBuilder(part.Name, "=", part.partitionValue(rawValues[part.Index])))
+	}
+	rec.Partition = filepath.Join(dirs...)
+
+	for _, i := range p.DataIndex {
+		val, err := p.Columns[i].value(rawValues[i])
+		if err != nil {
+			return rec, err
+		}
+		rec.Values = append(rec.Values, val)
+	}
+	return rec, nil
+}
+
+// Hive 风格分区值，NULL 使用默认分区，特殊字符 %XX 转义
+func (p ParquetPartition) partitionValue(rawValue interface{}) string {
+	if rawValue == nil {
+		return common.ParquetDefaultPartitionNull
+	}
+	var str string
+	switch v := rawValue.(type) {
+	case []byte:
+		str = fmt.Sprintf("%X", v)
+	default:
+		str = fmt.Sprintf("%v", v)
+	}
+	switch {
+	case p.Granularity == parquetPartitionYear && len(str) >= 4:
+		str = str[:4]
+	case p.Granularity == parquetPartitionMonth && len(str) >= 7:
+		str = str[:7]
+	case p.Granularity == parquetPartitionDay && len(str) >= 10:
+		str = str[:10]
+	}
+	if str == "" {
+		return common.ParquetDefaultPartitionNull
+	}
+
+	var b strings.Builder
+	for _, r := range str {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`"#%'*/:=?\{[]^`, r) {
+			b.WriteString(fmt.Sprintf("%%%02X", r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type parquetFile struct {
+	file   FileWriter
+	writer *writer.CSVWriter
+}
+
+// ParquetWriter 按分区目录写入 chunk parquet 文件
+// 非分区：OutputDir/SCHEMA/TABLE/TARGETSCHEMA.TABLE.N.parquet
+// 分区：OutputDir/SCHEMA/TABLE/COL=VAL/TARGETSCHEMA.TABLE.N.parquet
+type ParquetWriter struct {
+	table        *ParquetTable
+	chunkFile    string
+	rowGroupSize int64
+	compression  parquet.CompressionCodec
+	storage      *s3.S3
+	files        map[string]*parquetFile
+}
+
+func NewParquetWriter(chunkFile string, table *ParquetTable, csvCfg config.CSVConfig, storage *s3.S3) (*ParquetWriter, error) {
+	compression, err := parquet.CompressionCodecFromString(common.StringUPPER(csvCfg.Compression))
+	if err != nil {
+		return nil, fmt.Errorf("parquet compression [%s] isn't support: %v", csvCfg.Compression, err)
+	}
+	return &ParquetWriter{
+		table:        table,
+		chunkFile:    chunkFile,
+		rowGroupSize: int64(csvCfg.RowGroupSize) * 1024 * 1024,
+		compression:  compression,
+		storage:      storage,
+		files:        make(map[string]*parquetFile),
+	}, nil
+}
+
+func (w *ParquetWriter) Write(rec ParquetRecord) error {
+	pf, ok := w.files[rec.Partition]
+	if !ok {
+		var err error
+		pf, err = w.open(rec.Partition)
+		if err != nil {
+			return err
+		}
+		w.files[rec.Partition] = pf
+	}
+	if err := pf.writer.Write(rec.Values); err != nil {
+		return fmt.Errorf("failed to write data row to parquet file [%s]: %v", pf.file.Name(), err)
+	}
+	return nil
+}
+
+func (w *ParquetWriter) open(partition string) (*parquetFile, error) {
+	fileW, err := NewFileWriter(filepath.Join(filepath.Dir(w.chunkFile), partition, filepath.Base(w.chunkFile)), w.storage)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := writer.NewCSVWriterFromWriter(w.table.Metadata, fileW, 4)
+	if err != nil {
+		fileW.Abort(err)
+		return nil, fmt.Errorf("create parquet file [%s] writer failed: %v", fileW.Name(), err)
+	}
+	pw.RowGroupSize = w.rowGroupSize
+	pw.CompressionType = w.compression
+	return &parquetFile{file: fileW, writer: pw}, nil
+}
+
+// Close 写入 parquet footer 并关闭文件，非分区 chunk 无数据仍生成空文件
+func (w *ParquetWriter) Close() error {
+	if len(w.files) == 0 && len(w.table.Partitions) == 0 {
+		pf, err := w.open("")
+		if err != nil {
+			return err
+		}
+		w.files[""] = pf
+	}
+	var errs []string
+	for _, pf := range w.files {
+		if err := pf.writer.WriteStop(); err != nil {
+			errs = append(errs, fmt.Sprintf("parquet file [%s] write stop failed: %v", pf.file.Name(), err))
+			pf.file.Abort(err)
+			continue
+		}
+		if err := pf.file.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("parquet file [%s] close failed: %v", pf.file.Name(), err))
+		}
+	}
+	w.files = make(map[string]*parquetFile)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Abort 写入失败取消所有分区文件
+func (w *ParquetWriter) Abort(err error) {
+	for _, pf := range w.files {
+		pf.file.Abort(err)
+	}
+	w.files = make(map[string]*parquetFile)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/s3"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileWriter 导出文件写入，本地文件或者 S3/OSS 对象
+// 写入失败调用 Abort，对象存储取消 multipart 上传，不生成不完整对象
+type FileWriter interface {
+	io.WriteCloser
+	Name() string
+	Abort(err error)
+}
+
+type localFileWriter struct {
+	*os.File
+}
+
+func (f *localFileWriter) Abort(err error) {
+	// 本地文件保留，重新运行 chunk 时覆盖写
+	_ = f.File.Close()
+}
+
+// NewFileWriter storage 为空写本地文件，否则以文件路径作为对象 key 流式上传
+func NewFileWriter(file string, storage *s3.S3) (FileWriter, error) {
+	if storage != nil {
+		return storage.NewWriter(file), nil
+	}
+	if err := common.PathExist(filepath.Dir(file)); err != nil {
+		return nil, err
+	}
+	fileW, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &localFileWriter{File: fileW}, nil
+}
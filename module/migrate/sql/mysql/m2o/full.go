@@ -0,0 +1,241 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package m2o
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	reverseM2O "github.com/wentaojin/transferdb/module/reverse/mysql/public"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Migrate struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	MySQL  *mysql.MySQL
+	Oracle *oracle.Oracle
+}
+
+func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	mysqlDB.Throttle = common.NewThrottle(cfg.AppConfig.ExtractRowsPerSecond, cfg.AppConfig.ExtractMBPerSecond)
+	oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig, cfg.SchemaConfig.TargetSchema)
+	if err != nil {
+		return nil, err
+	}
+	oracleDB.Throttle = common.NewThrottle(cfg.AppConfig.ApplyRowsPerSecond, cfg.AppConfig.ApplyMBPerSecond)
+	return &Migrate{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		MySQL:  mysqlDB,
+		Oracle: oracleDB,
+	}, nil
+}
+
+// Full mysql -> oracle 全量数据同步（回迁），表级别单 SQL 一致性读取，预编译绑定变量批量写入
+// 未配置 migrate-config range 每次运行清理下游表数据重新同步，配置 range 只追加写入 range 范围数据，用于切换窗口期按时间字段等条件补齐回迁数据
+// 不支持断点续传，表结构需提前通过 reverse mysql -> oracle 创建
+func (r *Migrate) Full() error {
+	startTime := time.Now()
+	zap.L().Info("source schema full table data sync start",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+
+	// 获取配置文件待同步表列表
+	exporters, _, err := reverseM2O.FilterCFGTable(r.Cfg, r.MySQL)
+	if err != nil {
+		return err
+	}
+
+	// dry-run 只输出待同步表列表，不执行迁移
+	if r.Cfg.DryRun {
+		public.DryRunCFGTable(r.Cfg, exporters)
+		return nil
+	}
+
+	var (
+		mu           sync.Mutex
+		failedTables []string
+	)
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.FullConfig.TableThreads)
+
+	for _, table := range exporters {
+		t := table
+		g.Go(func() error {
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，未开始表不再同步
+			if signal.IsShutdown() {
+				return nil
+			}
+			if err := r.syncTable(t); err != nil {
+				zap.L().Error("full table data sync failed",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", t),
+					zap.Error(err))
+				mu.Lock()
+				failedTables = append(failedTables, t)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	if len(failedTables) > 0 {
+		return fmt.Errorf("source schema [%s] full table data sync failed tables [%v], please see the log and rerunning", r.Cfg.SchemaConfig.SourceSchema, failedTables)
+	}
+	zap.L().Info("source schema full table data finished",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.Int("table totals", len(exporters)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (r *Migrate) syncTable(sourceTable string) error {
+	startTime := time.Now()
+	// 与 reverse mysql -> oracle 保持一致，oracle 对象名不加双引号
+	targetSchema := common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema)
+	if targetSchema == "" {
+		targetSchema = common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema)
+	}
+	targetTable := common.StringUPPER(sourceTable)
+
+	columnDetail, insertSQL, err := r.AdjustTableSelectColumn(sourceTable, targetSchema, targetTable)
+	if err != nil {
+		return err
+	}
+
+	var rangeWhere string
+	for _, t := range r.Cfg.SchemaConfig.MigrateConfig {
+		if strings.EqualFold(t.SourceTable, sourceTable) && !strings.EqualFold(t.Range, "") {
+			rangeWhere = t.Range
+		}
+	}
+
+	querySQL := common.StringsBuilder("SELECT ", columnDetail, " FROM `", r.Cfg.SchemaConfig.SourceSchema, "`.`", sourceTable, "`")
+	if rangeWhere == "" {
+		// 清理已有表数据
+		if err = r.Oracle.TruncateOracleTable(targetSchema, targetTable); err != nil {
+			return err
+		}
+	} else {
+		querySQL = common.StringsBuilder(querySQL, " WHERE (", rangeWhere, ")")
+	}
+
+	var rowCounts int64
+	dataChan := make(chan [][]interface{}, common.ChannelBufferSize)
+	g := &errgroup.Group{}
+	g.Go(func() error {
+		defer close(dataChan)
+		return r.MySQL.GetMySQLTableRowsData(querySQL, r.Cfg.AppConfig.InsertBatchSize, dataChan)
+	})
+	g.Go(func() error {
+		var writeErr error
+		for rows := range dataChan {
+			// 写入失败继续消费通道数据，避免读取端阻塞
+			if writeErr != nil {
+				continue
+			}
+			// 目标端写入限速
+			var writeBytes int
+			for _, row := range rows {
+				for _, v := range row {
+					switch val := v.(type) {
+					case string:
+						writeBytes += len(val)
+					case []byte:
+						writeBytes += len(val)
+					}
+				}
+			}
+			if err := r.Oracle.Throttle.Wait(r.Ctx, len(rows), writeBytes); err != nil {
+				writeErr = err
+				continue
+			}
+			affectRows, err := r.Oracle.InsertOracleTable(insertSQL, rows)
+			if err != nil {
+				writeErr = err
+				continue
+			}
+			rowCounts += affectRows
+		}
+		return writeErr
+	})
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	zap.L().Info("full table data sync finished",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", sourceTable),
+		zap.String("range", rangeWhere),
+		zap.Int64("rows", rowCounts),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// AdjustTableSelectColumn 返回 mysql 查询字段以及 oracle 写入语句
+// 时间类型按 mysql 默认输出格式 TO_DATE/TO_TIMESTAMP 显式转换，避免依赖 oracle 会话 NLS_DATE_FORMAT
+func (r *Migrate) AdjustTableSelectColumn(sourceTable, targetSchema, targetTable string) (string, string, error) {
+	columnsINFO, err := r.MySQL.GetMySQLTableColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable)
+	if err != nil {
+		return "", "", err
+	}
+	if len(columnsINFO) == 0 {
+		return "", "", fmt.Errorf("mysql schema [%s] table [%s] column isn't exist", r.Cfg.SchemaConfig.SourceSchema, sourceTable)
+	}
+
+	var columnDetails, targetColumns, bindValues []string
+	for i, rowCol := range columnsINFO {
+		columnName := rowCol["COLUMN_NAME"]
+		columnDetails = append(columnDetails, common.StringsBuilder("`", columnName, "`"))
+		targetColumns = append(targetColumns, common.StringUPPER(columnName))
+
+		bind := fmt.Sprintf(":%d", i+1)
+		switch common.StringUPPER(rowCol["DATA_TYPE"]) {
+		case common.BuildInMySQLDatatypeDate:
+			bindValues = append(bindValues, fmt.Sprintf("TO_DATE(%s,'YYYY-MM-DD')", bind))
+		case common.BuildInMySQLDatatypeDatetime, common.BuildInMySQLDatatypeTimestamp:
+			bindValues = append(bindValues, fmt.Sprintf("TO_TIMESTAMP(%s,'YYYY-MM-DD HH24:MI:SS.FF')", bind))
+		case common.BuildInMySQLDatatypeTime:
+			// time -> date，日期部分固定 1970-01-01
+			bindValues = append(bindValues, fmt.Sprintf("TO_DATE('1970-01-01 ' || %s,'YYYY-MM-DD HH24:MI:SS')", bind))
+		default:
+			bindValues = append(bindValues, bind)
+		}
+	}
+
+	insertSQL := common.StringsBuilder("INSERT INTO ", targetSchema, ".", targetTable, " (", strings.Join(targetColumns, ","), ") VALUES (", strings.Join(bindValues, ","), ")")
+	return strings.Join(columnDetails, ","), insertSQL, nil
+}
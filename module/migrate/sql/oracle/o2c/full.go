@@ -0,0 +1,278 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2c
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/clickhouse"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Migrate struct {
+	Ctx        context.Context
+	Cfg        *config.Config
+	Oracle     *oracle.Oracle
+	ClickHouse *clickhouse.ClickHouse
+}
+
+func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
+	oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig, cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		return nil, err
+	}
+	oracleDB.Throttle = common.NewThrottle(cfg.AppConfig.ExtractRowsPerSecond, cfg.AppConfig.ExtractMBPerSecond)
+	oracleDB.Budget = common.NewMemoryBudget(cfg.FullConfig.MemoryBudget)
+	chDB, err := clickhouse.NewClickHouseDBEngine(ctx, cfg.ClickHouseConfig)
+	if err != nil {
+		return nil, err
+	}
+	chDB.Throttle = common.NewThrottle(cfg.AppConfig.ApplyRowsPerSecond, cfg.AppConfig.ApplyMBPerSecond)
+	return &Migrate{
+		Ctx:        ctx,
+		Cfg:        cfg,
+		Oracle:     oracleDB,
+		ClickHouse: chDB,
+	}, nil
+}
+
+// Full oracle -> clickhouse 全量数据同步，表级别一致性快照 SCN 读取，native 协议按 batch 批量写入
+// 不支持断点续传，每次运行清理下游表数据重新同步
+func (r *Migrate) Full() error {
+	startTime := time.Now()
+	zap.L().Info("source schema full table data sync start",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+
+	// 数据库字符集
+	// AMERICAN_AMERICA.AL32UTF8
+	charset, err := r.Oracle.GetOracleDBCharacterSet()
+	if err != nil {
+		return err
+	}
+	sourceDBCharset := strings.Split(charset, ".")[1]
+	if !strings.EqualFold(r.Cfg.OracleConfig.Charset, sourceDBCharset) {
+		return fmt.Errorf("oracle charset [%v] and oracle config charset [%v] aren't equal, please adjust oracle config charset", sourceDBCharset, r.Cfg.OracleConfig.Charset)
+	}
+	if _, ok := common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)]; !ok {
+		return fmt.Errorf("oracle current charset [%v] isn't support, support charset [%v]", r.Cfg.OracleConfig.Charset, common.MigrateOracleCharsetStringConvertMapping)
+	}
+
+	// 获取配置文件待同步表列表
+	exporters, err := public.FilterCFGTable(r.Cfg, r.Oracle)
+	if err != nil {
+		return err
+	}
+
+	// dry-run 只输出待同步表列表，不执行迁移
+	if r.Cfg.DryRun {
+		public.DryRunCFGTable(r.Cfg, exporters)
+		return nil
+	}
+
+	globalSCN, err := r.Oracle.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu           sync.Mutex
+		failedTables []string
+	)
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.FullConfig.TableThreads)
+
+	for _, table := range exporters {
+		t := table
+		g.Go(func() error {
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，未开始表不再同步
+			if signal.IsShutdown() {
+				return nil
+			}
+			if err := r.syncTable(t, globalSCN, sourceDBCharset); err != nil {
+				zap.L().Error("full table data sync failed",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", t),
+					zap.Error(err))
+				mu.Lock()
+				failedTables = append(failedTables, t)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	if len(failedTables) > 0 {
+		return fmt.Errorf("source schema [%s] full table data sync failed tables [%v], please see the log and rerunning", r.Cfg.SchemaConfig.SourceSchema, failedTables)
+	}
+	zap.L().Info("source schema full table data finished",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.Int("table totals", len(exporters)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (r *Migrate) syncTable(sourceTable string, globalSCN uint64, sourceDBCharset string) error {
+	startTime := time.Now()
+	targetSchema := common.ClickHouseIdentifierCase(r.Cfg.ReverseConfig.LowerCaseFieldName, r.Cfg.SchemaConfig.TargetSchema)
+	targetTable := common.ClickHouseIdentifierCase(r.Cfg.ReverseConfig.LowerCaseFieldName, sourceTable)
+
+	columnDetail, columnNames, err := r.AdjustTableSelectColumn(sourceTable)
+	if err != nil {
+		return err
+	}
+	// 下游字段类型，字段值按字段类型转换写入
+	columnTypes, err := r.ClickHouse.GetClickHouseTableColumnType(targetSchema, targetTable)
+	if err != nil {
+		return err
+	}
+	var targetColumns, targetColumnTypes []string
+	for _, c := range columnNames {
+		targetColumn := common.ClickHouseIdentifierCase(r.Cfg.ReverseConfig.LowerCaseFieldName, c)
+		columnType, ok := columnTypes[targetColumn]
+		if !ok {
+			return fmt.Errorf("clickhouse table [%s.%s] column [%s] isn't exist", targetSchema, targetTable, targetColumn)
+		}
+		targetColumns = append(targetColumns, targetColumn)
+		targetColumnTypes = append(targetColumnTypes, columnType)
+	}
+
+	// 清理已有表数据
+	if err = r.ClickHouse.TruncateClickHouseTable(targetSchema, targetTable); err != nil {
+		return err
+	}
+
+	var querySQL string
+	if r.Cfg.FullConfig.SQLHint == "" {
+		querySQL = common.StringsBuilder(`SELECT `, columnDetail, ` FROM "`, r.Cfg.SchemaConfig.SourceSchema, `"."`, sourceTable, `" AS OF SCN `, strconv.FormatUint(globalSCN, 10))
+	} else {
+		querySQL = common.StringsBuilder(`SELECT `, r.Cfg.FullConfig.SQLHint, ` `, columnDetail, ` FROM "`, r.Cfg.SchemaConfig.SourceSchema, `"."`, sourceTable, `" AS OF SCN `, strconv.FormatUint(globalSCN, 10))
+	}
+
+	var rowCounts int64
+	dataChan := make(chan [][]interface{}, common.ChannelBufferSize)
+	g := &errgroup.Group{}
+	g.Go(func() error {
+		defer close(dataChan)
+		return r.Oracle.GetOracleTableRowsDataDecoded(querySQL, r.Cfg.AppConfig.InsertBatchSize, common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(sourceDBCharset)],
+			r.Cfg.AppConfig.EmptyStringMode, r.Cfg.AppConfig.LOBMaxSize, r.Cfg.AppConfig.LOBOversizeMode, r.Cfg.AppConfig.CharsetErrorMode, dataChan)
+	})
+	g.Go(func() error {
+		var insertErr error
+		for rows := range dataChan {
+			// 批次字节数与抽取端按相同方式估算，写入前计算避免写入过程修改批次数据
+			batchBytes := oracle.DecodedRowsBytes(rows)
+			// 写入失败继续消费通道数据，避免读取端阻塞，批次出队释放内存预算
+			if insertErr != nil {
+				r.Oracle.Budget.Release(batchBytes)
+				continue
+			}
+			// 目标端写入限速，字节数按行数估算不做统计
+			if err := r.ClickHouse.Throttle.Wait(r.Ctx, len(rows), 0); err != nil {
+				r.Oracle.Budget.Release(batchBytes)
+				insertErr = err
+				continue
+			}
+			affectRows, err := r.ClickHouse.InsertClickHouseTable(targetSchema, targetTable, targetColumns, targetColumnTypes, rows)
+			// 批次写入完成（成功或者失败）释放内存预算
+			r.Oracle.Budget.Release(batchBytes)
+			if err != nil {
+				insertErr = err
+				continue
+			}
+			rowCounts += affectRows
+		}
+		return insertErr
+	})
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	zap.L().Info("full table data sync finished",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", sourceTable),
+		zap.Uint64("global scn", globalSCN),
+		zap.Int64("rows", rowCounts),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// AdjustTableSelectColumn 返回查询字段以及字段名，时间类型 TO_CHAR 统一格式化为 yyyy-mm-dd hh24:mi:ss[.ff9]，写入时按 UTC 解析
+func (r *Migrate) AdjustTableSelectColumn(sourceTable string) (string, []string, error) {
+	columnsINFO, err := r.Oracle.GetOracleSchemaTableColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable, false)
+	if err != nil {
+		return "", nil, err
+	}
+	// 字段投影，排除字段不抽取不写入
+	columnsINFO = r.Cfg.SchemaConfig.GetColumnProjection(sourceTable).ProjectColumnINFO(columnsINFO)
+
+	// 字段转换规则
+	columnTransforms := make(map[string]config.ColumnTransform)
+	for _, t := range r.Cfg.SchemaConfig.MigrateConfig {
+		if strings.EqualFold(t.SourceTable, sourceTable) {
+			for _, ct := range t.ColumnTransform {
+				columnTransforms[common.StringUPPER(ct.ColumnName)] = ct
+			}
+		}
+	}
+
+	var columnDetails, columnNames []string
+	for _, rowCol := range columnsINFO {
+		columnName := rowCol["COLUMN_NAME"]
+		columnNames = append(columnNames, columnName)
+		// 字段转换优先，转换表达式替换默认格式化
+		if ct, ok := columnTransforms[common.StringUPPER(columnName)]; ok {
+			expr, err := common.GenOracleColumnTransform(columnName, ct.Rule, ct.Algorithm, ct.Length, ct.Expression)
+			if err != nil {
+				return "", nil, err
+			}
+			columnDetails = append(columnDetails, expr)
+			continue
+		}
+		dataType := strings.ToUpper(rowCol["DATA_TYPE"])
+		switch {
+		case dataType == "XMLTYPE":
+			columnDetails = append(columnDetails, fmt.Sprintf(`XMLSERIALIZE(CONTENT "%s" AS CLOB) AS "%s"`, columnName, columnName))
+		case dataType == "DATE":
+			columnDetails = append(columnDetails, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-mm-dd hh24:mi:ss') AS "`, columnName, `"`))
+		case strings.Contains(dataType, "INTERVAL"):
+			columnDetails = append(columnDetails, common.StringsBuilder(`TO_CHAR("`, columnName, `") AS "`, columnName, `"`))
+		case strings.Contains(dataType, "TIMESTAMP"):
+			// 带时区时间按 target-time-zone 转换，输出时间字面值
+			timestampCol := common.GenOracleTimestampColumn(columnName, dataType, r.Cfg.AppConfig.TargetTimeZone)
+			columnDetails = append(columnDetails, common.StringsBuilder(`TO_CHAR(`, timestampCol, `,'yyyy-mm-dd hh24:mi:ss.ff9') AS "`, columnName, `"`))
+		default:
+			columnDetails = append(columnDetails, common.StringsBuilder(`"`, columnName, `"`))
+		}
+	}
+	return strings.Join(columnDetails, ","), columnNames, nil
+}
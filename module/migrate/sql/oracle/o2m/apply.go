@@ -22,31 +22,43 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/kafka"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"strings"
 	"sync"
+	"time"
 )
 
 type IncrTask struct {
-	Ctx            context.Context `json:"-"`
-	DBTypeS        string          `json:"db_type_s"`
-	DBTypeT        string          `json:"db_type_t"`
-	TaskMode       string          `json:"task_mode"`
-	GlobalSCN      uint64          `json:"global_scn"`
-	SourceTableSCN uint64          `json:"source_table_scn"`
-	SourceSchema   string          `json:"source_schema"`
-	SourceTable    string          `json:"source_table"`
-	TargetSchema   string          `json:"target_schema"`
-	TargetTable    string          `json:"target_table"`
-	Operation      string          `json:"operation"`
-	OracleRedo     string          `json:"oracle_redo"` // Oracle SQL
-	MySQLRedo      []string        `json:"mysql_redo"`  // MySQL 待执行 SQL
-	OperationType  string          `json:"operation_type"`
-	MySQL          *mysql.MySQL    `json:"-"`
-	MetaDB         *meta.Meta      `json:"-"`
+	Ctx            context.Context    `json:"-"`
+	DBTypeS        string             `json:"db_type_s"`
+	DBTypeT        string             `json:"db_type_t"`
+	TaskMode       string             `json:"task_mode"`
+	Seq            uint64             `json:"seq"`
+	GlobalSCN      uint64             `json:"global_scn"`
+	SourceTableSCN uint64             `json:"source_table_scn"`
+	SourceSchema   string             `json:"source_schema"`
+	SourceTable    string             `json:"source_table"`
+	TargetSchema   string             `json:"target_schema"`
+	TargetTable    string             `json:"target_table"`
+	Operation      string             `json:"operation"`
+	OracleRedo     string             `json:"oracle_redo"` // Oracle SQL
+	MySQLRedo      []string           `json:"mysql_redo"`  // MySQL 待执行 SQL
+	OperationType  string             `json:"operation_type"`
+	MySQL          *mysql.MySQL       `json:"-"`
+	Kafka          *kafka.Kafka       `json:"-"` // sink-type kafka 变更事件发布替代下游应用
+	Event          *kafka.ChangeEvent `json:"-"`
+	MetaDB         *meta.Meta         `json:"-"`
+	RetryPolicy    common.RetryPolicy `json:"-"`
+	ConflictPolicy string             `json:"conflict_policy"`
+	RowKeys        []string           `json:"row_keys"` // 行级路由键，主键/唯一键字段值相同记录同一 worker 按序应用
+	Barrier        bool               `json:"barrier"`  // 屏障记录，等待已分配记录应用完成后串行应用
 }
 
 type IncrResult struct {
@@ -55,25 +67,42 @@ type IncrResult struct {
 }
 
 // 应用当前日志文件中所有记录
-func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.Config, logminerMap map[string][]public.Logminer) error {
+func applyOracleIncrRecord(metaDB *meta.Meta, oracleDB *oracle.Oracle, mysqlDB *mysql.MySQL, kafkaSink *kafka.Kafka, cfg *config.Config, applyThreads int, logminerMap map[string][]public.Logminer) error {
+	// 字段名映射规则
+	columnNameRuleMap, err := meta.NewColumnNameRuleModel(metaDB).DetailColumnNameRuleMap(mysqlDB.Ctx, &meta.ColumnNameRule{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+
 	g := &errgroup.Group{}
-	g.SetLimit(cfg.AllConfig.ApplyThreads)
+	g.SetLimit(applyThreads)
 
 	for tableName, lcs := range logminerMap {
 		rowsResult := lcs
 		sourceTable := tableName
 		g.Go(func() error {
 			if len(rowsResult) > 0 {
+				// 行级路由键字段
+				keySets, err := public.GetOracleTableKeyColumns(oracleDB, cfg.SchemaConfig.SourceSchema, sourceTable)
+				if err != nil {
+					return err
+				}
 				var (
 					done        = make(chan bool)
 					taskQueue   = make(chan IncrTask, cfg.AllConfig.WorkerQueue)
 					resultQueue = make(chan IncrResult, cfg.AllConfig.WorkerQueue)
+					errQueue    = make(chan error, 1)
 				)
-				// 获取增量执行结果
-				go getIncrResult(done, resultQueue)
+				// 获取增量执行结果，按捕获顺序推进表级别 checkpoint
+				go getIncrResult(done, resultQueue, public.NewSCNCheckpoint(rowsResult))
 
 				// 转换捕获内容以及数据应用
 				go func(mysql *mysql.MySQL, sourceSchema, sourceTable string, rowsResult []public.Logminer, taskQueue chan IncrTask) {
+					defer close(errQueue)
 					defer func() {
 						if err := recover(); err != nil {
 							zap.L().Fatal("translatorAndApplyOracleIncrementRecord",
@@ -88,17 +117,25 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 						cfg.TaskMode,
 						sourceSchema,
 						sourceTable,
+						cfg.AllConfig.DDLMode,
+						cfg.AllConfig.ConflictPolicy,
 						metaDB,
+						oracleDB,
 						mysql,
-						rowsResult, taskQueue); err != nil {
-						return
+						kafkaSink,
+						common.NewRetryPolicy(cfg.AppConfig.RetryAttempts, cfg.AppConfig.RetryBackoff, cfg.AppConfig.RetryMaxBackoff),
+						keySets, cfg.SchemaConfig.GetColumnProjection(sourceTable), columnNameRuleMap[common.StringUPPER(sourceTable)], cfg.SchemaConfig.GetOperationFilter(sourceTable), rowsResult, taskQueue); err != nil {
+						errQueue <- err
 					}
 				}(mysqlDB, cfg.SchemaConfig.SourceSchema, sourceTable, rowsResult, taskQueue)
 
 				// 必须在任务分配和获取结果后创建工作池
 				go createWorkerPool(cfg.AllConfig.WorkerThreads, taskQueue, resultQueue)
-				// 等待执行完成
+				// 等待执行完成，已注册任务应用完成后返回转换错误
 				<-done
+				if err := <-errQueue; err != nil {
+					return fmt.Errorf("oracle schema [%s] table [%s] increment record translate failed: %v", cfg.SchemaConfig.SourceSchema, sourceTable, err)
+				}
 
 				return nil
 			}
@@ -120,31 +157,18 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 func (p *IncrTask) IncrApply() error {
 	// 数据写入并更新元数据表
 	//zap.L().Info("increment applier sql", zap.String("sql", sql))
-	if p.OperationType == common.MigrateOperationUpdate {
-		// update 语句拆分 delete/replace 放一个事务内
-		txn, err := p.MySQL.MySQLDB.BeginTx(p.Ctx, &sql.TxOptions{})
-		if err != nil {
-			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql redo [%v] transaction start falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-		}
-		for _, sql := range p.MySQLRedo {
-			if _, err = txn.ExecContext(p.Ctx, sql); err != nil {
-				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction doing falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-			}
-		}
-		if err = txn.Commit(); err != nil {
-			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction commit falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-		}
-	} else {
-		for _, s := range p.MySQLRedo {
-			_, err := p.MySQL.MySQLDB.ExecContext(p.Ctx, s)
-			if err != nil {
-				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] exec falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-			}
+	// sink-type kafka 变更事件发布替代下游应用
+	if p.Kafka != nil {
+		if err := p.incrPublish(); err != nil {
+			return err
 		}
+	} else if err := p.incrApplyTarget(); err != nil {
+		return err
 	}
-	// 数据写入完毕，更新元数据 checkpoint 表
-	// 如果同步中断，数据同步使用会以 global_scn_s 为准，也就是会进行重复消费
-	if p.Operation == common.MigrateOperationDropTable {
+
+	// 数据写入完毕，drop table 清理元数据，其他操作由 getIncrResult 按捕获顺序推进 checkpoint
+	// 如果同步中断，数据同步使用会以 table_scn_s 为准，checkpoint SCN 相同记录会重复消费
+	if p.OperationType == common.MigrateOperationDropTable {
 		err := meta.NewCommonModel(p.MetaDB).DeleteIncrSyncMetaAndWaitSyncMeta(p.Ctx, &meta.IncrSyncMeta{
 			DBTypeS:     p.DBTypeS,
 			DBTypeT:     p.DBTypeT,
@@ -163,21 +187,139 @@ func (p *IncrTask) IncrApply() error {
 				zap.Error(err))
 			return err
 		}
-	} else {
-		err := meta.NewIncrSyncMetaModel(p.MetaDB).UpdateIncrSyncMeta(p.Ctx, &meta.IncrSyncMeta{
-			DBTypeS:     p.DBTypeS,
-			DBTypeT:     p.DBTypeT,
-			SchemaNameS: p.SourceSchema,
-			TableNameS:  p.SourceTable,
-			GlobalScnS:  p.GlobalSCN,
-			TableScnS:   p.SourceTableSCN,
-		})
+	}
+	return nil
+}
+
+// 增量数据写入目标端，瞬时错误重试
+func (p *IncrTask) incrApplyTarget() error {
+	// 目标端写入限速，每条增量记录计一行
+	var redoBytes int
+	for _, s := range p.MySQLRedo {
+		redoBytes += len(s)
+	}
+	if err := p.MySQL.Throttle.Wait(p.Ctx, 1, redoBytes); err != nil {
+		return err
+	}
+	return common.Retry(p.Ctx, p.RetryPolicy, p.incrApplyRedo, func(attempt int, err error) {
+		metrics.RetryCounter.WithLabelValues(p.TargetSchema, p.TargetTable, "incr_apply").Inc()
+		zap.L().Warn("single increment table data apply retry",
+			zap.String("task", p.String()),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	})
+}
+
+// 更新表级别增量 checkpoint，scn 为连续应用完成记录的最大 SCN
+func (p *IncrTask) updateCheckpoint(scn uint64) error {
+	err := meta.NewIncrSyncMetaModel(p.MetaDB).UpdateIncrSyncMeta(p.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     p.DBTypeS,
+		DBTypeT:     p.DBTypeT,
+		SchemaNameS: p.SourceSchema,
+		TableNameS:  p.SourceTable,
+		GlobalScnS:  scn,
+		TableScnS:   scn,
+	})
+	if err != nil {
+		zap.L().Error("update table increment scn record failed",
+			zap.String("task", p.String()),
+			zap.Uint64("checkpoint scn", scn),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// 增量数据写入下游，update 语句拆分 delete/replace 放一个事务内，失败回滚便于重试
+// UPDATE/DELETE 影响行数为 0 以及 INSERT 主键/唯一键冲突按 conflict-policy 处理
+func (p *IncrTask) incrApplyRedo() error {
+	if p.OperationType == common.MigrateOperationUpdate && len(p.MySQLRedo) > 1 {
+		var affectRows int64
+		txn, err := p.MySQL.MySQLDB.BeginTx(p.Ctx, &sql.TxOptions{})
 		if err != nil {
-			zap.L().Error("update table increment scn record failed",
-				zap.String("task", p.String()),
-				zap.Error(err))
-			return err
+			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql redo [%v] transaction start falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+		}
+		for i, sql := range p.MySQLRedo {
+			execTime := time.Now()
+			res, err := txn.ExecContext(p.Ctx, sql)
+			if err != nil {
+				_ = txn.Rollback()
+				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction doing falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+			}
+			rows, _ := res.RowsAffected()
+			p.MySQL.SlowLog.Observe(sql, time.Since(execTime), rows)
+			// delete 影响行数
+			if i == 0 {
+				affectRows, _ = res.RowsAffected()
+			}
 		}
+		if err = txn.Commit(); err != nil {
+			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction commit falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+		}
+		if affectRows == 0 {
+			return p.incrConflict(common.ConflictTypeZeroRows, nil)
+		}
+	} else {
+		for _, s := range p.MySQLRedo {
+			execTime := time.Now()
+			res, err := p.MySQL.MySQLDB.ExecContext(p.Ctx, s)
+			p.MySQL.AuditDDL(s, err)
+			if err != nil {
+				if p.OperationType == common.MigrateOperationInsert && mysql.IsDuplicateEntryError(err) {
+					return p.incrConflict(common.ConflictTypeDuplicateKey, err)
+				}
+				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] exec falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+			}
+			affectRows, _ := res.RowsAffected()
+			p.MySQL.SlowLog.Observe(s, time.Since(execTime), affectRows)
+			switch {
+			case p.OperationType == common.MigrateOperationInsert && affectRows > 1:
+				// REPLACE INTO 覆盖已存在记录，影响行数为 2
+				return p.incrConflict(common.ConflictTypeDuplicateKey, nil)
+			case (p.OperationType == common.MigrateOperationUpdate || p.OperationType == common.MigrateOperationDelete) && affectRows == 0:
+				return p.incrConflict(common.ConflictTypeZeroRows, nil)
+			}
+		}
+	}
+	return nil
+}
+
+// 增量冲突记录审计表，conflict-policy error 报错退出，skip/overwrite 记录后继续
+func (p *IncrTask) incrConflict(conflictType string, conflictErr error) error {
+	policy := common.StringUPPER(p.ConflictPolicy)
+	if policy == "" {
+		policy = common.ConflictPolicyOverwrite
+	}
+	var errDetail string
+	if conflictErr != nil {
+		errDetail = conflictErr.Error()
+	}
+	zap.L().Warn("single increment table data apply conflict",
+		zap.String("task", p.String()),
+		zap.String("conflict type", conflictType),
+		zap.String("conflict policy", policy),
+		zap.String("error", errDetail))
+
+	if err := meta.NewConflictLogDetailModel(p.MetaDB).CreateConflictLog(p.Ctx, &meta.ConflictLogDetail{
+		DBTypeS:        p.DBTypeS,
+		DBTypeT:        p.DBTypeT,
+		SchemaNameS:    p.SourceSchema,
+		TableNameS:     p.SourceTable,
+		SchemaNameT:    p.TargetSchema,
+		TableNameT:     p.TargetTable,
+		SourceScn:      p.SourceTableSCN,
+		Operation:      p.OperationType,
+		ConflictType:   conflictType,
+		ConflictPolicy: policy,
+		SourceRedo:     p.OracleRedo,
+		TargetRedo:     strings.Join(p.MySQLRedo, ";"),
+		ErrorDetail:    errDetail,
+	}); err != nil {
+		return err
+	}
+
+	if policy == common.ConflictPolicyError {
+		return fmt.Errorf("single increment table [%s] data oracle redo [%v] apply mysql [%v] conflict [%s] by conflict-policy error", p.SourceTable, p.OracleRedo, p.MySQLRedo, conflictType)
 	}
 	return nil
 }
@@ -193,28 +335,66 @@ func (p *IncrTask) String() string {
 	return string(b)
 }
 
+// 按行级路由键分配 worker 队列，主键/唯一键字段值相同记录由同一 worker 按序应用，不同行并行应用
+// 屏障记录以及路由键对应不同 worker 的记录等待已分配记录全部应用完成后单独应用，完成后再继续分配
 func createWorkerPool(numOfWorkers int, jobQueue chan IncrTask, resultQueue chan IncrResult) {
-	var wg sync.WaitGroup
+	if numOfWorkers <= 0 {
+		numOfWorkers = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		inflight sync.WaitGroup
+		queues   = make([]chan IncrTask, numOfWorkers)
+	)
 	for i := 0; i < numOfWorkers; i++ {
+		queues[i] = make(chan IncrTask, cap(jobQueue))
 		wg.Add(1)
-		go worker(&wg, jobQueue, resultQueue)
+		go worker(&wg, &inflight, queues[i], resultQueue)
+	}
+
+	for job := range jobQueue {
+		w, ok := public.IncrRowKeyWorker(job.RowKeys, numOfWorkers)
+		if job.Barrier || !ok {
+			inflight.Wait()
+			inflight.Add(1)
+			queues[0] <- job
+			inflight.Wait()
+			continue
+		}
+		inflight.Add(1)
+		queues[w] <- job
+	}
+
+	for _, q := range queues {
+		close(q)
 	}
 	wg.Wait()
 	close(resultQueue)
 }
 
-func getIncrResult(done chan bool, resultQueue chan IncrResult) {
+func getIncrResult(done chan bool, resultQueue chan IncrResult, checkpoint *public.SCNCheckpoint) {
 	for result := range resultQueue {
 		if result.Err != nil {
 			zap.L().Fatal("task increment table record",
 				zap.String("payload", result.Task.String()),
 				zap.Error(result.Err))
 		}
+		if result.Task.OperationType == common.MigrateOperationDropTable {
+			continue
+		}
+		// 只推进至连续应用完成记录的 SCN，避免并发乱序应用中断导致丢失数据
+		if scn, ok := checkpoint.Done(result.Task.Seq); ok {
+			if err := result.Task.updateCheckpoint(scn); err != nil {
+				zap.L().Fatal("task increment table checkpoint",
+					zap.String("payload", result.Task.String()),
+					zap.Error(err))
+			}
+		}
 	}
 	done <- true
 }
 
-func worker(wg *sync.WaitGroup, jobQueue chan IncrTask, resultQueue chan IncrResult) {
+func worker(wg *sync.WaitGroup, inflight *sync.WaitGroup, jobQueue chan IncrTask, resultQueue chan IncrResult) {
 	defer wg.Done()
 	for job := range jobQueue {
 		if err := job.IncrApply(); err != nil {
@@ -223,11 +403,14 @@ func worker(wg *sync.WaitGroup, jobQueue chan IncrTask, resultQueue chan IncrRes
 				Err:  err,
 			}
 			resultQueue <- result
+			inflight.Done()
+			continue
 		}
 		result := IncrResult{
 			Task: job,
 			Err:  nil,
 		}
 		resultQueue <- result
+		inflight.Done()
 	}
 }
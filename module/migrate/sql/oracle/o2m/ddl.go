@@ -0,0 +1,172 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	checkPublic "github.com/wentaojin/transferdb/module/check/oracle/public"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// Oracle 增量 DDL 转换
+// 1、TRUNCATE TABLE / DROP TABLE 沿用 DML 解析转换
+// 2、ADD / MODIFY COLUMN 字段类型、默认值按表结构转换规则（内置以及自定义规则）基于源端当前字典生成
+// 3、COMMENT ON TABLE / COLUMN 注释基于源端当前字典生成，字段注释 MODIFY COLUMN 完整字段定义
+// 4、不支持得 DDL 记录日志，返回空语句不应用
+func translateOracleDDLToMySQLSQL(dbTypeS, dbTypeT string, metaDB *meta.Meta, oracle *oracle.Oracle, projection *config.ColumnProjection, rows public.Logminer) ([]string, string, error) {
+	targetSchema := common.StringUPPER(rows.TargetSchema)
+	targetTable := common.StringUPPER(rows.TargetTable)
+	targetName := common.QuoteMySQLTableName(targetSchema, targetTable)
+
+	ddl, err := public.ParseOracleDDL(rows.SQLRedo)
+	if err != nil {
+		zap.L().Warn("oracle ddl isn't support, ddl doesn't apply",
+			zap.String("oracle schema", rows.SourceSchema),
+			zap.String("oracle table", rows.SourceTable),
+			zap.Error(err))
+		return []string{}, common.MigrateOperationDDL, nil
+	}
+
+	// 字段投影，排除字段相关 DDL 不应用，引用排除字段的索引不创建
+	if projection != nil && len(ddl.Columns) > 0 {
+		var columns []string
+		for _, c := range ddl.Columns {
+			if fields := strings.Fields(c); len(fields) == 0 || projection.IsProjected(fields[0]) {
+				columns = append(columns, c)
+			}
+		}
+		if len(columns) == 0 || (ddl.Operation == common.MigrateOperationCreateIndex && len(columns) != len(ddl.Columns)) {
+			zap.L().Warn("oracle ddl reference exclude column, ddl doesn't apply",
+				zap.String("oracle schema", rows.SourceSchema),
+				zap.String("oracle table", rows.SourceTable),
+				zap.String("oracle ddl", rows.SQLRedo),
+				zap.String("operation", ddl.Operation))
+			return []string{}, ddl.Operation, nil
+		}
+		ddl.Columns = columns
+	}
+
+	switch ddl.Operation {
+	case common.MigrateOperationTruncateTable, common.MigrateOperationDropTable:
+		return translateOracleToMySQLSQL(rows.SQLRedo, rows.SQLUndo, targetSchema, targetTable, common.ConflictPolicyOverwrite, nil, nil)
+	case common.MigrateOperationAddColumn, common.MigrateOperationModifyColumn, common.MigrateOperationCommentColumn:
+		columnMetas, err := genOracleDDLColumnMeta(dbTypeS, dbTypeT, metaDB, oracle, rows.SourceSchema, rows.SourceTable, ddl.Columns)
+		if err != nil {
+			return []string{}, ddl.Operation, err
+		}
+		action := ddl.Operation
+		if ddl.Operation == common.MigrateOperationCommentColumn {
+			action = common.MigrateOperationModifyColumn
+		}
+		var actions []string
+		for _, c := range columnMetas {
+			actions = append(actions, common.StringsBuilder(action, " ", c))
+		}
+		return []string{common.StringsBuilder(`ALTER TABLE `, targetName, ` `, strings.Join(actions, ","))}, ddl.Operation, nil
+	case common.MigrateOperationCommentTable:
+		comments, err := oracle.GetOracleSchemaTableComment(rows.SourceSchema, rows.SourceTable)
+		if err != nil {
+			return []string{}, ddl.Operation, err
+		}
+		var comment string
+		if len(comments) > 0 {
+			comment = common.SpecialLettersUsingMySQL([]byte(comments[0]["COMMENTS"]))
+		}
+		return []string{common.StringsBuilder(`ALTER TABLE `, targetName, ` COMMENT = '`, comment, `'`)}, ddl.Operation, nil
+	case common.MigrateOperationDropColumn:
+		var actions []string
+		for _, c := range ddl.Columns {
+			actions = append(actions, common.StringsBuilder(ddl.Operation, " `", common.StringUPPER(c), "`"))
+		}
+		return []string{common.StringsBuilder(`ALTER TABLE `, targetName, ` `, strings.Join(actions, ","))}, ddl.Operation, nil
+	case common.MigrateOperationRenameColumn:
+		return []string{common.StringsBuilder(`ALTER TABLE `, targetName,
+			` RENAME COLUMN `, "`", common.StringUPPER(ddl.Columns[0]), "` TO `", common.StringUPPER(ddl.NewColumn), "`")}, ddl.Operation, nil
+	case common.MigrateOperationCreateIndex:
+		var columns []string
+		for _, c := range ddl.Columns {
+			fields := strings.Fields(c)
+			fields[0] = common.StringsBuilder("`", common.StringUPPER(fields[0]), "`")
+			columns = append(columns, strings.Join(fields, " "))
+		}
+		prefix := `CREATE INDEX `
+		if ddl.Unique {
+			prefix = `CREATE UNIQUE INDEX `
+		}
+		return []string{common.StringsBuilder(prefix, "`", common.StringUPPER(ddl.Index), "` ON ", targetName,
+			" (", strings.Join(columns, ","), ")")}, ddl.Operation, nil
+	case common.MigrateOperationDropIndex:
+		return []string{common.StringsBuilder(`DROP INDEX `, "`", common.StringUPPER(ddl.Index), "` ON ", targetName)}, ddl.Operation, nil
+	default:
+		// CREATE TABLE 新增表未注册增量元数据，需 reverse 以及全量同步后纳入增量同步
+		zap.L().Warn("oracle ddl doesn't apply, please manual processing",
+			zap.String("oracle schema", rows.SourceSchema),
+			zap.String("oracle table", rows.SourceTable),
+			zap.String("oracle ddl", rows.SQLRedo),
+			zap.String("operation", ddl.Operation))
+		return []string{}, ddl.Operation, nil
+	}
+}
+
+// 基于源端字典生成下游字段定义
+func genOracleDDLColumnMeta(dbTypeS, dbTypeT string, metaDB *meta.Meta, oracle *oracle.Oracle, sourceSchema, sourceTable string, columns []string) ([]string, error) {
+	var columnMetas []string
+
+	columnInfo, err := oracle.GetOracleSchemaTableColumn(sourceSchema, sourceTable, false)
+	if err != nil {
+		return columnMetas, err
+	}
+	columnInfoMap := make(map[string]map[string]string, len(columnInfo))
+	for _, rowCol := range columnInfo {
+		columnInfoMap[common.StringUPPER(rowCol["COLUMN_NAME"])] = rowCol
+	}
+
+	for _, c := range columns {
+		rowCol, ok := columnInfoMap[common.StringUPPER(c)]
+		if !ok {
+			return columnMetas, fmt.Errorf("oracle schema [%s] table [%s] column [%s] isn't exist, please check", sourceSchema, sourceTable, c)
+		}
+		dataDefault := rowCol["DATA_DEFAULT"]
+		if strings.EqualFold(dataDefault, common.OracleNULLSTRINGTableAttrWithoutNULL) {
+			dataDefault = ""
+		}
+		columnMeta, err := checkPublic.GenOracleTableColumnMeta(oracle.Ctx, metaDB, dbTypeS, dbTypeT, sourceSchema, sourceTable, common.StringUPPER(c), checkPublic.Column{
+			DataType:                common.StringUPPER(rowCol["DATA_TYPE"]),
+			CharLength:              rowCol["CHAR_LENGTH"],
+			CharUsed:                rowCol["CHAR_USED"],
+			OracleOriginDataDefault: dataDefault,
+			ColumnInfo: checkPublic.ColumnInfo{
+				DataLength:    rowCol["DATA_LENGTH"],
+				DataPrecision: rowCol["DATA_PRECISION"],
+				DataScale:     rowCol["DATA_SCALE"],
+				NULLABLE:      rowCol["NULLABLE"],
+				DataDefault:   dataDefault,
+				Comment:       rowCol["COMMENTS"],
+			},
+		})
+		if err != nil {
+			return columnMetas, err
+		}
+		columnMetas = append(columnMetas, columnMeta)
+	}
+	return columnMetas, nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"golang.org/x/sync/errgroup"
+	"strings"
+)
+
+// DryRun 输出待迁移表统计信息行数、chunk 切分计划、源端抽取 SQL 以及基于首行数据生成的样例写入 SQL
+// 仅读取源端，不写入元数据库以及下游
+func (r *Migrate) DryRun(exporters []string, oracleCollation bool) error {
+	// 按实际迁移顺序输出
+	exporters, err := public.OrderTables(r.Cfg, r.Oracle, exporters)
+	if err != nil {
+		return err
+	}
+	public.DryRunCFGTable(r.Cfg, exporters)
+
+	tableNameRule, err := r.GetTableNameRule()
+	if err != nil {
+		return err
+	}
+	tableMigrateRule := r.GetCustomMigrateConfig()
+
+	var totalRows, totalChunks int
+	for _, t := range exporters {
+		targetTableName := common.StringUPPER(t)
+		if val, ok := tableNameRule[common.StringUPPER(t)]; ok {
+			targetTableName = val
+		}
+		sqlHint := r.Cfg.FullConfig.SQLHint
+		whereRange := `1 = 1`
+		loadData := false
+		if val, ok := tableMigrateRule[common.StringUPPER(t)]; ok {
+			sqlHint = val.SQLHint
+			loadData = val.LoadData
+			if val.EnableSplit && !strings.EqualFold(val.Range, "") {
+				whereRange = common.StringsBuilder(`1 = 1 AND (`, val.Range, `)`)
+			}
+		}
+
+		sourceColumnInfo, err := r.AdjustTableSelectColumn(t, oracleCollation)
+		if err != nil {
+			return err
+		}
+		tableRows, err := r.Oracle.GetOracleTableRowsByStatistics(r.Cfg.SchemaConfig.SourceSchema, t)
+		if err != nil {
+			return err
+		}
+		// 统计信息行数 0 全表扫描，否则按 ROWID 切分 chunk
+		chunks := 1
+		chunkDetail := whereRange
+		if tableRows > 0 && r.Cfg.FullConfig.ChunkSize > 0 {
+			chunks = (tableRows + r.Cfg.FullConfig.ChunkSize - 1) / r.Cfg.FullConfig.ChunkSize
+			chunkDetail = `ROWID BETWEEN '${start_rowid}' AND '${end_rowid}'`
+		}
+		totalRows += tableRows
+		totalChunks += chunks
+
+		columnNameS, err := r.Oracle.GetOracleTableRowsColumn(
+			common.StringsBuilder(`SELECT *`, ` FROM `,
+				common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), `.`, common.StringUPPER(t), ` WHERE ROWNUM = 1`))
+		if err != nil {
+			return err
+		}
+		projection, err := r.GetTableColumnProjection(t)
+		if err != nil {
+			return err
+		}
+		columnNameS = projection.ProjectColumnNames(columnNameS)
+		columnNameT, err := r.GetTableColumnNameRule(t, columnNameS)
+		if err != nil {
+			return err
+		}
+
+		syncMeta := meta.FullSyncMeta{
+			DBTypeS:        r.Cfg.DBTypeS,
+			DBTypeT:        r.Cfg.DBTypeT,
+			SchemaNameS:    common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:     common.StringUPPER(t),
+			SchemaNameT:    common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
+			TableNameT:     common.StringUPPER(targetTableName),
+			ConsistentRead: "NO",
+			SQLHint:        sqlHint,
+			ColumnDetailS:  sourceColumnInfo,
+			ChunkDetailS:   chunkDetail,
+			TaskMode:       r.Cfg.TaskMode,
+		}
+		sampleDML, err := r.dryRunSampleDML(syncMeta, columnNameS, columnNameT)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\ntable [%s.%s] -> [%s.%s]\n", syncMeta.SchemaNameS, syncMeta.TableNameS, syncMeta.SchemaNameT, syncMeta.TableNameT)
+		fmt.Printf("  estimated rows [%d] chunk-size [%d] estimated chunks [%d] sql-threads [%d] write-mode [%s] load-data [%v]\n",
+			tableRows, r.Cfg.FullConfig.ChunkSize, chunks, r.GetTableSQLThreads(t), r.getWriteMode(), loadData)
+		fmt.Printf("  source sql: SELECT %s %s FROM %s.%s WHERE %s\n", sqlHint, sourceColumnInfo, syncMeta.SchemaNameS, syncMeta.TableNameS, chunkDetail)
+		fmt.Printf("  sample dml: %s\n", sampleDML)
+	}
+
+	// 迁移预估计划，dry-run 不写入元数据库
+	plans, err := public.GenMigratePlan(r.Cfg, r.Oracle, exporters, tableNameRule)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	public.PrintMigratePlan(r.Cfg, plans)
+
+	fmt.Printf("\ndry-run total tables [%d] estimated rows [%d] estimated chunks [%d], nothing has been written to target or meta database\n",
+		len(exporters), totalRows, totalChunks)
+	return nil
+}
+
+// dryRunSampleDML 读取源端首行数据，经全量同步相同的数据转换生成样例写入 SQL，表无数据时输出写入 SQL 前缀
+func (r *Migrate) dryRunSampleDML(syncMeta meta.FullSyncMeta, columnNameS, columnNameT []string) (string, error) {
+	syncMeta.ChunkDetailS = `ROWNUM <= 1`
+	tableOracle, _, err := r.GetTableOracle(syncMeta.TableNameS).WithTableLongFetch(r.Cfg.SchemaConfig.SourceSchema, syncMeta.TableNameS)
+	if err != nil {
+		return "", err
+	}
+	rows := NewRows(r.Ctx, syncMeta, tableOracle, r.Mysql,
+		common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
+		common.StringUPPER(r.Cfg.MySQLConfig.Charset), 1, 1, 0, nil, r.Cfg.AppConfig.EmptyStringMode, r.Cfg.AppConfig.LOBMaxSize, r.Cfg.AppConfig.LOBOversizeMode, r.Cfg.AppConfig.CharsetErrorMode, common.NewRetryPolicy(0, 0, 0), nil, r.getWriteMode(), false, columnNameS, columnNameT)
+
+	g := &errgroup.Group{}
+	g.Go(rows.ReadData)
+	g.Go(rows.ProcessData)
+	var batchRows []string
+	for batch := range rows.WriteChannel {
+		batchRows = append(batchRows, batch...)
+	}
+	if err := g.Wait(); err != nil {
+		return "", err
+	}
+	prefixSQL := rows.genBatchPrefix()
+	if len(batchRows) == 0 {
+		return common.StringsBuilder(prefixSQL, "(...)"), nil
+	}
+	return rows.genBatchData(prefixSQL, batchRows), nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"strconv"
+	"time"
+)
+
+// syncTableFlashbackRecord capture-mode flashback 微批增量，表水位来源 incr_sync_meta
+// 1、未配置 watermark-column：闪回查询当前 SCN 时间点 ORA_ROWSCN 大于表同步 SCN 的数据行
+// 2、配置 watermark-column：闪回查询当前 SCN 时间点 watermark-column 大于等于已同步水位的数据行
+// 数据行 replace 写入下游，表写入完成推进表同步 SCN 以及水位，中断后重新运行按表水位继续，水位边界数据重复写入保持幂等
+func (r *Migrate) syncTableFlashbackRecord() error {
+	currentSCN, err := r.Oracle.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return err
+	}
+	oraDBVersion, err := r.Oracle.GetOracleDBVersion()
+	if err != nil {
+		return err
+	}
+	oracleCollation := common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion)
+
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(r.MetaDB).DetailIncrSyncMetaBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+	if len(incrSyncMetas) == 0 {
+		return fmt.Errorf("mysql increment mete table [incr_sync_meta] can't null")
+	}
+	tableMigrateRule := r.GetCustomMigrateConfig()
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.Tuning.IncrApplyThreads())
+	for _, incrMeta := range incrSyncMetas {
+		m := incrMeta
+		if m.TableScnS >= currentSCN {
+			continue
+		}
+		g.Go(func() error {
+			// 收到退出信号，不再拉取新表，表水位保持不变
+			if signal.IsShutdown() {
+				return nil
+			}
+			startTime := time.Now()
+			watermarkColumn := tableMigrateRule[common.StringUPPER(m.TableNameS)].WatermarkColumn
+
+			var (
+				chunkDetail string
+				watermark   string
+				err         error
+			)
+			if watermarkColumn == "" {
+				chunkDetail = common.StringsBuilder(`ORA_ROWSCN > `, strconv.FormatUint(m.TableScnS, 10))
+			} else {
+				// 首次同步，水位为全量同步 SCN 时间点字段最大值
+				watermark = m.WatermarkS
+				if watermark == "" {
+					watermark, err = r.Oracle.GetOracleTableFlashbackWatermark(m.SchemaNameS, m.TableNameS, watermarkColumn, m.TableScnS)
+					if err != nil {
+						return fmt.Errorf("get oracle table [%s.%s] watermark column [%s] scn [%d] watermark failed: %v", m.SchemaNameS, m.TableNameS, watermarkColumn, m.TableScnS, err)
+					}
+				}
+				if watermark == "" {
+					chunkDetail = `1 = 1`
+				} else {
+					chunkDetail = fmt.Sprintf(`%s >= TO_TIMESTAMP('%s', '%s')`, watermarkColumn, watermark, common.FlashbackWatermarkFormat)
+				}
+			}
+
+			columnDetailS, err := r.AdjustTableSelectColumn(m.TableNameS, oracleCollation)
+			if err != nil {
+				return err
+			}
+			columnNameS, err := r.Oracle.GetOracleTableRowsColumn(
+				common.StringsBuilder(`SELECT *`, ` FROM `, m.SchemaNameS, `.`, m.TableNameS, ` WHERE ROWNUM = 1`))
+			if err != nil {
+				return err
+			}
+			projection, err := r.GetTableColumnProjection(m.TableNameS)
+			if err != nil {
+				return err
+			}
+			columnNameS = projection.ProjectColumnNames(columnNameS)
+			columnNameT, err := r.GetTableColumnNameRule(m.TableNameS, columnNameS)
+			if err != nil {
+				return err
+			}
+
+			rows := NewRows(r.Ctx, meta.FullSyncMeta{
+				DBTypeS:        r.Cfg.DBTypeS,
+				DBTypeT:        r.Cfg.DBTypeT,
+				SchemaNameS:    m.SchemaNameS,
+				TableNameS:     m.TableNameS,
+				SchemaNameT:    m.SchemaNameT,
+				TableNameT:     m.TableNameT,
+				GlobalScnS:     currentSCN,
+				ConsistentRead: "YES",
+				SQLHint:        tableMigrateRule[common.StringUPPER(m.TableNameS)].SQLHint,
+				ColumnDetailS:  columnDetailS,
+				ChunkDetailS:   chunkDetail,
+				TaskMode:       r.Cfg.TaskMode,
+			}, r.Oracle, r.Mysql,
+				common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
+				common.StringUPPER(r.Cfg.MySQLConfig.Charset), r.Tuning.ApplyThreads(), r.Tuning.InsertBatchSize(), r.Mysql.InsertBatchBytes(r.Cfg.AppConfig.InsertBatchBytes), nil, r.Cfg.AppConfig.EmptyStringMode, r.Cfg.AppConfig.LOBMaxSize, r.Cfg.AppConfig.LOBOversizeMode, r.Cfg.AppConfig.CharsetErrorMode, common.NewRetryPolicy(r.Cfg.AppConfig.RetryAttempts, r.Cfg.AppConfig.RetryBackoff, r.Cfg.AppConfig.RetryMaxBackoff), r.Quarantine, common.WriteModeReplace, false, columnNameS, columnNameT)
+			rows.Kafka = r.Kafka
+			if err = public.IMigrate(rows); err != nil {
+				return fmt.Errorf("oracle table [%s.%s] flashback increment sync scn [%d] chunk [%s] failed: %v", m.SchemaNameS, m.TableNameS, currentSCN, chunkDetail, err)
+			}
+
+			// 水位推进至当前 SCN 时间点字段最大值，表为空保持原水位
+			if watermarkColumn != "" {
+				newWatermark, err := r.Oracle.GetOracleTableFlashbackWatermark(m.SchemaNameS, m.TableNameS, watermarkColumn, currentSCN)
+				if err != nil {
+					return fmt.Errorf("get oracle table [%s.%s] watermark column [%s] scn [%d] watermark failed: %v", m.SchemaNameS, m.TableNameS, watermarkColumn, currentSCN, err)
+				}
+				if newWatermark != "" {
+					watermark = newWatermark
+				}
+			}
+			if err = meta.NewIncrSyncMetaModel(r.MetaDB).UpdateIncrSyncMeta(r.Ctx, &meta.IncrSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: m.SchemaNameS,
+				TableNameS:  m.TableNameS,
+				GlobalScnS:  currentSCN,
+				TableScnS:   currentSCN,
+				WatermarkS:  watermark,
+			}); err != nil {
+				return err
+			}
+			zap.L().Info("oracle table flashback increment sync finished",
+				zap.String("schema", m.SchemaNameS),
+				zap.String("table", m.TableNameS),
+				zap.String("chunk", chunkDetail),
+				zap.Uint64("scn", currentSCN),
+				zap.String("watermark", watermark),
+				zap.String("cost", time.Now().Sub(startTime).String()))
+			return nil
+		})
+	}
+	return g.Wait()
+}
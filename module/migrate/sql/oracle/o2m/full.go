@@ -21,15 +21,22 @@ import (
 	"github.com/google/uuid"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/kafka"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"github.com/wentaojin/transferdb/signal"
+	"github.com/wentaojin/transferdb/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -40,6 +47,21 @@ type Migrate struct {
 	OracleMiner *oracle.Oracle
 	Mysql       *mysql.MySQL
 	MetaDB      *meta.Meta
+	Quarantine  *public.Quarantine
+	// all 模式 sink-type kafka 变更事件发布，nil 直接应用下游
+	Kafka *kafka.Kafka
+	// 运行中可热更新的线程数、批次大小以及限速
+	Tuning *common.Tuning
+	// all 模式 [[all.schema-route]] 多 schema 增量同步，共用 logminer 会话、下游以及元数据库连接
+	Routes []*Migrate
+
+	// 用于控制当程序消费追平到当前 CURRENT 重做日志，
+	// 当值 == 0 启用 filterOracleIncrRecord 大于或者等于逻辑
+	// 当值 == 1 启用 filterOracleIncrRecord 大于逻辑，避免已被消费得日志一直被重复消费
+	currentResetFlag int
+
+	adaptiveMutex   sync.Mutex
+	adaptiveBatches map[string]*common.AdaptiveBatch
 }
 
 func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
@@ -47,20 +69,31 @@ func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
-	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	tuning := common.NewTuning(cfg.TuningParams())
+	// 登记运行中调优参数，SIGHUP 以及 REST/gRPC tune 接口按任务 ID 更新
+	common.RegisterTuning(cfg.AppConfig.TaskID, tuning)
+	oracleDB.Throttle = tuning.ExtractThrottle()
+	oracleDB.SlowLog = common.NewSlowLog(logger.SlowLogger(), common.SlowLogSource, cfg.LogConfig.SlowSourceThreshold)
+	oracleDB.Budget = common.NewMemoryBudget(cfg.FullConfig.MemoryBudget)
+	// full 模式装载会话额外设置 load-session-params，all 模式全量与增量共用连接不生效
+	mysqlDB, err := mysql.NewMySQLLoadEngine(ctx, cfg.MySQLConfig)
 	if err != nil {
 		return nil, err
 	}
+	mysqlDB.Throttle = tuning.ApplyThrottle()
+	mysqlDB.SlowLog = common.NewSlowLog(logger.SlowLogger(), common.SlowLogTarget, cfg.LogConfig.SlowTargetThreshold)
 	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
+	mysqlDB.Audit = meta.NewDDLAudit(ctx, metaDB, cfg.AppConfig.TaskID, cfg.DBTypeT, cfg.TaskMode, cfg.AppConfig.DDLAuditFile)
 	return &Migrate{
 		Ctx:    ctx,
 		Cfg:    cfg,
 		Oracle: oracleDB,
 		Mysql:  mysqlDB,
 		MetaDB: metaDB,
+		Tuning: tuning,
 	}, nil
 }
 
@@ -109,6 +142,23 @@ func (r *Migrate) Full() error {
 		return err
 	}
 
+	// dry-run 只输出待同步表列表、chunk 切分计划以及样例 SQL，不执行迁移
+	if r.Cfg.DryRun {
+		return r.DryRun(exporters, oracleCollation)
+	}
+
+	if !common.IsContainString(common.MigrateWriteModes, r.getWriteMode()) {
+		return fmt.Errorf("full config write-mode [%v] isn't support, support write-mode [%v]", r.Cfg.FullConfig.WriteMode, common.MigrateWriteModes)
+	}
+
+	// skip-error 下游写入失败行隔离
+	if r.Cfg.FullConfig.SkipError {
+		r.Quarantine, err = public.NewQuarantine(r.Cfg.FullConfig.ErrorDir)
+		if err != nil {
+			return err
+		}
+	}
+
 	// 关于全量断点恢复
 	//  - 若想断点恢复，设置 enable-checkpoint true,首次一旦运行则 batch 数不能调整，
 	//  - 若不想断点恢复或者重新调整 batch 数，设置 enable-checkpoint false,清理元数据表 [wait_sync_meta],重新运行全量任务
@@ -145,14 +195,16 @@ func (r *Migrate) Full() error {
 			if err != nil {
 				return err
 			}
-			// 清理已有表数据
-			if err := r.Mysql.TruncateMySQLTable(r.Cfg.SchemaConfig.TargetSchema, tableName); err != nil {
-				return err
+			// 清理已有表数据，全量数据发布至 Kafka 不涉及下游表
+			if r.Kafka == nil {
+				if err := r.Mysql.TruncateMySQLTable(r.Cfg.SchemaConfig.TargetSchema, tableName); err != nil {
+					return err
+				}
+				zap.L().Info("truncate table",
+					zap.String("schema", r.Cfg.SchemaConfig.TargetSchema),
+					zap.String("table", tableName),
+					zap.String("status", "success"))
 			}
-			zap.L().Info("truncate table",
-				zap.String("schema", r.Cfg.SchemaConfig.TargetSchema),
-				zap.String("table", tableName),
-				zap.String("status", "success"))
 
 			// 判断并记录待同步表列表
 			waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
@@ -228,7 +280,23 @@ func (r *Migrate) Full() error {
 		return err
 	}
 	if errTotals > 0 {
-		return fmt.Errorf(`full schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, update meta table [wait_sync_meta] column [task_status] table status RUNNING (Need UPPER) and delete meta table [chunk_error_detail] current task all records; finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode)
+		if !r.Cfg.FullConfig.EnableCheckpoint || !r.Cfg.FullConfig.RetryFailed {
+			return fmt.Errorf(`full schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, update meta table [wait_sync_meta] column [task_status] table status RUNNING (Need UPPER) and delete meta table [chunk_error_detail] current task all records or setting [retry-failed = true]; finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode)
+		}
+		// 失败表重置为 RUNNING，断点续传时跳过已成功 chunk，重试 FAILED chunk
+		err = meta.NewCommonModel(r.MetaDB).UpdateFailedWaitSyncMetaAndDeleteChunkErrorDetail(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TaskMode:    r.Cfg.TaskMode,
+		})
+		if err != nil {
+			return err
+		}
+		zap.L().Warn("full schema failed table retry",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("mode", r.Cfg.TaskMode),
+			zap.Int64("failed tables", errTotals))
 	}
 
 	// 判断并记录待同步表列表
@@ -331,6 +399,37 @@ func (r *Migrate) Full() error {
 		return fmt.Errorf("checkpoint isn't consistent, can't be resume, please reruning [enable-checkpoint = fase]")
 	}
 
+	// 表迁移顺序 table-order/priority/pinned-tables
+	partSyncTables, err = public.OrderTables(r.Cfg, r.Oracle, partSyncTables)
+	if err != nil {
+		return err
+	}
+	waitSyncTables, err = public.OrderTables(r.Cfg, r.Oracle, waitSyncTables)
+	if err != nil {
+		return err
+	}
+
+	// 迁移预估计划，输出并写入元数据库
+	planTableNameRule, err := r.GetTableNameRule()
+	if err != nil {
+		return err
+	}
+	plans, err := public.GenMigratePlan(r.Cfg, r.Oracle, append(append([]string{}, partSyncTables...), waitSyncTables...), planTableNameRule)
+	if err != nil {
+		return err
+	}
+	public.PrintMigratePlan(r.Cfg, plans)
+	if err = public.SaveMigratePlan(r.Ctx, r.MetaDB, r.Cfg, plans); err != nil {
+		return err
+	}
+
+	// 上次任务已装载完成但未重建索引的表，优先重建
+	if r.Cfg.FullConfig.RebuildIndex && r.Kafka == nil {
+		if err = r.rebuildPendingTableIndex(append(append([]string{}, partSyncTables...), waitSyncTables...)); err != nil {
+			return err
+		}
+	}
+
 	// 数据迁移
 	// 优先存在断点的表
 	// partSyncTables -> waitSyncTables
@@ -347,6 +446,11 @@ func (r *Migrate) Full() error {
 		}
 	}
 
+	// 收到退出信号，断点已写入元数据库，返回错误非正常退出
+	if signal.IsShutdown() {
+		return fmt.Errorf("oracle schema [%s] full task interrupted by exit signal, checkpoint saved, please rerun with [enable-checkpoint = true] to resume", r.Cfg.SchemaConfig.SourceSchema)
+	}
+
 	// 任务详情
 	succTotals, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
 		DBTypeS:     r.Cfg.DBTypeS,
@@ -376,6 +480,16 @@ func (r *Migrate) Full() error {
 		zap.Int("table failed", len(failedTotals)),
 		zap.String("log detail", "if exist table failed, please see meta table [wait/full_sync_meta/chunk_error_detail]"),
 		zap.String("cost", time.Now().Sub(startTime).String()))
+
+	if r.Quarantine != nil {
+		if quarantineTables, quarantineTotals := r.Quarantine.Summary(); quarantineTotals > 0 {
+			zap.L().Warn("full table data sync exist quarantined rows",
+				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+				zap.Int64("rows totals", quarantineTotals),
+				zap.Strings("tables", quarantineTables),
+				zap.String("error dir", r.Quarantine.Dir))
+		}
+	}
 	return nil
 }
 
@@ -385,13 +499,28 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 	// 获取报错 sql
 	re := regexp.MustCompile("sql \\[(?s).*] execute")
 
+	// table-threads 支持运行中调整，按调整后的值控制并发表数
 	g := &errgroup.Group{}
-	g.SetLimit(r.Cfg.FullConfig.TableThreads)
 
+	// 表装载完成后台并发重建下游索引，不阻塞后续表装载
+	rg := &errgroup.Group{}
+	rg.SetLimit(r.getRebuildIndexThreads())
+
+	var acquireErr error
 	for _, table := range fullPartTables {
 		t := table
+		if acquireErr = r.Tuning.AcquireTable(r.Ctx); acquireErr != nil {
+			break
+		}
 		g.Go(func() error {
+			defer r.Tuning.ReleaseTable()
 			startTime := time.Now()
+			// 链路追踪表级 span，表下 chunk span 挂载于表 span
+			tableCtx, tableSpan := tracing.Start(r.Ctx, "full.table",
+				attribute.String("schema", common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema)),
+				attribute.String("table", common.StringUPPER(t)))
+			defer tableSpan.End()
+
 			err := meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
 				DBTypeS:     r.Cfg.DBTypeS,
 				DBTypeT:     r.Cfg.DBTypeT,
@@ -405,6 +534,15 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 				return err
 			}
 
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，不再拉取新表任务，表保持 running 状态用于断点续传
+			if signal.IsShutdown() {
+				return nil
+			}
+
 			waitFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
 				DBTypeS:     r.Cfg.DBTypeS,
 				DBTypeT:     r.Cfg.DBTypeT,
@@ -429,6 +567,7 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 			}
 
 			waitFullMetas = append(waitFullMetas, failedFullMetas...)
+			tableSpan.SetAttributes(attribute.Int("chunks", len(waitFullMetas)))
 
 			columnNameS, err := r.Oracle.GetOracleTableRowsColumn(
 				common.StringsBuilder(`SELECT *`, ` FROM `,
@@ -436,16 +575,80 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 			if err != nil {
 				return nil
 			}
+			// 字段投影，排除字段不抽取不写入
+			projection, err := r.GetTableColumnProjection(t)
+			if err != nil {
+				return err
+			}
+			columnNameS = projection.ProjectColumnNames(columnNameS)
+			columnNameT, err := r.GetTableColumnNameRule(t, columnNameS)
+			if err != nil {
+				return err
+			}
+
+			// 装载前删除下游二级索引以及外键
+			if r.Cfg.FullConfig.RebuildIndex && r.Kafka == nil && len(waitFullMetas) > 0 {
+				if err = r.dropTargetTableIndex(t, waitFullMetas[0].SchemaNameT, waitFullMetas[0].TableNameT); err != nil {
+					return err
+				}
+			}
+
+			// LONG/LONG RAW 字段表逐行抽取
+			tableOracle, longColumns, err := r.GetTableOracle(t).WithTableLongFetch(r.Cfg.SchemaConfig.SourceSchema, t)
+			if err != nil {
+				return err
+			}
+			if len(longColumns) > 0 {
+				zap.L().Warn("full table exist long column, single-row fetch",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", t),
+					zap.Strings("long columns", longColumns))
+			}
 
 			g1 := &errgroup.Group{}
-			g1.SetLimit(r.Cfg.FullConfig.SQLThreads)
+			g1.SetLimit(r.GetTableSQLThreads(t))
 			for _, fullMeta := range waitFullMetas {
 				m := fullMeta
 				g1.Go(func() error {
-					// 数据写入
-					err = public.IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Mysql,
-						common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
-						common.StringUPPER(r.Cfg.MySQLConfig.Charset), r.Cfg.FullConfig.ApplyThreads, r.Cfg.AppConfig.InsertBatchSize, true, columnNameS))
+					// 任务暂停，等待恢复后继续拉取
+					if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+						return err
+					}
+					// 收到退出信号，不再拉取新 chunk，chunk 保持原状态用于断点续传
+					if signal.IsShutdown() {
+						return nil
+					}
+
+					// 数据写入，chunk span 下挂载 extract/convert/apply batch span
+					chunkCtx, chunkSpan := tracing.Start(tableCtx, "full.chunk",
+						attribute.String("schema", m.SchemaNameS),
+						attribute.String("table", m.TableNameS),
+						attribute.String("chunk", m.ChunkDetailS))
+					newRows := func() *Rows {
+						rows := NewRows(chunkCtx, m, tableOracle, r.Mysql,
+							common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
+							common.StringUPPER(r.Cfg.MySQLConfig.Charset), r.Tuning.ApplyThreads(), r.Tuning.InsertBatchSize(), r.Mysql.InsertBatchBytes(r.Cfg.AppConfig.InsertBatchBytes), r.getAdaptiveBatch(t), r.Cfg.AppConfig.EmptyStringMode, r.Cfg.AppConfig.LOBMaxSize, r.Cfg.AppConfig.LOBOversizeMode, r.Cfg.AppConfig.CharsetErrorMode, common.NewRetryPolicy(r.Cfg.AppConfig.RetryAttempts, r.Cfg.AppConfig.RetryBackoff, r.Cfg.AppConfig.RetryMaxBackoff), r.Quarantine, r.getWriteMode(), r.GetCustomMigrateConfig()[common.StringUPPER(t)].LoadData, columnNameS, columnNameT)
+						rows.Kafka = r.Kafka
+						return rows
+					}
+					err := public.IMigrate(newRows())
+					// 连接中断等瞬时错误，等待上下游连接恢复（故障切换）后重新分发 chunk，避免整表失败
+					// chunk 已有批次写入下游时错误标记不可重试（common.NonRetryable），不重新分发，避免无主键表重复写入以及 insert 主键冲突
+					for redispatch := 1; err != nil && redispatch <= r.Cfg.AppConfig.ChunkRedispatch && common.IsRetryableError(err) && !signal.IsShutdown(); redispatch++ {
+						zap.L().Warn("full table chunk connection failed, redispatch",
+							zap.String("schema", m.SchemaNameS),
+							zap.String("table", m.TableNameS),
+							zap.String("chunk", m.ChunkDetailS),
+							zap.Int("redispatch", redispatch),
+							zap.Error(err))
+						metrics.FullChunkRedispatchCounter.WithLabelValues(m.SchemaNameS, m.TableNameS).Inc()
+						if werr := r.waitReconnect(); werr != nil {
+							err = fmt.Errorf("%v, redispatch failed: %v", err, werr)
+							break
+						}
+						err = public.IMigrate(newRows())
+					}
+					tracing.End(chunkSpan, err)
 
 					if err != nil {
 						var (
@@ -485,6 +688,7 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 						if errf != nil {
 							return fmt.Errorf("get oracle schema table [%v] IMigrate failed: %v", m.String(), errf)
 						}
+						metrics.FullChunkCounter.WithLabelValues(m.SchemaNameS, m.TableNameS, common.TaskStatusFailed).Inc()
 						return nil
 					}
 
@@ -500,6 +704,7 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 					}); errf != nil {
 						return fmt.Errorf("get oracle schema table [%v] Success failed: %v", m.String(), errf)
 					}
+					metrics.FullChunkCounter.WithLabelValues(m.SchemaNameS, m.TableNameS, common.TaskStatusSuccess).Inc()
 					return nil
 				})
 			}
@@ -508,6 +713,14 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 				return err
 			}
 
+			// 收到退出信号，跳过表完成状态更新，等待下次断点续传
+			if signal.IsShutdown() {
+				zap.L().Warn("full single table task interrupted by exit signal, checkpoint saved",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", common.StringUPPER(t)))
+				return nil
+			}
+
 			// 清理元数据记录
 			// 更新 wait_sync_meta 记录
 			failedChunkTotalErrs, err := meta.NewFullSyncMetaModel(r.MetaDB).CountsErrorFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
@@ -555,6 +768,12 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 				if err != nil {
 					return err
 				}
+				// 装载完成重建下游二级索引以及外键，收到退出信号则下次任务重建
+				if r.Cfg.FullConfig.RebuildIndex && r.Kafka == nil && !signal.IsShutdown() {
+					rg.Go(func() error {
+						return r.rebuildTargetTableIndex(t)
+					})
+				}
 				zap.L().Info("full single table oracle to mysql finished",
 					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 					zap.String("table", common.StringUPPER(t)),
@@ -586,7 +805,14 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 		})
 	}
 
-	if err := g.Wait(); err != nil {
+	err := g.Wait()
+	if errR := rg.Wait(); errR != nil && err == nil {
+		err = errR
+	}
+	if err == nil {
+		err = acquireErr
+	}
+	if err != nil {
 		return err
 	}
 
@@ -626,6 +852,25 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, oracleCollatio
 	if err != nil {
 		return err
 	}
+	// 一致性读断点续传，沿用已初始化表 SCN，保证任务内所有表同一快照
+	if r.Cfg.FullConfig.ConsistentRead && r.Cfg.FullConfig.EnableCheckpoint {
+		checkpointSCN, err := meta.NewWaitSyncMetaModel(r.MetaDB).GetWaitSyncMetaMinGlobalScnSBySchema(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TaskMode:    r.Cfg.TaskMode,
+		})
+		if err != nil {
+			return err
+		}
+		if checkpointSCN > 0 {
+			zap.L().Warn("consistent read reuse checkpoint scn",
+				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+				zap.Uint64("current scn", globalSCN),
+				zap.Uint64("checkpoint scn", checkpointSCN))
+			globalSCN = checkpointSCN
+		}
+	}
 	partitionTables, err := r.Oracle.GetOracleSchemaPartitionTable(r.Cfg.SchemaConfig.SourceSchema)
 	if err != nil {
 		return err
@@ -645,6 +890,14 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, oracleCollatio
 	for _, table := range fullWaitTables {
 		t := table
 		g.Go(func() error {
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，不再切分新表 chunk，表保持 waiting 状态
+			if signal.IsShutdown() {
+				return nil
+			}
 			startTime := time.Now()
 			// 库名、表名规则
 			var targetTableName string
@@ -663,8 +916,17 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, oracleCollatio
 			)
 			if val, ok := tableMigrateRule[common.StringUPPER(t)]; ok {
 				sqlHint = val.SQLHint
-				wherePrefix = val.Range
 				enableSplit = val.EnableSplit
+				// 自定义过滤条件括号包裹，避免 OR 条件破坏 chunk 范围
+				if !strings.EqualFold(val.Range, "") {
+					wherePrefix = common.StringsBuilder("(", val.Range, ")")
+					if !enableSplit {
+						zap.L().Warn("table migrate config range isn't enable, because of enable-split is false",
+							zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+							zap.String("table", t),
+							zap.String("range", val.Range))
+					}
+				}
 			} else {
 				sqlHint = r.Cfg.FullConfig.SQLHint
 			}
@@ -732,17 +994,26 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, oracleCollatio
 
 			taskName := uuid.New().String()
 
-			if err = r.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
-				return err
-			}
+			var chunkRes []map[string]string
+			// ADG 备库只读不支持 DBMS_PARALLEL_EXECUTE，基于表段区间切分 ROWID chunk
+			if r.Oracle.Standby {
+				chunkRes, err = r.Oracle.GetOracleStandbyTableChunksByRowID(common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), r.Cfg.FullConfig.ChunkSize)
+				if err != nil {
+					return err
+				}
+			} else {
+				if err = r.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
+					return err
+				}
 
-			if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
-				return err
-			}
+				if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), strconv.Itoa(r.Cfg.FullConfig.ChunkSize)); err != nil {
+					return err
+				}
 
-			chunkRes, err := r.Oracle.GetOracleTableChunksByRowID(taskName)
-			if err != nil {
-				return err
+				chunkRes, err = r.Oracle.GetOracleTableChunksByRowID(taskName)
+				if err != nil {
+					return err
+				}
 			}
 
 			// 判断数据是否存在
@@ -839,8 +1110,10 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, oracleCollatio
 				return err
 			}
 
-			if err = r.Oracle.CloseOracleChunkTask(taskName); err != nil {
-				return err
+			if !r.Oracle.Standby {
+				if err = r.Oracle.CloseOracleChunkTask(taskName); err != nil {
+					return err
+				}
 			}
 
 			endTime := time.Now()
@@ -862,6 +1135,74 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, oracleCollatio
 	return nil
 }
 
+// 表级别 sql-threads 优先级高于全局 sql-threads
+func (r *Migrate) GetTableSQLThreads(tableName string) int {
+	if val, ok := r.GetCustomMigrateConfig()[common.StringUPPER(tableName)]; ok && val.SQLThreads > 0 {
+		return val.SQLThreads
+	}
+	return r.Tuning.SQLThreads()
+}
+
+// 表级别 fetch-array-size/prefetch-count 优先级高于全局 [oracle] 配置
+func (r *Migrate) GetTableOracle(tableName string) *oracle.Oracle {
+	if val, ok := r.GetCustomMigrateConfig()[common.StringUPPER(tableName)]; ok {
+		return r.Oracle.WithFetchSize(val.FetchArraySize, val.PrefetchCount)
+	}
+	return r.Oracle
+}
+
+// waitReconnect 等待上下游连接恢复，用于连接中断失败 chunk 重新分发
+func (r *Migrate) waitReconnect() error {
+	policy := common.NewRetryPolicy(r.Cfg.AppConfig.RetryAttempts, r.Cfg.AppConfig.RetryBackoff, r.Cfg.AppConfig.RetryMaxBackoff)
+	timeout := time.Duration(r.Cfg.AppConfig.ReconnectTimeout) * time.Second
+	if err := common.WaitDBHealthy(r.Ctx, policy, timeout, r.Oracle.OracleDB); err != nil {
+		return fmt.Errorf("oracle reconnect failed: %v", err)
+	}
+	if err := common.WaitDBHealthy(r.Ctx, policy, timeout, r.Mysql.MySQLDB); err != nil {
+		return fmt.Errorf("mysql reconnect failed: %v", err)
+	}
+	return nil
+}
+
+// 表级别自适应批次，同一张表所有 chunk 共享，未开启 adaptive-batch 返回 nil
+func (r *Migrate) getAdaptiveBatch(tableName string) *common.AdaptiveBatch {
+	if !r.Cfg.AppConfig.AdaptiveBatch {
+		return nil
+	}
+	r.adaptiveMutex.Lock()
+	defer r.adaptiveMutex.Unlock()
+	if r.adaptiveBatches == nil {
+		r.adaptiveBatches = make(map[string]*common.AdaptiveBatch)
+	}
+	if a, ok := r.adaptiveBatches[common.StringUPPER(tableName)]; ok {
+		return a
+	}
+	latency := r.Cfg.AppConfig.AdaptiveBatchLatency
+	if latency <= 0 {
+		latency = common.AdaptiveBatchDefaultLatency
+	}
+	minRows := r.Cfg.AppConfig.AdaptiveBatchMinRows
+	if minRows <= 0 {
+		minRows = common.AdaptiveBatchDefaultMinRows
+	}
+	maxRows := r.Cfg.AppConfig.AdaptiveBatchMaxRows
+	if maxRows <= 0 {
+		maxRows = r.Cfg.AppConfig.InsertBatchSize * common.AdaptiveBatchDefaultMaxRowsFactor
+	}
+	a := common.NewAdaptiveBatch(r.Cfg.AppConfig.InsertBatchSize, minRows, maxRows,
+		r.Mysql.InsertBatchBytes(r.Cfg.AppConfig.InsertBatchBytes), time.Duration(latency)*time.Millisecond)
+	r.adaptiveBatches[common.StringUPPER(tableName)] = a
+	return a
+}
+
+// 全量下游写入模式，未配置默认 replace
+func (r *Migrate) getWriteMode() string {
+	if strings.EqualFold(r.Cfg.FullConfig.WriteMode, "") {
+		return common.WriteModeReplace
+	}
+	return common.StringUPPER(r.Cfg.FullConfig.WriteMode)
+}
+
 func (r *Migrate) GetCustomMigrateConfig() map[string]config.MigrateConfig {
 	tableMigrateMap := make(map[string]config.MigrateConfig)
 	for _, t := range r.Cfg.SchemaConfig.MigrateConfig {
@@ -891,6 +1232,59 @@ func (r *Migrate) GetTableNameRule() (map[string]string, error) {
 	return tableNameRuleMap, nil
 }
 
+// 获取字段名自定义规则，返回与源端字段顺序一致的目标端字段名
+// GetTableColumnProjection 字段投影，虚拟列下游为生成列或者不创建，数据抽取统一排除
+func (r *Migrate) GetTableColumnProjection(sourceTable string) (*config.ColumnProjection, error) {
+	virtualColumns, err := r.Oracle.GetOracleSchemaTableVirtualColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(virtualColumns) > 0 {
+		zap.L().Warn("oracle table virtual column skip data extraction",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", sourceTable),
+			zap.Strings("virtual columns", virtualColumns))
+	}
+	// BFILE 以及 rowid-mode = skip ROWID/UROWID 下游不创建，数据抽取排除
+	skipColumns, err := r.Oracle.GetOracleSchemaTableDatatypeColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable, r.Cfg.ReverseConfig.SkipColumnDatatypes())
+	if err != nil {
+		return nil, err
+	}
+	if len(skipColumns) > 0 {
+		zap.L().Warn("oracle table unsupported datatype column skip data extraction",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", sourceTable),
+			zap.Strings("skip columns", skipColumns))
+	}
+	return r.Cfg.SchemaConfig.GetColumnProjection(sourceTable).Exclude(virtualColumns...).Exclude(skipColumns...), nil
+}
+
+func (r *Migrate) GetTableColumnNameRule(sourceTable string, columnNameS []string) ([]string, error) {
+	columnNameRules, err := meta.NewColumnNameRuleModel(r.MetaDB).DetailColumnNameRule(r.Ctx, &meta.ColumnNameRule{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  sourceTable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	columnNameRuleMap := make(map[string]string)
+	for _, cr := range columnNameRules {
+		columnNameRuleMap[common.StringUPPER(cr.ColumnNameS)] = cr.ColumnNameT
+	}
+
+	var columnNameT []string
+	for _, col := range columnNameS {
+		if val, ok := columnNameRuleMap[common.StringUPPER(col)]; ok {
+			columnNameT = append(columnNameT, val)
+		} else {
+			columnNameT = append(columnNameT, col)
+		}
+	}
+	return columnNameT, nil
+}
+
 func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, error) {
 	// Date/Timestamp 字段类型格式化
 	// Interval Year/Day 数据字符 TO_CHAR 格式化
@@ -898,10 +1292,37 @@ func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bo
 	if err != nil {
 		return "", err
 	}
+	projection, err := r.GetTableColumnProjection(sourceTable)
+	if err != nil {
+		return "", err
+	}
+	columnsINFO = projection.ProjectColumnINFO(columnsINFO)
+
+	// 字段转换规则
+	columnTransforms := make(map[string]config.ColumnTransform)
+	if val, ok := r.GetCustomMigrateConfig()[common.StringUPPER(sourceTable)]; ok {
+		for _, ct := range val.ColumnTransform {
+			columnTransforms[common.StringUPPER(ct.ColumnName)] = ct
+		}
+	}
 
 	var columnNames []string
 
 	for _, rowCol := range columnsINFO {
+		// 字段转换优先，转换表达式替换默认格式化
+		if ct, ok := columnTransforms[common.StringUPPER(rowCol["COLUMN_NAME"])]; ok {
+			expr, err := common.GenOracleColumnTransform(rowCol["COLUMN_NAME"], ct.Rule, ct.Algorithm, ct.Length, ct.Expression)
+			if err != nil {
+				return "", err
+			}
+			columnNames = append(columnNames, expr)
+			continue
+		}
+		// interval-mode/raw-mode 字段转换表达式
+		if expr, ok := common.GenOracleDatatypeStrategyColumn(rowCol["COLUMN_NAME"], rowCol["DATA_TYPE"], r.Cfg.ReverseConfig.IntervalMode, r.Cfg.ReverseConfig.RawMode); ok {
+			columnNames = append(columnNames, common.StringsBuilder(expr, ` AS "`, rowCol["COLUMN_NAME"], `"`))
+			continue
+		}
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
@@ -919,7 +1340,7 @@ func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bo
 			columnNames = append(columnNames, common.StringsBuilder(`"`, rowCol["COLUMN_NAME"], `"`))
 		// 时间
 		case "DATE":
-			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'yyyy-MM-dd HH24:mi:ss') AS "`, rowCol["COLUMN_NAME"], `"`))
+			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'`, common.GetOracleDateFormat(r.Cfg.AppConfig.DateFormat), `') AS "`, rowCol["COLUMN_NAME"], `"`))
 		// 默认其他类型
 		default:
 			if strings.Contains(rowCol["DATA_TYPE"], "INTERVAL") {
@@ -929,13 +1350,15 @@ func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bo
 				if err != nil {
 					return "", fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", rowCol["DATA_SCALE"], err)
 				}
+				// 带时区时间按 target-time-zone 转换
+				timestampCol := common.GenOracleTimestampColumn(rowCol["COLUMN_NAME"], rowCol["DATA_TYPE"], r.Cfg.AppConfig.TargetTimeZone)
 				if dataScale == 0 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'yyyy-MM-dd HH24:mi:ss') AS "`, rowCol["COLUMN_NAME"], `"`))
-				} else if dataScale < 0 && dataScale <= 6 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"],
-						`",'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, rowCol["COLUMN_NAME"], `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, timestampCol, `,'yyyy-MM-dd HH24:mi:ss') AS "`, rowCol["COLUMN_NAME"], `"`))
+				} else if dataScale > 0 && dataScale <= 6 {
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, timestampCol,
+						`,'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, rowCol["COLUMN_NAME"], `"`))
 				} else {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, rowCol["COLUMN_NAME"], `",'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, rowCol["COLUMN_NAME"], `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, timestampCol, `,'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, rowCol["COLUMN_NAME"], `"`))
 				}
 
 			} else {
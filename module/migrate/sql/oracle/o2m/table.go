@@ -20,51 +20,82 @@ import (
 	"fmt"
 	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/kafka"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"github.com/wentaojin/transferdb/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type Rows struct {
-	Ctx             context.Context
-	SyncMeta        meta.FullSyncMeta
-	Oracle          *oracle.Oracle
-	MySQL           *mysql.MySQL
-	SourceDBCharset string
-	TargetDBCharset string
-	ApplyThreads    int
-	BatchSize       int
-	SafeMode        bool
-	ColumnNameS     []string
-	ReadChannel     chan []map[string]string
-	WriteChannel    chan string
+	Ctx              context.Context
+	SyncMeta         meta.FullSyncMeta
+	Oracle           *oracle.Oracle
+	MySQL            *mysql.MySQL
+	Kafka            *kafka.Kafka
+	SourceDBCharset  string
+	TargetDBCharset  string
+	ApplyThreads     int
+	BatchSize        int
+	BatchBytes       int
+	AdaptiveBatch    *common.AdaptiveBatch
+	EmptyStringMode  string
+	LOBMaxSize       int
+	LOBOversizeMode  string
+	CharsetErrorMode string
+	RetryPolicy      common.RetryPolicy
+	Quarantine       *public.Quarantine
+	WriteMode        string
+	LoadData         bool
+	ColumnNameS      []string
+	ColumnNameT      []string
+	ReadChannel      chan []map[string]string
+	WriteChannel     chan []string
+
+	// chunk 已有批次写入下游，写入失败不再重新分发 chunk，避免重复写入
+	applied int32
 }
 
 func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
-	oracle *oracle.Oracle, mysql *mysql.MySQL, sourceDBCharset string, targetDBCharset string, applyThreads, batchSize int, safeMode bool,
-	columnNameS []string) *Rows {
+	oracle *oracle.Oracle, mysql *mysql.MySQL, sourceDBCharset string, targetDBCharset string, applyThreads, batchSize, batchBytes int, adaptiveBatch *common.AdaptiveBatch, emptyStringMode string, lobMaxSize int, lobOversizeMode, charsetErrorMode string, retryPolicy common.RetryPolicy, quarantine *public.Quarantine, writeMode string, loadData bool,
+	columnNameS, columnNameT []string) *Rows {
 
 	readChannel := make(chan []map[string]string, common.ChannelBufferSize)
-	writeChannel := make(chan string, common.ChannelBufferSize)
+	writeChannel := make(chan []string, common.ChannelBufferSize)
 
 	return &Rows{
-		Ctx:             ctx,
-		SyncMeta:        syncMeta,
-		Oracle:          oracle,
-		MySQL:           mysql,
-		SourceDBCharset: sourceDBCharset,
-		TargetDBCharset: targetDBCharset,
-		ApplyThreads:    applyThreads,
-		SafeMode:        safeMode,
-		BatchSize:       batchSize,
-		ColumnNameS:     columnNameS,
-		ReadChannel:     readChannel,
-		WriteChannel:    writeChannel,
+		Ctx:              ctx,
+		SyncMeta:         syncMeta,
+		Oracle:           oracle,
+		MySQL:            mysql,
+		SourceDBCharset:  sourceDBCharset,
+		TargetDBCharset:  targetDBCharset,
+		ApplyThreads:     applyThreads,
+		WriteMode:        writeMode,
+		LoadData:         loadData,
+		BatchSize:        batchSize,
+		BatchBytes:       batchBytes,
+		AdaptiveBatch:    adaptiveBatch,
+		EmptyStringMode:  emptyStringMode,
+		LOBMaxSize:       lobMaxSize,
+		LOBOversizeMode:  lobOversizeMode,
+		CharsetErrorMode: charsetErrorMode,
+		RetryPolicy:      retryPolicy,
+		Quarantine:       quarantine,
+		ColumnNameS:      columnNameS,
+		ColumnNameT:      columnNameT,
+		ReadChannel:      readChannel,
+		WriteChannel:     writeChannel,
 	}
 }
 
@@ -82,11 +113,41 @@ func (t *Rows) ReadData() error {
 		querySQL = common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, t.SyncMeta.SchemaNameS, `.`, t.SyncMeta.TableNameS, ` WHERE `, t.SyncMeta.ChunkDetailS)
 	}
 
-	err := t.Oracle.GetOracleTableRowsData(querySQL, t.BatchSize, t.SourceDBCharset, t.TargetDBCharset, t.ReadChannel)
+	_, span := tracing.Start(t.Ctx, "full.extract", attribute.String("sql", querySQL))
+	// 瞬时错误重试整个 chunk，首个批次写入读取通道后不再重试，避免无主键/唯一键表 replace 退化为 insert 重复写入
+	var sent bool
+	err := common.Retry(t.Ctx, t.RetryPolicy, func() error {
+		readChannel := make(chan []map[string]string)
+		forwardDone := make(chan struct{})
+		go func() {
+			defer close(forwardDone)
+			for rows := range readChannel {
+				sent = true
+				t.ReadChannel <- rows
+			}
+		}()
+		err := t.Oracle.GetOracleTableRowsData(querySQL, t.BatchSize, t.SourceDBCharset, t.TargetDBCharset, t.EmptyStringMode, t.LOBMaxSize, t.LOBOversizeMode, t.CharsetErrorMode, readChannel)
+		close(readChannel)
+		<-forwardDone
+		if err != nil && sent {
+			return common.NonRetryable(err)
+		}
+		return err
+	}, func(attempt int, err error) {
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt), attribute.String("error", err.Error())))
+		metrics.RetryCounter.WithLabelValues(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, "extract").Inc()
+		zap.L().Warn("source schema table chunk rows extractor retry",
+			zap.String("schema", t.SyncMeta.SchemaNameS),
+			zap.String("table", t.SyncMeta.TableNameS),
+			zap.String("chunk", t.SyncMeta.ChunkDetailS),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	})
+	tracing.End(span, err)
 	if err != nil {
 		// 通道关闭
 		close(t.ReadChannel)
-		return fmt.Errorf("source sql [%v] execute failed: %v", querySQL, err)
+		return fmt.Errorf("source sql [%v] execute failed: %w", querySQL, err)
 	}
 
 	endTime := time.Now()
@@ -104,9 +165,26 @@ func (t *Rows) ReadData() error {
 }
 
 func (t *Rows) ProcessData() error {
+	// 全量数据发布至 Kafka，不生成下游写入语句
+	if t.Kafka != nil {
+		return t.publishData()
+	}
+
+	prefixSQL := t.genBatchPrefix()
 
+	// convert span 覆盖读取通道消费至写入通道关闭，耗时包含等待上游读取
+	_, span := tracing.Start(t.Ctx, "full.convert")
+	defer span.End()
+
+	// 自适应批次模式下批次跨读取批次累积，按自适应行数拆分
+	var (
+		batchRows  []string
+		batchBytes int
+		rowCounts  int
+	)
 	for dataC := range t.ReadChannel {
-		var batchRows []string
+		metrics.FullRowsReadCounter.WithLabelValues(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS).Add(float64(len(dataC)))
+		rowCounts = rowCounts + len(dataC)
 
 		for _, dMap := range dataC {
 			// 按字段名顺序遍历获取对应值
@@ -125,17 +203,39 @@ func (t *Rows) ProcessData() error {
 
 				return fmt.Errorf("source schema table column counts vs data counts isn't match")
 			} else {
-				batchRows = append(batchRows, common.StringsBuilder("(", exstrings.Join(rowsTMP, ","), ")"))
+				row, err := t.genBatchRow(rowsTMP)
+				if err != nil {
+					// 通道关闭
+					close(t.WriteChannel)
+
+					return err
+				}
+				// 单条 SQL 大小超过 insert-batch-bytes 则提前拆分写入，避免超过下游 max_allowed_packet
+				if (t.BatchBytes > 0 && len(batchRows) > 0 && len(prefixSQL)+batchBytes+len(row) > t.BatchBytes) ||
+					(t.AdaptiveBatch != nil && len(batchRows) >= t.AdaptiveBatch.Rows()) {
+					t.Oracle.Budget.Add(batchBytes)
+					t.WriteChannel <- batchRows
+					batchRows = nil
+					batchBytes = 0
+				}
+				batchRows = append(batchRows, row)
+				batchBytes = batchBytes + len(row) + 1
 			}
 		}
 
 		// 数据输入
-		t.WriteChannel <- common.StringsBuilder(GenMySQLInsertSQLStmtPrefix(
-			t.SyncMeta.SchemaNameT,
-			t.SyncMeta.TableNameT,
-			t.ColumnNameS,
-			t.SafeMode), exstrings.Join(batchRows, ","))
+		if len(batchRows) > 0 && t.AdaptiveBatch == nil {
+			t.Oracle.Budget.Add(batchBytes)
+			t.WriteChannel <- batchRows
+			batchRows = nil
+			batchBytes = 0
+		}
+	}
+	if len(batchRows) > 0 {
+		t.Oracle.Budget.Add(batchBytes)
+		t.WriteChannel <- batchRows
 	}
+	span.SetAttributes(attribute.Int("rows", rowCounts))
 
 	// 通道关闭
 	close(t.WriteChannel)
@@ -146,21 +246,54 @@ func (t *Rows) ProcessData() error {
 func (t *Rows) ApplyData() error {
 	startTime := time.Now()
 
+	prefixSQL := t.genBatchPrefix()
+
 	g := &errgroup.Group{}
 	g.SetLimit(t.ApplyThreads)
 
 	for dataC := range t.WriteChannel {
-		querySql := dataC
-		g.Go(func() error {
-			err := t.MySQL.WriteMySQLTable(querySql)
-			if err != nil {
-				return fmt.Errorf("target sql [%v] execute failed: %v", querySql, err)
+		batchRows := dataC
+		g.Go(func() (err error) {
+			// 批次写入完成释放内存预算，与 ProcessData 按相同方式计算批次字节数
+			defer t.Oracle.Budget.Release(batchRowsBytes(batchRows))
+			applyTime := time.Now()
+			_, span := tracing.Start(t.Ctx, "full.apply.batch", attribute.Int("rows", len(batchRows)))
+			defer func() { tracing.End(span, err) }()
+
+			querySql := t.genBatchData(prefixSQL, batchRows)
+			span.SetAttributes(attribute.Int("bytes", len(querySql)))
+			// 目标端写入限速
+			if err := t.MySQL.Throttle.Wait(t.Ctx, len(batchRows), len(querySql)); err != nil {
+				return err
+			}
+			execTime := time.Now()
+			if err := t.applyBatchData(querySql); err != nil {
+				if t.Quarantine == nil {
+					return fmt.Errorf("target sql [%v] execute failed: %v", querySql, err)
+				}
+				// skip-error 批次写入失败，逐行写入，失败行写入隔离文件后继续
+				if err = t.quarantineBatchRows(prefixSQL, batchRows, err); err != nil {
+					return err
+				}
+			} else if t.AdaptiveBatch != nil {
+				// 根据批次写入耗时调整后续批次行数
+				batchSize := t.AdaptiveBatch.Observe(len(batchRows), len(querySql), time.Since(execTime))
+				metrics.FullAdaptiveBatchRowsGauge.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT).Set(float64(batchSize))
+				metrics.FullAdaptiveBatchBytesGauge.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT).Set(float64(len(querySql)))
 			}
+			atomic.StoreInt32(&t.applied, 1)
+			metrics.FullApplyDuration.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT).Observe(time.Since(applyTime).Seconds())
+			metrics.FullBatchWrittenCounter.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT).Inc()
+			metrics.FullBytesWrittenCounter.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT).Add(float64(len(querySql)))
 			return nil
 		})
 	}
 
 	if err := g.Wait(); err != nil {
+		// 已有批次写入下游，chunk 重新分发会重复写入
+		if atomic.LoadInt32(&t.applied) == 1 {
+			return common.NonRetryable(err)
+		}
 		return err
 	}
 
@@ -173,3 +306,87 @@ func (t *Rows) ApplyData() error {
 
 	return nil
 }
+
+// batchRowsBytes 批次待写入字节数，用于内存预算统计
+func batchRowsBytes(batchRows []string) int {
+	var bytes int
+	for _, row := range batchRows {
+		bytes = bytes + len(row) + 1
+	}
+	return bytes
+}
+
+// 批次数据写入下游，瞬时错误重试
+func (t *Rows) applyBatchData(querySql string) error {
+	return common.Retry(t.Ctx, t.RetryPolicy, func() error {
+		if t.LoadData {
+			return t.MySQL.LoadMySQLTable(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT, t.ColumnNameT, t.TargetDBCharset, t.WriteMode, querySql)
+		}
+		return t.MySQL.WriteMySQLTable(querySql)
+	}, func(attempt int, err error) {
+		metrics.RetryCounter.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT, "apply").Inc()
+		zap.L().Warn("target schema table chunk data applier retry",
+			zap.String("schema", t.SyncMeta.SchemaNameT),
+			zap.String("table", t.SyncMeta.TableNameT),
+			zap.String("chunk", t.SyncMeta.ChunkDetailS),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	})
+}
+
+// 批次写入失败逐行写入，写入失败行连同失败原因写入隔离文件
+func (t *Rows) quarantineBatchRows(prefixSQL string, batchRows []string, batchErr error) error {
+	zap.L().Warn("target schema table chunk batch apply failed, skip-error split rows apply",
+		zap.String("schema", t.SyncMeta.SchemaNameT),
+		zap.String("table", t.SyncMeta.TableNameT),
+		zap.String("chunk", t.SyncMeta.ChunkDetailS),
+		zap.Int("rows", len(batchRows)),
+		zap.Error(batchErr))
+
+	for _, row := range batchRows {
+		rowSQL := t.genBatchData(prefixSQL, []string{row})
+		if err := t.applyBatchData(rowSQL); err != nil {
+			if errq := t.Quarantine.Write(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT, rowSQL, err.Error()); errq != nil {
+				return errq
+			}
+			metrics.FullRowsQuarantinedCounter.WithLabelValues(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT).Inc()
+		}
+	}
+	return nil
+}
+
+// 批次 SQL 前缀，LOAD DATA 模式为空
+func (t *Rows) genBatchPrefix() string {
+	if t.LoadData {
+		return ""
+	}
+	return GenMySQLWriteSQLStmtPrefix(
+		t.SyncMeta.SchemaNameT,
+		t.SyncMeta.TableNameT,
+		t.ColumnNameT,
+		t.WriteMode)
+}
+
+// 单行数据，INSERT 模式 (v1,v2)，LOAD DATA 模式 TAB 分隔换行结尾
+func (t *Rows) genBatchRow(rowsTMP []string) (string, error) {
+	if !t.LoadData {
+		return common.StringsBuilder("(", exstrings.Join(rowsTMP, ","), ")"), nil
+	}
+	fields := make([]string, 0, len(rowsTMP))
+	for _, val := range rowsTMP {
+		field, err := common.SQLValueToLoadDataField(val)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, field)
+	}
+	return common.StringsBuilder(exstrings.Join(fields, "\t"), "\n"), nil
+}
+
+// 批次数据，INSERT 模式 SQL 语句，LOAD DATA 模式数据内容
+func (t *Rows) genBatchData(prefixSQL string, batchRows []string) string {
+	if t.LoadData {
+		return exstrings.Join(batchRows, "")
+	}
+	return common.StringsBuilder(prefixSQL, exstrings.Join(batchRows, ","), GenMySQLWriteSQLStmtSuffix(t.ColumnNameT, t.WriteMode))
+}
@@ -19,8 +19,11 @@ import (
 	"fmt"
 	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/kafka"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
 	"go.uber.org/zap"
 	"math"
@@ -119,14 +122,39 @@ func GenMySQLInsertSQLStmtPrefix(targetSchemaName, targetTableName string, colum
 	var prefixSQL string
 	column := common.StringsBuilder(" (", strings.Join(columns, ","), ")")
 	if safeMode {
-		prefixSQL = common.StringsBuilder(`REPLACE INTO `, targetSchemaName, ".", targetTableName, column, ` VALUES `)
+		prefixSQL = common.StringsBuilder(`REPLACE INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
 
 	} else {
-		prefixSQL = common.StringsBuilder(`INSERT INTO `, targetSchemaName, ".", targetTableName, column, ` VALUES `)
+		prefixSQL = common.StringsBuilder(`INSERT INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
 	}
 	return prefixSQL
 }
 
+// 全量写入 SQL Prefix 语句，按 write-mode 生成
+func GenMySQLWriteSQLStmtPrefix(targetSchemaName, targetTableName string, columns []string, writeMode string) string {
+	column := common.StringsBuilder(" (", strings.Join(columns, ","), ")")
+	switch common.StringUPPER(writeMode) {
+	case common.WriteModeInsert, common.WriteModeUpsert:
+		return common.StringsBuilder(`INSERT INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
+	case common.WriteModeIgnore:
+		return common.StringsBuilder(`INSERT IGNORE INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
+	default:
+		return common.StringsBuilder(`REPLACE INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
+	}
+}
+
+// 全量写入 SQL Suffix 语句，upsert 模式主键/唯一键冲突更新全部字段
+func GenMySQLWriteSQLStmtSuffix(columns []string, writeMode string) string {
+	if !strings.EqualFold(writeMode, common.WriteModeUpsert) {
+		return ""
+	}
+	var updates []string
+	for _, c := range columns {
+		updates = append(updates, common.StringsBuilder(c, "=VALUES(", c, ")"))
+	}
+	return common.StringsBuilder(` ON DUPLICATE KEY UPDATE `, strings.Join(updates, ","))
+}
+
 // SQL Prepare 语句
 func GenMySQLPrepareBindVarStmt(columns, bindVarBatch int) string {
 	var (
@@ -146,7 +174,9 @@ func GenMySQLPrepareBindVarStmt(columns, bindVarBatch int) string {
 
 // Oracle SQL 转换
 // ORACLE 数据库同步需要开附加日志且表需要捕获字段列日志，Logminer 内容 UPDATE/DELETE/INSERT 语句会带所有字段信息
-func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, sourceTable string, metaDB *meta.Meta, mysql *mysql.MySQL, logminers []public.Logminer, taskQueue chan IncrTask) error {
+func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, sourceTable, ddlMode, conflictPolicy string, metaDB *meta.Meta, oracle *oracle.Oracle, mysql *mysql.MySQL, kafkaSink *kafka.Kafka, retryPolicy common.RetryPolicy, keySets [][]string, projection *config.ColumnProjection, columnNameRule map[string]string, opFilter *config.OperationFilter, logminers []public.Logminer, taskQueue chan IncrTask) error {
+	// 任务结束或者转换出错，关闭通道，避免工作池等待未关闭通道阻塞
+	defer close(taskQueue)
 
 	startTime := time.Now()
 	zap.L().Info("oracle table increment log apply start",
@@ -155,6 +185,10 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 		zap.Time("start time", startTime))
 
 	for _, rows := range logminers {
+		// 大事务落盘记录读取，读取失败关闭任务通道并返回错误，由调用方终止增量任务
+		if err := rows.LoadSpill(); err != nil {
+			return fmt.Errorf("oracle table [%s.%s] scn [%d] xid [%s] load txn spill record failed: %v", rows.SourceSchema, rows.SourceTable, rows.SCN, rows.XID, err)
+		}
 		// 如果 sqlRedo 存在记录则继续处理，不存在记录则报错
 		if rows.SQLRedo == "" {
 			return fmt.Errorf("does not meet expectations [oracle sql redo is be null], please check")
@@ -182,10 +216,54 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 		// 比如：UPDATE MARVIN.MARVIN1 SET ID = 2 , NAME = 'marvin' WHERE ID = 2 AND NAME = 'pty'
 		// 比如: drop table marvin.marvin7
 		// 比如: truncate table marvin.marvin7
-		mysqlRedo, operationType, err := translateOracleToMySQLSQL(rows.SQLRedo, rows.SQLUndo, common.StringUPPER(rows.TargetSchema), common.StringUPPER(rows.TargetTable))
+		// 比如: alter table marvin.marvin7 add (c1 number(10,2))
+		// 比如: create index idx_c1 on marvin.marvin7 (c1)
+		var (
+			mysqlRedo     []string
+			operationType string
+			err           error
+		)
+		if rows.Operation == common.MigrateOperationDDL {
+			mysqlRedo, operationType, err = translateOracleDDLToMySQLSQL(dbTypeS, dbTypeT, metaDB, oracle, projection, rows)
+			if err != nil {
+				return err
+			}
+			// ddl-mode log 只记录日志不应用，仍注册任务用于推进 checkpoint
+			if strings.EqualFold(ddlMode, common.DDLModeLog) && len(mysqlRedo) > 0 {
+				zap.L().Warn("increment ddl only log, ddl doesn't apply",
+					zap.String("oracle schema", rows.SourceSchema),
+					zap.String("oracle table", rows.SourceTable),
+					zap.String("oracle ddl", rows.SQLRedo),
+					zap.Strings("mysql ddl", mysqlRedo))
+				mysqlRedo = []string{}
+			}
+		} else {
+			mysqlRedo, operationType, err = translateOracleToMySQLSQL(rows.SQLRedo, rows.SQLUndo, common.StringUPPER(rows.TargetSchema), common.StringUPPER(rows.TargetTable), conflictPolicy, projection, columnNameRule)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 表级别变更过滤，忽略变更不应用以及不发布，仍注册任务用于推进 checkpoint
+		ignored, err := public.IsIgnoredIncrRecord(opFilter, operationType, rows.SQLRedo)
 		if err != nil {
 			return err
 		}
+		if ignored {
+			mysqlRedo = []string{}
+		}
+
+		// 行级路由键，DDL 以及无法解析路由键记录屏障串行应用
+		rowKeys, barrier := public.GenIncrRowKey(operationType, rows.SQLRedo, rows.SQLUndo, keySets)
+
+		// 变更事件，ddl-mode log 不发布 DDL，表级别变更过滤忽略变更不发布
+		var event *kafka.ChangeEvent
+		if kafkaSink != nil && !ignored && (rows.Operation != common.MigrateOperationDDL || len(mysqlRedo) > 0) {
+			event, err = public.GenKafkaChangeEvent(rows, operationType, public.GetEventKeyColumns(keySets), projection)
+			if err != nil {
+				return err
+			}
+		}
 
 		// 注册任务到 Job 队列
 		lp := IncrTask{
@@ -195,6 +273,13 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 			TaskMode:       taskMode,
 			MetaDB:         metaDB,
 			MySQL:          mysql,
+			Kafka:          kafkaSink,
+			Event:          event,
+			RetryPolicy:    retryPolicy,
+			ConflictPolicy: conflictPolicy,
+			RowKeys:        rowKeys,
+			Barrier:        barrier,
+			Seq:            rows.Seq,
 			GlobalSCN:      rows.SCN, // 更新元数据 GLOBAL_SCN 至当前消费的 SCN 号
 			SourceTableSCN: rows.SCN,
 			SourceSchema:   rows.SourceSchema,
@@ -220,16 +305,16 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 		zap.Time("end time", endTime),
 		zap.String("cost time", time.Since(startTime).String()))
 
-	// 任务结束，关闭通道
-	close(taskQueue)
-
 	return nil
 }
 
 // Oracle SQL 转换
 // 1、INSERT INTO / REPLACE INTO
 // 2、UPDATE / DELETE、REPLACE INTO
-func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targetTable string) ([]string, string, error) {
+// 3、conflict-policy error/skip 按原始语义生成 INSERT INTO / UPDATE，用于冲突判断
+// 4、字段投影，排除字段不写入且不作为 WHERE 条件
+// 5、字段名映射，column_name_rule 源端字段名转换为目标字段名
+func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targetTable, conflictPolicy string, projection *config.ColumnProjection, columnNameRule map[string]string) ([]string, string, error) {
 	var (
 		sqls          []string
 		operationType string
@@ -240,10 +325,17 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 	}
 
 	stmt := public.ExtractStmt(astNode)
+	if err = stmt.Project(projection); err != nil {
+		return []string{}, operationType, err
+	}
+	if err = stmt.Rename(columnNameRule); err != nil {
+		return []string{}, operationType, err
+	}
 
-	// 库名、表名转换
+	// 库名、表名转换，反引号引用避免关键字表名语法错误
 	stmt.Schema = targetSchema
 	stmt.Table = targetTable
+	targetName := common.QuoteMySQLTableName(stmt.Schema, stmt.Table)
 
 	switch {
 	case stmt.Operation == common.MigrateOperationUpdate:
@@ -253,18 +345,37 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 			return []string{}, operationType, fmt.Errorf("parse error: %v\n", err.Error())
 		}
 		undoStmt := public.ExtractStmt(astUndoNode)
+		if err = undoStmt.Project(projection); err != nil {
+			return []string{}, operationType, err
+		}
+		if err = undoStmt.Rename(columnNameRule); err != nil {
+			return []string{}, operationType, err
+		}
 
 		stmt.Data = undoStmt.Before
 		for column, _ := range stmt.Before {
 			stmt.Columns = append(stmt.Columns, strings.ToUpper(column))
 		}
 
+		if !isConflictOverwrite(conflictPolicy) {
+			var sets []string
+			for _, col := range stmt.Columns {
+				sets = append(sets, common.StringsBuilder(col, " = ", stmt.Data[col].(string)))
+			}
+			updateSQL := common.StringsBuilder(`UPDATE `, targetName, ` SET `, strings.Join(sets, ","))
+			if stmt.WhereExpr != "" {
+				updateSQL = common.StringsBuilder(updateSQL, ` `, stmt.WhereExpr)
+			}
+			sqls = append(sqls, updateSQL)
+			break
+		}
+
 		var deleteSQL string
 
 		if stmt.WhereExpr == "" {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table)
+			deleteSQL = common.StringsBuilder(`DELETE FROM `, targetName)
 		} else {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table, ` `, stmt.WhereExpr)
+			deleteSQL = common.StringsBuilder(`DELETE FROM `, targetName, ` `, stmt.WhereExpr)
 		}
 
 		var (
@@ -273,7 +384,7 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 		for _, col := range stmt.Columns {
 			values = append(values, stmt.Data[col].(string))
 		}
-		insertSQL := common.StringsBuilder(`REPLACE INTO `, stmt.Schema, ".", stmt.Table,
+		insertSQL := common.StringsBuilder(`REPLACE INTO `, targetName,
 			"(",
 			strings.Join(stmt.Columns, ","),
 			")",
@@ -293,7 +404,11 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 		for _, col := range stmt.Columns {
 			values = append(values, stmt.Data[col].(string))
 		}
-		replaceSQL := common.StringsBuilder(`REPLACE INTO `, stmt.Schema, ".", stmt.Table,
+		insertPrefix := `REPLACE INTO `
+		if !isConflictOverwrite(conflictPolicy) {
+			insertPrefix = `INSERT INTO `
+		}
+		replaceSQL := common.StringsBuilder(insertPrefix, targetName,
 			"(",
 			strings.Join(stmt.Columns, ","),
 			")",
@@ -310,9 +425,9 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 		var deleteSQL string
 
 		if stmt.WhereExpr == "" {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table)
+			deleteSQL = common.StringsBuilder(`DELETE FROM `, targetName)
 		} else {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table, ` `, stmt.WhereExpr)
+			deleteSQL = common.StringsBuilder(`DELETE FROM `, targetName, ` `, stmt.WhereExpr)
 		}
 
 		sqls = append(sqls, deleteSQL)
@@ -320,15 +435,20 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 	case stmt.Operation == common.MigrateOperationTruncate:
 		operationType = common.MigrateOperationTruncateTable
 
-		truncateSQL := common.StringsBuilder(`TRUNCATE TABLE `, stmt.Schema, ".", stmt.Table)
+		truncateSQL := common.StringsBuilder(`TRUNCATE TABLE `, targetName)
 		sqls = append(sqls, truncateSQL)
 
 	case stmt.Operation == common.MigrateOperationDrop:
 		operationType = common.MigrateOperationDropTable
 
-		dropSQL := common.StringsBuilder(`DROP TABLE `, stmt.Schema, ".", stmt.Table)
+		dropSQL := common.StringsBuilder(`DROP TABLE `, targetName)
 
 		sqls = append(sqls, dropSQL)
 	}
 	return sqls, operationType, nil
 }
+
+// 未配置 conflict-policy 默认 overwrite
+func isConflictOverwrite(conflictPolicy string) bool {
+	return strings.EqualFold(conflictPolicy, "") || strings.EqualFold(conflictPolicy, common.ConflictPolicyOverwrite)
+}
@@ -20,10 +20,14 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/kafka"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"strconv"
 	"strings"
@@ -35,27 +39,73 @@ func NewIncr(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
-	oracleMiner, err := oracle.NewOracleLogminerEngine(ctx, cfg.OracleConfig)
-	if err != nil {
-		return nil, err
+	tuning := common.NewTuning(cfg.TuningParams())
+	// 登记运行中调优参数，SIGHUP 以及 REST/gRPC tune 接口按任务 ID 更新
+	common.RegisterTuning(cfg.AppConfig.TaskID, tuning)
+	oracleDB.Throttle = tuning.ExtractThrottle()
+	oracleDB.SlowLog = common.NewSlowLog(logger.SlowLogger(), common.SlowLogSource, cfg.LogConfig.SlowSourceThreshold)
+	oracleDB.Budget = common.NewMemoryBudget(cfg.FullConfig.MemoryBudget)
+	// capture-mode flashback 闪回查询增量，不连接 logminer
+	var oracleMiner *oracle.Oracle
+	if !strings.EqualFold(cfg.AllConfig.CaptureMode, common.CaptureModeFlashback) {
+		oracleMiner, err = oracle.NewOracleLogminerEngine(ctx, cfg.OracleConfig)
+		if err != nil {
+			return nil, err
+		}
 	}
 	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 	if err != nil {
 		return nil, err
 	}
+	mysqlDB.Throttle = tuning.ApplyThrottle()
+	mysqlDB.SlowLog = common.NewSlowLog(logger.SlowLogger(), common.SlowLogTarget, cfg.LogConfig.SlowTargetThreshold)
 	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
+	mysqlDB.Audit = meta.NewDDLAudit(ctx, metaDB, cfg.AppConfig.TaskID, cfg.DBTypeT, cfg.TaskMode, cfg.AppConfig.DDLAuditFile)
+	var kafkaSink *kafka.Kafka
+	if strings.EqualFold(cfg.AllConfig.SinkType, common.SinkTypeKafka) {
+		kafkaSink, err = kafka.NewKafkaProducer(ctx, cfg.KafkaConfig)
+		if err != nil {
+			return nil, err
+		}
+		kafkaSink.Throttle = mysqlDB.Throttle
+	}
 
-	return &Migrate{
+	m := &Migrate{
 		Ctx:         ctx,
 		Cfg:         cfg,
 		Oracle:      oracleDB,
 		OracleMiner: oracleMiner,
 		Mysql:       mysqlDB,
 		MetaDB:      metaDB,
-	}, nil
+		Kafka:       kafkaSink,
+		Tuning:      tuning,
+	}
+
+	// 多 schema 增量路由，源端按 schema 设置 CURRENT_SCHEMA 单独连接，其余连接共用
+	for _, route := range cfg.AllConfig.SchemaRoute {
+		routeCfg := cfg.SchemaRouteConfig(route)
+		routeOracle, err := oracle.NewOracleDBEngine(ctx, routeCfg.OracleConfig, routeCfg.SchemaConfig.SourceSchema)
+		if err != nil {
+			return nil, err
+		}
+		routeOracle.Throttle = oracleDB.Throttle
+		routeOracle.SlowLog = oracleDB.SlowLog
+		routeOracle.Budget = oracleDB.Budget
+		m.Routes = append(m.Routes, &Migrate{
+			Ctx:         ctx,
+			Cfg:         routeCfg,
+			Oracle:      routeOracle,
+			OracleMiner: oracleMiner,
+			Mysql:       mysqlDB,
+			MetaDB:      metaDB,
+			Kafka:       kafkaSink,
+			Tuning:      tuning,
+		})
+	}
+	return m, nil
 }
 
 func (r *Migrate) Incr() error {
@@ -91,12 +141,41 @@ func (r *Migrate) Incr() error {
 		return fmt.Errorf("mysql current config charset [%v] isn't support, support charset [%v]", r.Cfg.MySQLConfig.Charset, common.MigrateDataSupportCharset)
 	}
 
+	// [[all.schema-route]] 多 schema 依次完成全量同步以及增量元数据初始化，之后共用 logminer 会话轮询增量
+	for _, m := range r.schemaMigrates() {
+		if err = m.initIncr(oraDBVersion); err != nil {
+			return err
+		}
+	}
+	if r.Cfg.DryRun {
+		return nil
+	}
+	return r.loopTableIncrRecord()
+}
+
+// initIncr 单 schema 增量前置检查、全量同步以及增量元数据初始化
+func (r *Migrate) initIncr(oraDBVersion string) error {
 	// 获取配置文件待同步表列表
 	exporters, err := public.FilterCFGTable(r.Cfg, r.Oracle)
 	if err != nil {
 		return err
 	}
 
+	if !common.IsContainString(common.IncrCaptureModes, r.getCaptureMode()) {
+		return fmt.Errorf("all config capture-mode [%v] isn't support, support capture-mode [%v]", r.Cfg.AllConfig.CaptureMode, common.IncrCaptureModes)
+	}
+	// 增量同步前置检查，capture-mode flashback 不依赖 logminer 以及补充日志
+	if strings.EqualFold(r.getCaptureMode(), common.CaptureModeLogminer) {
+		if err = public.CheckIncrPrerequisite(r.Cfg, r.Oracle, oraDBVersion, exporters); err != nil {
+			return err
+		}
+	}
+
+	// dry-run 完成增量同步前置检查后，只输出全量阶段待同步表列表、chunk 切分计划以及样例 SQL，不执行迁移
+	if r.Cfg.DryRun {
+		return r.DryRun(exporters, common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion))
+	}
+
 	// 判断 [wait_sync_meta] 是否存在错误记录，是否可进行 ALL
 	errTotals, err := meta.NewWaitSyncMetaModel(r.MetaDB).CountsErrWaitSyncMetaBySchema(r.Ctx, &meta.WaitSyncMeta{
 		DBTypeS:     r.Cfg.DBTypeS,
@@ -165,12 +244,6 @@ func (r *Migrate) Incr() error {
 			if len(panicTables) != 0 {
 				return fmt.Errorf("table list %s can't incremently sync, because table increment sync meta record is exist and full meta sync isn't finished", panicTables)
 			}
-			// 增量数据同步
-			for range time.Tick(300 * time.Millisecond) {
-				if err := r.syncTableIncrRecord(); err != nil {
-					return err
-				}
-			}
 			return nil
 		}
 
@@ -180,8 +253,12 @@ func (r *Migrate) Incr() error {
 
 	// 如果下游数据库增量元数据表 incr_sync_meta 不存在任何记录，说明未进行过数据同步，则进行全量 + 增量数据同步
 	if len(incrExistTableList) == 0 && len(incrIsNotExistTableList) == len(exporters) {
-		// 全量同步
-		err = r.Full()
+		// 全量同步，sink-type kafka 且 full-load = false 不发布全量数据，增量从当前 SCN 开始发布
+		if r.Kafka != nil && !r.Cfg.KafkaConfig.FullLoad {
+			err = r.initKafkaWaitSyncMeta(exporters)
+		} else {
+			err = r.Full()
+		}
 		if err != nil {
 			return err
 		}
@@ -235,34 +312,115 @@ func (r *Migrate) Incr() error {
 			}
 		}
 
-		// 增量数据同步
-		for range time.Tick(300 * time.Millisecond) {
-			if err = r.syncTableIncrRecord(); err != nil {
+		return nil
+	}
+	return fmt.Errorf("increment sync taskflow condition isn't match, can't sync")
+}
+
+// loopTableIncrRecord 按 logminer-interval 间隔持续挖掘并应用增量数据，任务上下文取消或收到退出信号后退出
+// capture-mode flashback 按相同间隔闪回查询微批同步
+// 多 schema 增量路由每轮共用一次日志挖掘，按 schema 依次应用
+func (r *Migrate) loopTableIncrRecord() error {
+	syncTableRecord := r.syncTableIncrRecord
+	if strings.EqualFold(r.getCaptureMode(), common.CaptureModeFlashback) {
+		syncTableRecord = func(schemas []*Migrate) error {
+			for _, m := range schemas {
+				if err := m.syncTableFlashbackRecord(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	schemas := r.schemaMigrates()
+	var sourceSchemas []string
+	for _, m := range schemas {
+		sourceSchemas = append(sourceSchemas, m.Cfg.SchemaConfig.SourceSchema)
+	}
+	interval := r.Cfg.AllConfig.LogminerInterval
+	if interval <= 0 {
+		interval = common.DefaultLogminerInterval
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Ctx.Done():
+			zap.L().Warn("oracle increment sync table data canceled",
+				zap.Strings("schema", sourceSchemas),
+				zap.Error(r.Ctx.Err()))
+			return r.Ctx.Err()
+		case <-signal.Done():
+			// 收到退出信号，当前批次已应用且 incr_sync_meta 已更新，直接退出
+			return fmt.Errorf("oracle schema %v increment task interrupted by exit signal, checkpoint saved, please rerun to resume", sourceSchemas)
+		case <-ticker.C:
+			// 任务暂停，跳过本轮日志挖掘，恢复后从 incr_sync_meta 已应用 SCN 继续
+			if signal.IsPaused(r.Cfg.AppConfig.TaskID) {
+				continue
+			}
+			if err := syncTableRecord(schemas); err != nil {
 				return err
 			}
+			for _, m := range schemas {
+				// 同步延迟上报
+				public.ReportIncrLag(m.Ctx, m.MetaDB, m.Oracle, m.Cfg.DBTypeS, m.Cfg.DBTypeT, m.Cfg.SchemaConfig.SourceSchema)
+			}
 		}
-		return nil
 	}
-	return fmt.Errorf("increment sync taskflow condition isn't match, can't sync")
 }
 
-func (r *Migrate) syncTableIncrRecord() error {
-	// 获取自定义库表名规则
-	tableNameRule, err := r.GetTableNameRule()
-	if err != nil {
-		return err
+// 增量同步 schema 列表，[schema-config] 以及 [[all.schema-route]]
+func (r *Migrate) schemaMigrates() []*Migrate {
+	return append([]*Migrate{r}, r.Routes...)
+}
+
+// 增量捕获方式，未配置默认 logminer
+func (r *Migrate) getCaptureMode() string {
+	if strings.EqualFold(r.Cfg.AllConfig.CaptureMode, "") {
+		return common.CaptureModeLogminer
 	}
+	return common.StringUPPER(r.Cfg.AllConfig.CaptureMode)
+}
 
-	// 获取增量所需得日志文件
-	logFiles, err := r.getTableIncrRecordLogfile()
+// syncTableIncrRecord 多 schema 增量路由共用 logminer 会话，每个日志文件仅挖掘一次，过滤条件包含全部 schema，捕获记录按 schema 拆分后依次应用
+func (r *Migrate) syncTableIncrRecord(schemas []*Migrate) error {
+	// 获取增量所需得日志文件，以增量元数据 GLOBAL_SCN 最小的 schema 为准
+	var (
+		logMigrate *Migrate
+		minGlobal  uint64
+	)
+	for _, m := range schemas {
+		globalSCN, err := meta.NewIncrSyncMetaModel(m.MetaDB).GetIncrSyncMetaMinGlobalScnSBySchema(m.Ctx, &meta.IncrSyncMeta{
+			DBTypeS:     m.Cfg.DBTypeS,
+			DBTypeT:     m.Cfg.DBTypeT,
+			SchemaNameS: m.Cfg.SchemaConfig.SourceSchema,
+		})
+		if err != nil {
+			return err
+		}
+		if logMigrate == nil || globalSCN < minGlobal {
+			logMigrate, minGlobal = m, globalSCN
+		}
+	}
+	logFiles, err := logMigrate.getTableIncrRecordLogfile()
 	if err != nil {
 		return err
 	}
 	zap.L().Info("increment table log file get",
 		zap.String("logfile", fmt.Sprintf("%v", logFiles)))
 
+	// 大事务落盘
+	spill := public.NewTxnSpill(r.Cfg.SchemaConfig.SourceSchema, r.Cfg.AllConfig.TxnSpillDir, r.Cfg.AllConfig.TxnSpillRows)
+	defer spill.Close()
+
 	// 遍历所有日志文件
 	for _, log := range logFiles {
+		// 上一日志文件增量记录已应用完毕，落盘文件复用
+		if err = spill.Reset(); err != nil {
+			return err
+		}
+
 		// 获取日志文件起始 SCN
 		logFileStartSCN, err := common.StrconvUintBitSize(log["FIRST_CHANGE"], 64)
 		if err != nil {
@@ -275,44 +433,42 @@ func (r *Migrate) syncTableIncrRecord() error {
 			return fmt.Errorf("get oracle log file end scn %s utils.StrconvUintBitSize failed: %v", log["NEXT_CHANGE"], err)
 		}
 
-		zap.L().Info("increment table log file logminer",
-			zap.String("logfile", log["LOG_FILE"]),
-			zap.Uint64("logfile start scn", logFileStartSCN),
-			zap.Uint64("logminer start scn", logFileStartSCN),
-			zap.Uint64("logfile end scn", logFileEndSCN))
-
-		// 获取增量元数据表内所需同步表信息
-		incrSyncMetas, err := meta.NewIncrSyncMetaModel(r.MetaDB).DetailIncrSyncMetaBySchema(r.Ctx, &meta.IncrSyncMeta{
-			DBTypeS:     r.Cfg.DBTypeS,
-			DBTypeT:     r.Cfg.DBTypeT,
-			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
-		})
-		if err != nil {
-			return err
-		}
-		if len(incrSyncMetas) == 0 {
-			return fmt.Errorf("mysql increment mete table [incr_sync_meta] can't null")
-		}
-
+		// 获取各 schema 增量元数据表内所需同步表信息，GLOBAL_SCN 已越过当前日志文件的 schema 不参与本日志文件挖掘
 		var (
-			transferTableMetaMap map[string]uint64
-			syncSourceTables     []string
+			incrSchemas   []*incrSchemaRecord
+			schemaFilters []public.IncrSchemaFilter
+			minSourceSCN  uint64
 		)
-		transferTableMetaMap = make(map[string]uint64)
-		for _, tbl := range incrSyncMetas {
-			transferTableMetaMap[strings.ToUpper(tbl.TableNameS)] = tbl.TableScnS
-			syncSourceTables = append(syncSourceTables, strings.ToUpper(tbl.TableNameS))
+		for _, m := range schemas {
+			s, err := m.getIncrSchemaRecord(logFileEndSCN)
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				continue
+			}
+			if len(incrSchemas) == 0 || s.minSourceTableSCN < minSourceSCN {
+				minSourceSCN = s.minSourceTableSCN
+			}
+			incrSchemas = append(incrSchemas, s)
+			schemaFilters = append(schemaFilters, public.IncrSchemaFilter{
+				SourceSchema:  m.Cfg.SchemaConfig.SourceSchema,
+				TargetSchema:  m.Cfg.SchemaConfig.TargetSchema,
+				SourceTables:  s.syncSourceTables,
+				TableNameRule: s.tableNameRule,
+			})
 		}
-
-		// 获取 logminer query 起始最小 SCN
-		minSourceTableSCN, err := meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinTableScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
-			DBTypeS:     r.Cfg.DBTypeS,
-			DBTypeT:     r.Cfg.DBTypeT,
-			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema})
-		if err != nil {
-			return err
+		if len(incrSchemas) == 0 {
+			continue
 		}
 
+		zap.L().Info("increment table log file logminer",
+			zap.String("logfile", log["LOG_FILE"]),
+			zap.Uint64("logfile start scn", logFileStartSCN),
+			zap.Uint64("logminer start scn", logFileStartSCN),
+			zap.Uint64("logfile end scn", logFileEndSCN),
+			zap.Int("schema counts", len(incrSchemas)))
+
 		// logminer 运行
 		if err = r.OracleMiner.AddOracleLogminerlogFile(log["LOG_FILE"]); err != nil {
 			return err
@@ -322,20 +478,18 @@ func (r *Migrate) syncTableIncrRecord() error {
 			return err
 		}
 
-		// 捕获数据
+		// 捕获数据，全部 schema 单次挖掘
 		rowsResult, err := public.GetOracleIncrRecord(r.Ctx, r.OracleMiner,
-			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-			common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-			common.StringArrayToCapitalChar(syncSourceTables),
-			tableNameRule,
-			strconv.FormatUint(minSourceTableSCN, 10),
-			r.Cfg.AllConfig.LogminerQueryTimeout)
+			schemaFilters,
+			strconv.FormatUint(minSourceSCN, 10),
+			r.Cfg.AllConfig.LogminerQueryTimeout,
+			spill)
 		if err != nil {
 			return err
 		}
 		zap.L().Info("increment table log extractor", zap.String("logfile", log["LOG_FILE"]),
 			zap.Uint64("logfile start scn", logFileStartSCN),
-			zap.Uint64("source table last scn", minSourceTableSCN),
+			zap.Uint64("source table last scn", minSourceSCN),
 			zap.Int("row counts", len(rowsResult)))
 
 		// logminer 关闭
@@ -355,155 +509,238 @@ func (r *Migrate) syncTableIncrRecord() error {
 			return err
 		}
 
-		// 按表级别筛选数据
-		var (
-			logminerContentMap map[string][]public.Logminer
-		)
-		if len(rowsResult) > 0 {
-			// 判断当前日志文件是否是重做日志文件
-			if common.IsContainString(redoLogList, log["LOG_FILE"]) {
-				// 判断是否是当前重做日志文件
-				// 如果当前日志文件是当前重做日志文件则 FilterOracleIncrRecord 只运行一次大于或等于对应表数据记录，也就是只重放一次已消费得SCN
-				if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
-					logminerContentMap, err = public.FilterOracleIncrRecord(
-						rowsResult,
-						syncSourceTables,
-						transferTableMetaMap,
-						r.Cfg.AllConfig.FilterThreads,
-						common.MigrateCurrentResetFlag,
-					)
-					if err != nil {
-						return err
-					}
-					zap.L().Warn("oracle current redo log reset flag", zap.Int("MigrateCurrentResetFlag", common.MigrateCurrentResetFlag))
-					common.MigrateCurrentResetFlag = 1
-				} else {
-					logminerContentMap, err = public.FilterOracleIncrRecord(
-						rowsResult,
-						syncSourceTables,
-						transferTableMetaMap,
-						r.Cfg.AllConfig.FilterThreads,
-						0,
-					)
-					if err != nil {
-						return err
-					}
-				}
+		// 捕获记录按 schema 拆分
+		schemaRows := make(map[string][]public.Logminer)
+		for _, row := range rowsResult {
+			sourceSchema := common.StringUPPER(row.SourceSchema)
+			schemaRows[sourceSchema] = append(schemaRows[sourceSchema], row)
+		}
 
-				if len(logminerContentMap) > 0 {
-					// 数据应用
-					if err := applyOracleIncrRecord(r.MetaDB, r.Mysql, r.Cfg, logminerContentMap); err != nil {
-						return err
-					}
+		for _, s := range incrSchemas {
+			m := s.migrate
+			metrics.IncrCurrentSCNGauge.WithLabelValues(common.StringUPPER(m.Cfg.SchemaConfig.SourceSchema)).Set(float64(currentRedoLogMaxSCN))
+
+			// 索引 DDL 路由至所属表
+			s.rowsResult = public.RouteOracleIncrDDL(m.Mysql, common.StringUPPER(m.Cfg.SchemaConfig.TargetSchema),
+				schemaRows[common.StringUPPER(m.Cfg.SchemaConfig.SourceSchema)], s.tableNameRule)
+
+			// 按表级别筛选数据
+			var (
+				logminerContentMap map[string][]public.Logminer
+			)
+			if len(s.rowsResult) > 0 {
+				// 判断当前日志文件是否是重做日志文件
+				if common.IsContainString(redoLogList, log["LOG_FILE"]) {
+					// 判断是否是当前重做日志文件
+					// 如果当前日志文件是当前重做日志文件则 FilterOracleIncrRecord 只运行一次大于或等于对应表数据记录，也就是只重放一次已消费得SCN
 					if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
-						// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
-						err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(r.Ctx,
-							r.Cfg.DBTypeS,
-							r.Cfg.DBTypeT,
-							r.Cfg.SchemaConfig.SourceSchema,
-							currentRedoLogMaxSCN,
-							logFileStartSCN,
-							logFileEndSCN)
+						logminerContentMap, err = public.FilterOracleIncrRecord(
+							s.rowsResult,
+							s.syncSourceTables,
+							s.transferTableMetaMap,
+							m.Cfg.AllConfig.FilterThreads,
+							m.currentResetFlag,
+							m.Cfg.AllConfig.DDLMode,
+						)
 						if err != nil {
 							return err
 						}
+						zap.L().Warn("oracle current redo log reset flag", zap.Int("MigrateCurrentResetFlag", m.currentResetFlag))
+						m.currentResetFlag = 1
 					} else {
-						// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
-						err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(r.Ctx,
-							r.Cfg.DBTypeS,
-							r.Cfg.DBTypeT,
-							r.Cfg.SchemaConfig.SourceSchema,
-							currentRedoLogMaxSCN,
-							logFileStartSCN,
-							logFileEndSCN,
-							syncSourceTables)
+						logminerContentMap, err = public.FilterOracleIncrRecord(
+							s.rowsResult,
+							s.syncSourceTables,
+							s.transferTableMetaMap,
+							m.Cfg.AllConfig.FilterThreads,
+							0,
+							m.Cfg.AllConfig.DDLMode,
+						)
 						if err != nil {
 							return err
 						}
 					}
 
+					if len(logminerContentMap) > 0 {
+						// 数据应用
+						if err := applyOracleIncrRecord(m.MetaDB, m.Oracle, m.Mysql, m.Kafka, m.Cfg, m.Tuning.IncrApplyThreads(), logminerContentMap); err != nil {
+							return err
+						}
+						if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
+							// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
+							err = meta.NewCommonModel(m.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(m.Ctx,
+								m.Cfg.DBTypeS,
+								m.Cfg.DBTypeT,
+								m.Cfg.SchemaConfig.SourceSchema,
+								currentRedoLogMaxSCN,
+								logFileStartSCN,
+								logFileEndSCN)
+							if err != nil {
+								return err
+							}
+						} else {
+							// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
+							err = meta.NewCommonModel(m.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(m.Ctx,
+								m.Cfg.DBTypeS,
+								m.Cfg.DBTypeT,
+								m.Cfg.SchemaConfig.SourceSchema,
+								currentRedoLogMaxSCN,
+								logFileStartSCN,
+								logFileEndSCN,
+								s.syncSourceTables)
+							if err != nil {
+								return err
+							}
+						}
+
+						continue
+					}
+					zap.L().Warn("increment table log file logminer data that needn't to be consumed by current redo, transferdb will continue to capture")
 					continue
 				}
-				zap.L().Warn("increment table log file logminer data that needn't to be consumed by current redo, transferdb will continue to capture")
-				continue
-			}
-			logminerContentMap, err = public.FilterOracleIncrRecord(
-				rowsResult,
-				syncSourceTables,
-				transferTableMetaMap,
-				r.Cfg.AllConfig.FilterThreads,
-				0,
-			)
-			if err != nil {
-				return err
-			}
-			if len(logminerContentMap) > 0 {
-				// 数据应用
-				if err := applyOracleIncrRecord(r.MetaDB, r.Mysql, r.Cfg, logminerContentMap); err != nil {
-					return err
-				}
-				// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
-				err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(r.Ctx,
-					r.Cfg.DBTypeS,
-					r.Cfg.DBTypeT,
-					r.Cfg.SchemaConfig.SourceSchema,
-					logFileEndSCN,
-					syncSourceTables)
+				logminerContentMap, err = public.FilterOracleIncrRecord(
+					s.rowsResult,
+					s.syncSourceTables,
+					s.transferTableMetaMap,
+					m.Cfg.AllConfig.FilterThreads,
+					0,
+					m.Cfg.AllConfig.DDLMode,
+				)
 				if err != nil {
 					return err
 				}
+				if len(logminerContentMap) > 0 {
+					// 数据应用
+					if err := applyOracleIncrRecord(m.MetaDB, m.Oracle, m.Mysql, m.Kafka, m.Cfg, m.Tuning.IncrApplyThreads(), logminerContentMap); err != nil {
+						return err
+					}
+					// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
+					err = meta.NewCommonModel(m.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(m.Ctx,
+						m.Cfg.DBTypeS,
+						m.Cfg.DBTypeT,
+						m.Cfg.SchemaConfig.SourceSchema,
+						logFileEndSCN,
+						s.syncSourceTables)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				zap.L().Warn("increment table log file logminer data that needn't to be consumed by logfile, transferdb will continue to capture")
 				continue
 			}
-			zap.L().Warn("increment table log file logminer data that needn't to be consumed by logfile, transferdb will continue to capture")
-			continue
-		}
 
-		// 当前日志文件不存在数据记录
-		if common.IsContainString(redoLogList, log["LOG_FILE"]) {
-			if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
-				// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
-				err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(r.Ctx,
-					r.Cfg.DBTypeS,
-					r.Cfg.DBTypeT,
-					r.Cfg.SchemaConfig.SourceSchema,
-					currentRedoLogMaxSCN,
-					logFileStartSCN,
-					logFileEndSCN)
-				if err != nil {
-					return err
+			// 当前日志文件不存在数据记录
+			if common.IsContainString(redoLogList, log["LOG_FILE"]) {
+				if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
+					// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
+					err = meta.NewCommonModel(m.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(m.Ctx,
+						m.Cfg.DBTypeS,
+						m.Cfg.DBTypeT,
+						m.Cfg.SchemaConfig.SourceSchema,
+						currentRedoLogMaxSCN,
+						logFileStartSCN,
+						logFileEndSCN)
+					if err != nil {
+						return err
+					}
+				} else {
+					// 当前所有日志文件内容应用完毕，判断是否更新 GLOBAL_SCN 至日志文件结束 SCN
+					err = meta.NewCommonModel(m.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(m.Ctx,
+						m.Cfg.DBTypeS,
+						m.Cfg.DBTypeT,
+						m.Cfg.SchemaConfig.SourceSchema,
+						currentRedoLogMaxSCN,
+						logFileStartSCN,
+						logFileEndSCN,
+						s.syncSourceTables)
+					if err != nil {
+						return err
+					}
 				}
 			} else {
-				// 当前所有日志文件内容应用完毕，判断是否更新 GLOBAL_SCN 至日志文件结束 SCN
-				err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(r.Ctx,
-					r.Cfg.DBTypeS,
-					r.Cfg.DBTypeT,
-					r.Cfg.SchemaConfig.SourceSchema,
-					currentRedoLogMaxSCN,
-					logFileStartSCN,
+				// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
+				err = meta.NewCommonModel(m.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(m.Ctx,
+					m.Cfg.DBTypeS,
+					m.Cfg.DBTypeT,
+					m.Cfg.SchemaConfig.SourceSchema,
 					logFileEndSCN,
-					syncSourceTables)
+					s.syncSourceTables)
 				if err != nil {
 					return err
 				}
 			}
-		} else {
-			// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
-			err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(r.Ctx,
-				r.Cfg.DBTypeS,
-				r.Cfg.DBTypeT,
-				r.Cfg.SchemaConfig.SourceSchema,
-				logFileEndSCN,
-				syncSourceTables)
-			if err != nil {
-				return err
-			}
+			zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture")
+			continue
 		}
-		zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture")
-		continue
 	}
 	return nil
 }
 
+// incrSchemaRecord 单 schema 当前日志文件增量同步信息
+type incrSchemaRecord struct {
+	migrate              *Migrate
+	tableNameRule        map[string]string
+	transferTableMetaMap map[string]uint64
+	syncSourceTables     []string
+	minSourceTableSCN    uint64
+	rowsResult           []public.Logminer
+}
+
+// getIncrSchemaRecord 获取 schema 增量元数据表内所需同步表信息，GLOBAL_SCN 不小于日志文件结束 SCN 说明日志文件已应用，返回 nil
+func (r *Migrate) getIncrSchemaRecord(logFileEndSCN uint64) (*incrSchemaRecord, error) {
+	globalSCN, err := meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinGlobalScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if globalSCN >= logFileEndSCN {
+		return nil, nil
+	}
+
+	// 获取自定义库表名规则
+	tableNameRule, err := r.GetTableNameRule()
+	if err != nil {
+		return nil, err
+	}
+
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(r.MetaDB).DetailIncrSyncMetaBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(incrSyncMetas) == 0 {
+		return nil, fmt.Errorf("mysql increment mete table [incr_sync_meta] can't null")
+	}
+
+	s := &incrSchemaRecord{
+		migrate:              r,
+		tableNameRule:        tableNameRule,
+		transferTableMetaMap: make(map[string]uint64),
+	}
+	for _, tbl := range incrSyncMetas {
+		s.transferTableMetaMap[strings.ToUpper(tbl.TableNameS)] = tbl.TableScnS
+		s.syncSourceTables = append(s.syncSourceTables, strings.ToUpper(tbl.TableNameS))
+	}
+
+	// 获取 logminer query 起始最小 SCN
+	s.minSourceTableSCN, err = meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinTableScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema})
+	if err != nil {
+		return nil, err
+	}
+	metrics.IncrAppliedSCNGauge.WithLabelValues(common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema)).Set(float64(s.minSourceTableSCN))
+	return s, nil
+}
+
 func (r *Migrate) getTableIncrRecordLogfile() ([]map[string]string, error) {
 	var logFiles []map[string]string
 
@@ -0,0 +1,163 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"time"
+)
+
+// TiDB 同一张表索引逐条 ALTER TABLE 重建，兼容不支持 multi-schema change 的版本
+const rebuildIndexCombine = false
+
+// dropTargetTableIndex 全量装载前删除下游表非唯一二级索引以及外键，删除之前索引定义写入元数据库 [index_rebuild_meta]
+// 元数据库已存在记录说明上次中断时已删除，沿用已记录定义，不重复获取
+func (r *Migrate) dropTargetTableIndex(tableNameS, schemaNameT, tableNameT string) error {
+	rebuildMetas, err := meta.NewIndexRebuildMetaModel(r.MetaDB).DetailIndexRebuildMeta(r.Ctx, &meta.IndexRebuildMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableNameS,
+		TaskMode:    r.Cfg.TaskMode,
+	})
+	if err != nil {
+		return err
+	}
+	if len(rebuildMetas) > 0 {
+		return r.Mysql.DropMySQLTableRebuildIndex(schemaNameT, tableNameT, genRebuildIndex(rebuildMetas))
+	}
+
+	rebuilds, err := r.Mysql.GetMySQLTableRebuildIndex(schemaNameT, tableNameT)
+	if err != nil {
+		return err
+	}
+	if len(rebuilds) == 0 {
+		return nil
+	}
+	for _, ri := range rebuilds {
+		rebuildMetas = append(rebuildMetas, meta.IndexRebuildMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:  common.StringUPPER(tableNameS),
+			SchemaNameT: schemaNameT,
+			TableNameT:  tableNameT,
+			TaskMode:    r.Cfg.TaskMode,
+			IndexName:   ri.IndexName,
+			IndexType:   ri.IndexType,
+			Definition:  ri.Definition,
+		})
+	}
+	if err = meta.NewIndexRebuildMetaModel(r.MetaDB).BatchCreateIndexRebuildMeta(r.Ctx, rebuildMetas, r.Cfg.AppConfig.InsertBatchSize); err != nil {
+		return err
+	}
+	startTime := time.Now()
+	if err = r.Mysql.DropMySQLTableRebuildIndex(schemaNameT, tableNameT, rebuilds); err != nil {
+		return err
+	}
+	zap.L().Info("full single table target index dropped before load",
+		zap.String("schema", schemaNameT),
+		zap.String("table", tableNameT),
+		zap.Int("index counts", len(rebuilds)),
+		zap.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// rebuildTargetTableIndex 全量装载完成重建下游表二级索引以及外键，重建成功清理元数据库记录
+func (r *Migrate) rebuildTargetTableIndex(tableNameS string) error {
+	rebuildMetas, err := meta.NewIndexRebuildMetaModel(r.MetaDB).DetailIndexRebuildMeta(r.Ctx, &meta.IndexRebuildMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableNameS,
+		TaskMode:    r.Cfg.TaskMode,
+	})
+	if err != nil {
+		return err
+	}
+	if len(rebuildMetas) == 0 {
+		return nil
+	}
+	startTime := time.Now()
+	schemaNameT, tableNameT := rebuildMetas[0].SchemaNameT, rebuildMetas[0].TableNameT
+	if err = r.Mysql.CreateMySQLTableRebuildIndex(schemaNameT, tableNameT, genRebuildIndex(rebuildMetas), rebuildIndexCombine); err != nil {
+		return err
+	}
+	if err = meta.NewIndexRebuildMetaModel(r.MetaDB).DeleteIndexRebuildMeta(r.Ctx, &meta.IndexRebuildMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableNameS,
+		TaskMode:    r.Cfg.TaskMode,
+	}); err != nil {
+		return err
+	}
+	zap.L().Info("full single table target index rebuilt after load",
+		zap.String("schema", schemaNameT),
+		zap.String("table", tableNameT),
+		zap.Int("index counts", len(rebuildMetas)),
+		zap.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// rebuildPendingTableIndex 重建上次任务已装载完成但索引重建失败或中断的表，excludeTables 为本次待装载表
+func (r *Migrate) rebuildPendingTableIndex(excludeTables []string) error {
+	pendingTables, err := meta.NewIndexRebuildMetaModel(r.MetaDB).DetailIndexRebuildMetaTables(r.Ctx, &meta.IndexRebuildMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TaskMode:    r.Cfg.TaskMode,
+	})
+	if err != nil {
+		return err
+	}
+	pendingTables = common.FilterDifferenceStringItems(pendingTables, excludeTables)
+	if len(pendingTables) == 0 {
+		return nil
+	}
+	g := &errgroup.Group{}
+	g.SetLimit(r.getRebuildIndexThreads())
+	for _, table := range pendingTables {
+		t := table
+		g.Go(func() error {
+			return r.rebuildTargetTableIndex(t)
+		})
+	}
+	return g.Wait()
+}
+
+func (r *Migrate) getRebuildIndexThreads() int {
+	if r.Cfg.FullConfig.RebuildIndexThreads <= 0 {
+		return common.DefaultRebuildIndexThreads
+	}
+	return r.Cfg.FullConfig.RebuildIndexThreads
+}
+
+func genRebuildIndex(rebuildMetas []meta.IndexRebuildMeta) []mysql.RebuildIndex {
+	var rebuilds []mysql.RebuildIndex
+	for _, m := range rebuildMetas {
+		rebuilds = append(rebuilds, mysql.RebuildIndex{
+			IndexName:  m.IndexName,
+			IndexType:  m.IndexType,
+			Definition: m.Definition,
+		})
+	}
+	return rebuilds
+}
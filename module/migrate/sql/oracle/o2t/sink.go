@@ -0,0 +1,163 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/kafka"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"go.uber.org/zap"
+	"time"
+)
+
+// sink-type kafka 且 full-load = false，以当前 SCN 初始化全量元数据为完成状态，不发布全量数据
+func (r *Migrate) initKafkaWaitSyncMeta(exporters []string) error {
+	globalSCN, err := r.Oracle.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return err
+	}
+	partitionTables, err := r.Oracle.GetOracleSchemaPartitionTable(r.Cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range exporters {
+		isPartition := "NO"
+		if common.IsContainString(partitionTables, common.StringUPPER(t)) {
+			isPartition = "YES"
+		}
+		if err = meta.NewWaitSyncMetaModel(r.MetaDB).DeleteWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:  common.StringUPPER(t),
+			TaskMode:    r.Cfg.TaskMode,
+		}); err != nil {
+			return err
+		}
+		if err = meta.NewWaitSyncMetaModel(r.MetaDB).CreateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:        r.Cfg.DBTypeS,
+			DBTypeT:        r.Cfg.DBTypeT,
+			SchemaNameS:    common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:     common.StringUPPER(t),
+			TaskMode:       r.Cfg.TaskMode,
+			TaskStatus:     common.TaskStatusSuccess,
+			GlobalScnS:     globalSCN,
+			ConsistentRead: "NO",
+			IsPartition:    isPartition,
+		}); err != nil {
+			return err
+		}
+	}
+	zap.L().Warn("kafka sink full-load disabled, increment publish start from current scn",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.Int("tables", len(exporters)),
+		zap.Uint64("global scn", globalSCN))
+	return nil
+}
+
+// 全量数据行按读取批次发布 READ 事件，SCN 为 chunk 全局 SCN
+func (t *Rows) publishData() error {
+	startTime := time.Now()
+
+	keySets, err := public.GetOracleTableKeyColumns(t.Oracle, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS)
+	if err != nil {
+		// 通道关闭
+		close(t.WriteChannel)
+		return err
+	}
+	keyNames := kafka.NewKeyNames(public.GetEventKeyColumns(keySets))
+
+	for dataC := range t.ReadChannel {
+		metrics.FullRowsReadCounter.WithLabelValues(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS).Add(float64(len(dataC)))
+
+		events := make([]kafka.ChangeEvent, 0, len(dataC))
+		for _, dMap := range dataC {
+			after := make(map[string]interface{}, len(t.ColumnNameS))
+			for _, column := range t.ColumnNameS {
+				val, ok := dMap[column]
+				if !ok {
+					// 通道关闭
+					close(t.WriteChannel)
+					return fmt.Errorf("source schema table column counts vs data counts isn't match")
+				}
+				v, err := common.SQLValueToMessageValue(val)
+				if err != nil {
+					// 通道关闭
+					close(t.WriteChannel)
+					return err
+				}
+				after[column] = v
+			}
+			events = append(events, kafka.ChangeEvent{
+				Schema:    t.SyncMeta.SchemaNameS,
+				Table:     t.SyncMeta.TableNameS,
+				Operation: common.ChangeOperationRead,
+				SCN:       t.SyncMeta.GlobalScnS,
+				Timestamp: time.Now().UnixMilli(),
+				KeyNames:  keyNames,
+				After:     after,
+			})
+		}
+
+		err = common.Retry(t.Ctx, t.RetryPolicy, func() error {
+			return t.Kafka.WriteEvents(events)
+		}, func(attempt int, err error) {
+			metrics.RetryCounter.WithLabelValues(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, "publish").Inc()
+			zap.L().Warn("source schema table chunk rows publish retry",
+				zap.String("schema", t.SyncMeta.SchemaNameS),
+				zap.String("table", t.SyncMeta.TableNameS),
+				zap.String("chunk", t.SyncMeta.ChunkDetailS),
+				zap.Int("attempt", attempt),
+				zap.Error(err))
+		})
+		if err != nil {
+			// 通道关闭
+			close(t.WriteChannel)
+			return err
+		}
+		metrics.FullBatchWrittenCounter.WithLabelValues(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS).Inc()
+	}
+
+	// 通道关闭
+	close(t.WriteChannel)
+
+	zap.L().Info("source schema table chunk rows publish finished",
+		zap.String("schema", t.SyncMeta.SchemaNameS),
+		zap.String("table", t.SyncMeta.TableNameS),
+		zap.String("chunk", t.SyncMeta.ChunkDetailS),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// 增量变更事件发布，瞬时错误重试，无事件记录（ddl-mode log）直接推进 checkpoint
+func (p *IncrTask) incrPublish() error {
+	if p.Event == nil {
+		return nil
+	}
+	return common.Retry(p.Ctx, p.RetryPolicy, func() error {
+		return p.Kafka.WriteEvents([]kafka.ChangeEvent{*p.Event})
+	}, func(attempt int, err error) {
+		metrics.RetryCounter.WithLabelValues(p.SourceSchema, p.SourceTable, "incr_publish").Inc()
+		zap.L().Warn("single increment table change event publish retry",
+			zap.String("task", p.String()),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	})
+}
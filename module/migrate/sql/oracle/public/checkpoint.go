@@ -0,0 +1,58 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+// SCNCheckpoint 表级别增量应用 checkpoint
+// 增量记录按捕获顺序注册，并发应用完成后只推进至连续完成记录的最大 SCN，未完成记录之后的 SCN 不会写入元数据，
+// 中断恢复从 checkpoint SCN（>=）重新消费，checkpoint SCN 相同记录重复应用依赖 REPLACE/DELETE 幂等写入，不丢失数据
+// 仅支持单个 goroutine 调用 Done
+type SCNCheckpoint struct {
+	seqs []uint64
+	scns map[uint64]uint64
+	done map[uint64]struct{}
+	next int
+}
+
+func NewSCNCheckpoint(logminers []Logminer) *SCNCheckpoint {
+	c := &SCNCheckpoint{
+		scns: make(map[uint64]uint64, len(logminers)),
+		done: make(map[uint64]struct{}, len(logminers)),
+	}
+	for _, lc := range logminers {
+		c.seqs = append(c.seqs, lc.Seq)
+		c.scns[lc.Seq] = lc.SCN
+	}
+	return c
+}
+
+// Done 标记记录应用完成，返回连续完成记录的最大 SCN 以及 checkpoint 是否推进
+func (c *SCNCheckpoint) Done(seq uint64) (uint64, bool) {
+	c.done[seq] = struct{}{}
+
+	advanced := false
+	for c.next < len(c.seqs) {
+		if _, ok := c.done[c.seqs[c.next]]; !ok {
+			break
+		}
+		delete(c.done, c.seqs[c.next])
+		c.next++
+		advanced = true
+	}
+	if c.next == 0 {
+		return 0, false
+	}
+	return c.scns[c.seqs[c.next-1]], advanced
+}
@@ -0,0 +1,243 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+)
+
+var (
+	ddlTruncateTableRegex = regexp.MustCompile(`(?is)^TRUNCATE\s+TABLE\s+(\S+)`)
+	ddlDropTableRegex     = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(\S+)`)
+	ddlCreateTableRegex   = regexp.MustCompile(`(?is)^CREATE\s+(?:GLOBAL\s+TEMPORARY\s+)?TABLE\s+(\S+?)\s*\(`)
+	ddlAlterTableRegex    = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\S+)\s+(.*)$`)
+	ddlAddColumnRegex     = regexp.MustCompile(`(?is)^ADD\s+(.*)$`)
+	ddlModifyColumnRegex  = regexp.MustCompile(`(?is)^MODIFY\s+(.*)$`)
+	ddlDropColumnRegex    = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+(\S+)|\((.*)\))\s*(?:CASCADE\s+CONSTRAINTS)?\s*$`)
+	ddlRenameColumnRegex  = regexp.MustCompile(`(?is)^RENAME\s+COLUMN\s+(\S+)\s+TO\s+(\S+)\s*$`)
+	ddlCreateIndexRegex   = regexp.MustCompile(`(?is)^CREATE\s+(UNIQUE\s+)?INDEX\s+(\S+)\s+ON\s+(\S+?)\s*\((.*?)\)`)
+	ddlDropIndexRegex     = regexp.MustCompile(`(?is)^DROP\s+INDEX\s+(\S+)`)
+	ddlCommentTableRegex  = regexp.MustCompile(`(?is)^COMMENT\s+ON\s+TABLE\s+(\S+)\s+IS\s+`)
+	ddlCommentColumnRegex = regexp.MustCompile(`(?is)^COMMENT\s+ON\s+COLUMN\s+(\S+)\s+IS\s+`)
+	ddlIdentifierRegex    = regexp.MustCompile(`^[A-Za-z_$#][A-Za-z0-9_$#]*$`)
+)
+
+// Oracle 增量 DDL 解析结果
+type OracleDDL struct {
+	Operation string   // DDL 类型，比如 ADD COLUMN、CREATE INDEX
+	Table     string   // 表名，不含 schema
+	Index     string   // 索引名，不含 schema
+	Unique    bool     // 是否唯一索引
+	Columns   []string // ADD/MODIFY/DROP COLUMN 以及 CREATE INDEX 字段
+	NewColumn string   // RENAME COLUMN 新字段名
+}
+
+// 解析 Oracle DDL，sqlRedo 需已移除引号以及分号
+// 比如：alter table marvin.marvin1 add (c1 number(10,2), c2 varchar2(10))
+// 比如：alter table marvin1 drop (c1, c2)
+// 比如：create unique index marvin.idx_c1 on marvin.marvin1 (c1, c2 desc)
+// 比如：comment on column marvin.marvin1.c1 is 'c1 comment'
+// 不支持得 DDL 返回错误，由调用方记录日志
+func ParseOracleDDL(sqlRedo string) (OracleDDL, error) {
+	var ddl OracleDDL
+	sqlRedo = strings.TrimSpace(sqlRedo)
+
+	switch {
+	case ddlTruncateTableRegex.MatchString(sqlRedo):
+		ddl.Operation = common.MigrateOperationTruncateTable
+		ddl.Table = trimOracleDDLSchema(ddlTruncateTableRegex.FindStringSubmatch(sqlRedo)[1])
+	case ddlDropTableRegex.MatchString(sqlRedo):
+		ddl.Operation = common.MigrateOperationDropTable
+		ddl.Table = trimOracleDDLSchema(ddlDropTableRegex.FindStringSubmatch(sqlRedo)[1])
+	case ddlCreateTableRegex.MatchString(sqlRedo):
+		ddl.Operation = common.MigrateOperationCreateTable
+		ddl.Table = trimOracleDDLSchema(ddlCreateTableRegex.FindStringSubmatch(sqlRedo)[1])
+	case ddlCreateIndexRegex.MatchString(sqlRedo):
+		matches := ddlCreateIndexRegex.FindStringSubmatch(sqlRedo)
+		ddl.Operation = common.MigrateOperationCreateIndex
+		ddl.Unique = strings.TrimSpace(matches[1]) != ""
+		ddl.Index = trimOracleDDLSchema(matches[2])
+		ddl.Table = trimOracleDDLSchema(matches[3])
+		for _, c := range splitOracleDDLItems(matches[4]) {
+			fields := strings.Fields(c)
+			// 函数索引等表达式索引不支持
+			if len(fields) == 0 || len(fields) > 2 || !ddlIdentifierRegex.MatchString(fields[0]) {
+				return ddl, fmt.Errorf("oracle ddl [%s] index column [%s] isn't support", sqlRedo, c)
+			}
+			if len(fields) == 2 && !common.IsContainString([]string{"ASC", "DESC"}, common.StringUPPER(fields[1])) {
+				return ddl, fmt.Errorf("oracle ddl [%s] index column [%s] isn't support", sqlRedo, c)
+			}
+			ddl.Columns = append(ddl.Columns, strings.Join(fields, " "))
+		}
+	case ddlDropIndexRegex.MatchString(sqlRedo):
+		ddl.Operation = common.MigrateOperationDropIndex
+		ddl.Index = trimOracleDDLSchema(ddlDropIndexRegex.FindStringSubmatch(sqlRedo)[1])
+	case ddlCommentTableRegex.MatchString(sqlRedo):
+		ddl.Operation = common.MigrateOperationCommentTable
+		ddl.Table = trimOracleDDLSchema(ddlCommentTableRegex.FindStringSubmatch(sqlRedo)[1])
+	case ddlCommentColumnRegex.MatchString(sqlRedo):
+		// [schema.]table.column
+		names := strings.Split(ddlCommentColumnRegex.FindStringSubmatch(sqlRedo)[1], ".")
+		if len(names) < 2 {
+			return ddl, fmt.Errorf("oracle ddl [%s] comment column isn't support", sqlRedo)
+		}
+		ddl.Operation = common.MigrateOperationCommentColumn
+		ddl.Table = names[len(names)-2]
+		ddl.Columns = []string{names[len(names)-1]}
+	case ddlAlterTableRegex.MatchString(sqlRedo):
+		matches := ddlAlterTableRegex.FindStringSubmatch(sqlRedo)
+		ddl.Table = trimOracleDDLSchema(matches[1])
+		action := strings.TrimSpace(matches[2])
+
+		switch {
+		case ddlRenameColumnRegex.MatchString(action):
+			m := ddlRenameColumnRegex.FindStringSubmatch(action)
+			ddl.Operation = common.MigrateOperationRenameColumn
+			ddl.Columns = []string{m[1]}
+			ddl.NewColumn = m[2]
+		case ddlDropColumnRegex.MatchString(action):
+			m := ddlDropColumnRegex.FindStringSubmatch(action)
+			ddl.Operation = common.MigrateOperationDropColumn
+			if m[1] != "" {
+				ddl.Columns = []string{m[1]}
+			} else {
+				ddl.Columns = splitOracleDDLItems(m[2])
+			}
+		case ddlAddColumnRegex.MatchString(action):
+			ddl.Operation = common.MigrateOperationAddColumn
+			columns, err := extractOracleDDLColumns(ddlAddColumnRegex.FindStringSubmatch(action)[1])
+			if err != nil {
+				return ddl, fmt.Errorf("oracle ddl [%s] isn't support: %v", sqlRedo, err)
+			}
+			ddl.Columns = columns
+		case ddlModifyColumnRegex.MatchString(action):
+			ddl.Operation = common.MigrateOperationModifyColumn
+			columns, err := extractOracleDDLColumns(ddlModifyColumnRegex.FindStringSubmatch(action)[1])
+			if err != nil {
+				return ddl, fmt.Errorf("oracle ddl [%s] isn't support: %v", sqlRedo, err)
+			}
+			ddl.Columns = columns
+		default:
+			return ddl, fmt.Errorf("oracle ddl [%s] alter action isn't support", sqlRedo)
+		}
+	default:
+		return ddl, fmt.Errorf("oracle ddl [%s] isn't support", sqlRedo)
+	}
+	return ddl, nil
+}
+
+// 增量 DDL 路由至所属表
+// CREATE INDEX 按 DDL 解析所属表，DROP INDEX 源端索引已删除，按下游目标端索引所属表反查
+func RouteOracleIncrDDL(mysqlDB *mysql.MySQL, targetSchema string, lcs []Logminer, tableNameRule map[string]string) []Logminer {
+	targetTableRule := make(map[string]string)
+	for sourceTable, targetTable := range tableNameRule {
+		targetTableRule[common.StringUPPER(targetTable)] = sourceTable
+	}
+
+	for i, lc := range lcs {
+		if lc.Operation != common.MigrateOperationDDL {
+			continue
+		}
+		ddl, err := ParseOracleDDL(common.ReplaceSpecifiedString(common.ReplaceQuotesString(lc.SQLRedo), ";", ""))
+		if err != nil {
+			continue
+		}
+		switch ddl.Operation {
+		case common.MigrateOperationCreateIndex:
+			lcs[i].SourceTable = common.StringUPPER(ddl.Table)
+		case common.MigrateOperationDropIndex:
+			tables, err := mysqlDB.GetMySQLIndexTableName(targetSchema, ddl.Index)
+			if err != nil || len(tables) != 1 {
+				zap.L().Warn("oracle drop index ddl route table failed, ddl will be skipped",
+					zap.String("ddl", lc.SQLRedo),
+					zap.Strings("target index tables", tables),
+					zap.Error(err))
+				continue
+			}
+			if sourceTable, ok := targetTableRule[common.StringUPPER(tables[0])]; ok {
+				lcs[i].SourceTable = sourceTable
+			}
+		default:
+			continue
+		}
+		lcs[i].TargetTable = tableNameRule[common.StringUPPER(lcs[i].SourceTable)]
+	}
+	return lcs
+}
+
+// 移除 schema 前缀
+func trimOracleDDLSchema(name string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// 提取 ADD/MODIFY 字段名，约束变更不支持
+// 比如：(c1 number(10,2) default 0, c2 varchar2(10)) 或者 c1 number
+func extractOracleDDLColumns(action string) ([]string, error) {
+	action = strings.TrimSpace(action)
+	if strings.HasPrefix(action, "(") && strings.HasSuffix(action, ")") {
+		action = action[1 : len(action)-1]
+	}
+	var columns []string
+	for _, item := range splitOracleDDLItems(action) {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		if common.IsContainString([]string{"CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "SUPPLEMENTAL", "PARTITION"}, common.StringUPPER(fields[0])) ||
+			!ddlIdentifierRegex.MatchString(fields[0]) {
+			return columns, fmt.Errorf("column item [%s] isn't support", item)
+		}
+		columns = append(columns, fields[0])
+	}
+	if len(columns) == 0 {
+		return columns, fmt.Errorf("column items [%s] is null", action)
+	}
+	return columns, nil
+}
+
+// 按顶层逗号切分，忽略括号内逗号，比如 number(10,2)
+func splitOracleDDLItems(s string) []string {
+	var (
+		items []string
+		depth int
+		start int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
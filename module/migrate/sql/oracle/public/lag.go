@@ -0,0 +1,94 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
+	"go.uber.org/zap"
+)
+
+// IncrLag 增量同步延迟，LagSeconds 基于 SCN_TO_TIMESTAMP 映射计算，-1 代表已应用 SCN 超出映射保留范围无法计算
+type IncrLag struct {
+	AppliedSCN uint64
+	CurrentSCN uint64
+	LagSeconds int64
+}
+
+// 未同步 SCN 区间长度
+func (l IncrLag) UnreplicatedSCN() uint64 {
+	if l.CurrentSCN > l.AppliedSCN {
+		return l.CurrentSCN - l.AppliedSCN
+	}
+	return 0
+}
+
+// GetIncrLag 获取 schema 增量同步延迟，已应用 SCN 取 incr_sync_meta 表级别最小 SCN
+func GetIncrLag(ctx context.Context, metaDB *meta.Meta, oracle *oracle.Oracle, dbTypeS, dbTypeT, sourceSchema string) (IncrLag, error) {
+	var lag IncrLag
+
+	appliedSCN, err := meta.NewIncrSyncMetaModel(metaDB).GetIncrSyncMetaMinTableScnSBySchema(ctx, &meta.IncrSyncMeta{
+		DBTypeS:     dbTypeS,
+		DBTypeT:     dbTypeT,
+		SchemaNameS: common.StringUPPER(sourceSchema)})
+	if err != nil {
+		return lag, err
+	}
+	lag.AppliedSCN = appliedSCN
+
+	currentSCN, err := oracle.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return lag, err
+	}
+	lag.CurrentSCN = currentSCN
+
+	lagSeconds, err := oracle.GetOracleSCNLagSeconds(appliedSCN)
+	if err != nil {
+		zap.L().Warn("get oracle applied scn lag seconds failed, scn maybe out of scn_to_timestamp range",
+			zap.String("schema", sourceSchema),
+			zap.Uint64("applied scn", appliedSCN),
+			zap.Error(err))
+		lagSeconds = -1
+	}
+	lag.LagSeconds = lagSeconds
+	return lag, nil
+}
+
+// ReportIncrLag 输出增量同步延迟日志以及指标，获取失败只记录日志不影响同步
+func ReportIncrLag(ctx context.Context, metaDB *meta.Meta, oracle *oracle.Oracle, dbTypeS, dbTypeT, sourceSchema string) {
+	lag, err := GetIncrLag(ctx, metaDB, oracle, dbTypeS, dbTypeT, sourceSchema)
+	if err != nil {
+		zap.L().Warn("get increment replication lag failed",
+			zap.String("schema", sourceSchema),
+			zap.Error(err))
+		return
+	}
+	schema := common.StringUPPER(sourceSchema)
+	metrics.IncrAppliedSCNGauge.WithLabelValues(schema).Set(float64(lag.AppliedSCN))
+	metrics.IncrSourceSCNGauge.WithLabelValues(schema).Set(float64(lag.CurrentSCN))
+	metrics.IncrUnreplicatedSCNGauge.WithLabelValues(schema).Set(float64(lag.UnreplicatedSCN()))
+	metrics.IncrLagSecondsGauge.WithLabelValues(schema).Set(float64(lag.LagSeconds))
+
+	zap.L().Info("increment replication lag",
+		zap.String("schema", schema),
+		zap.Uint64("applied scn", lag.AppliedSCN),
+		zap.Uint64("source current scn", lag.CurrentSCN),
+		zap.Uint64("unreplicated scn", lag.UnreplicatedSCN()),
+		zap.Int64("seconds behind source", lag.LagSeconds))
+}
@@ -23,16 +23,19 @@ import (
 	"github.com/wentaojin/transferdb/database/oracle"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"sort"
 	"strings"
 	"time"
 )
 
 // 获取 Oracle Logminer 日志内容并过滤筛选已提交的 INSERT/DELETE/UPDATE 事务语句
-// 考虑异构数据库，只同步 INSERT/DELETE/UPDATE 事务语句以及 TRUNCATE TABLE/DROP TABLE DDL 语句，其他类型 SQL 不同步
+// 考虑异构数据库，只同步 INSERT/DELETE/UPDATE 事务语句以及 ddl-mode 支持得 DDL 语句，其他类型 SQL 不同步
+// 索引 DDL 对象名为索引名，按 SQL_REDO 额外捕获，由 RouteOracleIncrDDL 路由至所属表
 // V$LOGMNR_CONTENTS 字段解释参考链接
 // https://docs.oracle.com/en/database/oracle/oracle-database/21/refrn/V-LOGMNR_CONTENTS.html#GUID-B9196942-07BF-4935-B603-FA875064F5C3
 type Logminer struct {
 	SCN          uint64
+	Seq          uint64 // 捕获顺序号，按 SCN 排序捕获，筛选并发处理后用于恢复表内顺序
 	SourceSchema string
 	SourceTable  string
 	TargetSchema string
@@ -40,25 +43,68 @@ type Logminer struct {
 	SQLRedo      string
 	SQLUndo      string
 	Operation    string
+	XID          string
+	RowID        string
+	Rollback     int // 1 代表回滚至保存点生成的补偿变更
+
+	// 事务部分回滚，原始变更与补偿变更均不应用
+	rolledBack bool
+
+	// 大事务落盘位置，SQL_REDO/SQL_UNDO 落盘后内存为空，LoadSpill 读取
+	spill       *TxnSpill
+	spillOffset int64
+	redoLen     int
+	undoLen     int
 }
 
-// 捕获增量数据
-func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, sourceSchema, targetSchema string, sourceTable string, tableNameRule map[string]string, lastCheckpoint string, queryTimeout int) ([]Logminer, error) {
-	var lcs []Logminer
+// IncrSchemaFilter logminer 捕获 schema 过滤条件，多 schema 增量路由共用一次日志挖掘
+type IncrSchemaFilter struct {
+	SourceSchema  string
+	TargetSchema  string
+	SourceTables  []string
+	TableNameRule map[string]string
+}
+
+// 捕获增量数据，单事务超过 spill 阈值的行落盘
+// 多 schema 增量路由单次查询 V$LOGMNR_CONTENTS 捕获全部 schema 数据，由调用方按 SourceSchema 拆分
+// logminer COMMITTED_DATA_ONLY 已过滤整体回滚事务，已提交事务内回滚至保存点的变更以 ROLLBACK = 1 补偿变更输出
+// 补偿变更按事务 XID、表以及 ROW_ID 抵消最近一次原始变更，两者均不应用；原始变更不在本次捕获范围（已应用）时补偿变更按普通 DML 应用
+func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, schemaFilters []IncrSchemaFilter, lastCheckpoint string, queryTimeout int, spill *TxnSpill) ([]Logminer, error) {
+	var (
+		lcs           []Logminer
+		spillCount    int
+		rollbackCount int
+		sourceSchemas []string
+		schemaConds   []string
+	)
+	// 事务内各行原始变更捕获位置
+	txnRowChanges := make(map[string][]int)
+
+	schemaFilterMap := make(map[string]IncrSchemaFilter)
+	for _, f := range schemaFilters {
+		sourceSchema := common.StringUPPER(f.SourceSchema)
+		sourceSchemas = append(sourceSchemas, sourceSchema)
+		schemaFilterMap[sourceSchema] = f
+		schemaConds = append(schemaConds, common.StringsBuilder(`(UPPER(SEG_OWNER) = '`, sourceSchema, `'
+        AND (UPPER(TABLE_NAME) IN (`, common.StringArrayToCapitalChar(f.SourceTables), `) OR (OPERATION = 'DDL' AND UPPER(SQL_REDO) LIKE '%INDEX%')))`))
+	}
 
 	c, cancel := context.WithTimeout(ctx, time.Duration(queryTimeout)*time.Second)
 	defer cancel()
 
 	querySQL := common.StringsBuilder(`SELECT SCN,
        SEG_OWNER AS SOURCE_SCHEMA,
-       TABLE_NAME AS SOURCE_TABLE,
+       NVL(TABLE_NAME, ' ') AS SOURCE_TABLE,
        SQL_REDO,
        SQL_UNDO,
-       OPERATION
+       OPERATION,
+       RAWTOHEX(XID) AS XID,
+       NVL(ROW_ID, ' ') AS ROW_ID,
+       ROLLBACK
   FROM V$LOGMNR_CONTENTS
  WHERE 1 = 1
-   AND UPPER(SEG_OWNER) = '`, common.StringUPPER(sourceSchema), `'
-   AND UPPER(TABLE_NAME) IN (`, sourceTable, `)
+   AND (`, strings.Join(schemaConds, `
+     OR `), `)
    AND OPERATION IN ('INSERT', 'DELETE', 'UPDATE', 'DDL')
    AND SCN >= `, lastCheckpoint, ` ORDER BY SCN`)
 
@@ -72,16 +118,72 @@ func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, sourceSchem
 
 	for rows.Next() {
 		var lc Logminer
-		if err = rows.Scan(&lc.SCN, &lc.SourceSchema, &lc.SourceTable, &lc.SQLRedo, &lc.SQLUndo, &lc.Operation); err != nil {
+		if err = rows.Scan(&lc.SCN, &lc.SourceSchema, &lc.SourceTable, &lc.SQLRedo, &lc.SQLUndo, &lc.Operation, &lc.XID, &lc.RowID, &lc.Rollback); err != nil {
 			return lcs, err
 		}
 
+		rowKey := common.StringsBuilder(lc.XID, ".", lc.SourceSchema, ".", lc.SourceTable, ".", lc.RowID)
+		if lc.Rollback == 1 && lc.Operation != common.MigrateOperationDDL {
+			if idx := txnRowChanges[rowKey]; len(idx) > 0 {
+				lcs[idx[len(idx)-1]].rolledBack = true
+				txnRowChanges[rowKey] = idx[:len(idx)-1]
+				rollbackCount++
+				continue
+			}
+		}
+		if err = spill.Add(&lc); err != nil {
+			return lcs, err
+		}
+		if lc.spill != nil {
+			spillCount++
+		}
+
 		// 目标库名以及表名
-		lc.TargetSchema = targetSchema
-		lc.TargetTable = tableNameRule[common.StringUPPER(lc.SourceTable)]
+		schemaFilter := schemaFilterMap[common.StringUPPER(lc.SourceSchema)]
+		lc.TargetSchema = common.StringUPPER(schemaFilter.TargetSchema)
+		lc.TargetTable = schemaFilter.TableNameRule[common.StringUPPER(lc.SourceTable)]
+		lc.Seq = uint64(len(lcs))
+		if lc.Rollback == 0 && lc.Operation != common.MigrateOperationDDL {
+			txnRowChanges[rowKey] = append(txnRowChanges[rowKey], len(lcs))
+		}
 		lcs = append(lcs, lc)
 	}
 	endTime := time.Now()
+	if err = rows.Err(); err != nil {
+		return lcs, err
+	}
+	if err = spill.Flush(); err != nil {
+		return lcs, err
+	}
+
+	// 移除回滚至保存点的原始变更，重新编排捕获顺序号
+	if rollbackCount > 0 {
+		n := 0
+		for _, lc := range lcs {
+			if lc.rolledBack {
+				continue
+			}
+			lc.Seq = uint64(n)
+			lcs[n] = lc
+			n++
+		}
+		lcs = lcs[:n]
+		zap.L().Warn("logminer savepoint rollback record discarded",
+			zap.Strings("oracle schema", sourceSchemas),
+			zap.Int("rollback row counts", rollbackCount))
+	}
+
+	// 存在大事务落盘时不输出全部日志内容，避免日志内容占用大量内存
+	if spillCount > 0 {
+		zap.L().Info("logminer sql",
+			zap.String("sql", querySQL),
+			zap.Int("row counts", len(lcs)),
+			zap.Int("spill row counts", spillCount),
+			zap.String("start time", startTime.String()),
+			zap.String("end time", endTime.String()),
+			zap.String("cost time", endTime.Sub(startTime).String()))
+		return lcs, nil
+	}
 
 	jsonLCS, err := json.Marshal(lcs)
 	if err != nil {
@@ -101,7 +203,7 @@ func FilterOracleIncrRecord(
 	lognimers []Logminer,
 	syncSourceTables []string,
 	exporterTableSourceSCN map[string]uint64,
-	workerThreads, currentResetFlag int) (map[string][]Logminer, error) {
+	workerThreads, currentResetFlag int, ddlMode string) (map[string][]Logminer, error) {
 	var (
 		lcMap map[string][]Logminer
 		lc    []Logminer
@@ -129,20 +231,12 @@ func FilterOracleIncrRecord(
 		rows := rs
 		g.Go(func() error {
 			// 筛选过滤 Oracle Redo SQL
-			// 1、数据同步只同步 INSERT/DELETE/UPDATE DML以及只同步 truncate table/ drop table 限定 DDL
+			// 1、数据同步只同步 INSERT/DELETE/UPDATE DML以及 ddl-mode 限定 DDL
 			// 2、根据元数据表 incr_synce_meta 对应表已经同步写入得 SCN SQL 记录,过滤 Oracle 提交记录 SCN 号，过滤,防止重复写入
 			if currentResetFlag == 0 {
 				if rows.SCN >= sourceTableSCNMAP[strings.ToUpper(rows.SourceTable)] {
 					if rows.Operation == common.MigrateOperationDDL {
-						splitDDL := strings.Split(rows.SQLRedo, ` `)
-						ddl := common.StringsBuilder(splitDDL[0], ` `, splitDDL[1])
-						if strings.ToUpper(ddl) == common.MigrateOperationDropTable {
-							// 处理 drop table marvin8 AS "BIN$vVWfliIh6WfgU0EEEKzOvg==$0"
-							rows.SQLRedo = strings.Split(strings.ToUpper(rows.SQLRedo), "AS")[0]
-							s.AddData(rows)
-						}
-						if strings.ToUpper(ddl) == common.MigrateOperationTruncateTable {
-							// 处理 truncate table marvin8
+						if filterOracleIncrDDL(&rows, ddlMode) {
 							s.AddData(rows)
 						}
 					} else {
@@ -152,17 +246,9 @@ func FilterOracleIncrRecord(
 				return nil
 
 			} else if currentResetFlag == 1 {
-				if rows.SCN > sourceTableSCNMAP[strings.ToUpper(rows.SQLRedo)] {
+				if rows.SCN > sourceTableSCNMAP[strings.ToUpper(rows.SourceTable)] {
 					if rows.Operation == common.MigrateOperationDDL {
-						splitDDL := strings.Split(rows.SQLRedo, ` `)
-						ddl := common.StringsBuilder(splitDDL[0], ` `, splitDDL[1])
-						if strings.ToUpper(ddl) == common.MigrateOperationDropTable {
-							// 处理 drop table marvin8 AS "BIN$vVWfliIh6WfgU0EEEKzOvg==$0"
-							rows.SQLRedo = strings.Split(strings.ToUpper(rows.SQLRedo), "AS")[0]
-							s.AddData(rows)
-						}
-						if strings.ToUpper(ddl) == common.MigrateOperationTruncateTable {
-							// 处理 truncate table marvin8
+						if filterOracleIncrDDL(&rows, ddlMode) {
 							s.AddData(rows)
 						}
 					} else {
@@ -182,6 +268,13 @@ func FilterOracleIncrRecord(
 	s.Close()
 	<-c
 
+	// 并发筛选乱序，按捕获顺序恢复表内增量记录顺序，保证按序应用以及 checkpoint 推进
+	for table := range lcMap {
+		sort.SliceStable(lcMap[table], func(i, j int) bool {
+			return lcMap[table][i].Seq < lcMap[table][j].Seq
+		})
+	}
+
 	endTime := time.Now()
 	zap.L().Info("oracle table filter finished",
 		zap.String("status", "success"),
@@ -191,3 +284,24 @@ func FilterOracleIncrRecord(
 
 	return lcMap, nil
 }
+
+// 筛选 DDL，ddl-mode skip 忽略全部 DDL，不支持解析得 DDL 记录日志后忽略
+func filterOracleIncrDDL(rows *Logminer, ddlMode string) bool {
+	if strings.EqualFold(ddlMode, common.DDLModeSkip) {
+		return false
+	}
+	ddl, err := ParseOracleDDL(common.ReplaceSpecifiedString(common.ReplaceQuotesString(rows.SQLRedo), ";", ""))
+	if err != nil {
+		zap.L().Warn("oracle ddl isn't support, ddl will be skipped",
+			zap.String("oracle schema", rows.SourceSchema),
+			zap.String("oracle table", rows.SourceTable),
+			zap.Uint64("scn", rows.SCN),
+			zap.Error(err))
+		return false
+	}
+	if ddl.Operation == common.MigrateOperationDropTable {
+		// 处理 drop table marvin8 AS "BIN$vVWfliIh6WfgU0EEEKzOvg==$0"
+		rows.SQLRedo = strings.Split(strings.ToUpper(rows.SQLRedo), "AS")[0]
+	}
+	return true
+}
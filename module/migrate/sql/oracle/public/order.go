@@ -0,0 +1,154 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"go.uber.org/zap"
+	"sort"
+)
+
+// OrderTables 全量表迁移顺序
+// 1、按 table-order 排序：name 表名字母序（默认）、largest-first/smallest-first 按表段大小、dependency 按外键依赖父表优先
+// 2、按表级别 [[schema-config.migrate-config]] priority 降序稳定排序，数值越大越优先
+// 3、pinned-tables 固定置顶，按配置顺序
+func OrderTables(cfg *config.Config, oracle *oracle.Oracle, tables []string) ([]string, error) {
+	if len(tables) <= 1 {
+		return tables, nil
+	}
+	ordered := make([]string, len(tables))
+	copy(ordered, tables)
+	sort.Strings(ordered)
+
+	tableOrder := common.StringUPPER(cfg.FullConfig.TableOrder)
+	if tableOrder == "" {
+		tableOrder = common.TableOrderName
+	}
+	switch tableOrder {
+	case common.TableOrderName:
+	case common.TableOrderLargestFirst, common.TableOrderSmallestFirst:
+		tableBytes, err := oracle.GetOracleSchemaTableSegmentBytes(cfg.SchemaConfig.SourceSchema)
+		if err != nil {
+			return tables, err
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			if tableOrder == common.TableOrderLargestFirst {
+				return tableBytes[common.StringUPPER(ordered[i])] > tableBytes[common.StringUPPER(ordered[j])]
+			}
+			return tableBytes[common.StringUPPER(ordered[i])] < tableBytes[common.StringUPPER(ordered[j])]
+		})
+	case common.TableOrderDependency:
+		dependency, err := oracle.GetOracleSchemaTableDependency(cfg.SchemaConfig.SourceSchema)
+		if err != nil {
+			return tables, err
+		}
+		ordered = orderTablesByDependency(ordered, dependency)
+	default:
+		return tables, fmt.Errorf("full config table-order [%v] isn't support, support table-order [%v]", cfg.FullConfig.TableOrder, common.MigrateTableOrders)
+	}
+
+	// 表级别优先级
+	priority := make(map[string]int)
+	for _, m := range cfg.SchemaConfig.MigrateConfig {
+		priority[common.StringUPPER(m.SourceTable)] = m.Priority
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return priority[common.StringUPPER(ordered[i])] > priority[common.StringUPPER(ordered[j])]
+	})
+
+	// 固定置顶表
+	if len(cfg.FullConfig.PinnedTables) > 0 {
+		pinned := make(map[string]int)
+		for i, t := range cfg.FullConfig.PinnedTables {
+			if _, ok := pinned[common.StringUPPER(t)]; !ok {
+				pinned[common.StringUPPER(t)] = i
+			}
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			pi, oki := pinned[common.StringUPPER(ordered[i])]
+			pj, okj := pinned[common.StringUPPER(ordered[j])]
+			switch {
+			case oki && okj:
+				return pi < pj
+			default:
+				return oki && !okj
+			}
+		})
+	}
+
+	zap.L().Info("full table migrate order",
+		zap.String("schema", cfg.SchemaConfig.SourceSchema),
+		zap.String("table order", tableOrder),
+		zap.Strings("pinned tables", cfg.FullConfig.PinnedTables),
+		zap.Strings("tables", ordered))
+	return ordered, nil
+}
+
+// orderTablesByDependency 外键依赖拓扑排序，父表优先，同层级保持原顺序，存在循环依赖的表按原顺序追加末尾
+func orderTablesByDependency(tables []string, dependency map[string][]string) []string {
+	tableSet := make(map[string]struct{}, len(tables))
+	for _, t := range tables {
+		tableSet[common.StringUPPER(t)] = struct{}{}
+	}
+	// 仅统计待迁移表之间的依赖
+	inDegree := make(map[string]int, len(tables))
+	children := make(map[string][]string)
+	for _, t := range tables {
+		child := common.StringUPPER(t)
+		for _, parent := range dependency[child] {
+			if _, ok := tableSet[parent]; ok {
+				inDegree[child]++
+				children[parent] = append(children[parent], child)
+			}
+		}
+	}
+
+	var (
+		ordered []string
+		visited = make(map[string]bool, len(tables))
+	)
+	for len(ordered) < len(tables) {
+		progress := false
+		for _, t := range tables {
+			u := common.StringUPPER(t)
+			if visited[u] || inDegree[u] > 0 {
+				continue
+			}
+			visited[u] = true
+			ordered = append(ordered, t)
+			for _, c := range children[u] {
+				inDegree[c]--
+			}
+			progress = true
+		}
+		if !progress {
+			var cycles []string
+			for _, t := range tables {
+				if !visited[common.StringUPPER(t)] {
+					cycles = append(cycles, t)
+				}
+			}
+			zap.L().Warn("full table migrate order exist circular foreign key dependency, append by name",
+				zap.Strings("tables", cycles))
+			ordered = append(ordered, cycles...)
+			break
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// 获取增量并行应用行级路由键字段集合，主键在前，其后为全部唯一键，均不存在返回空
+// 主键/唯一键任一字段值相同的记录需路由至同一 worker，否则并行应用可能违反唯一约束
+func GetOracleTableKeyColumns(oracle *oracle.Oracle, schemaName, tableName string) ([][]string, error) {
+	var keySets [][]string
+
+	pk, err := oracle.GetOracleSchemaTablePrimaryKey(schemaName, tableName)
+	if err != nil {
+		return keySets, fmt.Errorf("get oracle schema [%s] table [%s] primary key failed: %v", schemaName, tableName, err)
+	}
+	uk, err := oracle.GetOracleSchemaTableUniqueKey(schemaName, tableName)
+	if err != nil {
+		return keySets, fmt.Errorf("get oracle schema [%s] table [%s] unique key failed: %v", schemaName, tableName, err)
+	}
+	for _, k := range append(pk, uk...) {
+		var keyColumns []string
+		for _, c := range strings.Split(k["COLUMN_LIST"], ",") {
+			keyColumns = append(keyColumns, common.StringsBuilder("`", common.StringUPPER(c), "`"))
+		}
+		keySets = append(keySets, keyColumns)
+	}
+	return keySets, nil
+}
+
+// 变更事件键字段，优先主键，无主键取第一个唯一键，均不存在返回空
+func GetEventKeyColumns(keySets [][]string) []string {
+	if len(keySets) == 0 {
+		return nil
+	}
+	return keySets[0]
+}
+
+// 生成增量记录行级路由键，每个主键/唯一键字段值各生成一个路由键，UPDATE 同时包含修改前后字段值
+// barrier 为 true 代表需屏障串行应用：DDL 以及无法解析路由键
+// 表不存在主键/唯一键返回空路由键，全表记录路由至同一 worker
+func GenIncrRowKey(operationType, sqlRedo, sqlUndo string, keySets [][]string) ([]string, bool) {
+	switch operationType {
+	case common.MigrateOperationInsert, common.MigrateOperationUpdate, common.MigrateOperationDelete:
+	default:
+		return nil, true
+	}
+	if len(keySets) == 0 {
+		return nil, false
+	}
+
+	astNode, err := ParseSQL(sqlRedo)
+	if err != nil {
+		return nil, true
+	}
+	stmt := ExtractStmt(astNode)
+
+	images := []map[string]interface{}{stmt.Before}
+	switch operationType {
+	case common.MigrateOperationInsert:
+		images = []map[string]interface{}{stmt.Data}
+	case common.MigrateOperationUpdate:
+		astUndoNode, err := ParseSQL(sqlUndo)
+		if err != nil {
+			return nil, true
+		}
+		// undo WHERE 条件为修改后字段值
+		images = append(images, ExtractStmt(astUndoNode).Before)
+	}
+
+	var rowKeys []string
+	for _, data := range images {
+		for i, keyColumns := range keySets {
+			rowKey, barrier := genIncrKeyValues(data, keyColumns)
+			if barrier {
+				return nil, true
+			}
+			rowKeys = append(rowKeys, common.StringsBuilder(strconv.Itoa(i), "\x00", rowKey))
+		}
+	}
+	return rowKeys, false
+}
+
+// 路由键对应 worker 序号，全部路由键对应同一 worker 返回 true，否则需屏障串行应用
+func IncrRowKeyWorker(rowKeys []string, workers int) (int, bool) {
+	if workers <= 1 || len(rowKeys) == 0 {
+		return 0, true
+	}
+	worker := -1
+	for _, rowKey := range rowKeys {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(rowKey))
+		w := int(h.Sum32() % uint32(workers))
+		if worker >= 0 && w != worker {
+			return 0, false
+		}
+		worker = w
+	}
+	return worker, true
+}
+
+// 拼接路由键字段值，字段值不存在（比如 IS NULL）返回 barrier
+func genIncrKeyValues(data map[string]interface{}, keyColumns []string) (string, bool) {
+	var values []string
+	for _, c := range keyColumns {
+		v, ok := data[c]
+		if !ok {
+			return "", true
+		}
+		values = append(values, fmt.Sprintf("%v", v))
+	}
+	return strings.Join(values, "\x00"), false
+}
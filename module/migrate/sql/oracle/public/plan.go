@@ -0,0 +1,155 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"go.uber.org/zap"
+	"time"
+)
+
+// GenMigratePlan 全量迁移前基于 DBA_TABLES 统计信息以及 DBA_SEGMENTS 表段大小生成预估计划
+// 预估耗时 = 预估行数 / 整体写入速率（plan-rows-per-second，配置 apply-rows-per-second 限速时取两者较小值）
+// 目标端存储 = 源端表段大小 * 目标端存储放大系数
+func GenMigratePlan(cfg *config.Config, oracle *oracle.Oracle, tables []string, tableNameRule map[string]string) ([]meta.MigratePlan, error) {
+	var plans []meta.MigratePlan
+	if len(tables) == 0 {
+		return plans, nil
+	}
+	tableRows, err := oracle.GetOracleSchemaTableRowsByStatistics(cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		return plans, err
+	}
+	tableBytes, err := oracle.GetOracleSchemaTableSegmentBytes(cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		return plans, err
+	}
+
+	chunkSize := cfg.FullConfig.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 1
+	}
+	rowsPerSecond := cfg.FullConfig.PlanRowsPerSecond
+	if rowsPerSecond <= 0 {
+		rowsPerSecond = common.MigratePlanDefaultRowsPerSecond
+	}
+	if cfg.AppConfig.ApplyRowsPerSecond > 0 && cfg.AppConfig.ApplyRowsPerSecond < rowsPerSecond {
+		rowsPerSecond = cfg.AppConfig.ApplyRowsPerSecond
+	}
+	storageRatio, ok := common.MigratePlanTargetStorageRatio[common.StringUPPER(cfg.DBTypeT)]
+	if !ok {
+		storageRatio = 1
+	}
+
+	for _, t := range tables {
+		targetTable := common.StringUPPER(t)
+		if val, ok := tableNameRule[common.StringUPPER(t)]; ok {
+			targetTable = val
+		}
+		rows := tableRows[common.StringUPPER(t)]
+		chunks := 1
+		if rows > int64(chunkSize) {
+			chunks = int((rows + int64(chunkSize) - 1) / int64(chunkSize))
+		}
+		plans = append(plans, meta.MigratePlan{
+			DBTypeS:           cfg.DBTypeS,
+			DBTypeT:           cfg.DBTypeT,
+			SchemaNameS:       common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+			TableNameS:        common.StringUPPER(t),
+			SchemaNameT:       common.StringUPPER(cfg.SchemaConfig.TargetSchema),
+			TableNameT:        targetTable,
+			TaskMode:          cfg.TaskMode,
+			EstimatedRows:     rows,
+			SourceBytes:       tableBytes[common.StringUPPER(t)],
+			ChunkSize:         chunkSize,
+			EstimatedChunks:   chunks,
+			TargetBytes:       int64(float64(tableBytes[common.StringUPPER(t)]) * storageRatio),
+			EstimatedDuration: float64(rows) / float64(rowsPerSecond),
+		})
+	}
+	return plans, nil
+}
+
+// SaveMigratePlan 预估计划写入元数据库 [migrate_plan]，覆盖同任务模式历史计划
+func SaveMigratePlan(ctx context.Context, metaDB *meta.Meta, cfg *config.Config, plans []meta.MigratePlan) error {
+	err := meta.NewMigratePlanModel(metaDB).DeleteMigratePlanBySchemaTaskMode(ctx, &meta.MigratePlan{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+		TaskMode:    cfg.TaskMode,
+	})
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		return nil
+	}
+	return meta.NewMigratePlanModel(metaDB).BatchCreateMigratePlan(ctx, plans, cfg.AppConfig.InsertBatchSize)
+}
+
+// PrintMigratePlan 输出预估计划报告
+func PrintMigratePlan(cfg *config.Config, plans []meta.MigratePlan) {
+	var (
+		totalRows, totalSourceBytes, totalTargetBytes int64
+		totalChunks                                   int
+		totalDuration                                 float64
+	)
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"SCHEMA", "TABLE NAME", "TARGET TABLE", "ESTIMATED ROWS", "SOURCE SIZE", "ESTIMATED CHUNKS", "TARGET SIZE", "ESTIMATED DURATION"})
+	for _, p := range plans {
+		totalRows += p.EstimatedRows
+		totalSourceBytes += p.SourceBytes
+		totalTargetBytes += p.TargetBytes
+		totalChunks += p.EstimatedChunks
+		totalDuration += p.EstimatedDuration
+		t.AppendRow(table.Row{p.SchemaNameS, p.TableNameS, p.TableNameT, p.EstimatedRows, formatPlanBytes(p.SourceBytes),
+			p.EstimatedChunks, formatPlanBytes(p.TargetBytes), formatPlanDuration(p.EstimatedDuration)})
+	}
+	t.AppendFooter(table.Row{"SUMMARY", fmt.Sprintf("%d", len(plans)), "", totalRows, formatPlanBytes(totalSourceBytes),
+		totalChunks, formatPlanBytes(totalTargetBytes), formatPlanDuration(totalDuration)})
+	fmt.Println(t.Render())
+
+	zap.L().Info("full migrate plan",
+		zap.String("schema", cfg.SchemaConfig.SourceSchema),
+		zap.Int("table totals", len(plans)),
+		zap.Int64("estimated rows", totalRows),
+		zap.Int("estimated chunks", totalChunks),
+		zap.String("source size", formatPlanBytes(totalSourceBytes)),
+		zap.String("target size", formatPlanBytes(totalTargetBytes)),
+		zap.String("estimated duration", formatPlanDuration(totalDuration)))
+}
+
+func formatPlanBytes(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/(1<<20))
+	default:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/(1<<10))
+	}
+}
+
+func formatPlanDuration(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// CheckIncrPrerequisite 增量同步前置检查，oracle 需开启归档、最小附加日志以及同步表全字段附加日志（update 拆分 delete/replace 依赖完整行数据），挖掘用户需具备 logminer 相关权限
+// 配置 prerequisite-auto-fix = true 自动执行附加日志以及授权修复语句，归档模式需重启数据库，不自动修复
+func CheckIncrPrerequisite(cfg *config.Config, oracle *oracle.Oracle, oraDBVersion string, exporters []string) error {
+	var fixSQLs []string
+
+	// 归档模式
+	logMode, err := oracle.GetOracleDBLogMode()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(logMode, "ARCHIVELOG") {
+		return fmt.Errorf("oracle db log mode [%s] isn't ARCHIVELOG, increment sync can't be running, please manual execute [SHUTDOWN IMMEDIATE; STARTUP MOUNT; ALTER DATABASE ARCHIVELOG; ALTER DATABASE OPEN;]", logMode)
+	}
+
+	// 数据库级别附加日志
+	supplemental, err := oracle.GetOracleDBSupplementalLogging()
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(supplemental["SUPPLEMENTAL_LOG_DATA_MIN"], "NO") {
+		fixSQLs = append(fixSQLs, `ALTER DATABASE ADD SUPPLEMENTAL LOG DATA`)
+	}
+
+	// 表级别全字段附加日志，数据库级别 ALL 已开启则忽略
+	if !strings.EqualFold(supplemental["SUPPLEMENTAL_LOG_DATA_ALL"], "YES") {
+		tableLogs, err := oracle.GetOracleSchemaTableSupplementalLogging(cfg.SchemaConfig.SourceSchema)
+		if err != nil {
+			return err
+		}
+		for _, t := range exporters {
+			if logs, ok := tableLogs[common.StringUPPER(t)]; ok && common.IsContainString(logs, "ALL COLUMN LOGGING") {
+				continue
+			}
+			fixSQLs = append(fixSQLs, fmt.Sprintf(`ALTER TABLE "%s"."%s" ADD SUPPLEMENTAL LOG DATA (ALL) COLUMNS`, common.StringUPPER(cfg.SchemaConfig.SourceSchema), common.StringUPPER(t)))
+		}
+	}
+
+	// 挖掘用户权限，DBA 角色忽略
+	privs, err := oracle.GetOracleSessionPrivileges()
+	if err != nil {
+		return err
+	}
+	if !common.IsContainString(privs, "DBA") {
+		requirePrivs := []string{"SELECT ANY TRANSACTION", "SELECT ANY DICTIONARY", "EXECUTE_CATALOG_ROLE"}
+		// oracle 12c 及以上 logminer 需 LOGMINING 权限
+		if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.RequireOracleLogminingDBVersion) {
+			requirePrivs = append(requirePrivs, "LOGMINING")
+		}
+		for _, p := range requirePrivs {
+			if !common.IsContainString(privs, p) {
+				fixSQLs = append(fixSQLs, fmt.Sprintf(`GRANT %s TO %s`, p, strings.ToUpper(cfg.OracleConfig.Username)))
+			}
+		}
+	}
+
+	if len(fixSQLs) == 0 {
+		zap.L().Info("oracle increment sync prerequisite check passed",
+			zap.String("schema", cfg.SchemaConfig.SourceSchema),
+			zap.Int("table totals", len(exporters)))
+		return nil
+	}
+
+	if !cfg.AllConfig.PrerequisiteAutoFix {
+		return fmt.Errorf("oracle increment sync prerequisite check failed, please manual execute [%s] or setting [all] prerequisite-auto-fix = true", strings.Join(fixSQLs, "; "))
+	}
+
+	for _, s := range fixSQLs {
+		if _, err = oracle.OracleDB.ExecContext(oracle.Ctx, s); err != nil {
+			return fmt.Errorf("oracle increment sync prerequisite auto fix sql [%s] exec failed: %v", s, err)
+		}
+		zap.L().Warn("oracle increment sync prerequisite auto fix",
+			zap.String("schema", cfg.SchemaConfig.SourceSchema),
+			zap.String("sql", s))
+	}
+	return nil
+}
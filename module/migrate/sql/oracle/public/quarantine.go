@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Quarantine 全量 skip-error 模式下游写入失败行隔离，按表写入 error-dir/${schema}.${table}.err 文件
+type Quarantine struct {
+	Dir    string
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func NewQuarantine(dir string) (*Quarantine, error) {
+	if strings.EqualFold(dir, "") {
+		dir = common.DefaultQuarantineDir
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create quarantine error-dir [%s] failed: %v", dir, err)
+	}
+	return &Quarantine{
+		Dir:    dir,
+		counts: make(map[string]int64),
+	}, nil
+}
+
+// Write 追加写入失败行以及失败原因
+func (q *Quarantine) Write(schemaName, tableName, row, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	schemaTable := common.StringsBuilder(schemaName, ".", tableName)
+	fileName := filepath.Join(q.Dir, common.StringsBuilder(schemaTable, ".err"))
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open quarantine file [%s] failed: %v", fileName, err)
+	}
+	defer file.Close()
+
+	if _, err = file.WriteString(common.StringsBuilder("-- ", strings.ReplaceAll(reason, "\n", " "), "\n", strings.TrimSuffix(row, "\n"), "\n")); err != nil {
+		return fmt.Errorf("write quarantine file [%s] failed: %v", fileName, err)
+	}
+	q.counts[schemaTable]++
+	return nil
+}
+
+// Summary 返回各表隔离行数，按表名排序
+func (q *Quarantine) Summary() ([]string, int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var (
+		tables []string
+		totals int64
+	)
+	for t, c := range q.counts {
+		tables = append(tables, fmt.Sprintf("%s: %d", t, c))
+		totals = totals + c
+	}
+	sort.Strings(tables)
+	return tables, totals
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/kafka"
+	"time"
+)
+
+// GenKafkaChangeEvent 增量记录转换变更事件，字段值取自 SQL_REDO/SQL_UNDO（需开启全字段附加日志）
+// UPDATE before 取 SQL_REDO WHERE 条件，after 取 SQL_UNDO WHERE 条件，DDL 以及 TRUNCATE 只记录源端原始语句
+func GenKafkaChangeEvent(rows Logminer, operationType string, keyColumns []string, projection *config.ColumnProjection) (*kafka.ChangeEvent, error) {
+	event := &kafka.ChangeEvent{
+		Schema:    rows.SourceSchema,
+		Table:     rows.SourceTable,
+		SCN:       rows.SCN,
+		Timestamp: time.Now().UnixMilli(),
+		KeyNames:  kafka.NewKeyNames(keyColumns),
+	}
+
+	switch operationType {
+	case common.MigrateOperationInsert, common.MigrateOperationUpdate, common.MigrateOperationDelete:
+	default:
+		event.Operation = common.MigrateOperationDDL
+		event.DDL = rows.SQLRedo
+		return event, nil
+	}
+
+	astNode, err := ParseSQL(rows.SQLRedo)
+	if err != nil {
+		return nil, fmt.Errorf("kafka change event parse sql redo [%s] failed: %v", rows.SQLRedo, err)
+	}
+	stmt := ExtractStmt(astNode)
+	if err = stmt.Project(projection); err != nil {
+		return nil, err
+	}
+
+	switch operationType {
+	case common.MigrateOperationInsert:
+		event.Operation = common.ChangeOperationInsert
+		if event.After, err = kafka.NewRowData(stmt.Data); err != nil {
+			return nil, err
+		}
+	case common.MigrateOperationDelete:
+		event.Operation = common.ChangeOperationDelete
+		if event.Before, err = kafka.NewRowData(stmt.Before); err != nil {
+			return nil, err
+		}
+	default:
+		event.Operation = common.ChangeOperationUpdate
+		if event.Before, err = kafka.NewRowData(stmt.Before); err != nil {
+			return nil, err
+		}
+		astUndoNode, err := ParseSQL(rows.SQLUndo)
+		if err != nil {
+			return nil, fmt.Errorf("kafka change event parse sql undo [%s] failed: %v", rows.SQLUndo, err)
+		}
+		undoStmt := ExtractStmt(astUndoNode)
+		if err = undoStmt.Project(projection); err != nil {
+			return nil, err
+		}
+		if event.After, err = kafka.NewRowData(undoStmt.Before); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/metrics"
+	"go.uber.org/zap"
+	"os"
+)
+
+// TxnSpill 增量大事务落盘
+// 单事务捕获行数超过 txn-spill-rows 后，超出部分 SQL_REDO/SQL_UNDO 顺序写入落盘文件，内存只保留文件偏移量，转换时按需读取
+// 同时按事务 XID 统计日志文件内事务行数，threshold <= 0 只统计不落盘
+type TxnSpill struct {
+	sourceSchema string
+	dir          string
+	threshold    int
+
+	file   *os.File
+	writer *bufio.Writer
+	offset int64
+
+	// 日志文件内各事务捕获行数以及已落盘事务
+	txnRows    map[string]int
+	txnSpilled map[string]bool
+}
+
+func NewTxnSpill(sourceSchema, dir string, threshold int) *TxnSpill {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return &TxnSpill{
+		sourceSchema: common.StringUPPER(sourceSchema),
+		dir:          dir,
+		threshold:    threshold,
+		txnRows:      make(map[string]int),
+		txnSpilled:   make(map[string]bool),
+	}
+}
+
+// Add 统计捕获行所属事务行数，超过阈值的 DML 行 SQL_REDO/SQL_UNDO 落盘，记录内存中清空
+func (s *TxnSpill) Add(lc *Logminer) error {
+	s.txnRows[lc.XID]++
+
+	if s.threshold <= 0 || s.txnRows[lc.XID] <= s.threshold || lc.Operation == common.MigrateOperationDDL {
+		return nil
+	}
+	if s.file == nil {
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			return fmt.Errorf("create txn spill dir [%s] failed: %v", s.dir, err)
+		}
+		f, err := os.CreateTemp(s.dir, fmt.Sprintf("transferdb_txn_%s_*.spill", s.sourceSchema))
+		if err != nil {
+			return fmt.Errorf("create txn spill file failed: %v", err)
+		}
+		s.file = f
+		s.writer = bufio.NewWriterSize(f, 1024*1024)
+	}
+	if !s.txnSpilled[lc.XID] {
+		s.txnSpilled[lc.XID] = true
+		metrics.IncrTxnSpilledCounter.WithLabelValues(s.sourceSchema).Inc()
+		zap.L().Warn("oracle large transaction spill to disk",
+			zap.String("oracle schema", s.sourceSchema),
+			zap.String("xid", lc.XID),
+			zap.Uint64("scn", lc.SCN),
+			zap.Int("txn-spill-rows", s.threshold),
+			zap.String("spill file", s.file.Name()))
+	}
+
+	if _, err := s.writer.WriteString(lc.SQLRedo); err != nil {
+		return fmt.Errorf("write txn spill file [%s] failed: %v", s.file.Name(), err)
+	}
+	if _, err := s.writer.WriteString(lc.SQLUndo); err != nil {
+		return fmt.Errorf("write txn spill file [%s] failed: %v", s.file.Name(), err)
+	}
+	lc.spill = s
+	lc.spillOffset = s.offset
+	lc.redoLen = len(lc.SQLRedo)
+	lc.undoLen = len(lc.SQLUndo)
+	s.offset += int64(lc.redoLen + lc.undoLen)
+	metrics.IncrTxnSpilledBytesCounter.WithLabelValues(s.sourceSchema).Add(float64(lc.redoLen + lc.undoLen))
+
+	lc.SQLRedo = ""
+	lc.SQLUndo = ""
+	return nil
+}
+
+// Flush 捕获完成刷新落盘缓冲，之后才可读取落盘记录
+func (s *TxnSpill) Flush() error {
+	if s.writer == nil {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("flush txn spill file [%s] failed: %v", s.file.Name(), err)
+	}
+	return nil
+}
+
+// Reset 上一日志文件增量记录应用完毕，统计事务行数并清空落盘文件复用
+func (s *TxnSpill) Reset() error {
+	s.observe()
+	if s.file == nil {
+		return nil
+	}
+	s.writer.Reset(s.file)
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate txn spill file [%s] failed: %v", s.file.Name(), err)
+	}
+	if _, err := s.file.Seek(0, 0); err != nil {
+		return fmt.Errorf("seek txn spill file [%s] failed: %v", s.file.Name(), err)
+	}
+	s.offset = 0
+	return nil
+}
+
+// Close 统计事务行数并删除落盘文件
+func (s *TxnSpill) Close() error {
+	s.observe()
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	s.writer = nil
+	return os.Remove(name)
+}
+
+func (s *TxnSpill) observe() {
+	for _, rows := range s.txnRows {
+		metrics.IncrTxnRowsHistogram.WithLabelValues(s.sourceSchema).Observe(float64(rows))
+	}
+	s.txnRows = make(map[string]int)
+	s.txnSpilled = make(map[string]bool)
+}
+
+// LoadSpill 读取落盘 SQL_REDO/SQL_UNDO，未落盘记录直接返回
+func (lc *Logminer) LoadSpill() error {
+	if lc.spill == nil {
+		return nil
+	}
+	buf := make([]byte, lc.redoLen+lc.undoLen)
+	if _, err := lc.spill.file.ReadAt(buf, lc.spillOffset); err != nil {
+		return fmt.Errorf("read txn spill file [%s] offset [%d] failed: %v", lc.spill.file.Name(), lc.spillOffset, err)
+	}
+	lc.SQLRedo = string(buf[:lc.redoLen])
+	lc.SQLUndo = string(buf[lc.redoLen:])
+	lc.spill = nil
+	return nil
+}
@@ -17,9 +17,13 @@ package public
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
 
 	"go.uber.org/zap"
 
@@ -47,6 +51,22 @@ func ExtractStmt(rootNode *ast.StmtNode) *Stmt {
 	return v
 }
 
+// IsIgnoredIncrRecord 增量记录是否按表级别变更过滤忽略，配置 ignore-update-columns 时解析 UPDATE 修改字段
+func IsIgnoredIncrRecord(filter *config.OperationFilter, operationType, sqlRedo string) (bool, error) {
+	if filter == nil {
+		return false, nil
+	}
+	var setColumns []string
+	if filter.HasUpdateColumns() && operationType == common.MigrateOperationUpdate {
+		astNode, err := ParseSQL(sqlRedo)
+		if err != nil {
+			return false, fmt.Errorf("parse error: %v\n", err.Error())
+		}
+		setColumns = ExtractStmt(astNode).SetColumns
+	}
+	return filter.IsIgnored(operationType, setColumns), nil
+}
+
 type Stmt struct {
 	Schema    string
 	Table     string
@@ -55,6 +75,10 @@ type Stmt struct {
 	Data      map[string]interface{}
 	Before    map[string]interface{}
 	WhereExpr string
+	// UPDATE SET 修改字段
+	SetColumns []string
+
+	where ast.ExprNode
 }
 
 // WARNING: sql parser Format() has be discrepancy ,be is instead of Restore()
@@ -71,6 +95,7 @@ func (v *Stmt) Enter(in ast.Node) (ast.Node, bool) {
 
 		// Set 修改值 -> data
 		for _, val := range node.List {
+			v.SetColumns = append(v.SetColumns, common.StringsBuilder("`", strings.ToUpper(val.Column.Name.String()), "`"))
 			var sb strings.Builder
 			flags := format.DefaultRestoreFlags
 			err := val.Expr.Restore(format.NewRestoreCtx(flags, &sb))
@@ -82,6 +107,7 @@ func (v *Stmt) Enter(in ast.Node) (ast.Node, bool) {
 
 		// 如果存在 WHERE 条件 -> before
 		if node.Where != nil {
+			v.where = node.Where
 			if node, ok := node.Where.Accept(v); ok {
 				if exprNode, ok := node.(ast.ExprNode); ok {
 					var sb strings.Builder
@@ -123,6 +149,7 @@ func (v *Stmt) Enter(in ast.Node) (ast.Node, bool) {
 		v.Before = make(map[string]interface{}, 1)
 		// 如果存在 WHERE 条件 -> before
 		if node.Where != nil {
+			v.where = node.Where
 			if node, ok := node.Where.Accept(v); ok {
 				if exprNode, ok := node.(ast.ExprNode); ok {
 					var sb strings.Builder
@@ -149,6 +176,105 @@ func (v *Stmt) Enter(in ast.Node) (ast.Node, bool) {
 	return in, false
 }
 
+// Project 字段投影，排除字段不参与写入，WHERE 条件移除引用排除字段的谓词
+func (v *Stmt) Project(projection *config.ColumnProjection) error {
+	if projection == nil {
+		return nil
+	}
+	var columns []string
+	for _, c := range v.Columns {
+		if projection.IsProjected(c) {
+			columns = append(columns, c)
+		}
+	}
+	v.Columns = columns
+	for c := range v.Data {
+		if !projection.IsProjected(c) {
+			delete(v.Data, c)
+		}
+	}
+	for c := range v.Before {
+		if !projection.IsProjected(c) {
+			delete(v.Before, c)
+		}
+	}
+
+	if v.where == nil {
+		return nil
+	}
+	var conds []ast.ExprNode
+	for _, cond := range splitLogicAnd(v.where) {
+		collector := &columnNameCollector{}
+		cond.Accept(collector)
+		projected := true
+		for _, c := range collector.columns {
+			if !projection.IsProjected(c) {
+				projected = false
+				break
+			}
+		}
+		if projected {
+			conds = append(conds, cond)
+		}
+	}
+	// 谓词全部引用排除字段，避免生成无 WHERE 条件 SQL 影响全表
+	if len(conds) == 0 {
+		return fmt.Errorf("table [%s.%s] where condition columns are all excluded by column projection", v.Schema, v.Table)
+	}
+	expr := conds[0]
+	for _, c := range conds[1:] {
+		expr = &ast.BinaryOperationExpr{Op: opcode.LogicAnd, L: expr, R: c}
+	}
+	v.where = expr
+	var sb strings.Builder
+	sb.WriteString("WHERE ")
+	if err := expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return fmt.Errorf("table [%s.%s] where condition restore failed: %v", v.Schema, v.Table, err)
+	}
+	v.WhereExpr = sb.String()
+	return nil
+}
+
+// Rename 字段名映射，按 column_name_rule 源端字段名转换为目标字段名，WHERE 条件引用字段同步转换
+func (v *Stmt) Rename(columnNameRule map[string]string) error {
+	if len(columnNameRule) == 0 {
+		return nil
+	}
+	rename := func(column string) string {
+		if val, ok := columnNameRule[strings.ToUpper(strings.Trim(column, "`"))]; ok {
+			return common.StringsBuilder("`", val, "`")
+		}
+		return column
+	}
+	for i, c := range v.Columns {
+		v.Columns[i] = rename(c)
+	}
+	renameKeys := func(values map[string]interface{}) map[string]interface{} {
+		if values == nil {
+			return nil
+		}
+		renamed := make(map[string]interface{}, len(values))
+		for c, val := range values {
+			renamed[rename(c)] = val
+		}
+		return renamed
+	}
+	v.Data = renameKeys(v.Data)
+	v.Before = renameKeys(v.Before)
+
+	if v.where == nil {
+		return nil
+	}
+	v.where.Accept(&columnNameRenamer{columnNameRule: columnNameRule})
+	var sb strings.Builder
+	sb.WriteString("WHERE ")
+	if err := v.where.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return fmt.Errorf("table [%s.%s] where condition restore failed: %v", v.Schema, v.Table, err)
+	}
+	v.WhereExpr = sb.String()
+	return nil
+}
+
 func (v *Stmt) Leave(in ast.Node) (ast.Node, bool) {
 	return in, true
 }
@@ -187,3 +313,44 @@ func beforeData(where ast.ExprNode, before map[string]interface{}) {
 		}
 	}
 }
+
+func splitLogicAnd(expr ast.ExprNode) []ast.ExprNode {
+	if binaryNode, ok := expr.(*ast.BinaryOperationExpr); ok && binaryNode.Op == opcode.LogicAnd {
+		return append(splitLogicAnd(binaryNode.L), splitLogicAnd(binaryNode.R)...)
+	}
+	return []ast.ExprNode{expr}
+}
+
+// columnNameCollector 获取表达式引用字段
+type columnNameCollector struct {
+	columns []string
+}
+
+func (c *columnNameCollector) Enter(in ast.Node) (ast.Node, bool) {
+	if node, ok := in.(*ast.ColumnNameExpr); ok {
+		c.columns = append(c.columns, node.Name.Name.O)
+	}
+	return in, false
+}
+
+func (c *columnNameCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// columnNameRenamer 表达式引用字段名映射
+type columnNameRenamer struct {
+	columnNameRule map[string]string
+}
+
+func (c *columnNameRenamer) Enter(in ast.Node) (ast.Node, bool) {
+	if node, ok := in.(*ast.ColumnNameExpr); ok {
+		if val, ok := c.columnNameRule[strings.ToUpper(node.Name.Name.O)]; ok {
+			node.Name.Name = model.NewCIStr(val)
+		}
+	}
+	return in, false
+}
+
+func (c *columnNameRenamer) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
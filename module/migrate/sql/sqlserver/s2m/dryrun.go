@@ -0,0 +1,41 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s2m
+
+import (
+	"fmt"
+)
+
+// DryRun 输出待同步表列表、表行数以及 chunk 切分计划，不写入下游以及元数据库
+func (r *Migrate) DryRun(exporters []string) error {
+	fmt.Printf("dry-run source [%s] schema [%s] task mode [%s] resolved table counts [%d]\n",
+		r.Source.DBType(), r.Cfg.SchemaConfig.SourceSchema, r.Cfg.TaskMode, len(exporters))
+	for _, t := range exporters {
+		numRows, err := r.Source.GetSchemaTableRows(r.Cfg.SchemaConfig.SourceSchema, t)
+		if err != nil {
+			return err
+		}
+		chunks, err := r.Source.GetTableChunks(r.Cfg.SchemaConfig.SourceSchema, t, r.Cfg.FullConfig.ChunkSize)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("table [%s] estimated rows [%d] chunks [%d]\n", t, numRows, len(chunks))
+		for _, c := range chunks {
+			fmt.Printf("  chunk: %s\n", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database"
+	"github.com/wentaojin/transferdb/filter"
+	"go.uber.org/zap"
+	"time"
+)
+
+// FilterCFGTable 按 include-table/exclude-table 过滤规则获取待同步表列表
+func FilterCFGTable(cfg *config.Config, reader database.SchemaReader) ([]string, error) {
+	startTime := time.Now()
+	var (
+		exporterTableSlice []string
+		excludeTables      []string
+	)
+
+	allTables, err := reader.GetSchemaTables(cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		return exporterTableSlice, err
+	}
+	if len(allTables) == 0 {
+		return exporterTableSlice, fmt.Errorf("source schema [%s] isn't exist or hasn't any table", cfg.SchemaConfig.SourceSchema)
+	}
+
+	switch {
+	case len(cfg.SchemaConfig.SourceIncludeTable) != 0 && len(cfg.SchemaConfig.SourceExcludeTable) == 0:
+		f, err := filter.Parse(cfg.SchemaConfig.SourceIncludeTable)
+		if err != nil {
+			return nil, fmt.Errorf("source config params include-table [%v] parse failed: %v", cfg.SchemaConfig.SourceIncludeTable, err)
+		}
+		for _, t := range allTables {
+			if f.MatchTable(t) {
+				exporterTableSlice = append(exporterTableSlice, t)
+			}
+		}
+	case len(cfg.SchemaConfig.SourceIncludeTable) == 0 && len(cfg.SchemaConfig.SourceExcludeTable) != 0:
+		f, err := filter.Parse(cfg.SchemaConfig.SourceExcludeTable)
+		if err != nil {
+			return nil, fmt.Errorf("source config params exclude-table [%v] parse failed: %v", cfg.SchemaConfig.SourceExcludeTable, err)
+		}
+		for _, t := range allTables {
+			if f.MatchTable(t) {
+				excludeTables = append(excludeTables, t)
+			}
+		}
+		exporterTableSlice = common.FilterDifferenceStringItems(allTables, excludeTables)
+	case len(cfg.SchemaConfig.SourceIncludeTable) == 0 && len(cfg.SchemaConfig.SourceExcludeTable) == 0:
+		exporterTableSlice = allTables
+	default:
+		return exporterTableSlice, fmt.Errorf("source config params include-table/exclude-table cannot exist at the same time")
+	}
+
+	if len(exporterTableSlice) == 0 {
+		return exporterTableSlice, fmt.Errorf("exporter tables aren't exist, please check config params include-table/exclude-table")
+	}
+
+	zap.L().Info("get sqlserver to mysql all tables",
+		zap.String("schema", cfg.SchemaConfig.SourceSchema),
+		zap.Strings("exporter tables list", exporterTableSlice),
+		zap.Int("include table counts", len(exporterTableSlice)),
+		zap.Int("exclude table counts", len(excludeTables)),
+		zap.Int("all table counts", len(allTables)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return exporterTableSlice, nil
+}
@@ -0,0 +1,521 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/sqlserver"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"strings"
+	"time"
+)
+
+// Migrate 基于 database.Source 源端接口的全量/增量数据同步，源端表结构读取、chunk 切分、数据读取以及 CDC 读取均通过接口完成
+type Migrate struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	Source database.Source
+	Mysql  *mysql.MySQL
+	MetaDB *meta.Meta
+}
+
+func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
+	sqlserverDB, err := sqlserver.NewSQLServerDBEngine(ctx, cfg.SQLServerConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlserverDB.Throttle = common.NewThrottle(cfg.AppConfig.ExtractRowsPerSecond, cfg.AppConfig.ExtractMBPerSecond)
+	mysqlDB, err := mysql.NewMySQLLoadEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	mysqlDB.Throttle = common.NewThrottle(cfg.AppConfig.ApplyRowsPerSecond, cfg.AppConfig.ApplyMBPerSecond)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrate{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		Source: sqlserverDB,
+		Mysql:  mysqlDB,
+		MetaDB: metaDB,
+	}, nil
+}
+
+// Full 全量数据同步，元数据 wait_sync_meta/full_sync_meta 记录表以及 chunk 进度，支持 enable-checkpoint 断点续传
+// 源端无一致性快照读，chunk 按读取时刻数据同步，增量 CDC 从全量开始前位点重放保证最终一致
+func (r *Migrate) Full() error {
+	startTime := time.Now()
+	zap.L().Info("source schema full table data sync start",
+		zap.String("db type", r.Source.DBType()),
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+
+	if !common.IsContainString(common.MigrateDataSupportCharset, common.StringUPPER(r.Cfg.MySQLConfig.Charset)) {
+		return fmt.Errorf("mysql current config charset [%v] isn't support, support charset [%v]", r.Cfg.MySQLConfig.Charset, common.MigrateDataSupportCharset)
+	}
+
+	exporters, err := FilterCFGTable(r.Cfg, r.Source)
+	if err != nil {
+		return err
+	}
+
+	// dry-run 只输出待同步表列表以及 chunk 切分计划，不执行迁移
+	if r.Cfg.DryRun {
+		return r.DryRun(exporters)
+	}
+
+	if !common.IsContainString(common.MigrateWriteModes, r.getWriteMode()) {
+		return fmt.Errorf("full config write-mode [%v] isn't support, support write-mode [%v]", r.Cfg.FullConfig.WriteMode, common.MigrateWriteModes)
+	}
+
+	// 关于全量断点恢复
+	//  - 若想断点恢复，设置 enable-checkpoint true
+	//  - 若不想断点恢复，设置 enable-checkpoint false，清理元数据记录以及下游表数据，重新运行全量任务
+	if !r.Cfg.FullConfig.EnableCheckpoint {
+		if err = meta.NewFullSyncMetaModel(r.MetaDB).DeleteFullSyncMetaBySchemaSyncMode(r.Ctx, &meta.FullSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+			TaskMode:    r.Cfg.TaskMode,
+		}); err != nil {
+			return err
+		}
+		if err = meta.NewChunkErrorDetailModel(r.MetaDB).DeleteChunkErrorDetailBySchemaTaskMode(r.Ctx, &meta.ChunkErrorDetail{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+			TaskMode:    r.Cfg.TaskMode,
+		}); err != nil {
+			return err
+		}
+		for _, tableName := range exporters {
+			if err = meta.NewWaitSyncMetaModel(r.MetaDB).DeleteWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+				TableNameS:  tableName,
+				TaskMode:    r.Cfg.TaskMode,
+			}); err != nil {
+				return err
+			}
+			if err = r.Mysql.TruncateMySQLTable(r.Cfg.SchemaConfig.TargetSchema, tableName); err != nil {
+				return err
+			}
+			zap.L().Info("truncate table",
+				zap.String("schema", r.Cfg.SchemaConfig.TargetSchema),
+				zap.String("table", tableName),
+				zap.String("status", "success"))
+		}
+	}
+
+	// 判断 [wait_sync_meta] 是否存在错误记录，是否可进行 FULL
+	errTotals, err := meta.NewWaitSyncMetaModel(r.MetaDB).CountsErrWaitSyncMetaBySchema(r.Ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return err
+	}
+	if errTotals > 0 {
+		if !r.Cfg.FullConfig.EnableCheckpoint || !r.Cfg.FullConfig.RetryFailed {
+			return fmt.Errorf(`full schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, setting [retry-failed = true]; finally rerunning`, r.Cfg.SchemaConfig.SourceSchema, r.Cfg.TaskMode)
+		}
+		// 失败表重置为 RUNNING，断点续传时跳过已成功 chunk，重试 FAILED chunk
+		if err = meta.NewCommonModel(r.MetaDB).UpdateFailedWaitSyncMetaAndDeleteChunkErrorDetail(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+			TaskMode:    r.Cfg.TaskMode,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 判断并记录待同步表列表，已成功表跳过
+	var syncTables []string
+	for _, tableName := range exporters {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+			TableNameS:  tableName,
+			TaskMode:    r.Cfg.TaskMode,
+		})
+		if err != nil {
+			return err
+		}
+		if len(waitSyncMetas) == 0 {
+			if err = meta.NewWaitSyncMetaModel(r.MetaDB).CreateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+				DBTypeS:        r.Cfg.DBTypeS,
+				DBTypeT:        r.Cfg.DBTypeT,
+				SchemaNameS:    r.Cfg.SchemaConfig.SourceSchema,
+				TableNameS:     tableName,
+				TaskMode:       r.Cfg.TaskMode,
+				TaskStatus:     common.TaskStatusWaiting,
+				GlobalScnS:     common.TaskTableDefaultSourceGlobalSCN,
+				ChunkTotalNums: common.TaskTableDefaultSplitChunkNums,
+			}); err != nil {
+				return err
+			}
+			syncTables = append(syncTables, tableName)
+			continue
+		}
+		if !strings.EqualFold(waitSyncMetas[0].TaskStatus, common.TaskStatusSuccess) {
+			syncTables = append(syncTables, tableName)
+		}
+	}
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.FullConfig.TableThreads)
+	for _, table := range syncTables {
+		t := table
+		g.Go(func() error {
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，未开始表不再同步
+			if signal.IsShutdown() {
+				return nil
+			}
+			return r.syncTable(t)
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	// 任务结束，判断是否存在失败表
+	errTotals, err = meta.NewWaitSyncMetaModel(r.MetaDB).CountsErrWaitSyncMetaBySchema(r.Ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return err
+	}
+	if errTotals > 0 {
+		return fmt.Errorf("source schema [%s] full table data sync failed tables [%d], please see meta table [wait_sync_meta] and [chunk_error_detail] and rerunning", r.Cfg.SchemaConfig.SourceSchema, errTotals)
+	}
+	zap.L().Info("source schema full table data finished",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.Int("table totals", len(exporters)),
+		zap.Int("table sync totals", len(syncTables)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (r *Migrate) syncTable(tableName string) error {
+	startTime := time.Now()
+
+	columns, err := r.Source.GetSchemaTableColumns(r.Cfg.SchemaConfig.SourceSchema, tableName)
+	if err != nil {
+		return err
+	}
+	var columnNameT []string
+	for _, c := range columns {
+		columnNameT = append(columnNameT, common.StringsBuilder("`", c["COLUMN_NAME"], "`"))
+	}
+
+	// 未切分表进行 chunk 切分并记录 full_sync_meta
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableName,
+		TaskMode:    r.Cfg.TaskMode,
+	})
+	if err != nil {
+		return err
+	}
+	if len(waitSyncMetas) == 0 {
+		return fmt.Errorf("meta table [wait_sync_meta] schema [%s] table [%s] record isn't exist", r.Cfg.SchemaConfig.SourceSchema, tableName)
+	}
+	if waitSyncMetas[0].ChunkTotalNums == common.TaskTableDefaultSplitChunkNums {
+		numRows, err := r.Source.GetSchemaTableRows(r.Cfg.SchemaConfig.SourceSchema, tableName)
+		if err != nil {
+			return err
+		}
+		chunks, err := r.Source.GetTableChunks(r.Cfg.SchemaConfig.SourceSchema, tableName, r.Cfg.FullConfig.ChunkSize)
+		if err != nil {
+			return err
+		}
+		columnDetail := r.Source.GenTableColumnDetail(columns)
+		var fullMetas []meta.FullSyncMeta
+		for _, chunk := range chunks {
+			fullMetas = append(fullMetas, meta.FullSyncMeta{
+				DBTypeS:        r.Cfg.DBTypeS,
+				DBTypeT:        r.Cfg.DBTypeT,
+				SchemaNameS:    r.Cfg.SchemaConfig.SourceSchema,
+				TableNameS:     tableName,
+				SchemaNameT:    r.Cfg.SchemaConfig.TargetSchema,
+				TableNameT:     tableName,
+				GlobalScnS:     common.TaskTableDefaultSourceGlobalSCN,
+				ConsistentRead: "NO",
+				ColumnDetailS:  columnDetail,
+				ChunkDetailS:   chunk,
+				TaskMode:       r.Cfg.TaskMode,
+				TaskStatus:     common.TaskStatusWaiting,
+			})
+		}
+		if err = meta.NewFullSyncMetaModel(r.MetaDB).BatchCreateFullSyncMeta(r.Ctx, fullMetas, r.Cfg.AppConfig.InsertBatchSize); err != nil {
+			return err
+		}
+		if err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+			TableNameS:  tableName,
+			TaskMode:    r.Cfg.TaskMode,
+		}, map[string]interface{}{
+			"TaskStatus":     common.TaskStatusRunning,
+			"TableNumRows":   uint64(numRows),
+			"ChunkTotalNums": int64(len(chunks)),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 待同步 chunk：首次运行 WAITING，断点续传 WAITING 以及 retry-failed 重置后的 FAILED
+	var pendingMetas []meta.FullSyncMeta
+	for _, status := range []string{common.TaskStatusWaiting, common.TaskStatusFailed} {
+		fullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+			TableNameS:  tableName,
+			TaskMode:    r.Cfg.TaskMode,
+			TaskStatus:  status,
+		})
+		if err != nil {
+			return err
+		}
+		pendingMetas = append(pendingMetas, fullMetas...)
+	}
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.FullConfig.SQLThreads)
+	for _, fullMeta := range pendingMetas {
+		m := fullMeta
+		g.Go(func() error {
+			// 任务暂停，等待恢复后继续拉取
+			if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+				return err
+			}
+			// 收到退出信号，未开始 chunk 不再同步，等待下次断点续传
+			if signal.IsShutdown() {
+				return nil
+			}
+			if err := r.syncChunk(m, columnNameT); err != nil {
+				if errf := meta.NewCommonModel(r.MetaDB).UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(r.Ctx, &meta.FullSyncMeta{
+					DBTypeS:      m.DBTypeS,
+					DBTypeT:      m.DBTypeT,
+					SchemaNameS:  m.SchemaNameS,
+					TableNameS:   m.TableNameS,
+					TaskMode:     m.TaskMode,
+					ChunkDetailS: m.ChunkDetailS,
+				}, map[string]interface{}{
+					"TaskStatus": common.TaskStatusFailed,
+				}, &meta.ChunkErrorDetail{
+					DBTypeS:      m.DBTypeS,
+					DBTypeT:      m.DBTypeT,
+					SchemaNameS:  m.SchemaNameS,
+					TableNameS:   m.TableNameS,
+					SchemaNameT:  m.SchemaNameT,
+					TableNameT:   m.TableNameT,
+					TaskMode:     m.TaskMode,
+					ChunkDetailS: m.ChunkDetailS,
+					InfoDetail:   m.String(),
+					ErrorSQL:     r.Source.GenTableQuerySQL(m.SchemaNameS, m.TableNameS, m.ColumnDetailS, m.ChunkDetailS),
+					ErrorDetail:  err.Error(),
+				}); errf != nil {
+					return fmt.Errorf("get sqlserver schema table [%v] chunk sync failed: %v", m.String(), errf)
+				}
+				metrics.FullChunkCounter.WithLabelValues(m.SchemaNameS, m.TableNameS, common.TaskStatusFailed).Inc()
+				return nil
+			}
+			if errf := meta.NewFullSyncMetaModel(r.MetaDB).UpdateFullSyncMetaChunk(r.Ctx, &meta.FullSyncMeta{
+				DBTypeS:      m.DBTypeS,
+				DBTypeT:      m.DBTypeT,
+				SchemaNameS:  m.SchemaNameS,
+				TableNameS:   m.TableNameS,
+				TaskMode:     m.TaskMode,
+				ChunkDetailS: m.ChunkDetailS,
+			}, map[string]interface{}{
+				"TaskStatus": common.TaskStatusSuccess,
+			}); errf != nil {
+				return fmt.Errorf("get sqlserver schema table [%v] Success failed: %v", m.String(), errf)
+			}
+			metrics.FullChunkCounter.WithLabelValues(m.SchemaNameS, m.TableNameS, common.TaskStatusSuccess).Inc()
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	// 收到退出信号，跳过表完成状态更新，等待下次断点续传
+	if signal.IsShutdown() {
+		zap.L().Warn("full single table task interrupted by exit signal, checkpoint saved",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", tableName))
+		return nil
+	}
+
+	failedChunkTotalErrs, err := meta.NewFullSyncMetaModel(r.MetaDB).CountsErrorFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableName,
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return fmt.Errorf("get meta table [full_sync_meta] counts failed, error: %v", err)
+	}
+	successChunkFullMeta, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableName,
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusSuccess,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 不存在错误，清理 full_sync_meta 记录, 更新 wait_sync_meta 记录
+	if failedChunkTotalErrs == 0 {
+		if err = meta.NewCommonModel(r.MetaDB).DeleteTableFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx,
+			&meta.FullSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+				TableNameS:  tableName,
+				TaskMode:    r.Cfg.TaskMode,
+			}, &meta.WaitSyncMeta{
+				DBTypeS:          r.Cfg.DBTypeS,
+				DBTypeT:          r.Cfg.DBTypeT,
+				SchemaNameS:      r.Cfg.SchemaConfig.SourceSchema,
+				TableNameS:       tableName,
+				TaskMode:         r.Cfg.TaskMode,
+				TaskStatus:       common.TaskStatusSuccess,
+				ChunkSuccessNums: int64(len(successChunkFullMeta)),
+				ChunkFailedNums:  0,
+			}); err != nil {
+			return err
+		}
+		zap.L().Info("full single table sqlserver to mysql finished",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", tableName),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+		return nil
+	}
+
+	// 若存在错误，修改表状态，skip 清理，统一忽略，最后显示
+	if err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  tableName,
+		TaskMode:    r.Cfg.TaskMode,
+	}, map[string]interface{}{
+		"TaskStatus":       common.TaskStatusFailed,
+		"ChunkSuccessNums": int64(len(successChunkFullMeta)),
+		"ChunkFailedNums":  failedChunkTotalErrs,
+	}); err != nil {
+		return err
+	}
+	zap.L().Warn("update mysql [wait_sync_meta] meta",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", tableName),
+		zap.String("mode", r.Cfg.TaskMode),
+		zap.String("updated", "table exist error, skip"),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// syncChunk 读取 chunk 数据并按 insert-batch-size 批量写入下游，瞬时错误重试整个 chunk，依赖 write-mode 幂等写入
+func (r *Migrate) syncChunk(m meta.FullSyncMeta, columnNameT []string) error {
+	querySQL := r.Source.GenTableQuerySQL(m.SchemaNameS, m.TableNameS, m.ColumnDetailS, m.ChunkDetailS)
+	targetTable := common.StringsBuilder("`", m.TableNameT, "`")
+	prefixSQL := genMySQLWriteSQLPrefix(m.SchemaNameT, targetTable, columnNameT, r.getWriteMode())
+	suffixSQL := genMySQLWriteSQLSuffix(columnNameT, r.getWriteMode())
+	targetDBCharset := common.StringUPPER(r.Cfg.MySQLConfig.Charset)
+
+	return common.Retry(r.Ctx, common.NewRetryPolicy(r.Cfg.AppConfig.RetryAttempts, r.Cfg.AppConfig.RetryBackoff, r.Cfg.AppConfig.RetryMaxBackoff), func() error {
+		dataChan := make(chan []map[string]string, common.ChannelBufferSize)
+		g := &errgroup.Group{}
+		g.Go(func() error {
+			defer close(dataChan)
+			return r.Source.GetTableRowsData(querySQL, r.Cfg.AppConfig.InsertBatchSize, targetDBCharset, r.Cfg.AppConfig.CharsetErrorMode, dataChan)
+		})
+		g.Go(func() error {
+			var applyErr error
+			for rows := range dataChan {
+				// 写入失败继续消费通道数据，避免读取端阻塞
+				if applyErr != nil {
+					continue
+				}
+				values, batchBytes := genMySQLRowValues(rows, columnNameT)
+				if err := r.Mysql.Throttle.Wait(r.Ctx, len(rows), batchBytes); err != nil {
+					applyErr = err
+					continue
+				}
+				if err := r.Mysql.WriteMySQLTable(common.StringsBuilder(prefixSQL, strings.Join(values, ","), suffixSQL)); err != nil {
+					applyErr = fmt.Errorf("sqlserver schema table [%s.%s] chunk [%s] write mysql failed: %v", m.SchemaNameS, m.TableNameS, m.ChunkDetailS, err)
+					continue
+				}
+				metrics.FullRowsReadCounter.WithLabelValues(m.SchemaNameS, m.TableNameS).Add(float64(len(rows)))
+				metrics.FullBatchWrittenCounter.WithLabelValues(m.SchemaNameS, m.TableNameS).Inc()
+				metrics.FullBytesWrittenCounter.WithLabelValues(m.SchemaNameS, m.TableNameS).Add(float64(batchBytes))
+			}
+			return applyErr
+		})
+		return g.Wait()
+	}, func(attempt int, err error) {
+		metrics.RetryCounter.WithLabelValues(m.SchemaNameS, m.TableNameS, "apply").Inc()
+		zap.L().Warn("source schema table chunk sync retry",
+			zap.String("schema", m.SchemaNameS),
+			zap.String("table", m.TableNameS),
+			zap.String("chunk", m.ChunkDetailS),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	})
+}
+
+func (r *Migrate) getWriteMode() string {
+	if strings.EqualFold(r.Cfg.FullConfig.WriteMode, "") {
+		return common.WriteModeReplace
+	}
+	return common.StringUPPER(r.Cfg.FullConfig.WriteMode)
+}
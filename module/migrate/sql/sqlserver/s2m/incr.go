@@ -0,0 +1,231 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/sqlserver"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"time"
+)
+
+func NewIncr(ctx context.Context, cfg *config.Config) (*Migrate, error) {
+	sqlserverDB, err := sqlserver.NewSQLServerDBEngine(ctx, cfg.SQLServerConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlserverDB.Throttle = common.NewThrottle(cfg.AppConfig.ExtractRowsPerSecond, cfg.AppConfig.ExtractMBPerSecond)
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	mysqlDB.Throttle = common.NewThrottle(cfg.AppConfig.ApplyRowsPerSecond, cfg.AppConfig.ApplyMBPerSecond)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrate{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		Source: sqlserverDB,
+		Mysql:  mysqlDB,
+		MetaDB: metaDB,
+	}, nil
+}
+
+// Incr 全量 + 增量数据同步，全量开始前记录源端变更位点，全量完成后基于 CDC 从该位点轮询重放变更
+// 增量统一 REPLACE INTO/DELETE 幂等写入，重放全量期间已同步变更不影响最终一致
+func (r *Migrate) Incr() error {
+	zap.L().Info("source schema all table data sync start",
+		zap.String("db type", r.Source.DBType()),
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+
+	exporters, err := FilterCFGTable(r.Cfg, r.Source)
+	if err != nil {
+		return err
+	}
+
+	cdcMetas, err := meta.NewCDCSyncMetaModel(r.MetaDB).DetailCDCSyncMetaBySchema(r.Ctx, &meta.CDCSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 增量元数据不存在，全量同步后初始化表级别增量位点
+	if len(cdcMetas) == 0 {
+		startPosition, err := r.Source.GetCurrentPosition()
+		if err != nil {
+			return err
+		}
+		if err = r.Full(); err != nil {
+			return err
+		}
+		if r.Cfg.DryRun || signal.IsShutdown() {
+			return nil
+		}
+		for _, t := range exporters {
+			cdcMetas = append(cdcMetas, meta.CDCSyncMeta{
+				DBTypeS:       r.Cfg.DBTypeS,
+				DBTypeT:       r.Cfg.DBTypeT,
+				SchemaNameS:   r.Cfg.SchemaConfig.SourceSchema,
+				TableNameS:    t,
+				SchemaNameT:   r.Cfg.SchemaConfig.TargetSchema,
+				TableNameT:    t,
+				StartPosition: startPosition,
+				Position:      startPosition,
+			})
+		}
+		if err = meta.NewCDCSyncMetaModel(r.MetaDB).BatchCreateCDCSyncMeta(r.Ctx, cdcMetas, r.Cfg.AppConfig.InsertBatchSize); err != nil {
+			return err
+		}
+	} else {
+		var metaTables []string
+		for _, m := range cdcMetas {
+			metaTables = append(metaTables, m.TableNameS)
+		}
+		if addTables := common.FilterDifferenceStringItems(exporters, metaTables); len(addTables) > 0 {
+			return fmt.Errorf("source schema [%s] tables [%v] aren't exist in meta table [cdc_sync_meta], please clear meta table [cdc_sync_meta] and [wait_sync_meta] current schema records and rerunning", r.Cfg.SchemaConfig.SourceSchema, addTables)
+		}
+	}
+
+	interval := r.Cfg.SQLServerConfig.CDCInterval
+	if interval <= 0 {
+		interval = common.SQLServerCDCDefaultInterval
+	}
+	targetDBCharset := common.StringUPPER(r.Cfg.MySQLConfig.Charset)
+
+	for {
+		// 任务暂停，等待恢复后从 cdc_sync_meta 已同步位点继续
+		if err := signal.WaitResume(r.Ctx, r.Cfg.AppConfig.TaskID); err != nil {
+			return err
+		}
+		if signal.IsShutdown() {
+			zap.L().Warn("all task interrupted by exit signal, checkpoint saved",
+				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+			return nil
+		}
+
+		toPosition, err := r.Source.GetCurrentPosition()
+		if err != nil {
+			return err
+		}
+
+		g := &errgroup.Group{}
+		g.SetLimit(r.Cfg.AllConfig.ApplyThreads)
+		for i := range cdcMetas {
+			m := &cdcMetas[i]
+			g.Go(func() error {
+				return r.applyTableChanges(m, toPosition, targetDBCharset)
+			})
+		}
+		if err = g.Wait(); err != nil {
+			return err
+		}
+
+		select {
+		case <-r.Ctx.Done():
+			return nil
+		case <-time.After(time.Duration(interval) * time.Second):
+		}
+	}
+}
+
+// applyTableChanges 单表变更按源端顺序在同一下游事务内应用，应用成功后推进表级别位点
+func (r *Migrate) applyTableChanges(m *meta.CDCSyncMeta, toPosition, targetDBCharset string) error {
+	changes, err := r.Source.GetTableChanges(m.SchemaNameS, m.TableNameS, m.Position, toPosition, targetDBCharset, r.Cfg.AppConfig.CharsetErrorMode)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		keyColumns, err := r.Source.GetSchemaTablePrimaryKey(m.SchemaNameS, m.TableNameS)
+		if err != nil {
+			return err
+		}
+		columns, err := r.Source.GetSchemaTableColumns(m.SchemaNameS, m.TableNameS)
+		if err != nil {
+			return err
+		}
+		var columnNames []string
+		for _, c := range columns {
+			columnNames = append(columnNames, c["COLUMN_NAME"])
+		}
+		if err = r.applyChanges(m, changes, keyColumns, columnNames); err != nil {
+			return err
+		}
+	}
+
+	if m.Position == toPosition {
+		return nil
+	}
+	m.Position = toPosition
+	if err = meta.NewCDCSyncMetaModel(r.MetaDB).UpdateCDCSyncMetaPosition(r.Ctx, m); err != nil {
+		return err
+	}
+	zap.L().Info("incr table changes applied",
+		zap.String("schema", m.SchemaNameS),
+		zap.String("table", m.TableNameS),
+		zap.Int("changes", len(changes)),
+		zap.String("position", toPosition))
+	return nil
+}
+
+func (r *Migrate) applyChanges(m *meta.CDCSyncMeta, changes []database.RowChange, keyColumns, columnNames []string) error {
+	var (
+		rowColumns []string
+		applyBytes int
+	)
+	for _, c := range columnNames {
+		rowColumns = append(rowColumns, common.StringsBuilder("`", c, "`"))
+	}
+	targetTable := common.StringsBuilder("`", m.TableNameT, "`")
+
+	txn, err := r.Mysql.MySQLDB.BeginTx(r.Ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		var sqlStr string
+		switch c.Operation {
+		case common.ChangeOperationDelete:
+			sqlStr = genMySQLDeleteSQL(m.SchemaNameT, m.TableNameT, keyColumns, columnNames, c.Values)
+		default:
+			values, _ := genMySQLRowValues([]map[string]string{c.Values}, rowColumns)
+			sqlStr = common.StringsBuilder(genMySQLWriteSQLPrefix(m.SchemaNameT, targetTable, rowColumns, common.WriteModeReplace), values[0])
+		}
+		applyBytes += len(sqlStr)
+		if _, err = txn.ExecContext(r.Ctx, sqlStr); err != nil {
+			_ = txn.Rollback()
+			return fmt.Errorf("sqlserver schema table [%s.%s] change position [%s] apply sql [%s] failed: %v", m.SchemaNameS, m.TableNameS, c.Position, sqlStr, err)
+		}
+	}
+	if err = r.Mysql.Throttle.Wait(r.Ctx, len(changes), applyBytes); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package s2m
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// 全量写入 SQL Prefix 语句，按 write-mode 生成
+func genMySQLWriteSQLPrefix(targetSchemaName, targetTableName string, columns []string, writeMode string) string {
+	column := common.StringsBuilder(" (", strings.Join(columns, ","), ")")
+	switch common.StringUPPER(writeMode) {
+	case common.WriteModeInsert, common.WriteModeUpsert:
+		return common.StringsBuilder(`INSERT INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
+	case common.WriteModeIgnore:
+		return common.StringsBuilder(`INSERT IGNORE INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
+	default:
+		return common.StringsBuilder(`REPLACE INTO `, common.QuoteMySQLTableName(targetSchemaName, targetTableName), column, ` VALUES `)
+	}
+}
+
+// 全量写入 SQL Suffix 语句，upsert 模式主键/唯一键冲突更新全部字段
+func genMySQLWriteSQLSuffix(columns []string, writeMode string) string {
+	if !strings.EqualFold(writeMode, common.WriteModeUpsert) {
+		return ""
+	}
+	var updates []string
+	for _, c := range columns {
+		updates = append(updates, common.StringsBuilder(c, "=VALUES(", c, ")"))
+	}
+	return common.StringsBuilder(` ON DUPLICATE KEY UPDATE `, strings.Join(updates, ","))
+}
+
+// genMySQLRowValues 按字段顺序生成 VALUES 行字面量，返回行字面量以及批次字节数，columns 为反引号引用字段名
+func genMySQLRowValues(rows []map[string]string, columns []string) ([]string, int) {
+	var (
+		values     []string
+		batchBytes int
+	)
+	for _, row := range rows {
+		var rowValues []string
+		for _, c := range columns {
+			val, ok := row[strings.Trim(c, "`")]
+			if !ok {
+				val = `NULL`
+			}
+			rowValues = append(rowValues, val)
+			batchBytes += len(val)
+		}
+		values = append(values, common.StringsBuilder("(", strings.Join(rowValues, ","), ")"))
+	}
+	return values, batchBytes
+}
+
+// genMySQLDeleteSQL 按主键生成 DELETE 语句，无主键按全部字段匹配且只删除一行
+func genMySQLDeleteSQL(targetSchemaName, targetTableName string, keyColumns []string, columns []string, row map[string]string) string {
+	matchColumns := keyColumns
+	limit := ""
+	if len(matchColumns) == 0 {
+		matchColumns = columns
+		limit = " LIMIT 1"
+	}
+	var conditions []string
+	for _, c := range matchColumns {
+		val, ok := row[c]
+		if !ok || val == `NULL` {
+			conditions = append(conditions, common.StringsBuilder("`", c, "` IS NULL"))
+			continue
+		}
+		conditions = append(conditions, common.StringsBuilder("`", c, "` = ", val))
+	}
+	return common.StringsBuilder(`DELETE FROM `, targetSchemaName, ".`", targetTableName, "` WHERE ", strings.Join(conditions, " AND "), limit)
+}
@@ -17,6 +17,7 @@ package prepare
 
 import (
 	"context"
+	"fmt"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"go.uber.org/zap"
@@ -26,6 +27,18 @@ import (
 func IPrepare(ctx context.Context, cfg *config.Config) error {
 	startTime := time.Now()
 	zap.L().Info("prepare tansferdb env start")
+	if cfg.DryRun {
+		tables, err := meta.DryRunTables()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("dry-run meta schema [%s] would create meta table counts [%d] and init buildin default values\n",
+			cfg.MetaConfig.MetaSchema, len(tables))
+		for _, t := range tables {
+			fmt.Println(t)
+		}
+		return nil
+	}
 	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return err
@@ -0,0 +1,410 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package report
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed template
+var fs embed.FS
+
+// Report 单次任务运行结束汇总报告，同时输出 JSON 以及 HTML 两种格式，便于附加至变更工单
+type Report struct {
+	TaskID       string         `json:"task_id"`
+	TaskMode     string         `json:"task_mode"`
+	DBTypeS      string         `json:"db_type_s"`
+	DBTypeT      string         `json:"db_type_t"`
+	SchemaNameS  string         `json:"schema_name_s"`
+	SchemaNameT  string         `json:"schema_name_t"`
+	TaskStatus   string         `json:"task_status"`
+	ErrorDetail  string         `json:"error_detail"`
+	StartTime    string         `json:"start_time"`
+	EndTime      string         `json:"end_time"`
+	Duration     string         `json:"duration"`
+	Summary      Summary        `json:"summary"`
+	Tables       []Table        `json:"tables"`
+	Warnings     []Warning      `json:"warnings"`
+	Skipped      []Skipped      `json:"skipped"`
+	Verification []Verification `json:"verification"`
+}
+
+type Summary struct {
+	TableTotals     int     `json:"table_totals"`
+	TableSuccess    int     `json:"table_success"`
+	TableFailed     int     `json:"table_failed"`
+	TableUnfinished int     `json:"table_unfinished"`
+	ChunkTotals     int64   `json:"chunk_totals"`
+	ChunkFailed     int64   `json:"chunk_failed"`
+	RowsRead        uint64  `json:"rows_read"`
+	BytesWritten    uint64  `json:"bytes_written"`
+	RowsPerSecond   float64 `json:"rows_per_second"`
+	BytesPerSecond  float64 `json:"bytes_per_second"`
+	RowsQuarantined uint64  `json:"rows_quarantined"`
+	Retries         uint64  `json:"retries"`
+	Warnings        int     `json:"warnings"`
+	Skipped         int     `json:"skipped"`
+	CompareFailed   int     `json:"compare_failed"`
+}
+
+type Table struct {
+	SchemaNameS   string  `json:"schema_name_s"`
+	TableNameS    string  `json:"table_name_s"`
+	TaskMode      string  `json:"task_mode"`
+	TaskStatus    string  `json:"task_status"`
+	TableNumRows  uint64  `json:"table_num_rows"`
+	RowsRead      uint64  `json:"rows_read"`
+	ChunkTotals   int64   `json:"chunk_totals"`
+	ChunkSuccess  int64   `json:"chunk_success"`
+	ChunkFailed   int64   `json:"chunk_failed"`
+	Duration      string  `json:"duration"`
+	RowsPerSecond float64 `json:"rows_per_second"`
+}
+
+// Warning 全量/csv/all 模式 chunk 执行失败记录，chunk 跳过，重跑任务断点续传
+type Warning struct {
+	TableNameS   string `json:"table_name_s"`
+	TaskMode     string `json:"task_mode"`
+	ChunkDetailS string `json:"chunk_detail_s"`
+	ErrorDetail  string `json:"error_detail"`
+}
+
+// Skipped reverse/check/compare 等模式跳过或者失败对象记录
+type Skipped struct {
+	TableNameS  string `json:"table_name_s"`
+	TaskMode    string `json:"task_mode"`
+	InfoDetail  string `json:"info_detail"`
+	ErrorDetail string `json:"error_detail"`
+}
+
+// Verification compare 模式表级数据校验结果
+type Verification struct {
+	TableNameS   string `json:"table_name_s"`
+	TableNameT   string `json:"table_name_t"`
+	ChunkTotals  int    `json:"chunk_totals"`
+	ChunkSuccess int    `json:"chunk_success"`
+	ChunkFailed  int    `json:"chunk_failed"`
+	ChunkWaiting int    `json:"chunk_waiting"`
+}
+
+// GenReport 任务结束输出汇总报告至 [app] report-dir，未配置则不输出
+func GenReport(ctx context.Context, cfg *config.Config, metaDB *meta.Meta, startTime, endTime time.Time, taskStatus, errorDetail string) error {
+	if cfg.AppConfig.ReportDir == "" {
+		return nil
+	}
+	report, err := NewReport(ctx, cfg, metaDB, startTime, endTime, taskStatus, errorDetail)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(cfg.AppConfig.ReportDir, 0755); err != nil {
+		return fmt.Errorf("create report dir [%s] failed: %v", cfg.AppConfig.ReportDir, err)
+	}
+	taskName := cfg.AppConfig.TaskID
+	if taskName == "" {
+		taskName = strings.ToLower(report.TaskMode)
+	}
+	fileName := filepath.Join(cfg.AppConfig.ReportDir,
+		common.StringsBuilder("report_", taskName, "_", endTime.Format("20060102150405")))
+
+	if err = report.WriteJSON(common.StringsBuilder(fileName, ".json")); err != nil {
+		return err
+	}
+	if err = report.WriteHTML(common.StringsBuilder(fileName, ".html")); err != nil {
+		return err
+	}
+	zap.L().Info("task report generated",
+		zap.String("task", cfg.AppConfig.TaskID),
+		zap.String("json", common.StringsBuilder(fileName, ".json")),
+		zap.String("html", common.StringsBuilder(fileName, ".html")))
+	return nil
+}
+
+// NewReport 汇总元数据库任务记录以及进程内运行指标
+func NewReport(ctx context.Context, cfg *config.Config, metaDB *meta.Meta, startTime, endTime time.Time, taskStatus, errorDetail string) (*Report, error) {
+	schemaNameS := common.StringUPPER(cfg.SchemaConfig.SourceSchema)
+	schemaNameT := common.StringUPPER(cfg.SchemaConfig.TargetSchema)
+	if schemaNameT == "" {
+		schemaNameT = schemaNameS
+	}
+	taskMode := common.StringUPPER(cfg.TaskMode)
+
+	report := &Report{
+		TaskID:       cfg.AppConfig.TaskID,
+		TaskMode:     taskMode,
+		DBTypeS:      cfg.DBTypeS,
+		DBTypeT:      cfg.DBTypeT,
+		SchemaNameS:  schemaNameS,
+		SchemaNameT:  schemaNameT,
+		TaskStatus:   taskStatus,
+		ErrorDetail:  errorDetail,
+		StartTime:    startTime.Format("2006-01-02 15:04:05"),
+		EndTime:      endTime.Format("2006-01-02 15:04:05"),
+		Duration:     endTime.Sub(startTime).Truncate(time.Second).String(),
+		Tables:       []Table{},
+		Warnings:     []Warning{},
+		Skipped:      []Skipped{},
+		Verification: []Verification{},
+	}
+
+	values, err := gatherMetrics(schemaNameS, schemaNameT)
+	if err != nil {
+		return report, err
+	}
+
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+		TaskMode:    taskMode,
+	})
+	if err != nil {
+		return report, err
+	}
+	sort.Slice(waitSyncMetas, func(i, j int) bool {
+		return waitSyncMetas[i].TableNameS < waitSyncMetas[j].TableNameS
+	})
+	for _, w := range waitSyncMetas {
+		t := Table{
+			SchemaNameS:  w.SchemaNameS,
+			TableNameS:   w.TableNameS,
+			TaskMode:     w.TaskMode,
+			TaskStatus:   w.TaskStatus,
+			TableNumRows: w.TableNumRows,
+			RowsRead:     uint64(values["transferdb_full_rows_read_total"][common.StringUPPER(w.TableNameS)]),
+			ChunkSuccess: w.ChunkSuccessNums,
+			ChunkFailed:  w.ChunkFailedNums,
+		}
+		// 全量任务 chunk 未切分 ChunkTotalNums 为 -1
+		if w.ChunkTotalNums > 0 {
+			t.ChunkTotals = w.ChunkTotalNums
+		}
+		if w.BaseModel != nil && w.UpdatedAt.After(w.CreatedAt) {
+			elapsed := w.UpdatedAt.Sub(w.CreatedAt)
+			t.Duration = elapsed.Truncate(time.Second).String()
+			if t.RowsRead > 0 {
+				t.RowsPerSecond = float64(t.RowsRead) / elapsed.Seconds()
+			}
+		}
+		report.Tables = append(report.Tables, t)
+
+		report.Summary.TableTotals++
+		switch w.TaskStatus {
+		case common.TaskStatusSuccess:
+			report.Summary.TableSuccess++
+		case common.TaskStatusFailed:
+			report.Summary.TableFailed++
+		default:
+			report.Summary.TableUnfinished++
+		}
+		report.Summary.ChunkTotals += t.ChunkTotals
+		report.Summary.ChunkFailed += t.ChunkFailed
+	}
+
+	report.Summary.RowsRead = uint64(sumValues(values["transferdb_full_rows_read_total"]))
+	report.Summary.BytesWritten = uint64(sumValues(values["transferdb_full_bytes_written_total"]))
+	report.Summary.RowsQuarantined = uint64(sumValues(values["transferdb_full_rows_quarantined_total"]))
+	report.Summary.Retries = uint64(sumValues(values["transferdb_retry_attempts_total"]))
+	if seconds := endTime.Sub(startTime).Seconds(); seconds > 0 {
+		report.Summary.RowsPerSecond = float64(report.Summary.RowsRead) / seconds
+		report.Summary.BytesPerSecond = float64(report.Summary.BytesWritten) / seconds
+	}
+
+	chunkErrs, err := meta.NewChunkErrorDetailModel(metaDB).DetailRecentChunkErrorDetail(ctx, &meta.ChunkErrorDetail{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+	}, common.ReportDetailLimit)
+	if err != nil {
+		return report, err
+	}
+	for _, c := range chunkErrs {
+		if c.TaskMode != taskMode {
+			continue
+		}
+		report.Warnings = append(report.Warnings, Warning{
+			TableNameS:   c.TableNameS,
+			TaskMode:     c.TaskMode,
+			ChunkDetailS: c.ChunkDetailS,
+			ErrorDetail:  c.ErrorDetail,
+		})
+	}
+	report.Summary.Warnings = len(report.Warnings)
+
+	errLogs, err := meta.NewErrorLogDetailModel(metaDB).DetailRecentErrorLog(ctx, &meta.ErrorLogDetail{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+	}, common.ReportDetailLimit)
+	if err != nil {
+		return report, err
+	}
+	for _, e := range errLogs {
+		if e.TaskMode != taskMode {
+			continue
+		}
+		report.Skipped = append(report.Skipped, Skipped{
+			TableNameS:  e.TableNameS,
+			TaskMode:    e.TaskMode,
+			InfoDetail:  e.InfoDetail,
+			ErrorDetail: e.ErrorDetail,
+		})
+	}
+	report.Summary.Skipped = len(report.Skipped)
+
+	if taskMode == common.TaskModeCompare {
+		compareMetas, err := meta.NewDataCompareMetaModel(metaDB).DetailDataCompareMeta(ctx, &meta.DataCompareMeta{
+			DBTypeS:     cfg.DBTypeS,
+			DBTypeT:     cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TaskMode:    taskMode,
+		})
+		if err != nil {
+			return report, err
+		}
+		verifications := make(map[string]*Verification)
+		for _, c := range compareMetas {
+			v, ok := verifications[c.TableNameS]
+			if !ok {
+				v = &Verification{TableNameS: c.TableNameS, TableNameT: c.TableNameT}
+				verifications[c.TableNameS] = v
+			}
+			v.ChunkTotals++
+			switch c.TaskStatus {
+			case common.TaskStatusSuccess:
+				v.ChunkSuccess++
+			case common.TaskStatusFailed:
+				v.ChunkFailed++
+			default:
+				v.ChunkWaiting++
+			}
+		}
+		for _, v := range verifications {
+			if v.ChunkFailed > 0 {
+				report.Summary.CompareFailed++
+			}
+			report.Verification = append(report.Verification, *v)
+		}
+		sort.Slice(report.Verification, func(i, j int) bool {
+			return report.Verification[i].TableNameS < report.Verification[j].TableNameS
+		})
+	}
+	return report, nil
+}
+
+func (r *Report) WriteJSON(file string) error {
+	js, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("report json marshal failed: %v", err)
+	}
+	if err = os.WriteFile(file, js, 0644); err != nil {
+		return fmt.Errorf("write report file [%s] failed: %v", file, err)
+	}
+	return nil
+}
+
+func (r *Report) WriteHTML(file string) error {
+	tf, err := template.New("report.html").Funcs(template.FuncMap{
+		"bytes": formatBytes,
+		"rate":  func(b float64) string { return common.StringsBuilder(formatBytes(uint64(b)), "/s") },
+		"float": func(f float64) string { return fmt.Sprintf("%.2f", f) },
+	}).ParseFS(fs, "template/report.html")
+	if err != nil {
+		return fmt.Errorf("template parse FS failed: %v", err)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("open report file [%s] failed: %v", file, err)
+	}
+	defer f.Close()
+	if err = tf.Execute(f, r); err != nil {
+		return fmt.Errorf("template FS Execute [report] template HTML failed: %v", err)
+	}
+	return nil
+}
+
+// gatherMetrics 汇总当前进程 transferdb 计数指标，按 schema 标签过滤上下游 schema，返回指标名 -> 表名 -> 指标值
+// 全量读取指标以源端表名为标签，写入指标以目标端表名为标签
+func gatherMetrics(schemaNameS, schemaNameT string) (map[string]map[string]float64, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("gather metrics failed: %v", err)
+	}
+	values := make(map[string]map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			if m.GetCounter() == nil {
+				continue
+			}
+			var (
+				matched bool
+				table   string
+			)
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "schema":
+					schema := common.StringUPPER(l.GetValue())
+					matched = schema == schemaNameS || schema == schemaNameT
+				case "table":
+					table = common.StringUPPER(l.GetValue())
+				}
+			}
+			if !matched {
+				continue
+			}
+			if _, ok := values[f.GetName()]; !ok {
+				values[f.GetName()] = make(map[string]float64)
+			}
+			values[f.GetName()][table] += m.GetCounter().GetValue()
+		}
+	}
+	return values, nil
+}
+
+func sumValues(values map[string]float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
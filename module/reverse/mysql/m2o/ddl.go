@@ -38,7 +38,7 @@ type DDL struct {
 	TableSuffix          string   `json:"table_suffix"`
 	TableComment         string   `json:"table_comment"`
 	ColumnCommentDDL     []string `json:"column_comment_ddl"`
-	TableCheckKeys       []string `json:"table_check_keys""`
+	TableCheckKeys       []string `json:"table_check_keys"`
 	TableForeignKeys     []string `json:"table_foreign_keys"`
 	TableCompatibleDDL   []string `json:"table_compatible_ddl"`
 	TablePartitionDetail string   `json:"table_partition_detail"`
@@ -132,26 +132,6 @@ func (r *Change) ChangeTableColumnDatatype() (map[string]map[string]string, erro
 		return tableColumnDatatypeMap, err
 	}
 
-	// 获取自定义 table 级别数据类型映射规则
-	tableDataTypeMapSlice, err := meta.NewTableDatatypeRuleModel(r.MetaDB).DetailTableRule(r.Ctx, &meta.TableDatatypeRule{
-		DBTypeS:     r.DBTypeS,
-		DBTypeT:     r.DBTypeT,
-		SchemaNameS: r.SourceSchemaName,
-	})
-	if err != nil {
-		return tableColumnDatatypeMap, err
-	}
-
-	// 获取自定义 column 映射规则
-	columnDataTypeMapSlice, err := meta.NewColumnDatatypeRuleModel(r.MetaDB).DetailColumnRule(r.Ctx, &meta.ColumnDatatypeRule{
-		DBTypeS:     r.DBTypeS,
-		DBTypeT:     r.DBTypeT,
-		SchemaNameS: r.SourceSchemaName,
-	})
-	if err != nil {
-		return tableColumnDatatypeMap, err
-	}
-
 	wg := &errgroup.Group{}
 	wg.SetLimit(r.Threads)
 
@@ -178,6 +158,28 @@ func (r *Change) ChangeTableColumnDatatype() (map[string]map[string]string, erro
 				return err
 			}
 
+			// 获取自定义 table 级别数据类型映射规则
+			tableDataTypeMapSlice, err := meta.NewTableDatatypeRuleModel(r.MetaDB).DetailTableRule(r.Ctx, &meta.TableDatatypeRule{
+				DBTypeS:     r.DBTypeS,
+				DBTypeT:     r.DBTypeT,
+				SchemaNameS: r.SourceSchemaName,
+				TableNameS:  sourceTable,
+			})
+			if err != nil {
+				return err
+			}
+
+			// 获取自定义 column 级别数据类型映射规则
+			columnDataTypeMapSlice, err := meta.NewColumnDatatypeRuleModel(r.MetaDB).DetailColumnRuleByTable(r.Ctx, &meta.ColumnDatatypeRule{
+				DBTypeS:     r.DBTypeS,
+				DBTypeT:     r.DBTypeT,
+				SchemaNameS: r.SourceSchemaName,
+				TableNameS:  sourceTable,
+			})
+			if err != nil {
+				return err
+			}
+
 			columnDatatypeMap := make(map[string]string, 1)
 			tableDatatypeTempMap := make(map[string]map[string]string, 1)
 
@@ -0,0 +1,253 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2c
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/clickhouse"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/reverse"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/public"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Reverse struct {
+	Ctx        context.Context
+	Cfg        *config.Config
+	ClickHouse *clickhouse.ClickHouse
+	Oracle     *oracle.Oracle
+	MetaDB     *meta.Meta
+}
+
+func NewReverse(ctx context.Context, cfg *config.Config) (*Reverse, error) {
+	oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig, cfg.SchemaConfig.SourceSchema)
+	if err != nil {
+		return nil, err
+	}
+	chDB, err := clickhouse.NewClickHouseDBEngine(ctx, cfg.ClickHouseConfig)
+	if err != nil {
+		return nil, err
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReverseConfig.DirectWrite {
+		createSchema := fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s%s`,
+			common.QuoteClickHouseIdentifier(common.ClickHouseIdentifierCase(cfg.ReverseConfig.LowerCaseFieldName, cfg.SchemaConfig.TargetSchema)), chDB.OnCluster())
+		if err = chDB.WriteClickHouseTable(createSchema); err != nil {
+			return nil, fmt.Errorf("error on exec target database sql [%v]: %v", createSchema, err)
+		}
+	}
+	return &Reverse{
+		Ctx:        ctx,
+		Cfg:        cfg,
+		ClickHouse: chDB,
+		Oracle:     oracleDB,
+		MetaDB:     metaDB,
+	}, nil
+}
+
+func (r *Reverse) Reverse() error {
+	startTime := time.Now()
+	zap.L().Info("reverse table oracle to clickhouse start",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+
+	// 获取配置文件待同步表列表
+	exporters, err := public.FilterCFGTable(r.Cfg, r.Oracle)
+	if err != nil {
+		return err
+	}
+
+	if len(exporters) == 0 {
+		zap.L().Warn("there are no table objects in the oracle schema",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+		return nil
+	}
+
+	// 判断 error_log_detail 是否存在错误记录，是否可进行 reverse
+	errTotals, err := meta.NewErrorLogDetailModel(r.MetaDB).CountsErrorLogBySchema(r.Ctx, &meta.ErrorLogDetail{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+		TaskMode:    r.Cfg.TaskMode,
+	})
+	if errTotals > 0 || err != nil {
+		return fmt.Errorf("reverse schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", r.Cfg.SchemaConfig.SourceSchema, r.Cfg.TaskMode, err)
+	}
+
+	// 筛选过滤可能不支持的表类型
+	partitionTables, temporaryTables, clusteredTables, materializedView, exporterTables, err := public.FilterOracleCompatibleTable(r.Cfg, r.Oracle, exporters)
+	if err != nil {
+		return err
+	}
+
+	// file writer
+	err = common.PathExist(r.Cfg.ReverseConfig.DDLReverseDir)
+	if err != nil {
+		return err
+	}
+	err = common.PathExist(r.Cfg.ReverseConfig.DDLCompatibleDir)
+	if err != nil {
+		return err
+	}
+	reverseFile := filepath.Join(r.Cfg.ReverseConfig.DDLReverseDir, fmt.Sprintf("reverse_%s.sql", r.Cfg.SchemaConfig.SourceSchema))
+	compFile := filepath.Join(r.Cfg.ReverseConfig.DDLCompatibleDir, fmt.Sprintf("compatibility_%s.sql", r.Cfg.SchemaConfig.SourceSchema))
+
+	f, err := reverse.NewWriter(r.Cfg, nil, r.Oracle, reverseFile, compFile)
+	if err != nil {
+		return err
+	}
+	f.ClickHouse = r.ClickHouse
+
+	targetSchema := common.ClickHouseIdentifierCase(r.Cfg.ReverseConfig.LowerCaseFieldName, r.Cfg.SchemaConfig.TargetSchema)
+	if !r.Cfg.ReverseConfig.DirectWrite {
+		if _, err = f.RWriteFile(fmt.Sprintf("-- clickhouse database\nCREATE DATABASE IF NOT EXISTS %s%s;\n\n", common.QuoteClickHouseIdentifier(targetSchema), r.ClickHouse.OnCluster())); err != nil {
+			return err
+		}
+	}
+
+	engine := common.ClickHouseDefaultEngine
+	if r.Cfg.ClickHouseConfig.Engine != "" {
+		engine = r.Cfg.ClickHouseConfig.Engine
+	}
+	migrateCfgs := make(map[string]config.MigrateConfig)
+	for _, m := range r.Cfg.SchemaConfig.MigrateConfig {
+		migrateCfgs[common.StringUPPER(m.SourceTable)] = m
+	}
+
+	// 表类型不兼容项输出，分区表、临时表、簇表以及物化视图按普通表转换
+	var compatibleTables []string
+	for _, ts := range [][]string{partitionTables, temporaryTables, clusteredTables, materializedView} {
+		compatibleTables = append(compatibleTables, ts...)
+	}
+	if len(compatibleTables) > 0 {
+		if _, err = f.CWriteFile(fmt.Sprintf("-- oracle schema [%s] table [%s] may exist incompatibility (partition/temporary/clustered/materialized view), would be reversed to clickhouse MergeTree table, please manual process\n\n",
+			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), strings.Join(compatibleTables, ","))); err != nil {
+			return err
+		}
+	}
+
+	// 表转换
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.ReverseConfig.ReverseThreads)
+
+	for _, table := range exporterTables {
+		t := &Table{
+			SourceSchemaName:   common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			SourceTableName:    common.StringUPPER(table),
+			TargetSchemaName:   targetSchema,
+			TargetTableName:    common.ClickHouseIdentifierCase(r.Cfg.ReverseConfig.LowerCaseFieldName, table),
+			LowerCaseFieldName: r.Cfg.ReverseConfig.LowerCaseFieldName,
+			Engine:             engine,
+			OnCluster:          r.ClickHouse.OnCluster(),
+			OrderBy:            migrateCfgs[common.StringUPPER(table)].OrderBy,
+			Oracle:             r.Oracle,
+			ColumnProjection:   r.Cfg.SchemaConfig.GetColumnProjection(table),
+		}
+		g.Go(func() error {
+			ddl, compatibleDDL, err := t.GenCreateTableDDL()
+			if err == nil {
+				if len(compatibleDDL) > 0 {
+					if _, err = f.CWriteFile(strings.Join(compatibleDDL, "\n") + "\n\n"); err != nil {
+						return err
+					}
+				}
+				if r.Cfg.ReverseConfig.DirectWrite {
+					for _, sql := range ddl {
+						if err = f.RWriteDB(sql); err != nil {
+							break
+						}
+					}
+				} else {
+					_, err = f.RWriteFile(fmt.Sprintf("-- oracle table %s.%s\n%s;\n\n", t.SourceSchemaName, t.SourceTableName, strings.Join(ddl, ";\n")))
+				}
+			}
+			if err != nil {
+				if errm := meta.NewErrorLogDetailModel(r.MetaDB).CreateErrorLog(r.Ctx, &meta.ErrorLogDetail{
+					DBTypeS:     r.Cfg.DBTypeS,
+					DBTypeT:     r.Cfg.DBTypeT,
+					SchemaNameS: t.SourceSchemaName,
+					TableNameS:  t.SourceTableName,
+					SchemaNameT: t.TargetSchemaName,
+					TableNameT:  t.TargetTableName,
+					TaskMode:    r.Cfg.TaskMode,
+					TaskStatus:  "Failed",
+					TargetDDL:   strings.Join(ddl, ";\n"),
+					InfoDetail:  t.String(),
+					ErrorDetail: err.Error(),
+				}); errm != nil {
+					zap.L().Error("reverse table oracle to clickhouse failed",
+						zap.String("schema", t.SourceSchemaName),
+						zap.String("table", t.SourceTableName),
+						zap.Error(
+							fmt.Errorf("reverse table task failed, detail see [error_log_detail], please rerunning")))
+
+					return fmt.Errorf("reverse table task failed, detail see [error_log_detail], please rerunning, error: %v", errm)
+				}
+			}
+			return nil
+		})
+	}
+
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	errTotals, err = meta.NewErrorLogDetailModel(r.MetaDB).CountsErrorLogBySchema(r.Ctx, &meta.ErrorLogDetail{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+		TaskMode:    r.Cfg.TaskMode,
+	})
+	if err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	if !r.Cfg.ReverseConfig.DirectWrite {
+		zap.L().Info("reverse", zap.String("create table and index output", reverseFile))
+	}
+	zap.L().Info("compatibility", zap.String("maybe exist compatibility output", compFile))
+	if errTotals == 0 {
+		zap.L().Info("reverse table oracle to clickhouse finished",
+			zap.Int("table totals", len(exporterTables)),
+			zap.Int("table success", len(exporterTables)),
+			zap.Int("table failed", int(errTotals)),
+			zap.String("cost", endTime.Sub(startTime).String()))
+	} else {
+		zap.L().Warn("reverse table oracle to clickhouse finished",
+			zap.Int("table totals", len(exporterTables)),
+			zap.Int("table success", len(exporterTables)-int(errTotals)),
+			zap.Int("table failed", int(errTotals)),
+			zap.String("failed tips", "failed detail, please see table [error_log_detail]"),
+			zap.String("cost", endTime.Sub(startTime).String()))
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2c
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/public"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type Table struct {
+	SourceSchemaName   string         `json:"source_schema_name"`
+	SourceTableName    string         `json:"source_table_name"`
+	TargetSchemaName   string         `json:"target_schema_name"`
+	TargetTableName    string         `json:"target_table_name"`
+	LowerCaseFieldName string         `json:"lower_case_field_name"`
+	Engine             string         `json:"engine"`
+	OnCluster          string         `json:"on_cluster"`
+	OrderBy            []string       `json:"order_by"`
+	Oracle             *oracle.Oracle `json:"-"`
+
+	ColumnProjection *config.ColumnProjection `json:"-"` // 字段投影，nil 代表全部字段
+}
+
+// GenCreateTableDDL 生成 clickhouse MergeTree 建表语句，主键、唯一约束以及索引 clickhouse 不支持，输出 compatibleDDL
+// ORDER BY 优先 [[schema-config.migrate-config]] order-by，其次主键、唯一约束字段，否则 tuple()
+func (t *Table) GenCreateTableDDL() ([]string, []string, error) {
+	var (
+		ddl, compatibleDDL, columnMetas []string
+	)
+	columns, err := t.Oracle.GetOracleSchemaTableColumn(t.SourceSchemaName, t.SourceTableName, false)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	var columnNames []string
+	for _, c := range columns {
+		columnNames = append(columnNames, c["COLUMN_NAME"])
+	}
+	columns = t.ColumnProjection.ProjectColumnINFO(columns)
+	nullableColumns := make(map[string]bool)
+	for _, c := range columns {
+		originColumnType, buildInColumnType, err := public.OracleTableColumnMapClickHouseRule(t.SourceSchemaName, t.SourceTableName, public.Column{
+			DataType:   c["DATA_TYPE"],
+			CharLength: c["CHAR_LENGTH"],
+			CharUsed:   c["CHAR_USED"],
+			ColumnInfo: public.ColumnInfo{
+				DataLength:    c["DATA_LENGTH"],
+				DataPrecision: c["DATA_PRECISION"],
+				DataScale:     c["DATA_SCALE"],
+				NULLABLE:      c["NULLABLE"],
+				DataDefault:   c["DATA_DEFAULT"],
+				Comment:       c["COMMENTS"],
+			},
+		})
+		if err != nil {
+			return ddl, compatibleDDL, err
+		}
+		zap.L().Debug("reverse oracle table column datatype",
+			zap.String("schema", t.SourceSchemaName),
+			zap.String("table", t.SourceTableName),
+			zap.String("column", c["COLUMN_NAME"]),
+			zap.String("origin datatype", originColumnType),
+			zap.String("clickhouse datatype", buildInColumnType))
+
+		// 字段默认值不转换，数据以源端全量写入为准
+		if !strings.EqualFold(c["NULLABLE"], "N") {
+			buildInColumnType = fmt.Sprintf("Nullable(%s)", buildInColumnType)
+			nullableColumns[common.StringUPPER(c["COLUMN_NAME"])] = true
+		}
+		columnMeta := fmt.Sprintf("%s %s", t.quoteName(c["COLUMN_NAME"]), buildInColumnType)
+		if c["COMMENTS"] != "" {
+			columnMeta = fmt.Sprintf("%s COMMENT %s", columnMeta, quoteClickHouseString(c["COMMENTS"]))
+		}
+		columnMetas = append(columnMetas, columnMeta)
+	}
+
+	primaryKeys, err := t.Oracle.GetOracleSchemaTablePrimaryKey(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	primaryKeys = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "primary key", "COLUMN_LIST", t.ColumnProjection, primaryKeys, columnNames)
+	uniqueKeys, err := t.Oracle.GetOracleSchemaTableUniqueKey(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	uniqueKeys = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "unique key", "COLUMN_LIST", t.ColumnProjection, uniqueKeys, columnNames)
+
+	orderBy := t.OrderBy
+	if len(orderBy) == 0 {
+		switch {
+		case len(primaryKeys) > 0:
+			orderBy = strings.Split(primaryKeys[0]["COLUMN_LIST"], ",")
+		case len(uniqueKeys) > 0:
+			orderBy = strings.Split(uniqueKeys[0]["COLUMN_LIST"], ",")
+		}
+	}
+	var (
+		orderByColumns []string
+		nullableKey    bool
+	)
+	for _, c := range orderBy {
+		orderByColumns = append(orderByColumns, t.quoteName(strings.TrimSpace(c)))
+		if nullableColumns[common.StringUPPER(strings.TrimSpace(c))] {
+			nullableKey = true
+		}
+	}
+	orderByClause := "tuple()"
+	if len(orderByColumns) > 0 {
+		orderByClause = fmt.Sprintf("(%s)", strings.Join(orderByColumns, ","))
+	}
+
+	targetTable := fmt.Sprintf("%s.%s", common.QuoteClickHouseIdentifier(t.TargetSchemaName), common.QuoteClickHouseIdentifier(t.TargetTableName))
+	createTable := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s (\n    %s\n) ENGINE = %s\nORDER BY %s", targetTable, t.OnCluster, strings.Join(columnMetas, ",\n    "), t.Engine, orderByClause)
+	// 排序键包含 Nullable 字段需开启 allow_nullable_key
+	if nullableKey {
+		createTable = fmt.Sprintf("%s\nSETTINGS allow_nullable_key = 1", createTable)
+	}
+
+	tableComments, err := t.Oracle.GetOracleSchemaTableComment(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	if len(tableComments) > 0 && tableComments[0]["COMMENTS"] != "" {
+		createTable = fmt.Sprintf("%s\nCOMMENT %s", createTable, quoteClickHouseString(tableComments[0]["COMMENTS"]))
+	}
+	ddl = append(ddl, createTable)
+
+	// clickhouse 不支持唯一性约束，主键仅作为排序键
+	for _, uk := range uniqueKeys {
+		compatibleDDL = append(compatibleDDL, fmt.Sprintf("-- oracle table %s.%s unique constraint [%s] columns [%s] isn't support in clickhouse, data uniqueness isn't guaranteed",
+			t.SourceSchemaName, t.SourceTableName, uk["CONSTRAINT_NAME"], uk["COLUMN_LIST"]))
+	}
+	uniqueIndexes, err := t.Oracle.GetOracleSchemaTableUniqueIndex(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	normalIndexes, err := t.Oracle.GetOracleSchemaTableNormalIndex(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	for _, idx := range append(uniqueIndexes, normalIndexes...) {
+		compatibleDDL = append(compatibleDDL, fmt.Sprintf("-- oracle table %s.%s index [%s] type [%s] columns [%s] isn't reversed, clickhouse data skipping index please manual process",
+			t.SourceSchemaName, t.SourceTableName, idx["INDEX_NAME"], idx["INDEX_TYPE"], idx["COLUMN_LIST"]))
+	}
+	return ddl, compatibleDDL, nil
+}
+
+func (t *Table) quoteName(name string) string {
+	return common.QuoteClickHouseIdentifier(common.ClickHouseIdentifierCase(t.LowerCaseFieldName, name))
+}
+
+// clickhouse 字符串字面量，反斜杠以及单引号转义
+func quoteClickHouseString(s string) string {
+	return common.StringsBuilder("'", strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`), "'")
+}
+
+func (t *Table) String() string {
+	jsonStr, _ := json.Marshal(t)
+	return string(jsonStr)
+}
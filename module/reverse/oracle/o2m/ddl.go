@@ -26,21 +26,23 @@ import (
 )
 
 type DDL struct {
-	SourceSchemaName   string   `json:"source_schema"`
-	SourceTableName    string   `json:"source_table_name"`
-	SourceTableType    string   `json:"source_table_type"`
-	SourceTableDDL     string   `json:"-"` // 忽略
-	TargetSchemaName   string   `json:"target_schema"`
-	TargetTableName    string   `json:"target_table_name"`
-	TargetDBVersion    string   `json:"target_db_version"`
-	TablePrefix        string   `json:"table_prefix"`
-	TableColumns       []string `json:"table_columns"`
-	TableKeys          []string `json:"table_keys"`
-	TableSuffix        string   `json:"table_suffix"`
-	TableComment       string   `json:"table_comment"`
-	TableCheckKeys     []string `json:"table_check_keys""`
-	TableForeignKeys   []string `json:"table_foreign_keys"`
-	TableCompatibleDDL []string `json:"table_compatible_ddl"`
+	SourceSchemaName      string   `json:"source_schema"`
+	SourceTableName       string   `json:"source_table_name"`
+	SourceTableType       string   `json:"source_table_type"`
+	SourceTableDDL        string   `json:"-"` // 忽略
+	TargetSchemaName      string   `json:"target_schema"`
+	TargetTableName       string   `json:"target_table_name"`
+	TargetDBVersion       string   `json:"target_db_version"`
+	TablePrefix           string   `json:"table_prefix"`
+	TableColumns          []string `json:"table_columns"`
+	TableKeys             []string `json:"table_keys"`
+	TableSuffix           string   `json:"table_suffix"`
+	TableComment          string   `json:"table_comment"`
+	TableCheckKeys        []string `json:"table_check_keys"`
+	TableForeignKeys      []string `json:"table_foreign_keys"`
+	TableCompatibleDDL    []string `json:"table_compatible_ddl"`
+	TablePartition        string   `json:"table_partition"`
+	AutoIncrementSequence string   `json:"auto_increment_sequence"`
 }
 
 func (d *DDL) Write(w *reverse.Write) (string, error) {
@@ -157,11 +159,10 @@ func (d *DDL) WriteDB(w *reverse.Write) (string, error) {
 
 func (d *DDL) GenDDLStructure() ([]string, []string) {
 	var (
-		reverseDDLS   []string
-		compDDLS      []string
-		tableDDL      string
-		checkKeyDDL   []string
-		foreignKeyDDL []string
+		reverseDDLS []string
+		compDDLS    []string
+		tableDDL    string
+		checkKeyDDL []string
 	)
 
 	// 表 with 主键
@@ -178,10 +179,15 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
 	}
 
 	if strings.EqualFold(d.TableComment, "") {
-		tableDDL = fmt.Sprintf("%s %s;", structDDL, d.TableSuffix)
+		tableDDL = fmt.Sprintf("%s %s", structDDL, d.TableSuffix)
 	} else {
-		tableDDL = fmt.Sprintf("%s %s %s;", structDDL, d.TableSuffix, d.TableComment)
+		tableDDL = fmt.Sprintf("%s %s %s", structDDL, d.TableSuffix, d.TableComment)
 	}
+	// 分区表
+	if !strings.EqualFold(d.TablePartition, "") {
+		tableDDL = fmt.Sprintf("%s\n%s", tableDDL, d.TablePartition)
+	}
+	tableDDL = tableDDL + ";"
 
 	zap.L().Info("reverse oracle table structure",
 		zap.String("schema", d.TargetSchemaName),
@@ -190,18 +196,7 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
 
 	reverseDDLS = append(reverseDDLS, tableDDL+"\n")
 
-	// foreign and check key sql ddl
-	if len(d.TableForeignKeys) > 0 {
-		for _, fk := range d.TableForeignKeys {
-			fkSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;",
-				d.TargetSchemaName, d.TargetTableName, fk)
-			zap.L().Info("reverse oracle table foreign key",
-				zap.String("schema", d.TargetSchemaName),
-				zap.String("table", d.TargetTableName),
-				zap.String("fk sql", fkSQL))
-			foreignKeyDDL = append(foreignKeyDDL, fkSQL)
-		}
-	}
+	// check key sql ddl，外键待全部表创建完成之后按依赖顺序统一输出 GenForeignKeyDDL
 	if len(d.TableCheckKeys) > 0 {
 		for _, ck := range d.TableCheckKeys {
 			ckSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;",
@@ -214,13 +209,7 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
 		}
 	}
 
-	// 外键约束、检查约束
-	if len(foreignKeyDDL) > 0 {
-		for _, sql := range foreignKeyDDL {
-			reverseDDLS = append(reverseDDLS, sql)
-		}
-	}
-
+	// 检查约束
 	if common.VersionOrdinal(d.TargetDBVersion) > common.VersionOrdinal(common.MySQLCheckConsVersion) {
 		if len(checkKeyDDL) > 0 {
 			for _, sql := range checkKeyDDL {
@@ -245,6 +234,21 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
 	return reverseDDLS, compDDLS
 }
 
+// GenForeignKeyDDL 生成外键语句，外键依赖被引用表，需全部表创建完成之后执行
+func (d *DDL) GenForeignKeyDDL() []string {
+	var foreignKeyDDL []string
+	for _, fk := range d.TableForeignKeys {
+		fkSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;",
+			d.TargetSchemaName, d.TargetTableName, fk)
+		zap.L().Info("reverse oracle table foreign key",
+			zap.String("schema", d.TargetSchemaName),
+			zap.String("table", d.TargetTableName),
+			zap.String("fk sql", fkSQL))
+		foreignKeyDDL = append(foreignKeyDDL, fkSQL)
+	}
+	return foreignKeyDDL
+}
+
 func (d *DDL) String() string {
 	jsonBytes, _ := json.Marshal(d)
 	return string(jsonBytes)
@@ -29,6 +29,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -53,9 +54,11 @@ func NewReverse(ctx context.Context, cfg *config.Config) (*Reverse, error) {
 	if err != nil {
 		return nil, err
 	}
+	mysqlDB.Audit = meta.NewDDLAudit(ctx, metaDB, cfg.AppConfig.TaskID, cfg.DBTypeT, cfg.TaskMode, cfg.AppConfig.DDLAuditFile)
 	if cfg.ReverseConfig.DirectWrite {
-		createSchema := fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, cfg.SchemaConfig.TargetSchema)
+		createSchema := fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, common.QuoteMySQLIdentifier(cfg.SchemaConfig.TargetSchema))
 		_, err = mysqlDB.MySQLDB.ExecContext(ctx, createSchema)
+		mysqlDB.AuditDDL(createSchema, err)
 		if err != nil {
 			return nil, fmt.Errorf("error on exec target database sql [%v]: %v", createSchema, err)
 		}
@@ -145,16 +148,21 @@ func (r *Reverse) Reverse() error {
 	// 获取规则
 	ruleTime := time.Now()
 	tableNameRuleMap, tableColumnRuleMap, tableDefaultRuleSourceMap, tableDefaultRuleMap, err := IChanger(&public.Change{
-		Ctx:              r.Ctx,
-		DBTypeS:          r.Cfg.DBTypeS,
-		DBTypeT:          r.Cfg.DBTypeT,
-		SourceSchemaName: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-		TargetSchemaName: common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-		SourceTables:     exporterTables,
-		OracleCollation:  oracleCollation,
-		Threads:          r.Cfg.ReverseConfig.ReverseThreads,
-		Oracle:           r.Oracle,
-		MetaDB:           r.MetaDB,
+		Ctx:                     r.Ctx,
+		DBTypeS:                 r.Cfg.DBTypeS,
+		DBTypeT:                 r.Cfg.DBTypeT,
+		SourceSchemaName:        common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+		TargetSchemaName:        common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
+		SourceTables:            exporterTables,
+		OracleCollation:         oracleCollation,
+		Threads:                 r.Cfg.ReverseConfig.ReverseThreads,
+		NumberUnconstrainedType: r.Cfg.ReverseConfig.NumberUnconstrainedType,
+		NumberSampleCheck:       r.Cfg.ReverseConfig.NumberSampleCheck,
+		NumberSamplePercent:     r.Cfg.ReverseConfig.NumberSamplePercent,
+		IntervalMode:            r.Cfg.ReverseConfig.IntervalMode,
+		RawMode:                 r.Cfg.ReverseConfig.RawMode,
+		Oracle:                  r.Oracle,
+		MetaDB:                  r.MetaDB,
 	})
 	if err != nil {
 		return err
@@ -169,6 +177,12 @@ func (r *Reverse) Reverse() error {
 		return err
 	}
 
+	// 依据外键依赖关系排序，被引用表优先
+	tables, err = SortReverseTableTask(r.Oracle, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), tables)
+	if err != nil {
+		return err
+	}
+
 	// file writer
 	err = common.PathExist(r.Cfg.ReverseConfig.DDLReverseDir)
 	if err != nil {
@@ -193,12 +207,40 @@ func (r *Reverse) Reverse() error {
 		return err
 	}
 
+	// 物化视图 view 模式转换视图，skip 模式输出不兼容项
+	var viewMaterializedViews, skipMaterializedViews []string
+	for _, mv := range materializedView {
+		if r.Cfg.GetMaterializedViewMode(mv) == common.ReverseMaterializedViewModeView {
+			viewMaterializedViews = append(viewMaterializedViews, mv)
+		} else {
+			skipMaterializedViews = append(skipMaterializedViews, mv)
+		}
+	}
+
 	// 表类型不兼容项输出
-	err = GenCompatibilityTable(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), partitionTables, temporaryTables, clusteredTables, materializedView)
+	err = GenCompatibilityTable(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), partitionTables, temporaryTables, clusteredTables, skipMaterializedViews)
+	if err != nil {
+		return err
+	}
+
+	// 标识符长度以及大小写冲突提示
+	lowerCaseTableNames, err := r.Mysql.GetMySQLDBLowerCaseTableNames()
+	if err != nil {
+		return err
+	}
+	err = GenCompatibilityIdentifier(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.ReverseConfig.LowerCaseFieldName, lowerCaseTableNames, exporterTables)
 	if err != nil {
 		return err
 	}
 
+	// 序列转换 AUTO_INCREMENT 记录
+	var sequenceMutex sync.Mutex
+	autoIncrementSequences := make(map[string][]string)
+
+	// 外键待全部表创建完成之后按依赖顺序输出
+	var foreignKeyMutex sync.Mutex
+	foreignKeys := make(map[string][]string)
+
 	// 表转换
 	g := &errgroup.Group{}
 	g.SetLimit(r.Cfg.ReverseConfig.ReverseThreads)
@@ -255,6 +297,12 @@ func (r *Reverse) Reverse() error {
 				return nil
 			}
 
+			if ddl.AutoIncrementSequence != "" {
+				sequenceMutex.Lock()
+				autoIncrementSequences[ddl.AutoIncrementSequence] = append(autoIncrementSequences[ddl.AutoIncrementSequence], ddl.TargetTableName)
+				sequenceMutex.Unlock()
+			}
+
 			errSql, errw := IWriter(f, ddl)
 			if errw != nil {
 				if errm := meta.NewErrorLogDetailModel(r.MetaDB).CreateErrorLog(r.Ctx, &meta.ErrorLogDetail{
@@ -282,6 +330,12 @@ func (r *Reverse) Reverse() error {
 				return nil
 			}
 
+			if fks := ddl.GenForeignKeyDDL(); len(fks) > 0 {
+				foreignKeyMutex.Lock()
+				foreignKeys[t.SourceTableName] = fks
+				foreignKeyMutex.Unlock()
+			}
+
 			return nil
 		})
 	}
@@ -290,6 +344,74 @@ func (r *Reverse) Reverse() error {
 		return err
 	}
 
+	// 外键按表依赖顺序输出
+	var deferForeignKeys []string
+	for _, t := range tables {
+		fks, ok := foreignKeys[t.SourceTableName]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(r.Cfg.ReverseConfig.ForeignKeyMode, common.ReverseForeignKeyModeDefer) {
+			deferForeignKeys = append(deferForeignKeys, fks...)
+			continue
+		}
+		errSql, errw := GenForeignKeyTable(f, r.Cfg.ReverseConfig.DirectWrite, fks)
+		if errw != nil {
+			if errm := meta.NewErrorLogDetailModel(r.MetaDB).CreateErrorLog(r.Ctx, &meta.ErrorLogDetail{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: t.SourceSchemaName,
+				TableNameS:  t.SourceTableName,
+				SchemaNameT: t.TargetSchemaName,
+				TableNameT:  t.TargetTableName,
+				TaskMode:    r.Cfg.TaskMode,
+				TaskStatus:  "Failed",
+				TargetDDL:   errSql,
+				InfoDetail:  t.String(),
+				ErrorDetail: errw.Error(),
+			}); errm != nil {
+				return fmt.Errorf("writer table foreign key failed, detail see [error_log_detail], please rerunning, error: %v", errm)
+			}
+		}
+	}
+	if len(deferForeignKeys) > 0 {
+		err = GenDeferForeignKey(filepath.Join(r.Cfg.ReverseConfig.DDLReverseDir, fmt.Sprintf("foreign_key_%s.sql", r.Cfg.SchemaConfig.SourceSchema)),
+			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), deferForeignKeys)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 物化视图转换视图，先于普通视图创建
+	if len(viewMaterializedViews) > 0 {
+		err = GenCreateMaterializedView(f, r.Cfg.ReverseConfig.LowerCaseFieldName,
+			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), viewMaterializedViews, r.Cfg.ReverseConfig.DirectWrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 视图转换
+	if r.Cfg.ReverseConfig.ViewConvert {
+		err = GenCreateView(f, r.Cfg.ReverseConfig.LowerCaseFieldName,
+			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), r.Cfg.ReverseConfig.DirectWrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 序列转换报告输出
+	if r.Cfg.ReverseConfig.SequenceAutoIncrement {
+		sequences, err := r.Oracle.GetOracleSchemaSequence(common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema))
+		if err != nil {
+			return err
+		}
+		err = GenCompatibilitySequence(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), sequences, autoIncrementSequences)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = f.Close()
 	if err != nil {
 		return err
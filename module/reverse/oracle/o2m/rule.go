@@ -19,27 +19,35 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/public"
 	"go.uber.org/zap"
 	"regexp"
 	"strings"
 )
 
+var virtualColumnIdentifierReg = regexp.MustCompile("`[^`]+`")
+
 type Rule struct {
 	*Table
 	*Info
 }
 
 type Info struct {
-	SourceTableDDL    string              `json:"-"` // 忽略
-	PrimaryKeyINFO    []map[string]string `json:"primary_key_info"`
-	UniqueKeyINFO     []map[string]string `json:"unique_key_info"`
-	ForeignKeyINFO    []map[string]string `json:"foreign_key_info"`
-	CheckKeyINFO      []map[string]string `json:"check_key_info"`
-	UniqueIndexINFO   []map[string]string `json:"unique_index_info"`
-	NormalIndexINFO   []map[string]string `json:"normal_index_info"`
-	TableCommentINFO  []map[string]string `json:"table_comment_info"`
-	TableColumnINFO   []map[string]string `json:"table_column_info"`
-	ColumnCommentINFO []map[string]string `json:"column_comment_info"`
+	SourceTableDDL      string              `json:"-"` // 忽略
+	PrimaryKeyINFO      []map[string]string `json:"primary_key_info"`
+	UniqueKeyINFO       []map[string]string `json:"unique_key_info"`
+	ForeignKeyINFO      []map[string]string `json:"foreign_key_info"`
+	CheckKeyINFO        []map[string]string `json:"check_key_info"`
+	UniqueIndexINFO     []map[string]string `json:"unique_index_info"`
+	NormalIndexINFO     []map[string]string `json:"normal_index_info"`
+	TableCommentINFO    []map[string]string `json:"table_comment_info"`
+	TableColumnINFO     []map[string]string `json:"table_column_info"`
+	ColumnCommentINFO   []map[string]string `json:"column_comment_info"`
+	VirtualColumnINFO   []string            `json:"virtual_column_info"`
+	IdentityColumnINFO  []map[string]string `json:"identity_column_info"`
+	SkipColumnINFO      []map[string]string `json:"skip_column_info"`
+	PartitionINFO       []map[string]string `json:"partition_info"`
+	TriggerSequenceINFO []map[string]string `json:"trigger_sequence_info"`
 }
 
 func (r *Rule) GenCreateTableDDL() (interface{}, error) {
@@ -54,6 +62,11 @@ func (r *Rule) GenCreateTableDDL() (interface{}, error) {
 		return nil, err
 	}
 
+	_, autoIncrementSequence, autoIncrementStart := r.GenTableAutoIncrement()
+	if autoIncrementSequence != "" {
+		tableSuffix = fmt.Sprintf("%s AUTO_INCREMENT=%s", tableSuffix, autoIncrementStart)
+	}
+
 	tableColumns, err := r.GenTableColumn()
 	if err != nil {
 		return nil, err
@@ -63,6 +76,8 @@ func (r *Rule) GenCreateTableDDL() (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	compatibleDDL = append(compatibleDDL, r.GenTableVirtualColumnCompatibleDDL()...)
+	compatibleDDL = append(compatibleDDL, r.GenTableSkipColumnCompatibleDDL()...)
 
 	tablePrefix = fmt.Sprintf("CREATE TABLE `%s`.`%s`", targetSchema, targetTable)
 
@@ -81,22 +96,29 @@ func (r *Rule) GenCreateTableDDL() (interface{}, error) {
 		return nil, err
 	}
 
+	tablePartition, err := r.GenTablePartition(foreignKeys)
+	if err != nil {
+		return nil, err
+	}
+
 	return &DDL{
-		SourceSchemaName:   r.SourceSchemaName,
-		SourceTableName:    r.SourceTableName,
-		SourceTableType:    r.SourceTableType,
-		SourceTableDDL:     r.SourceTableDDL,
-		TargetSchemaName:   r.GenSchemaName(), // change schema name
-		TargetTableName:    r.GenTableName(),  // change table name
-		TargetDBVersion:    r.TargetDBVersion,
-		TablePrefix:        tablePrefix,
-		TableColumns:       tableColumns,
-		TableKeys:          tableKeys,
-		TableSuffix:        tableSuffix,
-		TableComment:       tableComment,
-		TableCheckKeys:     checkKeys,
-		TableForeignKeys:   foreignKeys,
-		TableCompatibleDDL: compatibleDDL,
+		SourceSchemaName:      r.SourceSchemaName,
+		SourceTableName:       r.SourceTableName,
+		SourceTableType:       r.SourceTableType,
+		SourceTableDDL:        r.SourceTableDDL,
+		TargetSchemaName:      r.GenSchemaName(), // change schema name
+		TargetTableName:       r.GenTableName(),  // change table name
+		TargetDBVersion:       r.TargetDBVersion,
+		TablePrefix:           tablePrefix,
+		TableColumns:          tableColumns,
+		TableKeys:             tableKeys,
+		TableSuffix:           tableSuffix,
+		TableComment:          tableComment,
+		TableCheckKeys:        checkKeys,
+		TableForeignKeys:      foreignKeys,
+		TableCompatibleDDL:    compatibleDDL,
+		TablePartition:        tablePartition,
+		AutoIncrementSequence: autoIncrementSequence,
 	}, nil
 }
 
@@ -226,7 +248,7 @@ func (r *Rule) GenTablePrimaryKey() (primaryKeys []string, err error) {
 			columnList = r.PrimaryKeyINFO[0]["COLUMN_LIST"]
 		}
 		for _, col := range strings.Split(columnList, ",") {
-			primaryColumns = append(primaryColumns, fmt.Sprintf("`%s`", col))
+			primaryColumns = append(primaryColumns, fmt.Sprintf("`%s`", r.GenColumnName(col)))
 		}
 		pk := fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryColumns, ","))
 		primaryKeys = append(primaryKeys, pk)
@@ -252,7 +274,7 @@ func (r *Rule) GenTableUniqueKey() (uniqueKeys []string, err error) {
 				columnList = rowUKCol["COLUMN_LIST"]
 			}
 			for _, col := range strings.Split(columnList, ",") {
-				ukArr = append(ukArr, fmt.Sprintf("`%s`", col))
+				ukArr = append(ukArr, fmt.Sprintf("`%s`", r.GenColumnName(col)))
 			}
 			uk := fmt.Sprintf("UNIQUE KEY `%s` (%s)",
 				rowUKCol["CONSTRAINT_NAME"], strings.Join(ukArr, ","))
@@ -292,6 +314,21 @@ func (r *Rule) GenTableForeignKey() (foreignKeys []string, err error) {
 				rColumnList = rowFKCol["RCOLUMN_LIST"]
 			}
 
+			// 引用同 schema 表，被引用表 schema 转换为目标 schema
+			if strings.EqualFold(rowFKCol["R_OWNER"], r.SourceSchemaName) {
+				rOwner = r.GenSchemaName()
+			}
+
+			var fkColumns, rFKColumns []string
+			for _, col := range strings.Split(columnList, ",") {
+				fkColumns = append(fkColumns, fmt.Sprintf("`%s`", r.GenColumnName(col)))
+			}
+			for _, col := range strings.Split(rColumnList, ",") {
+				rFKColumns = append(rFKColumns, fmt.Sprintf("`%s`", col))
+			}
+			columnList = strings.Join(fkColumns, ",")
+			rColumnList = strings.Join(rFKColumns, ",")
+
 			if rowFKCol["DELETE_RULE"] == "" || rowFKCol["DELETE_RULE"] == "NO ACTION" {
 				fk = fmt.Sprintf("CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s`.`%s` (%s)",
 					rowFKCol["CONSTRAINT_NAME"],
@@ -442,7 +479,7 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexes []string, compatibilityIndex
 				case "NORMAL":
 					var uniqueIndex []string
 					for _, col := range strings.Split(columnList, ",") {
-						uniqueIndex = append(uniqueIndex, fmt.Sprintf("`%s`", col))
+						uniqueIndex = append(uniqueIndex, fmt.Sprintf("`%s`", r.GenColumnName(col)))
 					}
 
 					uniqueIDX := fmt.Sprintf("UNIQUE INDEX `%s` (%s)", idxMeta["INDEX_NAME"], strings.Join(uniqueIndex, ","))
@@ -464,17 +501,9 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexes []string, compatibilityIndex
 						idxMeta["INDEX_NAME"], r.GenSchemaName(), r.GenTableName(),
 						columnList)
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse unique key",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create unique index sql", sql),
-						zap.String("warn", "mysql not support"))
-
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					uniqueIndexes = append(uniqueIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				case "NORMAL/REV":
@@ -482,17 +511,9 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexes []string, compatibilityIndex
 						idxMeta["INDEX_NAME"], r.GenSchemaName(), r.GenTableName(),
 						columnList)
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse unique key",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create unique index sql", sql),
-						zap.String("warn", "mysql not support"))
-
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					uniqueIndexes = append(uniqueIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				default:
@@ -551,7 +572,7 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 				case "NORMAL":
 					var normalIndex []string
 					for _, col := range strings.Split(columnList, ",") {
-						normalIndex = append(normalIndex, fmt.Sprintf("`%s`", col))
+						normalIndex = append(normalIndex, fmt.Sprintf("`%s`", r.GenColumnName(col)))
 					}
 
 					keyIndex := fmt.Sprintf("KEY `%s` (%s)", idxMeta["INDEX_NAME"], strings.Join(normalIndex, ","))
@@ -572,16 +593,9 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 						idxMeta["INDEX_NAME"], r.GenSchemaName(), r.GenTableName(),
 						columnList)
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					normalIndexes = append(normalIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				case "BITMAP":
@@ -589,16 +603,9 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 						idxMeta["INDEX_NAME"], r.GenSchemaName(), r.GenTableName(),
 						columnList)
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					normalIndexes = append(normalIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				case "FUNCTION-BASED BITMAP":
@@ -606,16 +613,9 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 						idxMeta["INDEX_NAME"], r.GenSchemaName(), r.GenTableName(),
 						columnList)
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					normalIndexes = append(normalIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				case "DOMAIN":
@@ -626,38 +626,18 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 						itypName,
 						idxMeta["PARAMETERS"])
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("domain owner", idxMeta["ITYP_OWNER"]),
-						zap.String("domain index name", idxMeta["ITYP_NAME"]),
-						zap.String("domain parameters", idxMeta["PARAMETERS"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					normalIndexes = append(normalIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				case "NORMAL/REV":
 					sql := fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s) REVERSE;",
 						idxMeta["INDEX_NAME"], r.GenSchemaName(), r.GenTableName(),
 						columnList)
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("domain owner", idxMeta["ITYP_OWNER"]),
-						zap.String("domain index name", idxMeta["ITYP_NAME"]),
-						zap.String("domain parameters", idxMeta["PARAMETERS"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+					keyIndex, compSQL := r.GenTableCompatibleIndex(idxMeta, columnList, sql)
+					normalIndexes = append(normalIndexes, keyIndex...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				default:
@@ -691,13 +671,92 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 	return normalIndexes, compatibilityIndexSQL, err
 }
 
+// GenTableCompatibleIndex 处理 MySQL/TiDB 不支持的索引类型（函数索引、位图索引、反向键索引、DOMAIN 索引）
+// 依据 index-compatible-mode 转换为下游索引、忽略或者输出 Oracle 原索引语句到不兼容性文件
+func (r *Rule) GenTableCompatibleIndex(idxMeta map[string]string, columnList, compatibleSQL string) (keyIndexes []string, compatibilityIndexSQL []string) {
+	switch strings.ToLower(r.IndexCompatibleMode) {
+	case common.ReverseIndexModeSkip:
+		zap.L().Warn("reverse compatible index",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", idxMeta["TABLE_NAME"]),
+			zap.String("index name", idxMeta["INDEX_NAME"]),
+			zap.String("index type", idxMeta["INDEX_TYPE"]),
+			zap.String("index column list", idxMeta["COLUMN_LIST"]),
+			zap.String("skip index sql", compatibleSQL),
+			zap.String("warn", "index-compatible-mode skip"))
+		return nil, nil
+	case common.ReverseIndexModeConvert:
+		// MySQL InnoDB 支持 FULLTEXT 索引，Oracle Text CONTEXT 索引转换为 FULLTEXT 索引
+		if strings.EqualFold(idxMeta["INDEX_TYPE"], "DOMAIN") && public.IsOracleTextIndex(idxMeta["ITYP_OWNER"], idxMeta["ITYP_NAME"]) {
+			var fulltextIndex []string
+			for _, col := range strings.Split(columnList, ",") {
+				fulltextIndex = append(fulltextIndex, fmt.Sprintf("`%s`", r.GenColumnName(col)))
+			}
+			keyIndex := fmt.Sprintf("FULLTEXT KEY `%s` (%s)", idxMeta["INDEX_NAME"], strings.Join(fulltextIndex, ","))
+
+			zap.L().Warn("reverse compatible index",
+				zap.String("schema", r.SourceSchemaName),
+				zap.String("table", idxMeta["TABLE_NAME"]),
+				zap.String("index name", idxMeta["INDEX_NAME"]),
+				zap.String("index type", idxMeta["INDEX_TYPE"]),
+				zap.String("index column list", idxMeta["COLUMN_LIST"]),
+				zap.String("convert index info", keyIndex),
+				zap.String("warn", "oracle text index convert fulltext index, please check tokenizer and query syntax"))
+			return []string{keyIndex}, nil
+		}
+
+		keyParts, ok := public.GenOracleIndexKeyParts(common.DatabaseTypeMySQL, idxMeta["INDEX_TYPE"], columnList,
+			public.IsSupportExpressionIndex(common.DatabaseTypeMySQL, r.TargetDBVersion), r.GenColumnName)
+		if ok {
+			var keyIndex string
+			if strings.EqualFold(idxMeta["UNIQUENESS"], "UNIQUE") {
+				keyIndex = fmt.Sprintf("UNIQUE INDEX `%s` (%s)", idxMeta["INDEX_NAME"], strings.Join(keyParts, ","))
+			} else {
+				keyIndex = fmt.Sprintf("KEY `%s` (%s)", idxMeta["INDEX_NAME"], strings.Join(keyParts, ","))
+			}
+
+			zap.L().Warn("reverse compatible index",
+				zap.String("schema", r.SourceSchemaName),
+				zap.String("table", idxMeta["TABLE_NAME"]),
+				zap.String("index name", idxMeta["INDEX_NAME"]),
+				zap.String("index type", idxMeta["INDEX_TYPE"]),
+				zap.String("index column list", idxMeta["COLUMN_LIST"]),
+				zap.String("convert index info", keyIndex),
+				zap.String("warn", "index type convert btree index"))
+			return []string{keyIndex}, nil
+		}
+	}
+
+	zap.L().Warn("reverse compatible index",
+		zap.String("schema", r.SourceSchemaName),
+		zap.String("table", idxMeta["TABLE_NAME"]),
+		zap.String("index name", idxMeta["INDEX_NAME"]),
+		zap.String("index type", idxMeta["INDEX_TYPE"]),
+		zap.String("index column list", idxMeta["COLUMN_LIST"]),
+		zap.String("domain owner", idxMeta["ITYP_OWNER"]),
+		zap.String("domain index name", idxMeta["ITYP_NAME"]),
+		zap.String("domain parameters", idxMeta["PARAMETERS"]),
+		zap.String("create index sql", compatibleSQL),
+		zap.String("warn", "mysql not support"))
+	return nil, []string{compatibleSQL}
+}
+
 func (r *Rule) GenTableComment() (tableComment string, err error) {
-	if len(r.TableColumnINFO) > 0 && r.TableCommentINFO[0]["COMMENTS"] != "" {
+	if len(r.TableCommentINFO) > 0 && r.TableCommentINFO[0]["COMMENTS"] != "" {
 		convertUtf8Raw, err := common.CharsetConvert([]byte(r.TableCommentINFO[0]["COMMENTS"]), common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.SourceDBCharset)], common.CharsetUTF8MB4)
 		if err != nil {
 			return tableComment, fmt.Errorf("column [%s] charset convert failed, %v", r.TableCommentINFO[0]["COMMENTS"], err)
 		}
 
+		convertUtf8Raw, isTrunc := common.TruncateComment(convertUtf8Raw, common.MySQLTableCommentMaxLength)
+		if isTrunc {
+			zap.L().Warn("reverse table comment",
+				zap.String("schema", r.SourceSchemaName),
+				zap.String("table", r.SourceTableName),
+				zap.Int("max length", common.MySQLTableCommentMaxLength),
+				zap.String("warn", "table comment too long, truncated"))
+		}
+
 		convertTargetRaw, err := common.CharsetConvert([]byte(common.SpecialLettersUsingMySQL(convertUtf8Raw)), common.CharsetUTF8MB4, common.MigrateMYSQLCompatibleCharsetStringConvertMapping[common.StringUPPER(r.TargetDBCharset)])
 		if err != nil {
 			return tableComment, fmt.Errorf("column [%s] charset convert failed, %v", r.TableCommentINFO[0]["COMMENTS"], err)
@@ -708,6 +767,7 @@ func (r *Rule) GenTableComment() (tableComment string, err error) {
 }
 
 func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
+	autoIncrementColumn, _, _ := r.GenTableAutoIncrement()
 	for _, rowCol := range r.TableColumnINFO {
 		var (
 			columnCollation string
@@ -751,6 +811,16 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 				return tableColumns, fmt.Errorf("column [%s] comments charset convert failed, %v", rowCol["COLUMN_NAME"], err)
 			}
 
+			convertUtf8Raw, isTrunc := common.TruncateComment(convertUtf8Raw, common.MySQLColumnCommentMaxLength)
+			if isTrunc {
+				zap.L().Warn("reverse table column comment",
+					zap.String("schema", r.SourceSchemaName),
+					zap.String("table", r.SourceTableName),
+					zap.String("column", rowCol["COLUMN_NAME"]),
+					zap.Int("max length", common.MySQLColumnCommentMaxLength),
+					zap.String("warn", "column comment too long, truncated"))
+			}
+
 			convertTargetRaw, err := common.CharsetConvert([]byte(common.SpecialLettersUsingMySQL(convertUtf8Raw)), common.CharsetUTF8MB4, common.MigrateMYSQLCompatibleCharsetStringConvertMapping[common.StringUPPER(r.TargetDBCharset)])
 			if err != nil {
 				return tableColumns, fmt.Errorf("column [%s] comments charset convert failed, %v", rowCol["COLUMN_NAME"], err)
@@ -824,12 +894,39 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 		}
 
 		// 字段名大小写
-		columnName := rowCol["COLUMN_NAME"]
-		if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
-			columnName = strings.ToLower(columnName)
+		columnName := r.GenTargetColumnName(rowCol["COLUMN_NAME"])
+
+		// identity 字段未转换 AUTO_INCREMENT，系统序列默认值不迁移
+		if r.IsIdentityColumn(rowCol["COLUMN_NAME"]) && !strings.EqualFold(rowCol["COLUMN_NAME"], autoIncrementColumn) {
+			dataDefault = common.OracleNULLSTRINGTableAttrWithoutNULL
 		}
-		if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameUpperCase) {
-			columnName = strings.ToUpper(columnName)
+
+		// 虚拟列转换生成列，skip 或者表达式无法转换不创建，见不兼容性文件
+		if common.IsContainString(r.VirtualColumnINFO, rowCol["COLUMN_NAME"]) {
+			generatedExpr, reasons := r.GenVirtualColumnExpr(rowCol["DATA_DEFAULT"])
+			if len(reasons) > 0 {
+				continue
+			}
+			if comment != "" {
+				tableColumns = append(tableColumns, fmt.Sprintf("`%s` %s GENERATED ALWAYS AS (%s) VIRTUAL COMMENT %s", columnName, columnType, generatedExpr, comment))
+			} else {
+				tableColumns = append(tableColumns, fmt.Sprintf("`%s` %s GENERATED ALWAYS AS (%s) VIRTUAL", columnName, columnType, generatedExpr))
+			}
+			continue
+		}
+
+		// 序列转换自增列，自增列不支持 DEFAULT
+		if autoIncrementColumn != "" && strings.EqualFold(rowCol["COLUMN_NAME"], autoIncrementColumn) {
+			// identity 字段未指定精度 NUMBER 转换 BIGINT
+			if !isIntegerColumnType(columnType) {
+				columnType = "BIGINT"
+			}
+			if comment != "" {
+				tableColumns = append(tableColumns, fmt.Sprintf("`%s` %s NOT NULL AUTO_INCREMENT COMMENT %s", columnName, columnType, comment))
+			} else {
+				tableColumns = append(tableColumns, fmt.Sprintf("`%s` %s NOT NULL AUTO_INCREMENT", columnName, columnType))
+			}
+			continue
 		}
 
 		if strings.EqualFold(nullable, "NULL") {
@@ -952,6 +1049,300 @@ func (r *Rule) GenTableName() string {
 	return sourceTable
 }
 
+// 单列/多列 RANGE、LIST 分区表转换 RANGE COLUMNS、LIST COLUMNS 分区
+// 子分区、HASH/REFERENCE 等分区类型、分区键不包含于主键唯一键、存在外键以及分区值无法转换的，沿用普通表
+func (r *Rule) GenTablePartition(foreignKeys []string) (string, error) {
+	if len(r.PartitionINFO) == 0 {
+		return "", nil
+	}
+	partitionType := common.StringUPPER(r.PartitionINFO[0]["PARTITIONING_TYPE"])
+	subPartitionType := common.StringUPPER(r.PartitionINFO[0]["SUBPARTITIONING_TYPE"])
+
+	var partitionColumns []string
+	for _, col := range strings.Split(r.PartitionINFO[0]["PARTITION_EXPRESS"], ",") {
+		partitionColumns = append(partitionColumns, common.StringUPPER(col))
+	}
+
+	var keyColumnLists []string
+	for _, pk := range r.PrimaryKeyINFO {
+		keyColumnLists = append(keyColumnLists, pk["COLUMN_LIST"])
+	}
+	for _, uk := range r.UniqueKeyINFO {
+		keyColumnLists = append(keyColumnLists, uk["COLUMN_LIST"])
+	}
+	for _, ui := range r.UniqueIndexINFO {
+		keyColumnLists = append(keyColumnLists, ui["COLUMN_LIST"])
+	}
+
+	var reason string
+	switch {
+	case partitionType != "RANGE" && partitionType != "LIST":
+		reason = fmt.Sprintf("partition type [%s] isn't support", partitionType)
+	case subPartitionType != "" && subPartitionType != "NONE":
+		reason = fmt.Sprintf("subpartition type [%s] isn't support", subPartitionType)
+	case len(foreignKeys) > 0:
+		reason = "partition table foreign key isn't support"
+	}
+	for _, columnList := range keyColumnLists {
+		ok, _ := common.IsSubsetString(strings.Split(common.StringUPPER(columnList), ","), partitionColumns)
+		if !ok {
+			reason = fmt.Sprintf("primary or unique key [%s] isn't contain partition column [%s]", columnList, r.PartitionINFO[0]["PARTITION_EXPRESS"])
+		}
+	}
+
+	var partitions []string
+	for _, p := range r.PartitionINFO {
+		if reason != "" {
+			break
+		}
+		values, err := common.OraclePartitionHighValueToMySQL(p["HIGH_VALUE"])
+		if err != nil {
+			reason = err.Error()
+			break
+		}
+		partitionName := p["PARTITION_NAME"]
+		if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+			partitionName = strings.ToLower(partitionName)
+		}
+		switch partitionType {
+		case "RANGE":
+			partitions = append(partitions, fmt.Sprintf("PARTITION `%s` VALUES LESS THAN (%s)", partitionName, values))
+		case "LIST":
+			if strings.EqualFold(values, "DEFAULT") {
+				reason = "list partition default value isn't support"
+				break
+			}
+			partitions = append(partitions, fmt.Sprintf("PARTITION `%s` VALUES IN (%s)", partitionName, values))
+		}
+	}
+
+	if reason != "" {
+		zap.L().Warn("reverse oracle partition table",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("partition type", partitionType),
+			zap.String("subpartition type", subPartitionType),
+			zap.String("warn", reason),
+			zap.String("suggest", "convert to normal table, please manual process"))
+		return "", nil
+	}
+
+	var columns []string
+	for _, col := range partitionColumns {
+		if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+			col = strings.ToLower(col)
+		}
+		columns = append(columns, fmt.Sprintf("`%s`", r.GenColumnName(col)))
+	}
+
+	tablePartition := fmt.Sprintf("PARTITION BY %s COLUMNS(%s) (\n%s\n)", partitionType, strings.Join(columns, ","), strings.Join(partitions, ",\n"))
+
+	zap.L().Info("reverse oracle partition table",
+		zap.String("schema", r.SourceSchemaName),
+		zap.String("table", r.SourceTableName),
+		zap.String("partition", tablePartition))
+
+	return tablePartition, nil
+}
+
+// 序列转换 AUTO_INCREMENT，返回自增列、序列名以及自增起始值
+// 支持字段 DEFAULT seq.NEXTVAL 以及 INSERT 触发器引用序列（单列主键），要求单列整型主键、同 schema 序列且步长为 1
+// 12c identity 字段优先转换，不受 sequence-auto-increment 限制
+func (r *Rule) GenTableAutoIncrement() (string, string, string) {
+	if len(r.IdentityColumnINFO) > 0 {
+		return r.GenTableIdentityAutoIncrement()
+	}
+	if !r.SequenceAutoIncrement || len(r.PrimaryKeyINFO) != 1 {
+		return "", "", ""
+	}
+	primaryColumns := strings.Split(r.PrimaryKeyINFO[0]["COLUMN_LIST"], ",")
+	if len(primaryColumns) != 1 {
+		return "", "", ""
+	}
+	primaryColumn := common.StringUPPER(primaryColumns[0])
+
+	var sequenceName string
+	nextvalReg := regexp.MustCompile(`(?i)^\s*(?:"?(\w+)"?\s*\.\s*)?"?(\w+)"?\s*\.\s*NEXTVAL\s*$`)
+	for _, rowCol := range r.TableColumnINFO {
+		if !strings.EqualFold(rowCol["COLUMN_NAME"], primaryColumn) {
+			continue
+		}
+		matches := nextvalReg.FindStringSubmatch(rowCol["DATA_DEFAULT"])
+		if len(matches) == 3 && (matches[1] == "" || strings.EqualFold(matches[1], r.SourceSchemaName)) {
+			sequenceName = common.StringUPPER(matches[2])
+		}
+	}
+	if sequenceName == "" && len(r.TriggerSequenceINFO) == 1 {
+		sequenceName = common.StringUPPER(r.TriggerSequenceINFO[0]["SEQUENCE_NAME"])
+	}
+	if sequenceName == "" {
+		return "", "", ""
+	}
+
+	var reason string
+	seq, ok := r.SourceSequences[sequenceName]
+	switch {
+	case !ok:
+		reason = "sequence isn't exist in the source schema"
+	case seq["INCREMENT_BY"] != "1":
+		reason = fmt.Sprintf("sequence increment_by [%s] isn't 1", seq["INCREMENT_BY"])
+	}
+	if reason == "" {
+		reason = fmt.Sprintf("primary key column [%s] datatype isn't integer", primaryColumn)
+		if isIntegerColumnType(r.TableColumnDatatypeRule[primaryColumn]) {
+			reason = ""
+		}
+	}
+	if reason != "" {
+		zap.L().Warn("reverse oracle sequence auto_increment",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("column", primaryColumn),
+			zap.String("sequence", sequenceName),
+			zap.String("warn", reason),
+			zap.String("suggest", "sequence can't convert auto_increment, please manual process"))
+		return "", "", ""
+	}
+	return primaryColumn, sequenceName, seq["LAST_NUMBER"]
+}
+
+// identity 字段转换 AUTO_INCREMENT，返回自增列、identity 系统序列名以及自增起始值
+// 要求 identity 字段为单列主键或者单列唯一约束、步长为 1 且整型或者整数 NUMBER（转换 BIGINT）
+func (r *Rule) GenTableIdentityAutoIncrement() (string, string, string) {
+	identity := r.IdentityColumnINFO[0]
+	column := common.StringUPPER(identity["COLUMN_NAME"])
+
+	isKey := false
+	for _, k := range append(append([]map[string]string{}, r.PrimaryKeyINFO...), r.UniqueKeyINFO...) {
+		if strings.EqualFold(k["COLUMN_LIST"], column) {
+			isKey = true
+		}
+	}
+	isInteger := isIntegerColumnType(r.TableColumnDatatypeRule[column])
+	for _, rowCol := range r.TableColumnINFO {
+		if strings.EqualFold(rowCol["COLUMN_NAME"], column) && strings.EqualFold(rowCol["DATA_TYPE"], "NUMBER") &&
+			(rowCol["DATA_SCALE"] == "0" || rowCol["DATA_SCALE"] == "127") {
+			isInteger = true
+		}
+	}
+
+	var reason string
+	switch {
+	case !isKey:
+		reason = "identity column isn't single column primary key or unique key"
+	case identity["INCREMENT_BY"] != "1":
+		reason = fmt.Sprintf("identity increment_by [%s] isn't 1", identity["INCREMENT_BY"])
+	case !isInteger:
+		reason = fmt.Sprintf("identity column [%s] datatype isn't integer", column)
+	}
+	if reason != "" {
+		zap.L().Warn("reverse oracle identity auto_increment",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("column", column),
+			zap.String("generation type", identity["GENERATION_TYPE"]),
+			zap.String("warn", reason),
+			zap.String("suggest", "identity column can't convert auto_increment, please manual process"))
+		return "", "", ""
+	}
+	return column, common.StringUPPER(identity["SEQUENCE_NAME"]), identity["LAST_NUMBER"]
+}
+
+// 是否 identity 字段
+func (r *Rule) IsIdentityColumn(columnName string) bool {
+	for _, c := range r.IdentityColumnINFO {
+		if strings.EqualFold(c["COLUMN_NAME"], columnName) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIntegerColumnType(columnType string) bool {
+	for _, integerType := range []string{"TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT"} {
+		if strings.HasPrefix(common.StringUPPER(columnType), integerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// 下游字段名，字段名大小写规则以及字段名自定义规则
+func (r *Rule) GenTargetColumnName(columnName string) string {
+	if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+		columnName = strings.ToLower(columnName)
+	}
+	if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameUpperCase) {
+		columnName = strings.ToUpper(columnName)
+	}
+	return r.GenColumnName(columnName)
+}
+
+// 虚拟列表达式转换生成列表达式，返回无法转换原因，virtual-column-mode skip 不转换
+func (r *Rule) GenVirtualColumnExpr(dataDefault string) (string, []string) {
+	expr, reasons := public.TranslateOracleViewSQL(dataDefault, r.SourceSchemaName, "")
+	if len(reasons) > 0 {
+		return expr, reasons
+	}
+	// 表达式引用字段按下游字段名转换
+	expr = virtualColumnIdentifierReg.ReplaceAllStringFunc(expr, func(s string) string {
+		return fmt.Sprintf("`%s`", r.GenTargetColumnName(strings.Trim(s, "`")))
+	})
+	if strings.EqualFold(r.VirtualColumnMode, common.ReverseVirtualColumnModeSkip) {
+		return expr, []string{"virtual-column-mode skip"}
+	}
+	return expr, nil
+}
+
+// 虚拟列不创建输出不兼容性语句，数据抽取阶段排除虚拟列，需人工处理
+func (r *Rule) GenTableVirtualColumnCompatibleDDL() []string {
+	var compatibleDDL []string
+	for _, rowCol := range r.TableColumnINFO {
+		if !common.IsContainString(r.VirtualColumnINFO, rowCol["COLUMN_NAME"]) {
+			continue
+		}
+		expr, reasons := r.GenVirtualColumnExpr(rowCol["DATA_DEFAULT"])
+		if len(reasons) == 0 {
+			continue
+		}
+		zap.L().Warn("reverse oracle table virtual column",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("column", rowCol["COLUMN_NAME"]),
+			zap.String("expression", rowCol["DATA_DEFAULT"]),
+			zap.Strings("reason", reasons),
+			zap.String("suggest", "virtual column isn't created, please manual process"))
+		compatibleDDL = append(compatibleDDL, fmt.Sprintf("-- oracle table %s.%s virtual column [%s] isn't created, reason: %s\nALTER TABLE `%s`.`%s` ADD COLUMN `%s` %s GENERATED ALWAYS AS (%s) VIRTUAL;",
+			r.SourceSchemaName, r.SourceTableName, rowCol["COLUMN_NAME"], strings.Join(reasons, ","),
+			r.GenSchemaName(), r.GenTableName(), r.GenTargetColumnName(rowCol["COLUMN_NAME"]), r.TableColumnDatatypeRule[rowCol["COLUMN_NAME"]], expr))
+	}
+	return compatibleDDL
+}
+
+// GenTableSkipColumnCompatibleDDL BFILE 以及 rowid-mode = skip ROWID/UROWID 字段不创建，数据不迁移
+func (r *Rule) GenTableSkipColumnCompatibleDDL() []string {
+	var compatibleDDL []string
+	for _, rowCol := range r.SkipColumnINFO {
+		zap.L().Warn("reverse oracle table unsupported datatype column",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("column", rowCol["COLUMN_NAME"]),
+			zap.String("datatype", rowCol["DATA_TYPE"]),
+			zap.String("suggest", "column isn't created and data isn't migrated, please manual process"))
+		compatibleDDL = append(compatibleDDL, fmt.Sprintf("-- oracle table %s.%s column [%s] datatype [%s] isn't created and data isn't migrated, please manual process",
+			r.SourceSchemaName, r.SourceTableName, rowCol["COLUMN_NAME"], rowCol["DATA_TYPE"]))
+	}
+	return compatibleDDL
+}
+
+// 字段名自定义规则，不存在则沿用字段名大小写规则
+func (r *Rule) GenColumnName(columnName string) string {
+	if val, ok := r.TableColumnNameRule[common.StringUPPER(columnName)]; ok {
+		return val
+	}
+	return columnName
+}
+
 func (r *Rule) String() string {
 	jsonStr, _ := json.Marshal(r)
 	return string(jsonStr)
@@ -21,14 +21,18 @@ import (
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
 	"github.com/wentaojin/transferdb/module/reverse"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type Table struct {
@@ -47,11 +51,20 @@ type Table struct {
 	SourceDBNLSSort       string          `json:"sourcedb_nlssort"`
 	SourceDBNLSComp       string          `json:"sourcedb_nlscomp"`
 	SourceTableType       string          `json:"source_table_type"`
+	SourceDBVersion       string          `json:"sourcedb_version"`
+	VirtualColumnMode     string          `json:"virtual_column_mode"`
 	LowerCaseFieldName    string          `json:"lower_case_field_name"`
-
-	TableColumnDatatypeRule         map[string]string `json:"table_column_datatype_rule"`
-	TableColumnDefaultValRule       map[string]string `json:"table_column_default_val_rule"`
-	TableColumnDefaultValSourceRule map[string]bool   `json:"table_column_default_val_source_rule"` // 判断表字段 defaultVal 来源于 database or custom
+	IndexCompatibleMode   string          `json:"index_compatible_mode"`
+
+	TableColumnDatatypeRule         map[string]string            `json:"table_column_datatype_rule"`
+	TableColumnDefaultValRule       map[string]string            `json:"table_column_default_val_rule"`
+	TableColumnDefaultValSourceRule map[string]bool              `json:"table_column_default_val_source_rule"` // 判断表字段 defaultVal 来源于 database or custom
+	TableColumnNameRule             map[string]string            `json:"table_column_name_rule"`
+	PartitionTable                  bool                         `json:"partition_table"`
+	SequenceAutoIncrement           bool                         `json:"sequence_auto_increment"`
+	SourceSequences                 map[string]map[string]string `json:"-"`
+	ColumnProjection                *config.ColumnProjection     `json:"-"`                     // 字段投影，nil 代表全部字段
+	SkipColumnDatatypes             []string                     `json:"skip_column_datatypes"` // 不创建字段类型，BFILE 以及 rowid-mode = skip ROWID/UROWID
 
 	Overwrite bool           `json:"overwrite"`
 	Oracle    *oracle.Oracle `json:"-"`
@@ -111,6 +124,18 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 	if err != nil {
 		return tables, err
 	}
+
+	// 序列转换 AUTO_INCREMENT
+	sequencesMap := make(map[string]map[string]string)
+	if r.Cfg.ReverseConfig.SequenceAutoIncrement {
+		sequences, err := r.Oracle.GetOracleSchemaSequence(common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema))
+		if err != nil {
+			return tables, err
+		}
+		for _, seq := range sequences {
+			sequencesMap[common.StringUPPER(seq["SEQUENCE_NAME"])] = seq
+		}
+	}
 	endTime = time.Now()
 	zap.L().Info("get oracle table type finished",
 		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
@@ -152,6 +177,21 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 					targetTableName = common.StringUPPER(t)
 				}
 
+				// 字段名规则
+				columnNameRules, err := meta.NewColumnNameRuleModel(r.MetaDB).DetailColumnNameRule(r.Ctx, &meta.ColumnNameRule{
+					DBTypeS:     r.Cfg.DBTypeS,
+					DBTypeT:     r.Cfg.DBTypeT,
+					SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+					TableNameS:  t,
+				})
+				if err != nil {
+					return err
+				}
+				columnNameRule := make(map[string]string)
+				for _, cr := range columnNameRules {
+					columnNameRule[common.StringUPPER(cr.ColumnNameS)] = cr.ColumnNameT
+				}
+
 				tbl := &Table{
 					Ctx:                             r.Ctx,
 					SourceSchemaName:                common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
@@ -161,14 +201,23 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 					TargetTableName:                 targetTableName,
 					TargetTableOption:               common.StringUPPER(r.Cfg.MySQLConfig.TableOption),
 					SourceTableType:                 tablesMap[t],
+					SourceDBVersion:                 oracleDBVersion,
+					VirtualColumnMode:               r.Cfg.ReverseConfig.VirtualColumnMode,
 					SourceDBCharset:                 oracleDBCharset,
 					TargetDBCharset:                 targetDBCharset,
 					SourceDBNLSSort:                 nlsSort,
 					SourceDBNLSComp:                 nlsComp,
 					LowerCaseFieldName:              lowerCaseFieldName,
+					IndexCompatibleMode:             r.Cfg.ReverseConfig.IndexCompatibleMode,
 					TableColumnDatatypeRule:         tableColumnRule[common.StringUPPER(t)],
 					TableColumnDefaultValRule:       tableDefaultRule[common.StringUPPER(t)],
 					TableColumnDefaultValSourceRule: tableDefaultSourceRule[common.StringUPPER(t)],
+					TableColumnNameRule:             columnNameRule,
+					PartitionTable:                  r.Cfg.ReverseConfig.PartitionTable,
+					SequenceAutoIncrement:           r.Cfg.ReverseConfig.SequenceAutoIncrement,
+					SourceSequences:                 sequencesMap,
+					ColumnProjection:                r.Cfg.SchemaConfig.GetColumnProjection(t),
+					SkipColumnDatatypes:             r.Cfg.ReverseConfig.SkipColumnDatatypes(),
 					Overwrite:                       r.Cfg.MySQLConfig.Overwrite,
 					Oracle:                          r.Oracle,
 					MySQL:                           r.Mysql,
@@ -252,6 +301,35 @@ func (t *Table) GetTableColumnComment() ([]map[string]string, error) {
 	return t.Oracle.GetOracleSchemaTableColumnComment(t.SourceSchemaName, t.SourceTableName)
 }
 
+func (t *Table) GetTableVirtualColumn() ([]string, error) {
+	// 虚拟列，转换生成列或者不创建
+	return t.Oracle.GetOracleSchemaTableVirtualColumn(t.SourceSchemaName, t.SourceTableName)
+}
+
+func (t *Table) GetTableIdentityColumn() ([]map[string]string, error) {
+	// identity 字段转换 AUTO_INCREMENT，only oracle 12c 及以上获取
+	if common.VersionOrdinal(t.SourceDBVersion) < common.VersionOrdinal(common.OracleIdentityColumnDBVersion) {
+		return nil, nil
+	}
+	return t.Oracle.GetOracleSchemaTableIdentityColumn(t.SourceSchemaName, t.SourceTableName)
+}
+
+func (t *Table) GetTablePartition() ([]map[string]string, error) {
+	// 分区信息，only partition-table 开启且分区表获取
+	if !t.PartitionTable || !strings.EqualFold(t.SourceTableType, "PARTITIONED") {
+		return nil, nil
+	}
+	return t.Oracle.GetOracleSchemaTablePartition(t.SourceSchemaName, t.SourceTableName)
+}
+
+func (t *Table) GetTableTriggerSequence() ([]map[string]string, error) {
+	// 触发器引用序列，only sequence-auto-increment 开启获取
+	if !t.SequenceAutoIncrement {
+		return nil, nil
+	}
+	return t.Oracle.GetOracleSchemaTableTriggerSequence(t.SourceSchemaName, t.SourceTableName)
+}
+
 func (t *Table) GetTableInfo() (interface{}, error) {
 	primaryKey, err := t.GetTablePrimaryKey()
 	if err != nil {
@@ -292,22 +370,78 @@ func (t *Table) GetTableInfo() (interface{}, error) {
 		return nil, err
 	}
 
+	virtualColumn, err := t.GetTableVirtualColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	identityColumn, err := t.GetTableIdentityColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	partition, err := t.GetTablePartition()
+	if err != nil {
+		return nil, err
+	}
+
+	triggerSequence, err := t.GetTableTriggerSequence()
+	if err != nil {
+		return nil, err
+	}
+
 	ddl, err := t.GetTableOriginDDL()
 	if err != nil {
 		return nil, err
 	}
 
+	// BFILE 以及 rowid-mode = skip ROWID/UROWID 字段不创建，输出到不兼容性文件
+	var (
+		skipColumn      []map[string]string
+		skipColumnNames []string
+	)
+	for _, c := range columnMeta {
+		if common.IsContainString(t.SkipColumnDatatypes, common.StringUPPER(c["DATA_TYPE"])) && t.ColumnProjection.IsProjected(c["COLUMN_NAME"]) {
+			skipColumn = append(skipColumn, c)
+			skipColumnNames = append(skipColumnNames, c["COLUMN_NAME"])
+		}
+	}
+	t.ColumnProjection = t.ColumnProjection.Exclude(skipColumnNames...)
+
+	// 字段投影，排除字段不迁移，引用排除字段的约束以及索引一并过滤
+	if t.ColumnProjection != nil {
+		var columnNames []string
+		for _, c := range columnMeta {
+			columnNames = append(columnNames, c["COLUMN_NAME"])
+		}
+		columnMeta = t.ColumnProjection.ProjectColumnINFO(columnMeta)
+		columnComment = t.ColumnProjection.ProjectColumnINFO(columnComment)
+		primaryKey = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "primary key", "COLUMN_LIST", t.ColumnProjection, primaryKey, columnNames)
+		uniqueKey = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "unique key", "COLUMN_LIST", t.ColumnProjection, uniqueKey, columnNames)
+		foreignKey = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "foreign key", "COLUMN_LIST", t.ColumnProjection, foreignKey, columnNames)
+		checkKey = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "check key", "SEARCH_CONDITION", t.ColumnProjection, checkKey, columnNames)
+		uniqueIndex = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "unique index", "COLUMN_LIST", t.ColumnProjection, uniqueIndex, columnNames)
+		normalIndex = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "normal index", "COLUMN_LIST", t.ColumnProjection, normalIndex, columnNames)
+		virtualColumn = t.ColumnProjection.ProjectColumnNames(virtualColumn)
+		identityColumn = t.ColumnProjection.ProjectColumnINFO(identityColumn)
+	}
+
 	return &Info{
-		SourceTableDDL:    ddl,
-		PrimaryKeyINFO:    primaryKey,
-		UniqueKeyINFO:     uniqueKey,
-		ForeignKeyINFO:    foreignKey,
-		CheckKeyINFO:      checkKey,
-		UniqueIndexINFO:   uniqueIndex,
-		NormalIndexINFO:   normalIndex,
-		TableCommentINFO:  tableComment,
-		TableColumnINFO:   columnMeta,
-		ColumnCommentINFO: columnComment,
+		SourceTableDDL:      ddl,
+		PrimaryKeyINFO:      primaryKey,
+		UniqueKeyINFO:       uniqueKey,
+		ForeignKeyINFO:      foreignKey,
+		CheckKeyINFO:        checkKey,
+		UniqueIndexINFO:     uniqueIndex,
+		NormalIndexINFO:     normalIndex,
+		TableCommentINFO:    tableComment,
+		TableColumnINFO:     columnMeta,
+		ColumnCommentINFO:   columnComment,
+		VirtualColumnINFO:   virtualColumn,
+		IdentityColumnINFO:  identityColumn,
+		SkipColumnINFO:      skipColumn,
+		PartitionINFO:       partition,
+		TriggerSequenceINFO: triggerSequence,
 	}, nil
 }
 
@@ -384,13 +518,13 @@ func GenCreateSchema(w *reverse.Write, lowerCaseFieldName, sourceSchema, targetS
 		if !ok {
 			return fmt.Errorf("oracle schema collation [%s] isn't support", schemaCollation)
 		}
-		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;\n\n", targetSchema, targetDBCharset, targetSchemaCollation))
+		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` DEFAULT CHARACTER SET %s COLLATE %s;\n\n", targetSchema, targetDBCharset, targetSchemaCollation))
 	} else {
 		targetSchemaCollation, ok := common.MigrateTableStructureDatabaseCollationMap[common.TaskTypeOracle2MySQL][common.StringUPPER(nlsComp)][targetDBCharset]
 		if !ok {
 			return fmt.Errorf("oracle db nls_comp collation [%s] isn't support", nlsComp)
 		}
-		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;\n\n", targetSchema, targetDBCharset, targetSchemaCollation))
+		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` DEFAULT CHARACTER SET %s COLLATE %s;\n\n", targetSchema, targetDBCharset, targetSchemaCollation))
 	}
 
 	if directWrite {
@@ -481,3 +615,374 @@ func GenCompatibilityTable(f *reverse.Write, sourceSchema string, partitionTable
 
 	return nil
 }
+
+// GenCompatibilityIdentifier 标识符兼容提示
+// 1、表名、字段名、索引以及约束名超过 mysql 64 字符限制输出重命名建议
+// 2、lower-case-field-name 大小写转换或者下游 lower_case_table_names 非 0 按小写存储后表名冲突输出提示
+func GenCompatibilityIdentifier(f *reverse.Write, sourceSchema, lowerCaseFieldName, lowerCaseTableNames string, exporters []string) error {
+	startTime := time.Now()
+
+	if lowerCaseTableNames != common.MySQLLowerCaseTableNamesCaseSensitive && !strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+		zap.L().Warn("mysql lower_case_table_names isn't 0, schema and table name will be stored in lowercase",
+			zap.String("schema", sourceSchema),
+			zap.String("lower_case_table_names", lowerCaseTableNames),
+			zap.String("lower-case-field-name", lowerCaseFieldName),
+			zap.String("suggest", "config lower-case-field-name = 1"))
+	}
+
+	longIdentifiers, err := f.Oracle.GetOracleSchemaLongIdentifier(sourceSchema, common.MySQLIdentifierMaxLength)
+	if err != nil {
+		return err
+	}
+
+	exporterMap := make(map[string]struct{}, len(exporters))
+	for _, t := range exporters {
+		exporterMap[common.StringUPPER(t)] = struct{}{}
+	}
+
+	var rows []table.Row
+	for _, l := range longIdentifiers {
+		if _, ok := exporterMap[common.StringUPPER(l["TABLE_NAME"])]; !ok {
+			continue
+		}
+		rows = append(rows, table.Row{l["OBJECT_TYPE"], l["TABLE_NAME"], l["OBJECT_NAME"], utf8.RuneCountInString(l["OBJECT_NAME"]),
+			fmt.Sprintf("Rename To %s", common.MySQLIdentifierRenameSuggest(l["OBJECT_NAME"]))})
+	}
+
+	// 表名大小写转换后冲突，例如 ORACLE 双引号表名 "Emp" 与 EMP
+	targetTables := make(map[string][]string, len(exporters))
+	var targetNames []string
+	for _, t := range exporters {
+		name := t
+		if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) || lowerCaseTableNames != common.MySQLLowerCaseTableNamesCaseSensitive {
+			name = strings.ToLower(t)
+		}
+		if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameUpperCase) && lowerCaseTableNames == common.MySQLLowerCaseTableNamesCaseSensitive {
+			name = strings.ToUpper(t)
+		}
+		if _, ok := targetTables[name]; !ok {
+			targetNames = append(targetNames, name)
+		}
+		targetTables[name] = append(targetTables[name], t)
+	}
+	for _, name := range targetNames {
+		if len(targetTables[name]) > 1 {
+			rows = append(rows, table.Row{"TABLE", strings.Join(targetTables[name], ","), name, utf8.RuneCountInString(name), "Target Table Name Conflict, Manual Rename"})
+		}
+	}
+
+	if len(rows) > 0 {
+		var sqlComp strings.Builder
+
+		sqlComp.WriteString("/*\n")
+		sqlComp.WriteString(" oracle identifier maybe mysql has compatibility, exceed 64 characters or case conflict, please manual rename\n")
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"OBJECT TYPE", "TABLE NAME", "OBJECT NAME", "LENGTH", "SUGGEST"})
+		t.AppendRows(rows)
+		sqlComp.WriteString(t.Render() + "\n")
+		sqlComp.WriteString("*/\n")
+
+		if _, err = f.CWriteFile(sqlComp.String()); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("output oracle to mysql identifier compatibility tips",
+		zap.String("schema", sourceSchema),
+		zap.Int("identifier counts", len(rows)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+
+	return nil
+}
+
+func GenCompatibilitySequence(f *reverse.Write, sourceSchema string, sequences []map[string]string, autoIncrementSequences map[string][]string) error {
+	startTime := time.Now()
+	if len(sequences) > 0 {
+		var sqlComp strings.Builder
+
+		sqlComp.WriteString("/*\n")
+		sqlComp.WriteString(" oracle sequence, only single integer primary key sequence convert to mysql auto_increment, others please manual process\n")
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"SCHEMA", "SEQUENCE NAME", "INCREMENT BY", "LAST NUMBER", "SUGGEST"})
+
+		for _, seq := range sequences {
+			if tables, ok := autoIncrementSequences[common.StringUPPER(seq["SEQUENCE_NAME"])]; ok {
+				t.AppendRows([]table.Row{
+					{sourceSchema, seq["SEQUENCE_NAME"], seq["INCREMENT_BY"], seq["LAST_NUMBER"], fmt.Sprintf("Convert Table %v AUTO_INCREMENT", tables)},
+				})
+			} else {
+				t.AppendRows([]table.Row{
+					{sourceSchema, seq["SEQUENCE_NAME"], seq["INCREMENT_BY"], seq["LAST_NUMBER"], "Manual Process Sequence"},
+				})
+			}
+		}
+		sqlComp.WriteString(t.Render() + "\n")
+		sqlComp.WriteString("*/\n")
+
+		if _, err := f.CWriteFile(sqlComp.String()); err != nil {
+			return err
+		}
+	}
+	endTime := time.Now()
+	zap.L().Info("output oracle to mysql sequence compatibility tips",
+		zap.String("schema", sourceSchema),
+		zap.String("cost", endTime.Sub(startTime).String()))
+
+	return nil
+}
+
+// SortReverseTableTask 依据外键依赖关系排序 reverse 表任务，被引用表优先
+func SortReverseTableTask(oracle *oracle.Oracle, sourceSchema string, tables []*Table) ([]*Table, error) {
+	dependencies, err := oracle.GetOracleSchemaForeignKeyDependency(sourceSchema)
+	if err != nil {
+		return tables, err
+	}
+
+	var tableNames []string
+	tableMap := make(map[string]*Table, len(tables))
+	for _, t := range tables {
+		tableNames = append(tableNames, t.SourceTableName)
+		tableMap[t.SourceTableName] = t
+	}
+
+	sortedTables, cycleTables := public.SortTableByForeignKey(tableNames, dependencies)
+	if len(cycleTables) > 0 {
+		zap.L().Warn("reverse table foreign key cycle dependency",
+			zap.String("schema", sourceSchema),
+			zap.Strings("tables", cycleTables))
+	}
+
+	var sortTables []*Table
+	for _, t := range sortedTables {
+		sortTables = append(sortTables, tableMap[t])
+	}
+	return sortTables, nil
+}
+
+// GenForeignKeyTable 全部表创建完成之后输出外键，direct-write 直接下游执行，否则写入 reverse 文件
+func GenForeignKeyTable(f *reverse.Write, directWrite bool, foreignKeys []string) (string, error) {
+	if directWrite {
+		for _, fk := range foreignKeys {
+			if err := f.RWriteDB(fk); err != nil {
+				return fk, err
+			}
+		}
+		return "", nil
+	}
+
+	fkSQL := strings.Join(foreignKeys, "\n") + "\n"
+	if _, err := f.RWriteFile(fkSQL); err != nil {
+		return fkSQL, err
+	}
+	return "", nil
+}
+
+// GenDeferForeignKey foreign-key-mode = defer 外键按依赖顺序写入单独文件，待数据迁移完成之后执行
+func GenDeferForeignKey(fkFile, sourceSchema string, foreignKeys []string) error {
+	startTime := time.Now()
+
+	var sqlFK strings.Builder
+	sqlFK.WriteString("/*\n")
+	sqlFK.WriteString(" oracle table foreign key, defer create foreign key after data migrate\n")
+	sqlFK.WriteString("*/\n")
+	sqlFK.WriteString(strings.Join(foreignKeys, "\n") + "\n")
+
+	if err := os.WriteFile(fkFile, []byte(sqlFK.String()), 0666); err != nil {
+		return err
+	}
+
+	zap.L().Info("output oracle to mysql defer foreign key",
+		zap.String("schema", sourceSchema),
+		zap.String("foreign key output", fkFile),
+		zap.Int("foreign key counts", len(foreignKeys)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// GenCreateView view-convert 视图定义方言转换，可转换视图按依赖顺序 direct-write 直接下游执行或者写入 reverse 文件
+// 存在无法自动转换语法或者下游执行失败的视图输出到不兼容性文件，需人工审核
+// GenCreateMaterializedView view 模式物化视图定义查询转换 mysql 视图，无法自动转换输出到不兼容性文件
+func GenCreateMaterializedView(w *reverse.Write, lowerCaseFieldName, sourceSchema, targetSchema string, materializedViews []string, directWrite bool) error {
+	startTime := time.Now()
+
+	mviews, err := w.Oracle.GetOracleSchemaMaterializedViewQuery(sourceSchema)
+	if err != nil {
+		return err
+	}
+	mviewMap := make(map[string]string, len(mviews))
+	for _, v := range mviews {
+		mviewMap[common.StringUPPER(v["MVIEW_NAME"])] = v["QUERY"]
+	}
+
+	if targetSchema == "" {
+		targetSchema = sourceSchema
+	}
+	// 库名大小写
+	if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+		targetSchema = strings.ToLower(targetSchema)
+	}
+	if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameUpperCase) {
+		targetSchema = strings.ToUpper(targetSchema)
+	}
+
+	var (
+		sqlRev      strings.Builder
+		sqlComp     strings.Builder
+		convertRows []table.Row
+		manualRows  []table.Row
+	)
+	for _, v := range materializedViews {
+		query, ok := mviewMap[common.StringUPPER(v)]
+		if !ok {
+			return fmt.Errorf("oracle schema [%s] materialized view [%s] query isn't exist", sourceSchema, v)
+		}
+		viewName := v
+		if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+			viewName = strings.ToLower(v)
+		}
+		viewSQL, reasons := public.TranslateOracleViewSQL(query, sourceSchema, targetSchema)
+		createSQL := fmt.Sprintf("CREATE OR REPLACE VIEW `%s`.`%s` AS\n%s;", targetSchema, viewName, viewSQL)
+
+		if len(reasons) == 0 && directWrite {
+			if errw := w.RWriteDB(createSQL); errw != nil {
+				reasons = append(reasons, errw.Error())
+			}
+		}
+
+		if len(reasons) > 0 {
+			zap.L().Warn("reverse oracle materialized view",
+				zap.String("schema", sourceSchema),
+				zap.String("materialized view", v),
+				zap.Strings("manual review", reasons))
+			manualRows = append(manualRows, table.Row{"MATERIALIZED VIEW", fmt.Sprintf("%s.%s", sourceSchema, v), fmt.Sprintf("%s.%s", targetSchema, viewName), strings.Join(reasons, "; ")})
+			sqlComp.WriteString(createSQL + "\n\n")
+			continue
+		}
+		convertRows = append(convertRows, table.Row{"MATERIALIZED VIEW", fmt.Sprintf("%s.%s", sourceSchema, v), fmt.Sprintf("%s.%s", targetSchema, viewName), "Create View"})
+		sqlRev.WriteString(createSQL + "\n\n")
+	}
+
+	if !directWrite && len(convertRows) > 0 {
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"#", "ORACLE", "MYSQL", "SUGGEST"})
+		t.AppendRows(convertRows)
+		if _, err = w.RWriteFile(fmt.Sprintf("/*\n oracle materialized view reverse mysql view sql, dialect translation best-effort, data isn't migrated, please check\n%s\n*/\n%s", t.Render(), sqlRev.String())); err != nil {
+			return err
+		}
+	}
+	if len(manualRows) > 0 {
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"#", "ORACLE", "MYSQL", "MANUAL REVIEW"})
+		t.AppendRows(manualRows)
+		if _, err = w.CWriteFile(fmt.Sprintf("/*\n oracle materialized view maybe mysql has compatibility, please manual process\n%s\n*/\n%s", t.Render(), sqlComp.String())); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("output oracle to mysql materialized view create sql",
+		zap.String("schema", sourceSchema),
+		zap.Int("materialized view totals", len(materializedViews)),
+		zap.Int("materialized view convert", len(convertRows)),
+		zap.Int("materialized view manual review", len(manualRows)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func GenCreateView(w *reverse.Write, lowerCaseFieldName, sourceSchema, targetSchema string, directWrite bool) error {
+	startTime := time.Now()
+
+	views, err := w.Oracle.GetOracleSchemaView(sourceSchema)
+	if err != nil {
+		return err
+	}
+	if len(views) == 0 {
+		return nil
+	}
+	dependencies, err := w.Oracle.GetOracleSchemaViewDependency(sourceSchema)
+	if err != nil {
+		return err
+	}
+
+	if targetSchema == "" {
+		targetSchema = sourceSchema
+	}
+	// 库名大小写
+	if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+		targetSchema = strings.ToLower(targetSchema)
+	}
+	if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameUpperCase) {
+		targetSchema = strings.ToUpper(targetSchema)
+	}
+
+	var viewNames []string
+	viewMap := make(map[string]string, len(views))
+	for _, v := range views {
+		viewNames = append(viewNames, v["VIEW_NAME"])
+		viewMap[v["VIEW_NAME"]] = v["TEXT"]
+	}
+	sortedViews, _ := public.SortTableByForeignKey(viewNames, dependencies)
+
+	var (
+		sqlRev      strings.Builder
+		sqlComp     strings.Builder
+		convertRows []table.Row
+		manualRows  []table.Row
+	)
+	for _, v := range sortedViews {
+		viewName := v
+		if strings.EqualFold(lowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
+			viewName = strings.ToLower(v)
+		}
+		viewSQL, reasons := public.TranslateOracleViewSQL(viewMap[v], sourceSchema, targetSchema)
+		createSQL := fmt.Sprintf("CREATE OR REPLACE VIEW `%s`.`%s` AS\n%s;", targetSchema, viewName, viewSQL)
+
+		if len(reasons) == 0 && directWrite {
+			if errw := w.RWriteDB(createSQL); errw != nil {
+				reasons = append(reasons, errw.Error())
+			}
+		}
+
+		if len(reasons) > 0 {
+			zap.L().Warn("reverse oracle view",
+				zap.String("schema", sourceSchema),
+				zap.String("view", v),
+				zap.Strings("manual review", reasons))
+			manualRows = append(manualRows, table.Row{"VIEW", fmt.Sprintf("%s.%s", sourceSchema, v), fmt.Sprintf("%s.%s", targetSchema, viewName), strings.Join(reasons, "; ")})
+			sqlComp.WriteString(createSQL + "\n\n")
+			continue
+		}
+		convertRows = append(convertRows, table.Row{"VIEW", fmt.Sprintf("%s.%s", sourceSchema, v), fmt.Sprintf("%s.%s", targetSchema, viewName), "Create View"})
+		sqlRev.WriteString(createSQL + "\n\n")
+	}
+
+	if !directWrite && len(convertRows) > 0 {
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"#", "ORACLE", "MYSQL", "SUGGEST"})
+		t.AppendRows(convertRows)
+		if _, err = w.RWriteFile(fmt.Sprintf("/*\n oracle view reverse sql, dialect translation best-effort, please check\n%s\n*/\n%s", t.Render(), sqlRev.String())); err != nil {
+			return err
+		}
+	}
+	if len(manualRows) > 0 {
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"#", "ORACLE", "MYSQL", "MANUAL REVIEW"})
+		t.AppendRows(manualRows)
+		if _, err = w.CWriteFile(fmt.Sprintf("/*\n oracle view maybe mysql has compatibility, please manual process\n%s\n*/\n%s", t.Render(), sqlComp.String())); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("output oracle to mysql view create sql",
+		zap.String("schema", sourceSchema),
+		zap.Int("view totals", len(views)),
+		zap.Int("view convert", len(convertRows)),
+		zap.Int("view manual review", len(manualRows)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/public"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+)
+
+var (
+	oracleDatetimeDefaultReg = regexp.MustCompile(`(?i)^(SYSDATE|SYSTIMESTAMP|CURRENT_DATE|CURRENT_TIMESTAMP)(\(\d*\))?$`)
+	constantDefaultReg       = regexp.MustCompile(`^'.*'$|^-?\d+(\.\d+)?$`)
+)
+
+type Table struct {
+	SourceSchemaName   string         `json:"source_schema_name"`
+	SourceTableName    string         `json:"source_table_name"`
+	TargetSchemaName   string         `json:"target_schema_name"`
+	TargetTableName    string         `json:"target_table_name"`
+	LowerCaseFieldName string         `json:"lower_case_field_name"`
+	Oracle             *oracle.Oracle `json:"-"`
+
+	ColumnProjection *config.ColumnProjection `json:"-"` // 字段投影，nil 代表全部字段
+}
+
+// GenCreateTableDDL 生成 postgresql 建表语句、索引以及注释语句，不兼容项返回 compatibleDDL
+func (t *Table) GenCreateTableDDL() ([]string, []string, error) {
+	var (
+		ddl, compatibleDDL, columnMetas, tableKeys []string
+	)
+	columns, err := t.Oracle.GetOracleSchemaTableColumn(t.SourceSchemaName, t.SourceTableName, false)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	var columnNames []string
+	for _, c := range columns {
+		columnNames = append(columnNames, c["COLUMN_NAME"])
+	}
+	columns = t.ColumnProjection.ProjectColumnINFO(columns)
+	for _, c := range columns {
+		originColumnType, buildInColumnType, err := public.OracleTableColumnMapPostgreSQLRule(t.SourceSchemaName, t.SourceTableName, public.Column{
+			DataType:   c["DATA_TYPE"],
+			CharLength: c["CHAR_LENGTH"],
+			CharUsed:   c["CHAR_USED"],
+			ColumnInfo: public.ColumnInfo{
+				DataLength:    c["DATA_LENGTH"],
+				DataPrecision: c["DATA_PRECISION"],
+				DataScale:     c["DATA_SCALE"],
+				NULLABLE:      c["NULLABLE"],
+				DataDefault:   c["DATA_DEFAULT"],
+				Comment:       c["COMMENTS"],
+			},
+		})
+		if err != nil {
+			return ddl, compatibleDDL, err
+		}
+		zap.L().Debug("reverse oracle table column datatype",
+			zap.String("schema", t.SourceSchemaName),
+			zap.String("table", t.SourceTableName),
+			zap.String("column", c["COLUMN_NAME"]),
+			zap.String("origin datatype", originColumnType),
+			zap.String("postgresql datatype", buildInColumnType))
+
+		columnMeta := fmt.Sprintf("%s %s", t.quoteName(c["COLUMN_NAME"]), buildInColumnType)
+		if dataDefault, ok := t.genColumnDefault(c["COLUMN_NAME"], c["DATA_DEFAULT"]); ok {
+			columnMeta = fmt.Sprintf("%s DEFAULT %s", columnMeta, dataDefault)
+		}
+		if strings.EqualFold(c["NULLABLE"], "N") {
+			columnMeta = fmt.Sprintf("%s NOT NULL", columnMeta)
+		}
+		columnMetas = append(columnMetas, columnMeta)
+	}
+
+	primaryKeys, err := t.Oracle.GetOracleSchemaTablePrimaryKey(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	primaryKeys = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "primary key", "COLUMN_LIST", t.ColumnProjection, primaryKeys, columnNames)
+	for _, pk := range primaryKeys {
+		tableKeys = append(tableKeys, fmt.Sprintf("PRIMARY KEY (%s)", t.quoteColumnList(pk["COLUMN_LIST"])))
+	}
+	uniqueKeys, err := t.Oracle.GetOracleSchemaTableUniqueKey(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	uniqueKeys = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "unique key", "COLUMN_LIST", t.ColumnProjection, uniqueKeys, columnNames)
+	for _, uk := range uniqueKeys {
+		tableKeys = append(tableKeys, fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)", t.quoteName(uk["CONSTRAINT_NAME"]), t.quoteColumnList(uk["COLUMN_LIST"])))
+	}
+
+	targetTable := fmt.Sprintf("%s.%s", common.QuotePostgreSQLIdentifier(t.TargetSchemaName), common.QuotePostgreSQLIdentifier(t.TargetTableName))
+	ddl = append(ddl, fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", targetTable, strings.Join(append(columnMetas, tableKeys...), ",\n    ")))
+
+	// 索引，postgresql 索引名 schema 内唯一
+	uniqueIndexes, err := t.Oracle.GetOracleSchemaTableUniqueIndex(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	normalIndexes, err := t.Oracle.GetOracleSchemaTableNormalIndex(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	uniqueIndexes = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "unique index", "COLUMN_LIST", t.ColumnProjection, uniqueIndexes, columnNames)
+	normalIndexes = public.FilterProjectionTableKey(t.SourceSchemaName, t.SourceTableName, "normal index", "COLUMN_LIST", t.ColumnProjection, normalIndexes, columnNames)
+	for _, idx := range append(uniqueIndexes, normalIndexes...) {
+		createIndex := "CREATE INDEX"
+		if strings.EqualFold(idx["UNIQUENESS"], "UNIQUE") {
+			createIndex = "CREATE UNIQUE INDEX"
+		}
+		switch common.StringUPPER(idx["INDEX_TYPE"]) {
+		case "NORMAL", "BITMAP":
+			ddl = append(ddl, fmt.Sprintf("%s %s ON %s (%s)", createIndex, t.quoteName(idx["INDEX_NAME"]), targetTable, t.quoteColumnList(idx["COLUMN_LIST"])))
+		default:
+			// 函数索引等表达式需人工确认 postgresql 兼容性
+			compatibleDDL = append(compatibleDDL, fmt.Sprintf("-- oracle table %s.%s index [%s] type [%s] maybe incompatible, please manual process\n%s %s ON %s (%s);",
+				t.SourceSchemaName, t.SourceTableName, idx["INDEX_NAME"], idx["INDEX_TYPE"], createIndex, t.quoteName(idx["INDEX_NAME"]), targetTable, idx["COLUMN_LIST"]))
+		}
+	}
+
+	// 注释
+	tableComments, err := t.Oracle.GetOracleSchemaTableComment(t.SourceSchemaName, t.SourceTableName)
+	if err != nil {
+		return ddl, compatibleDDL, err
+	}
+	if len(tableComments) > 0 && tableComments[0]["COMMENTS"] != "" {
+		ddl = append(ddl, fmt.Sprintf("COMMENT ON TABLE %s IS '%s'", targetTable, strings.ReplaceAll(tableComments[0]["COMMENTS"], "'", "''")))
+	}
+	for _, c := range columns {
+		if c["COMMENTS"] != "" {
+			ddl = append(ddl, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS '%s'", targetTable, t.quoteName(c["COLUMN_NAME"]), strings.ReplaceAll(c["COMMENTS"], "'", "''")))
+		}
+	}
+	return ddl, compatibleDDL, nil
+}
+
+// 字段默认值转换，oracle 时间函数转换 CURRENT_TIMESTAMP，序列以及其他函数默认值不转换输出告警
+func (t *Table) genColumnDefault(columnName, dataDefault string) (string, bool) {
+	dataDefault = strings.TrimSpace(dataDefault)
+	switch {
+	case strings.EqualFold(dataDefault, common.OracleNULLSTRINGTableAttrWithoutNULL) || strings.EqualFold(dataDefault, "NULL") || dataDefault == "":
+		return "", false
+	case oracleDatetimeDefaultReg.MatchString(dataDefault):
+		return "CURRENT_TIMESTAMP", true
+	case constantDefaultReg.MatchString(dataDefault):
+		return dataDefault, true
+	default:
+		zap.L().Warn("reverse oracle table column default value",
+			zap.String("schema", t.SourceSchemaName),
+			zap.String("table", t.SourceTableName),
+			zap.String("column", columnName),
+			zap.String("default", dataDefault),
+			zap.String("suggest", "default value can't convert postgresql, would be disabled, please manual process"))
+		return "", false
+	}
+}
+
+func (t *Table) quoteName(name string) string {
+	return common.QuotePostgreSQLIdentifier(common.PostgreSQLIdentifierCase(t.LowerCaseFieldName, name))
+}
+
+func (t *Table) quoteColumnList(columnList string) string {
+	var columns []string
+	for _, c := range strings.Split(columnList, ",") {
+		columns = append(columns, t.quoteName(strings.TrimSpace(c)))
+	}
+	return strings.Join(columns, ",")
+}
+
+func (t *Table) String() string {
+	jsonStr, _ := json.Marshal(t)
+	return string(jsonStr)
+}
@@ -25,21 +25,23 @@ import (
 )
 
 type DDL struct {
-	SourceSchemaName   string   `json:"source_schema"`
-	SourceTableName    string   `json:"source_table_name"`
-	SourceTableType    string   `json:"source_table_type"`
-	SourceTableDDL     string   `json:"-"` // 忽略
-	TargetSchemaName   string   `json:"target_schema"`
-	TargetTableName    string   `json:"target_table_name"`
-	TargetDBVersion    string   `json:"target_db_version"`
-	TablePrefix        string   `json:"table_prefix"`
-	TableColumns       []string `json:"table_columns"`
-	TableKeys          []string `json:"table_keys"`
-	TableSuffix        string   `json:"table_suffix"`
-	TableComment       string   `json:"table_comment"`
-	TableCheckKeys     []string `json:"table_check_keys""`
-	TableForeignKeys   []string `json:"table_foreign_keys"`
-	TableCompatibleDDL []string `json:"table_compatible_ddl"`
+	SourceSchemaName      string   `json:"source_schema"`
+	SourceTableName       string   `json:"source_table_name"`
+	SourceTableType       string   `json:"source_table_type"`
+	SourceTableDDL        string   `json:"-"` // 忽略
+	TargetSchemaName      string   `json:"target_schema"`
+	TargetTableName       string   `json:"target_table_name"`
+	TargetDBVersion       string   `json:"target_db_version"`
+	TablePrefix           string   `json:"table_prefix"`
+	TableColumns          []string `json:"table_columns"`
+	TableKeys             []string `json:"table_keys"`
+	TableSuffix           string   `json:"table_suffix"`
+	TableComment          string   `json:"table_comment"`
+	TableCheckKeys        []string `json:"table_check_keys"`
+	TableForeignKeys      []string `json:"table_foreign_keys"`
+	TableCompatibleDDL    []string `json:"table_compatible_ddl"`
+	TablePartition        string   `json:"table_partition"`
+	AutoIncrementSequence string   `json:"auto_increment_sequence"`
 }
 
 func (d *DDL) Write(w *reverse.Write) (string, error) {
@@ -177,10 +179,15 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
 	}
 
 	if strings.EqualFold(d.TableComment, "") {
-		tableDDL = fmt.Sprintf("%s %s;", structDDL, d.TableSuffix)
+		tableDDL = fmt.Sprintf("%s %s", structDDL, d.TableSuffix)
 	} else {
-		tableDDL = fmt.Sprintf("%s %s %s;", structDDL, d.TableSuffix, d.TableComment)
+		tableDDL = fmt.Sprintf("%s %s %s", structDDL, d.TableSuffix, d.TableComment)
 	}
+	// 分区表
+	if !strings.EqualFold(d.TablePartition, "") {
+		tableDDL = fmt.Sprintf("%s\n%s", tableDDL, d.TablePartition)
+	}
+	tableDDL = tableDDL + ";"
 
 	zap.L().Info("reverse oracle table structure",
 		zap.String("schema", d.TargetSchemaName),
@@ -29,6 +29,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -53,9 +54,11 @@ func NewReverse(ctx context.Context, cfg *config.Config) (*Reverse, error) {
 	if err != nil {
 		return nil, err
 	}
+	mysqlDB.Audit = meta.NewDDLAudit(ctx, metaDB, cfg.AppConfig.TaskID, cfg.DBTypeT, cfg.TaskMode, cfg.AppConfig.DDLAuditFile)
 	if cfg.ReverseConfig.DirectWrite {
-		createSchema := fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, cfg.SchemaConfig.TargetSchema)
+		createSchema := fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, common.QuoteMySQLIdentifier(cfg.SchemaConfig.TargetSchema))
 		_, err = mysqlDB.MySQLDB.ExecContext(ctx, createSchema)
+		mysqlDB.AuditDDL(createSchema, err)
 		if err != nil {
 			return nil, fmt.Errorf("error on exec target database sql [%v]: %v", createSchema, err)
 		}
@@ -74,6 +77,15 @@ func (r *Reverse) Reverse() error {
 	zap.L().Info("reverse table oracle to tidb start",
 		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
 
+	switch common.StringUPPER(r.Cfg.ReverseConfig.TiDBClusteredIndex) {
+	case "", common.TiDBPrimaryKeyClustered, common.TiDBPrimaryKeyNonClustered:
+	default:
+		return fmt.Errorf("reverse config tidb-clustered-index [%s] isn't support, only support [%s/%s] or empty", r.Cfg.ReverseConfig.TiDBClusteredIndex, common.TiDBPrimaryKeyClustered, common.TiDBPrimaryKeyNonClustered)
+	}
+	if r.Cfg.ReverseConfig.TiDBAutoRandomBits <= 0 {
+		r.Cfg.ReverseConfig.TiDBAutoRandomBits = common.DefaultTiDBAutoRandomBits
+	}
+
 	// 获取配置文件待同步表列表
 	exporters, err := public.FilterCFGTable(r.Cfg, r.Oracle)
 	if err != nil {
@@ -144,16 +156,21 @@ func (r *Reverse) Reverse() error {
 	// 获取规则
 	ruleTime := time.Now()
 	tableNameRuleMap, tableColumnRuleMap, tableDefaultRuleSourceMap, tableDefaultRuleMap, err := IChanger(&public.Change{
-		Ctx:              r.Ctx,
-		DBTypeS:          r.Cfg.DBTypeS,
-		DBTypeT:          r.Cfg.DBTypeT,
-		SourceSchemaName: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-		TargetSchemaName: common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-		SourceTables:     exporterTables,
-		OracleCollation:  oracleCollation,
-		Threads:          r.Cfg.ReverseConfig.ReverseThreads,
-		Oracle:           r.Oracle,
-		MetaDB:           r.MetaDB,
+		Ctx:                     r.Ctx,
+		DBTypeS:                 r.Cfg.DBTypeS,
+		DBTypeT:                 r.Cfg.DBTypeT,
+		SourceSchemaName:        common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+		TargetSchemaName:        common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
+		SourceTables:            exporterTables,
+		OracleCollation:         oracleCollation,
+		Threads:                 r.Cfg.ReverseConfig.ReverseThreads,
+		NumberUnconstrainedType: r.Cfg.ReverseConfig.NumberUnconstrainedType,
+		NumberSampleCheck:       r.Cfg.ReverseConfig.NumberSampleCheck,
+		NumberSamplePercent:     r.Cfg.ReverseConfig.NumberSamplePercent,
+		IntervalMode:            r.Cfg.ReverseConfig.IntervalMode,
+		RawMode:                 r.Cfg.ReverseConfig.RawMode,
+		Oracle:                  r.Oracle,
+		MetaDB:                  r.MetaDB,
 	})
 	if err != nil {
 		return err
@@ -168,6 +185,12 @@ func (r *Reverse) Reverse() error {
 		return err
 	}
 
+	// 依据外键依赖关系排序，被引用表优先
+	tables, err = SortReverseTableTask(r.Oracle, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), tables)
+	if err != nil {
+		return err
+	}
+
 	// file writer
 	err = common.PathExist(r.Cfg.ReverseConfig.DDLReverseDir)
 	if err != nil {
@@ -192,12 +215,36 @@ func (r *Reverse) Reverse() error {
 		return err
 	}
 
+	// 物化视图 view 模式转换视图，skip 模式输出不兼容项
+	var viewMaterializedViews, skipMaterializedViews []string
+	for _, mv := range materializedView {
+		if r.Cfg.GetMaterializedViewMode(mv) == common.ReverseMaterializedViewModeView {
+			viewMaterializedViews = append(viewMaterializedViews, mv)
+		} else {
+			skipMaterializedViews = append(skipMaterializedViews, mv)
+		}
+	}
+
 	// 表类型不兼容项输出
-	err = GenCompatibilityTable(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), partitionTables, temporaryTables, clusteredTables, materializedView)
+	err = GenCompatibilityTable(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), partitionTables, temporaryTables, clusteredTables, skipMaterializedViews)
+	if err != nil {
+		return err
+	}
+
+	// 标识符长度以及大小写冲突提示
+	lowerCaseTableNames, err := r.Mysql.GetMySQLDBLowerCaseTableNames()
+	if err != nil {
+		return err
+	}
+	err = GenCompatibilityIdentifier(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.ReverseConfig.LowerCaseFieldName, lowerCaseTableNames, exporterTables)
 	if err != nil {
 		return err
 	}
 
+	// 序列转换 AUTO_INCREMENT 记录
+	var sequenceMutex sync.Mutex
+	autoIncrementSequences := make(map[string][]string)
+
 	// 表转换
 	g := &errgroup.Group{}
 	g.SetLimit(r.Cfg.ReverseConfig.ReverseThreads)
@@ -254,6 +301,12 @@ func (r *Reverse) Reverse() error {
 				return nil
 			}
 
+			if ddl.AutoIncrementSequence != "" {
+				sequenceMutex.Lock()
+				autoIncrementSequences[ddl.AutoIncrementSequence] = append(autoIncrementSequences[ddl.AutoIncrementSequence], ddl.TargetTableName)
+				sequenceMutex.Unlock()
+			}
+
 			errSql, errw := IWriter(f, ddl)
 			if errw != nil {
 				if errm := meta.NewErrorLogDetailModel(r.MetaDB).CreateErrorLog(r.Ctx, &meta.ErrorLogDetail{
@@ -289,6 +342,36 @@ func (r *Reverse) Reverse() error {
 		return err
 	}
 
+	// 物化视图转换视图，先于普通视图创建
+	if len(viewMaterializedViews) > 0 {
+		err = GenCreateMaterializedView(f, r.Cfg.ReverseConfig.LowerCaseFieldName,
+			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), viewMaterializedViews, r.Cfg.ReverseConfig.DirectWrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 视图转换
+	if r.Cfg.ReverseConfig.ViewConvert {
+		err = GenCreateView(f, r.Cfg.ReverseConfig.LowerCaseFieldName,
+			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), r.Cfg.ReverseConfig.DirectWrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 序列转换报告输出
+	if r.Cfg.ReverseConfig.SequenceAutoIncrement {
+		sequences, err := r.Oracle.GetOracleSchemaSequence(common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema))
+		if err != nil {
+			return err
+		}
+		err = GenCompatibilitySequence(f, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), sequences, autoIncrementSequences)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = f.Close()
 	if err != nil {
 		return err
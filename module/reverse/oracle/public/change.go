@@ -35,8 +35,15 @@ type Change struct {
 	SourceTables     []string        `json:"source_tables"`
 	Threads          int             `json:"threads"`
 	OracleCollation  bool            `json:"oracle_collation"`
-	Oracle           *oracle.Oracle  `json:"-"`
-	MetaDB           *meta.Meta      `json:"-"`
+	// 未指定精度 number 映射类型以及数据采样
+	NumberUnconstrainedType string `json:"number_unconstrained_type"`
+	NumberSampleCheck       bool   `json:"number_sample_check"`
+	NumberSamplePercent     int    `json:"number_sample_percent"`
+	// INTERVAL/RAW 字段映射方式
+	IntervalMode string         `json:"interval_mode"`
+	RawMode      string         `json:"raw_mode"`
+	Oracle       *oracle.Oracle `json:"-"`
+	MetaDB       *meta.Meta     `json:"-"`
 }
 
 func (r *Change) ChangeTableName() (map[string]string, error) {
@@ -131,26 +138,6 @@ func (r *Change) ChangeTableColumnDatatype() (map[string]map[string]string, erro
 		return tableDatatypeMap, err
 	}
 
-	// 获取自定义 table 级别数据类型映射规则
-	tableDataTypeMapSlice, err := meta.NewTableDatatypeRuleModel(r.MetaDB).DetailTableRule(r.Ctx, &meta.TableDatatypeRule{
-		DBTypeS:     r.DBTypeS,
-		DBTypeT:     r.DBTypeT,
-		SchemaNameS: r.SourceSchemaName,
-	})
-	if err != nil {
-		return tableDatatypeMap, err
-	}
-
-	// 获取自定义字段数据类型映射规则
-	columnDataTypeMapSlice, err := meta.NewColumnDatatypeRuleModel(r.MetaDB).DetailColumnRule(r.Ctx, &meta.ColumnDatatypeRule{
-		DBTypeS:     r.DBTypeS,
-		DBTypeT:     r.DBTypeT,
-		SchemaNameS: r.SourceSchemaName,
-	})
-	if err != nil {
-		return tableDatatypeMap, err
-	}
-
 	wg := &errgroup.Group{}
 	wg.SetLimit(r.Threads)
 
@@ -177,9 +164,56 @@ func (r *Change) ChangeTableColumnDatatype() (map[string]map[string]string, erro
 				return err
 			}
 
+			// 获取自定义 table 级别数据类型映射规则
+			tableDataTypeMapSlice, err := meta.NewTableDatatypeRuleModel(r.MetaDB).DetailTableRule(r.Ctx, &meta.TableDatatypeRule{
+				DBTypeS:     r.DBTypeS,
+				DBTypeT:     r.DBTypeT,
+				SchemaNameS: r.SourceSchemaName,
+				TableNameS:  sourceTable,
+			})
+			if err != nil {
+				return err
+			}
+
+			// 获取自定义 column 级别数据类型映射规则
+			columnDataTypeMapSlice, err := meta.NewColumnDatatypeRuleModel(r.MetaDB).DetailColumnRuleByTable(r.Ctx, &meta.ColumnDatatypeRule{
+				DBTypeS:     r.DBTypeS,
+				DBTypeT:     r.DBTypeT,
+				SchemaNameS: r.SourceSchemaName,
+				TableNameS:  sourceTable,
+			})
+			if err != nil {
+				return err
+			}
+
 			columnDatatypeMap := make(map[string]string, 1)
 			tableDatatypeTempMap := make(map[string]map[string]string, 1)
 
+			// 未指定精度 number 字段数据采样
+			numberSampleMap := make(map[string]map[string]string)
+			if r.NumberSampleCheck {
+				var numberColumns []string
+				for _, rowCol := range tableColumnINFO {
+					if IsOracleUnconstrainedNumber(rowCol["DATA_TYPE"], rowCol["DATA_PRECISION"], rowCol["DATA_SCALE"]) {
+						numberColumns = append(numberColumns, rowCol["COLUMN_NAME"])
+					}
+				}
+				samplePercent := r.NumberSamplePercent
+				if samplePercent <= 0 {
+					samplePercent = common.ReverseNumberSamplePercent
+				}
+				numberSampleMap, err = r.Oracle.GetOracleTableNumberColumnSample(r.SourceSchemaName, sourceTable, numberColumns, samplePercent)
+				if err != nil {
+					// 采样失败不影响表结构转换，沿用默认映射
+					zap.L().Warn("oracle table number column sample failed, use number-unconstrained-type",
+						zap.String("schema", r.SourceSchemaName),
+						zap.String("table", sourceTable),
+						zap.Strings("columns", numberColumns),
+						zap.Error(err))
+					numberSampleMap = make(map[string]map[string]string)
+				}
+			}
+
 			for _, rowCol := range tableColumnINFO {
 				originColumnType, buildInColumnType, err := OracleTableColumnMapMySQLRule(r.SourceSchemaName, sourceTable, Column{
 					DataType:   rowCol["DATA_TYPE"],
@@ -198,6 +232,32 @@ func (r *Change) ChangeTableColumnDatatype() (map[string]map[string]string, erro
 					return err
 				}
 
+				// interval-mode numeric、raw-mode hex 映射
+				if strategyColumnType := OracleDatatypeStrategyMapMySQLType(rowCol["DATA_TYPE"], rowCol["DATA_LENGTH"], rowCol["DATA_SCALE"], r.IntervalMode, r.RawMode); strategyColumnType != "" {
+					buildInColumnType = strategyColumnType
+				}
+
+				// 未指定精度 number 映射，优先采样推断类型，其次 number-unconstrained-type
+				if IsOracleUnconstrainedNumber(rowCol["DATA_TYPE"], rowCol["DATA_PRECISION"], rowCol["DATA_SCALE"]) {
+					if r.NumberUnconstrainedType != "" {
+						buildInColumnType = common.StringUPPER(r.NumberUnconstrainedType)
+					}
+					if sample, ok := numberSampleMap[rowCol["COLUMN_NAME"]]; ok {
+						sampleColumnType, err := GenOracleNumberSampleColumnType(sample)
+						if err != nil {
+							return err
+						}
+						if sampleColumnType != "" {
+							zap.L().Warn("oracle table number column sample data is integer, change column type",
+								zap.String("schema", r.SourceSchemaName),
+								zap.String("table", sourceTable),
+								zap.String("column", rowCol["COLUMN_NAME"]),
+								zap.String("column type", sampleColumnType))
+							buildInColumnType = sampleColumnType
+						}
+					}
+				}
+
 				// 优先级
 				// column > table > schema > buildin
 				if len(columnDataTypeMapSlice) == 0 {
@@ -54,7 +54,7 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		// 过滤规则加载
 		f, err := filter.Parse(cfg.SchemaConfig.SourceIncludeTable)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("source config params include-table [%v] parse failed: %v", cfg.SchemaConfig.SourceIncludeTable, err)
 		}
 
 		for _, t := range allTables {
@@ -66,7 +66,7 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 		// 过滤规则加载
 		f, err := filter.Parse(cfg.SchemaConfig.SourceExcludeTable)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("source config params exclude-table [%v] parse failed: %v", cfg.SchemaConfig.SourceExcludeTable, err)
 		}
 
 		for _, t := range allTables {
@@ -139,17 +139,13 @@ func FilterOracleCompatibleTable(cfg *config.Config, oracle *oracle.Oracle, expo
 			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
 	}
 
-	var exporterTables []string
+	// table 模式物化视图按普通表转换，view/skip 模式物化视图排除
+	exporterTables, materializedView := cfg.FilterMaterializedViewTable(exporters, materializedView)
 	if len(materializedView) != 0 {
 		zap.L().Warn("materialized views",
 			zap.String("schema", cfg.SchemaConfig.SourceSchema),
 			zap.String("materialized view list", fmt.Sprintf("%v", materializedView)),
-			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
-
-		// 排除物化视图
-		exporterTables = common.FilterDifferenceStringItems(exporters, materializedView)
-	} else {
-		exporterTables = exporters
+			zap.String("suggest", "if necessary, please manually process the tables in the above list or config materialized-view-mode"))
 	}
 	return partitionTables, temporaryTables, clusteredTables, materializedView, exporterTables, nil
 }
@@ -185,3 +181,29 @@ func filterOracleMaterializedView(cfg *config.Config, oracle *oracle.Oracle, exp
 	}
 	return common.FilterIntersectionStringItems(exporters, tables), nil
 }
+
+// FilterProjectionTableKey 字段投影过滤约束以及索引，引用排除字段的约束以及索引不迁移并输出告警
+// columnField 约束以及索引字段列表或检查约束条件，columnNames 为表全部字段
+func FilterProjectionTableKey(schemaName, tableName, keyType, columnField string, projection *config.ColumnProjection, keys []map[string]string, columnNames []string) []map[string]string {
+	if projection == nil {
+		return keys
+	}
+	var projected []map[string]string
+	for _, k := range keys {
+		if projection.IsIndexProjected(k[columnField], columnNames) {
+			projected = append(projected, k)
+			continue
+		}
+		keyName := k["CONSTRAINT_NAME"]
+		if keyName == "" {
+			keyName = k["INDEX_NAME"]
+		}
+		zap.L().Warn("reverse oracle table key reference exclude column, skip",
+			zap.String("schema", schemaName),
+			zap.String("table", tableName),
+			zap.String("key type", keyType),
+			zap.String("key name", keyName),
+			zap.String("key column", k[columnField]))
+	}
+	return projected
+}
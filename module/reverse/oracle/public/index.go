@@ -0,0 +1,170 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"regexp"
+	"strings"
+)
+
+// MySQL 表达式索引允许的函数，仅包含 Oracle 与 MySQL 语义一致的函数
+var mysqlExpressionIndexFuncs = []string{"UPPER", "LOWER", "TRIM", "LTRIM", "RTRIM", "ABS"}
+
+// TiDB 表达式索引允许的函数，参考 tidb_allow_function_for_expression_index
+var tidbExpressionIndexFuncs = []string{"UPPER", "LOWER"}
+
+var (
+	indexPlainColumnReg = regexp.MustCompile(`^"?[A-Za-z_$#][A-Za-z0-9_$#]*"?$`)
+	indexFuncNameReg    = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+)
+
+// IsSupportExpressionIndex 判断下游数据库版本是否支持表达式索引
+// MySQL 8.0.13 及以上支持 functional key parts，TiDB 5.2.0 及以上支持表达式索引
+func IsSupportExpressionIndex(dbTypeT, dbVersion string) bool {
+	switch strings.ToUpper(dbTypeT) {
+	case common.DatabaseTypeMySQL:
+		return common.VersionOrdinal(strings.Split(dbVersion, common.MySQLVersionDelimiter)[0]) >= common.VersionOrdinal(common.MySQLFunctionalIndexVersion)
+	case common.DatabaseTypeTiDB:
+		// TiDB 版本格式 5.7.25-TiDB-v6.5.0
+		idx := strings.Index(dbVersion, common.TiDBVersionPrefix)
+		if idx == -1 {
+			return false
+		}
+		tidbVersion := strings.Split(dbVersion[idx+len(common.TiDBVersionPrefix):], common.MySQLVersionDelimiter)[0]
+		return common.VersionOrdinal(tidbVersion) >= common.VersionOrdinal(common.TiDBExpressionIndexVersion)
+	default:
+		return false
+	}
+}
+
+// GenOracleIndexKeyParts 转换 Oracle 索引字段为 MySQL/TiDB 索引字段
+// BITMAP、NORMAL/REV 索引转换为普通 B-Tree 索引字段，函数索引在下游支持表达式索引且仅使用允许函数时转换为表达式索引字段
+// ok 为 false 代表无法转换
+func GenOracleIndexKeyParts(dbTypeT, indexType, columnList string, expressionIndex bool, genColumnName func(string) string) (keyParts []string, ok bool) {
+	switch strings.ToUpper(indexType) {
+	case "NORMAL", "BITMAP", "NORMAL/REV":
+		for _, col := range strings.Split(columnList, ",") {
+			keyParts = append(keyParts, fmt.Sprintf("`%s`", genColumnName(col)))
+		}
+		return keyParts, true
+	case "FUNCTION-BASED NORMAL", "FUNCTION-BASED BITMAP":
+		for _, expr := range splitOracleIndexExpression(columnList) {
+			// 降序索引 Oracle 同样记录为函数索引，表达式为带双引号字段名
+			if indexPlainColumnReg.MatchString(expr) {
+				keyParts = append(keyParts, fmt.Sprintf("`%s`", genColumnName(strings.Trim(expr, `"`))))
+				continue
+			}
+			if !expressionIndex {
+				return nil, false
+			}
+			mysqlExpr, isConvert := convertOracleIndexExpression(dbTypeT, expr, genColumnName)
+			if !isConvert {
+				return nil, false
+			}
+			keyParts = append(keyParts, fmt.Sprintf("(%s)", mysqlExpr))
+		}
+		return keyParts, true
+	default:
+		return nil, false
+	}
+}
+
+// IsOracleTextIndex 判断 DOMAIN 索引是否为 Oracle Text CTXSYS.CONTEXT 索引，可转换为 MySQL FULLTEXT 索引
+func IsOracleTextIndex(itypOwner, itypName string) bool {
+	return strings.EqualFold(itypOwner, "CTXSYS") && strings.EqualFold(itypName, "CONTEXT")
+}
+
+// convertOracleIndexExpression 转换 Oracle 函数索引表达式，双引号字段名转换为反引号字段名，字符串常量保持不变
+func convertOracleIndexExpression(dbTypeT, expr string, genColumnName func(string) string) (string, bool) {
+	allowFuncs := mysqlExpressionIndexFuncs
+	if strings.EqualFold(dbTypeT, common.DatabaseTypeTiDB) {
+		allowFuncs = tidbExpressionIndexFuncs
+	}
+
+	var (
+		sb      strings.Builder
+		literal strings.Builder
+		inQuote bool
+	)
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case inQuote:
+			sb.WriteByte(c)
+			if c == '\'' {
+				inQuote = false
+			}
+		case c == '\'':
+			// 字符串常量前的片段校验函数
+			if !isAllowIndexFuncs(literal.String(), allowFuncs) {
+				return "", false
+			}
+			literal.Reset()
+			inQuote = true
+			sb.WriteByte(c)
+		case c == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end == -1 {
+				return "", false
+			}
+			sb.WriteString(fmt.Sprintf("`%s`", genColumnName(expr[i+1:i+1+end])))
+			i = i + 1 + end
+		default:
+			literal.WriteByte(c)
+			sb.WriteByte(c)
+		}
+	}
+	if inQuote || !isAllowIndexFuncs(literal.String(), allowFuncs) {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+func isAllowIndexFuncs(s string, allowFuncs []string) bool {
+	for _, m := range indexFuncNameReg.FindAllStringSubmatch(s, -1) {
+		if !common.IsContainString(allowFuncs, strings.ToUpper(m[1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitOracleIndexExpression 按顶层逗号拆分 COLUMN_LIST，忽略函数参数以及字符串常量内逗号
+func splitOracleIndexExpression(columnList string) []string {
+	var (
+		items   []string
+		depth   int
+		inQuote bool
+		start   int
+	)
+	for i := 0; i < len(columnList); i++ {
+		switch c := columnList[i]; {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(columnList[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(columnList[start:]))
+}
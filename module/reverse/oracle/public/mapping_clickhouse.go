@@ -0,0 +1,103 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strconv"
+	"strings"
+)
+
+// OracleTableColumnMapClickHouseRule oracle 字段类型转换 clickhouse 内置规则，返回 oracle 原始字段类型以及内置转换字段类型（不含 Nullable）
+// - number 未指定精度统一转换 Decimal(38,10)，超出 10 位小数截断
+// - clickhouse 无长度限制字符串，字符/大字段/二进制统一 String
+// - 时间类型统一 DateTime64(p, 'UTC')，按源端时间字面值存储，取值范围 1900-01-01 ~ 2299-12-31
+func OracleTableColumnMapClickHouseRule(sourceSchema, sourceTable string, column Column) (string, string, error) {
+	dataPrecision, err := strconv.Atoi(column.DataPrecision)
+	if err != nil {
+		return "", "", fmt.Errorf("oracle schema [%s] table [%s] reverser column data_precision string to int failed: %v", sourceSchema, sourceTable, err)
+	}
+	dataScale, err := strconv.Atoi(column.DataScale)
+	if err != nil {
+		return "", "", fmt.Errorf("oracle schema [%s] table [%s] reverser column data_scale string to int failed: %v", sourceSchema, sourceTable, err)
+	}
+
+	dataType := common.StringUPPER(column.DataType)
+	switch dataType {
+	case common.BuildInOracleDatatypeNumber:
+		originColumnType := fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+		switch {
+		// number / number(*) -> number(38,127)
+		case dataPrecision == 38 && dataScale == 127:
+			return originColumnType, "Decimal(38,10)", nil
+		case dataScale > 0:
+			// number(2,5) 类型 scale 大于 precision
+			if dataScale > dataPrecision {
+				dataPrecision = dataScale
+			}
+			return originColumnType, fmt.Sprintf("Decimal(%d,%d)", dataPrecision, dataScale), nil
+		case dataScale < 0:
+			// 负数 scale 整数位数扩展，clickhouse Decimal 精度最大 76
+			precision := dataPrecision - dataScale
+			if precision > 76 {
+				precision = 76
+			}
+			return originColumnType, fmt.Sprintf("Decimal(%d,0)", precision), nil
+		case dataPrecision < 3:
+			return originColumnType, "Int8", nil
+		case dataPrecision < 5:
+			return originColumnType, "Int16", nil
+		case dataPrecision < 10:
+			return originColumnType, "Int32", nil
+		case dataPrecision < 19:
+			return originColumnType, "Int64", nil
+		default:
+			return originColumnType, fmt.Sprintf("Decimal(%d,0)", dataPrecision), nil
+		}
+	case common.BuildInOracleDatatypeDecimal, common.BuildInOracleDatatypeDec, common.BuildInOracleDatatypeNumeric:
+		return fmt.Sprintf("%s(%d,%d)", dataType, dataPrecision, dataScale), fmt.Sprintf("Decimal(%d,%d)", dataPrecision, dataScale), nil
+	case common.BuildInOracleDatatypeInteger, common.BuildInOracleDatatypeInt, common.BuildInOracleDatatypeSmallint:
+		return dataType, "Decimal(38,0)", nil
+	case common.BuildInOracleDatatypeFloat, common.BuildInOracleDatatypeDoublePrecision, common.BuildInOracleDatatypeBinaryDouble:
+		return dataType, "Float64", nil
+	case common.BuildInOracleDatatypeReal, common.BuildInOracleDatatypeBinaryFloat:
+		return dataType, "Float32", nil
+	case common.BuildInOracleDatatypeChar, common.BuildInOracleDatatypeCharacter, common.BuildInOracleDatatypeNchar,
+		common.BuildInOracleDatatypeVarchar2, common.BuildInOracleDatatypeVarchar, common.BuildInOracleDatatypeNvarchar2, common.BuildInOracleDatatypeNcharVarying,
+		common.BuildInOracleDatatypeClob, common.BuildInOracleDatatypeNclob, common.BuildInOracleDatatypeLong,
+		common.BuildInOracleDatatypeRowid, common.BuildInOracleDatatypeUrowid, common.BuildInOracleDatatypeXmltype:
+		return dataType, "String", nil
+	case common.BuildInOracleDatatypeBlob, common.BuildInOracleDatatypeLongRAW, common.BuildInOracleDatatypeBfile, common.BuildInOracleDatatypeRaw:
+		return dataType, "String", nil
+	case common.BuildInOracleDatatypeDate:
+		return dataType, "DateTime64(0,'UTC')", nil
+	default:
+		switch {
+		case strings.Contains(dataType, "INTERVAL"):
+			return dataType, "String", nil
+		case strings.Contains(dataType, "TIMESTAMP"):
+			// clickhouse DateTime64 精度最大 9
+			datetimePrecision := dataScale
+			if datetimePrecision > 9 {
+				datetimePrecision = 9
+			}
+			return dataType, fmt.Sprintf("DateTime64(%d,'UTC')", datetimePrecision), nil
+		default:
+			return dataType, "", fmt.Errorf("oracle schema [%s] table [%s] column datatype [%s] map clickhouse column type rule isn't exist, please checkin", sourceSchema, sourceTable, dataType)
+		}
+	}
+}
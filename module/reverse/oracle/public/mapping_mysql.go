@@ -44,6 +44,30 @@ type ColumnInfo struct {
 	Comment           string
 }
 
+// OracleDatatypeStrategyMapMySQLType interval-mode numeric、raw-mode hex 字段类型映射，其他字段或者默认方式返回空
+// INTERVAL YEAR TO MONTH 总月数 BIGINT，INTERVAL DAY(9) TO SECOND(s) 总秒数最大 14 位整数 DECIMAL(14+s,s)，RAW(N) 十六进制 VARCHAR(2*N)
+func OracleDatatypeStrategyMapMySQLType(dataType, dataLength, dataScale, intervalMode, rawMode string) string {
+	dataType = common.StringUPPER(dataType)
+	switch {
+	case strings.EqualFold(intervalMode, common.ReverseIntervalModeNumeric) && strings.Contains(dataType, "INTERVAL YEAR"):
+		return "BIGINT"
+	case strings.EqualFold(intervalMode, common.ReverseIntervalModeNumeric) && strings.Contains(dataType, "INTERVAL DAY"):
+		scale, err := strconv.Atoi(dataScale)
+		if err != nil || scale < 0 || scale > 9 {
+			scale = 6
+		}
+		return fmt.Sprintf("DECIMAL(%d,%d)", 14+scale, scale)
+	case strings.EqualFold(rawMode, common.ReverseRawModeHex) && dataType == common.BuildInOracleDatatypeRaw:
+		length, err := strconv.Atoi(dataLength)
+		if err != nil || length <= 0 {
+			length = 2000
+		}
+		return fmt.Sprintf("VARCHAR(%d)", 2*length)
+	default:
+		return ""
+	}
+}
+
 func OracleTableColumnMapMySQLRule(sourceSchema, sourceTable string, column Column, buildinDatatypes []meta.BuildinDatatypeRule) (string, string, error) {
 	var (
 		// oracle 表原始字段类型
@@ -120,47 +144,47 @@ func OracleTableColumnMapMySQLRule(sourceSchema, sourceTable string, column Colu
 						}
 					}
 				}
-			case dataScale == 0:
+			default:
+				// 整数位数，负数 scale 小数点左侧舍入，number(p,-s) 整数位数 p+s
+				// number(p) 取值上限 10^p - 1，按上限选择可完整容纳的最小整数类型
+				// tinyint 127 / smallint 32767 / int 2147483647 / bigint 9223372036854775807
+				integerDigits := dataPrecision - dataScale
+				originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
 				switch {
-				case dataPrecision >= 1 && dataPrecision < 3:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+				case integerDigits >= 1 && integerDigits < 3:
 					if _, ok = numberDatatypeMap["TINYINT"]; ok {
 						buildInColumnType = "TINYINT"
 					} else {
 						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [TINYINT]", originColumnType)
 					}
-				case dataPrecision >= 3 && dataPrecision < 5:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+				case integerDigits >= 3 && integerDigits < 5:
 					if _, ok = numberDatatypeMap["SMALLINT"]; ok {
 						buildInColumnType = "SMALLINT"
 					} else {
 						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [SMALLINT]", originColumnType)
 					}
-				case dataPrecision >= 5 && dataPrecision < 9:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+				case integerDigits >= 5 && integerDigits < 10:
 					if _, ok = numberDatatypeMap["INT"]; ok {
 						buildInColumnType = "INT"
 					} else {
 						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [INT]", originColumnType)
 					}
-				case dataPrecision >= 9 && dataPrecision < 19:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+				case integerDigits >= 10 && integerDigits < 19:
 					if _, ok = numberDatatypeMap["BIGINT"]; ok {
 						buildInColumnType = "BIGINT"
 					} else {
 						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [BIGINT]", originColumnType)
 					}
-				case dataPrecision >= 19 && dataPrecision <= 38:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+				case integerDigits >= 19 && integerDigits <= 65:
 					if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-						buildInColumnType = fmt.Sprintf("DECIMAL(%d)", dataPrecision)
+						buildInColumnType = fmt.Sprintf("DECIMAL(%d)", integerDigits)
 					} else {
 						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
 					}
 				default:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+					// decimal 最大精度 65
 					if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-						buildInColumnType = fmt.Sprintf("DECIMAL(%d,%d)", 65, dataScale)
+						buildInColumnType = fmt.Sprintf("DECIMAL(%d)", 65)
 					} else {
 						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
 					}
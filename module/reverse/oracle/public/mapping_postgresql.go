@@ -0,0 +1,112 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strconv"
+	"strings"
+)
+
+// OracleTableColumnMapPostgreSQLRule oracle 字段类型转换 postgresql 内置规则，返回 oracle 原始字段类型以及内置转换字段类型
+// postgresql 数值精度最大 1000，不存在 oracle number 精度溢出问题，number 未指定精度统一转换 NUMERIC
+func OracleTableColumnMapPostgreSQLRule(sourceSchema, sourceTable string, column Column) (string, string, error) {
+	dataLength, err := strconv.Atoi(column.DataLength)
+	if err != nil {
+		return "", "", fmt.Errorf("oracle schema [%s] table [%s] reverser column data_length string to int failed: %v", sourceSchema, sourceTable, err)
+	}
+	dataPrecision, err := strconv.Atoi(column.DataPrecision)
+	if err != nil {
+		return "", "", fmt.Errorf("oracle schema [%s] table [%s] reverser column data_precision string to int failed: %v", sourceSchema, sourceTable, err)
+	}
+	dataScale, err := strconv.Atoi(column.DataScale)
+	if err != nil {
+		return "", "", fmt.Errorf("oracle schema [%s] table [%s] reverser column data_scale string to int failed: %v", sourceSchema, sourceTable, err)
+	}
+
+	// 字符类型长度，CHAR_USED = C 按字符长度，否则按字节长度
+	charLength := strconv.Itoa(dataLength)
+	if strings.EqualFold(column.CharUsed, "C") {
+		charLength = column.CharLength
+	}
+
+	dataType := common.StringUPPER(column.DataType)
+	switch dataType {
+	case common.BuildInOracleDatatypeNumber:
+		originColumnType := fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+		switch {
+		// number / number(*) -> number(38,127)
+		case dataPrecision == 38 && dataScale == 127:
+			return originColumnType, "NUMERIC", nil
+		case dataScale > 0 || dataScale < 0:
+			return originColumnType, fmt.Sprintf("NUMERIC(%d,%d)", dataPrecision, dataScale), nil
+		case dataPrecision < 5:
+			return originColumnType, "SMALLINT", nil
+		case dataPrecision < 10:
+			return originColumnType, "INTEGER", nil
+		case dataPrecision < 19:
+			return originColumnType, "BIGINT", nil
+		default:
+			return originColumnType, fmt.Sprintf("NUMERIC(%d)", dataPrecision), nil
+		}
+	case common.BuildInOracleDatatypeDecimal, common.BuildInOracleDatatypeDec, common.BuildInOracleDatatypeNumeric:
+		return fmt.Sprintf("%s(%d,%d)", dataType, dataPrecision, dataScale), fmt.Sprintf("NUMERIC(%d,%d)", dataPrecision, dataScale), nil
+	case common.BuildInOracleDatatypeInteger, common.BuildInOracleDatatypeInt, common.BuildInOracleDatatypeSmallint:
+		return dataType, "NUMERIC(38)", nil
+	case common.BuildInOracleDatatypeFloat, common.BuildInOracleDatatypeDoublePrecision, common.BuildInOracleDatatypeBinaryDouble:
+		return dataType, "DOUBLE PRECISION", nil
+	case common.BuildInOracleDatatypeReal, common.BuildInOracleDatatypeBinaryFloat:
+		return dataType, "REAL", nil
+	case common.BuildInOracleDatatypeChar, common.BuildInOracleDatatypeCharacter, common.BuildInOracleDatatypeNchar:
+		return fmt.Sprintf("%s(%s)", dataType, charLength), fmt.Sprintf("CHAR(%s)", charLength), nil
+	case common.BuildInOracleDatatypeVarchar2, common.BuildInOracleDatatypeVarchar, common.BuildInOracleDatatypeNvarchar2, common.BuildInOracleDatatypeNcharVarying:
+		return fmt.Sprintf("%s(%s)", dataType, charLength), fmt.Sprintf("VARCHAR(%s)", charLength), nil
+	case common.BuildInOracleDatatypeClob, common.BuildInOracleDatatypeNclob, common.BuildInOracleDatatypeLong:
+		return dataType, "TEXT", nil
+	case common.BuildInOracleDatatypeBlob, common.BuildInOracleDatatypeLongRAW, common.BuildInOracleDatatypeBfile:
+		return dataType, "BYTEA", nil
+	case common.BuildInOracleDatatypeRaw:
+		return fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeRaw, dataLength), "BYTEA", nil
+	case common.BuildInOracleDatatypeDate:
+		return dataType, "TIMESTAMP(0)", nil
+	case common.BuildInOracleDatatypeRowid:
+		return dataType, "VARCHAR(64)", nil
+	case common.BuildInOracleDatatypeUrowid:
+		return fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeUrowid, dataLength), fmt.Sprintf("VARCHAR(%d)", dataLength), nil
+	case common.BuildInOracleDatatypeXmltype:
+		return dataType, "XML", nil
+	default:
+		switch {
+		case strings.Contains(dataType, "INTERVAL YEAR"):
+			return dataType, "INTERVAL YEAR TO MONTH", nil
+		case strings.Contains(dataType, "INTERVAL DAY"):
+			return dataType, "INTERVAL DAY TO SECOND", nil
+		case strings.Contains(dataType, "TIMESTAMP"):
+			// postgresql 时间精度最大 6
+			datetimePrecision := dataScale
+			if datetimePrecision > 6 {
+				datetimePrecision = 6
+			}
+			if strings.Contains(dataType, "TIME ZONE") {
+				return dataType, fmt.Sprintf("TIMESTAMPTZ(%d)", datetimePrecision), nil
+			}
+			return dataType, fmt.Sprintf("TIMESTAMP(%d)", datetimePrecision), nil
+		default:
+			return dataType, "", fmt.Errorf("oracle schema [%s] table [%s] column datatype [%s] map postgresql column type rule isn't exist, please checkin", sourceSchema, sourceTable, dataType)
+		}
+	}
+}
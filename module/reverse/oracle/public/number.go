@@ -0,0 +1,59 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"math"
+	"strconv"
+)
+
+// IsOracleUnconstrainedNumber 判断是否未指定精度 number 字段，number、number(*) 字典 DATA_PRECISION/DATA_SCALE 为空，查询转换为 38/127
+func IsOracleUnconstrainedNumber(dataType, dataPrecision, dataScale string) bool {
+	return common.StringUPPER(dataType) == common.BuildInOracleDatatypeNumber &&
+		dataPrecision == common.ReverseNumberUnconstrainedPrecision && dataScale == common.ReverseNumberUnconstrainedScale
+}
+
+// GenOracleNumberSampleColumnType 根据采样统计结果推断未指定精度 number 字段映射类型
+// 采样数据全部为整数且位于 BIGINT 范围 -> BIGINT，超出 BIGINT 范围 -> DECIMAL(65,0)，存在小数或无采样数据返回空，沿用默认映射
+func GenOracleNumberSampleColumnType(sample map[string]string) (string, error) {
+	sampleRows, err := strconv.ParseInt(sample["SAMPLE_ROWS"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("number column sample rows [%s] strconv.ParseInt failed: %v", sample["SAMPLE_ROWS"], err)
+	}
+	decimalRows, err := strconv.ParseInt(sample["DECIMAL_ROWS"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("number column sample decimal rows [%s] strconv.ParseInt failed: %v", sample["DECIMAL_ROWS"], err)
+	}
+	if sampleRows == 0 || decimalRows > 0 {
+		return "", nil
+	}
+
+	maxValue, err := decimal.NewFromString(sample["MAX_VALUE"])
+	if err != nil {
+		return "", fmt.Errorf("number column sample max value [%s] parse failed: %v", sample["MAX_VALUE"], err)
+	}
+	minValue, err := decimal.NewFromString(sample["MIN_VALUE"])
+	if err != nil {
+		return "", fmt.Errorf("number column sample min value [%s] parse failed: %v", sample["MIN_VALUE"], err)
+	}
+	if maxValue.LessThanOrEqual(decimal.NewFromInt(math.MaxInt64)) && minValue.GreaterThanOrEqual(decimal.NewFromInt(math.MinInt64)) {
+		return common.ReverseNumberSampleIntegerType, nil
+	}
+	return common.ReverseNumberSampleDecimalType, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"github.com/wentaojin/transferdb/common"
+)
+
+// SortTableByForeignKey 依据外键依赖关系拓扑排序表，被引用表排在引用表之前
+// 自引用外键忽略，存在循环依赖的表保持原有顺序追加到末尾并返回
+func SortTableByForeignKey(tables []string, dependencies []map[string]string) (sortedTables []string, cycleTables []string) {
+	inDegree := make(map[string]int, len(tables))
+	for _, t := range tables {
+		inDegree[common.StringUPPER(t)] = 0
+	}
+
+	// 被引用表 -> 引用表
+	children := make(map[string][]string)
+	for _, dep := range dependencies {
+		child, parent := common.StringUPPER(dep["TABLE_NAME"]), common.StringUPPER(dep["RTABLE_NAME"])
+		if child == parent {
+			continue
+		}
+		_, okc := inDegree[child]
+		_, okp := inDegree[parent]
+		// 未参与本次转换的表不影响排序
+		if !okc || !okp {
+			continue
+		}
+		children[parent] = append(children[parent], child)
+		inDegree[child]++
+	}
+
+	visited := make(map[string]bool, len(tables))
+	for {
+		var level []string
+		for _, t := range tables {
+			ut := common.StringUPPER(t)
+			if !visited[ut] && inDegree[ut] == 0 {
+				level = append(level, t)
+			}
+		}
+		if len(level) == 0 {
+			break
+		}
+		for _, t := range level {
+			ut := common.StringUPPER(t)
+			visited[ut] = true
+			for _, c := range children[ut] {
+				inDegree[c]--
+			}
+		}
+		sortedTables = append(sortedTables, level...)
+	}
+
+	for _, t := range tables {
+		if !visited[common.StringUPPER(t)] {
+			cycleTables = append(cycleTables, t)
+		}
+	}
+	return append(sortedTables, cycleTables...), cycleTables
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Oracle 视图方言转换规则，仅作用于非字符串常量部分
+var viewTranslateRules = []struct {
+	reg     *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(?i)\bNVL\s*\(`), "IFNULL("},
+	{regexp.MustCompile(`(?i)\bSYSTIMESTAMP\b`), "CURRENT_TIMESTAMP(6)"},
+	{regexp.MustCompile(`(?i)\bSYSDATE\b`), "NOW()"},
+}
+
+// Oracle 视图无法自动转换语法，需人工审核
+var viewIncompatibleRules = []struct {
+	reg    *regexp.Regexp
+	reason string
+}{
+	{regexp.MustCompile(`\(\s*\+\s*\)`), "oracle outer join (+)"},
+	{regexp.MustCompile(`(?i)\bCONNECT\s+BY\b`), "connect by hierarchical query"},
+	{regexp.MustCompile(`(?i)\bROWNUM\b`), "rownum"},
+	{regexp.MustCompile(`(?i)\bROWID\b`), "rowid"},
+	{regexp.MustCompile(`(?i)\bDECODE\s*\(`), "decode function"},
+	{regexp.MustCompile(`(?i)\bTO_(CHAR|DATE|NUMBER|TIMESTAMP)\s*\(`), "to_char/to_date/to_number/to_timestamp format function"},
+	{regexp.MustCompile(`\|\|`), "|| string concatenation"},
+	{regexp.MustCompile(`(?i)\bMINUS\b`), "minus set operator"},
+	{regexp.MustCompile(`(?i)\bNEXTVAL\b|\bCURRVAL\b`), "sequence"},
+	{regexp.MustCompile(`(?i)\bSYS_CONTEXT\s*\(|\bUSERENV\s*\(`), "sys_context/userenv function"},
+	{regexp.MustCompile(`(?i)\bWITH\s+(READ\s+ONLY|CHECK\s+OPTION\s+CONSTRAINT)\b`), "with read only/check option constraint"},
+}
+
+var (
+	viewRownumLimitReg = regexp.MustCompile(`(?is)\s+(WHERE|AND)\s+ROWNUM\s*(<=|<)\s*(\d+)\s*$`)
+	viewLiteralReg     = regexp.MustCompile("\x00(\\d+)\x00")
+	viewOrReg          = regexp.MustCompile(`(?i)\bOR\b`)
+)
+
+// TranslateOracleViewSQL 视图定义方言转换（尽力而为）
+// NVL -> IFNULL、SYSDATE -> NOW()、SYSTIMESTAMP -> CURRENT_TIMESTAMP(6)、查询末尾 ROWNUM <= N -> LIMIT N、双引号标识符 -> 反引号
+// 同 schema 对象引用转换为目标 schema，返回无法转换语法原因，非空代表需人工审核
+func TranslateOracleViewSQL(viewText, sourceSchema, targetSchema string) (string, []string) {
+	var (
+		literals []string
+		sb       strings.Builder
+	)
+
+	// 字符串常量替换占位符，双引号标识符转换反引号
+	for i := 0; i < len(viewText); i++ {
+		c := viewText[i]
+		switch c {
+		case '\'':
+			j := i + 1
+			for j < len(viewText) {
+				if viewText[j] == '\'' {
+					// '' 转义单引号
+					if j+1 < len(viewText) && viewText[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(viewText) {
+				return viewText, []string{"unterminated string literal"}
+			}
+			sb.WriteString(fmt.Sprintf("\x00%d\x00", len(literals)))
+			literals = append(literals, viewText[i:j+1])
+			i = j
+		case '"':
+			end := strings.IndexByte(viewText[i+1:], '"')
+			if end == -1 {
+				return viewText, []string{"unterminated quoted identifier"}
+			}
+			sb.WriteString("`" + viewText[i+1:i+1+end] + "`")
+			i = i + 1 + end
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	text := strings.TrimSpace(sb.String())
+	text = strings.TrimSuffix(text, ";")
+
+	// 查询末尾 ROWNUM 限制行数转换 LIMIT，末尾无 ORDER BY，语义一致
+	// AND 条件同时存在 OR 条件，移除 ROWNUM 条件会改变优先级，不转换
+	if m := viewRownumLimitReg.FindStringSubmatch(text); m != nil && !(strings.EqualFold(m[1], "AND") && viewOrReg.MatchString(text)) {
+		limit, err := strconv.Atoi(m[3])
+		if err == nil {
+			if m[2] == "<" {
+				limit = limit - 1
+			}
+			// WHERE ROWNUM 或者 AND ROWNUM 条件移除
+			text = fmt.Sprintf("%s LIMIT %d", viewRownumLimitReg.ReplaceAllString(text, ""), limit)
+		}
+	}
+
+	for _, r := range viewTranslateRules {
+		text = r.reg.ReplaceAllString(text, r.replace)
+	}
+
+	// 同 schema 对象引用转换为目标 schema
+	if !strings.EqualFold(sourceSchema, targetSchema) && targetSchema != "" {
+		schemaReg := regexp.MustCompile(fmt.Sprintf("(?i)(^|[^A-Za-z0-9_$#`])`?%s`?\\s*\\.", regexp.QuoteMeta(sourceSchema)))
+		text = schemaReg.ReplaceAllString(text, "${1}`"+targetSchema+"`.")
+	}
+
+	var reasons []string
+	for _, r := range viewIncompatibleRules {
+		if r.reg.MatchString(text) && !common.IsContainString(reasons, r.reason) {
+			reasons = append(reasons, r.reason)
+		}
+	}
+
+	// 还原字符串常量，MySQL 反斜杠为转义字符
+	text = viewLiteralReg.ReplaceAllStringFunc(text, func(s string) string {
+		idx, _ := strconv.Atoi(viewLiteralReg.FindStringSubmatch(s)[1])
+		return strings.ReplaceAll(literals[idx], `\`, `\\`)
+	})
+	return text, reasons
+}
@@ -19,8 +19,10 @@ import (
 	"bufio"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/clickhouse"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/database/postgres"
 	"os"
 	"strings"
 	"sync"
@@ -34,13 +36,25 @@ type Write struct {
 	CWriter *bufio.Writer
 	Mutex   *sync.Mutex
 
-	MySQL  *mysql.MySQL
-	Oracle *oracle.Oracle
+	MySQL      *mysql.MySQL
+	Oracle     *oracle.Oracle
+	PostgreSQL *postgres.PostgreSQL
+	ClickHouse *clickhouse.ClickHouse
 }
 
 func NewWriter(cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.Oracle, reverseFile, compFile string) (*Write, error) {
 	w := &Write{}
 
+	// dry-run 模式下 DDL 以及兼容性语句直接输出至标准输出，不落盘亦不写目标端
+	if cfg.DryRun {
+		w.RWriter, w.CWriter = bufio.NewWriter(os.Stdout), bufio.NewWriter(os.Stdout)
+		w.Mutex = &sync.Mutex{}
+		w.Cfg = cfg
+		w.MySQL = mysql
+		w.Oracle = oracle
+		return w, nil
+	}
+
 	if !cfg.ReverseConfig.DirectWrite {
 		err := w.initOutReverseFile(reverseFile)
 		if err != nil {
@@ -77,6 +91,16 @@ func (w *Write) RWriteDB(s string) error {
 		if err != nil {
 			return err
 		}
+	case strings.EqualFold(w.Cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(w.Cfg.DBTypeT, common.DatabaseTypePostgreSQL):
+		err := w.PostgreSQL.WritePostgreSQLTable(s)
+		if err != nil {
+			return err
+		}
+	case strings.EqualFold(w.Cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(w.Cfg.DBTypeT, common.DatabaseTypeClickHouse):
+		err := w.ClickHouse.WriteClickHouseTable(s)
+		if err != nil {
+			return err
+		}
 	case strings.EqualFold(w.Cfg.DBTypeS, common.DatabaseTypeMySQL) && strings.EqualFold(w.Cfg.DBTypeT, common.DatabaseTypeOracle):
 		err := w.Oracle.WriteOracleTable(s)
 		if err != nil {
@@ -116,6 +140,12 @@ func (w *Write) initOutCompatibleFile(compFile string) error {
 }
 
 func (w *Write) Close() error {
+	if w.Cfg != nil && w.Cfg.DryRun {
+		if err := w.RWriter.Flush(); err != nil {
+			return err
+		}
+		return w.CWriter.Flush()
+	}
 	if w.RFile != nil {
 		err := w.RWriter.Flush()
 		if err != nil {
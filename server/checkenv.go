@@ -0,0 +1,37 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/checkenv/oracle/o2m"
+	"github.com/wentaojin/transferdb/module/checkenv/oracle/o2t"
+	"strings"
+)
+
+func ICheckEnv(ctx context.Context, cfg *config.Config) error {
+	switch {
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
+		return o2m.NewCheckEnv(ctx, cfg).CheckEnv()
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeTiDB):
+		return o2t.NewCheckEnv(ctx, cfg).CheckEnv()
+	default:
+		return fmt.Errorf("check-env mode source [%s] target [%s] isn't support, only support oracle to mysql/tidb", cfg.DBTypeS, cfg.DBTypeT)
+	}
+}
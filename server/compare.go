@@ -17,11 +17,13 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/module/compare"
 	"github.com/wentaojin/transferdb/module/compare/oracle/o2m"
 	"github.com/wentaojin/transferdb/module/compare/oracle/o2t"
+	"github.com/wentaojin/transferdb/module/compare/sqlserver/s2m"
 	"strings"
 )
 
@@ -41,6 +43,13 @@ func ICompare(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeSQLServer) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
+		c, err = s2m.NewCompare(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("compare mode db-type-s [%s] db-type-t [%s] isn't support", cfg.DBTypeS, cfg.DBTypeT)
 	}
 	err = c.NewCompare()
 	if err != nil {
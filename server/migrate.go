@@ -17,11 +17,17 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/module/migrate"
+	"github.com/wentaojin/transferdb/module/migrate/sql/mysql/m2o"
+	"github.com/wentaojin/transferdb/module/migrate/sql/mysql/t2o"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/o2c"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/o2m"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/o2p"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/o2t"
+	"github.com/wentaojin/transferdb/module/migrate/sql/sqlserver/s2m"
 	"strings"
 )
 
@@ -41,6 +47,33 @@ func IMigrateFull(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypePostgreSQL):
+		f, err = o2p.NewFuller(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeClickHouse):
+		f, err = o2c.NewFuller(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeMySQL) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeOracle):
+		f, err = m2o.NewFuller(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeTiDB) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeOracle):
+		f, err = t2o.NewFuller(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeSQLServer) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
+		f, err = s2m.NewFuller(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("full mode db-type-s [%s] db-type-t [%s] isn't support", cfg.DBTypeS, cfg.DBTypeT)
 	}
 	err = f.Full()
 	if err != nil {
@@ -65,6 +98,13 @@ func IMigrateIncr(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeSQLServer) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
+		i, err = s2m.NewIncr(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("incr mode db-type-s [%s] db-type-t [%s] isn't support", cfg.DBTypeS, cfg.DBTypeT)
 	}
 	err = i.Incr()
 	if err != nil {
@@ -0,0 +1,136 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"time"
+)
+
+// IPause 命令行暂停/恢复运行中任务，更新元数据表 task_meta 对应 [app] task-id 任务状态，运行中进程定时读取后暂停或恢复
+func IPause(ctx context.Context, cfg *config.Config, pause bool) error {
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+	taskMetas, err := meta.NewTaskMetaModel(metaDB).DetailTaskMeta(ctx, cfg.AppConfig.TaskID)
+	if err != nil {
+		return err
+	}
+	if len(taskMetas) == 0 {
+		return fmt.Errorf("task [%s] isn't exist in meta table [task_meta], please check task whether start", cfg.AppConfig.TaskID)
+	}
+
+	fromStatus, toStatus := common.TaskStatusRunning, common.TaskStatusPaused
+	if !pause {
+		fromStatus, toStatus = common.TaskStatusPaused, common.TaskStatusRunning
+	}
+	if taskMetas[0].TaskStatus != fromStatus {
+		return fmt.Errorf("task [%s] status is [%s], only [%s] task can be changed to [%s]", cfg.AppConfig.TaskID, taskMetas[0].TaskStatus, fromStatus, toStatus)
+	}
+	if err = meta.NewTaskMetaModel(metaDB).UpdateTaskMetaStatus(ctx, cfg.AppConfig.TaskID, toStatus, ""); err != nil {
+		return err
+	}
+	fmt.Printf("task [%s] status changed to [%s], running process takes effect within %v\n", cfg.AppConfig.TaskID, toStatus, common.TaskPausePollInterval)
+	return nil
+}
+
+// watchTaskPause 任务暂停状态双向同步
+// - 元数据表 task_meta 任务状态变更（命令行 pause/resume 或者人工更新）：暂停或恢复当前任务
+// - 进程内人工暂停状态变更（SIGUSR2 信号或者接口 pause/resume）：任务状态写入 task_meta
+func watchTaskPause(ctx context.Context, metaDB *meta.Meta, taskID string, done <-chan struct{}) {
+	ticker := time.NewTicker(common.TaskPausePollInterval)
+	defer ticker.Stop()
+
+	lastStatus := common.TaskStatusRunning
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		taskMetas, err := meta.NewTaskMetaModel(metaDB).DetailTaskMeta(ctx, taskID)
+		if err != nil || len(taskMetas) == 0 {
+			zap.L().Warn("get task status from meta failed", zap.String("task", taskID), zap.Error(err))
+			continue
+		}
+
+		metaStatus := taskMetas[0].TaskStatus
+		// 时间窗口暂停不写入 task_meta，避免窗口恢复时覆盖人工暂停
+		paused := signal.IsManualPaused(taskID)
+		switch {
+		case metaStatus != lastStatus && metaStatus == common.TaskStatusPaused:
+			signal.Pause(taskID)
+			zap.L().Warn("task paused by meta task status, stop dispatching new table/chunk", zap.String("task", taskID))
+		case metaStatus != lastStatus && metaStatus == common.TaskStatusRunning:
+			signal.Resume(taskID)
+			zap.L().Warn("task resumed by meta task status, continue dispatching table/chunk", zap.String("task", taskID))
+		case paused && metaStatus == common.TaskStatusRunning:
+			metaStatus = common.TaskStatusPaused
+		case !paused && metaStatus == common.TaskStatusPaused:
+			metaStatus = common.TaskStatusRunning
+		default:
+			continue
+		}
+		if metaStatus != taskMetas[0].TaskStatus {
+			if err = meta.NewTaskMetaModel(metaDB).UpdateTaskMetaStatus(ctx, taskID, metaStatus, ""); err != nil {
+				zap.L().Warn("update task status failed", zap.String("task", taskID), zap.String("status", metaStatus), zap.Error(err))
+				continue
+			}
+		}
+		lastStatus = metaStatus
+	}
+}
+
+// watchTimeWindow 定时检查 [app] run-windows/blackout-windows，窗口外暂停任务，窗口内恢复
+func watchTimeWindow(ctx context.Context, runWindows, blackoutWindows []common.TimeWindow, taskID string, done <-chan struct{}) {
+	ticker := time.NewTicker(common.TimeWindowCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		checkTimeWindow(runWindows, blackoutWindows, taskID)
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkTimeWindow(runWindows, blackoutWindows []common.TimeWindow, taskID string) {
+	paused := !common.InTimeWindow(runWindows, blackoutWindows, time.Now())
+	if paused == signal.IsWindowPaused(taskID) {
+		return
+	}
+	signal.SetWindowPaused(taskID, paused)
+	if paused {
+		zap.L().Warn("outside task run window, stop dispatching new table/chunk, in-flight chunk continue",
+			zap.String("task", taskID), zap.Stringers("run-windows", runWindows), zap.Stringers("blackout-windows", blackoutWindows))
+	} else {
+		zap.L().Warn("inside task run window, continue dispatching table/chunk", zap.String("task", taskID))
+	}
+}
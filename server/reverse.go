@@ -22,7 +22,9 @@ import (
 	"github.com/wentaojin/transferdb/module/reverse"
 	"github.com/wentaojin/transferdb/module/reverse/mysql/m2o"
 	"github.com/wentaojin/transferdb/module/reverse/mysql/t2o"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/o2c"
 	"github.com/wentaojin/transferdb/module/reverse/oracle/o2m"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/o2p"
 	"github.com/wentaojin/transferdb/module/reverse/oracle/o2t"
 	"strings"
 )
@@ -32,6 +34,10 @@ func IReverse(ctx context.Context, cfg *config.Config) error {
 		r   reverse.Reverser
 		err error
 	)
+	// dry-run 模式只输出 DDL，禁止直写目标端
+	if cfg.DryRun {
+		cfg.ReverseConfig.DirectWrite = false
+	}
 	switch {
 	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
 		r, err = o2m.NewReverse(ctx, cfg)
@@ -43,6 +49,16 @@ func IReverse(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypePostgreSQL):
+		r, err = o2p.NewReverse(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeClickHouse):
+		r, err = o2c.NewReverse(ctx, cfg)
+		if err != nil {
+			return err
+		}
 	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeMySQL) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeOracle):
 		r, err = m2o.NewReverse(ctx, cfg)
 		if err != nil {
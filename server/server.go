@@ -20,12 +20,114 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/module/prepare"
+	"github.com/wentaojin/transferdb/module/report"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
 	"strings"
+	"time"
 )
 
-// 程序运行
+// 程序运行，元数据按 [app] task-id 隔离，prepare/status 以及 dry-run 之外的任务运行登记元数据表 task_meta
 func Run(ctx context.Context, cfg *config.Config) error {
+	ctx = meta.WithTaskID(ctx, cfg.AppConfig.TaskID)
+	// full/all 模式运行中登记调优参数，任务结束注销
+	defer common.UnregisterTuning(cfg.AppConfig.TaskID)
+
+	switch strings.ToUpper(strings.TrimSpace(cfg.TaskMode)) {
+	case common.TaskModePrepare, common.TaskModeStatus, common.TaskModeCheckEnv:
+		return run(ctx, cfg)
+	case common.TaskModePause:
+		return IPause(ctx, cfg, true)
+	case common.TaskModeResume:
+		return IPause(ctx, cfg, false)
+	}
+	if cfg.DryRun {
+		return run(ctx, cfg)
+	}
+
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+	if err = meta.NewTaskMetaModel(metaDB).CreateOrUpdateTaskMeta(ctx, &meta.TaskMeta{
+		TaskID:      cfg.AppConfig.TaskID,
+		TaskMode:    common.StringUPPER(cfg.TaskMode),
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+		TaskStatus:  common.TaskStatusRunning,
+		TaskConfig:  cfg.String(),
+	}); err != nil {
+		return fmt.Errorf("register task [%s] failed, please run prepare mode to upgrade meta database: %v", cfg.AppConfig.TaskID, err)
+	}
+
+	// 任务暂停状态与元数据表 task_meta 同步，任务结束后停止同步并清理暂停状态
+	pauseDone := make(chan struct{})
+	pauseExited := make(chan struct{})
+	go func() {
+		defer close(pauseExited)
+		watchTaskPause(ctx, metaDB, cfg.AppConfig.TaskID, pauseDone)
+	}()
+
+	// 运行时间窗口，任务开始前完成首次检查，窗口外任务启动后即暂停
+	runWindows, err := common.ParseTimeWindows(cfg.AppConfig.RunWindows)
+	if err != nil {
+		return err
+	}
+	blackoutWindows, err := common.ParseTimeWindows(cfg.AppConfig.BlackoutWindows)
+	if err != nil {
+		return err
+	}
+	windowExited := make(chan struct{})
+	if len(runWindows) > 0 || len(blackoutWindows) > 0 {
+		checkTimeWindow(runWindows, blackoutWindows, cfg.AppConfig.TaskID)
+		go func() {
+			defer close(windowExited)
+			watchTimeWindow(ctx, runWindows, blackoutWindows, cfg.AppConfig.TaskID, pauseDone)
+		}()
+	} else {
+		close(windowExited)
+	}
+
+	startTime := time.Now()
+	runErr := run(ctx, cfg)
+
+	close(pauseDone)
+	<-pauseExited
+	<-windowExited
+	signal.Resume(cfg.AppConfig.TaskID)
+	signal.SetWindowPaused(cfg.AppConfig.TaskID, false)
+
+	// 任务上下文可能已取消，任务状态采用独立上下文更新
+	var (
+		taskStatus  string
+		errorDetail string
+	)
+	switch {
+	case runErr != nil && ctx.Err() != nil:
+		taskStatus = common.TaskStatusStopped
+		errorDetail = runErr.Error()
+	case runErr != nil:
+		taskStatus = common.TaskStatusFailed
+		errorDetail = runErr.Error()
+	default:
+		taskStatus = common.TaskStatusSuccess
+	}
+	if err = meta.NewTaskMetaModel(metaDB).UpdateTaskMetaStatus(
+		meta.WithTaskID(context.Background(), cfg.AppConfig.TaskID), cfg.AppConfig.TaskID, taskStatus, errorDetail); err != nil {
+		zap.L().Warn("update task status failed", zap.String("task", cfg.AppConfig.TaskID), zap.String("status", taskStatus), zap.Error(err))
+	}
+
+	// 任务结束汇总报告，报告输出失败不影响任务结果
+	if err = report.GenReport(meta.WithTaskID(context.Background(), cfg.AppConfig.TaskID), cfg, metaDB, startTime, time.Now(), taskStatus, errorDetail); err != nil {
+		zap.L().Warn("generate task report failed", zap.String("task", cfg.AppConfig.TaskID), zap.String("report-dir", cfg.AppConfig.ReportDir), zap.Error(err))
+	}
+	return runErr
+}
+
+func run(ctx context.Context, cfg *config.Config) error {
 	switch strings.ToUpper(strings.TrimSpace(cfg.TaskMode)) {
 	case common.TaskModePrepare:
 		// 表结构转换 - only prepare 阶段
@@ -75,6 +177,18 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case common.TaskModeCheckEnv:
+		// 运行环境预检查 - 输出 PASS/WARN/FAIL 检查清单
+		err := ICheckEnv(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case common.TaskModeStatus:
+		// 任务状态 - 读取元数据库输出 full/csv/all 任务进度
+		err := IStatus(ctx, cfg)
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("flag [mode] can not null or value configure error")
 	}
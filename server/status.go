@@ -0,0 +1,160 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"sort"
+	"time"
+)
+
+// IStatus 读取元数据库 wait_sync_meta，输出 [app] task-id 任务 schema 下各表 full/csv/all 任务进度以及增量同步延迟
+func IStatus(ctx context.Context, cfg *config.Config) error {
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+
+	taskMetas, err := meta.NewTaskMetaModel(metaDB).DetailTaskMeta(ctx, cfg.AppConfig.TaskID)
+	if err != nil {
+		return err
+	}
+	for _, t := range taskMetas {
+		var updatedAt string
+		if t.BaseModel != nil {
+			updatedAt = t.UpdatedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("task [%s] mode [%s] schema [%s] status [%s] last updated [%s]\n",
+			t.TaskID, t.TaskMode, t.SchemaNameS, t.TaskStatus, updatedAt)
+	}
+
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+	})
+	if err != nil {
+		return err
+	}
+	if len(waitSyncMetas) == 0 {
+		fmt.Printf("schema [%s] task status records are not exist, please check task whether start\n", cfg.SchemaConfig.SourceSchema)
+		return incrStatus(ctx, cfg, metaDB)
+	}
+
+	sort.Slice(waitSyncMetas, func(i, j int) bool {
+		if waitSyncMetas[i].TaskMode != waitSyncMetas[j].TaskMode {
+			return waitSyncMetas[i].TaskMode < waitSyncMetas[j].TaskMode
+		}
+		return waitSyncMetas[i].TableNameS < waitSyncMetas[j].TableNameS
+	})
+
+	var (
+		rows         []table.Row
+		statusCounts = make(map[string]int)
+	)
+	for _, w := range waitSyncMetas {
+		statusCounts[w.TaskStatus]++
+
+		var (
+			progress  string
+			rowsMoved uint64
+			remaining string
+		)
+		// 全量任务 chunk 未切分 ChunkTotalNums 为 -1
+		if w.ChunkTotalNums > 0 {
+			progress = fmt.Sprintf("%d/%d", w.ChunkSuccessNums, w.ChunkTotalNums)
+			rowsMoved = uint64(float64(w.TableNumRows) * float64(w.ChunkSuccessNums) / float64(w.ChunkTotalNums))
+
+			// 以已完成 chunk 平均耗时估算剩余时间
+			if w.TaskStatus == common.TaskStatusRunning && w.ChunkSuccessNums > 0 && w.BaseModel != nil {
+				elapsed := w.UpdatedAt.Sub(w.CreatedAt)
+				remainChunks := w.ChunkTotalNums - w.ChunkSuccessNums - w.ChunkFailedNums
+				if elapsed > 0 && remainChunks > 0 {
+					remaining = (time.Duration(int64(elapsed) / w.ChunkSuccessNums * remainChunks)).Truncate(time.Second).String()
+				}
+			}
+		}
+		rows = append(rows, table.Row{w.TaskMode, w.SchemaNameS, w.TableNameS, w.TaskStatus, progress, w.ChunkFailedNums, w.TableNumRows, rowsMoved, remaining})
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"TASK MODE", "SCHEMA", "TABLE NAME", "STATUS", "CHUNK SUCCESS/TOTAL", "CHUNK FAILED", "TABLE ROWS", "ROWS MOVED", "ESTIMATED REMAINING"})
+	t.AppendRows(rows)
+	t.AppendFooter(table.Row{"SUMMARY", "", fmt.Sprintf("%d", len(waitSyncMetas)),
+		fmt.Sprintf("%s %d / %s %d / %s %d / %s %d",
+			common.TaskStatusWaiting, statusCounts[common.TaskStatusWaiting],
+			common.TaskStatusRunning, statusCounts[common.TaskStatusRunning],
+			common.TaskStatusSuccess, statusCounts[common.TaskStatusSuccess],
+			common.TaskStatusFailed, statusCounts[common.TaskStatusFailed]),
+		"", "", "", "", ""})
+	fmt.Println(t.Render())
+
+	return incrStatus(ctx, cfg, metaDB)
+}
+
+// incrStatus 输出增量同步表级别 SCN 以及同步延迟，上游连接失败只输出已应用 SCN
+func incrStatus(ctx context.Context, cfg *config.Config, metaDB *meta.Meta) error {
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(metaDB).DetailIncrSyncMetaBySchema(ctx, &meta.IncrSyncMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+	})
+	if err != nil {
+		return err
+	}
+	if len(incrSyncMetas) == 0 {
+		return nil
+	}
+	sort.Slice(incrSyncMetas, func(i, j int) bool {
+		return incrSyncMetas[i].TableNameS < incrSyncMetas[j].TableNameS
+	})
+
+	var lag public.IncrLag
+	oracleDB, lagErr := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig, cfg.SchemaConfig.SourceSchema)
+	if lagErr == nil {
+		lag, lagErr = public.GetIncrLag(ctx, metaDB, oracleDB, cfg.DBTypeS, cfg.DBTypeT, cfg.SchemaConfig.SourceSchema)
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"SCHEMA", "TABLE NAME", "TARGET TABLE", "GLOBAL SCN", "TABLE SCN", "UNREPLICATED SCN"})
+	for _, m := range incrSyncMetas {
+		var unreplicated string
+		if lagErr == nil && lag.CurrentSCN > m.TableScnS {
+			unreplicated = fmt.Sprintf("%d-%d", m.TableScnS, lag.CurrentSCN)
+		}
+		t.AppendRow(table.Row{m.SchemaNameS, m.TableNameS, m.TableNameT, m.GlobalScnS, m.TableScnS, unreplicated})
+	}
+	if lagErr != nil {
+		t.AppendFooter(table.Row{"SUMMARY", fmt.Sprintf("%d", len(incrSyncMetas)), "", "", "", fmt.Sprintf("lag unknown: %v", lagErr)})
+	} else {
+		t.AppendFooter(table.Row{"SUMMARY", fmt.Sprintf("%d", len(incrSyncMetas)),
+			fmt.Sprintf("SOURCE SCN %d", lag.CurrentSCN),
+			fmt.Sprintf("APPLIED SCN %d", lag.AppliedSCN),
+			fmt.Sprintf("UNREPLICATED %d", lag.UnreplicatedSCN()),
+			fmt.Sprintf("SECONDS BEHIND SOURCE %d", lag.LagSeconds)})
+	}
+	fmt.Println(t.Render())
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"go.uber.org/zap"
+)
+
+// ReloadTuning 重新读取配置文件调优参数应用于运行中任务，配置文件 task-id 与运行中任务不一致不生效
+func ReloadTuning(cfg *config.Config) error {
+	taskID, params, err := config.ReadTuningParams(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if taskID != cfg.AppConfig.TaskID {
+		return fmt.Errorf("config file [%s] task-id [%s] isn't equal to running task-id [%s]", cfg.ConfigFile, taskID, cfg.AppConfig.TaskID)
+	}
+	tuning, ok := common.GetTuning(taskID)
+	if !ok {
+		return fmt.Errorf("task [%s] mode [%s] isn't running or not support tuning, only oracle to mysql/tidb full and all mode support", taskID, cfg.TaskMode)
+	}
+	old := tuning.Params()
+	if err = tuning.Update(params); err != nil {
+		return err
+	}
+	zap.L().Warn("task tuning params reloaded",
+		zap.String("task", taskID),
+		zap.Any("old", old),
+		zap.Any("new", params))
+	return nil
+}
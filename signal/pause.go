@@ -0,0 +1,154 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package signal
+
+import (
+	"context"
+	"sync"
+)
+
+// 任务暂停控制，暂停期间任务不再拉取新的表/chunk，进行中 chunk 正常完成并写入断点，恢复后继续拉取
+// - 进程级别：SIGUSR2 信号切换暂停/恢复，作用于进程内全部任务
+// - 任务级别：server 模式接口 pause/resume 或者元数据表 task_meta 任务状态，按任务 ID 暂停/恢复
+// - 时间窗口：[app] run-windows/blackout-windows 窗口外自动暂停，窗口内自动恢复，与人工暂停相互独立
+var (
+	pauseMu      sync.Mutex
+	pauseAll     bool
+	pausedTasks  = make(map[string]struct{})
+	windowPaused = make(map[string]struct{})
+	resumeCh     = make(chan struct{})
+)
+
+// PauseAll 进程级别暂停
+func PauseAll() {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	pauseAll = true
+}
+
+// ResumeAll 进程级别恢复，任务级别暂停保持不变
+func ResumeAll() {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	pauseAll = false
+	notifyResume()
+}
+
+// TogglePauseAll 切换进程级别暂停状态，返回切换后是否暂停
+func TogglePauseAll() bool {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	pauseAll = !pauseAll
+	if !pauseAll {
+		notifyResume()
+	}
+	return pauseAll
+}
+
+// Pause 任务级别暂停
+func Pause(taskID string) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	pausedTasks[taskID] = struct{}{}
+}
+
+// Resume 任务级别恢复
+func Resume(taskID string) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	if _, ok := pausedTasks[taskID]; ok {
+		delete(pausedTasks, taskID)
+		notifyResume()
+	}
+}
+
+// SetWindowPaused 时间窗口暂停/恢复
+func SetWindowPaused(taskID string, paused bool) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	if paused {
+		windowPaused[taskID] = struct{}{}
+		return
+	}
+	if _, ok := windowPaused[taskID]; ok {
+		delete(windowPaused, taskID)
+		notifyResume()
+	}
+}
+
+// IsWindowPaused 判断任务是否处于时间窗口暂停状态
+func IsWindowPaused(taskID string) bool {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	_, ok := windowPaused[taskID]
+	return ok
+}
+
+// IsPaused 判断任务是否处于暂停状态，包含时间窗口暂停
+func IsPaused(taskID string) bool {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	return isPaused(taskID)
+}
+
+// IsManualPaused 判断任务是否处于人工暂停状态（信号、接口或者 task_meta），不包含时间窗口暂停
+func IsManualPaused(taskID string) bool {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	if pauseAll {
+		return true
+	}
+	_, ok := pausedTasks[taskID]
+	return ok
+}
+
+// WaitResume 任务暂停期间阻塞等待恢复，收到退出信号直接返回，由调用方判断退出，上下文取消返回错误
+func WaitResume(ctx context.Context, taskID string) error {
+	for {
+		pauseMu.Lock()
+		if !isPaused(taskID) {
+			pauseMu.Unlock()
+			return nil
+		}
+		ch := resumeCh
+		pauseMu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-shutdownCh:
+			return nil
+		case <-ch:
+		}
+	}
+}
+
+func isPaused(taskID string) bool {
+	if pauseAll {
+		return true
+	}
+	if _, ok := pausedTasks[taskID]; ok {
+		return true
+	}
+	_, ok := windowPaused[taskID]
+	return ok
+}
+
+// notifyResume 唤醒全部等待者重新判断暂停状态，调用方需持有 pauseMu
+func notifyResume() {
+	close(resumeCh)
+	resumeCh = make(chan struct{})
+}
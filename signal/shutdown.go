@@ -0,0 +1,45 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package signal
+
+import "sync"
+
+var (
+	shutdownOnce sync.Once
+	shutdownCh   = make(chan struct{})
+)
+
+// Shutdown 标记程序进入优雅退出，任务不再拉取新的表/chunk
+func Shutdown() {
+	shutdownOnce.Do(func() {
+		close(shutdownCh)
+	})
+}
+
+// Done 返回优雅退出通知 channel
+func Done() <-chan struct{} {
+	return shutdownCh
+}
+
+// IsShutdown 判断是否已收到退出信号
+func IsShutdown() bool {
+	select {
+	case <-shutdownCh:
+		return true
+	default:
+		return false
+	}
+}
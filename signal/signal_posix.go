@@ -27,18 +27,29 @@ import (
 	"go.uber.org/zap"
 )
 
-// 处理退出信号量
-func SetupSignalHandler(shutdownFunc func()) {
+// 处理退出信号量，SIGHUP 调用 reloadFunc 热更新运行中任务调优参数
+func SetupSignalHandler(shutdownFunc, reloadFunc func()) {
 	usrDefSignalChan := make(chan os.Signal, 1)
 
-	signal.Notify(usrDefSignalChan, syscall.SIGUSR1)
+	signal.Notify(usrDefSignalChan, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
 	go func() {
 		buf := make([]byte, 1<<16)
 		for {
 			sig := <-usrDefSignalChan
-			if sig == syscall.SIGUSR1 {
+			switch sig {
+			case syscall.SIGUSR1:
 				stackLen := runtime.Stack(buf, true)
 				zap.L().Info(" dump goroutine stack", zap.String("stack", fmt.Sprintf("=== Got signal [%s] to dump goroutine stack. ===\n%s\n=== Finished dumping goroutine stack. ===", sig, buf[:stackLen])))
+			case syscall.SIGUSR2:
+				// 切换进程级别暂停/恢复
+				if TogglePauseAll() {
+					zap.L().Warn("got signal to pause, stop dispatching new table/chunk, in-flight chunk continue", zap.Stringer("signal", sig))
+				} else {
+					zap.L().Warn("got signal to resume, continue dispatching table/chunk", zap.Stringer("signal", sig))
+				}
+			case syscall.SIGHUP:
+				zap.L().Info("got signal to reload tuning params", zap.Stringer("signal", sig))
+				reloadFunc()
 			}
 		}
 	}()
@@ -46,7 +57,6 @@ func SetupSignalHandler(shutdownFunc func()) {
 	closeSignalChan := make(chan os.Signal, 1)
 	signal.Notify(closeSignalChan,
 		os.Interrupt,
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
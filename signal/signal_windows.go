@@ -25,8 +25,8 @@ import (
 	"go.uber.org/zap"
 )
 
-// 处理退出信号量
-func SetupSignalHandler(shutdownFunc func()) {
+// 处理退出信号量，windows 不支持 SIGHUP 热更新，reloadFunc 不生效
+func SetupSignalHandler(shutdownFunc, reloadFunc func()) {
 	closeSignalChan := make(chan os.Signal, 1)
 	signal.Notify(closeSignalChan,
 		os.Interrupt,
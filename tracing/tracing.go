@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transferdb 链路追踪，未开启时使用 otel 全局默认 noop provider，埋点无额外开销
+const instrumentationName = "github.com/wentaojin/transferdb"
+
+// Init 根据 [trace] 配置初始化全局 tracer provider，返回退出前刷新导出 span 的 shutdown
+func Init(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	traceCfg := cfg.TraceConfig
+	if !traceCfg.Enable {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch common.StringUPPER(traceCfg.Exporter) {
+	case common.TraceExporterJaeger:
+		// jaeger collector http 地址，例如 http://127.0.0.1:14268/api/traces
+		exporter, err = jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(traceCfg.Endpoint)))
+	case common.TraceExporterOTLP:
+		// otlp http 地址，例如 127.0.0.1:4318，jaeger 1.35+ 原生支持 otlp 接收
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(traceCfg.Endpoint)}
+		if traceCfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("trace config exporter [%s] isn't support, only support [otlp jaeger]", traceCfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("trace exporter [%s] endpoint [%s] create failed: %v", traceCfg.Exporter, traceCfg.Endpoint, err)
+	}
+
+	serviceName := traceCfg.ServiceName
+	if serviceName == "" {
+		serviceName = common.TraceDefaultServiceName
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+		semconv.ServiceVersionKey.String(config.Version),
+		attribute.String("transferdb.task_id", cfg.AppConfig.TaskID),
+		attribute.String("transferdb.task_mode", cfg.TaskMode))
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(traceCfg.SampleRatio))))
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start 基于父 ctx 创建子 span，表 -> chunk -> extract/convert/apply batch 逐级挂载
+func Start(ctx context.Context, spanName string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, spanName, trace.WithAttributes(attrs...))
+}
+
+// End 记录 span 错误状态并结束 span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}